@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/markers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	annotateExamplePath string
+	annotatePlain       bool
+	annotateTypeName    string
+	annotateInferTypes  bool
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <types.go>",
+	Short: "Write markers added to generated Go types back to the example values",
+	Long: `Write the kubebuilder markers of an edited types.go back to the fields of the
+example values file (example.values.yaml by default) as comments, in place,
+so the example values stay the source of truth.
+
+The types are compared with the types generated from the example values:
+  - markers the edited types add are added above the field (or replace a
+    marker of the same name, e.g., a changed Minimum)
+  - markers the edited types remove are removed from the field, if it has them
+    as comments (markers generated by miaka markers, like +miaka:format, can't
+    be removed this way)
+
+Fields are matched by their JSON names, so the types' fields must be in the
+example values. Types nested under spec (see 'miaka build --wrap-spec') are
+detected. Markers on types rather than fields aren't written back. Comments and
+formatting of the example values are kept.`,
+	Example: `  # After adding markers to the types generated with 'miaka build -t types.go'
+  miaka annotate types.go
+
+  # Plain values with a custom type name
+  miaka annotate types.go --plain --type-name Config -e values.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnnotate,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.Flags().StringVarP(&annotateExamplePath, "example", "e", defaultExampleValuesFile, "Path to the example values file to write markers to")
+	annotateCmd.Flags().BoolVar(&annotatePlain, "plain", false, "Parse example values without apiVersion or kind (see 'miaka build --plain')")
+	annotateCmd.Flags().StringVar(&annotateTypeName, "type-name", schema.DefaultPlainTypeName, "Name of the type of plain values (requires --plain)")
+	annotateCmd.Flags().BoolVar(&annotateInferTypes, "infer-semantic-types", false, "Infer semantic types like the build did (see 'miaka build --infer-semantic-types')")
+
+	completeFlagFiles(annotateCmd, "example", yamlExtensions...)
+}
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	typesPath := args[0]
+	edited, err := os.ReadFile(typesPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", typesPath, err)
+	}
+	info, err := os.Stat(annotateExamplePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", annotateExamplePath, err)
+	}
+	data, err := os.ReadFile(annotateExamplePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", annotateExamplePath, err)
+	}
+
+	s, err := parsing.NewParserWithOptions(parsing.Options{
+		InferSemanticTypes: annotateInferTypes,
+		Plain:              annotatePlain,
+		TypeName:           annotateTypeName,
+	}).Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", annotateExamplePath, err)
+	}
+
+	// The values are in the spec type if the types wrap them
+	root := s.Kind
+	if declaresType(edited, schema.SpecTypeName(s.Kind)) {
+		root = schema.SpecTypeName(s.Kind)
+		s.WrapSpec = true
+	}
+	generated, err := gotypes.NewGenerator(s).Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate types: %w", err)
+	}
+
+	generatedMarkers, err := markers.FromGoTypes(generated, root)
+	if err != nil {
+		return fmt.Errorf("failed to read generated types: %w", err)
+	}
+	editedMarkers, err := markers.FromGoTypes(edited, root)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", typesPath, err)
+	}
+	annotated, changes, err := markers.WriteBack(data, generatedMarkers, editedMarkers)
+	if err != nil {
+		return fmt.Errorf("failed to write markers to %s: %w", annotateExamplePath, err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "✓ %s has the markers of %s\n", annotateExamplePath, typesPath)
+		return nil
+	}
+	if err := os.WriteFile(annotateExamplePath, annotated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", annotateExamplePath, err)
+	}
+	for _, change := range changes {
+		if change.Removed {
+			fmt.Fprintf(out, "✓ %s: removed %s\n", change.Path, change.Marker)
+		} else {
+			fmt.Fprintf(out, "✓ %s: %s\n", change.Path, change.Marker)
+		}
+	}
+	fmt.Fprintf(out, "\nRun 'miaka build' to regenerate the outputs from %s\n", annotateExamplePath)
+	return nil
+}
+
+// declaresType reports whether Go source declares a type
+func declaresType(src []byte, name string) bool {
+	return regexp.MustCompile(`(?m)^type ` + regexp.QuoteMeta(name) + `\s`).Match(src)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAnnotateCommand creates a fresh annotate command instance for testing
+func newAnnotateCommand() *cobra.Command {
+	annotateExamplePath = defaultExampleValuesFile
+	annotatePlain = false
+	annotateTypeName = schema.DefaultPlainTypeName
+	annotateInferTypes = false
+
+	cmd := &cobra.Command{
+		Use:          "annotate",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runAnnotate,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&annotateExamplePath, "example", "e", defaultExampleValuesFile, "Path to the example values file")
+	cmd.Flags().BoolVar(&annotatePlain, "plain", false, "Parse example values without apiVersion or kind")
+	cmd.Flags().StringVar(&annotateTypeName, "type-name", schema.DefaultPlainTypeName, "Name of the type of plain values")
+	cmd.Flags().BoolVar(&annotateInferTypes, "infer-semantic-types", false, "Infer semantic types")
+
+	return cmd
+}
+
+const annotateExample = `apiVersion: demo.io/v1
+kind: Demo
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 3
+image:
+  # Image tag
+  tag: latest
+`
+
+// generateTypes generates the Go types of example values, as 'miaka build -t' does
+func generateTypes(t *testing.T, example string, wrapSpec bool) string {
+	t.Helper()
+	s, err := parsing.NewParser().Parse([]byte(example))
+	require.NoError(t, err)
+	s.WrapSpec = wrapSpec
+	types, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+	return string(types)
+}
+
+func TestAnnotateCommand(t *testing.T) {
+	for _, wrapSpec := range []bool{false, true} {
+		t.Run(map[bool]string{false: "flat", true: "wrap spec"}[wrapSpec], func(t *testing.T) {
+			tmpDir := t.TempDir()
+			examplePath := filepath.Join(tmpDir, "example.values.yaml")
+			typesPath := filepath.Join(tmpDir, "types.go")
+			require.NoError(t, os.WriteFile(examplePath, []byte(annotateExample), 0644))
+
+			types := generateTypes(t, annotateExample, wrapSpec)
+			types = strings.Replace(types, "// +kubebuilder:validation:Minimum=1", "// +kubebuilder:validation:Minimum=2\n\t// +kubebuilder:validation:Maximum=10", 1)
+			types = strings.Replace(types, "// Image tag", "// Image tag\n\t// +kubebuilder:validation:MinLength=1", 1)
+			require.NoError(t, os.WriteFile(typesPath, []byte(types), 0644))
+
+			cmd := newAnnotateCommand()
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetArgs([]string{typesPath, "-e", examplePath})
+			require.NoError(t, cmd.Execute())
+
+			data, err := os.ReadFile(examplePath)
+			require.NoError(t, err)
+			assert.Equal(t, `apiVersion: demo.io/v1
+kind: Demo
+# Number of replicas
+# +kubebuilder:validation:Minimum=2
+# +kubebuilder:validation:Maximum=10
+replicas: 3
+image:
+  # Image tag
+  # +kubebuilder:validation:MinLength=1
+  tag: latest
+`, string(data))
+			assert.Contains(t, out.String(), "image.tag: +kubebuilder:validation:MinLength=1")
+
+			// The example values now match the types
+			cmd = newAnnotateCommand()
+			out.Reset()
+			cmd.SetOut(&out)
+			cmd.SetArgs([]string{typesPath, "-e", examplePath})
+			require.NoError(t, cmd.Execute())
+			assert.Contains(t, out.String(), "has the markers of")
+		})
+	}
+}
+
+func TestAnnotateCommand_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	typesPath := filepath.Join(tmpDir, "types.go")
+	require.NoError(t, os.WriteFile(examplePath, []byte(annotateExample), 0644))
+
+	cmd := newAnnotateCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{typesPath, "-e", examplePath})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read")
+
+	// A field the example values don't have
+	types := generateTypes(t, annotateExample, false)
+	types = strings.Replace(types, "\tReplicas int", "\t// +kubebuilder:validation:MinLength=1\n\tName string `json:\"name,omitempty\"`\n\n\tReplicas int", 1)
+	require.NoError(t, os.WriteFile(typesPath, []byte(types), 0644))
+	cmd = newAnnotateCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{typesPath, "-e", examplePath})
+	assert.ErrorContains(t, cmd.Execute(), "failed to write markers")
+}
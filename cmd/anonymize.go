@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/anonymize"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	anonymizeCRDPath     string
+	anonymizeExamplePath string
+	anonymizeOutput      string
+	anonymizeSeed        string
+)
+
+var anonymizeCmd = &cobra.Command{
+	Use:   "anonymize <values.yaml>",
+	Short: "Scrub a values file so it can be shared in a bug report",
+	Long: `Replace string values in a values file so it can be shared without leaking data.
+
+The structure of the file is kept: keys, numbers, and booleans are unchanged,
+and each string keeps its length, punctuation, and character classes
+(letters stay letters, digits stay digits). Comments are removed.
+
+If the CRD (crd.yaml by default) exists, the output stays valid against it:
+enum values and date/duration formats are kept, int-or-string values keep
+their units, and values with a pattern are scrambled in a way that still
+matches it.
+
+Fields marked with +miaka:secret in the example values file
+(example.values.yaml by default) are always masked with asterisks, with
+every value beneath them, numbers and booleans included.`,
+	Example: `  # Print an anonymized copy of a values file
+  miaka anonymize prod.yaml
+
+  # Write to a file
+  miaka anonymize prod.yaml -o bug-report.yaml
+
+  # Mark a field as secret in example.values.yaml
+  #   # Database password
+  #   # +miaka:secret
+  #   password: changeme`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnonymize,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(anonymizeCmd)
+
+	anonymizeCmd.Flags().StringVarP(&anonymizeCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file used to keep values valid")
+	anonymizeCmd.Flags().StringVarP(&anonymizeExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file with +miaka:secret markers")
+	anonymizeCmd.Flags().StringVarP(&anonymizeOutput, "output", "o", "", "Output path for anonymized values (default: stdout)")
+	anonymizeCmd.Flags().StringVar(&anonymizeSeed, "seed", "", "Seed for reproducible output (default: random)")
+}
+
+func runAnonymize(cmd *cobra.Command, args []string) error {
+	opts := anonymize.Options{Seed: anonymizeSeed}
+
+	if _, err := os.Stat(anonymizeCRDPath); err == nil {
+		s, err := validation.LoadCRDSchema(anonymizeCRDPath)
+		if err != nil {
+			return fmt.Errorf("failed to load CRD schema: %w", err)
+		}
+		opts.Schema = s
+	}
+
+	if _, err := os.Stat(anonymizeExamplePath); err == nil {
+		secrets, err := anonymize.FindSecretPathsInFile(anonymizeExamplePath)
+		if err != nil {
+			return fmt.Errorf("failed to find secret fields: %w", err)
+		}
+		opts.SecretPaths = secrets
+	}
+
+	a, err := anonymize.NewAnonymizer(opts)
+	if err != nil {
+		return err
+	}
+
+	output, err := a.AnonymizeFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	if anonymizeOutput == "" {
+		if _, err := cmd.OutOrStdout().Write(output); err != nil {
+			return fmt.Errorf("failed to write anonymized values: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(anonymizeOutput, output, 0644); err != nil {
+		return fmt.Errorf("failed to write anonymized values: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// newAnonymizeCommand creates a fresh anonymize command instance for testing
+func newAnonymizeCommand() *cobra.Command {
+	anonymizeCRDPath = defaultCRDPath
+	anonymizeExamplePath = defaultExampleValuesFile
+	anonymizeOutput = ""
+	anonymizeSeed = ""
+
+	cmd := &cobra.Command{
+		Use:          "anonymize",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runAnonymize,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&anonymizeCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	cmd.Flags().StringVarP(&anonymizeExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file")
+	cmd.Flags().StringVarP(&anonymizeOutput, "output", "o", "", "Output path for anonymized values")
+	cmd.Flags().StringVar(&anonymizeSeed, "seed", "", "Seed for reproducible output")
+
+	return cmd
+}
+
+func TestAnonymizeCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	valuesPath := filepath.Join(tmpDir, "prod.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+
+	example := `apiVersion: example.com/v1
+kind: Example
+# +kubebuilder:validation:Enum=ClusterIP;NodePort
+serviceType: ClusterIP
+# +miaka:secret
+password: changeme
+host: example.com
+`
+	require.NoError(t, os.WriteFile(examplePath, []byte(example), 0644))
+	require.NoError(t, os.WriteFile(valuesPath, []byte(`apiVersion: example.com/v1
+kind: Example
+serviceType: NodePort
+password: hunter2
+host: db.corp.internal
+`), 0644))
+
+	// Build the CRD so enum values are kept
+	buildCmd := newBuildCommand()
+	buildCmd.SetArgs([]string{examplePath, "-c", crdPath, "-s", filepath.Join(tmpDir, "values.schema.json")})
+	require.NoError(t, buildCmd.Execute())
+
+	cmd := newAnonymizeCommand()
+	cmd.SetArgs([]string{valuesPath, "--crd", crdPath, "--example", examplePath})
+	outBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	require.NoError(t, cmd.Execute())
+
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(outBuf.Bytes(), &values))
+	assert.Equal(t, "NodePort", values["serviceType"])
+	assert.Equal(t, "*******", values["password"])
+	assert.NotEqual(t, "db.corp.internal", values["host"])
+	assert.Len(t, values["host"], len("db.corp.internal"))
+}
+
+func TestAnonymizeCommand_OutputFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	outputPath := filepath.Join(tmpDir, "out.yaml")
+	require.NoError(t, os.WriteFile(valuesPath, []byte("replicas: 3\n"), 0644))
+
+	cmd := newAnonymizeCommand()
+	cmd.SetArgs([]string{
+		valuesPath,
+		"-o", outputPath,
+		"--crd", filepath.Join(tmpDir, "missing.yaml"),
+		"--example", filepath.Join(tmpDir, "missing.yaml"),
+	})
+	require.NoError(t, cmd.Execute())
+
+	output, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 3\n", string(output))
+}
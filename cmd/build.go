@@ -2,32 +2,109 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
 	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
 	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
 	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/typescript"
+	"github.com/crenshaw-dev/miaka/pkg/build/hints"
 	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
 	"github.com/crenshaw-dev/miaka/pkg/build/schema"
 	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/history"
+	"github.com/crenshaw-dev/miaka/pkg/miaka"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/crenshaw-dev/miaka/pkg/workspace"
 	"github.com/spf13/cobra"
-	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const (
 	defaultExampleValuesFile = "example.values.yaml"
 	defaultCRDPath           = "crd.yaml"
 	defaultSchemaPath        = "values.schema.json"
+	defaultSubchartDir       = "schemas"
 )
 
 var (
-	buildTypesPath  string
-	buildCRDPath    string
-	buildSchemaPath string
+	buildTypesPath     string
+	buildCRDPath       string
+	buildSchemaPath    string
+	buildTSPath        string
+	buildEmit          []string
+	buildSuggestHints  bool
+	buildInMemory      bool
+	buildHermetic      bool
+	buildPreviousCRD   string
+	buildPreviousTypes string
+	buildDepsFile      string
+	buildNoColor       bool
+	buildMaxLosses     int
+	buildInferTypes    bool
+	buildPlain         bool
+	buildTypeName      string
+	buildDeterministic bool
+	buildUmbrella      bool
+	buildSubchartDir   string
+	buildReuseSchemas  bool
+	buildInclude       []string
+	buildExclude       []string
+	buildStrict        string
+	buildDuplicateKeys string
+	buildTemplates     string
+	buildLimits        parsing.Limits
+	buildDescriptions  string
+	buildDefaultsFile  string
+	buildHelmCompat    bool
+	buildNoProvenance  bool
+	buildCheck         bool
+	buildScope         string
+	buildPlural        string
+	buildListKind      string
+	buildShortNames    []string
+	buildCategories    []string
+	buildWrapSpec      bool
+	buildOutput        string
+	buildHistoryDir    string
+	buildSupported     []string
+	buildSnapshot      string
+	buildConfig        string
+	buildParallel      bool
+	buildNoCache       bool
+	buildTitles        string
+	buildExamples      bool
+	buildSchemaKRM     bool
+	buildResourcePath  string
+	buildWorkDir       string
 )
 
+// typeNamePattern matches the Go type names allowed for --type-name
+var typeNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// buildLog receives build progress messages (on the command's output, or its
+// error output in hermetic mode)
+var buildLog = newLogger(os.Stdout)
+
+// buildResult collects the files, errors, and warnings of the build for --output json and sarif
+var buildResult commandResult
+
+// buildFS is the filesystem the build reads its inputs from and writes its
+// outputs to, except for the charts of --umbrella
+var buildFS = filesystem.OS()
+
 var buildCmd = &cobra.Command{
 	Use:   "build [example.values.yaml]",
 	Short: "Generate Go types and/or CRD from example.values.yaml",
@@ -40,9 +117,138 @@ in the current directory.
 The tool generates:
   - Go type definitions (types.go) with proper struct tags
   - Kubernetes CRD with OpenAPI v3 schema (optional)
+  - TypeScript declarations (values.d.ts) for front-end consumers (optional)
 
 The generated CRD includes field descriptions, validation rules, and all
-kubebuilder markers from your YAML comments.`,
+kubebuilder markers from your YAML comments.
+
+For hermetic build systems like Bazel, --hermetic requires the input file,
+--crd, and --schema to be given explicitly, never reads the existing CRD
+output (use --previous-crd for breaking change detection), and writes all
+progress to stderr. --deps-file lists every file the build read.
+
+CRD constructs that JSON Schema can't represent (CEL rules, list uniqueness,
+Kubernetes-specific formats, etc.) are listed as warnings, since Helm won't
+validate them. --max-conversion-losses fails the build when there are more.
+
+Quantities, durations, and int-or-strings become resource.Quantity,
+metav1.Duration, and intstr.IntOrString with a +miaka:type hint (quantity,
+duration, or int-or-string), so the CRD validates their format.
+--infer-semantic-types also detects values like 512Mi, 72h, and 25%.
+
+--helm-compat generates a JSON Schema for the way Helm validates values: it
+drops the keywords draft-07 doesn't define (example, externalDocs, and
+deprecated) and the formats validators don't check like Kubernetes (e.g.,
+duration and int32), and allows the top-level global key, which Helm adds to
+the values of subcharts even when the schema rejects unknown fields.
+
+Each property of the JSON Schema gets a title, which IDEs show in tooltips:
+its name split into words (e.g., "Image Pull Policy" for imagePullPolicy).
+--schema-titles=comment uses the first sentence of the field's comment
+instead, and --schema-titles=none leaves titles out, for output identical to
+earlier versions.
+
+--schema-examples adds the values of the example values file to the JSON
+Schema as the examples of their properties, which editors show in completions
+and documentation generators print. Objects and lists of objects get examples
+for their fields instead, and empty values get none.
+
+The JSON Schema validates values files, so it leaves out metadata, and accepts
+any apiVersion and kind. --schema-krm keeps them, to validate full KRM
+documents instead: apiVersion and kind are required and pinned to the example
+values', and metadata is an object with the usual name, namespace, labels, and
+annotations.
+
+--resource-schema writes both shapes in one build: values.schema.json for Helm
+values files, without apiVersion or kind, and a JSON Schema of the custom
+resource operators apply, with apiVersion and kind pinned, metadata, and the
+values under spec with --wrap-spec.
+
+For charts that only want a values.schema.json, --plain builds values
+without apiVersion or kind. No CRD is written; Go types (--types) and
+TypeScript declarations are named after --type-name (default Values).
+
+--deterministic strips annotations that depend on the environment (such as
+the controller-gen version) and sorts required fields, so identical input
+always gives byte-identical output that can be committed and diffed.
+
+Generated outputs are cached in $MIAKA_CACHE_DIR (default: miaka in the
+user's cache directory), keyed by a hash of the parsed values, the flags that
+affect the outputs, and the miaka binary (its version, or for builds from
+source their commit or a hash of the binary), so building unchanged values
+again doesn't regenerate them. --no-cache generates every output, 'miaka cache
+clean' empties the cache, and --hermetic builds never use it.
+
+The CRD and JSON Schema are stamped with the miaka version, the input file's
+hash, and the generation time, so 'miaka verify' can detect stale outputs.
+With --deterministic only the hash is stamped; --no-provenance stamps nothing.
+
+In CI, --check generates all outputs in memory and compares them with the
+files on disk (the CRD, the JSON Schema, types.go with --types, and any
+--emit outputs). It writes nothing, and fails with a diff if any differ.
+Annotations that depend on the environment or the build time are ignored.
+
+Values that are intentionally freeform can be left out of the schema with
+--exclude (or --include, to only type some values). Both take globs over YAML
+paths, where "*" matches part of a field name and "**" any number of fields
+(e.g., controller.affinity.**). List items are written as "[]" after the
+list (e.g., env[].valueFrom). Freeform values accept anything, using
+x-kubernetes-preserve-unknown-fields in the CRD.
+
+Keys that a mapping has more than once fail the build with their line
+numbers, since Helm silently keeps the last one. --duplicate-keys=warn keeps
+the last one with a warning, and --duplicate-keys=last-wins keeps it silently.
+
+String values with Helm template expressions ({{ ... }}) are typed as opaque
+strings, since they render to values of any shape, with a warning unless
+their field is marked +miaka:template (for charts that render them with tpl).
+--template-strings=allow drops the warning, and --template-strings=error
+fails the build instead.
+
+Defaults can come from a separate file with the shape of the values
+(--defaults defaults.yaml) instead of +kubebuilder:default markers, keeping the
+example values unchanged. Each value of the file is the default of its field,
+except objects, whose fields are set one by one. Defaults that differ from a
+field's marker, and values that aren't fields, fail the build.
+
+--require-descriptions fails the build when fields have no description (a
+comment above the field), listing them. It takes the percentage of fields
+that must have one (default 100), or warn to only list them.
+
+Objects whose example is empty ({}) accept no fields by default. --strict=false
+keeps them open, for charts that pass arbitrary extra values to templates.
+--strict=warn keeps them open too, but records the mode in the CRD so that
+'miaka validate' reports fields the schema doesn't declare as warnings.
+
+The CRD's names and scope are derived from the kind. --scope, --plural,
+--list-kind, --short-names, and --categories override them (as does a
++miaka:crd:resource marker at the top of the input), and are checked against
+the naming rules of the Kubernetes API server.
+
+--wrap-spec generates the idiomatic KRM layout that controller frameworks
+expect: the values are nested under spec (a <Kind>Spec type), next to an empty
+<Kind>Status. The JSON Schema still describes the values themselves, and
+'miaka validate' nests them under spec to validate them against the CRD.
+
+For umbrella charts, --umbrella reads the dependencies in the Chart.yaml next
+to the input file. The values under each subchart's key get their own schema
+in --subchart-schemas, and values.schema.json refers to them with $ref.
+--reuse-dependency-schemas uses the values.schema.json of a dependency in
+charts/ instead of generating one.
+
+To support several release lines at once, keep a snapshot of the CRD of each
+release in a history directory (.miaka/history by default, as
+<version>/crd.yaml): --snapshot <version> saves the generated CRD there. If
+the directory exists, the build also checks for breaking changes against every
+snapshot, or only the release lines still supported with --supported-versions
+(e.g., 2.x,3.x).
+
+To generate several CRDs in one repository, list them as targets in a project
+config (miaka.yaml), each with its own input file, expected apiVersion and
+kind, and output paths. Without an input file, the build builds every target
+of miaka.yaml if it exists (or of --config), keeps going when one fails, and
+reports the failures, including breaking changes, together at the end.
+--parallel generates the outputs of all targets at once before writing them.`,
 	Example: `  # Generate CRD from example.values.yaml (default)
   miaka build
 
@@ -55,6 +261,71 @@ kubebuilder markers from your YAML comments.`,
   # Generate CRD and preserve types.go
   miaka build -t types.go
 
+  # Also generate TypeScript declarations
+  miaka build --typescript values.d.ts
+
+  # Add missing +miaka:type hints to the example file, then build
+  miaka build --suggest-hints
+
+  # Build without temp files or the go command (read-only containers, Bazel)
+  miaka build --in-memory
+
+  # Keep intermediate files in the workspace, to debug failed builds on CI
+  miaka build --workdir .miaka/work
+
+  # Hermetic build for Bazel and similar build systems
+  miaka build values/example.yaml --hermetic -c out/crd.yaml -s out/values.schema.json \
+    --previous-crd crds/crd.yaml --deps-file out/build.d
+
+  # Map values like 500m, 512Mi, 72h, and 25% to Kubernetes types
+  miaka build --infer-semantic-types
+
+  # Only generate values.schema.json and Go types, from values without apiVersion or kind
+  miaka build values.yaml --plain --type-name ChartValues -t types.go
+
+  # Byte-identical output for golden files committed to the repository
+  miaka build --deterministic
+
+  # Fail in CI if the committed outputs are out of date
+  miaka build --check -t types.go
+
+  # Accept any affinity and annotations without generating their schema
+  miaka build --exclude 'controller.affinity.**' --exclude '*.podAnnotations'
+
+  # Allow extra values, but warn about them in 'miaka validate'
+  miaka build --strict=warn
+
+  # A cluster-scoped CRD that 'kubectl get ex' lists
+  miaka build --scope Cluster --short-names ex
+
+  # Nest the values in a spec, for controllers built with kubebuilder
+  miaka build --wrap-spec -t api/v1/types.go
+
+  # Save the CRD of a release, then keep 2.x and 3.x releases compatible
+  miaka build --snapshot 3.0.0
+  miaka build --supported-versions 2.x,3.x
+
+  # Separate schemas for the subcharts of an umbrella chart
+  miaka build --umbrella --reuse-dependency-schemas
+
+  # Build every target of miaka.yaml, generating their outputs in parallel
+  miaka build --parallel
+
+  # Write any registered output target
+  miaka build --emit typescript=web/values.d.ts
+
+  # Types and DeepCopy functions for a controller built with controller-runtime
+  miaka build --wrap-spec -t api/v1/types.go --emit deepcopy=api/v1/zz_generated.deepcopy.go
+
+  # Validate values at render time in a library chart that can't ship a CRD
+  miaka build --emit helmtemplate=templates/_schema.yaml
+
+  # A result object listing the generated files, for tools that wrap miaka
+  miaka build -o json
+
+  # Breaking changes located in the example values, for GitHub code scanning
+  miaka build -o sarif > miaka.sarif
+
   # Custom types.go and CRD output locations
   miaka build -t pkg/apis/v1/types.go -c crds/my-crd.yaml myfile.yaml`,
 	Args: cobra.MaximumNArgs(1),
@@ -69,9 +340,132 @@ func init() {
 	buildCmd.Flags().StringVarP(&buildTypesPath, "types", "t", "", "Output path for types.go file (if empty, types.go is not preserved)")
 	buildCmd.Flags().StringVarP(&buildCRDPath, "crd", "c", defaultCRDPath, "Output path for CRD YAML file")
 	buildCmd.Flags().StringVarP(&buildSchemaPath, "schema", "s", defaultSchemaPath, "Output path for JSON Schema file")
+	buildCmd.Flags().StringVar(&buildTSPath, "typescript", "", "Output path for TypeScript declarations (if empty, no TypeScript is generated)")
+	buildCmd.Flags().StringVar(&buildResourcePath, "resource-schema", "", "Output path for a JSON Schema of the custom resource (apiVersion, kind, metadata, and spec with --wrap-spec), leaving apiVersion and kind out of the values JSON Schema")
+	buildCmd.Flags().StringArrayVar(&buildEmit, "emit", nil, "Additional output as target=path (repeatable; targets: gotypes, typescript, crd, jsonschema, resourceschema, helmtemplate, deepcopy)")
+	buildCmd.Flags().BoolVar(&buildSuggestHints, "suggest-hints", false, "Insert +miaka:type hints into the input file for fields whose type can't be inferred")
+	buildCmd.Flags().BoolVar(&buildInMemory, "in-memory", false, "Generate the CRD entirely in memory, without temp files or the go command (for read-only and hermetic builds)")
+	buildCmd.Flags().StringVar(&buildWorkDir, "workdir", "", "Directory for intermediate files, like controller-gen's temporary Go module, keeping those of failed builds for debugging (default: the system temp directory)")
+	buildCmd.Flags().BoolVar(&buildHermetic, "hermetic", false, "Require explicit input and output paths, never read undeclared files, and write progress to stderr (implies --in-memory)")
+	buildCmd.Flags().StringVar(&buildPreviousCRD, "previous-crd", "", "Check for breaking changes against this CRD instead of the existing CRD output file")
+	buildCmd.Flags().StringVar(&buildPreviousTypes, "previous-types", "", "Check the Go types for breaking changes (removed, renamed, or retyped types and fields) against this types.go, e.g., the committed -t output")
+	buildCmd.Flags().StringVar(&buildDepsFile, "deps-file", "", "Write the list of files read by the build to this path, one per line")
+	buildCmd.Flags().BoolVar(&buildNoColor, "no-color", false, "Print plain-text status messages without emoji (also enabled by the NO_COLOR environment variable)")
+	buildCmd.Flags().IntVar(&buildMaxLosses, "max-conversion-losses", -1, "Fail if more than this many CRD constructs can't be represented in the JSON Schema (-1 for no limit)")
+	buildCmd.Flags().BoolVar(&buildInferTypes, "infer-semantic-types", false, "Map string values that look like quantities, durations, or int-or-strings to those Kubernetes types without a +miaka:type hint")
+	buildCmd.Flags().BoolVar(&buildPlain, "plain", false, "Build values without apiVersion or kind, generating only the JSON Schema and optional types (no CRD)")
+	buildCmd.Flags().StringVar(&buildTypeName, "type-name", schema.DefaultPlainTypeName, "Name of the generated type of plain values (requires --plain)")
+	buildCmd.Flags().BoolVar(&buildUmbrella, "umbrella", false, "Generate a schema for each subchart in Chart.yaml and compose them into the JSON Schema with $ref")
+	buildCmd.Flags().StringVar(&buildSubchartDir, "subchart-schemas", defaultSubchartDir, "Output directory for the generated subchart schemas (requires --umbrella)")
+	buildCmd.Flags().BoolVar(&buildReuseSchemas, "reuse-dependency-schemas", false, "Use the values.schema.json published by a dependency chart instead of generating one (requires --umbrella)")
+	buildCmd.Flags().StringArrayVar(&buildInclude, "include", nil, "Only type values at YAML paths matching this glob (repeatable, e.g., 'controller.**'); other values are freeform")
+	buildCmd.Flags().StringArrayVar(&buildExclude, "exclude", nil, "Make values at YAML paths matching this glob freeform (repeatable, e.g., 'controller.affinity.**')")
+	buildCmd.Flags().BoolVar(&buildDeterministic, "deterministic", false, "Strip volatile annotations (e.g., the controller-gen version) and sort required fields, so identical input gives byte-identical output")
+	buildCmd.Flags().BoolVar(&buildCheck, "check", false, "Generate all outputs in memory and fail with a diff if the files on disk differ, without writing anything")
+	buildCmd.Flags().BoolVar(&buildNoProvenance, "no-provenance", false, "Don't stamp the CRD and JSON Schema with the miaka version, input file hash, and generation time")
+	buildCmd.Flags().StringVar(&buildStrict, "strict", string(crd.StrictOn), "Reject fields that empty example objects don't declare: true, false, or warn (open schemas, but 'miaka validate' warns about unknown fields)")
+	buildCmd.Flags().StringVar(&buildDuplicateKeys, "duplicate-keys", string(parsing.DuplicatesError), "Keys that a mapping has more than once: error, warn, or last-wins (keep the last one, like Helm)")
+	buildCmd.Flags().StringVar(&buildTemplates, "template-strings", string(parsing.TemplatesWarn), "String values with Helm template expressions ({{ ... }}) not marked +miaka:template: allow, warn, or error (all but error type them as opaque strings)")
+	buildCmd.Flags().IntVar(&buildLimits.MaxDepth, "max-depth", parsing.DefaultLimits.MaxDepth, "Fail on values nested more deeply than this (-1 for no limit)")
+	buildCmd.Flags().IntVar(&buildLimits.MaxFields, "max-fields", parsing.DefaultLimits.MaxFields, "Fail on values with more fields and list items than this, counting aliases each time they're used (-1 for no limit)")
+	buildCmd.Flags().IntVar(&buildLimits.MaxSize, "max-size", parsing.DefaultLimits.MaxSize, "Fail on input files larger than this many bytes (-1 for no limit)")
+	buildCmd.Flags().StringVar(&buildDescriptions, "require-descriptions", "", "Fail if fewer than this percentage of fields have a description comment (100 without a value), listing the fields without one, or only list them with warn")
+	buildCmd.Flags().Lookup("require-descriptions").NoOptDefVal = "100"
+	buildCmd.Flags().BoolVar(&buildHelmCompat, "helm-compat", false, "Adapt the JSON Schema to Helm: drop keywords and formats draft-07 validators don't support, and allow the global key of subcharts")
+	buildCmd.Flags().StringVar(&buildTitles, "schema-titles", string(jsonschema.TitlesName), "Titles of the JSON Schema's properties for IDE tooltips: name (the field name in words), comment (the first sentence of the field's comment), or none (the output of earlier versions)")
+	buildCmd.Flags().BoolVar(&buildSchemaKRM, "schema-krm", false, "Keep apiVersion, kind (pinned to the example values'), and metadata in the JSON Schema, to validate full KRM documents rather than values files")
+	buildCmd.Flags().BoolVar(&buildExamples, "schema-examples", false, "Add each value of the example values to the JSON Schema as the examples of its property, for editors and documentation generators")
+	buildCmd.Flags().StringVar(&buildDefaultsFile, "defaults", "", "File with the shape of the values setting field defaults for the CRD and JSON Schema, instead of +kubebuilder:default markers")
+	buildCmd.Flags().StringVar(&buildScope, "scope", "", "Scope of the CRD: Namespaced (the default) or Cluster")
+	buildCmd.Flags().StringVar(&buildPlural, "plural", "", "Plural name of the CRD (default: the pluralized, lowercase kind)")
+	buildCmd.Flags().StringVar(&buildListKind, "list-kind", "", "List kind of the CRD (default: <kind>List)")
+	buildCmd.Flags().StringSliceVar(&buildShortNames, "short-names", nil, "Comma-separated short names of the CRD (e.g., for 'kubectl get')")
+	buildCmd.Flags().BoolVar(&buildWrapSpec, "wrap-spec", false, "Nest the values under spec in the Go types and CRD (<Kind>Spec), with an empty status (<Kind>Status)")
+	buildCmd.Flags().StringSliceVar(&buildCategories, "categories", nil, "Comma-separated categories of the CRD (e.g., all)")
+	buildCmd.Flags().StringVar(&buildHistoryDir, "history", "", "Directory of CRD snapshots of released versions to check for breaking changes against (default: "+history.DefaultDir+", if it exists)")
+	buildCmd.Flags().StringSliceVar(&buildSupported, "supported-versions", nil, "Comma-separated versions in the history that must stay compatible (e.g., 2.x,3.x; default: all)")
+	buildCmd.Flags().StringVar(&buildSnapshot, "snapshot", "", "Save the generated CRD to the history as the snapshot of this released version")
+	buildCmd.Flags().StringVar(&buildConfig, "config", "", "Build the targets of this project config (default: "+workspace.DefaultFile+", if it exists and no input file is given)")
+	buildCmd.Flags().BoolVar(&buildParallel, "parallel", false, "Generate the outputs of all targets of the project config in parallel")
+	buildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Generate every output, ignoring and not updating the build cache")
+	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", outputText, "Output format: text, json for a result object with the generated files, errors, and warnings, or sarif for GitHub code scanning")
+
+	buildCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagValues(buildCmd, "output", outputText, outputJSON, outputSARIF)
+	completeFlagValues(buildCmd, "strict", string(crd.StrictOn), string(crd.StrictOff), string(crd.StrictWarn))
+	completeFlagValues(buildCmd, "duplicate-keys", string(parsing.DuplicatesError), string(parsing.DuplicatesWarn), string(parsing.DuplicatesLastWins))
+	completeFlagValues(buildCmd, "template-strings", string(parsing.TemplatesAllow), string(parsing.TemplatesWarn), string(parsing.TemplatesError))
+	completeFlagValues(buildCmd, "require-descriptions", descriptionsWarn, "100")
+	completeFlagValues(buildCmd, "scope", "Namespaced", "Cluster")
+	completeFlagValues(buildCmd, "schema-titles", string(jsonschema.TitlesName), string(jsonschema.TitlesComment), string(jsonschema.TitlesNone))
+	completeFlagFiles(buildCmd, "previous-crd", yamlExtensions...)
+	completeFlagFiles(buildCmd, "previous-types", "go")
+	completeFlagFiles(buildCmd, "config", yamlExtensions...)
 }
 
-func runBuild(_ *cobra.Command, args []string) error {
+func runBuild(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(buildOutput, outputSARIF); err != nil {
+		return err
+	}
+	buildResult = commandResult{Command: "build"}
+	err := build(cmd, args)
+	switch buildOutput {
+	case outputJSON:
+		return writeResult(commandOut(cmd), buildResult, err)
+	case outputSARIF:
+		return writeSARIF(commandOut(cmd), buildResult, err)
+	}
+	return err
+}
+
+// build runs the build, recording its outputs in buildResult
+func build(cmd *cobra.Command, args []string) error {
+	out := commandOut(cmd)
+	if buildHermetic {
+		// Keep stdout empty so build systems can't mistake progress for an output
+		out = commandErr(cmd)
+		if err := checkHermeticBuild(cmd, args); err != nil {
+			return err
+		}
+	}
+	if buildOutput == outputJSON || buildOutput == outputSARIF {
+		// The result replaces the progress messages
+		out = io.Discard
+	}
+	buildLog = newLogger(statusWriter(out, noColorRequested(buildNoColor)))
+	configPath, err := workspaceConfigPath(args)
+	if err != nil {
+		return err
+	}
+	if configPath != "" {
+		return buildWorkspace(cmd, configPath)
+	}
+	if buildParallel {
+		return fmt.Errorf("--parallel requires a project config (%s or --config)", workspace.DefaultFile)
+	}
+	return buildInput(cmd, args)
+}
+
+// buildInput builds one input file, the first of args or example.values.yaml
+func buildInput(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if err := checkPlainBuild(cmd); err != nil {
+		return err
+	}
+	if err := checkUmbrellaBuild(cmd); err != nil {
+		return err
+	}
+	if err := checkCheckBuild(); err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+	if err := opts.Parsing.Paths.Validate(); err != nil {
+		return err
+	}
+
 	// Determine input file: use provided arg, or default to example.values.yaml
 	inputFile := defaultExampleValuesFile
 	if len(args) > 0 {
@@ -79,87 +473,398 @@ func runBuild(_ *cobra.Command, args []string) error {
 	}
 
 	// Check if input file exists
-	if _, err := os.Stat(inputFile); err != nil {
+	if _, err := buildFS.Stat(inputFile); err != nil {
 		if len(args) == 0 {
 			return fmt.Errorf("%s not found in current directory (specify a file or run 'miaka init' first)", defaultExampleValuesFile)
 		}
 		return fmt.Errorf("input file not found: %s", inputFile)
 	}
 
+	// Insert missing type hints into the input file if requested
+	if buildSuggestHints {
+		if err := suggestTypeHints(inputFile); err != nil {
+			return err
+		}
+	}
+
 	// Parse the YAML file
-	p := parsing.NewParser()
-	s, err := p.ParseFile(inputFile)
+	s, registry, err := parseInput(opts, inputFile)
 	if err != nil {
-		return fmt.Errorf("failed to parse YAML: %w", err)
+		return err
+	}
+	if s.APIVersion == "" && s.Kind == "" {
+		return fmt.Errorf("%s has no apiVersion or kind (add them with 'miaka init', or use --plain to only generate a JSON Schema)", inputFile)
+	}
+	buildLog.Debugf("Parsed %s: kind %s with %d struct(s)", inputFile, s.Kind, len(s.Structs))
+	for _, warning := range s.Warnings {
+		buildLog.Warnf("⚠️  %s: %s", inputFile, warning)
+		buildResult.Warnings = append(buildResult.Warnings, resultIssue{Rule: "parse-warning", Message: warning, File: inputFile})
+	}
+	if err := checkDescriptions(s, inputFile); err != nil {
+		return err
+	}
+
+	targets, err := parseEmitTargets()
+	if err != nil {
+		return err
 	}
 
-	// Prepare types file path and cleanup
-	typesFilePath, cleanup, err := prepareTypesFile()
+	// Generate all outputs concurrently from the parsed schema. The steps below
+	// write and validate them in order, and report any error from the cached results.
+	names := prefetchTargets(registry, targets)
+	buildLog.Debugf("Generating outputs: %s", strings.Join(names, ", "))
+	_ = registry.Prefetch(ctx, *s, names...)
+
+	stamp, err := buildProvenance(inputFile)
 	if err != nil {
 		return err
 	}
-	defer cleanup()
+	// Catch example values that break their own markers before writing anything
+	if err := validateExampleValues(ctx, registry, s, inputFile); err != nil {
+		return err
+	}
+	if buildCheck {
+		return runBuildCheck(ctx, registry, s, inputFile, targets, stamp)
+	}
 
 	// Generate and write types
-	if err := generateAndWriteTypes(s, inputFile, typesFilePath); err != nil {
+	if err := generateAndWriteTypes(ctx, registry, s, inputFile); err != nil {
 		return err
 	}
 
-	// Generate CRD with breaking change detection
-	hadExistingCRD, err := handleCRDGeneration(s, typesFilePath, inputFile)
-	if err != nil {
+	// Generate additional outputs (e.g., TypeScript declarations) if requested
+	if err := emitAdditionalTargets(ctx, registry, s, targets); err != nil {
+		return err
+	}
+
+	// Generate CRD with breaking change detection. Plain builds only generate the
+	// CRD internally, so they're first builds until the JSON Schema exists.
+	var hadExistingCRD bool
+	if buildPlain {
+		_, statErr := buildFS.Stat(buildSchemaPath)
+		hadExistingCRD = statErr == nil
+	} else if hadExistingCRD, err = handleCRDGeneration(ctx, registry, s, inputFile, stamp); err != nil {
 		return err
 	}
 
 	// Generate and validate JSON Schema
-	if err := generateJSONSchema(inputFile); err != nil {
+	if err := generateJSONSchema(ctx, registry, s, inputFile, stamp); err != nil {
 		return err
 	}
 
+	if buildDepsFile != "" {
+		if err := writeDepsFile(buildDepsFile, inputFile); err != nil {
+			return err
+		}
+	}
+
 	// Print next steps for first-time users
-	if !hadExistingCRD {
+	if !hadExistingCRD && !buildHermetic && buildWorkspaceTarget == "" {
 		printNextSteps(inputFile)
 	}
 
 	return nil
 }
 
-// prepareTypesFile sets up the types file path and returns a cleanup function
-func prepareTypesFile() (typesFilePath string, cleanup func(), err error) {
-	if buildTypesPath != "" {
-		// Use specified output path
-		return buildTypesPath, func() {}, nil
+// parseInput parses the input file and creates the registry of its outputs, or
+// returns those a workspace build prepared for it with --parallel
+func parseInput(opts miaka.BuildOptions, inputFile string) (*schema.Schema, *generation.Registry, error) {
+	if prepared, ok := buildPrepared[inputFile]; ok {
+		return prepared.schema, prepared.registry, nil
+	}
+	s, err := parsing.NewParserWithOptions(opts.Parsing).ParseFileFS(buildFS, inputFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	s.WrapSpec = buildWrapSpec
+	registry, err := miaka.NewRegistry(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, registry, nil
+}
+
+// checkHermeticBuild ensures a hermetic build has no implicit inputs or outputs
+func checkHermeticBuild(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("--hermetic requires the input file to be specified")
+	}
+	for _, flag := range []string{"crd", "schema"} {
+		if flag == "crd" && buildPlain {
+			continue
+		}
+		if cmd == nil || !cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--hermetic requires --%s to be specified", flag)
+		}
+	}
+	if buildSuggestHints {
+		return fmt.Errorf("--hermetic can't be used with --suggest-hints, which modifies the input file")
+	}
+	if buildSnapshot != "" && buildHistoryDir == "" {
+		return fmt.Errorf("--hermetic requires --history to be specified with --snapshot")
+	}
+	return nil
+}
+
+// checkPlainBuild ensures the plain mode flags are consistent
+func checkPlainBuild(cmd *cobra.Command) error {
+	if !buildPlain {
+		if cmd != nil && cmd.Flags().Changed("type-name") {
+			return fmt.Errorf("--type-name requires --plain")
+		}
+		return nil
+	}
+	if buildSchemaKRM {
+		return fmt.Errorf("--schema-krm can't be used with --plain, whose values have no apiVersion or kind")
+	}
+	for _, flag := range []string{"crd", "resource-schema", "previous-crd", "scope", "plural", "list-kind", "short-names", "categories", "wrap-spec", "history", "supported-versions", "snapshot"} {
+		if cmd != nil && cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s can't be used with --plain, which doesn't generate a CRD", flag)
+		}
+	}
+	if !typeNamePattern.MatchString(buildTypeName) {
+		return fmt.Errorf("invalid --type-name %q (expected an exported Go type name, e.g., Values)", buildTypeName)
+	}
+	return nil
+}
+
+// buildProvenance returns the provenance to stamp the CRD and JSON Schema with,
+// or nil with --no-provenance. With --deterministic, only the input hash is
+// stamped, since the version and time depend on the environment.
+func buildProvenance(inputFile string) (*provenance.Provenance, error) {
+	if buildNoProvenance {
+		return nil, nil
+	}
+	input, err := buildFS.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	p := provenance.New(version, input, time.Now())
+	if buildDeterministic {
+		p = provenance.Provenance{InputHash: p.InputHash}
+	}
+	return &p, nil
+}
+
+// loadBuildDefaults parses the --defaults file, if there is one
+func loadBuildDefaults() (*parsing.Defaults, error) {
+	if buildDefaultsFile == "" {
+		return nil, nil
+	}
+	data, err := buildFS.ReadFile(buildDefaultsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read defaults file: %w", err)
+	}
+	defaults, err := parsing.ParseDefaults(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %s: %w", buildDefaultsFile, err)
+	}
+	return defaults, nil
+}
+
+// buildDeps lists the files read by the build, for --deps-file
+func buildDeps(inputFile string) []string {
+	deps := []string{inputFile}
+	if buildDefaultsFile != "" {
+		deps = append(deps, buildDefaultsFile)
+	}
+	for _, previous := range []string{previousCRDPath(), buildPreviousTypes} {
+		if previous == "" {
+			continue
+		}
+		if _, err := buildFS.Stat(previous); err == nil {
+			deps = append(deps, previous)
+		}
 	}
+	if dir := historyDir(); dir != "" {
+		snapshots, _ := history.List(dir)
+		for _, snapshot := range history.Supported(snapshots, buildSupported) {
+			deps = append(deps, snapshot.Path)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// writeDepsFile writes the files read by the build, one per line
+func writeDepsFile(path, inputFile string) error {
+	content := strings.Join(buildDeps(inputFile), "\n") + "\n"
+	if err := writeBuildOutput(path, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write deps file: %w", err)
+	}
+	return nil
+}
+
+// previousCRDPath returns the CRD to check for breaking changes against, or "" if there is none.
+// Hermetic builds never read the CRD output file, since it isn't a declared input,
+// and plain builds have none.
+func previousCRDPath() string {
+	if buildPlain {
+		return ""
+	}
+	if buildPreviousCRD != "" {
+		return buildPreviousCRD
+	}
+	if buildHermetic {
+		return ""
+	}
+	return buildCRDPath
+}
 
-	// No --types flag specified, use temp file
-	tmpDir, err := os.MkdirTemp("", "miaka-build-*")
+// historyDir returns the history of release snapshots, or "" if there is none.
+// Hermetic builds only read a history given with --history.
+func historyDir() string {
+	if buildPlain {
+		return ""
+	}
+	if buildHistoryDir != "" {
+		return buildHistoryDir
+	}
+	if buildHermetic {
+		return ""
+	}
+	return history.DefaultDir
+}
+
+// buildOptions returns the options of the build for its flags
+func buildOptions() (miaka.BuildOptions, error) {
+	strict, err := crd.ParseStrictMode(buildStrict)
+	if err != nil {
+		return miaka.BuildOptions{}, err
+	}
+	duplicateKeys, err := parsing.ParseDuplicateKeyPolicy(buildDuplicateKeys)
+	if err != nil {
+		return miaka.BuildOptions{}, err
+	}
+	templates, err := parsing.ParseTemplateStringPolicy(buildTemplates)
+	if err != nil {
+		return miaka.BuildOptions{}, err
+	}
+	titles, err := jsonschema.ParseTitleMode(buildTitles)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+		return miaka.BuildOptions{}, err
+	}
+	if buildSchemaKRM && buildResourcePath != "" {
+		return miaka.BuildOptions{}, fmt.Errorf("--schema-krm can't be used with --resource-schema, whose schema validates the resource form instead")
+	}
+	defaults, err := loadBuildDefaults()
+	if err != nil {
+		return miaka.BuildOptions{}, err
+	}
+	opts := miaka.BuildOptions{
+		Parsing: parsing.Options{
+			InferSemanticTypes: buildInferTypes,
+			Plain:              buildPlain,
+			TypeName:           buildTypeName,
+			Paths:              parsing.PathFilter{Include: buildInclude, Exclude: buildExclude},
+			DuplicateKeys:      duplicateKeys,
+			TemplateStrings:    templates,
+			Limits:             buildLimits,
+			Defaults:           defaults,
+		},
+		WrapSpec: buildWrapSpec,
+		Strict:   strict,
+		Resource: crd.Resource{
+			Scope:      buildScope,
+			Plural:     buildPlural,
+			ListKind:   buildListKind,
+			ShortNames: buildShortNames,
+			Categories: buildCategories,
+		},
+		Deterministic: buildDeterministic,
+		HelmCompat:    buildHelmCompat,
+		Titles:        titles,
+		Examples:      buildExamples,
+		KRM:           buildSchemaKRM,
+		ValuesOnly:    buildResourcePath != "",
+		// --in-memory, --hermetic, and --check generate the CRD without controller-gen's temp module
+		TempModule: !buildInMemory && !buildHermetic && !buildCheck,
+		WorkDir:    buildWorkDir,
+		Log:        buildLog,
 	}
-	cleanup = func() {
-		if err := os.RemoveAll(tmpDir); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to remove temp directory %s: %v\n", tmpDir, err)
+	if buildWorkDir != "" && !opts.TempModule {
+		return miaka.BuildOptions{}, fmt.Errorf("--workdir can't be used with --in-memory, --hermetic, or --check, which write no intermediate files")
+	}
+	// Hermetic builds don't read or write files they don't declare
+	if !buildNoCache && !buildHermetic {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			buildLog.Debugf("Not caching outputs: %v", err)
+		} else {
+			opts.Cache = cache.New(dir)
+			opts.CacheVersion = cacheVersion()
 		}
 	}
-	return filepath.Join(tmpDir, "types.go"), cleanup, nil
+	return opts, nil
 }
 
-// generateAndWriteTypes generates Go types and writes them to file
-func generateAndWriteTypes(s *schema.Schema, inputFile, typesFilePath string) error {
-	fmt.Printf("Generating Go types from %s...\n", inputFile)
-	g := gotypes.NewGenerator(s)
-	code, err := g.Generate()
+// newEmitterRegistry registers all output targets for a single build
+// (see miaka.NewRegistry), configured by the build's flags
+func newEmitterRegistry() (*generation.Registry, error) {
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	return miaka.NewRegistry(opts)
+}
+
+// writeOutput writes generated content, creating the parent directory if needed
+func writeOutput(path string, content []byte) error {
+	if err := buildFS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return buildFS.WriteFile(path, content, 0644)
+}
+
+// writeBuildOutput writes an output of the build, listing it in the result
+func writeBuildOutput(path string, content []byte) error {
+	if err := writeOutput(path, content); err != nil {
+		return err
+	}
+	buildResult.Files = append(buildResult.Files, path)
+	return nil
+}
+
+// validateExampleValues validates the example values against the generated CRD
+// (see miaka.CheckExample), listing the values that break their markers in the result
+func validateExampleValues(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string) error {
+	data, err := buildFS.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	err = miaka.CheckExample(ctx, registry, s, inputFile, data)
+	var exampleErr *miaka.ExampleError
+	if errors.As(err, &exampleErr) {
+		for _, problem := range exampleErr.Problems {
+			buildResult.Errors = append(buildResult.Errors, problemIssue("example-validation", problem))
+		}
+	}
+	return err
+}
+
+// generateAndWriteTypes generates Go types and writes them to file when --types is set
+func generateAndWriteTypes(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string) error {
+	buildLog.Infof("Generating Go types from %s...", inputFile)
+	file, err := registry.Emit(ctx, gotypes.TargetName, *s)
+
+	// Check for breaking changes before overwriting the existing types
+	if err == nil && buildPreviousTypes != "" {
+		buildLog.Infof("Checking the Go types for breaking changes against %s...", buildPreviousTypes)
+		if err := validation.CheckGoTypesBreakingChangesFS(buildFS, buildPreviousTypes, file.Content); err != nil {
+			return fmt.Errorf("failed to generate Go code: %w", err)
+		}
+	}
 
 	// Write types.go file even if there were formatting errors (for debugging)
-	if len(code) > 0 {
-		if writeErr := os.WriteFile(typesFilePath, code, 0644); writeErr != nil {
+	if buildTypesPath != "" && len(file.Content) > 0 {
+		if writeErr := writeBuildOutput(buildTypesPath, file.Content); writeErr != nil {
 			if err != nil {
 				return fmt.Errorf("failed to write types file: %w (original error: %w)", writeErr, err)
 			}
 			return fmt.Errorf("failed to write types file: %w", writeErr)
 		}
-		if err != nil && buildTypesPath != "" {
-			fmt.Fprintf(os.Stderr, "\nUnformatted types written to: %s\n", typesFilePath)
+		if err != nil {
+			buildLog.Warnf("\nUnformatted types written to: %s", buildTypesPath)
 		}
 	}
 
@@ -168,166 +873,365 @@ func generateAndWriteTypes(s *schema.Schema, inputFile, typesFilePath string) er
 		return fmt.Errorf("failed to generate Go code: %w", err)
 	}
 
-	fmt.Println("✓ Go types generated successfully")
+	buildLog.Infof("✓ Go types generated successfully")
 
 	// Validate schema after writing types (so users can inspect the file on failure)
-	fmt.Println("Validating schema...")
+	buildLog.Infof("Validating schema...")
 	if err := schema.ValidateSchema(s); err != nil {
 		if buildTypesPath != "" {
-			fmt.Fprintf(os.Stderr, "\nGenerated types with issues written to: %s\n", typesFilePath)
+			buildLog.Warnf("\nGenerated types with issues written to: %s", buildTypesPath)
+		}
+		var interfaceErr *schema.InterfaceTypeError
+		if errors.As(err, &interfaceErr) && !buildSuggestHints {
+			return fmt.Errorf("%w\n💡 Run 'miaka build --suggest-hints' to insert type hints automatically", err)
 		}
 		return err
 	}
 
-	fmt.Println("✓ Schema validation passed")
+	buildLog.Infof("✓ Schema validation passed")
 
 	// Print success message for types if preserving them
 	if buildTypesPath != "" {
-		fmt.Printf("✓ Types saved to %s\n", typesFilePath)
+		buildLog.Infof("✓ Types saved to %s", buildTypesPath)
 	}
 
 	return nil
 }
 
-// handleCRDGeneration generates CRD and handles breaking change detection
-func handleCRDGeneration(s *schema.Schema, typesFilePath, inputFile string) (hadExistingCRD bool, err error) {
-	fmt.Printf("Generating CRD %s...\n", buildCRDPath)
+// suggestTypeHints inserts +miaka:type hints into the input file for fields that
+// would otherwise be generated as interface{}
+func suggestTypeHints(inputFile string) error {
+	data, err := buildFS.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	suggestions, err := hints.Suggest(data)
+	if err != nil {
+		return fmt.Errorf("failed to suggest type hints: %w", err)
+	}
+	if len(suggestions) == 0 {
+		buildLog.Infof("✓ No type hints needed")
+		return nil
+	}
+
+	if err := buildFS.WriteFile(inputFile, hints.Apply(data, suggestions), 0644); err != nil {
+		return fmt.Errorf("failed to write type hints: %w", err)
+	}
+
+	buildLog.Infof("Type hints for %s:", inputFile)
+	for _, suggestion := range suggestions {
+		switch {
+		case suggestion.Manual:
+			buildLog.Infof("  ✗ %s (add manually above the list item)", suggestion)
+		case suggestion.Guessed:
+			buildLog.Infof("  ✓ %s (guessed - please review)", suggestion)
+		default:
+			buildLog.Infof("  ✓ %s", suggestion)
+		}
+	}
+
+	return nil
+}
+
+// emitTarget is an additional output requested with --typescript or --emit
+type emitTarget struct {
+	name string
+	path string
+}
+
+// parseEmitTargets parses the outputs requested with --typescript and --emit
+func parseEmitTargets() ([]emitTarget, error) {
+	values := buildEmit
+	if buildResourcePath != "" {
+		values = append([]string{jsonschema.ResourceTargetName + "=" + buildResourcePath}, values...)
+	}
+	if buildTSPath != "" {
+		values = append([]string{typescript.TargetName + "=" + buildTSPath}, values...)
+	}
+
+	targets := make([]emitTarget, 0, len(values))
+	for _, value := range values {
+		name, path, ok := strings.Cut(value, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid --emit value %q (expected target=path)", value)
+		}
+		if buildPlain && (name == crd.TargetName || name == jsonschema.ResourceTargetName) {
+			return nil, fmt.Errorf("--emit %s can't be used with --plain, which doesn't generate a CRD", name)
+		}
+		targets = append(targets, emitTarget{name: name, path: path})
+	}
+	return targets, nil
+}
+
+// prefetchTargets returns the registered targets a build generates. Unknown
+// --emit targets are left for emitAdditionalTargets to report.
+func prefetchTargets(registry *generation.Registry, targets []emitTarget) []string {
+	names := []string{gotypes.TargetName, jsonschema.TargetName}
+	if !buildPlain {
+		names = append(names, crd.TargetName)
+	}
+	for _, target := range targets {
+		if _, ok := registry.Get(target.name); ok && !slices.Contains(names, target.name) {
+			names = append(names, target.name)
+		}
+	}
+	return names
+}
 
-	crdDir := filepath.Dir(buildCRDPath)
-	crdFileName := filepath.Base(buildCRDPath)
+// emitAdditionalTargets writes the outputs requested with --typescript and --emit
+func emitAdditionalTargets(ctx context.Context, registry *generation.Registry, s *schema.Schema, targets []emitTarget) error {
+	for _, target := range targets {
+		buildLog.Infof("Generating %s output %s...", target.name, target.path)
+		file, err := registry.Emit(ctx, target.name, *s)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s output: %w", target.name, err)
+		}
+		if err := writeBuildOutput(target.path, file.Content); err != nil {
+			return fmt.Errorf("failed to write %s output: %w", target.name, err)
+		}
+		buildLog.Infof("✓ %s output generated: %s", target.name, target.path)
+	}
 
-	// Save existing CRD for breaking change comparison (if it exists)
-	var oldCRDContent []byte
-	if existingData, readErr := os.ReadFile(buildCRDPath); readErr == nil {
-		oldCRDContent = existingData
-		hadExistingCRD = true
-		fmt.Println("Checking for breaking changes against existing CRD...")
+	return nil
+}
+
+// handleCRDGeneration generates CRD and handles breaking change detection
+func handleCRDGeneration(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string, stamp *provenance.Provenance) (hadExistingCRD bool, err error) {
+	buildLog.Infof("Generating CRD %s...", buildCRDPath)
+
+	previousCRD := previousCRDPath()
+	if previousCRD != "" {
+		if _, statErr := buildFS.Stat(previousCRD); statErr == nil {
+			hadExistingCRD = true
+		}
 	}
 
-	if err := generateCRD(s, typesFilePath, crdDir, crdFileName); err != nil {
+	file, err := registry.Emit(ctx, crd.TargetName, *s)
+	if err != nil {
 		return hadExistingCRD, fmt.Errorf("failed to generate CRD: %w", err)
 	}
 
-	// Check for breaking changes if there was an existing CRD
+	// Check for breaking changes before overwriting the existing CRD
 	if hadExistingCRD {
-		if err := checkBreakingChanges(oldCRDContent); err != nil {
-			return hadExistingCRD, err
+		buildLog.Infof("Checking for breaking changes against %s...", previousCRD)
+		if err := validation.CheckBreakingChangesFS(buildFS, previousCRD, file.Content, miaka.CRDRenames(s)...); err != nil {
+			recordBreakingChanges(err, s, inputFile, "")
+			return hadExistingCRD, fmt.Errorf("failed to generate CRD: %w", err)
 		}
+	} else {
+		buildLog.Debugf("No previous CRD, skipping breaking change detection")
 	}
-
-	// Add strict validation to CRD (additionalProperties: false)
-	if err := crd.AddStrictValidation(buildCRDPath); err != nil {
-		return hadExistingCRD, fmt.Errorf("failed to add strict validation to CRD: %w", err)
+	if err := checkHistory(file.Content, s, inputFile); err != nil {
+		return hadExistingCRD, fmt.Errorf("failed to generate CRD: %w", err)
 	}
 
-	// Validate the generated CRD itself
-	if err := crd.ValidateCRD(buildCRDPath); err != nil {
-		return hadExistingCRD, fmt.Errorf("generated CRD is invalid: %w", err)
+	content, err := miaka.StampCRD(file.Content, s, stamp)
+	if err != nil {
+		return hadExistingCRD, err
+	}
+	if err := writeBuildOutput(buildCRDPath, content); err != nil {
+		return hadExistingCRD, fmt.Errorf("failed to write CRD: %w", err)
 	}
 
-	fmt.Printf("✓ CRD generated: %s\n", buildCRDPath)
+	buildLog.Infof("✓ CRD generated: %s", buildCRDPath)
+
+	if buildSnapshot != "" {
+		dir := historyDir()
+		if dir == "" {
+			dir = history.DefaultDir
+		}
+		snapshot, err := history.Save(dir, buildSnapshot, file.Content)
+		if err != nil {
+			return hadExistingCRD, fmt.Errorf("failed to save snapshot: %w", err)
+		}
+		buildLog.Infof("✓ Snapshot of release %s saved: %s", snapshot.Version, snapshot.Path)
+	}
 
 	// Validate the input YAML against the generated CRD
-	fmt.Printf("Validating %s against CRD...\n", inputFile)
-	if err := validation.ValidateAgainstCRD(buildCRDPath, inputFile); err != nil {
+	buildLog.Infof("Validating %s against CRD...", inputFile)
+	if err := validation.ValidateAgainstCRDFS(buildFS, buildCRDPath, inputFile); err != nil {
 		return hadExistingCRD, fmt.Errorf("validation failed: %w", err)
 	}
 
-	fmt.Printf("✓ Validation passed: %s conforms to CRD schema\n", inputFile)
+	buildLog.Infof("✓ Validation passed: %s conforms to CRD schema", inputFile)
 
 	return hadExistingCRD, nil
 }
 
-// checkBreakingChanges compares old and new CRD for breaking changes
-func checkBreakingChanges(oldCRDContent []byte) error {
-	newCRDContent, err := os.ReadFile(buildCRDPath)
-	if err != nil {
-		return fmt.Errorf("failed to read generated CRD: %w", err)
+// checkHistory checks a generated CRD for breaking changes against the
+// snapshots of the supported releases in the history
+func checkHistory(content []byte, s *schema.Schema, inputFile string) error {
+	dir := historyDir()
+	if dir == "" {
+		return nil
 	}
-
-	// Create temp file with old CRD for comparison
-	tmpOldCRD, err := os.CreateTemp("", "old-crd-*.yaml")
+	snapshots, err := history.List(dir)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file for old CRD: %w", err)
+		return err
+	}
+	supported := history.Supported(snapshots, buildSupported)
+	if len(supported) == 0 {
+		if len(buildSupported) > 0 {
+			buildLog.Warnf("⚠️  No snapshots in %s match --supported-versions %s", dir, strings.Join(buildSupported, ","))
+		}
+		return nil
 	}
-	defer os.Remove(tmpOldCRD.Name())
 
-	if _, err := tmpOldCRD.Write(oldCRDContent); err != nil {
-		tmpOldCRD.Close()
-		return fmt.Errorf("failed to write old CRD to temp file: %w", err)
+	for _, snapshot := range supported {
+		buildLog.Infof("Checking for breaking changes against release %s...", snapshot.Version)
+		if err := validation.CheckBreakingChangesFS(buildFS, snapshot.Path, content, miaka.CRDRenames(s)...); err != nil {
+			recordBreakingChanges(err, s, inputFile, fmt.Sprintf(" (against release %s)", snapshot.Version))
+			return fmt.Errorf("breaking changes against release %s (%s): %w", snapshot.Version, snapshot.Path, err)
+		}
 	}
-	tmpOldCRD.Close()
+	return nil
+}
 
-	// Check for breaking changes
-	if err := validation.CheckBreakingChanges(tmpOldCRD.Name(), newCRDContent); err != nil {
-		// Restore the old CRD since we're rejecting the breaking change
-		if writeErr := os.WriteFile(buildCRDPath, oldCRDContent, 0644); writeErr != nil {
-			return fmt.Errorf("breaking change detected and failed to restore old CRD: %w (original error: %w)", writeErr, err)
+// recordBreakingChanges lists the breaking changes of a failed check in the
+// result, positioned at the changed values in the input file. suffix is added
+// to their messages.
+func recordBreakingChanges(err error, s *schema.Schema, inputFile, suffix string) {
+	var breakingErr *validation.BreakingChangesError
+	if !errors.As(err, &breakingErr) {
+		return
+	}
+	sources := validation.Sources{}
+	if data, readErr := buildFS.ReadFile(inputFile); readErr == nil {
+		if source, parseErr := validation.ParseSource(inputFile, data); parseErr == nil {
+			sources = append(sources, source)
 		}
-		return fmt.Errorf("failed to generate CRD: %w", err)
 	}
 
-	return nil
+	for _, change := range breakingErr.Changes {
+		path := change.Path()
+		if s.WrapSpec {
+			path = validation.UnwrapPath(path)
+		}
+		// The example values have one item for each list
+		for i, segment := range path {
+			if segment == "*" {
+				path[i] = "0"
+			}
+		}
+		problem := sources.Locate(path, change.Message+suffix)
+		if problem.File == "" {
+			problem.File = inputFile
+		}
+		buildResult.Errors = append(buildResult.Errors, problemIssue("breaking-change/"+change.Validation, problem))
+	}
 }
 
 // generateJSONSchema generates and validates JSON Schema
-func generateJSONSchema(inputFile string) error {
+func generateJSONSchema(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string, stamp *provenance.Provenance) error {
 	// Generate JSON Schema
-	fmt.Printf("Generating JSON Schema %s...\n", buildSchemaPath)
-	if err := jsonschema.GenerateFromCRD(buildCRDPath, buildSchemaPath); err != nil {
-		return fmt.Errorf("failed to generate JSON Schema: %w", err)
+	buildLog.Infof("Generating JSON Schema %s...", buildSchemaPath)
+	content, err := jsonSchemaOutput(ctx, registry, s, inputFile, stamp, writeBuildOutput)
+	if err != nil {
+		return err
+	}
+	if err := writeBuildOutput(buildSchemaPath, content); err != nil {
+		return fmt.Errorf("failed to write JSON Schema file: %w", err)
+	}
+	buildLog.Infof("✓ JSON Schema generated: %s", buildSchemaPath)
+
+	if err := reportConversionLosses(ctx, registry, s); err != nil {
+		return err
 	}
-	fmt.Printf("✓ JSON Schema generated: %s\n", buildSchemaPath)
 
 	// Validate input against JSON Schema
-	fmt.Printf("Validating %s against JSON Schema...\n", inputFile)
-	if err := validation.ValidateYAML(inputFile, buildSchemaPath); err != nil {
+	buildLog.Infof("Validating %s against JSON Schema...", inputFile)
+	if err := validation.ValidateYAMLFS(buildFS, inputFile, buildSchemaPath); err != nil {
 		return fmt.Errorf("JSON Schema validation failed: %w", err)
 	}
-	fmt.Printf("✓ JSON Schema validation passed\n")
+	buildLog.Infof("✓ JSON Schema validation passed")
 
 	return nil
 }
 
-// printNextSteps prints helpful next steps for first-time users
-func printNextSteps(inputFile string) {
-	fmt.Println()
-	fmt.Println("🎉 Generated schemas for the first time!")
-	fmt.Println()
-	fmt.Println("📝 Next steps:")
-	fmt.Println("  1. Validate your actual values files:")
-	fmt.Printf("       miaka validate your-values.yaml\n")
-	fmt.Println()
-	fmt.Println("  2. Improve your schema by editing", inputFile+":")
-	fmt.Println("       - Add kubebuilder validation markers (e.g., +kubebuilder:validation:Minimum=1)")
-	fmt.Println("       - Add field descriptions as comments")
-	fmt.Println("       - Then run 'miaka build' again to regenerate schemas")
-	fmt.Println()
-	fmt.Println("  3. Commit the generated files to git:")
-	fmt.Printf("       git add %s %s %s\n", buildCRDPath, buildSchemaPath, inputFile)
-	fmt.Println("       git commit -m 'Add Miaka schemas'")
-	fmt.Println("       (This enables breaking change detection on future builds)")
-}
-
-// generateCRD generates a CRD from the schema and types file
-func generateCRD(s *schema.Schema, typesFile string, outputDir string, outputFileName string) error {
-	// Parse apiVersion using Kubernetes libraries
-	gv, err := runtimeschema.ParseGroupVersion(s.APIVersion)
+// jsonSchemaOutput returns the content of the JSON Schema output: composed with
+// the subchart schemas, which are written with write, for --umbrella, and stamped
+// with the provenance, if any
+func jsonSchemaOutput(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string, stamp *provenance.Provenance, write func(path string, content []byte) error) ([]byte, error) {
+	file, err := registry.Emit(ctx, jsonschema.TargetName, *s)
 	if err != nil {
-		return fmt.Errorf("invalid apiVersion format: %s: %w", s.APIVersion, err)
+		return nil, fmt.Errorf("failed to generate JSON Schema: %w", err)
+	}
+	content := file.Content
+	if buildUmbrella {
+		if content, err = composeUmbrellaSchema(ctx, content, inputFile, write); err != nil {
+			return nil, err
+		}
 	}
+	if buildExamples {
+		input, err := buildFS.ReadFile(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		if content, err = jsonschema.AddExamples(content, input); err != nil {
+			return nil, err
+		}
+	}
+	// Composing sorts the properties again, which stamping orders
+	return miaka.StampJSONSchema(content, s, stamp)
+}
 
-	// Create CRD generator options
-	opts := crd.Options{
-		Group:          gv.Group,
-		Version:        gv.Version,
-		Kind:           s.Kind,
-		OutputFileName: outputFileName,
+// reportConversionLosses warns about CRD constructs the JSON Schema can't represent,
+// and fails if there are more than --max-conversion-losses
+func reportConversionLosses(ctx context.Context, registry *generation.Registry, s *schema.Schema) error {
+	crdFile, err := registry.Emit(ctx, crd.TargetName, *s)
+	if err != nil {
+		return fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	losses, err := jsonschema.ConversionLosses(crdFile.Content)
+	if err != nil {
+		return fmt.Errorf("failed to compare JSON Schema with CRD: %w", err)
+	}
+	if len(losses) == 0 {
+		return nil
 	}
 
-	// Create CRD generator
-	gen := crd.NewGenerator(opts)
+	buildLog.Warnf("⚠️  JSON Schema can't represent %d construct(s) from the CRD; Helm won't validate them:", len(losses))
+	for _, loss := range losses {
+		buildLog.Warnf("  - %s", loss)
+		buildResult.Warnings = append(buildResult.Warnings, resultIssue{Rule: "conversion-loss", Message: loss.Detail, Path: loss.Path})
+	}
+	if buildMaxLosses >= 0 && len(losses) > buildMaxLosses {
+		return fmt.Errorf("%d constructs can't be represented in the JSON Schema, more than --max-conversion-losses=%d", len(losses), buildMaxLosses)
+	}
+	return nil
+}
 
-	// Generate CRD
-	return gen.Generate(typesFile, outputDir)
+// printNextSteps prints helpful next steps for first-time users
+func printNextSteps(inputFile string) {
+	buildLog.Infof("")
+	buildLog.Infof("🎉 Generated schemas for the first time!")
+	buildLog.Infof("")
+	buildLog.Infof("📝 Next steps:")
+	if buildPlain {
+		buildLog.Infof("  1. Validate your actual values files (Helm checks them against %s):", buildSchemaPath)
+		buildLog.Infof("       helm lint . --values your-values.yaml")
+	} else {
+		buildLog.Infof("  1. Validate your actual values files:")
+		buildLog.Infof("       miaka validate your-values.yaml")
+	}
+	buildLog.Infof("")
+	buildLog.Infof("  2. Improve your schema by editing %s:", inputFile)
+	buildLog.Infof("       - Add kubebuilder validation markers (e.g., +kubebuilder:validation:Minimum=1)")
+	buildLog.Infof("       - Add field descriptions as comments")
+	if buildPlain {
+		buildLog.Infof("       - Then run 'miaka build --plain' again to regenerate the schema")
+	} else {
+		buildLog.Infof("       - Then run 'miaka build' again to regenerate schemas")
+	}
+	buildLog.Infof("")
+	buildLog.Infof("  3. Commit the generated files to git:")
+	if buildPlain {
+		buildLog.Infof("       git add %s %s", buildSchemaPath, inputFile)
+		buildLog.Infof("       git commit -m 'Add Miaka schemas'")
+		return
+	}
+	buildLog.Infof("       git add %s %s %s", buildCRDPath, buildSchemaPath, inputFile)
+	buildLog.Infof("       git commit -m 'Add Miaka schemas'")
+	buildLog.Infof("       (This enables breaking change detection on future builds)")
 }
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/miaka"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/pmezard/go-difflib/difflib"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// checkCheckBuild ensures --check isn't combined with flags that modify files
+func checkCheckBuild() error {
+	if buildCheck && buildSuggestHints {
+		return fmt.Errorf("--check can't be used with --suggest-hints, which modifies the input file")
+	}
+	return nil
+}
+
+// outputChecker compares generated outputs with the files on disk, for --check
+type outputChecker struct {
+	stale int
+	// order is the order of the properties of the CRD and JSON Schema, which
+	// are sorted again when their provenance is adopted
+	order *schema.PropertyOrder
+}
+
+// compare prints whether the file at path has the generated content, with a
+// diff if it doesn't
+func (c *outputChecker) compare(path string, generated []byte) error {
+	existing, err := buildFS.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		buildLog.Errorf("✗ %s doesn't exist", path)
+		c.stale++
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if bytes.Equal(existing, generated) {
+		buildLog.Infof("✓ %s is up to date", path)
+		return nil
+	}
+
+	c.stale++
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(existing)),
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: path,
+		ToFile:   path + " (generated)",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff %s: %w", path, err)
+	}
+	buildLog.Errorf("✗ %s is out of date:\n%s", path, strings.TrimSuffix(diff, "\n"))
+	return nil
+}
+
+// compareCRD is like compare, but ignores annotations that depend on the
+// environment or the build time, by keeping the values of the CRD on disk
+func (c *outputChecker) compareCRD(path string, generated []byte) error {
+	if existing, err := buildFS.ReadFile(path); err == nil {
+		generated = adoptVolatileAnnotations(generated, existing)
+		if ordered, err := crd.OrderProperties(generated, c.order); err == nil {
+			generated = ordered
+		}
+	}
+	return c.compare(path, generated)
+}
+
+// compareSchema is like compare, but ignores the miaka version and build time
+// in the provenance, by keeping the values of the schema on disk
+func (c *outputChecker) compareSchema(path string, generated []byte) error {
+	existing, err := buildFS.ReadFile(path)
+	if err != nil {
+		return c.compare(path, generated)
+	}
+	p, ok, err := provenance.ReadSchema(generated)
+	if err != nil || !ok {
+		return c.compare(path, generated)
+	}
+	if previous, ok, err := provenance.ReadSchema(existing); err == nil && ok {
+		p.Version, p.GeneratedAt = previous.Version, previous.GeneratedAt
+		if generated, err = provenance.StampSchema(generated, p); err != nil {
+			return err
+		}
+		if generated, err = jsonschema.OrderProperties(generated, c.order); err != nil {
+			return err
+		}
+	}
+	return c.compare(path, generated)
+}
+
+// adoptVolatileAnnotations returns the generated CRD with the existing CRD's
+// values of annotations that depend on the environment or the build time. If
+// either CRD can't be parsed, the generated CRD is returned as is.
+func adoptVolatileAnnotations(generated, existing []byte) []byte {
+	var generatedCRD, existingCRD apiextensionsv1.CustomResourceDefinition
+	if yaml.Unmarshal(generated, &generatedCRD) != nil || yaml.Unmarshal(existing, &existingCRD) != nil {
+		return generated
+	}
+
+	keys := append([]string{provenance.VersionAnnotation, provenance.GeneratedAtAnnotation}, crd.VolatileAnnotations...)
+	for _, key := range keys {
+		value, ok := existingCRD.Annotations[key]
+		if _, generatedOK := generatedCRD.Annotations[key]; !generatedOK {
+			continue
+		}
+		if ok {
+			generatedCRD.Annotations[key] = value
+		} else {
+			delete(generatedCRD.Annotations, key)
+		}
+	}
+
+	output, err := yaml.Marshal(&generatedCRD)
+	if err != nil {
+		return generated
+	}
+	return output
+}
+
+// runBuildCheck generates all outputs in memory and compares them with the files
+// on disk, for --check. Nothing is written.
+func runBuildCheck(ctx context.Context, registry *generation.Registry, s *schema.Schema, inputFile string, targets []emitTarget, stamp *provenance.Provenance) error {
+	buildLog.Infof("Checking outputs generated from %s...", inputFile)
+	checker := &outputChecker{order: s.PropertyOrder()}
+
+	if buildTypesPath != "" {
+		file, err := registry.Emit(ctx, gotypes.TargetName, *s)
+		if err != nil {
+			return fmt.Errorf("failed to generate Go code: %w", err)
+		}
+		if err := checker.compare(buildTypesPath, file.Content); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range targets {
+		file, err := registry.Emit(ctx, target.name, *s)
+		if err != nil {
+			return fmt.Errorf("failed to generate %s output: %w", target.name, err)
+		}
+		compare := checker.compare
+		if target.name == crd.TargetName {
+			compare = checker.compareCRD
+		}
+		if err := compare(target.path, file.Content); err != nil {
+			return err
+		}
+	}
+
+	if !buildPlain {
+		file, err := registry.Emit(ctx, crd.TargetName, *s)
+		if err != nil {
+			return fmt.Errorf("failed to generate CRD: %w", err)
+		}
+		content, err := miaka.StampCRD(file.Content, s, stamp)
+		if err != nil {
+			return err
+		}
+		if err := checker.compareCRD(buildCRDPath, content); err != nil {
+			return err
+		}
+	}
+
+	content, err := jsonSchemaOutput(ctx, registry, s, inputFile, stamp, checker.compare)
+	if err != nil {
+		return err
+	}
+	if err := checker.compareSchema(buildSchemaPath, content); err != nil {
+		return err
+	}
+
+	if checker.stale > 0 {
+		return fmt.Errorf("%d output(s) are out of date with %s (run 'miaka build' to update them)", checker.stale, inputFile)
+	}
+	buildLog.Infof("✓ All outputs are up to date")
+	return nil
+}
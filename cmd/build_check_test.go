@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildCheckOutputs returns the output flags of the check tests, for outputs in dir
+func buildCheckOutputs(dir string) []string {
+	return []string{
+		"-c", filepath.Join(dir, "crd.yaml"),
+		"-s", filepath.Join(dir, "values.schema.json"),
+		"-t", filepath.Join(dir, "types.go"),
+		"--typescript", filepath.Join(dir, "values.d.ts"),
+	}
+}
+
+// readOutputs returns the content of each file in dir
+func readOutputs(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	contents := make(map[string]string)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		contents[entry.Name()] = string(data)
+	}
+	return contents
+}
+
+func TestBuildCommand_Check(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\n# Number of replicas\nreplicas: 1\n"), 0644))
+
+	// Outputs are built with the controller-gen backend, and checked in memory
+	cmd := newBuildCommand()
+	cmd.SetArgs(append([]string{input}, buildCheckOutputs(dir)...))
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+
+	// The build time isn't compared
+	crdPath := filepath.Join(dir, "crd.yaml")
+	crdContent, err := os.ReadFile(crdPath)
+	require.NoError(t, err)
+	p, _, err := provenance.ReadCRD(crdContent)
+	require.NoError(t, err)
+	crdContent = []byte(strings.Replace(string(crdContent), p.GeneratedAt, "2000-01-01T00:00:00Z", 1))
+	require.NoError(t, os.WriteFile(crdPath, crdContent, 0644))
+	before := readOutputs(t, dir)
+
+	cmd = newBuildCommand()
+	cmd.SetArgs(append([]string{input, "--check"}, buildCheckOutputs(dir)...))
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err, stdout)
+	assert.Contains(t, stdout, "crd.yaml is up to date")
+	assert.Contains(t, stdout, "values.schema.json is up to date")
+	assert.Contains(t, stdout, "types.go is up to date")
+	assert.Contains(t, stdout, "values.d.ts is up to date")
+	assert.Contains(t, stdout, "All outputs are up to date")
+
+	// Changing the input makes the check fail with a diff, without writing anything
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\n# Number of pods\nreplicas: 1\n"), 0644))
+	before["example.values.yaml"] = "apiVersion: example.com/v1\nkind: Example\n# Number of pods\nreplicas: 1\n"
+	cmd = newBuildCommand()
+	cmd.SetArgs(append([]string{input, "--check"}, buildCheckOutputs(dir)...))
+	stdout, _, err = captureStdoutStderr(t, cmd.Execute)
+	assert.ErrorContains(t, err, "4 output(s) are out of date")
+	assert.Contains(t, stdout, "types.go is out of date")
+	assert.Contains(t, stdout, "-\t// Number of replicas\n+\t// Number of pods\n")
+	assert.Contains(t, stdout, "+++ "+filepath.Join(dir, "types.go")+" (generated)")
+	assert.Equal(t, before, readOutputs(t, dir))
+}
+
+func TestBuildCommand_CheckMissingOutput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("replicas: 1\n"), 0644))
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{input, "--check", "--plain", "-s", filepath.Join(dir, "values.schema.json")})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	assert.ErrorContains(t, err, "1 output(s) are out of date")
+	assert.Contains(t, stdout, "values.schema.json doesn't exist")
+	assert.NoFileExists(t, filepath.Join(dir, "values.schema.json"))
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{input, "--check", "--plain", "--suggest-hints"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	assert.ErrorContains(t, err, "--check can't be used with --suggest-hints")
+}
+
+func TestAdoptVolatileAnnotations(t *testing.T) {
+	generated := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v2
+    miaka.dev/generated-at: "2025-01-02T00:00:00Z"
+    miaka.dev/input-sha256: new
+    miaka.dev/version: v2
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions: null
+`
+	existing := `metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v1
+    miaka.dev/generated-at: "2024-01-02T00:00:00Z"
+    miaka.dev/input-sha256: old
+`
+	adopted := string(adoptVolatileAnnotations([]byte(generated), []byte(existing)))
+	assert.Contains(t, adopted, "controller-gen.kubebuilder.io/version: v1\n")
+	assert.Contains(t, adopted, `miaka.dev/generated-at: "2024-01-02T00:00:00Z"`)
+	assert.Contains(t, adopted, "miaka.dev/input-sha256: new\n")
+	assert.NotContains(t, adopted, "miaka.dev/version")
+
+	assert.Equal(t, generated, string(adoptVolatileAnnotations([]byte(generated), []byte("not: [valid"))))
+}
+
+// TestBuildCommand_PropertyOrder tests that the CRD and JSON Schema keep the
+// order of the example values through provenance stamping and --check
+func TestBuildCommand_PropertyOrder(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\nimage:\n  tag: v1\n  repository: nginx\nautoscaling: false\n"), 0644))
+
+	cmd := newBuildCommand()
+	cmd.SetArgs(append([]string{input, "--in-memory"}, buildCheckOutputs(dir)...))
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+
+	outputs := readOutputs(t, dir)
+	for name, keys := range map[string][]string{
+		"crd.yaml":           {"replicas:", "image:", "tag:", "repository:", "autoscaling:"},
+		"values.schema.json": {`"replicas"`, `"image"`, `"tag"`, `"repository"`, `"autoscaling"`},
+	} {
+		content := outputs[name]
+		last := -1
+		for _, key := range keys {
+			index := strings.Index(content[last+1:], key)
+			require.GreaterOrEqual(t, index, 0, "expected %s in the order %v in %s:\n%s", key, keys, name, content)
+			last += 1 + index
+		}
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs(append([]string{input, "--check"}, buildCheckOutputs(dir)...))
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err, stdout)
+	assert.Contains(t, stdout, "All outputs are up to date")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/stats"
+)
+
+// descriptionsWarn is the --require-descriptions value that only warns about
+// fields without a description
+const descriptionsWarn = "warn"
+
+// descriptionPolicy is a parsed --require-descriptions value
+type descriptionPolicy struct {
+	// percent of fields that must have a description; fields without one fail
+	// the build below it
+	percent float64
+	// warn lists the fields without a description instead of failing
+	warn bool
+}
+
+// parseDescriptionPolicy parses --require-descriptions: "" to not check, "warn",
+// or the percentage of fields that must have descriptions (e.g., "80" or "80%")
+func parseDescriptionPolicy(value string) (*descriptionPolicy, error) {
+	switch value {
+	case "":
+		return nil, nil
+	case descriptionsWarn:
+		return &descriptionPolicy{warn: true}, nil
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("invalid --require-descriptions %q (must be a percentage from 0 to 100, or warn)", value)
+	}
+	return &descriptionPolicy{percent: percent}, nil
+}
+
+// checkDescriptions enforces --require-descriptions on the fields of the
+// example values, listing the fields without a description
+func checkDescriptions(s *schema.Schema, inputFile string) error {
+	policy, err := parseDescriptionPolicy(buildDescriptions)
+	if err != nil || policy == nil {
+		return err
+	}
+	report := stats.Analyze(s)
+	if report.Undescribed == 0 {
+		return nil
+	}
+	described := float64(report.Fields-report.Undescribed) * 100 / float64(report.Fields)
+
+	if policy.warn {
+		buildLog.Warnf("⚠️  %s: %d field(s) have no description:", inputFile, report.Undescribed)
+		for _, path := range report.UndescribedPaths {
+			buildLog.Warnf("  - %s", path)
+			buildResult.Warnings = append(buildResult.Warnings, resultIssue{Rule: "missing-description", Message: fmt.Sprintf("%s has no description", path), File: inputFile, Path: path})
+		}
+		return nil
+	}
+	if described >= policy.percent {
+		return nil
+	}
+	for _, path := range report.UndescribedPaths {
+		buildResult.Errors = append(buildResult.Errors, resultIssue{Rule: "missing-description", Message: fmt.Sprintf("%s has no description", path), File: inputFile, Path: path})
+	}
+	// Rounded down, so a failing percentage is never shown as the required one
+	return fmt.Errorf("%s: %g%% of fields have a description, below the required %g%% (add a comment above each of these fields):\n  %s",
+		inputFile, math.Floor(described*10)/10, policy.percent, strings.Join(report.UndescribedPaths, "\n  "))
+}
@@ -2,11 +2,29 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/typescript"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/sarif"
+	"github.com/crenshaw-dev/miaka/pkg/structdiff"
 	"github.com/spf13/cobra"
 )
 
@@ -148,6 +166,52 @@ func newBuildCommand() *cobra.Command {
 	buildTypesPath = ""
 	buildCRDPath = defaultCRDPath
 	buildSchemaPath = defaultSchemaPath
+	buildTSPath = ""
+	buildEmit = nil
+	buildSuggestHints = false
+	buildInMemory = false
+	buildHermetic = false
+	buildPreviousCRD = ""
+	buildPreviousTypes = ""
+	buildDepsFile = ""
+	buildNoColor = false
+	buildMaxLosses = -1
+	buildInferTypes = false
+	buildPlain = false
+	buildTypeName = schema.DefaultPlainTypeName
+	buildDeterministic = false
+	buildUmbrella = false
+	buildSubchartDir = defaultSubchartDir
+	buildReuseSchemas = false
+	buildInclude = nil
+	buildExclude = nil
+	buildStrict = string(crd.StrictOn)
+	buildDuplicateKeys = string(parsing.DuplicatesError)
+	buildTemplates = string(parsing.TemplatesWarn)
+	buildLimits = parsing.DefaultLimits
+	buildDescriptions = ""
+	buildDefaultsFile = ""
+	buildHelmCompat = false
+	buildNoProvenance = false
+	buildCheck = false
+	buildScope = ""
+	buildPlural = ""
+	buildListKind = ""
+	buildShortNames = nil
+	buildCategories = nil
+	buildWrapSpec = false
+	buildHistoryDir = ""
+	buildSupported = nil
+	buildSnapshot = ""
+	buildConfig = ""
+	buildParallel = false
+	buildNoCache = false
+	buildTitles = string(jsonschema.TitlesName)
+	buildExamples = false
+	buildSchemaKRM = false
+	buildResourcePath = ""
+	buildWorkDir = ""
+	buildOutput = outputText
 
 	// Create new command
 	cmd := &cobra.Command{
@@ -161,6 +225,55 @@ func newBuildCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&buildTypesPath, "types", "t", "", "Output path for types.go file (if empty, types.go is not preserved)")
 	cmd.Flags().StringVarP(&buildCRDPath, "crd", "c", defaultCRDPath, "Output path for CRD YAML file")
 	cmd.Flags().StringVarP(&buildSchemaPath, "schema", "s", defaultSchemaPath, "Output path for JSON Schema file")
+	cmd.Flags().StringVar(&buildTSPath, "typescript", "", "Output path for TypeScript declarations (if empty, no TypeScript is generated)")
+	cmd.Flags().StringArrayVar(&buildEmit, "emit", nil, "Additional output as target=path")
+	cmd.Flags().BoolVar(&buildSuggestHints, "suggest-hints", false, "Insert +miaka:type hints into the input file")
+	cmd.Flags().BoolVar(&buildInMemory, "in-memory", false, "Generate the CRD entirely in memory")
+	cmd.Flags().BoolVar(&buildHermetic, "hermetic", false, "Require explicit inputs and outputs")
+	cmd.Flags().StringVar(&buildPreviousCRD, "previous-crd", "", "CRD to check for breaking changes against")
+	cmd.Flags().StringVar(&buildPreviousTypes, "previous-types", "", "types.go to check for breaking changes against")
+	cmd.Flags().StringVar(&buildDepsFile, "deps-file", "", "Write the list of files read by the build")
+	cmd.Flags().BoolVar(&buildNoColor, "no-color", false, "Print plain-text status messages")
+	cmd.Flags().IntVar(&buildMaxLosses, "max-conversion-losses", -1, "Fail if more constructs are lost")
+	cmd.Flags().BoolVar(&buildInferTypes, "infer-semantic-types", false, "Infer quantities, durations, and int-or-strings")
+	cmd.Flags().BoolVar(&buildPlain, "plain", false, "Build values without apiVersion or kind")
+	cmd.Flags().StringVar(&buildTypeName, "type-name", schema.DefaultPlainTypeName, "Name of the type of plain values")
+	cmd.Flags().BoolVar(&buildDeterministic, "deterministic", false, "Strip volatile annotations and sort required fields")
+	cmd.Flags().BoolVar(&buildUmbrella, "umbrella", false, "Compose subchart schemas")
+	cmd.Flags().StringVar(&buildSubchartDir, "subchart-schemas", defaultSubchartDir, "Output directory for subchart schemas")
+	cmd.Flags().BoolVar(&buildReuseSchemas, "reuse-dependency-schemas", false, "Reuse published dependency schemas")
+	cmd.Flags().StringArrayVar(&buildInclude, "include", nil, "Only type values at matching YAML paths")
+	cmd.Flags().StringArrayVar(&buildExclude, "exclude", nil, "Make values at matching YAML paths freeform")
+	cmd.Flags().BoolVar(&buildCheck, "check", false, "Compare outputs with the files on disk")
+	cmd.Flags().BoolVar(&buildNoProvenance, "no-provenance", false, "Don't stamp outputs with their provenance")
+	cmd.Flags().StringVar(&buildStrict, "strict", string(crd.StrictOn), "Strict validation mode: true, false, or warn")
+	cmd.Flags().StringVar(&buildDuplicateKeys, "duplicate-keys", string(parsing.DuplicatesError), "Keys that a mapping has more than once: error, warn, or last-wins")
+	cmd.Flags().StringVar(&buildTemplates, "template-strings", string(parsing.TemplatesWarn), "String values with Helm template expressions: allow, warn, or error")
+	cmd.Flags().IntVar(&buildLimits.MaxDepth, "max-depth", parsing.DefaultLimits.MaxDepth, "Maximum nesting depth of the values")
+	cmd.Flags().IntVar(&buildLimits.MaxFields, "max-fields", parsing.DefaultLimits.MaxFields, "Maximum number of fields of the values")
+	cmd.Flags().IntVar(&buildLimits.MaxSize, "max-size", parsing.DefaultLimits.MaxSize, "Maximum size of the input file in bytes")
+	cmd.Flags().StringVar(&buildDescriptions, "require-descriptions", "", "Percentage of fields that must have a description, or warn")
+	cmd.Flags().StringVar(&buildDefaultsFile, "defaults", "", "File setting field defaults")
+	cmd.Flags().BoolVar(&buildHelmCompat, "helm-compat", false, "Adapt the JSON Schema to Helm")
+	cmd.Flags().Lookup("require-descriptions").NoOptDefVal = "100"
+	cmd.Flags().StringVar(&buildScope, "scope", "", "Scope of the CRD")
+	cmd.Flags().StringVar(&buildPlural, "plural", "", "Plural name of the CRD")
+	cmd.Flags().StringVar(&buildListKind, "list-kind", "", "List kind of the CRD")
+	cmd.Flags().StringSliceVar(&buildShortNames, "short-names", nil, "Short names of the CRD")
+	cmd.Flags().StringSliceVar(&buildCategories, "categories", nil, "Categories of the CRD")
+	cmd.Flags().BoolVar(&buildWrapSpec, "wrap-spec", false, "Nest the values under spec")
+	cmd.Flags().StringVar(&buildHistoryDir, "history", "", "Directory of CRD snapshots of released versions")
+	cmd.Flags().StringSliceVar(&buildSupported, "supported-versions", nil, "Versions in the history that must stay compatible")
+	cmd.Flags().StringVar(&buildSnapshot, "snapshot", "", "Save the generated CRD to the history")
+	cmd.Flags().StringVar(&buildConfig, "config", "", "Build the targets of this project config")
+	cmd.Flags().BoolVar(&buildParallel, "parallel", false, "Generate the outputs of all targets in parallel")
+	cmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Ignore the build cache")
+	cmd.Flags().StringVar(&buildTitles, "schema-titles", string(jsonschema.TitlesName), "Titles of the JSON Schema's properties")
+	cmd.Flags().BoolVar(&buildExamples, "schema-examples", false, "Add the example values to the JSON Schema as examples")
+	cmd.Flags().BoolVar(&buildSchemaKRM, "schema-krm", false, "Keep apiVersion, kind, and metadata in the JSON Schema")
+	cmd.Flags().StringVar(&buildResourcePath, "resource-schema", "", "Output path for a JSON Schema of the custom resource")
+	cmd.Flags().StringVar(&buildWorkDir, "workdir", "", "Directory for intermediate files")
+	cmd.Flags().StringVarP(&buildOutput, "output", "o", outputText, "Output format: text or json")
 
 	return cmd
 }
@@ -475,13 +588,33 @@ func compareFiles(t *testing.T, fileType, generatedPath, expectedPath string) {
 	expectedStr := normalizeOutput(string(expected))
 
 	if generatedStr != expectedStr {
-		t.Errorf("%s mismatch:\n\nFirst difference:\n%s",
-			fileType,
-			findFirstDifference(expectedStr, generatedStr),
-		)
+		t.Errorf("%s mismatch:\n\n%s", fileType, describeDifference(expectedStr, generatedStr))
 	}
 }
 
+// describeDifference lists the structural differences between two JSON or YAML
+// documents, or shows the first differing line if they only differ in
+// formatting or aren't objects (e.g., Go source)
+func describeDifference(expected, generated string) string {
+	expectedValue, expectedErr := structdiff.Parse([]byte(expected))
+	generatedValue, generatedErr := structdiff.Parse([]byte(generated))
+	_, expectedObject := expectedValue.(map[string]interface{})
+	_, generatedObject := generatedValue.(map[string]interface{})
+	var changes []structdiff.Change
+	if expectedErr == nil && generatedErr == nil && expectedObject && generatedObject {
+		changes = structdiff.Compare(expectedValue, generatedValue)
+	}
+	if len(changes) == 0 {
+		return "First difference:\n" + findFirstDifference(expected, generated)
+	}
+	var sb strings.Builder
+	sb.WriteString("Differences from expected to generated:\n")
+	for _, change := range changes {
+		sb.WriteString("  - " + change.String() + "\n")
+	}
+	return sb.String()
+}
+
 // normalizeOutput normalizes whitespace and line endings for comparison
 func normalizeOutput(s string) string {
 	// Normalize line endings
@@ -490,6 +623,9 @@ func normalizeOutput(s string) string {
 	// Normalize controller-gen version annotation (non-deterministic)
 	s = normalizeControllerGenVersion(s)
 
+	// Remove provenance, which depends on the time and the input file hash
+	s = removeProvenance(s)
+
 	// Trim trailing whitespace from each line
 	lines := strings.Split(s, "\n")
 	for i, line := range lines {
@@ -513,6 +649,14 @@ func normalizeControllerGenVersion(s string) string {
 	return strings.Join(lines, "\n")
 }
 
+// provenancePattern matches the provenance stamped on CRDs and JSON Schemas
+var provenancePattern = regexp.MustCompile(`(?m)^ *miaka\.dev/(version|input-sha256|generated-at): .*\n|,\n *"x-miaka-provenance": \{[^}]*\}`)
+
+// removeProvenance removes the provenance stamped on CRDs and JSON Schemas
+func removeProvenance(s string) string {
+	return provenancePattern.ReplaceAllString(s, "")
+}
+
 // findFirstDifference finds and formats the first difference between two strings
 func findFirstDifference(expected, generated string) string {
 	expLines := strings.Split(expected, "\n")
@@ -689,10 +833,10 @@ appName: "myapp"
 		t.Errorf("Expected 'AppName' field in types.go, got:\n%s", contentStr)
 	}
 
-	// Verify success message mentions the file (it prints to stdout via fmt.Printf, not cmd.OutOrStdout)
-	// Since we're calling runBuild directly through cobra, check the actual console output isn't captured
-	// Instead, let's just verify the file was created successfully
-	t.Logf("Types file successfully created at: %s", typesOutput)
+	// Verify success message mentions the file
+	if want := "✓ Types saved to " + typesOutput + "\n"; !strings.Contains(outBuf.String(), want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, outBuf.String())
+	}
 }
 
 // TestBuildCommand_InvalidApiVersion tests error handling for invalid apiVersion format
@@ -810,3 +954,2029 @@ replicas: "3"
 		t.Error("CRD was not restored to original after breaking change detection")
 	}
 }
+
+// TestBuildCommand_TypeScript tests that --typescript writes a declarations file
+func TestBuildCommand_TypeScript(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	tsOutput := filepath.Join(tmpDir, "values.d.ts")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "schema.json")
+
+	validYAML := `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+replicas: 3
+service:
+  port: 80
+`
+	if err := os.WriteFile(inputPath, []byte(validYAML), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath,
+		"--typescript", tsOutput,
+		"-c", crdOutput,
+		"-s", schemaOutput,
+	})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tsOutput)
+	if err != nil {
+		t.Fatalf("Failed to read TypeScript output: %v", err)
+	}
+
+	contentStr := string(content)
+	if !strings.Contains(contentStr, "export interface Example {") {
+		t.Errorf("Expected 'export interface Example' in values.d.ts, got:\n%s", contentStr)
+	}
+	if !strings.Contains(contentStr, "service?: ServiceConfig;") {
+		t.Errorf("Expected 'service?: ServiceConfig;' in values.d.ts, got:\n%s", contentStr)
+	}
+}
+
+// TestBuildCommand_Emit tests that --emit writes registered output targets
+func TestBuildCommand_Emit(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "schema.json")
+	tsOutput := filepath.Join(tmpDir, "web", "values.d.ts")
+	templateOutput := filepath.Join(tmpDir, "templates", "_schema.yaml")
+
+	validYAML := `apiVersion: example.com/v1
+kind: Example
+replicas: 3
+`
+	if err := os.WriteFile(inputPath, []byte(validYAML), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath,
+		"--emit", "typescript=" + tsOutput,
+		"--emit", "helmtemplate=" + templateOutput,
+		"-c", crdOutput,
+		"-s", schemaOutput,
+	})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	if _, err := os.Stat(tsOutput); err != nil {
+		t.Errorf("Expected TypeScript output at %s: %v", tsOutput, err)
+	}
+	template, err := os.ReadFile(templateOutput)
+	if err != nil {
+		t.Fatalf("Expected Helm template output at %s: %v", templateOutput, err)
+	}
+	if !strings.Contains(string(template), `define "miaka.example.validate"`) || !strings.Contains(string(template), `"replicas"`) {
+		t.Errorf("Unexpected Helm template output:\n%s", template)
+	}
+
+	// Unknown targets are rejected
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath,
+		"--emit", "protobuf=values.proto",
+		"-c", crdOutput,
+		"-s", schemaOutput,
+	})
+
+	err = cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error for unknown output target")
+	}
+	if !strings.Contains(err.Error(), `unknown output target "protobuf"`) {
+		t.Errorf("Expected unknown target error, got: %v", err)
+	}
+}
+
+// TestBuildCommand_EmitDeepCopy tests generating DeepCopy functions for the Go types
+func TestBuildCommand_EmitDeepCopy(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	deepCopyOutput := filepath.Join(tmpDir, "api", "zz_generated.deepcopy.go")
+
+	validYAML := `apiVersion: example.com/v1
+kind: Example
+tags:
+  - a
+`
+	if err := os.WriteFile(inputPath, []byte(validYAML), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath,
+		"--in-memory",
+		"--emit", "deepcopy=" + deepCopyOutput,
+		"-c", filepath.Join(tmpDir, "crd.yaml"),
+		"-s", filepath.Join(tmpDir, "schema.json"),
+	})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(deepCopyOutput)
+	if err != nil {
+		t.Fatalf("Expected DeepCopy output at %s: %v", deepCopyOutput, err)
+	}
+	for _, want := range []string{"func (in *Example) DeepCopyObject() runtime.Object", "copy(*out, *in)"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected DeepCopy output to contain %q:\n%s", want, content)
+		}
+	}
+}
+
+// TestBuildCommand_SuggestHints tests that --suggest-hints fixes interface{} fields in place
+func TestBuildCommand_SuggestHints(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "schema.json")
+
+	inputYAML := `apiVersion: example.com/v1
+kind: Example
+# Annotations for the pod
+podAnnotations:
+replicas: 1
+`
+	if err := os.WriteFile(inputPath, []byte(inputYAML), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// Without the flag, the build fails and suggests it
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "-c", crdOutput, "-s", schemaOutput})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected interface{} validation error but command succeeded")
+	}
+	if !strings.Contains(err.Error(), "--suggest-hints") {
+		t.Errorf("Expected error to mention --suggest-hints, got: %v", err)
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--suggest-hints", "-c", crdOutput, "-s", schemaOutput})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	content, err := os.ReadFile(inputPath)
+	if err != nil {
+		t.Fatalf("Failed to read input file: %v", err)
+	}
+	expected := "# Annotations for the pod\n# +miaka:type: map[string]string\npodAnnotations: {}\n"
+	if !strings.Contains(string(content), expected) {
+		t.Errorf("Expected type hint in input file, got:\n%s", content)
+	}
+}
+
+func TestBuildCommand_InMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "schema.json")
+
+	// Point TMPDIR at a missing directory so any temp usage fails
+	t.Setenv("TMPDIR", filepath.Join(tmpDir, "missing"))
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{
+		filepath.Join("..", "testdata", "build", "comprehensive", "input.yaml"),
+		"--in-memory",
+		"-c", crdOutput,
+		"-s", schemaOutput,
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	compareFiles(t, "crd.yaml", crdOutput, filepath.Join("..", "testdata", "build", "comprehensive", "expected_crd.yaml"))
+	compareFiles(t, "schema.json", schemaOutput, filepath.Join("..", "testdata", "build", "comprehensive", "expected_schema.json"))
+}
+
+// TestBuildCommand_Deterministic tests that both CRD backends give byte-identical output
+func TestBuildCommand_Deterministic(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join("..", "testdata", "build", "comprehensive", "input.yaml")
+
+	var outputs [][]byte
+	for _, extra := range [][]string{nil, {"--in-memory"}} {
+		dir := filepath.Join(tmpDir, strconv.Itoa(len(outputs)))
+		crdOutput := filepath.Join(dir, "crd.yaml")
+
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{input, "--deterministic", "-c", crdOutput, "-s", filepath.Join(dir, "schema.json")}, extra...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Build command failed: %v", err)
+		}
+
+		content, err := os.ReadFile(crdOutput)
+		if err != nil {
+			t.Fatalf("Failed to read CRD: %v", err)
+		}
+		if strings.Contains(string(content), "controller-gen.kubebuilder.io/version") {
+			t.Errorf("Expected no controller-gen version annotation, got:\n%s", content)
+		}
+		outputs = append(outputs, content)
+	}
+
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("Expected identical CRDs, got:\n%s\nand:\n%s", outputs[0], outputs[1])
+	}
+}
+
+// TestBuildCommand_PathFilters tests that excluded values are freeform in the CRD and JSON Schema
+func TestBuildCommand_PathFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+	input := `apiVersion: example.com/v1
+kind: Example
+controller:
+  replicas: 1
+  affinity:
+    nodeAffinity:
+      required: true
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--exclude", "controller.affinity.**", "-c", crdOutput, "-s", schemaOutput})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	crdContent, err := os.ReadFile(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to read CRD: %v", err)
+	}
+	if !strings.Contains(string(crdContent), "affinity:\n                x-kubernetes-preserve-unknown-fields: true") {
+		t.Errorf("Expected affinity to preserve unknown fields, got:\n%s", crdContent)
+	}
+	if strings.Contains(string(crdContent), "nodeAffinity") {
+		t.Errorf("Expected no schema for the excluded values, got:\n%s", crdContent)
+	}
+
+	// Any affinity is valid, while the rest of the values are still validated
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("controller:\n  affinity:\n    anything: [1, 2]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateYAML(valuesPath, schemaOutput); err != nil {
+		t.Errorf("Expected freeform values to be valid, got %v", err)
+	}
+	if err := os.WriteFile(valuesPath, []byte("controller:\n  replicas: many\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateYAML(valuesPath, schemaOutput); err == nil {
+		t.Error("Expected typed values to still be validated")
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--exclude", "controller..affinity"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), "invalid path pattern") {
+		t.Errorf("Expected an invalid path pattern error, got %v", err)
+	}
+}
+
+func TestBuildCommand_PreserveUnknownFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+	input := `apiVersion: example.com/v1
+kind: Example
+# +miaka:preserveUnknownFields
+open: {}
+closed: {}
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdOutput, "-s", schemaOutput})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	crdContent, err := os.ReadFile(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to read CRD: %v", err)
+	}
+	if !strings.Contains(string(crdContent), "type: object\n            x-kubernetes-preserve-unknown-fields: true") {
+		t.Errorf("Expected open to preserve unknown fields, got:\n%s", crdContent)
+	}
+
+	// Unknown fields are allowed in the open object, but not in the strict one
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("open:\n  anything: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateYAML(valuesPath, schemaOutput); err != nil {
+		t.Errorf("Expected unknown fields to be allowed, got %v", err)
+	}
+	if err := os.WriteFile(valuesPath, []byte("closed:\n  anything: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateYAML(valuesPath, schemaOutput); err == nil {
+		t.Error("Expected unknown fields to be rejected by strict validation")
+	}
+}
+
+func TestBuildCommand_CRDMarkers(t *testing.T) {
+	input := `# +miaka:crd:subresource:status
+# +miaka:crd:printcolumn:name=Replicas,path=.replicas,type=integer
+apiVersion: example.com/v1
+kind: App
+replicas: 1
+`
+	// Both CRD backends must produce the same subresources and columns
+	for _, args := range [][]string{nil, {"--in-memory"}} {
+		tmpDir := t.TempDir()
+		inputPath := filepath.Join(tmpDir, "example.yaml")
+		crdOutput := filepath.Join(tmpDir, "crd.yaml")
+		if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "-c", crdOutput, "-s", filepath.Join(tmpDir, "values.schema.json")}, args...))
+		if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+			t.Fatalf("Build command %v failed: %v", args, err)
+		}
+
+		crdContent, err := os.ReadFile(crdOutput)
+		if err != nil {
+			t.Fatalf("Failed to read CRD: %v", err)
+		}
+		for _, want := range []string{
+			"additionalPrinterColumns:\n    - jsonPath: .replicas\n      name: Replicas\n      type: integer",
+			"subresources:\n      status: {}",
+		} {
+			if !strings.Contains(string(crdContent), want) {
+				t.Errorf("Build %v: expected CRD to contain %q, got:\n%s", args, want, crdContent)
+			}
+		}
+	}
+}
+
+func TestBuildCommand_Resource(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+	input := `# +miaka:crd:resource:shortName=app
+apiVersion: example.com/v1
+kind: App
+replicas: 1
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdOutput, "-s", schemaOutput,
+		"--scope", "Cluster", "--plural", "applications", "--list-kind", "AppCollection", "--categories", "all,apps"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	crdContent, err := os.ReadFile(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to read CRD: %v", err)
+	}
+	for _, want := range []string{
+		"name: applications.example.com",
+		"categories:\n    - all\n    - apps",
+		"listKind: AppCollection",
+		"plural: applications",
+		"shortNames:\n    - app",
+		"scope: Cluster",
+	} {
+		if !strings.Contains(string(crdContent), want) {
+			t.Errorf("Expected CRD to contain %q, got:\n%s", want, crdContent)
+		}
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdOutput, "-s", schemaOutput, "--short-names", "App"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), `invalid short name "App"`) {
+		t.Errorf("Expected invalid short name error, got %v", err)
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--plain", "-s", schemaOutput, "--scope", "Cluster"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), "--scope can't be used with --plain") {
+		t.Errorf("Expected --scope to be rejected with --plain, got %v", err)
+	}
+}
+
+func TestBuildCommand_WrapSpec(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+	typesOutput := filepath.Join(tmpDir, "types.go")
+	input := `apiVersion: example.com/v1
+kind: App
+# +kubebuilder:validation:Minimum=1
+replicas: 1
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--wrap-spec", "-c", crdOutput, "-s", schemaOutput, "-t", typesOutput})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build command failed: %v", err)
+	}
+
+	typesContent, err := os.ReadFile(typesOutput)
+	if err != nil {
+		t.Fatalf("Failed to read types: %v", err)
+	}
+	if !strings.Contains(string(typesContent), "type AppSpec struct") || !strings.Contains(string(typesContent), "type AppStatus struct") {
+		t.Errorf("Expected AppSpec and AppStatus types, got:\n%s", typesContent)
+	}
+
+	crdDef, err := loadCRD(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to load CRD: %v", err)
+	}
+	root := crdDef.Spec.Versions[0].Schema.OpenAPIV3Schema
+	if _, ok := root.Properties["spec"].Properties["replicas"]; !ok {
+		t.Errorf("Expected replicas under spec in the CRD, got %v", root.Properties)
+	}
+	if _, ok := root.Properties["status"]; !ok {
+		t.Errorf("Expected status in the CRD, got %v", root.Properties)
+	}
+
+	// The JSON Schema describes the values themselves, and both schemas validate them
+	schemaContent, err := os.ReadFile(schemaOutput)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if strings.Contains(string(schemaContent), `"spec"`) || !strings.Contains(string(schemaContent), `"replicas"`) {
+		t.Errorf("Expected the JSON Schema to describe the values, got:\n%s", schemaContent)
+	}
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("apiVersion: example.com/v1\nkind: App\nreplicas: 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateAgainstCRD(crdOutput, valuesPath); err == nil || !strings.Contains(err.Error(), "spec.replicas") {
+		t.Errorf("Expected the CRD to reject replicas: 0, got %v", err)
+	}
+	if err := validation.ValidateYAML(valuesPath, schemaOutput); err == nil {
+		t.Error("Expected the JSON Schema to reject replicas: 0")
+	}
+}
+
+func TestBuildCommand_Strict(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := `apiVersion: example.com/v1
+kind: Example
+extra: {}
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	values := "apiVersion: example.com/v1\nkind: Example\nextra:\n  anything: 1\n"
+	if err := os.WriteFile(valuesPath, []byte(values), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	t.Cleanup(func() {
+		validateCRDPath = defaultCRDPath
+		validateSchemaPath = defaultSchemaPath
+	})
+
+	tests := []struct {
+		mode       string
+		wantValid  bool
+		wantWarned bool
+	}{
+		{mode: "true", wantValid: false},
+		{mode: "false", wantValid: true},
+		{mode: "warn", wantValid: true, wantWarned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			validateCRDPath = filepath.Join(tmpDir, tt.mode+".crd.yaml")
+			validateSchemaPath = filepath.Join(tmpDir, tt.mode+".schema.json")
+			cmd := newBuildCommand()
+			cmd.SetArgs([]string{inputPath, "--in-memory", "--strict", tt.mode, "-c", validateCRDPath, "-s", validateSchemaPath})
+			if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+				t.Fatalf("Build command failed: %v", err)
+			}
+
+			stdout, _, err := captureStdoutStderr(t, func() error {
+				return runValidate(nil, []string{valuesPath})
+			})
+			if valid := err == nil; valid != tt.wantValid {
+				t.Errorf("valid = %v, want %v; output:\n%s", valid, tt.wantValid, stdout)
+			}
+			warned := strings.Contains(stdout, valuesPath+":4:3: extra.anything: Forbidden: unknown field")
+			if warned != tt.wantWarned {
+				t.Errorf("warned = %v, want %v; output:\n%s", warned, tt.wantWarned, stdout)
+			}
+		})
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--strict", "maybe"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), "invalid strict validation mode") {
+		t.Errorf("Expected an invalid strict validation mode error, got %v", err)
+	}
+}
+
+// captureStdoutStderr runs fn and returns what it wrote to stdout and stderr
+func captureStdoutStderr(t *testing.T, fn func() error) (stdout, stderr string, err error) {
+	t.Helper()
+	oldOut, oldErr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	err = fn()
+
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldOut, oldErr
+
+	var outBuf, errBuf bytes.Buffer
+	io.Copy(&outBuf, outR)
+	io.Copy(&errBuf, errR)
+	return outBuf.String(), errBuf.String(), err
+}
+
+// TestBuildCommand_Hermetic tests the explicit-inputs contract used by hermetic build systems
+func TestBuildCommand_Hermetic(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdOutput := filepath.Join(tmpDir, "out", "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "out", "values.schema.json")
+	depsFile := filepath.Join(tmpDir, "out", "build.d")
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	// A stale CRD at the output path is not a declared input, so it must not be read
+	if err := os.MkdirAll(filepath.Dir(crdOutput), 0755); err != nil {
+		t.Fatalf("Failed to create output directory: %v", err)
+	}
+	if err := os.WriteFile(crdOutput, []byte("not: [a crd"), 0644); err != nil {
+		t.Fatalf("Failed to write stale CRD: %v", err)
+	}
+
+	// Point TMPDIR at a missing directory since --hermetic implies --in-memory
+	t.Setenv("TMPDIR", filepath.Join(tmpDir, "missing"))
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--hermetic", "-c", crdOutput, "-s", schemaOutput, "--deps-file", depsFile})
+	stdout, stderr, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Hermetic build failed: %v\nStderr: %s", err, stderr)
+	}
+
+	if stdout != "" {
+		t.Errorf("Expected no stdout in hermetic mode, got: %s", stdout)
+	}
+	if !strings.Contains(stderr, "CRD generated") {
+		t.Errorf("Expected progress on stderr, got: %s", stderr)
+	}
+	if strings.Contains(stderr, "Next steps") {
+		t.Errorf("Expected no next steps in hermetic mode, got: %s", stderr)
+	}
+
+	deps, err := os.ReadFile(depsFile)
+	if err != nil {
+		t.Fatalf("Failed to read deps file: %v", err)
+	}
+	if string(deps) != inputPath+"\n" {
+		t.Errorf("Expected deps file to list only the input, got: %q", string(deps))
+	}
+}
+
+// TestBuildCommand_HermeticPreviousCRD tests breaking change detection against an explicit previous CRD
+func TestBuildCommand_HermeticPreviousCRD(t *testing.T) {
+	tmpDir := t.TempDir()
+	previousCRD := filepath.Join(tmpDir, "previous.yaml")
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	depsFile := filepath.Join(tmpDir, "build.d")
+
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "-c", previousCRD, "-s", filepath.Join(tmpDir, "previous.json")})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Initial build failed: %v", err)
+	}
+
+	// Changing replicas to a string is a breaking change
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: \"3\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath, "--hermetic",
+		"-c", filepath.Join(tmpDir, "crd.yaml"),
+		"-s", filepath.Join(tmpDir, "values.schema.json"),
+		"--previous-crd", previousCRD,
+		"--deps-file", depsFile,
+	})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), "breaking changes detected") {
+		t.Fatalf("Expected breaking change error, got: %v", err)
+	}
+
+	// A compatible change succeeds and lists the previous CRD as a dependency
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\nname: app\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{
+		inputPath, "--hermetic",
+		"-c", filepath.Join(tmpDir, "crd.yaml"),
+		"-s", filepath.Join(tmpDir, "values.schema.json"),
+		"--previous-crd", previousCRD,
+		"--deps-file", depsFile,
+	})
+	if _, stderr, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Compatible build failed: %v\nStderr: %s", err, stderr)
+	}
+	deps, err := os.ReadFile(depsFile)
+	if err != nil {
+		t.Fatalf("Failed to read deps file: %v", err)
+	}
+	expected := []string{inputPath, previousCRD}
+	sort.Strings(expected)
+	if string(deps) != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("Unexpected deps file: %q", string(deps))
+	}
+}
+
+// TestBuildCommand_HermeticRequiresExplicitPaths tests that hermetic builds have no implicit paths
+func TestBuildCommand_HermeticRequiresExplicitPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no input", []string{"--hermetic", "-c", "crd.yaml", "-s", "schema.json"}, "requires the input file"},
+		{"no crd", []string{"in.yaml", "--hermetic", "-s", "schema.json"}, "requires --crd"},
+		{"no schema", []string{"in.yaml", "--hermetic", "-c", "crd.yaml"}, "requires --schema"},
+		{"suggest hints", []string{"in.yaml", "--hermetic", "-c", "crd.yaml", "-s", "schema.json", "--suggest-hints"}, "can't be used with --suggest-hints"},
+		{"snapshot", []string{"in.yaml", "--hermetic", "-c", "crd.yaml", "-s", "schema.json", "--snapshot", "1.0.0"}, "requires --history"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newBuildCommand()
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestBuildCommand_NoColor tests that --no-color removes emoji from status messages
+func TestBuildCommand_NoColor(t *testing.T) {
+	tmpDir := t.TempDir()
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{
+		filepath.Join("..", "testdata", "build", "minimal", "input.yaml"),
+		"--no-color",
+		"-c", filepath.Join(tmpDir, "crd.yaml"),
+		"-s", filepath.Join(tmpDir, "values.schema.json"),
+	})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(stdout, "ok: CRD generated") {
+		t.Errorf("Expected plain status messages, got: %s", stdout)
+	}
+	for _, emoji := range []string{"✓", "🎉", "📝"} {
+		if strings.Contains(stdout, emoji) {
+			t.Errorf("Expected no %s in output, got: %s", emoji, stdout)
+		}
+	}
+}
+
+func TestBuildCommand_ConversionLosses(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	input := `apiVersion: test.io/v1
+kind: Test
+# +kubebuilder:validation:XValidation:rule="self >= 1",message="must be positive"
+replicas: 3
+# +kubebuilder:validation:Format=int32
+port: "8080"
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, want := range []string{
+		"JSON Schema can't represent 2 construct(s)",
+		`  - replicas: CEL rule "self >= 1" is not validated (must be positive)`,
+		`  - port: format "int32" is not validated`,
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in output, got: %s", want, stdout)
+		}
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--max-conversion-losses", "1", "-c", filepath.Join(tmpDir, "crd2.yaml"), "-s", filepath.Join(tmpDir, "values2.schema.json")})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), "more than --max-conversion-losses=1") {
+		t.Errorf("Expected conversion loss budget error, got: %v", err)
+	}
+}
+
+// TestBuildCommand_ItemMarkers tests that +miaka:items: markers reach list items and map values in both schemas
+func TestBuildCommand_ItemMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	input := `apiVersion: test.io/v1
+kind: Test
+# +miaka:items:kubebuilder:validation:MinLength=1
+tags:
+- a
+# +miaka:type:map[string]string
+# +miaka:items:kubebuilder:validation:MaxLength=63
+labels: {}
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdOutput, "-s", schemaOutput})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	props, err := validation.LoadCRDSchema(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to load CRD: %v", err)
+	}
+	if tags := props.Properties["tags"]; tags.Items == nil || tags.Items.Schema.MinLength == nil || *tags.Items.Schema.MinLength != 1 {
+		t.Errorf("Expected CRD tags items to have minLength 1, got: %+v", tags.Items)
+	}
+	if labels := props.Properties["labels"]; labels.AdditionalProperties == nil || labels.AdditionalProperties.Schema.MaxLength == nil || *labels.AdditionalProperties.Schema.MaxLength != 63 {
+		t.Errorf("Expected CRD labels values to have maxLength 63, got: %+v", labels.AdditionalProperties)
+	}
+
+	schemaContent, err := os.ReadFile(schemaOutput)
+	if err != nil {
+		t.Fatalf("Failed to read JSON Schema: %v", err)
+	}
+	for _, want := range []string{
+		"\"additionalProperties\": {\n        \"maxLength\": 63,",
+		"\"items\": {\n        \"minLength\": 1,",
+	} {
+		if !strings.Contains(string(schemaContent), want) {
+			t.Errorf("Expected %q in JSON Schema, got:\n%s", want, schemaContent)
+		}
+	}
+}
+
+// TestBuildCommand_Nullable tests that nullable fields become pointers and accept null in both schemas
+func TestBuildCommand_Nullable(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	input := `apiVersion: test.io/v1
+kind: Test
+# +miaka:nullable
+automountServiceAccountToken: false
+# +miaka:type: int
+replicas: null
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+	typesOutput := filepath.Join(tmpDir, "types.go")
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdOutput, "-s", schemaOutput, "-t", typesOutput})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	types, err := os.ReadFile(typesOutput)
+	if err != nil {
+		t.Fatalf("Failed to read types.go: %v", err)
+	}
+	for _, want := range []string{"*bool", "*int"} {
+		if !strings.Contains(string(types), want) {
+			t.Errorf("Expected %q in types.go, got:\n%s", want, types)
+		}
+	}
+
+	props, err := validation.LoadCRDSchema(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to load CRD: %v", err)
+	}
+	for _, name := range []string{"automountServiceAccountToken", "replicas"} {
+		if !props.Properties[name].Nullable {
+			t.Errorf("Expected %s to be nullable in the CRD", name)
+		}
+	}
+
+	schemaContent, err := os.ReadFile(schemaOutput)
+	if err != nil {
+		t.Fatalf("Failed to read JSON Schema: %v", err)
+	}
+	values := map[string]interface{}{"automountServiceAccountToken": nil, "replicas": nil}
+	if problems, err := validation.SchemaProblems(values, schemaContent, nil); err != nil || len(problems) > 0 {
+		t.Errorf("Expected null values to be valid, got problems %v, error %v", problems, err)
+	}
+}
+
+func TestBuildCommand_InferSemanticTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	input := `apiVersion: test.io/v1
+kind: Test
+memory: 512Mi
+timeout: 30s
+maxUnavailable: 25%
+name: server
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	crdOutput := filepath.Join(tmpDir, "crd.yaml")
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--infer-semantic-types", "-c", crdOutput, "-s", filepath.Join(tmpDir, "values.schema.json")})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	props, err := validation.LoadCRDSchema(crdOutput)
+	if err != nil {
+		t.Fatalf("Failed to load CRD: %v", err)
+	}
+	for _, name := range []string{"memory", "maxUnavailable"} {
+		if !props.Properties[name].XIntOrString {
+			t.Errorf("Expected %s to be int-or-string in the CRD", name)
+		}
+	}
+	if props.Properties["timeout"].Pattern != schema.DurationPattern {
+		t.Errorf("Expected timeout to have the duration pattern, got %q", props.Properties["timeout"].Pattern)
+	}
+	if name := props.Properties["name"]; name.Type != "string" || name.Pattern != "" {
+		t.Errorf("Expected name to be a plain string, got %+v", name)
+	}
+}
+
+// TestBuildCommand_Plain tests building values without apiVersion or kind
+func TestBuildCommand_Plain(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "values.yaml")
+	input := `# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicaCount: 1
+image:
+  repository: nginx
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	typesOutput := filepath.Join(tmpDir, "types.go")
+	schemaOutput := filepath.Join(tmpDir, "values.schema.json")
+
+	// Run from tmpDir, so a CRD written to the default path would show up there
+	t.Chdir(tmpDir)
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--plain", "--type-name", "ChartValues", "--in-memory", "-t", typesOutput, "-s", schemaOutput})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Build failed: %v\n%s", err, stdout)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, defaultCRDPath)); !os.IsNotExist(err) {
+		t.Errorf("Expected no CRD to be written, got %v", err)
+	}
+	if !strings.Contains(stdout, "miaka build --plain") {
+		t.Errorf("Expected next steps for plain builds, got:\n%s", stdout)
+	}
+
+	types, err := os.ReadFile(typesOutput)
+	if err != nil {
+		t.Fatalf("Failed to read types: %v", err)
+	}
+	for _, want := range []string{"package values", "type ChartValues struct {", "ReplicaCount int"} {
+		if !strings.Contains(string(types), want) {
+			t.Errorf("Expected types to contain %q, got:\n%s", want, types)
+		}
+	}
+	if strings.Contains(string(types), "TypeMeta") {
+		t.Errorf("Expected plain types without TypeMeta, got:\n%s", types)
+	}
+
+	schemaJSON, err := os.ReadFile(schemaOutput)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	for _, unwanted := range []string{`"apiVersion"`, `"kind"`, `"metadata"`} {
+		if strings.Contains(string(schemaJSON), unwanted) {
+			t.Errorf("Expected the schema not to contain %s, got:\n%s", unwanted, schemaJSON)
+		}
+	}
+
+	// Values that break the schema's rules are still rejected
+	if err := validation.ValidateValues(map[string]interface{}{"replicaCount": 0}, schemaJSON); err == nil {
+		t.Error("Expected replicaCount: 0 to fail validation")
+	}
+}
+
+// TestBuildCommand_PlainErrors tests flags that don't work with plain builds
+func TestBuildCommand_PlainErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(inputPath, []byte("replicaCount: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "type name without plain", args: []string{"--type-name", "Values"}, err: "--type-name requires --plain"},
+		{name: "CRD", args: []string{"--plain", "-c", "crd.yaml"}, err: "--crd can't be used with --plain"},
+		{name: "previous CRD", args: []string{"--plain", "--previous-crd", "crd.yaml"}, err: "--previous-crd can't be used with --plain"},
+		{name: "emit CRD", args: []string{"--plain", "--emit", "crd=crd.yaml"}, err: "--emit crd can't be used with --plain"},
+		{name: "snapshot", args: []string{"--plain", "--snapshot", "1.0.0"}, err: "--snapshot can't be used with --plain"},
+		{name: "invalid type name", args: []string{"--plain", "--type-name", "values"}, err: `invalid --type-name "values"`},
+		{name: "no plain", args: nil, err: "has no apiVersion or kind (add them with 'miaka init', or use --plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newBuildCommand()
+			cmd.SetArgs(append([]string{inputPath, "--in-memory", "-s", filepath.Join(tmpDir, "values.schema.json")}, tt.args...))
+			_, _, err := captureStdoutStderr(t, cmd.Execute)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// BenchmarkGenerate compares generating all outputs one at a time with prefetching them concurrently
+func BenchmarkGenerate(b *testing.B) {
+	s, err := parsing.NewParser().ParseFile(filepath.Join("..", "testdata", "build", "argo-events", "input.yaml"))
+	if err != nil {
+		b.Fatalf("Failed to parse input: %v", err)
+	}
+	newBuildCommand()
+	buildInMemory = true
+	targets := []string{gotypes.TargetName, typescript.TargetName, crd.TargetName, jsonschema.TargetName}
+
+	b.Run("sequential", func(b *testing.B) {
+		for b.Loop() {
+			registry, err := newEmitterRegistry()
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, target := range targets {
+				if _, err := registry.Emit(context.Background(), target, *s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("concurrent", func(b *testing.B) {
+		for b.Loop() {
+			registry, err := newEmitterRegistry()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := registry.Prefetch(context.Background(), *s, targets...); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestBuildCommand_LabelKeyWarnings tests that keys like kubernetes.io/arch that
+// become struct fields are reported
+func TestBuildCommand_LabelKeyWarnings(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "input.yaml")
+	input := `apiVersion: test.io/v1
+kind: Test
+nodeSelector:
+  kubernetes.io/arch: amd64
+affinity:
+  kubernetes.io/hostname:
+    weight: 1
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := inputPath + `: line 7: key "kubernetes.io/hostname" becomes the Go field KubernetesIoHostname`
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+	if strings.Contains(stdout, "kubernetes.io/arch") {
+		t.Errorf("Expected no warning for the node selector, which is a map, got:\n%s", stdout)
+	}
+}
+
+// TestBuildCommand_PreviousTypes tests that --previous-types fails on breaking
+// changes to the Go types, even if the CRD is compatible
+func TestBuildCommand_ExampleBreaksMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	input := `apiVersion: example.com/v1
+kind: Example
+# +kubebuilder:validation:Minimum=1
+replicas: 0
+containers:
+  - name: app
+    # +kubebuilder:validation:Enum=Always;Never
+    pullPolicy: Sometimes
+`
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdPath, "-s", filepath.Join(tmpDir, "values.schema.json")})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err == nil {
+		t.Fatal("Expected the example values to break their markers")
+	}
+	for _, want := range []string{
+		inputPath + " breaks its own markers:",
+		inputPath + ":4:1: replicas: Invalid value: 0: replicas in body should be greater than or equal to 1 (+kubebuilder:validation:Minimum=1)",
+		inputPath + `:8:5: containers.0.pullPolicy: Unsupported value: "Sometimes": supported values: "Always", "Never" (+kubebuilder:validation:Enum=Always;Never)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in error, got:\n%v", want, err)
+		}
+	}
+
+	// Nothing is written
+	if _, err := os.Stat(crdPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no CRD to be written, got: %v", err)
+	}
+}
+
+func TestBuildCommand_RenamedFrom(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	build := func(input string) error {
+		t.Helper()
+		if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdPath, "-s", schemaPath})
+		_, _, err := captureStdoutStderr(t, cmd.Execute)
+		return err
+	}
+
+	if err := build("apiVersion: example.com/v1\nkind: Example\nimage:\n  repo: nginx\n"); err != nil {
+		t.Fatalf("Initial build failed: %v", err)
+	}
+
+	// Renaming a field removes it from the CRD, unless the rename is declared
+	if err := build("apiVersion: example.com/v1\nkind: Example\nimage:\n  repository: nginx\n"); err == nil {
+		t.Fatal("Expected breaking change error for the removed field")
+	}
+	if err := build("apiVersion: example.com/v1\nkind: Example\nimage:\n  # +miaka:renamedFrom: repo\n  repository: nginx\n"); err != nil {
+		t.Fatalf("Build with a declared rename failed: %v", err)
+	}
+
+	// Values using the old key stay valid against the JSON Schema
+	oldValues := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(oldValues, []byte("image:\n  repo: nginx\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	if err := validation.ValidateYAML(oldValues, schemaPath); err != nil {
+		t.Errorf("Expected values with the old key to be valid: %v", err)
+	}
+	content, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read JSON Schema: %v", err)
+	}
+	if !strings.Contains(string(content), `"description": "Deprecated: renamed to repository."`) {
+		t.Errorf("Expected a deprecated alias in the JSON Schema, got:\n%s", content)
+	}
+}
+
+func TestBuildCommand_PreviousTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	typesPath := filepath.Join(tmpDir, "types.go")
+	depsFile := filepath.Join(tmpDir, "build.d")
+	build := func(input string) (string, error) {
+		t.Helper()
+		if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs([]string{inputPath, "--in-memory", "-t", typesPath, "--previous-types", typesPath, "--deps-file", depsFile,
+			"-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")})
+		stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout, err
+	}
+
+	if _, err := build("apiVersion: example.com/v1\nkind: Example\nimage:\n  tag: v1\n"); err != nil {
+		t.Fatalf("Initial build failed: %v", err)
+	}
+
+	// A new field is compatible, and the previous types are a dependency
+	stdout, err := build("apiVersion: example.com/v1\nkind: Example\nimage:\n  tag: v1\nreplicas: 1\n")
+	if err != nil {
+		t.Fatalf("Compatible build failed: %v", err)
+	}
+	if !strings.Contains(stdout, "Checking the Go types for breaking changes against "+typesPath) {
+		t.Errorf("Expected the check in output, got:\n%s", stdout)
+	}
+	deps, err := os.ReadFile(depsFile)
+	if err != nil {
+		t.Fatalf("Failed to read deps file: %v", err)
+	}
+	expected := []string{inputPath, filepath.Join(tmpDir, "crd.yaml"), typesPath}
+	sort.Strings(expected)
+	if string(deps) != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("Unexpected deps file: %q", string(deps))
+	}
+
+	// A large integer changes the Go type of replicas
+	before, err := os.ReadFile(typesPath)
+	if err != nil {
+		t.Fatalf("Failed to read types: %v", err)
+	}
+	_, err = build("apiVersion: example.com/v1\nkind: Example\nimage:\n  tag: v1\nreplicas: 10000000000\n")
+	if err == nil || !strings.Contains(err.Error(), "field Example.Replicas changed type from int to int64") {
+		t.Fatalf("Expected breaking change error, got: %v", err)
+	}
+	after, err := os.ReadFile(typesPath)
+	if err != nil {
+		t.Fatalf("Failed to read types: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("Expected the types to be kept on a breaking change")
+	}
+}
+
+// TestBuildCommand_JSONOutput tests that -o json prints only a result object with
+// the written files
+func TestBuildCommand_JSONOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdPath, "-s", schemaPath, "-o", "json"})
+	stdout, stderr, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("Build failed: %v\nStderr: %s", err, stderr)
+	}
+
+	var result commandResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Expected only a JSON result on stdout: %v\n%s", err, stdout)
+	}
+	if result.Command != "build" || result.Status != statusOK {
+		t.Errorf("Expected an ok build result, got %+v", result)
+	}
+	if want := []string{crdPath, schemaPath}; !reflect.DeepEqual(result.Files, want) {
+		t.Errorf("Expected files %v, got %v", want, result.Files)
+	}
+}
+
+// TestBuildCommand_JSONOutputErrors tests that -o json reports the values that
+// break their markers with their positions
+func TestBuildCommand_JSONOutputErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\n# +kubebuilder:validation:Minimum=1\nreplicas: 0\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json"), "-o", "json"})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err == nil {
+		t.Fatal("Expected the example values to break their markers")
+	}
+
+	var result commandResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Expected only a JSON result on stdout: %v\n%s", err, stdout)
+	}
+	if result.Status != statusFailed || len(result.Files) != 0 {
+		t.Errorf("Expected a failed build without files, got %+v", result)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 error, got %+v", result.Errors)
+	}
+	got := result.Errors[0]
+	if got.File != inputPath || got.Line != 4 || got.Path != "replicas" || !strings.Contains(got.Message, "+kubebuilder:validation:Minimum=1") {
+		t.Errorf("Expected the error at %s:4 replicas with its marker, got %+v", inputPath, got)
+	}
+}
+
+// TestBuildCommand_InvalidOutput tests that unknown output formats are rejected
+func TestBuildCommand_InvalidOutput(t *testing.T) {
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{"-o", "yaml"})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), `invalid output format "yaml"`) {
+		t.Errorf("Expected invalid output format error, got: %v", err)
+	}
+}
+
+// TestBuildCommand_Verbosity tests that --quiet only prints warnings and --verbose
+// adds the details of each step
+func TestBuildCommand_Verbosity(t *testing.T) {
+	t.Cleanup(func() { logQuiet, logVerbose = false, false })
+
+	for _, tt := range []struct {
+		name           string
+		quiet, verbose bool
+		want, notWant  []string
+	}{
+		{
+			name:    "default",
+			want:    []string{"⚠️  ", "✓ CRD generated: "},
+			notWant: []string{"Parsed "},
+		},
+		{
+			name:    "quiet",
+			quiet:   true,
+			want:    []string{"⚠️  "},
+			notWant: []string{"✓ CRD generated: ", "Parsed "},
+		},
+		{
+			name:    "verbose",
+			verbose: true,
+			want:    []string{"⚠️  ", "✓ CRD generated: ", "Parsed ", "No previous CRD"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			inputPath := filepath.Join(tmpDir, "example.yaml")
+			// The JSON Schema can't represent the CEL rule, which is a warning
+			input := "apiVersion: example.com/v1\nkind: Example\n# +kubebuilder:validation:XValidation:rule=\"self > 0\"\nport: 80\n"
+			if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+				t.Fatalf("Failed to write input: %v", err)
+			}
+			logQuiet, logVerbose = tt.quiet, tt.verbose
+
+			var out bytes.Buffer
+			cmd := newBuildCommand()
+			cmd.SetOut(&out)
+			cmd.SetArgs([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Build failed: %v\n%s", err, out.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected %q in output, got:\n%s", want, out.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("Expected no %q in output, got:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+}
+
+// TestBuildCommand_MemoryFS tests that a build reads its input from and writes
+// its outputs to buildFS
+func TestBuildCommand_MemoryFS(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{
+		"chart/example.yaml": []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"),
+	})
+	buildFS = fsys
+	t.Cleanup(func() { buildFS = filesystem.OS() })
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{"chart/example.yaml", "--in-memory", "-c", "chart/crd.yaml", "-s", "chart/values.schema.json", "-t", "chart/types.go"})
+	if _, stderr, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build failed: %v\nStderr: %s", err, stderr)
+	}
+
+	want := []string{"chart/crd.yaml", "chart/example.yaml", "chart/types.go", "chart/values.schema.json"}
+	if got := fsys.Files(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected files %v, got %v", want, got)
+	}
+	crdContent, err := fsys.ReadFile("chart/crd.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read CRD: %v", err)
+	}
+	if !strings.Contains(string(crdContent), "examples.example.com") {
+		t.Errorf("Expected the CRD of Example, got:\n%s", crdContent)
+	}
+
+	// A second build checks the CRD in memory for breaking changes
+	if err := fsys.WriteFile("chart/example.yaml", []byte("apiVersion: example.com/v1\nkind: Example\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{"chart/example.yaml", "--in-memory", "-c", "chart/crd.yaml", "-s", "chart/values.schema.json"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), "breaking changes detected") {
+		t.Errorf("Expected breaking changes against the CRD in memory, got %v", err)
+	}
+}
+
+// TestBuildCommand_Canceled tests that a build whose context is canceled (e.g.,
+// by Ctrl-C or --timeout) aborts without writing outputs
+func TestBuildCommand_Canceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdPath, "-s", filepath.Join(tmpDir, "values.schema.json")})
+	_, _, err := captureStdoutStderr(t, func() error { return cmd.ExecuteContext(ctx) })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the build to be canceled, got %v", err)
+	}
+	if _, err := os.Stat(crdPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no CRD to be written, got %v", err)
+	}
+}
+
+// TestBuildCommand_History tests saving release snapshots and checking for
+// breaking changes against the supported ones
+func TestBuildCommand_History(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	historyPath := filepath.Join(tmpDir, "history")
+	build := func(values string, args ...string) (string, error) {
+		t.Helper()
+		if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\n"+values), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{
+			inputPath,
+			"-c", crdPath,
+			"-s", filepath.Join(tmpDir, "values.schema.json"),
+			"--history", historyPath,
+		}, args...))
+		stdout, output, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout + output, err
+	}
+
+	// Release 1.0.0 has replicas as an integer
+	if _, err := build("replicas: 3\n", "--snapshot", "1.0.0"); err != nil {
+		t.Fatalf("Build of 1.0.0 failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(historyPath, "1.0.0", "crd.yaml")); err != nil {
+		t.Fatalf("Expected snapshot of 1.0.0: %v", err)
+	}
+
+	// Changing it to a string breaks 1.0.0, even without the previous CRD
+	if err := os.Remove(crdPath); err != nil {
+		t.Fatalf("Failed to remove CRD: %v", err)
+	}
+	_, err := build("replicas: \"3\"\n", "--snapshot", "v2.0.0")
+	if err == nil || !strings.Contains(err.Error(), "breaking changes against release 1.0.0") {
+		t.Fatalf("Expected breaking change error, got: %v", err)
+	}
+
+	// Once 1.x is no longer supported, the change is allowed
+	output, err := build("replicas: \"3\"\n", "--snapshot", "v2.0.0", "--supported-versions", "2.x")
+	if err != nil {
+		t.Fatalf("Build of 2.0.0 failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Snapshot of release 2.0.0 saved") {
+		t.Errorf("Expected snapshot message, got: %s", output)
+	}
+	if _, err := os.Stat(filepath.Join(historyPath, "2.0.0", "crd.yaml")); err != nil {
+		t.Fatalf("Expected snapshot of 2.0.0: %v", err)
+	}
+
+	// Compatible changes are checked against the supported releases only
+	output, err = build("replicas: \"3\"\nname: app\n", "--supported-versions", "2.x")
+	if err != nil {
+		t.Fatalf("Compatible build failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "against release 2.0.0") || strings.Contains(output, "against release 1.0.0") {
+		t.Errorf("Expected a check against 2.0.0 only, got: %s", output)
+	}
+
+	// Supported versions without snapshots are only a warning
+	output, err = build("replicas: \"3\"\nname: app\n", "--supported-versions", "3.x")
+	if err != nil {
+		t.Fatalf("Build failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "No snapshots in") {
+		t.Errorf("Expected a warning about --supported-versions, got: %s", output)
+	}
+}
+
+// TestBuildCommand_SARIFOutput tests that breaking changes are SARIF results
+// located at the changed values in the input file
+func TestBuildCommand_SARIFOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	build := func(values string, args ...string) (string, error) {
+		t.Helper()
+		if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\n"+values), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "-c", crdPath, "-s", filepath.Join(tmpDir, "values.schema.json")}, args...))
+		stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout, err
+	}
+
+	if _, err := build("replicas: 3\nports:\n  - name: http\n"); err != nil {
+		t.Fatalf("Initial build failed: %v", err)
+	}
+	stdout, err := build("replicas: \"3\"\nports:\n  - name: 80\n", "-o", "sarif")
+	if err == nil || !strings.Contains(err.Error(), "breaking changes detected") {
+		t.Fatalf("Expected breaking change error, got: %v", err)
+	}
+
+	var log sarif.Log
+	if err := json.Unmarshal([]byte(stdout), &log); err != nil {
+		t.Fatalf("Expected only a SARIF log on stdout: %v\n%s", err, stdout)
+	}
+	lines := map[string]int{}
+	for _, result := range log.Runs[0].Results {
+		if !strings.HasPrefix(result.RuleID, "breaking-change/") || result.Level != sarif.LevelError {
+			t.Errorf("Expected a breaking change error, got %+v", result)
+			continue
+		}
+		if len(result.Locations) != 1 || result.Locations[0].PhysicalLocation.Region == nil {
+			t.Errorf("Expected a located result, got %+v", result)
+			continue
+		}
+		location := result.Locations[0].PhysicalLocation
+		if location.ArtifactLocation.URI != filepath.ToSlash(inputPath) {
+			t.Errorf("Expected a result in %s, got %s", inputPath, location.ArtifactLocation.URI)
+		}
+		path, _, _ := strings.Cut(result.Message.Text, ":")
+		lines[path] = location.Region.StartLine
+	}
+	if lines["replicas"] != 3 || lines["ports.0.name"] != 5 {
+		t.Errorf("Expected results at replicas (line 3) and ports.0.name (line 5), got %v", lines)
+	}
+}
+
+// TestBuildCommand_DuplicateKeys tests the --duplicate-keys policies
+func TestBuildCommand_DuplicateKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\nimage: nginx\nreplicas: 5\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	build := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")}, args...))
+		stdout, stderr, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout + stderr, err
+	}
+
+	_, err := build()
+	if err == nil || !strings.Contains(err.Error(), "line 5: duplicate key replicas (first set at line 3)") {
+		t.Fatalf("Expected a duplicate key error, got: %v", err)
+	}
+
+	output, err := build("--duplicate-keys", "warn")
+	if err != nil {
+		t.Fatalf("Build with --duplicate-keys=warn failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "line 5: duplicate key replicas") {
+		t.Errorf("Expected a duplicate key warning, got: %s", output)
+	}
+
+	output, err = build("--duplicate-keys", "last-wins")
+	if err != nil {
+		t.Fatalf("Build with --duplicate-keys=last-wins failed: %v\nOutput: %s", err, output)
+	}
+	if strings.Contains(output, "duplicate key") {
+		t.Errorf("Expected no duplicate key warning, got: %s", output)
+	}
+
+	if _, err := build("--duplicate-keys", "first-wins"); err == nil || !strings.Contains(err.Error(), "invalid duplicate key policy") {
+		t.Errorf("Expected an invalid policy error, got: %v", err)
+	}
+}
+
+// TestBuildCommand_TemplateStrings tests the --template-strings policies
+func TestBuildCommand_TemplateStrings(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	input := "apiVersion: example.com/v1\nkind: Example\nservicePort: \"{{ .Values.global.port }}\"\n# +miaka:template\nconfigName: \"{{ .Release.Name }}-config\"\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	build := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "--in-memory", "--infer-semantic-types", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath}, args...))
+		stdout, stderr, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout + stderr, err
+	}
+
+	output, err := build()
+	if err != nil {
+		t.Fatalf("Build failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "line 3: servicePort is a Helm template expression") || strings.Contains(output, "configName is a Helm") {
+		t.Errorf("Expected a warning for the unmarked template expression only, got: %s", output)
+	}
+	// The template expression is a string, not the int-or-string its key suggests
+	content, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read JSON Schema: %v", err)
+	}
+	if strings.Contains(string(content), "x-kubernetes-int-or-string") || strings.Contains(string(content), "anyOf") {
+		t.Errorf("Expected servicePort to be a plain string, got: %s", content)
+	}
+
+	output, err = build("--template-strings", "allow")
+	if err != nil {
+		t.Fatalf("Build with --template-strings=allow failed: %v\nOutput: %s", err, output)
+	}
+	if strings.Contains(output, "Helm template expression") {
+		t.Errorf("Expected no template warning, got: %s", output)
+	}
+
+	if _, err := build("--template-strings", "error"); err == nil || !strings.Contains(err.Error(), "line 3: servicePort is a Helm template expression") {
+		t.Errorf("Expected a template string error, got: %v", err)
+	}
+	if _, err := build("--template-strings", "ignore"); err == nil || !strings.Contains(err.Error(), "invalid template string policy") {
+		t.Errorf("Expected an invalid policy error, got: %v", err)
+	}
+}
+
+func TestBuildCommand_Limits(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\nserver:\n  tls:\n    enabled: true\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	build := func(args ...string) error {
+		t.Helper()
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")}, args...))
+		_, _, err := captureStdoutStderr(t, cmd.Execute)
+		return err
+	}
+
+	if err := build("--max-depth", "2"); err == nil || !strings.Contains(err.Error(), "line 5: values are nested more than 2 levels deep") {
+		t.Errorf("Expected a depth error, got: %v", err)
+	}
+	if err := build("--max-fields", "4"); err == nil || !strings.Contains(err.Error(), "values have more than 4 fields") {
+		t.Errorf("Expected a fields error, got: %v", err)
+	}
+	if err := build("--max-size", "16"); err == nil || !strings.Contains(err.Error(), "values file is larger than 16 bytes") {
+		t.Errorf("Expected a size error, got: %v", err)
+	}
+	if err := build("--max-depth", "-1"); err != nil {
+		t.Errorf("Expected the build to succeed without a depth limit, got: %v", err)
+	}
+}
+
+func TestBuildCommand_RequireDescriptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\n# Number of replicas\nreplicas: 1\nimage:\n  # Image repository\n  repository: nginx\n  tag: latest\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	build := func(args ...string) (string, error) {
+		t.Helper()
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")}, args...))
+		stdout, stderr, err := captureStdoutStderr(t, cmd.Execute)
+		return stdout + stderr, err
+	}
+
+	// Two of four fields have descriptions
+	_, err := build("--require-descriptions")
+	want := "50% of fields have a description, below the required 100% (add a comment above each of these fields):\n  image\n  image.tag"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error %q, got: %v", want, err)
+	}
+	if _, err := build("--require-descriptions=75%"); err == nil {
+		t.Error("Expected the build to fail below 75%")
+	}
+	if output, err := build("--require-descriptions=50"); err != nil {
+		t.Errorf("Expected the build to pass at 50%%, got: %v\nOutput: %s", err, output)
+	}
+
+	output, err := build("--require-descriptions=warn")
+	if err != nil {
+		t.Fatalf("Expected the build to pass with warn, got: %v", err)
+	}
+	if !strings.Contains(output, "2 field(s) have no description:") || !strings.Contains(output, "  - image.tag") {
+		t.Errorf("Expected the fields without a description to be listed, got: %s", output)
+	}
+
+	if _, err := build("--require-descriptions=most"); err == nil || !strings.Contains(err.Error(), `invalid --require-descriptions "most"`) {
+		t.Errorf("Expected an invalid value error, got: %v", err)
+	}
+}
+
+func TestBuildCommand_Defaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\n# Number of replicas\n# +kubebuilder:default=1\nreplicas: 2\nimage:\n  tag: latest\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	defaultsPath := filepath.Join(tmpDir, "defaults.yaml")
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	build := func(defaults string) error {
+		t.Helper()
+		if err := os.WriteFile(defaultsPath, []byte(defaults), 0644); err != nil {
+			t.Fatalf("Failed to write defaults: %v", err)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs([]string{inputPath, "--in-memory", "-c", crdPath, "-s", schemaPath, "--defaults", defaultsPath, "--deps-file", filepath.Join(tmpDir, "build.d")})
+		_, _, err := captureStdoutStderr(t, cmd.Execute)
+		return err
+	}
+
+	if err := build("replicas: 1\nimage:\n  tag: \"1.25\"\n"); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	schemaJSON, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	if !strings.Contains(string(schemaJSON), `"default": "1.25"`) {
+		t.Errorf("Expected the JSON Schema to have the default of the defaults file, got:\n%s", schemaJSON)
+	}
+	crdYAML, err := os.ReadFile(crdPath)
+	if err != nil {
+		t.Fatalf("Failed to read CRD: %v", err)
+	}
+	if !strings.Contains(string(crdYAML), `default: "1.25"`) {
+		t.Errorf("Expected the CRD to have the default of the defaults file, got:\n%s", crdYAML)
+	}
+	deps, err := os.ReadFile(filepath.Join(tmpDir, "build.d"))
+	if err != nil {
+		t.Fatalf("Failed to read deps file: %v", err)
+	}
+	if !strings.Contains(string(deps), defaultsPath) {
+		t.Errorf("Expected the deps file to list the defaults file, got: %s", deps)
+	}
+
+	// Defaults that differ from markers are conflicts
+	err = build("replicas: 3\n")
+	want := "line 5: replicas defaults to 1 in its marker, but to 3 in the defaults file (line 1)"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got: %v", want, err)
+	}
+	if err := build("image:\n  digest: sha256\n"); err == nil || !strings.Contains(err.Error(), "defaults file line 2: image.digest isn't a field of the values") {
+		t.Errorf("Expected an unknown field error, got: %v", err)
+	}
+	if err := build("- replicas\n"); err == nil || !strings.Contains(err.Error(), "failed to parse defaults file") {
+		t.Errorf("Expected a defaults file error, got: %v", err)
+	}
+}
+
+func TestBuildCommand_HelmCompat(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\n# +miaka:type: duration\ntimeout: 30s\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath, "--helm-compat"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	content, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+	var jsonSchema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(content, &jsonSchema); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if global, ok := jsonSchema.Properties["global"]; !ok || global["type"] != "object" {
+		t.Errorf("Expected the global key to be allowed, got: %s", content)
+	}
+	if _, ok := jsonSchema.Properties["timeout"]["format"]; ok {
+		t.Errorf("Expected the duration format to be removed, got: %s", content)
+	}
+}
+
+func TestBuildCommand_SchemaTitles(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\n# Policy for pulling the image. Defaults to IfNotPresent.\nimagePullPolicy: Always\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for mode, want := range map[string]interface{}{
+		"":        "Image Pull Policy",
+		"comment": "Policy for pulling the image",
+		"none":    nil,
+	} {
+		schemaPath := filepath.Join(tmpDir, "values.schema.json")
+		args := []string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath}
+		if mode != "" {
+			args = append(args, "--schema-titles", mode)
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs(args)
+		if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+			t.Fatalf("Build with --schema-titles=%s failed: %v", mode, err)
+		}
+
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		var jsonSchema struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		}
+		if err := json.Unmarshal(content, &jsonSchema); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if got := jsonSchema.Properties["imagePullPolicy"]["title"]; got != want {
+			t.Errorf("Expected the title %v with --schema-titles=%s, got %v", want, mode, got)
+		}
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--schema-titles", "names"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), "invalid title mode") {
+		t.Errorf("Expected an invalid title mode error, got: %v", err)
+	}
+}
+
+func TestBuildCommand_SchemaExamples(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\nimage:\n  repository: nginx\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		schemaPath := filepath.Join(tmpDir, "values.schema.json")
+		args := []string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath}
+		if enabled {
+			args = append(args, "--schema-examples")
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs(args)
+		if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+			t.Fatalf("Build with --schema-examples=%t failed: %v", enabled, err)
+		}
+
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		var jsonSchema struct {
+			Properties map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(content, &jsonSchema); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		examples := jsonSchema.Properties["image"].Properties["repository"]["examples"]
+		if !enabled && examples != nil {
+			t.Errorf("Expected no examples without --schema-examples, got %v", examples)
+		}
+		if enabled && !reflect.DeepEqual(examples, []interface{}{"nginx"}) {
+			t.Errorf("Expected the examples [nginx] with --schema-examples, got %v", examples)
+		}
+	}
+}
+
+func TestBuildCommand_SchemaKRM(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\nmetadata:\n  name: example\nreplicas: 3\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		schemaPath := filepath.Join(tmpDir, "values.schema.json")
+		args := []string{inputPath, "--in-memory", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath}
+		if enabled {
+			args = append(args, "--schema-krm")
+		}
+		cmd := newBuildCommand()
+		cmd.SetArgs(args)
+		if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+			t.Fatalf("Build with --schema-krm=%t failed: %v", enabled, err)
+		}
+
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		var jsonSchema struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		}
+		if err := json.Unmarshal(content, &jsonSchema); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		_, hasMetadata := jsonSchema.Properties["metadata"]
+		if hasMetadata != enabled {
+			t.Errorf("Expected metadata in the schema with --schema-krm=%t: %t", enabled, hasMetadata)
+		}
+		kinds := jsonSchema.Properties["kind"]["enum"]
+		if !enabled && kinds != nil {
+			t.Errorf("Expected any kind without --schema-krm, got the enum %v", kinds)
+		}
+		if enabled && !reflect.DeepEqual(kinds, []interface{}{"Example"}) {
+			t.Errorf("Expected the kind pinned to Example with --schema-krm, got %v", kinds)
+		}
+	}
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--plain", "--schema-krm"})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), "--schema-krm can't be used with --plain") {
+		t.Errorf("Expected an error for --schema-krm with --plain, got: %v", err)
+	}
+}
+
+func TestBuildCommand_ResourceSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	input := "apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"
+	if err := os.WriteFile(inputPath, []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	resourcePath := filepath.Join(tmpDir, "resource.schema.json")
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--wrap-spec", "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", schemaPath, "--resource-schema", resourcePath})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build with --resource-schema failed: %v", err)
+	}
+
+	var values, resource struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	for path, target := range map[string]interface{}{schemaPath: &values, resourcePath: &resource} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if err := json.Unmarshal(content, target); err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+	}
+	if _, ok := values.Properties["apiVersion"]; ok {
+		t.Errorf("Expected no apiVersion in the values schema, got properties %v", values.Properties)
+	}
+	if _, ok := values.Properties["replicas"]; !ok {
+		t.Errorf("Expected replicas at the root of the values schema, got properties %v", values.Properties)
+	}
+	if kinds := resource.Properties["kind"]["enum"]; !reflect.DeepEqual(kinds, []interface{}{"Example"}) {
+		t.Errorf("Expected the resource kind pinned to Example, got %v", kinds)
+	}
+	if _, ok := resource.Properties["metadata"]; !ok {
+		t.Errorf("Expected metadata in the resource schema, got properties %v", resource.Properties)
+	}
+	spec, _ := resource.Properties["spec"]["properties"].(map[string]interface{})
+	if _, ok := spec["replicas"]; !ok {
+		t.Errorf("Expected replicas under spec in the resource schema, got %v", resource.Properties["spec"])
+	}
+
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--schema-krm"}, "--schema-krm can't be used with --resource-schema"},
+		{[]string{"--plain"}, "--resource-schema can't be used with --plain"},
+	} {
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{inputPath, "--in-memory", "--resource-schema", resourcePath}, tt.args...))
+		if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected the error %q, got: %v", tt.err, err)
+		}
+	}
+}
+
+func TestBuildCommand_WorkDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "example.yaml")
+	if err := os.WriteFile(inputPath, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	workDir := filepath.Join(tmpDir, "work")
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--no-cache", "--workdir", workDir, "-c", filepath.Join(tmpDir, "crd.yaml"), "-s", filepath.Join(tmpDir, "values.schema.json")})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("Build with --workdir failed: %v", err)
+	}
+	// The work directory is used, and cleaned up after a successful build
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		t.Fatalf("Expected the work directory to be created: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no intermediate files left after a successful build, got %d", len(entries))
+	}
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--workdir", workDir})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err == nil || !strings.Contains(err.Error(), "--workdir can't be used with --in-memory") {
+		t.Errorf("Expected an error for --workdir with --in-memory, got: %v", err)
+	}
+}
+
+// TestBuildOptions_CacheVersion tests that cached outputs are keyed by the
+// binary, not the "dev" defaults every build from source shares
+func TestBuildOptions_CacheVersion(t *testing.T) {
+	newBuildCommand()
+	t.Setenv("MIAKA_CACHE_DIR", t.TempDir())
+
+	opts, err := buildOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.CacheVersion != cacheVersion() || opts.CacheVersion == version+" "+commit {
+		t.Errorf("Expected the cache version of the binary, got %q", opts.CacheVersion)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/crenshaw-dev/miaka/pkg/umbrella"
+	"github.com/spf13/cobra"
+)
+
+// checkUmbrellaBuild ensures the umbrella mode flags are consistent
+func checkUmbrellaBuild(cmd *cobra.Command) error {
+	if !buildUmbrella {
+		for _, flag := range []string{"subchart-schemas", "reuse-dependency-schemas"} {
+			if cmd != nil && cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s requires --umbrella", flag)
+			}
+		}
+		return nil
+	}
+	if buildHermetic {
+		return fmt.Errorf("--umbrella can't be used with --hermetic, since Chart.yaml and dependency charts aren't declared inputs")
+	}
+	return nil
+}
+
+// composeUmbrellaSchema generates or reuses a schema for each dependency in the
+// Chart.yaml next to inputFile, writes the generated ones to --subchart-schemas
+// with write, and composes them into the JSON Schema of the umbrella chart
+func composeUmbrellaSchema(ctx context.Context, parent []byte, inputFile string, write func(path string, content []byte) error) ([]byte, error) {
+	chartDir := filepath.Dir(inputFile)
+	metadata, err := chart.LoadMetadata(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read umbrella chart: %w", err)
+	}
+
+	values, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	keys := make([]string, 0, len(metadata.Dependencies))
+	for _, dep := range metadata.Dependencies {
+		keys = append(keys, dep.ValuesKey())
+	}
+	examples, err := umbrella.ExtractValues(values, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var subcharts []umbrella.Subchart
+	for _, dep := range metadata.Dependencies {
+		key := dep.ValuesKey()
+		if buildReuseSchemas {
+			published, err := publishedSchema(chartDir, dep.Name)
+			if err != nil {
+				return nil, err
+			}
+			if published != nil {
+				buildLog.Infof("✓ Reusing published schema of subchart %s", key)
+				subcharts = append(subcharts, umbrella.Subchart{Key: key, Schema: published, Published: true})
+				continue
+			}
+		}
+
+		example, ok := examples[key]
+		if !ok {
+			buildLog.Infof("Skipping subchart %s, which has no example values", key)
+			continue
+		}
+		path := filepath.Join(buildSubchartDir, key+".schema.json")
+		buildLog.Infof("Generating subchart schema %s...", path)
+		generated, err := generateSubchartSchema(ctx, key, example)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(path, generated); err != nil {
+			return nil, fmt.Errorf("failed to write schema of subchart %s: %w", key, err)
+		}
+		buildLog.Infof("✓ Subchart schema generated: %s", path)
+		subcharts = append(subcharts, umbrella.Subchart{Key: key, Schema: generated})
+	}
+
+	composed, err := umbrella.Compose(parent, subcharts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose umbrella schema: %w", err)
+	}
+	return composed, nil
+}
+
+// publishedSchema returns the values.schema.json of a dependency in charts/,
+// or nil if the dependency isn't there or has none
+func publishedSchema(chartDir, name string) ([]byte, error) {
+	depPath, err := chart.FindDependency(chartDir, name)
+	if err != nil {
+		return nil, nil
+	}
+	data, err := chart.ReadFile(depPath, chart.SchemaFile)
+	if errors.Is(err, chart.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema of dependency %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// generateSubchartSchema generates the JSON Schema of a subchart's example values,
+// which are plain values named after the subchart's key
+func generateSubchartSchema(ctx context.Context, key string, example []byte) ([]byte, error) {
+	opts, err := buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	p := parsing.NewParserWithOptions(parsing.Options{
+		InferSemanticTypes: buildInferTypes,
+		Plain:              true,
+		TypeName:           schema.ToPascalCase(key) + "Values",
+		DuplicateKeys:      opts.Parsing.DuplicateKeys,
+		TemplateStrings:    opts.Parsing.TemplateStrings,
+	})
+	s, err := p.Parse(example)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values of subchart %s: %w", key, err)
+	}
+	for _, warning := range s.Warnings {
+		buildLog.Warnf("⚠️  subchart %s: %s", key, warning)
+	}
+	if err := schema.ValidateSchema(s); err != nil {
+		return nil, fmt.Errorf("invalid values of subchart %s: %w", key, err)
+	}
+
+	registry, err := newEmitterRegistry()
+	if err != nil {
+		return nil, err
+	}
+	file, err := registry.Emit(ctx, jsonschema.TargetName, *s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema of subchart %s: %w", key, err)
+	}
+	return file.Content, nil
+}
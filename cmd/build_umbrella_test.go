@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const umbrellaChart = `apiVersion: v2
+name: platform
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: 18.x
+    repository: https://charts.example.com
+  - name: postgresql
+    alias: db
+    version: 12.x
+    repository: https://charts.example.com
+  - name: metrics
+    version: 1.x
+    repository: https://charts.example.com
+`
+
+const umbrellaValues = `apiVersion: example.com/v1
+kind: Platform
+# Number of replicas
+replicas: 1
+redis:
+  # Port of the Redis server
+  port: 6379
+db:
+  # Name of the database
+  name: app
+`
+
+// writeUmbrellaChart writes an umbrella chart and returns the path of its example values
+func writeUmbrellaChart(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(umbrellaChart), 0644))
+	inputPath := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(inputPath, []byte(umbrellaValues), 0644))
+	return inputPath
+}
+
+func TestBuildCommand_Umbrella(t *testing.T) {
+	inputPath := writeUmbrellaChart(t)
+	dir := filepath.Dir(inputPath)
+	schemaPath := filepath.Join(dir, "values.schema.json")
+	subchartDir := filepath.Join(dir, "schemas")
+
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--umbrella", "--subchart-schemas", subchartDir,
+		"-c", filepath.Join(dir, "crd.yaml"), "-s", schemaPath})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Skipping subchart metrics, which has no example values")
+
+	redis, err := os.ReadFile(filepath.Join(subchartDir, "redis.schema.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(redis), "RedisValues is the schema of the chart's values")
+	assert.Contains(t, string(redis), "Port of the Redis server")
+	assert.FileExists(t, filepath.Join(subchartDir, "db.schema.json"))
+	assert.NoFileExists(t, filepath.Join(subchartDir, "metrics.schema.json"))
+
+	data, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+	var composed map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &composed))
+	properties := composed["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/redis"}, properties["redis"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/db"}, properties["db"])
+	assert.Contains(t, properties, "replicas")
+	assert.Contains(t, composed["definitions"], "redis")
+
+	// The composed schema validates subchart values through the refs
+	invalidPath := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("apiVersion: example.com/v1\nkind: Platform\nredis:\n  port: high\n"), 0644))
+	require.Error(t, validation.ValidateYAML(invalidPath, schemaPath))
+}
+
+func TestBuildCommand_UmbrellaReuseSchemas(t *testing.T) {
+	inputPath := writeUmbrellaChart(t)
+	dir := filepath.Dir(inputPath)
+	published := `{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"name": {"type": "string", "maxLength": 5}}}`
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "postgresql"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "postgresql", "values.schema.json"), []byte(published), 0644))
+
+	schemaPath := filepath.Join(dir, "values.schema.json")
+	subchartDir := filepath.Join(dir, "schemas")
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--umbrella", "--reuse-dependency-schemas", "--subchart-schemas", subchartDir,
+		"-c", filepath.Join(dir, "crd.yaml"), "-s", schemaPath})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Reusing published schema of subchart db")
+
+	assert.FileExists(t, filepath.Join(subchartDir, "redis.schema.json"))
+	assert.NoFileExists(t, filepath.Join(subchartDir, "db.schema.json"))
+
+	data, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"maxLength": 5`)
+}
+
+func TestBuildCommand_UmbrellaErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"subchart dir without umbrella", []string{"--subchart-schemas", "out"}, "--subchart-schemas requires --umbrella"},
+		{"reuse without umbrella", []string{"--reuse-dependency-schemas"}, "--reuse-dependency-schemas requires --umbrella"},
+		{"hermetic", []string{"--umbrella", "--hermetic", "-c", "crd.yaml", "-s", "schema.json"}, "--umbrella can't be used with --hermetic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newBuildCommand()
+			cmd.SetArgs(append([]string{"example.values.yaml"}, tt.args...))
+			_, _, err := captureStdoutStderr(t, cmd.Execute)
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+
+	// Chart.yaml must be next to the input file
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(inputPath, []byte(umbrellaValues), 0644))
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{inputPath, "--in-memory", "--umbrella", "-c", filepath.Join(dir, "crd.yaml"), "-s", filepath.Join(dir, "values.schema.json")})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.ErrorContains(t, err, "failed to read umbrella chart")
+}
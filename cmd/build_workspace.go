@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/workspace"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	"sigs.k8s.io/yaml"
+)
+
+// workspaceTargetFlags are the flags that each target of a project config sets
+// for itself, or that don't apply to several targets
+var workspaceTargetFlags = []string{
+	"types", "crd", "schema", "typescript", "emit", "plain", "type-name", "previous-crd", "previous-types",
+	"history", "deps-file", "hermetic", "suggest-hints", "umbrella", "subchart-schemas", "reuse-dependency-schemas",
+	"scope", "plural", "list-kind", "short-names", "categories",
+}
+
+// preparedBuild is the parsed input and output registry of a target, whose
+// outputs a workspace build generated ahead of time with --parallel
+type preparedBuild struct {
+	schema   *schema.Schema
+	registry *generation.Registry
+}
+
+// buildPrepared holds the prepared builds of the targets of a parallel
+// workspace build by input file
+var buildPrepared map[string]preparedBuild
+
+// buildWorkspaceTarget is the name of the target of the project config being
+// built, or "" outside workspace builds
+var buildWorkspaceTarget string
+
+// workspaceConfigPath returns the project config to build the targets of: the
+// --config flag, or miaka.yaml if it exists and no input file is given. It
+// returns "" to build a single input file.
+func workspaceConfigPath(args []string) (string, error) {
+	if buildConfig != "" {
+		if len(args) > 0 {
+			return "", fmt.Errorf("--config can't be used with an input file")
+		}
+		return buildConfig, nil
+	}
+	if len(args) > 0 || buildHermetic {
+		return "", nil
+	}
+	if _, err := buildFS.Stat(workspace.DefaultFile); err != nil {
+		return "", nil
+	}
+	return workspace.DefaultFile, nil
+}
+
+// buildWorkspace builds every target of a project config in order. A failed
+// target doesn't stop the others; the failures, including breaking changes,
+// are reported together at the end.
+func buildWorkspace(cmd *cobra.Command, configPath string) error {
+	for _, flag := range workspaceTargetFlags {
+		if cmd != nil && cmd.Flags().Changed(flag) {
+			return fmt.Errorf("--%s can't be used when building the targets of a project config (set it on the targets in %s)", flag, configPath)
+		}
+	}
+	config, err := workspace.Load(buildFS, configPath)
+	if err != nil {
+		return err
+	}
+	buildLog.Infof("Building %d target(s) of %s", len(config.Targets), configPath)
+
+	if buildParallel {
+		buildLog.Infof("Generating the outputs of all targets in parallel...")
+		prepareWorkspace(commandContext(cmd), config.Targets)
+		defer func() { buildPrepared = nil }()
+	}
+
+	var failures []string
+	for _, target := range config.Targets {
+		buildLog.Infof("")
+		buildLog.Infof("▸ Target %s (%s)", target.Name, target.Input)
+		recorded := len(buildResult.Errors)
+		if err := buildTarget(cmd, target); err != nil {
+			buildLog.Errorf("✗ Target %s failed", target.Name)
+			message := strings.TrimRight(err.Error(), "\n")
+			failures = append(failures, fmt.Sprintf("  %s: %s", target.Name, strings.ReplaceAll(message, "\n", "\n    ")))
+			if len(buildResult.Errors) == recorded {
+				// Breaking changes are already recorded at their values
+				buildResult.Errors = append(buildResult.Errors, resultIssue{Message: fmt.Sprintf("target %s: %v", target.Name, err), File: target.Input})
+			}
+			continue
+		}
+		buildLog.Infof("✓ Target %s built", target.Name)
+	}
+
+	buildLog.Infof("")
+	if len(failures) > 0 {
+		buildLog.Errorf("✗ %d of %d target(s) failed:", len(failures), len(config.Targets))
+		for _, failure := range failures {
+			buildLog.Errorf("%s", failure)
+		}
+		return fmt.Errorf("%d of %d target(s) failed", len(failures), len(config.Targets))
+	}
+	buildLog.Infof("✓ %d target(s) built", len(config.Targets))
+	return nil
+}
+
+// buildTarget builds one target of a project config with the flags set to its
+// inputs and outputs, restoring them afterwards
+func buildTarget(cmd *cobra.Command, target workspace.Target) error {
+	defer useTarget(target)()
+	if err := checkTargetHeader(target); err != nil {
+		return err
+	}
+	return buildInput(cmd, []string{target.Input})
+}
+
+// useTarget sets the build flags to the inputs and outputs of a target, and
+// returns a function that restores them
+func useTarget(target workspace.Target) (restore func()) {
+	saved := struct {
+		crd, schema, types, typescript, resource, history, typeName, target string
+		plain                                                               bool
+	}{buildCRDPath, buildSchemaPath, buildTypesPath, buildTSPath, buildResourcePath, buildHistoryDir, buildTypeName, buildWorkspaceTarget, buildPlain}
+
+	buildCRDPath = target.CRD
+	buildSchemaPath = target.Schema
+	buildTypesPath = target.Types
+	buildTSPath = target.TypeScript
+	buildResourcePath = target.ResourceSchema
+	buildHistoryDir = target.History
+	buildPlain = target.Plain
+	buildTypeName = schema.DefaultPlainTypeName
+	if target.TypeName != "" {
+		buildTypeName = target.TypeName
+	}
+	buildWorkspaceTarget = target.Name
+
+	return func() {
+		buildCRDPath, buildSchemaPath, buildTypesPath, buildTSPath, buildResourcePath = saved.crd, saved.schema, saved.types, saved.typescript, saved.resource
+		buildHistoryDir, buildTypeName, buildWorkspaceTarget, buildPlain = saved.history, saved.typeName, saved.target, saved.plain
+	}
+}
+
+// checkTargetHeader ensures the input of a target has the apiVersion and kind
+// the target expects, so a config can't silently build the wrong file
+func checkTargetHeader(target workspace.Target) error {
+	if target.APIVersion == "" && target.Kind == "" {
+		return nil
+	}
+	data, err := buildFS.ReadFile(target.Input)
+	if err != nil {
+		return fmt.Errorf("input file not found: %s", target.Input)
+	}
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if target.APIVersion != "" && header.APIVersion != target.APIVersion {
+		return fmt.Errorf("%s has apiVersion %q, but the target expects %q", target.Input, header.APIVersion, target.APIVersion)
+	}
+	if target.Kind != "" && header.Kind != target.Kind {
+		return fmt.Errorf("%s has kind %q, but the target expects %q", target.Input, header.Kind, target.Kind)
+	}
+	return nil
+}
+
+// prepareWorkspace parses the input of every target and generates their
+// outputs in parallel, for the builds of the targets to write in order. Targets
+// that fail to parse are left for their builds to report.
+func prepareWorkspace(ctx context.Context, targets []workspace.Target) {
+	buildPrepared = map[string]preparedBuild{}
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, target := range targets {
+		prepared, names, err := prepareTarget(target)
+		if err != nil {
+			buildLog.Debugf("Not preparing target %s: %v", target.Name, err)
+			continue
+		}
+		buildPrepared[target.Input] = prepared
+		g.Go(func() error {
+			// Errors are kept by the registry and reported by the target's build
+			_ = prepared.registry.Prefetch(ctx, *prepared.schema, names...)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// prepareTarget parses the input of a target and returns it with the registry
+// and names of its outputs
+func prepareTarget(target workspace.Target) (preparedBuild, []string, error) {
+	defer useTarget(target)()
+	opts, err := buildOptions()
+	if err != nil {
+		return preparedBuild{}, nil, err
+	}
+	s, registry, err := parseInput(opts, target.Input)
+	if err != nil {
+		return preparedBuild{}, nil, err
+	}
+	emitTargets, err := parseEmitTargets()
+	if err != nil {
+		return preparedBuild{}, nil, err
+	}
+	return preparedBuild{schema: s, registry: registry}, prefetchTargets(registry, emitTargets), nil
+}
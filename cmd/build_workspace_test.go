@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const workspaceConfig = `targets:
+  - name: api
+    input: api/example.values.yaml
+    apiVersion: example.com/v1
+    kind: API
+    types: api/types.go
+  - name: worker
+    input: worker/example.values.yaml
+    kind: Worker
+  - input: chart/values.yaml
+    plain: true
+    typeName: ChartValues
+`
+
+// writeWorkspace writes a project with two CRD targets and a plain target, and
+// changes to its directory
+func writeWorkspace(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"miaka.yaml":                 workspaceConfig,
+		"api/example.values.yaml":    "apiVersion: example.com/v1\nkind: API\n# Number of replicas\nreplicas: 2\n",
+		"worker/example.values.yaml": "apiVersion: example.com/v1\nkind: Worker\nqueue: jobs\n",
+		"chart/values.yaml":          "image: nginx\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+func TestBuildCommand_Workspace(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		t.Run(map[bool]string{false: "sequential", true: "parallel"}[parallel], func(t *testing.T) {
+			dir := writeWorkspace(t)
+
+			cmd := newBuildCommand()
+			args := []string{"--in-memory"}
+			if parallel {
+				args = append(args, "--parallel")
+			}
+			cmd.SetArgs(args)
+			stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+			require.NoError(t, err)
+			assert.Contains(t, stdout, "Building 3 target(s) of miaka.yaml")
+			assert.Contains(t, stdout, "▸ Target api (api/example.values.yaml)")
+			assert.Contains(t, stdout, "✓ 3 target(s) built")
+			assert.NotContains(t, stdout, "Next steps")
+
+			for _, output := range []string{"api/crd.yaml", "api/values.schema.json", "api/types.go", "worker/crd.yaml", "worker/values.schema.json", "chart/values.schema.json"} {
+				assert.FileExists(t, filepath.Join(dir, output))
+			}
+			assert.NoFileExists(t, filepath.Join(dir, "chart/crd.yaml"))
+			crd, err := os.ReadFile(filepath.Join(dir, "worker/crd.yaml"))
+			require.NoError(t, err)
+			assert.Contains(t, string(crd), "kind: Worker")
+			types, err := os.ReadFile(filepath.Join(dir, "api/types.go"))
+			require.NoError(t, err)
+			assert.Contains(t, string(types), "type API struct")
+		})
+	}
+}
+
+func TestBuildCommand_WorkspaceFailures(t *testing.T) {
+	dir := writeWorkspace(t)
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{"--in-memory"})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+
+	// A breaking change to one target and the wrong kind in another don't stop
+	// the third, and are reported together
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api/example.values.yaml"), []byte("apiVersion: example.com/v1\nkind: API\nreplicas: two\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "worker/example.values.yaml"), []byte("apiVersion: example.com/v1\nkind: Job\nqueue: jobs\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "chart/values.yaml"), []byte("image: nginx\ntag: latest\n"), 0644))
+
+	cmd = newBuildCommand()
+	cmd.SetArgs([]string{"--in-memory"})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.Error(t, err)
+	assert.Equal(t, "2 of 3 target(s) failed", err.Error())
+	assert.Contains(t, stdout, "✗ 2 of 3 target(s) failed:")
+	assert.Contains(t, stdout, "  api: failed to generate CRD: breaking changes detected:\n    - v1 - ^.replicas")
+	assert.Contains(t, stdout, "\n    - v1 - ^.replicas - type - type changed : \"integer\" -> \"string\"")
+	assert.Contains(t, stdout, `worker: worker/example.values.yaml has kind "Job", but the target expects "Worker"`)
+	assert.Contains(t, stdout, "✓ Target chart/values.yaml built")
+
+	schema, err := os.ReadFile(filepath.Join(dir, "chart/values.schema.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(schema), `"tag"`)
+}
+
+func TestBuildCommand_WorkspaceFlags(t *testing.T) {
+	dir := writeWorkspace(t)
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"target flag", []string{"-c", "crd.yaml"}, "--crd can't be used when building the targets of a project config"},
+		{"config with input", []string{"--config", "miaka.yaml", "api/example.values.yaml"}, "--config can't be used with an input file"},
+		{"parallel without config", []string{"api/example.values.yaml", "--parallel"}, "--parallel requires a project config"},
+		{"missing config", []string{"--config", "other.yaml"}, "failed to read project config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newBuildCommand()
+			cmd.SetArgs(append(tt.args, "--in-memory"))
+			_, _, err := captureStdoutStderr(t, cmd.Execute)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	// An input file builds just that file, ignoring miaka.yaml
+	cmd := newBuildCommand()
+	cmd.SetArgs([]string{"worker/example.values.yaml", "--in-memory", "-c", "worker.crd.yaml", "-s", "worker.schema.json"})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "target(s)")
+	assert.FileExists(t, filepath.Join(dir, "worker.crd.yaml"))
+	assert.NoFileExists(t, filepath.Join(dir, "api/crd.yaml"))
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/crenshaw-dev/miaka/pkg/scan"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the build cache",
+	Long: `Manage the cache of generated outputs that 'miaka build' reads from and
+writes to, in $MIAKA_CACHE_DIR (default: miaka in the user's cache directory,
+e.g., ~/.cache/miaka on Linux).
+
+Entries are keyed by a hash of everything that determines the outputs, so a
+stale entry is never used, and the cache only needs cleaning to free disk
+space.`,
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove every entry of the build cache",
+	Long: `Remove every entry of the build cache, and the cache of 'miaka hook'
+(.miaka/cache) of the git repository of the current directory, if any.`,
+	Example: `  # Free the disk space of the cache
+  miaka cache clean`,
+	Args: cobra.NoArgs,
+	RunE: runCacheClean,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+var cacheDirCmd = &cobra.Command{
+	Use:   "dir",
+	Short: "Print the directory of the build cache",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheDir,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheCleanCmd)
+	cacheCmd.AddCommand(cacheDirCmd)
+}
+
+func runCacheClean(cmd *cobra.Command, _ []string) error {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return err
+	}
+	dirs := []string{dir}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if repo, err := gitOutput(ctx, ".", "rev-parse", "--show-toplevel"); err == nil {
+		dirs = append(dirs, filepath.Join(strings.TrimSpace(repo), scan.DefaultCacheDir))
+	}
+
+	removed := 0
+	for _, dir := range dirs {
+		entries, err := cache.New(dir).Clean()
+		if err != nil {
+			return err
+		}
+		removed += entries
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "✓ Removed %d cache entries\n", removed)
+	return nil
+}
+
+func runCacheDir(cmd *cobra.Command, _ []string) error {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), dir)
+	return nil
+}
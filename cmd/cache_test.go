@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCacheCommand creates a fresh cache command instance for testing
+func newCacheCommand() *cobra.Command {
+	clean := &cobra.Command{Use: "clean", Args: cobra.NoArgs, RunE: runCacheClean, SilenceUsage: true}
+	dir := &cobra.Command{Use: "dir", Args: cobra.NoArgs, RunE: runCacheDir, SilenceUsage: true}
+	cmd := &cobra.Command{Use: "cache"}
+	cmd.AddCommand(clean, dir)
+	return cmd
+}
+
+// runCache runs a cache subcommand and returns its output
+func runCache(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := newCacheCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	require.NoError(t, cmd.Execute())
+	return out.String()
+}
+
+// cacheEntries counts the entries of the cache in dir
+func cacheEntries(t *testing.T, dir string) int {
+	t.Helper()
+	entries := 0
+	_ = filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			entries++
+		}
+		return nil
+	})
+	return entries
+}
+
+func TestBuildCommand_Cache(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	t.Setenv(cache.DirEnv, cacheDir)
+	input, err := os.ReadFile(filepath.Join("..", "testdata", "build", "basic", "input.yaml"))
+	require.NoError(t, err)
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("example.values.yaml", input, 0644))
+
+	build := func(args ...string) {
+		t.Helper()
+		cmd := newBuildCommand()
+		cmd.SetArgs(append([]string{"--in-memory", "--deterministic"}, args...))
+		require.NoError(t, cmd.Execute())
+	}
+
+	// --no-cache doesn't write the cache
+	build("--no-cache")
+	assert.Equal(t, 0, cacheEntries(t, cacheDir))
+	uncached, err := os.ReadFile("crd.yaml")
+	require.NoError(t, err)
+
+	// The Go types, CRD, and JSON Schema are cached
+	build()
+	assert.Equal(t, 3, cacheEntries(t, cacheDir))
+	require.NoError(t, os.Remove("crd.yaml"))
+
+	// Building again uses the cached outputs, which match the generated ones
+	build()
+	assert.Equal(t, 3, cacheEntries(t, cacheDir))
+	cached, err := os.ReadFile("crd.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(uncached), string(cached))
+
+	// Options that change the outputs are part of the keys
+	build("--helm-compat")
+	assert.Equal(t, 6, cacheEntries(t, cacheDir))
+
+	assert.Equal(t, cacheDir+"\n", runCache(t, "dir"))
+	assert.Contains(t, runCache(t, "clean"), "Removed 6 cache entries")
+	assert.NoDirExists(t, cacheDir)
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/anonymize"
+	"github.com/crenshaw-dev/miaka/pkg/build/hints"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	crdmarkers "sigs.k8s.io/controller-tools/pkg/crd/markers"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+var capabilitiesOutput string
+
+// Capabilities describes the features supported by this miaka binary
+type Capabilities struct {
+	Version       string              `json:"version"`
+	Markers       []MarkerCapability  `json:"markers"`
+	OutputTargets []string            `json:"outputTargets"`
+	SchemaDrafts  SchemaDrafts        `json:"schemaDrafts"`
+	Commands      []CommandCapability `json:"commands"`
+}
+
+// MarkerCapability is a comment marker supported in example values files
+type MarkerCapability struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"` // "miaka" or "kubebuilder"
+	Summary    string `json:"summary,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+}
+
+// SchemaDrafts lists the schema dialects miaka generates and validates against
+type SchemaDrafts struct {
+	Generated []string `json:"generated"`
+	Validated []string `json:"validated"`
+}
+
+// CommandCapability is a CLI command and its flags
+type CommandCapability struct {
+	Name  string           `json:"name"`
+	Flags []FlagCapability `json:"flags,omitempty"`
+}
+
+// FlagCapability is a single command-line flag
+type FlagCapability struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type"`
+	Default   string `json:"default,omitempty"`
+	Usage     string `json:"usage"`
+}
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "List the features supported by this binary",
+	Long: `List the markers, output targets, schema drafts, commands, and flags
+supported by this miaka binary.
+
+Use --output json so wrappers and plugins can detect features reliably
+instead of parsing --help text, which may change between versions.`,
+	Example: `  # Human-readable summary
+  miaka capabilities
+
+  # Machine-readable output
+  miaka capabilities --output json | jq '.outputTargets'`,
+	Args: cobra.NoArgs,
+	RunE: runCapabilities,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+
+	capabilitiesCmd.Flags().StringVarP(&capabilitiesOutput, "output", "o", "text", "Output format: text or json")
+}
+
+func runCapabilities(cmd *cobra.Command, _ []string) error {
+	if err := checkOutputFormat(capabilitiesOutput); err != nil {
+		return err
+	}
+	caps, err := collectCapabilities()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if capabilitiesOutput == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(caps)
+	}
+	printCapabilities(out, caps)
+	return nil
+}
+
+// collectCapabilities gathers the capabilities of this binary
+func collectCapabilities() (*Capabilities, error) {
+	registry, err := newEmitterRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capabilities{
+		Version:       version,
+		Markers:       collectMarkers(),
+		OutputTargets: registry.Names(),
+		SchemaDrafts: SchemaDrafts{
+			Generated: []string{"openapi-v3 (CRD)", "draft-07"},
+			Validated: []string{"draft-04", "draft-06", "draft-07", "draft-2019-09", "draft-2020-12"},
+		},
+		Commands: collectCommands(rootCmd),
+	}, nil
+}
+
+// collectMarkers lists miaka's own markers and the kubebuilder field markers
+// understood by controller-gen
+func collectMarkers() []MarkerCapability {
+	result := []MarkerCapability{
+		{Name: hints.TypeMarker, Source: "miaka", Summary: "sets the Go type of a field whose type can't be inferred, or a semantic type (quantity, duration, int-or-string)"},
+		{Name: parsing.NullableMarker, Source: "miaka", Summary: "allows null, making the field a pointer in Go so unset and zero values differ"},
+		{Name: parsing.PreserveUnknownFieldsMarker, Source: "miaka", Summary: "allows and keeps fields an object doesn't declare, overriding strict validation"},
+		{Name: parsing.CRDMarker, Source: "miaka", Summary: "at the top of the document, adds subresources (subresource:status, subresource:scale), printer columns (printcolumn), or names and scope (resource) to the CRD"},
+		{Name: parsing.FormatMarker, Source: "miaka", Summary: "sets the format of a number (int32, int64, float, double), and so its Go type, or validates a common shape of value (imageref, duration, cidr, port)"},
+		{Name: parsing.ItemsMarker, Source: "miaka", Summary: "applies a kubebuilder:validation marker to the items of a list or the values of a map"},
+		{Name: parsing.RenamedFromMarker, Source: "miaka", Summary: "declares the old key of a renamed field: accepted by the breaking-change check, kept as a deprecated alias in the JSON Schema, and rewritten by 'miaka migrate'"},
+		{Name: parsing.CELMarker, Source: "miaka", Summary: "adds a CEL rule relating the fields of an object (rule, message): x-kubernetes-validations in the CRD, evaluated by 'miaka validate'"},
+		{Name: parsing.ListTypeMarker, Source: "miaka", Summary: "sets the merge semantics of a list (atomic, set, map): x-kubernetes-list-type in the CRD, with items checked for duplicates by 'miaka validate'"},
+		{Name: parsing.ListMapKeyMarker, Source: "miaka", Summary: "names a field that identifies the items of a map list, which is made required: x-kubernetes-list-map-keys in the CRD"},
+		{Name: parsing.NameMarker, Source: "miaka", Summary: "sets the Go name of a field, for keys that aren't valid Go identifiers (which are otherwise transliterated and reported)"},
+		{Name: parsing.TemplateMarker, Source: "miaka", Summary: "declares that a string field holds Helm template expressions the chart renders with tpl: typed as an opaque string without a warning"},
+		{Name: parsing.DeprecatedMarker, Source: "miaka", Summary: "deprecates a field, saying what to use instead: a Deprecated: paragraph in the Go types and descriptions, deprecated in the JSON Schema, and a warning from 'miaka validate'"},
+		{Name: anonymize.SecretMarker, Source: "miaka", Summary: "always masks the field in 'miaka anonymize'"},
+	}
+
+	seen := make(map[string]bool)
+	var kubebuilder []MarkerCapability
+	for _, def := range crdmarkers.AllDefinitions {
+		if def.Target != markers.DescribesField || seen[def.Name] {
+			continue
+		}
+		seen[def.Name] = true
+
+		marker := MarkerCapability{Name: "+" + def.Name, Source: "kubebuilder"}
+		if def.Help != nil {
+			marker.Summary = def.Help.Summary
+			marker.Deprecated = def.Help.DeprecatedInFavorOf != nil
+		}
+		kubebuilder = append(kubebuilder, marker)
+	}
+	sort.Slice(kubebuilder, func(i, j int) bool {
+		return kubebuilder[i].Name < kubebuilder[j].Name
+	})
+
+	return append(result, kubebuilder...)
+}
+
+// collectCommands lists the subcommands of a command, recursively, and their flags.
+// Nested commands are named by their path (e.g., "import schema").
+func collectCommands(root *cobra.Command) []CommandCapability {
+	var commands []CommandCapability
+	for _, c := range root.Commands() {
+		if c.Hidden || c.Name() == "help" || c.Name() == "completion" {
+			continue
+		}
+
+		name := strings.TrimPrefix(c.CommandPath(), c.Root().Name()+" ")
+		command := CommandCapability{Name: name}
+		c.Flags().VisitAll(func(f *pflag.Flag) {
+			if f.Hidden || f.Name == "help" {
+				return
+			}
+			command.Flags = append(command.Flags, FlagCapability{
+				Name:      f.Name,
+				Shorthand: f.Shorthand,
+				Type:      f.Value.Type(),
+				Default:   f.DefValue,
+				Usage:     f.Usage,
+			})
+		})
+		commands = append(commands, command)
+		commands = append(commands, collectCommands(c)...)
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// printCapabilities prints a human-readable summary
+func printCapabilities(out io.Writer, caps *Capabilities) {
+	fmt.Fprintf(out, "miaka %s\n\n", caps.Version)
+
+	fmt.Fprintln(out, "Output targets:")
+	for _, target := range caps.OutputTargets {
+		fmt.Fprintf(out, "  %s\n", target)
+	}
+
+	fmt.Fprintln(out, "\nSchema drafts:")
+	fmt.Fprintf(out, "  generated: %v\n", caps.SchemaDrafts.Generated)
+	fmt.Fprintf(out, "  validated: %v\n", caps.SchemaDrafts.Validated)
+
+	fmt.Fprintln(out, "\nMarkers:")
+	for _, marker := range caps.Markers {
+		fmt.Fprintf(out, "  %s\n", marker.Name)
+	}
+
+	fmt.Fprintln(out, "\nCommands:")
+	for _, command := range caps.Commands {
+		fmt.Fprintf(out, "  %s\n", command.Name)
+		for _, flag := range command.Flags {
+			fmt.Fprintf(out, "    --%s\n", flag.Name)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCapabilitiesCommand creates a fresh capabilities command instance for testing
+func newCapabilitiesCommand() *cobra.Command {
+	capabilitiesOutput = "text"
+
+	cmd := &cobra.Command{
+		Use:          "capabilities",
+		Args:         cobra.NoArgs,
+		RunE:         runCapabilities,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&capabilitiesOutput, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+func TestCapabilitiesCommand_JSON(t *testing.T) {
+	cmd := newCapabilitiesCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--output", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var caps Capabilities
+	require.NoError(t, json.Unmarshal(out.Bytes(), &caps))
+
+	assert.Equal(t, version, caps.Version)
+	assert.Equal(t, []string{"gotypes", "typescript", "crd", "jsonschema", "resourceschema", "helmtemplate", "deepcopy"}, caps.OutputTargets)
+	assert.Contains(t, caps.SchemaDrafts.Generated, "draft-07")
+
+	markers := make(map[string]MarkerCapability)
+	for _, m := range caps.Markers {
+		markers[m.Name] = m
+	}
+	assert.Equal(t, "miaka", markers["+miaka:type:"].Source)
+	assert.Equal(t, "miaka", markers["+miaka:secret"].Source)
+	assert.Equal(t, "miaka", markers["+miaka:items:"].Source)
+	assert.Equal(t, "miaka", markers["+miaka:nullable"].Source)
+	assert.Equal(t, "miaka", markers["+miaka:preserveUnknownFields"].Source)
+	assert.Equal(t, "miaka", markers["+miaka:crd:"].Source)
+	assert.Equal(t, "kubebuilder", markers["+kubebuilder:validation:Enum"].Source)
+	assert.NotEmpty(t, markers["+kubebuilder:validation:Enum"].Summary)
+
+	commands := make(map[string]CommandCapability)
+	for _, c := range caps.Commands {
+		commands[c.Name] = c
+	}
+	require.Contains(t, commands, "build")
+	require.Contains(t, commands, "capabilities")
+	require.Contains(t, commands, "import schema")
+	assert.NotContains(t, commands, "help")
+
+	flags := make(map[string]FlagCapability)
+	for _, f := range commands["build"].Flags {
+		flags[f.Name] = f
+	}
+	assert.Equal(t, FlagCapability{
+		Name:      "crd",
+		Shorthand: "c",
+		Type:      "string",
+		Default:   "crd.yaml",
+		Usage:     flags["crd"].Usage,
+	}, flags["crd"])
+	assert.Equal(t, "stringArray", flags["emit"].Type)
+}
+
+func TestCapabilitiesCommand_Text(t *testing.T) {
+	cmd := newCapabilitiesCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), "Output targets:\n  gotypes\n")
+	assert.Contains(t, out.String(), "  +miaka:type:\n")
+	assert.Contains(t, out.String(), "  build\n")
+}
+
+func TestCapabilitiesCommand_InvalidOutput(t *testing.T) {
+	cmd := newCapabilitiesCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"-o", "xml"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid output format "xml" (must be text or json)`)
+}
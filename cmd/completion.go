@@ -0,0 +1,25 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// Cobra provides the completion command ('miaka completion bash|zsh|fish|powershell').
+// The helpers below complete the arguments and flag values of miaka's commands.
+
+// yamlExtensions are the extensions of values files, for completion
+var yamlExtensions = []string{"yaml", "yml"}
+
+// completeYAMLFiles completes positional arguments with values files
+func completeYAMLFiles(_ *cobra.Command, _ []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return yamlExtensions, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeFlagValues completes a flag with a fixed set of values. Registering only
+// fails for unknown flags, which the completion tests catch.
+func completeFlagValues(cmd *cobra.Command, flag string, values ...string) {
+	_ = cmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// completeFlagFiles completes a flag with files with the given extensions
+func completeFlagFiles(cmd *cobra.Command, flag string, extensions ...string) {
+	_ = cmd.MarkFlagFilename(flag, extensions...)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// complete returns the completions miaka prints for the arguments
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&bytes.Buffer{})
+	rootCmd.SetArgs(append([]string{"__complete"}, args...))
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	})
+	require.NoError(t, rootCmd.Execute())
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestCompletion_FlagValues(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"build", "-o", ""}, []string{"text", "json", "sarif"}},
+		{[]string{"build", "--strict", ""}, []string{"true", "false", "warn"}},
+		{[]string{"build", "--scope", ""}, []string{"Namespaced", "Cluster"}},
+		{[]string{"validate", "--format", ""}, []string{"text", "github", "json", "sarif"}},
+		{[]string{"upgrade-check", "--output", ""}, []string{"text", "json"}},
+	} {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			got := complete(t, tt.args...)
+			// The last line is the shell directive
+			assert.Equal(t, tt.want, got[:len(got)-1])
+		})
+	}
+}
+
+func TestCompletion_ValuesFiles(t *testing.T) {
+	for _, command := range []string{"build", "validate", "migrate", "init", "upgrade-check"} {
+		t.Run(command, func(t *testing.T) {
+			// Required flags (of upgrade-check) are suggested first
+			got := complete(t, command, "")
+			assert.Equal(t, []string{"yaml", "yml", fmt.Sprintf(":%d", cobra.ShellCompDirectiveFilterFileExt)}, got[len(got)-3:])
+		})
+	}
+}
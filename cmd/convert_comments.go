@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/markers"
+	"github.com/spf13/cobra"
+)
+
+var convertCommentsCmd = &cobra.Command{
+	Use:   "convert-comments [example.values.yaml]...",
+	Short: "Rewrite helm-docs comments in values files as miaka comments and markers",
+	Long: `Rewrite the comments of values files from helm-docs syntax to miaka's, in
+place (example.values.yaml by default).
+
+miaka already reads helm-docs comments: "# -- " starts a description, and a
+default documented with "# @default -- " is added to the description without
+setting a default. convert-comments makes the conversion explicit:
+  - "# -- Port of the service" becomes "# Port of the service"
+  - "# @default -- ` + "`8080`" + `" becomes "# +kubebuilder:default=8080" if the
+    default is a literal number, boolean, or double-quoted string
+  - other documented defaults become descriptions, like
+    "# Default: ` + "`.Release.Namespace`" + `"
+  - annotations that only lay out helm-docs output, like "# @section -- Ingress",
+    are removed
+
+Other lines are kept as they are.`,
+	Example: `  # Convert example.values.yaml
+  miaka convert-comments
+
+  # Convert other values files
+  miaka convert-comments values/redis.yaml values/postgres.yaml`,
+	RunE: runConvertComments,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(convertCommentsCmd)
+
+	convertCommentsCmd.ValidArgsFunction = completeYAMLFiles
+}
+
+func runConvertComments(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{defaultExampleValuesFile}
+	}
+
+	out := cmd.OutOrStdout()
+	for _, path := range args {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		converted, changed := markers.ConvertHelmDocs(data)
+		if changed == 0 {
+			fmt.Fprintf(out, "✓ %s has no helm-docs comments\n", path)
+			continue
+		}
+		if err := os.WriteFile(path, converted, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(out, "✓ Converted %d helm-docs comment line(s) in %s\n", changed, path)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConvertCommentsCommand creates a fresh convert-comments command instance for testing
+func newConvertCommentsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "convert-comments",
+		RunE:         runConvertComments,
+		SilenceUsage: true,
+	}
+}
+
+func TestConvertCommentsCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	plainPath := filepath.Join(tmpDir, "plain.yaml")
+	require.NoError(t, os.WriteFile(examplePath, []byte("apiVersion: demo.io/v1\nkind: Demo\n# -- Number of replicas\n# @default -- `1`\nreplicas: 3\n"), 0600))
+	require.NoError(t, os.WriteFile(plainPath, []byte("# Number of replicas\nreplicas: 3\n"), 0644))
+
+	cmd := newConvertCommentsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{examplePath, plainPath})
+	require.NoError(t, cmd.Execute())
+
+	data, err := os.ReadFile(examplePath)
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion: demo.io/v1\nkind: Demo\n# Number of replicas\n# +kubebuilder:default=1\nreplicas: 3\n", string(data))
+	info, err := os.Stat(examplePath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		// Windows has no Unix permissions to keep
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	assert.Contains(t, out.String(), "Converted 2 helm-docs comment line(s)")
+	assert.Contains(t, out.String(), "plain.yaml has no helm-docs comments")
+
+	cmd = newConvertCommentsCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{filepath.Join(tmpDir, "missing.yaml")})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read")
+}
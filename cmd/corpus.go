@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/corpus"
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultCorpusDir = "corpus"
+
+// maxAttemptsPerDocument limits how many candidates are generated for each corpus document
+const maxAttemptsPerDocument = 20
+
+var (
+	corpusCRDPath    string
+	corpusSchemaPath string
+	corpusDir        string
+	corpusCount      int
+	corpusSeed       int64
+)
+
+var corpusCmd = &cobra.Command{
+	Use:   "corpus",
+	Short: "Maintain a regression corpus of valid values documents",
+	Long: `Maintain a committed corpus of representative valid values documents,
+generated from the schema, to catch schema edits that invalidate values that
+used to be valid - even without hand-written fixtures.
+
+Run 'miaka corpus update' once and commit the corpus directory. After editing
+the example values file and running 'miaka build', 'miaka corpus check' fails
+if any previously-valid document no longer validates.`,
+}
+
+var corpusUpdateCmd = &cobra.Command{
+	Use:   "update [example-file]",
+	Short: "Generate corpus documents and remove invalid ones",
+	Long: `Generate valid values documents from the CRD and add them to the corpus
+directory until it has --count documents. Documents are named by the hash of
+their content, so regenerating never duplicates them.
+
+Documents that no longer validate are removed, so run this only when a change
+that invalidates them is intentional. The example values file is always part
+of the corpus and supplies values that can't be generated (e.g., strings with
+a pattern or format).`,
+	Example: `  # Create or top up the corpus
+  miaka corpus update
+
+  # Larger corpus with a different seed
+  miaka corpus update --count 50 --seed 2`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCorpusUpdate,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+var corpusCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validate every corpus document against the current schemas",
+	Long: `Validate every document in the corpus directory against the CRD and
+JSON Schema. Fails if any document is invalid, which means a schema change
+would break values that used to be valid.`,
+	Example: `  # Check the corpus after rebuilding the schemas
+  miaka build && miaka corpus check`,
+	Args: cobra.NoArgs,
+	RunE: runCorpusCheck,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(corpusCmd)
+	corpusCmd.AddCommand(corpusUpdateCmd)
+	corpusCmd.AddCommand(corpusCheckCmd)
+
+	for _, c := range []*cobra.Command{corpusUpdateCmd, corpusCheckCmd} {
+		c.Flags().StringVarP(&corpusCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+		c.Flags().StringVarP(&corpusSchemaPath, "schema", "s", defaultSchemaPath, "Path to JSON Schema file (skipped if it doesn't exist)")
+		c.Flags().StringVarP(&corpusDir, "dir", "d", defaultCorpusDir, "Corpus directory")
+	}
+	corpusUpdateCmd.Flags().IntVar(&corpusCount, "count", 20, "Number of documents to keep in the corpus")
+	corpusUpdateCmd.Flags().Int64Var(&corpusSeed, "seed", 1, "Random seed for generating documents")
+}
+
+func runCorpusUpdate(_ *cobra.Command, args []string) error {
+	examplePath := defaultExampleValuesFile
+	if len(args) > 0 {
+		examplePath = args[0]
+	}
+	data, err := os.ReadFile(examplePath)
+	if err != nil {
+		return fmt.Errorf("failed to read example values file: %w", err)
+	}
+	example := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &example); err != nil {
+		return fmt.Errorf("failed to parse example values file: %w", err)
+	}
+	delete(example, "metadata")
+
+	validate, crd, err := loadCorpusValidator()
+	if err != nil {
+		return err
+	}
+	props := crdVersionSchema(crd, example)
+	if props == nil {
+		return fmt.Errorf("no schema found for %v in %s", example["apiVersion"], corpusCRDPath)
+	}
+
+	entries, err := corpus.Load(corpusDir)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	removed := 0
+	for _, entry := range entries {
+		if problems := validate(entry.Values); len(problems) > 0 {
+			if err := corpus.Remove(corpusDir, entry.Name); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s: %s\n", entry.Name, problems[0])
+			removed++
+			continue
+		}
+		existing[entry.Name] = true
+	}
+
+	added := 0
+	add := func(doc map[string]interface{}) error {
+		name, _, err := corpus.Encode(doc)
+		if err != nil || existing[name] || len(validate(doc)) > 0 {
+			return err
+		}
+		if _, err := corpus.Write(corpusDir, doc); err != nil {
+			return err
+		}
+		existing[name] = true
+		added++
+		return nil
+	}
+
+	if err := add(example); err != nil {
+		return err
+	}
+	generator := corpus.NewGenerator(props, example, corpusSeed)
+	for attempt := 0; len(existing) < corpusCount && attempt < corpusCount*maxAttemptsPerDocument; attempt++ {
+		if err := add(generator.Document()); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✓ Corpus %s has %d documents (%d added, %d removed)\n", corpusDir, len(existing), added, removed)
+	if len(existing) < corpusCount {
+		fmt.Printf("⚠️  Could only generate %d of %d valid documents; add more example values or try another --seed\n",
+			len(existing), corpusCount)
+	}
+	return nil
+}
+
+func runCorpusCheck(_ *cobra.Command, _ []string) error {
+	validate, _, err := loadCorpusValidator()
+	if err != nil {
+		return err
+	}
+
+	entries, err := corpus.Load(corpusDir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("corpus %s is empty (run 'miaka corpus update' first)", corpusDir)
+	}
+
+	invalid := 0
+	for _, entry := range entries {
+		problems := validate(entry.Values)
+		if len(problems) == 0 {
+			continue
+		}
+		invalid++
+		fmt.Printf("✗ %s:\n", entry.Name)
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d corpus documents are no longer valid (run 'miaka corpus update' if the change is intentional)",
+			invalid, len(entries))
+	}
+	fmt.Printf("✓ All %d corpus documents are valid\n", len(entries))
+	return nil
+}
+
+// loadCorpusValidator loads the CRD and optional JSON Schema and returns a
+// function listing the problems with a document
+func loadCorpusValidator() (func(map[string]interface{}) []string, *apiextensionsv1.CustomResourceDefinition, error) {
+	crd, err := loadCRD(corpusCRDPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var schemaJSON []byte
+	if _, err := os.Stat(corpusSchemaPath); err == nil {
+		if schemaJSON, err = os.ReadFile(corpusSchemaPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+	}
+
+	validate := func(values map[string]interface{}) []string {
+		var problems []string
+		errs, err := validation.ValidateResource(crd, values)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		for _, e := range errs {
+			problems = append(problems, e.Error())
+		}
+
+		if schemaJSON != nil {
+			if err := validation.ValidateValues(values, schemaJSON); err != nil {
+				violations := validation.Violations(err)
+				if violations == nil {
+					return append(problems, err.Error())
+				}
+				for _, v := range violations {
+					problems = append(problems, fmt.Sprintf("%s: %s", strings.Join(v.Path, "."), v.Message))
+				}
+			}
+		}
+		return problems
+	}
+	return validate, crd, nil
+}
+
+// crdVersionSchema returns the schema of the CRD version matching a document's apiVersion
+func crdVersionSchema(crd *apiextensionsv1.CustomResourceDefinition, values map[string]interface{}) *apiextensionsv1.JSONSchemaProps {
+	apiVersion, _ := values["apiVersion"].(string)
+	for _, version := range crd.Spec.Versions {
+		if crd.Spec.Group+"/"+version.Name == apiVersion && version.Schema != nil {
+			return version.Schema.OpenAPIV3Schema
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCorpusCommand creates a fresh corpus command instance for testing
+func newCorpusCommand() *cobra.Command {
+	corpusCRDPath = defaultCRDPath
+	corpusSchemaPath = defaultSchemaPath
+	corpusDir = defaultCorpusDir
+	corpusCount = 20
+	corpusSeed = 1
+
+	update := &cobra.Command{Use: "update [example-file]", Args: cobra.MaximumNArgs(1), RunE: runCorpusUpdate, SilenceUsage: true}
+	check := &cobra.Command{Use: "check", Args: cobra.NoArgs, RunE: runCorpusCheck, SilenceUsage: true}
+	for _, c := range []*cobra.Command{update, check} {
+		c.Flags().StringVarP(&corpusCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+		c.Flags().StringVarP(&corpusSchemaPath, "schema", "s", defaultSchemaPath, "Path to JSON Schema file (skipped if it doesn't exist)")
+		c.Flags().StringVarP(&corpusDir, "dir", "d", defaultCorpusDir, "Corpus directory")
+	}
+	update.Flags().IntVar(&corpusCount, "count", 20, "Number of documents to keep in the corpus")
+	update.Flags().Int64Var(&corpusSeed, "seed", 1, "Random seed for generating documents")
+
+	cmd := &cobra.Command{Use: "corpus"}
+	cmd.AddCommand(update, check)
+	return cmd
+}
+
+// runCorpus runs a corpus subcommand and returns its stdout
+func runCorpus(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	stdout, _, err := captureStdoutStderr(t, func() error {
+		cmd := newCorpusCommand()
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	})
+	return stdout, err
+}
+
+func TestCorpusCommand(t *testing.T) {
+	testdata := filepath.Join("..", "testdata", "build", "basic")
+	dir := t.TempDir()
+	for src, dst := range map[string]string{
+		"input.yaml":           "example.values.yaml",
+		"expected_crd.yaml":    "crd.yaml",
+		"expected_schema.json": "values.schema.json",
+	} {
+		data, err := os.ReadFile(filepath.Join(testdata, src))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, dst), data, 0644))
+	}
+	t.Chdir(dir)
+
+	// An empty corpus fails the check
+	_, err := runCorpus(t, "check")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is empty")
+
+	stdout, err := runCorpus(t, "update", "--count", "5")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "has 5 documents (5 added, 0 removed)")
+	files, err := os.ReadDir(defaultCorpusDir)
+	require.NoError(t, err)
+	assert.Len(t, files, 5)
+
+	// Updating again is a no-op
+	stdout, err = runCorpus(t, "update", "--count", "5")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "(0 added, 0 removed)")
+
+	stdout, err = runCorpus(t, "check")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "All 5 corpus documents are valid")
+
+	// A stricter schema invalidates previously-valid documents
+	require.NoError(t, os.WriteFile(defaultSchemaPath, []byte(`{"required": ["doesNotExist"]}`), 0644))
+	stdout, err = runCorpus(t, "check")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 of 5 corpus documents are no longer valid")
+	assert.Contains(t, stdout, "doesNotExist")
+
+	// Updating removes them, and nothing valid can be generated
+	stdout, err = runCorpus(t, "update", "--count", "5")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "(0 added, 5 removed)")
+	assert.Contains(t, stdout, "Could only generate 0 of 5")
+}
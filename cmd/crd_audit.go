@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/crenshaw-dev/miaka/pkg/crdaudit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	crdAuditCRDPath string
+	crdAuditOutput  string
+)
+
+var crdAuditCmd = &cobra.Command{
+	Use:   "crd-audit [chart-dir]",
+	Short: "Check that the CRDs shipped in a chart match the generated CRD",
+	Long: `Compare the generated CRD with the CRDs shipped under the chart's crds/
+and templates/ directories, and report drift: shipped CRDs of the same group
+and kind whose schemas differ, or that have different versions.
+
+Hand-copied CRDs that aren't updated when the values change are a common
+source of mismatches between the cluster and the schema. Run this in CI after
+'miaka build' to catch them.
+
+Templates are parsed after removing lines that only hold template actions,
+such as {{- if .Values.installCRDs }}. Templates that look like CRDs but still
+can't be parsed are reported and skipped. Shipped CRDs of other kinds are
+ignored.
+
+The chart directory defaults to the current directory.`,
+	Example: `  # Compare crd.yaml with the CRDs shipped in the current chart
+  miaka crd-audit
+
+  # Compare a CRD with the CRDs shipped in another chart
+  miaka crd-audit -c build/crd.yaml charts/myapp
+
+  # Machine-readable output
+  miaka crd-audit -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCRDAudit,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(crdAuditCmd)
+
+	crdAuditCmd.Flags().StringVarP(&crdAuditCRDPath, "crd", "c", defaultCRDPath, "Path to the generated CRD YAML file")
+	crdAuditCmd.Flags().StringVarP(&crdAuditOutput, "output", "o", "text", "Output format: text or json")
+}
+
+func runCRDAudit(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(crdAuditOutput); err != nil {
+		return err
+	}
+	chartDir := "."
+	if len(args) > 0 {
+		chartDir = args[0]
+	}
+
+	generated, err := crdaudit.LoadFile(crdAuditCRDPath)
+	if err != nil {
+		return fmt.Errorf("failed to load CRD: %w", err)
+	}
+	if len(generated) == 0 {
+		return fmt.Errorf("no CRD found in %s", crdAuditCRDPath)
+	}
+	shipped, skipped, err := crdaudit.LoadShipped(chartDir)
+	if err != nil {
+		return fmt.Errorf("failed to load shipped CRDs: %w", err)
+	}
+	report := crdaudit.Audit(generated, shipped)
+	report.Skipped = skipped
+
+	out := cmd.OutOrStdout()
+	if crdAuditOutput == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		printCRDAudit(out, chartDir, report)
+	}
+
+	if report.HasDrift() {
+		return fmt.Errorf("%d shipped CRD version(s) have drifted from %s", len(report.Drifts), crdAuditCRDPath)
+	}
+	return nil
+}
+
+// printCRDAudit prints a human-readable audit report
+func printCRDAudit(out io.Writer, chartDir string, report *crdaudit.Report) {
+	for _, path := range report.Skipped {
+		fmt.Fprintf(out, "⚠️  Skipped %s: it looks like a CRD but can't be parsed\n", path)
+	}
+
+	if len(report.Compared) == 0 {
+		fmt.Fprintf(out, "✓ No CRDs of the generated kinds are shipped in %s\n", chartDir)
+		return
+	}
+
+	drifted := make(map[string]bool)
+	for _, drift := range report.Drifts {
+		drifted[drift.Source] = true
+	}
+	for _, source := range report.Compared {
+		if !drifted[source] {
+			fmt.Fprintf(out, "✓ %s matches the generated CRD\n", source)
+		}
+	}
+
+	for _, drift := range report.Drifts {
+		fmt.Fprintf(out, "✗ %s/%s %s in %s has drifted:\n", drift.Group, drift.Kind, drift.Version, drift.Source)
+		if drift.Message != "" {
+			fmt.Fprintf(out, "  - %s\n", drift.Message)
+		}
+		for _, difference := range drift.Differences {
+			fmt.Fprintf(out, "  - %s\n", difference)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/crdaudit"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCRDAuditCommand creates a fresh crd-audit command instance for testing
+func newCRDAuditCommand() *cobra.Command {
+	crdAuditCRDPath = defaultCRDPath
+	crdAuditOutput = "text"
+
+	cmd := &cobra.Command{
+		Use:          "crd-audit [chart-dir]",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runCRDAudit,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&crdAuditCRDPath, "crd", "c", defaultCRDPath, "Path to the generated CRD YAML file")
+	cmd.Flags().StringVarP(&crdAuditOutput, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// writeShippedCRD copies the basic testdata CRD into a chart, applying replacements
+func writeShippedCRD(t *testing.T, chartDir, name string, replacements ...string) {
+	t.Helper()
+	crd, err := os.ReadFile(filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"))
+	require.NoError(t, err)
+	path := filepath.Join(chartDir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(strings.NewReplacer(replacements...).Replace(string(crd))), 0644))
+}
+
+func TestCRDAuditCommand(t *testing.T) {
+	chartDir := t.TempDir()
+	writeShippedCRD(t, chartDir, "crds/crd.yaml")
+
+	cmd := newCRDAuditCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"), chartDir})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "✓ "+filepath.Join(chartDir, "crds", "crd.yaml")+" matches the generated CRD\n", out.String())
+}
+
+func TestCRDAuditCommand_Drift(t *testing.T) {
+	chartDir := t.TempDir()
+	writeShippedCRD(t, chartDir, "templates/crd.yaml", "type: integer", "type: string")
+
+	cmd := newCRDAuditCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"), chartDir})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 shipped CRD version(s) have drifted")
+
+	assert.Contains(t, out.String(), "✗ example.com/Example v1alpha1 in "+filepath.Join(chartDir, "templates", "crd.yaml")+" has drifted:\n")
+	assert.Contains(t, out.String(), `  - replicas: type is "string" in the shipped CRD, "integer" in the generated CRD`)
+}
+
+func TestCRDAuditCommand_JSON(t *testing.T) {
+	chartDir := t.TempDir()
+	writeShippedCRD(t, chartDir, "crds/crd.yaml", "type: integer", "type: string")
+
+	cmd := newCRDAuditCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"), "-o", "json", chartDir})
+	require.Error(t, cmd.Execute())
+
+	var report crdaudit.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, "Example", report.Drifts[0].Kind)
+	assert.NotEmpty(t, report.Drifts[0].Differences)
+}
+
+func TestCRDAuditCommand_NothingShipped(t *testing.T) {
+	chartDir := t.TempDir()
+
+	cmd := newCRDAuditCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"), chartDir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "No CRDs of the generated kinds are shipped")
+}
+
+func TestCRDAuditCommand_Errors(t *testing.T) {
+	cmd := newCRDAuditCommand()
+	cmd.SetArgs([]string{"-c", filepath.Join(t.TempDir(), "missing.yaml")})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load CRD")
+
+	cmd = newCRDAuditCommand()
+	cmd.SetArgs([]string{"-o", "xml"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid output format "xml" (must be text or json)`)
+}
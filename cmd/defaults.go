@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/defaults"
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	defaultsOutput string
+	defaultsDiff   string
+)
+
+var defaultsCmd = &cobra.Command{
+	Use:   "defaults [example.values.yaml]",
+	Short: "Print a values document with every field set to its default",
+	Long: `Print a fully-populated values document with every field set to its
+default.
+
+Fields with a +kubebuilder:default marker get the default; other fields keep
+their value from the example values file. Defaults are applied inside
+defaulted objects too, the same way the Kubernetes API server applies them.
+
+With --diff, print only the values in a values file that differ from the
+defaults, to see what a user actually customized.`,
+	Example: `  # Print the defaults of example.values.yaml
+  miaka defaults
+
+  # As JSON, e.g., for golden tests
+  miaka defaults -o json
+
+  # Show what my-values.yaml changes from the defaults
+  miaka defaults --diff my-values.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDefaults,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(defaultsCmd)
+
+	defaultsCmd.Flags().StringVarP(&defaultsOutput, "output", "o", "yaml", "Output format: yaml or json")
+	defaultsCmd.Flags().StringVar(&defaultsDiff, "diff", "", "Print the values in this file that differ from the defaults")
+}
+
+func runDefaults(cmd *cobra.Command, args []string) error {
+	if err := checkFormat(defaultsOutput, "yaml", outputJSON); err != nil {
+		return err
+	}
+
+	inputFile := defaultExampleValuesFile
+	if len(args) > 0 {
+		inputFile = args[0]
+	}
+	values, err := materializeDefaults(inputFile)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if defaultsDiff == "" {
+		return writeValues(out, values)
+	}
+
+	data, err := os.ReadFile(defaultsDiff)
+	if err != nil {
+		return fmt.Errorf("failed to read values file: %w", err)
+	}
+	userValues := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &userValues); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", defaultsDiff, err)
+	}
+	return writeChanges(out, defaults.Diff(values, userValues))
+}
+
+// materializeDefaults generates the schema of an example values file in memory
+// and applies its defaults to the example values
+func materializeDefaults(inputFile string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	s, err := parsing.NewParser().Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", inputFile, err)
+	}
+	files, err := crd.NewInMemoryEmitter().Emit(*s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	generated := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(files[0].Content, generated); err != nil {
+		return nil, fmt.Errorf("failed to parse generated CRD: %w", err)
+	}
+	if len(generated.Spec.Versions) == 0 || generated.Spec.Versions[0].Schema == nil {
+		return nil, fmt.Errorf("no schema found in generated CRD")
+	}
+
+	example := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &example); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", inputFile, err)
+	}
+	return defaults.Materialize(example, generated.Spec.Versions[0].Schema.OpenAPIV3Schema)
+}
+
+// writeValues writes values in the --output format
+func writeValues(out io.Writer, values interface{}) error {
+	var data []byte
+	var err error
+	if defaultsOutput == "json" {
+		data, err = json.MarshalIndent(values, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(values)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode values: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// writeChanges writes the values that differ from the defaults in the --output format
+func writeChanges(out io.Writer, changes []defaults.Change) error {
+	if defaultsOutput == "json" {
+		if changes == nil {
+			changes = []defaults.Change{}
+		}
+		return writeValues(out, changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "✓ %s only uses default values\n", defaultsDiff)
+		return nil
+	}
+	fmt.Fprintf(out, "%s changes %d value(s) from the defaults:\n", defaultsDiff, len(changes))
+	for _, change := range changes {
+		def := "(unset)"
+		if change.Default != nil {
+			def = compactJSON(change.Default)
+		}
+		fmt.Fprintf(out, "  %s: %s → %s\n", strings.Join(change.Path, "."), def, compactJSON(change.Value))
+	}
+	return nil
+}
+
+// compactJSON renders a value on one line
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDefaultsCommand creates a fresh defaults command instance for testing
+func newDefaultsCommand() *cobra.Command {
+	defaultsOutput = "yaml"
+	defaultsDiff = ""
+
+	cmd := &cobra.Command{
+		Use:          "defaults",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runDefaults,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&defaultsOutput, "output", "o", "yaml", "Output format: yaml or json")
+	cmd.Flags().StringVar(&defaultsDiff, "diff", "", "Print the values that differ from the defaults")
+
+	return cmd
+}
+
+const defaultsExample = `apiVersion: demo.io/v1
+kind: Demo
+# +kubebuilder:default=2
+replicas: 5
+service:
+  # +kubebuilder:default="ClusterIP"
+  type: LoadBalancer
+  port: 80
+`
+
+// writeDefaultsExample writes an example values file with default markers
+func writeDefaultsExample(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "example.values.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(defaultsExample), 0644))
+	return path
+}
+
+func TestDefaultsCommand(t *testing.T) {
+	cmd := newDefaultsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{writeDefaultsExample(t)})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, `apiVersion: demo.io/v1
+kind: Demo
+replicas: 2
+service:
+  port: 80
+  type: ClusterIP
+`, out.String())
+}
+
+func TestDefaultsCommand_JSON(t *testing.T) {
+	cmd := newDefaultsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{writeDefaultsExample(t), "-o", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var values map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &values))
+	assert.Equal(t, float64(2), values["replicas"])
+}
+
+func TestDefaultsCommand_Diff(t *testing.T) {
+	example := writeDefaultsExample(t)
+	valuesPath := filepath.Join(t.TempDir(), "my-values.yaml")
+	require.NoError(t, os.WriteFile(valuesPath, []byte("replicas: 3\nservice:\n  type: ClusterIP\nextra: true\n"), 0644))
+
+	cmd := newDefaultsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{example, "--diff", valuesPath})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, valuesPath+` changes 2 value(s) from the defaults:
+  extra: (unset) → true
+  replicas: 2 → 3
+`, out.String())
+
+	// Values that match the defaults
+	require.NoError(t, os.WriteFile(valuesPath, []byte("replicas: 2\n"), 0644))
+	cmd = newDefaultsCommand()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{example, "--diff", valuesPath})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "✓ "+valuesPath+" only uses default values\n", out.String())
+
+	cmd = newDefaultsCommand()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{example, "--diff", valuesPath, "-o", "json"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestDefaultsCommand_Errors(t *testing.T) {
+	cmd := newDefaultsCommand()
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing.yaml")})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read input file")
+
+	cmd = newDefaultsCommand()
+	cmd.SetArgs([]string{writeDefaultsExample(t), "--diff", filepath.Join(t.TempDir(), "missing.yaml")})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read values file")
+
+	cmd = newDefaultsCommand()
+	cmd.SetArgs([]string{"-o", "toml"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid output format "toml" (must be yaml or json)`)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/structdiff"
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Report the structural differences between two JSON or YAML documents",
+	Long: `Compare two JSON or YAML documents (e.g., two versions of a CRD or
+values.schema.json) by structure instead of by line, and report each
+difference at its path:
+
+  spec.properties.controller.properties.replicas: type changed integer -> string
+
+Objects are compared key by key, so reordered keys and formatting don't
+matter, and a JSON document can be compared with a YAML one. Lists are
+compared item by item after matching the items both have, so an item inserted
+into a list is reported once. Numbers are compared by value.
+
+diff fails if the documents differ.`,
+	Example: `  # Compare the committed CRD with a new build
+  miaka diff crd.yaml /tmp/crd.yaml
+
+  # A result object with the differences, for tools that wrap miaka
+  miaka diff old.schema.json values.schema.json -o json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", outputText, "Output format: text, or json for a result object with the differences")
+
+	diffCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagValues(diffCmd, "output", outputText, outputJSON)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(diffOutput); err != nil {
+		return err
+	}
+	out := commandOut(cmd)
+	if diffOutput == outputJSON {
+		// The result replaces the report
+		out = io.Discard
+	}
+	result := commandResult{Command: "diff"}
+	err := diffDocuments(out, args[0], args[1], &result)
+	if diffOutput == outputJSON {
+		return writeResult(commandOut(cmd), result, err)
+	}
+	return err
+}
+
+// diffDocuments compares two documents, printing the report to out and
+// recording the differences in result
+func diffDocuments(out io.Writer, oldPath, newPath string, result *commandResult) error {
+	oldData, err := os.ReadFile(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", oldPath, err)
+	}
+	newData, err := os.ReadFile(newPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", newPath, err)
+	}
+	oldValue, err := structdiff.Parse(oldData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newValue, err := structdiff.Parse(newData)
+	if err != nil {
+		return fmt.Errorf("%s: %w", newPath, err)
+	}
+
+	changes := structdiff.Compare(oldValue, newValue)
+	result.Diff = changes
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "✓ %s and %s are structurally equal\n", oldPath, newPath)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Differences from %s to %s (%d):\n", oldPath, newPath, len(changes))
+	for _, change := range changes {
+		fmt.Fprintf(out, "  - %s\n", change)
+	}
+	return fmt.Errorf("%d difference(s) between %s and %s", len(changes), oldPath, newPath)
+}
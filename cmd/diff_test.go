@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDiffCommand creates a fresh diff command instance for testing
+func newDiffCommand() *cobra.Command {
+	diffOutput = outputText
+
+	cmd := &cobra.Command{
+		Use:          "diff",
+		Args:         cobra.ExactArgs(2),
+		RunE:         runDiff,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&diffOutput, "output", "o", outputText, "Output format: text or json")
+	return cmd
+}
+
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.yaml")
+	newPath := filepath.Join(dir, "new.json")
+	require.NoError(t, os.WriteFile(oldPath, []byte("properties:\n  replicas:\n    type: integer\n  name:\n    type: string\n"), 0644))
+
+	run := func(args ...string) (string, error) {
+		cmd := newDiffCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	// Formatting and key order don't matter
+	require.NoError(t, os.WriteFile(newPath, []byte(`{"properties": {"name": {"type": "string"}, "replicas": {"type": "integer"}}}`), 0644))
+	out, err := run(oldPath, newPath)
+	require.NoError(t, err)
+	assert.Contains(t, out, "are structurally equal")
+
+	require.NoError(t, os.WriteFile(newPath, []byte(`{"properties": {"replicas": {"type": "string"}}}`), 0644))
+	out, err = run(oldPath, newPath)
+	require.ErrorContains(t, err, "2 difference(s)")
+	assert.Contains(t, out, `  - properties.name: removed {"type":"string"}`)
+	assert.Contains(t, out, "  - properties.replicas: type changed integer -> string")
+
+	out, err = run(oldPath, newPath, "-o", "json")
+	require.Error(t, err)
+	var result commandResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, statusFailed, result.Status)
+	require.Len(t, result.Diff, 2)
+	assert.Equal(t, []string{"properties", "replicas", "type"}, result.Diff[1].Path)
+	assert.Equal(t, "integer", result.Diff[1].Old)
+
+	_, err = run(oldPath, filepath.Join(dir, "missing.yaml"))
+	require.ErrorContains(t, err, "failed to read")
+}
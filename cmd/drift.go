@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/crenshaw-dev/miaka/pkg/oci"
+	"github.com/crenshaw-dev/miaka/pkg/upgrade"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	driftExample   string
+	driftOutput    string
+	driftPlainHTTP bool
+)
+
+// driftKRMKeys are the top-level keys of example values that upstream values don't have
+var driftKRMKeys = []string{"apiVersion", "kind", "metadata"}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift [values.yaml]",
+	Short: "Report structural drift between example values and upstream values",
+	Long: `Compare the structure of the example values with an upstream values.yaml and
+report keys added, removed, renamed, or retyped upstream, without regenerating
+anything.
+
+The upstream values can be:
+  - a values file (default: values.yaml)
+  - a chart directory or packaged .tgz archive
+  - an OCI chart reference (oci://registry/repository:version), pulled with
+    the same credentials as 'miaka push'
+
+Types are inferred from the values. Null values match any type, and the
+contents of empty objects and lists aren't compared, since examples fill in
+placeholders like 'resources: {}'. apiVersion, kind, and metadata of the
+example values are ignored.
+
+drift fails if the structure drifted; 'miaka init --merge' adds the keys
+added upstream to the example values.`,
+	Example: `  # Compare example.values.yaml with values.yaml
+  miaka drift
+
+  # Compare with a newer version of the upstream chart
+  miaka drift oci://registry-1.docker.io/bitnamicharts/redis:18.1.0 -e redis.example.yaml
+
+  # A result object with the changes, for tools that wrap miaka
+  miaka drift -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDrift,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().StringVarP(&driftExample, "example", "e", defaultExampleValuesFile, "Path to the example values file")
+	driftCmd.Flags().StringVarP(&driftOutput, "output", "o", outputText, "Output format: text, or json for a result object with the changes")
+	driftCmd.Flags().BoolVar(&driftPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS to pull oci:// charts")
+
+	driftCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagValues(driftCmd, "output", outputText, outputJSON)
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(driftOutput); err != nil {
+		return err
+	}
+	out := commandOut(cmd)
+	if driftOutput == outputJSON {
+		// The result replaces the report
+		out = io.Discard
+	}
+	result := commandResult{Command: "drift"}
+	err := drift(commandContext(cmd), out, args, &result)
+	if driftOutput == outputJSON {
+		return writeResult(commandOut(cmd), result, err)
+	}
+	return err
+}
+
+// drift compares the example values with the upstream values, printing the
+// report to out and recording the changes in result
+func drift(ctx context.Context, out io.Writer, args []string, result *commandResult) error {
+	upstream := chart.ValuesFile
+	if len(args) > 0 {
+		upstream = args[0]
+	}
+
+	exampleData, err := os.ReadFile(driftExample)
+	if err != nil {
+		return fmt.Errorf("failed to read example values: %w", err)
+	}
+	exampleValues, err := parseDriftValues(driftExample, exampleData)
+	if err != nil {
+		return err
+	}
+	upstreamData, err := readUpstreamValues(ctx, upstream)
+	if err != nil {
+		return fmt.Errorf("failed to read upstream values: %w", err)
+	}
+	upstreamValues, err := parseDriftValues(upstream, upstreamData)
+	if err != nil {
+		return err
+	}
+	for _, key := range driftKRMKeys {
+		if _, ok := upstreamValues[key]; !ok {
+			delete(exampleValues, key)
+		}
+	}
+
+	changes := upgrade.DiffValues(exampleValues, upstreamValues)
+	result.Changes = changes
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "✓ %s has the structure of %s\n", driftExample, upstream)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Drift from %s to %s (%d):\n", driftExample, upstream, len(changes))
+	for _, change := range changes {
+		fmt.Fprintf(out, "  - %s\n", change)
+	}
+	return fmt.Errorf("%d structural change(s) between %s and %s (run 'miaka init --merge' to add new keys)", len(changes), driftExample, upstream)
+}
+
+// parseDriftValues parses the values read from path
+func parseDriftValues(path string, data []byte) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// readUpstreamValues reads a values file, or the values.yaml of a chart
+// directory, archive, or OCI chart reference
+func readUpstreamValues(ctx context.Context, upstream string) ([]byte, error) {
+	if !oci.IsReference(upstream) {
+		if info, err := os.Stat(upstream); chart.IsArchive(upstream) || (err == nil && info.IsDir()) {
+			return chart.ReadFile(upstream, chart.ValuesFile)
+		}
+		return os.ReadFile(upstream)
+	}
+
+	ref, err := oci.ParseReference(upstream)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newOCIClient(driftPlainHTTP)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := client.PullChart(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull %s: %w", upstream, err)
+	}
+	return chart.ReadArchiveFile(bytes.NewReader(archive), chart.ValuesFile)
+}
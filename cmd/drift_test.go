@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDriftCommand creates a fresh drift command instance for testing
+func newDriftCommand() *cobra.Command {
+	driftExample = defaultExampleValuesFile
+	driftOutput = outputText
+	driftPlainHTTP = false
+
+	cmd := &cobra.Command{
+		Use:          "drift",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runDrift,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&driftExample, "example", "e", defaultExampleValuesFile, "Path to the example values file")
+	cmd.Flags().StringVarP(&driftOutput, "output", "o", outputText, "Output format: text or json")
+	cmd.Flags().BoolVar(&driftPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS")
+
+	return cmd
+}
+
+const driftExampleValues = `apiVersion: myapp.io/v1
+kind: MyApp
+replicas: 3
+resources:
+  limits:
+    cpu: 100m
+`
+
+func TestDriftCommand(t *testing.T) {
+	dir := t.TempDir()
+	examplePath := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(examplePath, []byte(driftExampleValues), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicas: 1\nresources: {}\n"), 0644))
+
+	cmd := newDriftCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{filepath.Join(dir, "values.yaml"), "-e", examplePath})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "has the structure of")
+
+	// Drift in a chart directory's values
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicas: \"1\"\nimage: nginx\n"), 0644))
+	cmd = newDriftCommand()
+	out.Reset()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{dir, "-e", examplePath})
+	err := cmd.Execute()
+	require.ErrorContains(t, err, "3 structural change(s)")
+	assert.Contains(t, out.String(), "image: added")
+	assert.Contains(t, out.String(), "replicas: type changed number -> string")
+	assert.Contains(t, out.String(), "resources: removed")
+}
+
+func TestDriftCommand_ChartArchive(t *testing.T) {
+	dir := t.TempDir()
+	examplePath := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(examplePath, []byte(driftExampleValues), 0644))
+	archivePath := filepath.Join(dir, "my-app-1.0.0.tgz")
+	require.NoError(t, os.WriteFile(archivePath, chartArchive(t, "my-app", map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		"values.yaml": "replicas: 1\nresources: {}\nport: 80\n",
+	}), 0644))
+
+	cmd := newDriftCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{archivePath, "-e", examplePath, "-o", "json"})
+	require.Error(t, cmd.Execute())
+
+	var result commandResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "drift", result.Command)
+	require.Len(t, result.Changes, 1)
+	assert.Equal(t, "port", result.Changes[0].Path)
+}
+
+func TestDriftCommand_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	cmd := newDriftCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{filepath.Join(dir, "values.yaml"), "-e", filepath.Join(dir, "missing.yaml")})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read example values")
+
+	examplePath := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(examplePath, []byte(driftExampleValues), 0644))
+	cmd = newDriftCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{filepath.Join(dir, "values.yaml"), "-e", examplePath})
+	assert.ErrorContains(t, cmd.Execute(), "failed to read upstream values")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/fn"
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var fnCRDPath string
+
+var fnCmd = &cobra.Command{
+	Use:   "fn",
+	Short: "Run validation as a KRM function",
+	Long: `Run miaka validation as a KRM function for kustomize and kpt pipelines.
+
+Reads a ResourceList from stdin, validates every resource whose apiVersion and
+kind match the CRD, and writes the ResourceList to stdout with validation
+failures added to its results. Resources are passed through unchanged.
+
+The CRD is read from --crd, unless the ResourceList's functionConfig is itself
+a CustomResourceDefinition. The command exits non-zero if any resource is
+invalid, so pipelines stop on invalid values.`,
+	Example: `  # Validate resources from kustomize
+  kustomize build . | kustomize fn source | miaka fn --crd crd.yaml
+
+  # As an exec function in a kustomization (kustomize build --enable-exec)
+  # transformers:
+  # - |-
+  #   apiVersion: apiextensions.k8s.io/v1
+  #   kind: CustomResourceDefinition
+  #   metadata:
+  #     name: myapps.myapp.io
+  #     annotations:
+  #       config.kubernetes.io/function: |
+  #         exec:
+  #           path: miaka
+  #           args: [fn]
+  #   spec: ...`,
+	Args: cobra.NoArgs,
+	RunE: runFn,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(fnCmd)
+
+	fnCmd.Flags().StringVarP(&fnCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file (ignored if the functionConfig is a CRD)")
+}
+
+func runFn(cmd *cobra.Command, _ []string) error {
+	input, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("failed to read ResourceList: %w", err)
+	}
+
+	crd, err := fn.FunctionConfigCRD(input)
+	if err != nil {
+		return err
+	}
+	if crd == nil {
+		if crd, err = loadCRD(fnCRDPath); err != nil {
+			return err
+		}
+	}
+
+	output, err := fn.Process(input, crd)
+	if err != nil {
+		return err
+	}
+	if _, err := cmd.OutOrStdout().Write(output.ResourceList); err != nil {
+		return fmt.Errorf("failed to write ResourceList: %w", err)
+	}
+
+	if len(output.Results) > 0 {
+		return fmt.Errorf("%d validation errors", len(output.Results))
+	}
+	return nil
+}
+
+// loadCRD reads and parses a CRD file
+func loadCRD(path string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRD file: %w", err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(data, crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+	return crd, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFnCommand creates a fresh fn command instance for testing
+func newFnCommand() *cobra.Command {
+	fnCRDPath = defaultCRDPath
+
+	cmd := &cobra.Command{
+		Use:          "fn",
+		Args:         cobra.NoArgs,
+		RunE:         runFn,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&fnCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+
+	return cmd
+}
+
+func TestFnCommand(t *testing.T) {
+	crdPath := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+
+	valid := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+  - apiVersion: demo.io/v1
+    kind: Demo
+    metadata:
+      name: demo
+    port: 8080
+`
+	cmd := newFnCommand()
+	cmd.SetArgs([]string{"--crd", crdPath})
+	cmd.SetIn(strings.NewReader(valid))
+	outBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, valid, outBuf.String())
+
+	invalid := strings.Replace(valid, "port: 8080", "port: http", 1)
+	cmd = newFnCommand()
+	cmd.SetArgs([]string{"--crd", crdPath})
+	cmd.SetIn(strings.NewReader(invalid))
+	outBuf = new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 validation errors")
+	assert.Contains(t, outBuf.String(), "results:\n")
+	assert.Contains(t, outBuf.String(), "path: port\n")
+}
+
+func TestFnCommand_FunctionConfigCRD(t *testing.T) {
+	crd, err := os.ReadFile(filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml"))
+	require.NoError(t, err)
+
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: demo.io/v1
+  kind: Demo
+  enabled: "yes"
+functionConfig:
+  ` + strings.ReplaceAll(strings.TrimSpace(string(crd)), "\n", "\n  ") + "\n"
+
+	// --crd points at a missing file, so the functionConfig must be used
+	cmd := newFnCommand()
+	cmd.SetArgs([]string{"--crd", filepath.Join(t.TempDir(), "missing.yaml")})
+	cmd.SetIn(strings.NewReader(input))
+	outBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, outBuf.String(), "path: enabled\n")
+}
+
+func TestFnCommand_MissingCRD(t *testing.T) {
+	cmd := newFnCommand()
+	cmd.SetArgs([]string{"--crd", filepath.Join(t.TempDir(), "missing.yaml")})
+	cmd.SetIn(strings.NewReader("kind: ResourceList\nitems: []\n"))
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read CRD file")
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	helmValidateValues []string
+	helmValidateSchema string
+)
+
+var helmValidateCmd = &cobra.Command{
+	Use:   "helm-validate <chart>",
+	Short: "Validate values for a chart before helm install",
+	Long: `Validate the values a chart would be installed with, before running
+'helm install' or 'helm upgrade'.
+
+The chart's values.yaml and each --values file are merged in order, the way
+Helm merges them: objects are merged key by key, other values are replaced,
+and null deletes a key. The result is validated against the chart's schema,
+and every violation is reported with its path.
+
+The schema is located in this order:
+  1. --schema
+  2. values.schema.json in the chart (directory or packaged archive)
+  3. generated in memory from example.values.yaml in the chart
+
+This command is designed to run as a Helm plugin. Scaffold the plugin with
+'miaka init --helm-plugin'.`,
+	Example: `  # Validate a chart's defaults
+  miaka helm-validate ./mychart
+
+  # Validate the values of a release
+  miaka helm-validate ./mychart -f values.yaml -f prod.yaml
+
+  # Validate a packaged chart against a separate schema
+  miaka helm-validate mychart-1.0.0.tgz -f values.yaml --schema values.schema.json
+
+  # As a Helm plugin
+  helm miaka ./mychart -f values.yaml && helm install myapp ./mychart -f values.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHelmValidate,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(helmValidateCmd)
+
+	helmValidateCmd.Flags().StringArrayVarP(&helmValidateValues, "values", "f", nil, "Values file to merge over the chart's values.yaml (repeatable, applied in order)")
+	helmValidateCmd.Flags().StringVarP(&helmValidateSchema, "schema", "s", "", "Path to JSON Schema file (default: found in or generated from the chart)")
+}
+
+func runHelmValidate(_ *cobra.Command, args []string) error {
+	chartPath := args[0]
+	if _, err := os.Stat(chartPath); err != nil {
+		return fmt.Errorf("chart not found: %s", chartPath)
+	}
+
+	values, err := chart.LoadValues(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to load chart values: %w", err)
+	}
+	for _, path := range helmValidateValues {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read values file: %w", err)
+		}
+		override := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &override); err != nil {
+			return fmt.Errorf("failed to parse values file %s: %w", path, err)
+		}
+		values = chart.CoalesceValues(values, override)
+	}
+
+	schemaJSON, source, err := locateChartSchema(chartPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Validating values for %s against %s...\n", chartPath, source)
+	err = validation.ValidateValues(values, schemaJSON)
+	if err == nil {
+		fmt.Println("✓ Values are valid")
+		return nil
+	}
+
+	violations := validation.Violations(err)
+	if len(violations) == 0 {
+		return err
+	}
+	fmt.Printf("✗ %d validation error(s):\n", len(violations))
+	for _, v := range violations {
+		path := strings.Join(v.Path, ".")
+		if path == "" {
+			path = "(root)"
+		}
+		fmt.Printf("  - %s: %s\n", path, v.Message)
+	}
+	return fmt.Errorf("values for %s are invalid", chartPath)
+}
+
+// locateChartSchema returns the JSON Schema for a chart and a description of where it came from
+func locateChartSchema(chartPath string) ([]byte, string, error) {
+	if helmValidateSchema != "" {
+		data, err := os.ReadFile(helmValidateSchema)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read schema file: %w", err)
+		}
+		return data, helmValidateSchema, nil
+	}
+
+	data, err := chart.ReadFile(chartPath, chart.SchemaFile)
+	if err == nil {
+		return data, chart.SchemaFile, nil
+	}
+	if !errors.Is(err, chart.ErrFileNotFound) {
+		return nil, "", fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	example, err := chart.ReadFile(chartPath, defaultExampleValuesFile)
+	if errors.Is(err, chart.ErrFileNotFound) {
+		return nil, "", fmt.Errorf("no schema found: %s has neither %s nor %s (use --schema)",
+			chartPath, chart.SchemaFile, defaultExampleValuesFile)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", defaultExampleValuesFile, err)
+	}
+
+	s, err := parsing.NewParser().Parse(example)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", defaultExampleValuesFile, err)
+	}
+	files, err := jsonschema.NewEmitter(crd.NewInMemoryEmitter()).Emit(*s)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate schema from %s: %w", defaultExampleValuesFile, err)
+	}
+	return files[0].Content, "schema generated from " + defaultExampleValuesFile, nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHelmValidateCommand creates a fresh helm-validate command instance for testing
+func newHelmValidateCommand() *cobra.Command {
+	helmValidateValues = nil
+	helmValidateSchema = ""
+
+	cmd := &cobra.Command{
+		Use:          "helm-validate <chart>",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runHelmValidate,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringArrayVarP(&helmValidateValues, "values", "f", nil, "Values file to merge over the chart's values.yaml (repeatable, applied in order)")
+	cmd.Flags().StringVarP(&helmValidateSchema, "schema", "s", "", "Path to JSON Schema file (default: found in or generated from the chart)")
+
+	return cmd
+}
+
+// writeTestChart creates a chart directory with the given files
+func writeTestChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestHelmValidateCommand(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("..", "testdata", "build", "minimal", "expected_schema.json"))
+	require.NoError(t, err)
+	chartDir := writeTestChart(t, map[string]string{
+		"Chart.yaml":         "apiVersion: v2\nname: demo\nversion: 1.0.0\n",
+		"values.yaml":        "port: 8080\nenabled: true\n",
+		"values.schema.json": string(schema),
+	})
+
+	valid := filepath.Join(t.TempDir(), "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte("port: 9090\n"), 0644))
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("port: http\nenabled: \"yes\"\n"), 0644))
+	reset := filepath.Join(t.TempDir(), "reset.yaml")
+	require.NoError(t, os.WriteFile(reset, []byte("port: null\nenabled: true\n"), 0644))
+
+	// Chart defaults
+	stdout, _, err := captureStdoutStderr(t, func() error {
+		cmd := newHelmValidateCommand()
+		cmd.SetArgs([]string{chartDir})
+		return cmd.Execute()
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "against values.schema.json")
+	assert.Contains(t, stdout, "✓ Values are valid")
+
+	// Each invalid value is reported
+	stdout, _, err = captureStdoutStderr(t, func() error {
+		cmd := newHelmValidateCommand()
+		cmd.SetArgs([]string{chartDir, "-f", valid, "-f", invalid})
+		return cmd.Execute()
+	})
+	require.Error(t, err)
+	assert.Contains(t, stdout, "✗ 2 validation error(s)")
+	assert.Contains(t, stdout, "  - port: ")
+	assert.Contains(t, stdout, "  - enabled: ")
+
+	// Later files override earlier ones, and null deletes a value
+	_, _, err = captureStdoutStderr(t, func() error {
+		cmd := newHelmValidateCommand()
+		cmd.SetArgs([]string{chartDir, "-f", invalid, "-f", reset})
+		return cmd.Execute()
+	})
+	require.NoError(t, err)
+}
+
+func TestHelmValidateCommand_GeneratedSchema(t *testing.T) {
+	example, err := os.ReadFile(filepath.Join("..", "testdata", "build", "minimal", "input.yaml"))
+	require.NoError(t, err)
+	chartDir := writeTestChart(t, map[string]string{
+		"example.values.yaml": string(example),
+		"values.yaml":         "port: not-a-port\n",
+	})
+
+	stdout, _, err := captureStdoutStderr(t, func() error {
+		cmd := newHelmValidateCommand()
+		cmd.SetArgs([]string{chartDir})
+		return cmd.Execute()
+	})
+	require.Error(t, err)
+	assert.Contains(t, stdout, "schema generated from example.values.yaml")
+	assert.Contains(t, stdout, "  - port: ")
+}
+
+func TestHelmValidateCommand_NoSchema(t *testing.T) {
+	chartDir := writeTestChart(t, map[string]string{"values.yaml": "port: 8080\n"})
+
+	cmd := newHelmValidateCommand()
+	cmd.SetArgs([]string{chartDir})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no schema found")
+}
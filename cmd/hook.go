@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/scan"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	hookAll     bool
+	hookNoCache bool
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook [dir]",
+	Short: "Check the projects affected by the staged changes, for pre-commit hooks",
+	Long: `Check the miaka projects that the changes staged in git affect, like
+'miaka scan' but fast enough for a pre-commit hook.
+
+A project (a directory with Chart.yaml or example.values.yaml, see 'miaka
+scan') is affected if the staged changes touch its example values, outputs,
+Chart.yaml, or values files (YAML files whose names contain "values"). Its
+example values are built in memory and its outputs checked, and the changed
+values files are validated against its values.schema.json. Other projects
+aren't checked. Files are read from the working tree, which matches the index
+when the hook runs under the pre-commit framework.
+
+Builds that passed are cached under .miaka/cache at the root of the repository,
+keyed by a hash of the example values, the CRD they extend, and the miaka
+version, so projects whose example values didn't change are checked in well
+under a second. Add .miaka/cache to .gitignore.`,
+	Example: `  # Check the projects the staged changes affect
+  miaka hook
+
+  # Run from .pre-commit-config.yaml
+  repos:
+    - repo: https://github.com/crenshaw-dev/miaka
+      rev: vX.Y.Z
+      hooks:
+        - id: miaka
+
+  # Check every project, like 'miaka scan' with the cache
+  miaka hook --all`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHook,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+
+	hookCmd.Flags().BoolVar(&hookAll, "all", false, "Check every project, not only those the staged changes affect")
+	hookCmd.Flags().BoolVar(&hookNoCache, "no-cache", false, "Build every checked project, ignoring and not updating the cache")
+}
+
+// hookProject is a project to check, with its changed values files
+type hookProject struct {
+	project scan.Project
+	values  []string
+}
+
+func runHook(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := cmd.OutOrStdout()
+
+	repo, err := gitOutput(ctx, root, "rev-parse", "--show-toplevel")
+	switch {
+	case err == nil:
+		repo = strings.TrimSpace(repo)
+	case hookAll:
+		// Checking every project doesn't need git, and the cache goes in root
+		repo = root
+	default:
+		return err
+	}
+	projects, err := scan.Find(root)
+	if err != nil {
+		return err
+	}
+
+	var checked []hookProject
+	if hookAll {
+		for _, project := range projects {
+			checked = append(checked, hookProject{project: project})
+		}
+	} else {
+		staged, err := stagedFiles(ctx, root, repo)
+		if err != nil {
+			return err
+		}
+		checked = affectedProjects(projects, staged)
+	}
+	if len(checked) == 0 {
+		fmt.Fprintln(out, "✓ No staged changes to miaka projects")
+		return nil
+	}
+
+	var cache *scan.Cache
+	if !hookNoCache {
+		cache = scan.NewCache(filepath.Join(repo, scan.DefaultCacheDir), cacheVersion())
+	}
+	summary := scanSummary{Projects: make([]scan.Result, len(checked))}
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i, c := range checked {
+		g.Go(func() error {
+			summary.Projects[i] = scan.CheckWithOptions(ctx, c.project, scan.CheckOptions{Cache: cache, Values: c.values})
+			return nil
+		})
+	}
+	_ = g.Wait()
+	for _, result := range summary.Projects {
+		switch result.Status {
+		case scan.StatusOK:
+			summary.OK++
+		case scan.StatusFailed:
+			summary.Failed++
+		case scan.StatusSkipped:
+			summary.Skipped++
+		}
+	}
+	printScanSummary(out, summary)
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d project(s) failed", summary.Failed, len(checked))
+	}
+	return nil
+}
+
+// stagedFiles returns the files with staged changes, including deleted ones,
+// relative to root
+func stagedFiles(ctx context.Context, root, repo string) ([]string, error) {
+	output, err := gitOutput(ctx, root, "diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return nil, err
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(absRoot); err == nil {
+		// git resolves symlinks in the path of the repository
+		absRoot = resolved
+	}
+
+	var files []string
+	for _, name := range strings.Split(output, "\x00") {
+		if name == "" {
+			continue
+		}
+		rel, err := filepath.Rel(absRoot, filepath.Join(repo, filepath.FromSlash(name)))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			// Outside of root
+			continue
+		}
+		files = append(files, rel)
+	}
+	return files, nil
+}
+
+// affectedProjects returns the projects with changed files, each file counting
+// for the innermost project it's in
+func affectedProjects(projects []scan.Project, files []string) []hookProject {
+	affected := map[int]*hookProject{}
+	for _, file := range files {
+		owner := -1
+		for i, project := range projects {
+			if inDir(file, project.Dir) && (owner < 0 || depth(project.Dir) > depth(projects[owner].Dir)) {
+				owner = i
+			}
+		}
+		if owner < 0 {
+			continue
+		}
+		rel, _ := filepath.Rel(projects[owner].Dir, file)
+		isValues := isValuesFile(rel) && rel != scan.ExampleValuesFile
+		switch rel {
+		case scan.ExampleValuesFile, scan.CRDFile, scan.SchemaFile, scan.ChartFile:
+		default:
+			if !isValues {
+				continue
+			}
+		}
+		if affected[owner] == nil {
+			affected[owner] = &hookProject{project: projects[owner]}
+		}
+		if isValues {
+			affected[owner].values = append(affected[owner].values, rel)
+		}
+	}
+
+	var checked []hookProject
+	for i := range projects {
+		if p, ok := affected[i]; ok {
+			checked = append(checked, *p)
+		}
+	}
+	return checked
+}
+
+// inDir reports whether a relative path is in dir ("." for the root)
+func inDir(path, dir string) bool {
+	return dir == "." || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// depth is the number of directories of a relative path ("." has none)
+func depth(dir string) int {
+	if dir == "." {
+		return 0
+	}
+	return strings.Count(dir, string(filepath.Separator)) + 1
+}
+
+// isValuesFile reports whether a file is a values file: a YAML file whose name
+// contains "values", like the files of 'miaka validate --recursive'
+func isValuesFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	return (ext == ".yaml" || ext == ".yml") && strings.Contains(name, "values")
+}
+
+// gitOutput runs git in dir and returns its output
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("failed to run git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("failed to run git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/scan"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHookCommand creates a fresh hook command instance for testing
+func newHookCommand() *cobra.Command {
+	hookAll = false
+	hookNoCache = false
+
+	cmd := &cobra.Command{
+		Use:          "hook",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runHook,
+		SilenceUsage: true,
+	}
+	cmd.Flags().BoolVar(&hookAll, "all", false, "Check every project")
+	cmd.Flags().BoolVar(&hookNoCache, "no-cache", false, "Ignore the cache")
+	return cmd
+}
+
+// git runs git in dir
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	command := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := command.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestHookCommand(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	root := writeScanRepo(t)
+	git(t, root, "init", "-q")
+
+	run := func(args ...string) (string, error) {
+		cmd := newHookCommand()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{root}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	// Nothing is staged
+	out, err := run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "No staged changes to miaka projects")
+
+	// Only the charts with staged changes are checked
+	git(t, root, "add", filepath.Join("charts", "good"))
+	out, err = run()
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "charts/good")
+	assert.NotContains(t, out, "charts/bad")
+	assert.Contains(t, out, "1 project(s): 1 ok, 0 failed, 0 skipped")
+
+	// Changed values files are validated against the chart's schema
+	require.NoError(t, os.WriteFile(filepath.Join(root, "charts", "good", "values-prod.yaml"), []byte("replicas: 0\n"), 0644))
+	git(t, root, "add", filepath.Join("charts", "good", "values-prod.yaml"))
+	out, err = run()
+	require.Error(t, err)
+	assert.Contains(t, out, "values-prod.yaml:1:1: replicas: minimum: got 0, want 1")
+
+	// Other staged files don't affect the charts
+	git(t, root, "reset", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "charts", "bad", "README.md"), []byte("# bad\n"), 0644))
+	git(t, root, "add", filepath.Join("charts", "bad", "README.md"))
+	out, err = run()
+	require.NoError(t, err)
+	assert.Contains(t, out, "No staged changes to miaka projects")
+
+	out, err = run("--all")
+	require.Error(t, err)
+	assert.Contains(t, out, "3 project(s): 1 ok, 1 failed, 1 skipped")
+}
+
+func TestHookCommand_Cache(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	root := t.TempDir()
+	dir := filepath.Join(root, "api")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	input := []byte("apiVersion: example.com/v1\nkind: App\nreplicas: 2\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, scan.ExampleValuesFile), input, 0644))
+	git(t, root, "init", "-q")
+	git(t, root, "add", "-A")
+
+	cmd := newHookCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{root})
+	// The outputs don't exist, but the build passes and is cached
+	require.Error(t, cmd.Execute())
+	assert.Contains(t, out.String(), "crd.yaml doesn't exist")
+	entries, err := os.ReadDir(filepath.Join(root, scan.DefaultCacheDir))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// The cached build isn't repeated, even if it would fail now
+	projects, err := scan.Find(root)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache := scan.NewCache(filepath.Join(root, scan.DefaultCacheDir), cacheVersion())
+	result := scan.CheckWithOptions(ctx, projects[0], scan.CheckOptions{Cache: cache})
+	assert.Contains(t, result.Passed, "built")
+	result = scan.CheckWithOptions(ctx, projects[0], scan.CheckOptions{})
+	assert.NotContains(t, result.Passed, "built")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/importer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importAPIVersion string
+	importKind       string
+	importOutput     string
+	importCRDVersion string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import existing schemas into an example values file",
+	Long: `Convert existing schemas into a miaka example values file, so charts
+that already have schemas can adopt miaka without rewriting them by hand.`,
+}
+
+var importSchemaCmd = &cobra.Command{
+	Use:   "schema <values.schema.json>",
+	Short: "Convert a JSON Schema into example.values.yaml",
+	Long: `Convert an existing JSON Schema (e.g., a chart's values.schema.json) into
+a KRM-compliant example values file.
+
+Descriptions become comments, and constraints (enum, minimum, pattern, etc.)
+become kubebuilder markers. Example values come from defaults, examples, and
+enums, and are otherwise chosen to satisfy the constraints. Maps and empty
+lists get +miaka:type hints.
+
+Local $ref, allOf, and nullable types are supported. For anyOf/oneOf only the
+first alternative is imported. Fields that can't be represented exactly are
+reported as warnings and should be reviewed before running 'miaka build'.`,
+	Example: `  # Import a chart's schema (will prompt for apiVersion/kind)
+  miaka import schema values.schema.json
+
+  # Provide apiVersion and kind via flags
+  miaka import schema values.schema.json --api-version=myapp.io/v1 --kind=MyApp
+
+  # With custom output file
+  miaka import schema values.schema.json --api-version=myapp.io/v1 --kind=MyApp -o custom.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportSchema,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+var importCRDCmd = &cobra.Command{
+	Use:   "crd <crd.yaml>",
+	Short: "Convert a CustomResourceDefinition into example.values.yaml",
+	Long: `Convert an existing CustomResourceDefinition into a KRM-compliant example
+values file, for adopting miaka in operators whose CRDs predate it.
+
+apiVersion and kind come from the CRD. The storage version is imported unless
+--version is set. Schema descriptions become comments, defaults become both
+example values and +kubebuilder:default markers, and validations become
+kubebuilder markers. metadata and status are not imported.`,
+	Example: `  # Import the storage version of a CRD
+  miaka import crd config/crd/bases/myapp.io_myapps.yaml
+
+  # Import a specific version
+  miaka import crd my-crd.yaml --version v1beta1 -o custom.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportCRD,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importSchemaCmd)
+	importCmd.AddCommand(importCRDCmd)
+
+	importSchemaCmd.Flags().StringVar(&importAPIVersion, "api-version", "", "API version (e.g., myapp.io/v1)")
+	importSchemaCmd.Flags().StringVar(&importKind, "kind", "", "Kind name (e.g., MyApp)")
+	importSchemaCmd.Flags().StringVarP(&importOutput, "output", "o", defaultExampleValuesFile, "Output file path")
+
+	importCRDCmd.Flags().StringVar(&importCRDVersion, "version", "", "CRD version to import (default: the storage version)")
+	importCRDCmd.Flags().StringVarP(&importOutput, "output", "o", defaultExampleValuesFile, "Output file path")
+}
+
+func runImportSchema(_ *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	apiVersion, kind := importAPIVersion, importKind
+	if err := promptForMissingValues(&apiVersion, &kind, false, false, "", ""); err != nil {
+		return err
+	}
+	if apiVersion == "" || kind == "" {
+		return fmt.Errorf("apiVersion and kind are required (provide via --api-version and --kind flags or run interactively)")
+	}
+
+	result, err := importer.FromJSONSchema(data, importer.Options{APIVersion: apiVersion, Kind: kind})
+	if err != nil {
+		return fmt.Errorf("failed to import schema: %w", err)
+	}
+
+	return writeImportResult(args[0], result)
+}
+
+func runImportCRD(_ *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read CRD: %w", err)
+	}
+
+	result, err := importer.FromCRD(data, importCRDVersion)
+	if err != nil {
+		return fmt.Errorf("failed to import CRD: %w", err)
+	}
+
+	return writeImportResult(args[0], result)
+}
+
+// writeImportResult writes an imported example values file and reports its warnings
+func writeImportResult(inputFile string, result *importer.Result) error {
+	if err := writeOutput(importOutput, result.Content); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Printf("✓ Successfully imported %s to %s\n", inputFile, importOutput)
+
+	if len(result.Warnings) > 0 {
+		fmt.Println()
+		fmt.Println("⚠️  Review these fields:")
+		for _, warning := range result.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("📝 Next steps:")
+	fmt.Println("  1. Review", importOutput, "and replace placeholder example values")
+	fmt.Println("  2. Run 'miaka build' to generate the CRD and JSON Schema")
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImportSchemaCommand creates a fresh import schema command instance for testing
+func newImportSchemaCommand() *cobra.Command {
+	importAPIVersion = ""
+	importKind = ""
+	importOutput = defaultExampleValuesFile
+
+	cmd := &cobra.Command{
+		Use:          "schema <values.schema.json>",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runImportSchema,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&importAPIVersion, "api-version", "", "API version (e.g., myapp.io/v1)")
+	cmd.Flags().StringVar(&importKind, "kind", "", "Kind name (e.g., MyApp)")
+	cmd.Flags().StringVarP(&importOutput, "output", "o", defaultExampleValuesFile, "Output file path")
+
+	return cmd
+}
+
+// TestImportSchemaCommand tests that an imported schema can be built by miaka
+func TestImportSchemaCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["service"],
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1, "default": 1},
+    "service": {
+      "type": "object",
+      "properties": {
+        "type": {"type": "string", "enum": ["ClusterIP", "NodePort"]},
+        "port": {"type": "integer", "maximum": 65535, "exclusiveMinimum": 0}
+      }
+    },
+    "nodeSelector": {"type": "object", "additionalProperties": {"type": "string"}},
+    "tolerations": {"type": "array", "items": {"type": "object", "properties": {"key": {"type": "string"}}}}
+  }
+}`), 0644))
+
+	cmd := newImportSchemaCommand()
+	cmd.SetArgs([]string{schemaPath, "--api-version", "example.com/v1", "--kind", "Example", "-o", examplePath})
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(examplePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "kind: Example\n")
+	assert.Contains(t, string(content), "# +kubebuilder:validation:Enum=ClusterIP;NodePort\n  type: ClusterIP\n")
+
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	generatedSchemaPath := filepath.Join(tmpDir, "generated.schema.json")
+	buildCmd := newBuildCommand()
+	buildCmd.SetArgs([]string{examplePath, "-c", crdPath, "-s", generatedSchemaPath})
+	require.NoError(t, buildCmd.Execute())
+
+	crd, err := os.ReadFile(crdPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(crd), "- ClusterIP")
+	assert.Contains(t, string(crd), "exclusiveMinimum: true")
+
+	generated, err := os.ReadFile(generatedSchemaPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(generated), `"exclusiveMinimum": 0`)
+}
+
+// TestImportSchemaCommand_MissingFlags tests error handling when apiVersion or kind is missing
+func TestImportSchemaCommand_MissingFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaPath := filepath.Join(tmpDir, "values.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": "object"}`), 0644))
+
+	cmd := newImportSchemaCommand()
+	cmd.SetArgs([]string{schemaPath, "--kind", "Example", "-o", filepath.Join(tmpDir, "out.yaml")})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "apiVersion and kind are required")
+}
+
+// TestImportSchemaCommand_NonExistentFile tests error handling for a missing schema file
+func TestImportSchemaCommand_NonExistentFile(t *testing.T) {
+	cmd := newImportSchemaCommand()
+	cmd.SetArgs([]string{"nonexistent.json", "--api-version", "example.com/v1", "--kind", "Example"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read schema")
+}
+
+// newImportCRDCommand creates a fresh import crd command instance for testing
+func newImportCRDCommand() *cobra.Command {
+	importCRDVersion = ""
+	importOutput = defaultExampleValuesFile
+
+	cmd := &cobra.Command{
+		Use:          "crd <crd.yaml>",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runImportCRD,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&importCRDVersion, "version", "", "CRD version to import")
+	cmd.Flags().StringVarP(&importOutput, "output", "o", defaultExampleValuesFile, "Output file path")
+
+	return cmd
+}
+
+// TestImportCRDCommand tests that importing a generated CRD and rebuilding it gives the same CRD
+func TestImportCRDCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	expectedCRD := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+
+	cmd := newImportCRDCommand()
+	cmd.SetArgs([]string{expectedCRD, "-o", examplePath})
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(examplePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "apiVersion: demo.io/v1\nkind: Demo\n")
+	assert.NotContains(t, string(content), "metadata")
+
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	buildCmd := newBuildCommand()
+	buildCmd.SetArgs([]string{examplePath, "-c", crdPath, "-s", filepath.Join(tmpDir, "values.schema.json")})
+	require.NoError(t, buildCmd.Execute())
+
+	compareFiles(t, "crd.yaml", crdPath, expectedCRD)
+}
+
+// TestImportCRDCommand_VersionNotFound tests error handling for a missing CRD version
+func TestImportCRDCommand_VersionNotFound(t *testing.T) {
+	cmd := newImportCRDCommand()
+	cmd.SetArgs([]string{
+		filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml"),
+		"--version", "v9",
+		"-o", filepath.Join(t.TempDir(), "out.yaml"),
+	})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version v9 not found")
+}
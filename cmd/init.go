@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
 	initpkg "github.com/crenshaw-dev/miaka/pkg/init"
+	"github.com/crenshaw-dev/miaka/pkg/oci"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -14,8 +20,18 @@ var (
 	initAPIVersion string
 	initKind       string
 	initOutput     string
+	initHelmPlugin bool
+	initPlain      bool
+	initFromChart  string
+	initPlainHTTP  bool
+	initDomain     string
+	initYes        bool
+	initMerge      bool
 )
 
+// initLog receives init's messages, on the command's output
+var initLog = newLogger(os.Stdout)
+
 var initCmd = &cobra.Command{
 	Use:   "init [values.yaml]",
 	Short: "Convert values.yaml to KRM-compliant example.values.yaml",
@@ -33,13 +49,38 @@ will be created.
 
 If apiVersion and kind are not provided via flags and not present in the input 
 file, the command will prompt you interactively for these values (unless running 
-in non-interactive mode like CI/CD).`,
+in non-interactive mode like CI/CD).
+
+Inside a chart, the prompts propose them from Chart.yaml: the chart's name in
+PascalCase as the kind, and <name>.<domain>/v<major version> as the apiVersion
+(v1alpha1 before 1.0.0), where --domain is your organization's domain
+(default: example.com). --yes accepts the proposals without prompting.
+
+With --plain, apiVersion and kind aren't added or prompted for, and the values
+are copied as-is, for 'miaka build --plain' to generate only values.schema.json.
+
+With --from-chart-archive, the values.yaml of a packaged chart (.tgz) or of a
+chart in an OCI registry (oci://registry/repository:version) is converted,
+without unpacking the chart. OCI charts are pulled with Helm's registry
+client, with credentials read the same way as 'miaka push'; use --plain-http
+for local registries without TLS.
+
+With --merge, the keys that an updated upstream values.yaml added since the
+example values were made from it are merged into the existing example values
+(--output), with their comments. The example's values, markers, and comments are
+kept, and keys removed upstream are reported but not removed.
+
+With --helm-plugin, the argument is a directory instead, and a Helm plugin
+manifest (plugin.yaml) that runs 'miaka helm-validate' is written to it.`,
 	Example: `  # Convert values.yaml to KRM format (will prompt for apiVersion/kind)
   miaka init
 
   # Provide apiVersion and kind via flags
   miaka init --api-version=myapp.io/v1 --kind=MyApp
 
+  # Inside a chart, accept apiVersion and kind proposed from Chart.yaml
+  miaka init --domain=myorg.io --yes
+
   # Convert a different file
   miaka init --api-version=myapp.io/v1 --kind=MyApp myvalues.yaml
 
@@ -47,7 +88,20 @@ in non-interactive mode like CI/CD).`,
   miaka init --api-version=myapp.io/v1 --kind=MyApp -o custom.yaml
   
   # Use existing apiVersion/kind from input file
-  miaka init input.yaml  # (if input already has apiVersion and kind)`,
+  miaka init input.yaml  # (if input already has apiVersion and kind)
+
+  # Only generate values.schema.json, without apiVersion or kind
+  miaka init --plain
+
+  # Bootstrap from a packaged chart or a chart in an OCI registry
+  miaka init --from-chart-archive redis-18.1.0.tgz --api-version=redis.myorg.io/v1 --kind=Redis
+  miaka init --from-chart-archive oci://registry-1.docker.io/bitnamicharts/redis:18.1.0 --plain
+
+  # Merge keys added by a newer upstream values.yaml into example.values.yaml
+  miaka init --merge values.yaml
+
+  # Scaffold a Helm plugin that runs 'miaka helm-validate'
+  miaka init --helm-plugin helm-miaka`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInit,
 }
@@ -56,11 +110,28 @@ func init() {
 	initCmd.Flags().StringVar(&initAPIVersion, "api-version", "", "API version (e.g., myapp.io/v1)")
 	initCmd.Flags().StringVar(&initKind, "kind", "", "Kind name (e.g., MyApp)")
 	initCmd.Flags().StringVarP(&initOutput, "output", "o", "example.values.yaml", "Output file path")
+	initCmd.Flags().BoolVar(&initHelmPlugin, "helm-plugin", false, "Scaffold a Helm plugin (plugin.yaml) in the given directory instead of converting values")
+	initCmd.Flags().BoolVar(&initPlain, "plain", false, "Copy values without adding apiVersion or kind, for 'miaka build --plain'")
+	initCmd.Flags().StringVar(&initFromChart, "from-chart-archive", "", "Convert the values.yaml of a packaged chart (.tgz) or oci:// chart reference")
+	initCmd.Flags().BoolVar(&initPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS to pull oci:// charts")
+	initCmd.Flags().StringVar(&initDomain, "domain", "", "Domain of the API group proposed from Chart.yaml (default: "+initpkg.DefaultDomain+")")
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept the apiVersion and kind proposed from Chart.yaml without prompting")
+	initCmd.Flags().BoolVar(&initMerge, "merge", false, "Merge keys added upstream into the existing example values (--output) instead of converting")
 
 	// Don't mark as required - we'll validate conditionally in runInit
+
+	initCmd.ValidArgsFunction = completeYAMLFiles
 }
 
-func runInit(_ *cobra.Command, args []string) error {
+func runInit(cmd *cobra.Command, args []string) error {
+	initLog = newLogger(commandOut(cmd))
+	if initHelmPlugin {
+		return runInitHelmPlugin(args)
+	}
+	if initFromChart != "" {
+		return runInitFromChart(commandContext(cmd), args)
+	}
+
 	// Determine input file: use provided arg, or default to values.yaml
 	inputFile := "values.yaml"
 	if len(args) > 0 {
@@ -74,47 +145,269 @@ func runInit(_ *cobra.Command, args []string) error {
 	}
 
 	// If default values.yaml doesn't exist and no arg was provided, treat as empty
-	if !fileExists && len(args) == 0 {
+	if !fileExists && len(args) == 0 && !initMerge {
+		initLog.Debugf("No %s found, creating %s from empty values", inputFile, initOutput)
 		inputFile = ""
 	}
 
+	var input []byte
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		input = data
+	}
+	// The values of a chart are next to its Chart.yaml
+	return convertValues(input, inputFile, loadChartMetadata(filepath.Dir(inputFile)))
+}
+
+// loadChartMetadata reads the Chart.yaml of a chart directory or archive, for
+// proposing apiVersion and kind. It returns nil if there's none, or if it can't
+// be read, which only costs the proposals.
+func loadChartMetadata(chartPath string) *chart.Metadata {
+	metadata, err := chart.LoadMetadata(chartPath)
+	if errors.Is(err, chart.ErrFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		initLog.Warnf("⚠️  Not proposing apiVersion and kind: %v", err)
+		return nil
+	}
+	return metadata
+}
+
+// convertValues converts input values (or empty values if they're nil) to
+// initOutput. source names the input in messages, and metadata, if any, is the
+// Chart.yaml of its chart, which apiVersion and kind are proposed from.
+func convertValues(input []byte, source string, metadata *chart.Metadata) error {
+	if initMerge {
+		return runInitMerge(input, source)
+	}
+	if initPlain {
+		return runInitPlain(input, source)
+	}
+
 	// Get apiVersion and kind, prompting interactively if needed
 	apiVersion := initAPIVersion
 	kind := initKind
 
 	// Check if the input file already has apiVersion and kind
 	hasAPIVersion, hasKind := false, false
-	if fileExists {
-		hasAPIVersion, hasKind = initpkg.CheckKRMFields(inputFile)
+	if input != nil {
+		hasAPIVersion, hasKind = initpkg.CheckKRMFieldsContent(input)
+	}
+
+	var proposedAPIVersion, proposedKind string
+	if metadata != nil {
+		proposedAPIVersion, proposedKind = initpkg.ProposeKRMFields(metadata, initDomain)
+		initLog.Debugf("Proposing apiVersion %s and kind %s from %s", proposedAPIVersion, proposedKind, chart.MetadataFile)
 	}
 
-	// Prompt for missing values if in terminal
-	if err := promptForMissingValues(&apiVersion, &kind, hasAPIVersion, hasKind); err != nil {
+	if initYes {
+		acceptProposals(&apiVersion, &kind, hasAPIVersion, hasKind, proposedAPIVersion, proposedKind)
+	} else if err := promptForMissingValues(&apiVersion, &kind, hasAPIVersion, hasKind, proposedAPIVersion, proposedKind); err != nil {
+		// Prompt for missing values if in terminal
 		return err
 	}
 
-	if err := initpkg.ConvertToKRM(inputFile, initOutput, apiVersion, kind); err != nil {
+	output, err := initpkg.ConvertToKRMContent(input, apiVersion, kind)
+	if err != nil {
 		return fmt.Errorf("failed to convert: %w", err)
 	}
+	if err := os.WriteFile(initOutput, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initOutput, err)
+	}
 
-	if inputFile != "" {
-		fmt.Printf("✓ Successfully converted %s to %s\n", inputFile, initOutput)
+	if input != nil {
+		initLog.Infof("✓ Successfully converted %s to %s", source, initOutput)
 	} else {
-		fmt.Printf("✓ Successfully created %s\n", initOutput)
+		initLog.Infof("✓ Successfully created %s", initOutput)
 	}
 
 	// Print next steps
-	fmt.Println()
-	fmt.Println("📝 Next steps:")
-	fmt.Println("  1. Edit", initOutput, "to add example values for all fields")
-	fmt.Println("  2. Add validation rules using kubebuilder markers (e.g., +kubebuilder:validation:Minimum=1)")
-	fmt.Println("  3. Run 'miaka build' to generate the CRD and JSON Schema")
+	initLog.Infof("")
+	initLog.Infof("📝 Next steps:")
+	initLog.Infof("  1. Edit %s to add example values for all fields", initOutput)
+	initLog.Infof("  2. Add validation rules using kubebuilder markers (e.g., +kubebuilder:validation:Minimum=1)")
+	initLog.Infof("  3. Run 'miaka build' to generate the CRD and JSON Schema")
+
+	return nil
+}
+
+// runInitPlain copies values for plain builds, which need no apiVersion or kind
+func runInitPlain(input []byte, source string) error {
+	if initAPIVersion != "" || initKind != "" {
+		return fmt.Errorf("--api-version and --kind can't be used with --plain")
+	}
+	output, err := initpkg.ConvertToPlainContent(input)
+	if err != nil {
+		return fmt.Errorf("failed to convert: %w", err)
+	}
+	if err := os.WriteFile(initOutput, output, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", initOutput, err)
+	}
+
+	if input != nil {
+		initLog.Infof("✓ Successfully copied %s to %s", source, initOutput)
+	} else {
+		initLog.Infof("✓ Successfully created %s", initOutput)
+	}
+
+	initLog.Infof("")
+	initLog.Infof("📝 Next steps:")
+	initLog.Infof("  1. Edit %s to add example values for all fields", initOutput)
+	initLog.Infof("  2. Add validation rules using kubebuilder markers (e.g., +kubebuilder:validation:Minimum=1)")
+	initLog.Infof("  3. Run 'miaka build --plain' to generate the JSON Schema")
 
 	return nil
 }
 
-// promptForMissingValues prompts user for missing apiVersion and kind if in terminal
-func promptForMissingValues(apiVersion, kind *string, hasAPIVersion, hasKind bool) error {
+// runInitMerge merges the keys added to upstream values since initOutput was made from them
+func runInitMerge(input []byte, source string) error {
+	if initAPIVersion != "" || initKind != "" {
+		return fmt.Errorf("--api-version and --kind can't be used with --merge")
+	}
+	if input == nil {
+		return fmt.Errorf("no upstream values to merge from %s", source)
+	}
+	if _, err := os.Stat(initOutput); err != nil {
+		return fmt.Errorf("%s must exist to merge into; run 'miaka init' without --merge first: %w", initOutput, err)
+	}
+
+	report, err := initpkg.MergeUpstreamValues(initOutput, input, initOutput)
+	if err != nil {
+		return fmt.Errorf("failed to merge: %w", err)
+	}
+
+	if len(report.Added) == 0 {
+		initLog.Infof("✓ %s has all the keys of %s", initOutput, source)
+	} else {
+		initLog.Infof("✓ Merged %d keys added to %s into %s:", len(report.Added), source, initOutput)
+		for _, path := range report.Added {
+			initLog.Infof("  + %s", path)
+		}
+	}
+	if len(report.Removed) > 0 {
+		initLog.Warnf("⚠️  %d keys of %s aren't in %s; remove them if they were removed upstream:", len(report.Removed), initOutput, source)
+		for _, path := range report.Removed {
+			initLog.Warnf("  - %s", path)
+		}
+	}
+
+	if len(report.Added) > 0 {
+		initLog.Infof("")
+		initLog.Infof("📝 Next steps:")
+		initLog.Infof("  1. Edit %s to add example values and markers for the new keys", initOutput)
+		initLog.Infof("  2. Run 'miaka build' to regenerate the CRD and JSON Schema")
+	}
+
+	return nil
+}
+
+// runInitFromChart converts the values.yaml of a chart archive or OCI chart reference
+func runInitFromChart(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("a values file can't be given with --from-chart-archive")
+	}
+
+	source := chart.ValuesFile + " from " + initFromChart
+	values, metadata, err := readChartValues(ctx, initFromChart)
+	if errors.Is(err, chart.ErrFileNotFound) {
+		// Charts don't need a values.yaml
+		return convertValues(nil, source, metadata)
+	}
+	if err != nil {
+		return err
+	}
+
+	if values == nil {
+		// An empty values.yaml is still values to convert
+		values = []byte{}
+	}
+	return convertValues(values, source, metadata)
+}
+
+// readChartValues reads values.yaml and Chart.yaml from a packaged chart archive,
+// or pulls an OCI chart reference and reads them from it. The metadata is nil if
+// Chart.yaml can't be read.
+func readChartValues(ctx context.Context, chartRef string) ([]byte, *chart.Metadata, error) {
+	if !oci.IsReference(chartRef) {
+		if !chart.IsArchive(chartRef) {
+			return nil, nil, fmt.Errorf("%s is not a chart archive (.tgz) or %s reference", chartRef, oci.Scheme)
+		}
+		values, err := chart.ReadFile(chartRef, chart.ValuesFile)
+		if err != nil && !errors.Is(err, chart.ErrFileNotFound) {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", chartRef, err)
+		}
+		return values, loadChartMetadata(chartRef), err
+	}
+
+	ref, err := oci.ParseReference(chartRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := newOCIClient(initPlainHTTP)
+	if err != nil {
+		return nil, nil, err
+	}
+	archive, err := client.PullChart(ctx, ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pull %s: %w", chartRef, err)
+	}
+	initLog.Debugf("Pulled %s (%d bytes)", chartRef, len(archive))
+	values, err := chart.ReadArchiveFile(bytes.NewReader(archive), chart.ValuesFile)
+	if err != nil && !errors.Is(err, chart.ErrFileNotFound) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", chartRef, err)
+	}
+	var metadata *chart.Metadata
+	if data, metadataErr := chart.ReadArchiveFile(bytes.NewReader(archive), chart.MetadataFile); metadataErr == nil {
+		metadata, _ = chart.ParseMetadata(data)
+	}
+	return values, metadata, err
+}
+
+// runInitHelmPlugin scaffolds a Helm plugin in the given directory (default: current directory)
+func runInitHelmPlugin(args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	path, err := initpkg.WriteHelmPlugin(dir, version)
+	if err != nil {
+		return fmt.Errorf("failed to scaffold Helm plugin: %w", err)
+	}
+	initLog.Infof("✓ Successfully created %s", path)
+
+	initLog.Infof("")
+	initLog.Infof("📝 Next steps:")
+	initLog.Infof("  1. Copy the miaka binary into %s", dir)
+	initLog.Infof("  2. Run 'helm plugin install %s'", dir)
+	initLog.Infof("  3. Run 'helm miaka <chart> -f values.yaml' before 'helm install'")
+
+	return nil
+}
+
+// acceptProposals fills in the missing apiVersion and kind with the ones proposed
+// from Chart.yaml, for --yes
+func acceptProposals(apiVersion, kind *string, hasAPIVersion, hasKind bool, proposedAPIVersion, proposedKind string) {
+	if *apiVersion == "" && !hasAPIVersion && proposedAPIVersion != "" {
+		*apiVersion = proposedAPIVersion
+		initLog.Infof("Using apiVersion %s from %s", proposedAPIVersion, chart.MetadataFile)
+		if initDomain == "" {
+			initLog.Warnf("⚠️  %s uses %s; pass --domain with your organization's domain", proposedAPIVersion, initpkg.DefaultDomain)
+		}
+	}
+	if *kind == "" && !hasKind && proposedKind != "" {
+		*kind = proposedKind
+		initLog.Infof("Using kind %s from %s", proposedKind, chart.MetadataFile)
+	}
+}
+
+// promptForMissingValues prompts user for missing apiVersion and kind if in
+// terminal, proposing the ones from Chart.yaml, if any
+func promptForMissingValues(apiVersion, kind *string, hasAPIVersion, hasKind bool, proposedAPIVersion, proposedKind string) error {
 	if !term.IsTerminal(int(os.Stdin.Fd())) {
 		return nil
 	}
@@ -122,6 +415,7 @@ func promptForMissingValues(apiVersion, kind *string, hasAPIVersion, hasKind boo
 	if *apiVersion == "" && !hasAPIVersion {
 		prompt := &survey.Input{
 			Message: "API Version (e.g., myapp.io/v1):",
+			Default: proposedAPIVersion,
 		}
 		if err := survey.AskOne(prompt, apiVersion, survey.WithValidator(survey.Required)); err != nil {
 			return fmt.Errorf("failed to get API version: %w", err)
@@ -131,6 +425,7 @@ func promptForMissingValues(apiVersion, kind *string, hasAPIVersion, hasKind boo
 	if *kind == "" && !hasKind {
 		prompt := &survey.Input{
 			Message: "Kind (e.g., MyApp):",
+			Default: proposedKind,
 		}
 		if err := survey.AskOne(prompt, kind, survey.WithValidator(survey.Required)); err != nil {
 			return fmt.Errorf("failed to get kind: %w", err)
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +20,13 @@ func newInitCommand() *cobra.Command {
 	initAPIVersion = ""
 	initKind = ""
 	initOutput = "example.values.yaml"
+	initHelmPlugin = false
+	initPlain = false
+	initFromChart = ""
+	initPlainHTTP = false
+	initDomain = ""
+	initYes = false
+	initMerge = false
 
 	// Create new command
 	cmd := &cobra.Command{
@@ -28,6 +39,13 @@ func newInitCommand() *cobra.Command {
 	cmd.Flags().StringVar(&initAPIVersion, "api-version", "", "API version (e.g., myapp.io/v1)")
 	cmd.Flags().StringVar(&initKind, "kind", "", "Kind name (e.g., MyApp)")
 	cmd.Flags().StringVarP(&initOutput, "output", "o", "example.values.yaml", "Output file path")
+	cmd.Flags().BoolVar(&initHelmPlugin, "helm-plugin", false, "Scaffold a Helm plugin (plugin.yaml) in the given directory instead of converting values")
+	cmd.Flags().BoolVar(&initPlain, "plain", false, "Copy values without adding apiVersion or kind")
+	cmd.Flags().StringVar(&initFromChart, "from-chart-archive", "", "Convert the values.yaml of a packaged chart (.tgz) or oci:// chart reference")
+	cmd.Flags().BoolVar(&initPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS to pull oci:// charts")
+	cmd.Flags().StringVar(&initDomain, "domain", "", "Domain of the API group proposed from Chart.yaml")
+	cmd.Flags().BoolVarP(&initYes, "yes", "y", false, "Accept the apiVersion and kind proposed from Chart.yaml without prompting")
+	cmd.Flags().BoolVar(&initMerge, "merge", false, "Merge keys added upstream into the existing example values")
 
 	return cmd
 }
@@ -178,10 +196,10 @@ func compareInitFiles(t *testing.T, generatedPath, expectedPath string) {
 	expectedStr := normalizeInitOutput(string(expected))
 
 	if generatedStr != expectedStr {
-		t.Errorf("Output mismatch:\n\nExpected:\n%s\n\nGot:\n%s\n\nFirst difference:\n%s",
+		t.Errorf("Output mismatch:\n\nExpected:\n%s\n\nGot:\n%s\n\n%s",
 			expectedStr,
 			generatedStr,
-			findFirstDifference(expectedStr, generatedStr),
+			describeDifference(expectedStr, generatedStr),
 		)
 	}
 }
@@ -234,6 +252,25 @@ func TestInitCommand_ValidationError(t *testing.T) {
 	}
 }
 
+// TestInitCommand_PlainWithKRMFlags tests that --plain rejects apiVersion and kind
+func TestInitCommand_PlainWithKRMFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{
+		"--plain",
+		"--kind", "Test",
+		"-o", filepath.Join(tmpDir, "output.yaml"),
+	})
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "can't be used with --plain") {
+		t.Errorf("Expected --plain to reject --kind, got: %v", err)
+	}
+}
+
 // TestInitCommand_MissingFlags tests error handling when required flags are missing
 func TestInitCommand_MissingFlags(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -349,3 +386,369 @@ data:
 		t.Errorf("Expected error about missing apiVersion, got: %v", err)
 	}
 }
+
+// TestInitCommand_HelmPlugin tests scaffolding a Helm plugin manifest
+func TestInitCommand_HelmPlugin(t *testing.T) {
+	pluginDir := filepath.Join(t.TempDir(), "helm-miaka")
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{"--helm-plugin", pluginDir})
+	outBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --helm-plugin failed: %v", err)
+	}
+	if want := "2. Run 'helm plugin install " + pluginDir + "'"; !strings.Contains(outBuf.String(), want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, outBuf.String())
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(pluginDir, "plugin.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read plugin.yaml: %v", err)
+	}
+	if !strings.Contains(string(manifest), `command: "$HELM_PLUGIN_DIR/miaka helm-validate"`) {
+		t.Errorf("plugin.yaml does not run helm-validate:\n%s", manifest)
+	}
+
+	// No example values file is created
+	if _, err := os.Stat("example.values.yaml"); err == nil {
+		t.Error("init --helm-plugin should not create example.values.yaml")
+	}
+}
+
+// chartArchive builds a packaged chart archive with the given files under a root directory
+func chartArchive(t *testing.T, root string, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: root + "/" + name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("Failed to write archive header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write archive file: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestInitCommand_FromChartArchive tests converting the values.yaml of a packaged chart
+func TestInitCommand_FromChartArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "redis-18.1.0.tgz")
+	archive := chartArchive(t, "redis", map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: redis\nversion: 18.1.0\n",
+		"values.yaml":               "# Number of replicas\nreplicas: 1\n",
+		"charts/common/values.yaml": "common: true\n",
+	})
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	outputPath := filepath.Join(tmpDir, "output.yaml")
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{
+		"--from-chart-archive", archivePath,
+		"--api-version", "redis.example.com/v1",
+		"--kind", "Redis",
+		"-o", outputPath,
+	})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	if err != nil {
+		t.Fatalf("init --from-chart-archive failed: %v", err)
+	}
+	if !strings.Contains(stdout, "converted values.yaml from "+archivePath) {
+		t.Errorf("Expected the chart to be named in the output, got:\n%s", stdout)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "apiVersion: redis.example.com/v1\nkind: Redis\n# Number of replicas\nreplicas: 1\n"
+	if string(output) != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+// TestInitCommand_FromChartArchiveOCI tests pulling a chart from an OCI registry
+func TestInitCommand_FromChartArchiveOCI(t *testing.T) {
+	archive := chartArchive(t, "redis", map[string]string{"values.yaml": "replicas: 1\n"})
+	registry := ocitest.NewRegistry(t)
+	registry.SetChart("charts/redis", "18.1.0", archive)
+
+	outputPath := filepath.Join(t.TempDir(), "output.yaml")
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{
+		"--from-chart-archive", "oci://" + registry.Host() + "/charts/redis:18.1.0",
+		"--plain-http",
+		"--plain",
+		"-o", outputPath,
+	})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("init --from-chart-archive failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(output) != "replicas: 1\n" {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	// Unknown versions fail to pull
+	cmd = newInitCommand()
+	cmd.SetArgs([]string{
+		"--from-chart-archive", "oci://" + registry.Host() + "/charts/redis:19.0.0",
+		"--plain-http",
+		"--plain",
+		"-o", outputPath,
+	})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	if err == nil || !strings.Contains(err.Error(), "failed to pull") {
+		t.Errorf("Expected a pull error, got: %v", err)
+	}
+}
+
+// TestInitCommand_FromChartArchiveWithoutValues tests charts that have no values.yaml
+func TestInitCommand_FromChartArchiveWithoutValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "empty-0.1.0.tgz")
+	if err := os.WriteFile(archivePath, chartArchive(t, "empty", map[string]string{"Chart.yaml": "name: empty\n"}), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	outputPath := filepath.Join(tmpDir, "output.yaml")
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{"--from-chart-archive", archivePath, "--plain", "-o", outputPath})
+	if _, _, err := captureStdoutStderr(t, cmd.Execute); err != nil {
+		t.Fatalf("init --from-chart-archive failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(output) != "{}\n" {
+		t.Errorf("Expected empty values, got:\n%s", output)
+	}
+}
+
+// TestInitCommand_FromChartArchiveErrors tests invalid --from-chart-archive usage
+func TestInitCommand_FromChartArchiveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "values file", args: []string{"values.yaml", "--from-chart-archive", "redis-18.1.0.tgz"}, err: "can't be given with --from-chart-archive"},
+		{name: "chart directory", args: []string{"--from-chart-archive", "charts/redis"}, err: "is not a chart archive"},
+		{name: "missing archive", args: []string{"--from-chart-archive", "missing-1.0.0.tgz"}, err: "failed to read missing-1.0.0.tgz"},
+		{name: "no tag", args: []string{"--from-chart-archive", "oci://ghcr.io/charts/redis"}, err: "has no tag or digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newInitCommand()
+			cmd.SetArgs(append(tt.args, "-o", filepath.Join(t.TempDir(), "output.yaml")))
+			cmd.SetOut(new(bytes.Buffer))
+			cmd.SetErr(new(bytes.Buffer))
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestInitCommand_ProposeFromChart tests accepting the apiVersion and kind
+// proposed from Chart.yaml with --yes
+func TestInitCommand_ProposeFromChart(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		warning  bool
+	}{
+		{
+			name:     "domain",
+			args:     []string{"--domain", "myorg.io"},
+			expected: "apiVersion: my-app.myorg.io/v2\nkind: MyApp\nreplicas: 1\n",
+		},
+		{
+			name:     "default domain",
+			expected: "apiVersion: my-app.example.com/v2\nkind: MyApp\nreplicas: 1\n",
+			warning:  true,
+		},
+		{
+			name:     "flags win",
+			args:     []string{"--domain", "myorg.io", "--kind", "Frontend"},
+			expected: "apiVersion: my-app.myorg.io/v2\nkind: Frontend\nreplicas: 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 2.1.0\n"), 0644); err != nil {
+				t.Fatalf("Failed to write Chart.yaml: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("replicas: 1\n"), 0644); err != nil {
+				t.Fatalf("Failed to write values: %v", err)
+			}
+			outputPath := filepath.Join(chartDir, "example.values.yaml")
+
+			cmd := newInitCommand()
+			cmd.SetArgs(append([]string{filepath.Join(chartDir, "values.yaml"), "--yes", "-o", outputPath}, tt.args...))
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("init --yes failed: %v", err)
+			}
+
+			output, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, tt.expected)
+			}
+			if got := strings.Contains(out.String(), "pass --domain"); got != tt.warning {
+				t.Errorf("Expected a --domain warning: %v, got:\n%s", tt.warning, out.String())
+			}
+		})
+	}
+}
+
+// TestInitCommand_ProposeWithoutChart tests that --yes outside a chart still
+// needs apiVersion and kind
+func TestInitCommand_ProposeWithoutChart(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(inputPath, []byte("replicas: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{inputPath, "--yes", "-o", filepath.Join(tmpDir, "output.yaml")})
+	cmd.SetOut(&bytes.Buffer{})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "apiVersion and kind are required") {
+		t.Errorf("Expected apiVersion and kind to be required, got: %v", err)
+	}
+}
+
+// TestInitCommand_ProposeFromChartArchive tests proposing apiVersion and kind
+// from the Chart.yaml of a packaged chart
+func TestInitCommand_ProposeFromChartArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "redis-0.9.0.tgz")
+	archive := chartArchive(t, "redis", map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: redis\nversion: 0.9.0\n",
+		"values.yaml": "replicas: 1\n",
+	})
+	if err := os.WriteFile(archivePath, archive, 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	outputPath := filepath.Join(tmpDir, "output.yaml")
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{"--from-chart-archive", archivePath, "--domain", "myorg.io", "-y", "-o", outputPath})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --from-chart-archive --yes failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "apiVersion: redis.myorg.io/v1alpha1\nkind: Redis\nreplicas: 1\n"
+	if string(output) != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+// TestInitCommand_Merge tests merging keys added upstream into example values
+func TestInitCommand_Merge(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "values.yaml")
+	outputPath := filepath.Join(tmpDir, "example.values.yaml")
+	if err := os.WriteFile(inputPath, []byte("replicas: 1\n# Image to run\nimage: nginx\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+	example := "apiVersion: myapp.io/v1\nkind: MyApp\n# +kubebuilder:validation:Minimum=1\nreplicas: 3\nlegacy: true\n"
+	if err := os.WriteFile(outputPath, []byte(example), 0644); err != nil {
+		t.Fatalf("Failed to write example: %v", err)
+	}
+
+	cmd := newInitCommand()
+	cmd.SetArgs([]string{inputPath, "--merge", "-o", outputPath})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --merge failed: %v", err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "apiVersion: myapp.io/v1\nkind: MyApp\n# +kubebuilder:validation:Minimum=1\nreplicas: 3\n# Image to run\nimage: nginx\nlegacy: true\n"
+	if string(output) != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+	for _, want := range []string{"+ image", "- legacy"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestInitCommand_MergeErrors tests --merge without example values or with flags it doesn't use
+func TestInitCommand_MergeErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(inputPath, []byte("replicas: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "missing example",
+			args: []string{inputPath, "--merge", "-o", filepath.Join(tmpDir, "missing.yaml")},
+			want: "must exist to merge into",
+		},
+		{
+			name: "kind",
+			args: []string{inputPath, "--merge", "--kind", "MyApp"},
+			want: "can't be used with --merge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newInitCommand()
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(&bytes.Buffer{})
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
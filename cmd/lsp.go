@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/crenshaw-dev/miaka/pkg/lsp"
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lspSchemaPath  string
+	lspStdio       bool
+	lspMetricsAddr string
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for values files",
+	Long: `Run a Language Server Protocol server over stdin/stdout for editing
+example.values.yaml and values files.
+
+The server provides:
+  - diagnostics: JSON Schema validation errors, placed on the offending field
+  - hovers: field descriptions (from comments in the example values file),
+    types, defaults, and allowed values
+  - completions: field names, enum values, and booleans
+
+It is backed by the generated JSON Schema, which is reloaded whenever it
+changes, so diagnostics stay current as you run 'miaka build'.
+
+With --metrics-addr, Prometheus metrics of the validations are served over
+HTTP at /metrics, for language servers shared by a team.`,
+	Example: `  # Configure your editor to run
+  miaka lsp --schema values.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runLSP,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+
+	lspCmd.Flags().StringVarP(&lspSchemaPath, "schema", "s", defaultSchemaPath, "Path to JSON Schema file")
+	// Many editors pass --stdio to language servers; stdio is the only transport
+	lspCmd.Flags().BoolVar(&lspStdio, "stdio", true, "Communicate over stdin/stdout")
+	_ = lspCmd.Flags().MarkHidden("stdio")
+	lspCmd.Flags().StringVar(&lspMetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on (if empty, metrics are disabled)")
+}
+
+func runLSP(cmd *cobra.Command, _ []string) error {
+	var opts lsp.Options
+	if lspMetricsAddr != "" {
+		// Listen first, so a bad address fails before the editor connects
+		listener, err := net.Listen("tcp", lspMetricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", lspMetricsAddr, err)
+		}
+		opts.Metrics = metrics.New("lsp")
+		server := newMetricsServer(lspMetricsAddr, opts.Metrics.Handler())
+		go func() {
+			_ = server.Serve(listener)
+		}()
+		defer func() {
+			_ = server.Close()
+		}()
+		// stdout carries the protocol
+		fmt.Fprintf(cmd.ErrOrStderr(), "✓ Serving metrics on %s%s\n", listener.Addr(), metrics.Path)
+	}
+	return lsp.NewServer(lspSchemaPath, opts).Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+}
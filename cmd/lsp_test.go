@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLSPCommand creates a fresh lsp command instance for testing
+func newLSPCommand() *cobra.Command {
+	lspSchemaPath = defaultSchemaPath
+	lspMetricsAddr = ""
+
+	cmd := &cobra.Command{
+		Use:          "lsp",
+		Args:         cobra.NoArgs,
+		RunE:         runLSP,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&lspSchemaPath, "schema", "s", defaultSchemaPath, "Path to JSON Schema file")
+	cmd.Flags().BoolVar(&lspStdio, "stdio", true, "Communicate over stdin/stdout")
+	cmd.Flags().StringVar(&lspMetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on (if empty, metrics are disabled)")
+
+	return cmd
+}
+
+func TestLSPCommand(t *testing.T) {
+	schemaPath := filepath.Join("..", "testdata", "build", "minimal", "expected_schema.json")
+
+	var in strings.Builder
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///v.yaml","text":"port: http\n"}}}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	cmd := newLSPCommand()
+	cmd.SetArgs([]string{"--stdio", "--schema", schemaPath})
+	cmd.SetIn(strings.NewReader(in.String()))
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), `"hoverProvider":true`)
+	assert.Contains(t, out.String(), `"method":"textDocument/publishDiagnostics"`)
+	assert.Contains(t, out.String(), `port: got string, want integer`)
+}
+
+func TestLSPCommand_MetricsAddrInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	cmd := newLSPCommand()
+	cmd.SetArgs([]string{"--metrics-addr", listener.Addr().String()})
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to listen on "+listener.Addr().String())
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+)
+
+// TestMain builds into a cache of its own, so tests don't fill the user's build
+// cache or read outputs it cached
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "miaka-cache-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create cache directory: %v\n", err)
+		os.Exit(1)
+	}
+	os.Setenv(cache.DirEnv, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/merge"
+	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+var (
+	mergeCRDPath string
+	mergeOutput  string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <base.yaml> <ours.yaml> <theirs.yaml>",
+	Short: "Three-way merge values files using the schema",
+	Long: `Perform a structure-aware three-way merge of values files.
+
+Objects are merged key by key, so changes to different fields never conflict.
+Lists follow the x-kubernetes-list-type of the field in the CRD:
+  - atomic (default) - the whole list is replaced; concurrent edits conflict
+  - set              - additions and removals from both sides are combined
+  - map              - items are merged by their x-kubernetes-list-map-keys
+
+Conflicts are only reported where a value was changed differently on both
+sides. Conflicting values keep "our" side in the output, and the command
+exits with an error listing every conflict.
+
+Comments and key order from "ours" are preserved.
+
+If the CRD (crd.yaml by default) does not exist, all lists are treated as atomic.`,
+	Example: `  # Merge and print the result
+  miaka merge base.yaml ours.yaml theirs.yaml
+
+  # Write the result back to our file
+  miaka merge base.yaml values.yaml theirs.yaml -o values.yaml
+
+  # Use as a git merge driver (in .git/config)
+  #   [merge "miaka"]
+  #     driver = miaka merge %O %A %B -o %A`,
+	Args: cobra.ExactArgs(3),
+	RunE: runMerge,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVarP(&mergeCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file used to determine list merge semantics")
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "Output path for merged values (default: stdout)")
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	var crdSchema *apiextensionsv1.JSONSchemaProps
+	if _, err := os.Stat(mergeCRDPath); err == nil {
+		s, err := validation.LoadCRDSchema(mergeCRDPath)
+		if err != nil {
+			return fmt.Errorf("failed to load CRD schema: %w", err)
+		}
+		crdSchema = s
+	}
+
+	output, mergeErr := merge.NewMerger(crdSchema).MergeFiles(args[0], args[1], args[2])
+
+	var conflictErr *merge.ConflictError
+	if mergeErr != nil && !errors.As(mergeErr, &conflictErr) {
+		return mergeErr
+	}
+
+	if mergeOutput == "" {
+		if _, err := cmd.OutOrStdout().Write(output); err != nil {
+			return fmt.Errorf("failed to write merged values: %w", err)
+		}
+	} else if err := os.WriteFile(mergeOutput, output, 0644); err != nil {
+		return fmt.Errorf("failed to write merged values: %w", err)
+	}
+
+	return mergeErr
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMergeCommand creates a fresh merge command instance for testing
+func newMergeCommand() *cobra.Command {
+	mergeCRDPath = defaultCRDPath
+	mergeOutput = ""
+
+	cmd := &cobra.Command{
+		Use:          "merge",
+		Args:         cobra.ExactArgs(3),
+		RunE:         runMerge,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&mergeCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	cmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "Output path for merged values")
+
+	return cmd
+}
+
+func TestMergeCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	base := write("base.yaml", "replicas: 1\ntag: v1\n")
+	ours := write("ours.yaml", "replicas: 3\ntag: v1\n")
+	theirs := write("theirs.yaml", "replicas: 1\ntag: v2\n")
+
+	cmd := newMergeCommand()
+	cmd.SetArgs([]string{base, ours, theirs, "--crd", filepath.Join(tmpDir, "missing.yaml")})
+	outBuf := new(bytes.Buffer)
+	cmd.SetOut(outBuf)
+
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "replicas: 3\ntag: v2\n", outBuf.String())
+}
+
+func TestMergeCommand_ConflictWritesOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	base := write("base.yaml", "replicas: 1\n")
+	ours := write("ours.yaml", "replicas: 3\n")
+	theirs := write("theirs.yaml", "replicas: 5\n")
+	output := filepath.Join(tmpDir, "merged.yaml")
+
+	cmd := newMergeCommand()
+	cmd.SetArgs([]string{base, ours, theirs, "-o", output, "--crd", filepath.Join(tmpDir, "missing.yaml")})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "replicas")
+
+	merged, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 3\n", string(merged))
+}
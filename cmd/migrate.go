@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateExamplePath string
+	migratePlain       bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate <values.yaml>...",
+	Short: "Rename the old keys of renamed fields in values files",
+	Long: `Rename the keys of values files whose fields were renamed in the example
+values file (example.values.yaml by default), in place.
+
+Declare a rename with +miaka:renamedFrom on the field's new key. The build
+then treats it as a rename rather than a removal: the breaking-change check
+accepts it, and the JSON Schema keeps the old key as a deprecated alias.
+Comments and formatting of the values files are kept.`,
+	Example: `  # In example.values.yaml, after renaming image.repo to image.repository:
+  #   image:
+  #     # +miaka:renamedFrom: repo
+  #     repository: nginx
+
+  # Rename image.repo in user values files
+  miaka migrate values-prod.yaml values-staging.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMigrate,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVarP(&migrateExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file with +miaka:renamedFrom markers")
+	migrateCmd.Flags().BoolVar(&migratePlain, "plain", false, "Parse example values without apiVersion or kind (see 'miaka build --plain')")
+
+	migrateCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagFiles(migrateCmd, "example", yamlExtensions...)
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	s, err := parsing.NewParserWithOptions(parsing.Options{Plain: migratePlain}).ParseFile(migrateExamplePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", migrateExamplePath, err)
+	}
+	renames := s.Renames()
+	if len(renames) == 0 {
+		return fmt.Errorf("%s has no +miaka:renamedFrom markers", migrateExamplePath)
+	}
+
+	out := cmd.OutOrStdout()
+	for _, path := range args {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		migrated, changes, err := migrate.Migrate(data, renames)
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", path, err)
+		}
+		if len(changes) == 0 {
+			fmt.Fprintf(out, "✓ %s is up to date\n", path)
+			continue
+		}
+		if err := os.WriteFile(path, migrated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		for _, change := range changes {
+			fmt.Fprintf(out, "✓ %s: %s → %s\n", path, change.From, change.To)
+		}
+	}
+	return nil
+}
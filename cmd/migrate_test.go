@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMigrateCommand creates a fresh migrate command instance for testing
+func newMigrateCommand() *cobra.Command {
+	migrateExamplePath = defaultExampleValuesFile
+	migratePlain = false
+
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Args:         cobra.MinimumNArgs(1),
+		RunE:         runMigrate,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&migrateExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file")
+	cmd.Flags().BoolVar(&migratePlain, "plain", false, "Parse example values without apiVersion or kind")
+
+	return cmd
+}
+
+func TestMigrateCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	prodPath := filepath.Join(tmpDir, "prod.yaml")
+	stagingPath := filepath.Join(tmpDir, "staging.yaml")
+
+	require.NoError(t, os.WriteFile(examplePath, []byte(`apiVersion: demo.io/v1
+kind: Demo
+image:
+  # +miaka:renamedFrom: repo
+  repository: nginx
+`), 0644))
+	require.NoError(t, os.WriteFile(prodPath, []byte("image:\n  repo: nginx # pinned\n"), 0600))
+	require.NoError(t, os.WriteFile(stagingPath, []byte("image:\n  repository: nginx\n"), 0644))
+
+	cmd := newMigrateCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-e", examplePath, prodPath, stagingPath})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "✓ "+prodPath+": image.repo → image.repository\n✓ "+stagingPath+" is up to date\n", out.String())
+	migrated, err := os.ReadFile(prodPath)
+	require.NoError(t, err)
+	assert.Equal(t, "image:\n  repository: nginx # pinned\n", string(migrated))
+
+	// The file keeps its permissions, which Windows doesn't have
+	info, err := os.Stat(prodPath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestMigrateCommand_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	require.NoError(t, os.WriteFile(valuesPath, []byte("image:\n  repo: a\n  repository: b\n"), 0644))
+
+	require.NoError(t, os.WriteFile(examplePath, []byte("apiVersion: demo.io/v1\nkind: Demo\nreplicas: 1\n"), 0644))
+	cmd := newMigrateCommand()
+	cmd.SetArgs([]string{"-e", examplePath, valuesPath})
+	assert.EqualError(t, cmd.Execute(), examplePath+" has no +miaka:renamedFrom markers")
+
+	require.NoError(t, os.WriteFile(examplePath, []byte("apiVersion: demo.io/v1\nkind: Demo\nimage:\n  # +miaka:renamedFrom: repo\n  repository: nginx\n"), 0644))
+	cmd = newMigrateCommand()
+	cmd.SetArgs([]string{"-e", examplePath, valuesPath})
+	assert.EqualError(t, cmd.Execute(), "failed to migrate "+valuesPath+": both image.repo and image.repository, its new name, are set")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/crenshaw-dev/miaka/pkg/importer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newValuesOutput        string
+	newValuesOnlyRequired  bool
+	newValuesSkipDefaulted bool
+	newValuesCRDVersion    string
+	newValuesForce         bool
+)
+
+var newValuesCmd = &cobra.Command{
+	Use:   "new-values [schema]",
+	Short: "Generate a starter values file from a chart's schema",
+	Long: `Generate a starter values file for installing a chart, from its
+values.schema.json or CRD. This is the counterpart of 'miaka build' for chart
+consumers: it bootstraps a configuration that matches the schema.
+
+Each field is set to its default, or an example value that satisfies the
+schema, with its description as a comment. Required fields are marked
+"# required", and deprecated fields are left out.
+
+  --only-required   only include required fields
+  --skip-defaulted  leave out fields with a default, which apply without being set
+
+Together they give the fields a consumer has to set.
+
+The schema is a values.schema.json, a CustomResourceDefinition, or a chart
+directory containing values.schema.json. It defaults to values.schema.json, or
+crd.yaml if there is none. The storage version of a CRD is used unless
+--version is set.`,
+	Example: `  # Print a starter values file for the chart in the current directory
+  miaka new-values
+
+  # Just the fields that have to be set, from a chart directory
+  miaka new-values charts/myapp --only-required --skip-defaulted -o my-values.yaml
+
+  # From a CRD
+  miaka new-values config/crd/bases/myapp.io_myapps.yaml --version v1beta1`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runNewValues,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(newValuesCmd)
+
+	newValuesCmd.Flags().StringVarP(&newValuesOutput, "output", "o", "", "Output file path (default: stdout)")
+	newValuesCmd.Flags().BoolVar(&newValuesOnlyRequired, "only-required", false, "Only include required fields")
+	newValuesCmd.Flags().BoolVar(&newValuesSkipDefaulted, "skip-defaulted", false, "Leave out fields with a default")
+	newValuesCmd.Flags().StringVar(&newValuesCRDVersion, "version", "", "CRD version to use (default: the storage version)")
+	newValuesCmd.Flags().BoolVar(&newValuesForce, "force", false, "Overwrite the output file if it exists")
+}
+
+func runNewValues(cmd *cobra.Command, args []string) error {
+	input, err := newValuesInput(args)
+	if err != nil {
+		return err
+	}
+	if newValuesOutput != "" && !newValuesForce {
+		if _, err := os.Stat(newValuesOutput); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", newValuesOutput)
+		}
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	result, err := importer.NewValues(data, importer.ValuesOptions{
+		OnlyRequired:  newValuesOnlyRequired,
+		SkipDefaulted: newValuesSkipDefaulted,
+		Version:       newValuesCRDVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate values from %s: %w", input, err)
+	}
+
+	header := fmt.Sprintf("# Starter values generated from %s by 'miaka new-values'.\n", filepath.ToSlash(input))
+	content := append([]byte(header), result.Content...)
+	if newValuesOutput == "" {
+		if _, err := cmd.OutOrStdout().Write(content); err != nil {
+			return fmt.Errorf("failed to write values: %w", err)
+		}
+		return nil
+	}
+	if err := writeOutput(newValuesOutput, content); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "✓ Created %s from %s\n", newValuesOutput, input)
+	return nil
+}
+
+// newValuesInput returns the schema to generate values from: the argument (or
+// the values.schema.json in it, for a directory), or values.schema.json or
+// crd.yaml in the current directory
+func newValuesInput(args []string) (string, error) {
+	if len(args) == 0 {
+		if _, err := os.Stat(defaultSchemaPath); err == nil {
+			return defaultSchemaPath, nil
+		}
+		if _, err := os.Stat(defaultCRDPath); err == nil {
+			return defaultCRDPath, nil
+		}
+		return "", fmt.Errorf("no %s or %s found (pass the schema as an argument)", defaultSchemaPath, defaultCRDPath)
+	}
+	if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+		return filepath.Join(args[0], defaultSchemaPath), nil
+	}
+	return args[0], nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNewValuesCommand creates a fresh new-values command instance for testing
+func newNewValuesCommand() *cobra.Command {
+	newValuesOutput = ""
+	newValuesOnlyRequired = false
+	newValuesSkipDefaulted = false
+	newValuesCRDVersion = ""
+	newValuesForce = false
+
+	cmd := &cobra.Command{
+		Use:          "new-values",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runNewValues,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&newValuesOutput, "output", "o", "", "Output file path")
+	cmd.Flags().BoolVar(&newValuesOnlyRequired, "only-required", false, "Only include required fields")
+	cmd.Flags().BoolVar(&newValuesSkipDefaulted, "skip-defaulted", false, "Leave out fields with a default")
+	cmd.Flags().StringVar(&newValuesCRDVersion, "version", "", "CRD version to use")
+	cmd.Flags().BoolVar(&newValuesForce, "force", false, "Overwrite the output file if it exists")
+
+	return cmd
+}
+
+const newValuesSchema = `{
+  "type": "object",
+  "required": ["host"],
+  "properties": {
+    "host": {"type": "string", "description": "Public hostname"},
+    "replicas": {"type": "integer", "default": 1}
+  }
+}`
+
+func TestNewValuesCommand(t *testing.T) {
+	chartDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "values.schema.json"), []byte(newValuesSchema), 0644))
+
+	// A chart directory uses its values.schema.json
+	cmd := newNewValuesCommand()
+	cmd.SetArgs([]string{chartDir, "--only-required"})
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "# Starter values generated from "+filepath.ToSlash(filepath.Join(chartDir, "values.schema.json"))+" by 'miaka new-values'.\n# Public hostname\nhost: \"\" # required\n", out.String())
+
+	// The current directory's schema is the default, and -o writes a file
+	t.Chdir(chartDir)
+	cmd = newNewValuesCommand()
+	cmd.SetArgs([]string{"-o", "my-values.yaml"})
+	out.Reset()
+	cmd.SetOut(out)
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "✓ Created my-values.yaml from values.schema.json\n", out.String())
+	content, err := os.ReadFile("my-values.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "host: \"\" # required\nreplicas: 1\n")
+
+	cmd = newNewValuesCommand()
+	cmd.SetArgs([]string{"-o", "my-values.yaml"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "my-values.yaml already exists (use --force to overwrite)")
+
+	cmd = newNewValuesCommand()
+	cmd.SetArgs([]string{"-o", "my-values.yaml", "--force", "--skip-defaulted"})
+	cmd.SetOut(new(bytes.Buffer))
+	require.NoError(t, cmd.Execute())
+	content, err = os.ReadFile("my-values.yaml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "replicas")
+}
+
+func TestNewValuesCommand_CRD(t *testing.T) {
+	cmd := newNewValuesCommand()
+	cmd.SetArgs([]string{filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")})
+	out := new(bytes.Buffer)
+	cmd.SetOut(out)
+	require.NoError(t, cmd.Execute())
+	assert.NotContains(t, out.String(), "apiVersion")
+	assert.NotContains(t, out.String(), "metadata")
+
+	cmd = newNewValuesCommand()
+	cmd.SetArgs([]string{filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml"), "--version", "v9"})
+	cmd.SetOut(new(bytes.Buffer))
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "version v9 not found")
+}
+
+func TestNewValuesCommand_NoSchema(t *testing.T) {
+	t.Chdir(t.TempDir())
+	cmd := newNewValuesCommand()
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no values.schema.json or crd.yaml found")
+}
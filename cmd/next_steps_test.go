@@ -31,6 +31,10 @@ image: nginx:latest`
 	initAPIVersion = "myapp.io/v1"
 	initKind = "MyApp"
 	initOutput = "example.values.yaml"
+	initHelmPlugin = false
+	initPlain = false
+	initFromChart = ""
+	initMerge = false
 
 	// Run init command
 	err = runInit(nil, []string{"values.yaml"})
@@ -80,6 +84,15 @@ image: nginx:latest`
 	buildCRDPath = "crd.yaml"
 	buildSchemaPath = "values.schema.json"
 	buildTypesPath = ""
+	buildTSPath = ""
+	buildEmit = nil
+	buildSuggestHints = false
+	buildInMemory = false
+	buildHermetic = false
+	buildPreviousCRD = ""
+	buildDepsFile = ""
+	buildNoColor = false
+	buildMaxLosses = -1
 
 	// Run build command
 	err = runBuild(nil, []string{"example.values.yaml"})
@@ -169,6 +182,15 @@ spec:
 	buildCRDPath = "crd.yaml"
 	buildSchemaPath = "values.schema.json"
 	buildTypesPath = ""
+	buildTSPath = ""
+	buildEmit = nil
+	buildSuggestHints = false
+	buildInMemory = false
+	buildHermetic = false
+	buildPreviousCRD = ""
+	buildDepsFile = ""
+	buildNoColor = false
+	buildMaxLosses = -1
 
 	// Run build command
 	err = runBuild(nil, []string{"example.values.yaml"})
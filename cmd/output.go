@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/logging"
+	"github.com/crenshaw-dev/miaka/pkg/sarif"
+	"github.com/crenshaw-dev/miaka/pkg/structdiff"
+	"github.com/crenshaw-dev/miaka/pkg/upgrade"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of commands with a machine-readable result
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+)
+
+// Statuses of a commandResult
+const (
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+// commandResult is the result of a command with --output json, for tools that
+// wrap miaka. Fields may be added, but are never renamed or removed.
+type commandResult struct {
+	Command string `json:"command"`
+	Status  string `json:"status"`
+	// Files are the files the command wrote
+	Files    []string      `json:"files,omitempty"`
+	Errors   []resultIssue `json:"errors,omitempty"`
+	Warnings []resultIssue `json:"warnings,omitempty"`
+	// Changes are the changes between two schemas, for upgrade-check
+	Changes []upgrade.SchemaChange `json:"changes,omitempty"`
+	// Diff lists the structural differences between two documents, for diff
+	Diff []structdiff.Change `json:"diff,omitempty"`
+}
+
+// resultIssue is an error or warning of a commandResult, located in a values
+// file when it's about a value
+type resultIssue struct {
+	// Rule is the kind of issue (e.g., "schema-validation"), or "" for errors
+	// that stop the command
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	// Path is the dotted path of the value (e.g., "service.ports.0.name")
+	Path string `json:"path,omitempty"`
+}
+
+// checkOutputFormat rejects output formats other than text, json, and the
+// extra formats a command supports
+func checkOutputFormat(format string, extra ...string) error {
+	return checkFormat(format, append([]string{outputText, outputJSON}, extra...)...)
+}
+
+// checkFormat rejects output formats other than formats, for commands that
+// don't print text
+func checkFormat(format string, formats ...string) error {
+	for _, f := range formats {
+		if format == f {
+			return nil
+		}
+	}
+	if len(formats) == 2 {
+		return fmt.Errorf("invalid output format %q (must be %s or %s)", format, formats[0], formats[1])
+	}
+	return fmt.Errorf("invalid output format %q (must be %s, or %s)", format, strings.Join(formats[:len(formats)-1], ", "), formats[len(formats)-1])
+}
+
+// problemIssue converts a validation problem to an issue of a rule
+func problemIssue(rule string, p validation.Problem) resultIssue {
+	return resultIssue{Rule: rule, Message: p.Message, File: p.File, Line: p.Line, Column: p.Column, Path: strings.Join(p.Path, ".")}
+}
+
+// writeResult writes a command's result as JSON and returns err, the command's
+// error. The status is failed if err is set, which is added to the errors unless
+// they already explain it.
+func writeResult(w io.Writer, result commandResult, err error) error {
+	result.Status = statusOK
+	if err != nil {
+		result.Status = statusFailed
+		if len(result.Errors) == 0 {
+			result.Errors = []resultIssue{{Message: err.Error()}}
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(result); encodeErr != nil && err == nil {
+		return encodeErr
+	}
+	return err
+}
+
+// writeSARIF writes a command's errors and warnings as a SARIF log, for GitHub
+// code scanning, and returns err, the command's error. err is added to the
+// results unless the errors already explain it. Issues without a rule are
+// results of a rule named after the command.
+func writeSARIF(w io.Writer, result commandResult, err error) error {
+	if err != nil && len(result.Errors) == 0 {
+		result.Errors = []resultIssue{{Message: err.Error()}}
+	}
+
+	log := sarif.NewLog(sarif.Driver{Name: "miaka", Version: version, InformationURI: "https://github.com/crenshaw-dev/miaka"})
+	for _, issues := range []struct {
+		level  string
+		issues []resultIssue
+	}{{sarif.LevelError, result.Errors}, {sarif.LevelWarning, result.Warnings}} {
+		for _, issue := range issues.issues {
+			rule := issue.Rule
+			if rule == "" {
+				rule = result.Command
+			}
+			message := issue.Message
+			if issue.Path != "" {
+				message = issue.Path + ": " + message
+			}
+			log.Add(rule, issues.level, message, issue.File, issue.Line, issue.Column)
+		}
+	}
+	if writeErr := log.Write(w); writeErr != nil && err == nil {
+		return writeErr
+	}
+	return err
+}
+
+// newLogger returns a logger printing to w at the level chosen with --quiet or --verbose
+func newLogger(w io.Writer) logging.Logger {
+	level := logging.LevelInfo
+	switch {
+	case logQuiet:
+		level = logging.LevelWarn
+	case logVerbose:
+		level = logging.LevelDebug
+	}
+	return logging.New(w, level)
+}
+
+// commandOut returns the output of a command, or stdout if there is no command
+// (e.g., in tests calling RunE directly)
+func commandOut(cmd *cobra.Command) io.Writer {
+	if cmd == nil {
+		return os.Stdout
+	}
+	return cmd.OutOrStdout()
+}
+
+// commandContext returns the context of a command, which is done on Ctrl-C or
+// after --timeout, or the background context if there is no command
+func commandContext(cmd *cobra.Command) context.Context {
+	if cmd == nil || cmd.Context() == nil {
+		return context.Background()
+	}
+	return cmd.Context()
+}
+
+// commandErr returns the error output of a command, or stderr if there is no command
+func commandErr(cmd *cobra.Command) io.Writer {
+	if cmd == nil {
+		return os.Stderr
+	}
+	return cmd.ErrOrStderr()
+}
+
+// plainReplacer replaces the emoji used in status messages with plain text
+var plainReplacer = strings.NewReplacer(
+	"✓", "ok:",
+	"✗", "error:",
+	"⚠️  ", "warning: ",
+	"⚠️", "warning:",
+	"💡", "hint:",
+	"📝 ", "",
+	"🎉 ", "",
+)
+
+// noColorRequested reports whether decorated output was disabled with a flag or the NO_COLOR environment variable
+func noColorRequested(flag bool) bool {
+	return flag || os.Getenv("NO_COLOR") != ""
+}
+
+// statusWriter returns w, or a writer that strips emoji from status messages when plain is set
+func statusWriter(w io.Writer, plain bool) io.Writer {
+	if !plain {
+		return w
+	}
+	return plainWriter{w: w}
+}
+
+// plainWriter writes status messages without emoji, for logs and terminals that can't render them
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, plainReplacer.Replace(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := statusWriter(&buf, false)
+	fmt.Fprintln(w, "✓ CRD generated: crd.yaml")
+	assert.Equal(t, "✓ CRD generated: crd.yaml\n", buf.String())
+
+	buf.Reset()
+	w = statusWriter(&buf, true)
+	fmt.Fprintln(w, "✓ CRD generated: crd.yaml")
+	fmt.Fprintln(w, "⚠️  Review these fields:")
+	fmt.Fprintln(w, "📝 Next steps:")
+	fmt.Fprintln(w, "💡 Run 'miaka build --suggest-hints'")
+	assert.Equal(t, `ok: CRD generated: crd.yaml
+warning: Review these fields:
+Next steps:
+hint: Run 'miaka build --suggest-hints'
+`, buf.String())
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	assert.NoError(t, checkOutputFormat("text"))
+	assert.NoError(t, checkOutputFormat("sarif", outputSARIF))
+	assert.EqualError(t, checkOutputFormat("xml"), `invalid output format "xml" (must be text or json)`)
+	assert.EqualError(t, checkOutputFormat("xml", outputSARIF), `invalid output format "xml" (must be text, json, or sarif)`)
+	assert.EqualError(t, checkFormat("text", "yaml", outputJSON), `invalid output format "text" (must be yaml or json)`)
+}
+
+func TestNoColorRequested(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	assert.False(t, noColorRequested(false))
+	assert.True(t, noColorRequested(true))
+
+	t.Setenv("NO_COLOR", "1")
+	assert.True(t, noColorRequested(false))
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Cleanup(func() { logQuiet, logVerbose = false, false })
+
+	for _, tt := range []struct {
+		quiet, verbose bool
+		want           string
+	}{
+		{want: "info\nwarn\n"},
+		{quiet: true, want: "warn\n"},
+		{verbose: true, want: "debug\ninfo\nwarn\n"},
+	} {
+		logQuiet, logVerbose = tt.quiet, tt.verbose
+		var buf bytes.Buffer
+		log := newLogger(&buf)
+		log.Debugf("debug")
+		log.Infof("info")
+		log.Warnf("warn")
+		assert.Equal(t, tt.want, buf.String())
+	}
+}
+
+func TestQuietAndVerboseExclusive(t *testing.T) {
+	rootCmd.SetArgs([]string{"version", "--quiet", "--verbose"})
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		logQuiet, logVerbose = false, false
+		rootCmd.PersistentFlags().Lookup("quiet").Changed = false
+		rootCmd.PersistentFlags().Lookup("verbose").Changed = false
+	})
+
+	err := rootCmd.Execute()
+	assert.ErrorContains(t, err, "[quiet verbose] were all set")
+}
+
+func TestApplyTimeout(t *testing.T) {
+	t.Cleanup(func() {
+		cancelTimeout()
+		commandTimeout, cancelTimeout = 0, func() {}
+	})
+
+	// No timeout by default
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	applyTimeout(cmd, nil)
+	_, ok := cmd.Context().Deadline()
+	assert.False(t, ok)
+
+	commandTimeout = time.Millisecond
+	applyTimeout(cmd, nil)
+	<-cmd.Context().Done()
+	assert.EqualError(t, context.Cause(cmd.Context()), "timed out after 1ms (--timeout)")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/anonymize"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/overlay"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	overlayCRDPath      string
+	overlayExamplePath  string
+	overlayOutput       string
+	overlayOnlyRequired bool
+	overlayForce        bool
+)
+
+var overlayCmd = &cobra.Command{
+	Use:   "overlay",
+	Short: "Manage environment overlay values files",
+	Long: `Manage values files that override the chart's values for one environment,
+e.g., values-prod.yaml used as "helm install -f values-prod.yaml".`,
+}
+
+var overlayNewCmd = &cobra.Command{
+	Use:   "new <environment>",
+	Short: "Generate a minimal values file for a new environment",
+	Long: `Generate a minimal values file for a new environment, containing only the
+fields each environment has to set:
+  - required fields without a default
+  - fields without a safe default: fields marked +miaka:secret, and fields with
+    no default whose value in the example values file is empty
+
+Each field is pre-populated with a placeholder marked "# TODO". With
+--only-required, only required fields are included.
+
+The schema comes from the CRD (crd.yaml by default), and placeholders from the
+example values file (example.values.yaml by default).`,
+	Example: `  # Create values-prod.yaml
+  miaka overlay new prod
+
+  # Only required fields, with a custom output path
+  miaka overlay new staging --only-required -o envs/staging.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runOverlayNew,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(overlayCmd)
+	overlayCmd.AddCommand(overlayNewCmd)
+
+	overlayNewCmd.Flags().StringVarP(&overlayCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	overlayNewCmd.Flags().StringVarP(&overlayExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file used for placeholders and +miaka:secret markers")
+	overlayNewCmd.Flags().StringVarP(&overlayOutput, "output", "o", "", "Output file path (default: values-<environment>.yaml)")
+	overlayNewCmd.Flags().BoolVar(&overlayOnlyRequired, "only-required", false, "Only include required fields")
+	overlayNewCmd.Flags().BoolVar(&overlayForce, "force", false, "Overwrite the output file if it exists")
+}
+
+func runOverlayNew(_ *cobra.Command, args []string) error {
+	environment := args[0]
+	output := overlayOutput
+	if output == "" {
+		output = fmt.Sprintf("values-%s.yaml", environment)
+	}
+	if _, err := os.Stat(output); err == nil && !overlayForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", output)
+	}
+
+	props, err := validation.LoadCRDSchema(overlayCRDPath)
+	if err != nil {
+		return fmt.Errorf("failed to load CRD schema: %w", err)
+	}
+
+	opts := overlay.Options{Environment: environment, OnlyRequired: overlayOnlyRequired}
+	example := map[string]interface{}{}
+	if data, err := os.ReadFile(overlayExamplePath); err == nil {
+		if err := yaml.Unmarshal(data, &example); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", overlayExamplePath, err)
+		}
+		if opts.SecretPaths, err = anonymize.FindSecretPaths(data); err != nil {
+			return fmt.Errorf("failed to find secret fields: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read example values file: %w", err)
+	}
+
+	result, err := overlay.Generate(props, example, opts)
+	if err != nil {
+		return err
+	}
+
+	header := fmt.Sprintf("# Values for the %s environment, generated by 'miaka overlay new'.\n# Replace each TODO placeholder.\n", environment)
+	if err := writeOutput(output, append([]byte(header), result.Content...)); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Printf("✓ Created %s with %d field(s) to fill in\n", output, len(result.Fields))
+	for _, field := range result.Fields {
+		fmt.Printf("  - %s\n", field)
+	}
+
+	fmt.Println()
+	fmt.Println("📝 Next steps:")
+	fmt.Println("  1. Replace each TODO placeholder in", output)
+	fmt.Printf("  2. Validate it on top of the chart's values: miaka validate -f values.yaml -f %s\n", output)
+	return nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOverlayNewCommand creates a fresh overlay new command instance for testing
+func newOverlayNewCommand() *cobra.Command {
+	overlayCRDPath = defaultCRDPath
+	overlayExamplePath = defaultExampleValuesFile
+	overlayOutput = ""
+	overlayOnlyRequired = false
+	overlayForce = false
+
+	cmd := &cobra.Command{
+		Use:          "new",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runOverlayNew,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&overlayCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	cmd.Flags().StringVarP(&overlayExamplePath, "example", "e", defaultExampleValuesFile, "Path to example values file")
+	cmd.Flags().StringVarP(&overlayOutput, "output", "o", "", "Output file path")
+	cmd.Flags().BoolVar(&overlayOnlyRequired, "only-required", false, "Only include required fields")
+	cmd.Flags().BoolVar(&overlayForce, "force", false, "Overwrite the output file if it exists")
+
+	return cmd
+}
+
+const overlayCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: demos.demo.io
+spec:
+  group: demo.io
+  names:
+    kind: Demo
+    plural: demos
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        required: [host]
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          host:
+            description: Public hostname
+            type: string
+          password:
+            type: string
+          tag:
+            type: string
+          replicas:
+            type: integer
+`
+
+const overlayExample = `apiVersion: demo.io/v1
+kind: Demo
+# Public hostname
+# +kubebuilder:validation:Required
+host: app.example.com
+# +miaka:secret
+password: changeme
+tag: ""
+replicas: 1
+`
+
+func TestOverlayNewCommand(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(defaultCRDPath, []byte(overlayCRD), 0644))
+	require.NoError(t, os.WriteFile(defaultExampleValuesFile, []byte(overlayExample), 0644))
+
+	cmd := newOverlayNewCommand()
+	cmd.SetArgs([]string{"prod"})
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "✓ Created values-prod.yaml with 3 field(s) to fill in\n")
+	assert.Contains(t, stdout, "miaka validate -f values.yaml -f values-prod.yaml\n")
+
+	content, err := os.ReadFile("values-prod.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `# Values for the prod environment, generated by 'miaka overlay new'.
+# Replace each TODO placeholder.
+# Public hostname
+host: app.example.com # TODO: set for prod
+password: "" # TODO: set for prod
+tag: "" # TODO: set for prod
+`, string(content))
+
+	// Existing files aren't overwritten without --force
+	cmd = newOverlayNewCommand()
+	cmd.SetArgs([]string{"prod", "--only-required"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "values-prod.yaml already exists")
+
+	cmd = newOverlayNewCommand()
+	cmd.SetArgs([]string{"prod", "--only-required", "--force"})
+	_, _, err = captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+	content, err = os.ReadFile("values-prod.yaml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "password")
+}
+
+func TestOverlayNewCommand_Output(t *testing.T) {
+	tmpDir := t.TempDir()
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	require.NoError(t, os.WriteFile(crdPath, []byte(overlayCRD), 0644))
+	output := filepath.Join(tmpDir, "envs", "staging.yaml")
+
+	// Without an example values file, placeholders are zero values
+	cmd := newOverlayNewCommand()
+	cmd.SetArgs([]string{"staging", "-c", crdPath, "-e", filepath.Join(tmpDir, "missing.yaml"), "-o", output})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "host: \"\" # TODO: set for staging\n")
+}
+
+func TestOverlayNewCommand_MissingCRD(t *testing.T) {
+	cmd := newOverlayNewCommand()
+	cmd.SetArgs([]string{"prod", "-c", filepath.Join(t.TempDir(), "missing.yaml"), "-o", filepath.Join(t.TempDir(), "out.yaml")})
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load CRD schema")
+}
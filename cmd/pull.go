@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pullCRDPath    string
+	pullSchemaPath string
+	pullPlainHTTP  bool
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull oci://<registry>/<repository>:<tag>",
+	Short: "Pull a CRD and JSON Schema pushed with 'miaka push'",
+	Long: `Pull a schema artifact pushed with 'miaka push' and write its CRD and JSON
+Schema, e.g. for CI validators or editors that check values against schemas
+published by another repository.
+
+The reference may have a tag or a digest (oci://<registry>/<repository>@sha256:...).
+Files the artifact doesn't contain aren't written. Credentials are read the
+same way as 'miaka push'.`,
+	Example: `  # Write crd.yaml and values.schema.json
+  miaka pull oci://ghcr.io/myorg/schemas/myapp:1.0.0
+
+  # Only write the JSON Schema, then validate against it
+  miaka pull oci://ghcr.io/myorg/schemas/myapp:1.0.0 --crd "" -s schemas/myapp.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPull,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+
+	pullCmd.Flags().StringVarP(&pullCRDPath, "crd", "c", defaultCRDPath, "Output path for the CRD YAML file (empty to skip)")
+	pullCmd.Flags().StringVarP(&pullSchemaPath, "schema", "s", defaultSchemaPath, "Output path for the JSON Schema file (empty to skip)")
+	pullCmd.Flags().BoolVar(&pullPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS")
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	ref, err := oci.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+	client, err := newOCIClient(pullPlainHTTP)
+	if err != nil {
+		return err
+	}
+	files, err := client.PullArtifact(cmd.Context(), ref, oci.SchemaArtifactType)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+
+	outputs := map[string]string{
+		oci.CRDMediaType:        pullCRDPath,
+		oci.JSONSchemaMediaType: pullSchemaPath,
+	}
+	written := 0
+	for _, file := range files {
+		path := outputs[file.MediaType]
+		if path == "" {
+			continue
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+		}
+		if err := os.WriteFile(path, file.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Wrote %s to %s\n", file.Name, path)
+		written++
+	}
+
+	if written == 0 {
+		return fmt.Errorf("%s has no files to write", ref)
+	}
+	return nil
+}
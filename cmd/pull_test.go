@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPullCommand creates a fresh pull command instance for testing
+func newPullCommand() *cobra.Command {
+	pullCRDPath = defaultCRDPath
+	pullSchemaPath = defaultSchemaPath
+	pullPlainHTTP = false
+
+	cmd := &cobra.Command{
+		Use:          "pull",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runPull,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&pullCRDPath, "crd", "c", defaultCRDPath, "Output path for the CRD YAML file (empty to skip)")
+	cmd.Flags().StringVarP(&pullSchemaPath, "schema", "s", defaultSchemaPath, "Output path for the JSON Schema file (empty to skip)")
+	cmd.Flags().BoolVar(&pullPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS")
+
+	return cmd
+}
+
+func TestPullCommand(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	ref := "oci://" + registry.Host() + "/schemas/basic:1.0.0"
+	pushBasic(t, ref)
+	testdata, err := filepath.Abs(filepath.Join("..", "testdata", "build", "basic"))
+	require.NoError(t, err)
+
+	t.Chdir(t.TempDir())
+	cmd := newPullCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{ref, "--plain-http", "-s", filepath.Join("schemas", "basic.json")})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "✓ Wrote expected_crd.yaml to crd.yaml\n✓ Wrote expected_schema.json to "+filepath.Join("schemas", "basic.json")+"\n", out.String())
+	for pulled, original := range map[string]string{
+		"crd.yaml":                             "expected_crd.yaml",
+		filepath.Join("schemas", "basic.json"): "expected_schema.json",
+	} {
+		expected, err := os.ReadFile(filepath.Join(testdata, original))
+		require.NoError(t, err)
+		actual, err := os.ReadFile(pulled)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual))
+	}
+}
+
+func TestPullCommand_SkipFiles(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	ref := "oci://" + registry.Host() + "/schemas/basic:1.0.0"
+	pushBasic(t, ref, "--crd", "")
+
+	t.Chdir(t.TempDir())
+	cmd := newPullCommand()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{ref, "--plain-http"})
+	require.NoError(t, cmd.Execute())
+	assert.FileExists(t, defaultSchemaPath)
+	assert.NoFileExists(t, defaultCRDPath)
+
+	// Nothing is left to write without the schema
+	cmd = newPullCommand()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{ref, "--plain-http", "-s", ""})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no files to write")
+}
+
+func TestPullCommand_Errors(t *testing.T) {
+	t.Setenv("MIAKA_REGISTRY_USERNAME", "")
+	registry := ocitest.NewRegistry(t)
+
+	cmd := newPullCommand()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"oci://" + registry.Host() + "/schemas/missing:1.0.0", "--plain-http"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to pull")
+	assert.Contains(t, err.Error(), "not found")
+}
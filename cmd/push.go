@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushCRDPath    string
+	pushSchemaPath string
+	pushPlainHTTP  bool
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push oci://<registry>/<repository>:<tag>",
+	Short: "Push the generated CRD and JSON Schema to an OCI registry",
+	Long: `Package the generated CRD and JSON Schema as an OCI artifact and push it to
+a registry, so CI validators and editors across many repositories can fetch
+schemas from one place with 'miaka pull'.
+
+The artifact has the type ` + oci.SchemaArtifactType + `, with one layer per file:
+  ` + oci.CRDMediaType + `        the CRD
+  ` + oci.JSONSchemaMediaType + `   the JSON Schema
+Each layer is annotated with its file name (` + oci.TitleAnnotation + `).
+
+Credentials are read from the ` + oci.UsernameEnv + ` and ` + oci.PasswordEnv + `
+environment variables, or from the Helm and Docker registry configs written by
+'helm registry login' and 'docker login', like Helm reads them. Credential
+helpers (credsStore and credHelpers) are supported, e.g., for ECR, GCR, and ACR.`,
+	Example: `  # Push crd.yaml and values.schema.json
+  miaka push oci://ghcr.io/myorg/schemas/myapp:1.0.0
+
+  # Push only the JSON Schema of a plain build
+  miaka push oci://ghcr.io/myorg/schemas/myapp:1.0.0 --crd ""
+
+  # Push to a local registry without TLS
+  miaka push oci://localhost:5000/myapp:dev --plain-http`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPush,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+
+	pushCmd.Flags().StringVarP(&pushCRDPath, "crd", "c", defaultCRDPath, "Path to the CRD YAML file (empty to skip)")
+	pushCmd.Flags().StringVarP(&pushSchemaPath, "schema", "s", defaultSchemaPath, "Path to the JSON Schema file (empty to skip)")
+	pushCmd.Flags().BoolVar(&pushPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS")
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	ref, err := oci.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+	if ref.Tag == "" {
+		return fmt.Errorf("%s has no tag to push to", ref)
+	}
+
+	var files []oci.File
+	for _, f := range []struct{ path, mediaType string }{
+		{pushCRDPath, oci.CRDMediaType},
+		{pushSchemaPath, oci.JSONSchemaMediaType},
+	} {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.path, err)
+		}
+		files = append(files, oci.File{Name: filepath.Base(f.path), MediaType: f.mediaType, Data: data})
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("nothing to push: --crd and --schema are both empty")
+	}
+
+	client, err := newOCIClient(pushPlainHTTP)
+	if err != nil {
+		return err
+	}
+	digest, err := client.PushArtifact(cmd.Context(), ref, oci.SchemaArtifactType, files)
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "✓ Pushed %s to %s\n", strings.Join(names, ", "), ref)
+	fmt.Fprintf(cmd.OutOrStdout(), "  Digest: %s\n", digest)
+	return nil
+}
+
+// newOCIClient creates a registry client with the credentials of the
+// environment, or else of the Helm and Docker registry configs
+func newOCIClient(plainHTTP bool) (*oci.Client, error) {
+	client := oci.NewClient()
+	client.PlainHTTP = plainHTTP
+	if username := os.Getenv(oci.UsernameEnv); username != "" {
+		client.Username, client.Password = username, os.Getenv(oci.PasswordEnv)
+		return client, nil
+	}
+
+	store, err := oci.NewCredentialsStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+	client.Credentials = store
+	return client, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPushCommand creates a fresh push command instance for testing
+func newPushCommand() *cobra.Command {
+	pushCRDPath = defaultCRDPath
+	pushSchemaPath = defaultSchemaPath
+	pushPlainHTTP = false
+
+	cmd := &cobra.Command{
+		Use:          "push",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runPush,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&pushCRDPath, "crd", "c", defaultCRDPath, "Path to the CRD YAML file (empty to skip)")
+	cmd.Flags().StringVarP(&pushSchemaPath, "schema", "s", defaultSchemaPath, "Path to the JSON Schema file (empty to skip)")
+	cmd.Flags().BoolVar(&pushPlainHTTP, "plain-http", false, "Use HTTP instead of HTTPS")
+
+	return cmd
+}
+
+// pushBasic pushes the basic testdata schemas to a registry, returning the push output
+func pushBasic(t *testing.T, ref string, extraArgs ...string) string {
+	t.Helper()
+	t.Setenv("MIAKA_REGISTRY_USERNAME", "")
+	testdata := filepath.Join("..", "testdata", "build", "basic")
+
+	cmd := newPushCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(append([]string{
+		ref,
+		"--plain-http",
+		"-c", filepath.Join(testdata, "expected_crd.yaml"),
+		"-s", filepath.Join(testdata, "expected_schema.json"),
+	}, extraArgs...))
+	require.NoError(t, cmd.Execute())
+	return out.String()
+}
+
+func TestPushCommand(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	ref := "oci://" + registry.Host() + "/schemas/basic:1.0.0"
+
+	out := pushBasic(t, ref)
+	assert.Contains(t, out, "✓ Pushed expected_crd.yaml, expected_schema.json to "+ref+"\n")
+	assert.Regexp(t, `Digest: sha256:[0-9a-f]{64}\n`, out)
+	// The empty config, the CRD, and the schema
+	assert.Equal(t, 3, registry.Uploads())
+
+	out = pushBasic(t, ref, "--crd", "")
+	assert.Contains(t, out, "✓ Pushed expected_schema.json to "+ref+"\n")
+}
+
+func TestPushCommand_Errors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "not OCI", args: []string{"ghcr.io/myapp:1.0.0"}, err: "is not an OCI reference"},
+		{name: "no tag", args: []string{"oci://" + registry.Host() + "/myapp@sha256:abc"}, err: "has no tag to push to"},
+		{name: "missing file", args: []string{"oci://" + registry.Host() + "/myapp:1.0.0", "-c", "missing.yaml"}, err: "failed to read missing.yaml"},
+		{name: "nothing to push", args: []string{"oci://" + registry.Host() + "/myapp:1.0.0", "-c", "", "-s", ""}, err: "nothing to push"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MIAKA_REGISTRY_USERNAME", "")
+			cmd := newPushCommand()
+			cmd.SetOut(new(bytes.Buffer))
+			cmd.SetErr(new(bytes.Buffer))
+			cmd.SetArgs(append(tt.args, "--plain-http"))
+			err := cmd.Execute()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -14,6 +18,18 @@ var (
 	date    = "unknown"
 )
 
+// Verbosity of the commands that log their progress (build, init, validate)
+var (
+	logQuiet   bool
+	logVerbose bool
+)
+
+// commandTimeout aborts any command after it has run this long (0 for no timeout)
+var commandTimeout time.Duration
+
+// cancelTimeout stops the timer of --timeout once the command is done
+var cancelTimeout context.CancelFunc = func() {}
+
 var rootCmd = &cobra.Command{
 	Use:   "miaka",
 	Short: "Generate Go types and CRDs from KRM-compliant YAML",
@@ -25,24 +41,33 @@ It provides two main workflows:
 
 For more information about golden charts and the two-step rendering process,
 see the documentation at https://github.com/crenshaw-dev/miaka`,
+	PersistentPreRun: applyTimeout,
 }
 
-// Execute runs the root command
+// Execute runs the root command. Ctrl-C (or SIGTERM) cancels its context, so
+// commands abort between steps; a second Ctrl-C exits right away.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := rootCmd.ExecuteContext(ctx)
+	cancelTimeout()
+	stop()
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print version information",
-	Run: func(_ *cobra.Command, _ []string) {
-		fmt.Printf("miaka version %s\n", version)
-		fmt.Printf("  commit: %s\n", commit)
-		fmt.Printf("  built:  %s\n", date)
-	},
+// applyTimeout limits the context of the command to --timeout, if set
+func applyTimeout(cmd *cobra.Command, _ []string) {
+	if commandTimeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeoutCause(cmd.Context(), commandTimeout, fmt.Errorf("timed out after %s (--timeout)", commandTimeout))
+	cmd.SetContext(ctx)
+	cancelTimeout = cancel
 }
 
 func init() {
@@ -50,5 +75,9 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(validateCmd)
-	rootCmd.AddCommand(versionCmd)
+
+	rootCmd.PersistentFlags().BoolVarP(&logQuiet, "quiet", "q", false, "Only print warnings and errors")
+	rootCmd.PersistentFlags().BoolVarP(&logVerbose, "verbose", "v", false, "Also print the details of each step")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Abort the command after this long (e.g., 5m; 0 for no timeout)")
 }
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/scaffold"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+)
+
+// apimachineryModule is the only dependency of the scaffolded package
+const apimachineryModule = "k8s.io/apimachinery"
+
+var (
+	scaffoldModulePath string
+	scaffoldOutputDir  string
+	scaffoldWrapSpec   bool
+	scaffoldInferTypes bool
+)
+
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold [example.values.yaml]",
+	Short: "Generate a ready-to-compile Go API package for a controller",
+	Long: `Generate a Go module with an API package for the types of the example
+values, ready to compile and to use in a controller:
+
+  go.mod, go.sum                          the module, depending only on k8s.io/apimachinery
+  api/<version>/doc.go                    the package with the +groupName marker
+  api/<version>/types.go                  the Go types (as 'miaka build -t' writes them)
+  api/<version>/groupversion_info.go      the list type, GroupVersion, and AddToScheme
+  api/<version>/zz_generated.deepcopy.go  the DeepCopy functions
+
+If the output directory already has a go.mod, the package is added to that
+module, and go.mod and go.sum are left alone. Otherwise, --module sets the path
+of the new module. Run scaffold again after changing the example values to
+update the package.`,
+	Example: `  # Create a module for the types of example.values.yaml
+  miaka scaffold --module github.com/example/app-operator -o app-operator
+
+  # Add the API package to the module in the current directory, with spec and status
+  miaka scaffold --wrap-spec`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScaffold,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scaffoldCmd)
+
+	scaffoldCmd.Flags().StringVarP(&scaffoldModulePath, "module", "m", "", "Go module path (required unless the output directory has a go.mod)")
+	scaffoldCmd.Flags().StringVarP(&scaffoldOutputDir, "output-dir", "o", ".", "Root directory of the module")
+	scaffoldCmd.Flags().BoolVar(&scaffoldWrapSpec, "wrap-spec", false, "Nest the values under spec, with an empty status (see 'miaka build --wrap-spec')")
+	scaffoldCmd.Flags().BoolVar(&scaffoldInferTypes, "infer-semantic-types", false, "Map values like 500m, 512Mi, 72h, and 25% to Kubernetes types")
+}
+
+func runScaffold(cmd *cobra.Command, args []string) error {
+	inputFile := defaultExampleValuesFile
+	if len(args) > 0 {
+		inputFile = args[0]
+	}
+
+	modulePath, goMod, err := scaffoldModule()
+	if err != nil {
+		return err
+	}
+
+	p := parsing.NewParserWithOptions(parsing.Options{InferSemanticTypes: scaffoldInferTypes})
+	s, err := p.ParseFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if s.APIVersion == "" && s.Kind == "" {
+		return fmt.Errorf("%s has no apiVersion or kind (add them with 'miaka init')", inputFile)
+	}
+	s.WrapSpec = scaffoldWrapSpec
+
+	files, err := scaffold.Generate(*s, modulePath)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	for _, file := range files {
+		if goMod != nil && (file.Name == "go.mod" || file.Name == "go.sum") {
+			continue
+		}
+		filePath := filepath.Join(scaffoldOutputDir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(filePath, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filePath, err)
+		}
+		fmt.Fprintf(out, "✓ %s\n", filePath)
+	}
+
+	fmt.Fprintf(out, "\nImport the types as %s\n", path.Join(modulePath, scaffold.PackageDir(*s)))
+	if goMod != nil && !requiresModule(goMod, apimachineryModule) {
+		fmt.Fprintf(out, "Run 'go mod tidy' to add %s to go.mod\n", apimachineryModule)
+	}
+	return nil
+}
+
+// scaffoldModule returns the path of the module to scaffold, and the go.mod of
+// the output directory, or nil if it has none
+func scaffoldModule() (string, *modfile.File, error) {
+	goModPath := filepath.Join(scaffoldOutputDir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if os.IsNotExist(err) {
+		if scaffoldModulePath == "" {
+			return "", nil, fmt.Errorf("%s has no go.mod (set the path of the new module with --module)", scaffoldOutputDir)
+		}
+		return scaffoldModulePath, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	goMod, err := modfile.ParseLax(goModPath, data, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", goModPath, err)
+	}
+	if goMod.Module == nil {
+		return "", nil, fmt.Errorf("%s has no module path", goModPath)
+	}
+	modulePath := goMod.Module.Mod.Path
+	if scaffoldModulePath != "" && scaffoldModulePath != modulePath {
+		return "", nil, fmt.Errorf("--module %s doesn't match the existing module %s", scaffoldModulePath, modulePath)
+	}
+	return modulePath, goMod, nil
+}
+
+// requiresModule reports whether a go.mod requires a module
+func requiresModule(goMod *modfile.File, modulePath string) bool {
+	for _, require := range goMod.Require {
+		if require.Mod.Path == modulePath {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScaffoldCommand creates a fresh scaffold command instance for testing
+func newScaffoldCommand() *cobra.Command {
+	scaffoldModulePath = ""
+	scaffoldOutputDir = "."
+	scaffoldWrapSpec = false
+	scaffoldInferTypes = false
+
+	cmd := &cobra.Command{
+		Use:          "scaffold",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runScaffold,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&scaffoldModulePath, "module", "m", "", "Go module path")
+	cmd.Flags().StringVarP(&scaffoldOutputDir, "output-dir", "o", ".", "Root directory of the module")
+	cmd.Flags().BoolVar(&scaffoldWrapSpec, "wrap-spec", false, "Nest the values under spec")
+	cmd.Flags().BoolVar(&scaffoldInferTypes, "infer-semantic-types", false, "Map values to Kubernetes types")
+	return cmd
+}
+
+// runScaffoldCommand runs scaffold with the given arguments
+func runScaffoldCommand(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := newScaffoldCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestScaffoldCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	moduleDir := filepath.Join(dir, "operator")
+
+	out, err := runScaffoldCommand(input, "--module", "example.com/operator", "-o", moduleDir, "--wrap-spec")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Import the types as example.com/operator/api/v1")
+
+	for _, name := range []string{"go.mod", "go.sum", "api/v1/doc.go", "api/v1/types.go", "api/v1/groupversion_info.go", "api/v1/zz_generated.deepcopy.go"} {
+		assert.FileExists(t, filepath.Join(moduleDir, filepath.FromSlash(name)))
+	}
+	types, err := os.ReadFile(filepath.Join(moduleDir, "api", "v1", "types.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(types), "Spec ExampleSpec")
+}
+
+func TestScaffoldCommand_ExistingModule(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	goMod := []byte("module example.com/existing\n\ngo 1.25\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), goMod, 0644))
+
+	out, err := runScaffoldCommand(input, "-o", dir)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Import the types as example.com/existing/api/v1")
+	assert.Contains(t, out, "Run 'go mod tidy' to add k8s.io/apimachinery to go.mod")
+	assert.FileExists(t, filepath.Join(dir, "api", "v1", "types.go"))
+	assert.NoFileExists(t, filepath.Join(dir, "go.sum"))
+
+	// The existing go.mod is kept
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, goMod, content)
+
+	_, err = runScaffoldCommand(input, "-o", dir, "--module", "example.com/other")
+	assert.ErrorContains(t, err, "--module example.com/other doesn't match the existing module example.com/existing")
+}
+
+func TestScaffoldCommand_Errors(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	plain := filepath.Join(dir, "values.yaml")
+	require.NoError(t, os.WriteFile(plain, []byte("replicas: 1\n"), 0644))
+
+	_, err := runScaffoldCommand(input, "-o", filepath.Join(dir, "operator"))
+	assert.ErrorContains(t, err, "has no go.mod (set the path of the new module with --module)")
+
+	_, err = runScaffoldCommand(plain, "-o", filepath.Join(dir, "operator"), "--module", "example.com/operator")
+	assert.ErrorContains(t, err, "has no apiVersion or kind")
+
+	_, err = runScaffoldCommand(input, "-o", filepath.Join(dir, "operator"), "--module", "not a module")
+	assert.ErrorContains(t, err, "invalid module path")
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/crenshaw-dev/miaka/pkg/scan"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var scanOutput string
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [dir]",
+	Short: "Build and validate every chart and example values file in a repository",
+	Long: `Find every chart (a directory with Chart.yaml) and miaka project (a
+directory with example.values.yaml) under a directory, check each, and print a
+summary table with the status of each one. Hidden directories are skipped.
+
+For each directory with example.values.yaml, scan builds it in memory (as a
+plain build if it has no apiVersion or kind), failing on the problems 'miaka
+build' fails on, including breaking changes to the existing crd.yaml. It then
+checks that crd.yaml and values.schema.json were built from the current
+example values, like 'miaka verify'. For each chart with a values.schema.json,
+scan validates its values.yaml against it.
+
+Nothing is written. Projects are checked in parallel, and scan fails if any
+of them fails, so platform teams can check all their charts with one command
+in CI. Charts without example values or a values.schema.json are listed as
+skipped.`,
+	Example: `  # Check every chart in the repository
+  miaka scan
+
+  # Check the charts under charts/, with a result for tools and dashboards
+  miaka scan charts/ -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScan,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", outputText, "Output format: text or json")
+	completeFlagValues(scanCmd, "output", outputText, outputJSON)
+}
+
+// scanSummary is the result of a scan for --output json
+type scanSummary struct {
+	Projects []scan.Result `json:"projects"`
+	OK       int           `json:"ok"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(scanOutput); err != nil {
+		return err
+	}
+	root := "."
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	projects, err := scan.Find(root)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return fmt.Errorf("no charts (%s) or example values (%s) found in %s", scan.ChartFile, scan.ExampleValuesFile, root)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	summary := scanSummary{Projects: make([]scan.Result, len(projects))}
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i, project := range projects {
+		g.Go(func() error {
+			summary.Projects[i] = scan.Check(ctx, project)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	for _, result := range summary.Projects {
+		switch result.Status {
+		case scan.StatusOK:
+			summary.OK++
+		case scan.StatusFailed:
+			summary.Failed++
+		case scan.StatusSkipped:
+			summary.Skipped++
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if scanOutput == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summary); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+	} else {
+		printScanSummary(out, summary)
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d of %d project(s) failed", summary.Failed, len(projects))
+	}
+	return nil
+}
+
+// printScanSummary prints a table with the status of each project
+func printScanSummary(out io.Writer, summary scanSummary) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tKIND\tSTATUS\tDETAILS")
+	for _, result := range summary.Projects {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Dir, scanKind(result.Project), scanStatus(result.Status), scanDetails(result))
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\n%d project(s): %d ok, %d failed, %d skipped\n", len(summary.Projects), summary.OK, summary.Failed, summary.Skipped)
+}
+
+// scanKind describes what a project has
+func scanKind(project scan.Project) string {
+	switch {
+	case project.Chart && project.Example:
+		return "chart+example"
+	case project.Chart:
+		return "chart"
+	}
+	return "example"
+}
+
+// scanStatus marks a status for the table
+func scanStatus(status scan.Status) string {
+	switch status {
+	case scan.StatusOK:
+		return "✓ ok"
+	case scan.StatusFailed:
+		return "✗ failed"
+	}
+	return "- skipped"
+}
+
+// scanDetails summarizes the checks of a project on one line: the first
+// problem if any failed, or else what passed and what wasn't checked
+func scanDetails(result scan.Result) string {
+	if len(result.Problems) > 0 {
+		details, _, _ := strings.Cut(result.Problems[0], "\n")
+		details = strings.TrimSuffix(details, ":")
+		if more := len(result.Problems) - 1; more > 0 {
+			details += fmt.Sprintf(" (+%d more)", more)
+		}
+		return details
+	}
+	return strings.Join(append(append([]string{}, result.Passed...), result.Notes...), ", ")
+}
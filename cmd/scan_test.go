@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/scan"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScanCommand creates a fresh scan command instance for testing
+func newScanCommand() *cobra.Command {
+	scanOutput = outputText
+
+	cmd := &cobra.Command{
+		Use:          "scan",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runScan,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&scanOutput, "output", "o", outputText, "Output format: text or json")
+	return cmd
+}
+
+// writeScanRepo writes a repository with a valid chart, a chart with invalid
+// values, and a chart without a schema
+func writeScanRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	basic := filepath.Join("..", "testdata", "validate", "valid-basic")
+	schemaJSON, err := os.ReadFile(filepath.Join(basic, "schema.json"))
+	require.NoError(t, err)
+	values, err := os.ReadFile(filepath.Join(basic, "values.yaml"))
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		"charts/good/Chart.yaml":         []byte("name: good\n"),
+		"charts/good/values.schema.json": schemaJSON,
+		"charts/good/values.yaml":        values,
+		"charts/bad/Chart.yaml":          []byte("name: bad\n"),
+		"charts/bad/values.schema.json":  schemaJSON,
+		"charts/bad/values.yaml":         []byte("replicas: 0\nappName: bad\n"),
+		"charts/legacy/Chart.yaml":       []byte("name: legacy\n"),
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, content, 0644))
+	}
+	return root
+}
+
+func TestScanCommand(t *testing.T) {
+	root := writeScanRepo(t)
+
+	cmd := newScanCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{root})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Equal(t, "1 of 3 project(s) failed", err.Error())
+
+	assert.Contains(t, out.String(), "PROJECT        KIND   STATUS     DETAILS\n")
+	assert.Contains(t, out.String(), "charts/bad     chart  ✗ failed   values.yaml:1:1: replicas: minimum: got 0, want 1\n")
+	assert.Contains(t, out.String(), "charts/good    chart  ✓ ok       values.yaml valid\n")
+	assert.Contains(t, out.String(), "charts/legacy  chart  - skipped  no example.values.yaml or values.schema.json\n")
+	assert.Contains(t, out.String(), "\n3 project(s): 1 ok, 1 failed, 1 skipped\n")
+}
+
+func TestScanCommand_JSON(t *testing.T) {
+	root := writeScanRepo(t)
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "charts", "bad")))
+
+	cmd := newScanCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{root, "-o", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var summary scanSummary
+	require.NoError(t, json.Unmarshal(out.Bytes(), &summary))
+	assert.Equal(t, 1, summary.OK)
+	assert.Equal(t, 0, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	require.Len(t, summary.Projects, 2)
+	assert.Equal(t, filepath.Join("charts", "good"), summary.Projects[0].Dir)
+	assert.Equal(t, scan.StatusOK, summary.Projects[0].Status)
+	assert.Equal(t, scan.StatusSkipped, summary.Projects[1].Status)
+}
+
+func TestScanCommand_Errors(t *testing.T) {
+	cmd := newScanCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{t.TempDir()})
+	assert.ErrorContains(t, cmd.Execute(), "no charts (Chart.yaml) or example values (example.values.yaml) found in")
+
+	cmd = newScanCommand()
+	cmd.SetArgs([]string{t.TempDir(), "-o", "xml"})
+	assert.ErrorContains(t, cmd.Execute(), `invalid output format "xml" (must be text or json)`)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/score"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scoreCRDPath       string
+	scoreOutput        string
+	scoreMaxComplexity int
+	scoreMaxDepth      int
+	scoreMaxUntyped    int
+	scoreMinCoverage   float64
+)
+
+var scoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Score the size and complexity of a values schema",
+	Long: `Compute a complexity score for the schema in a generated CRD, with a
+breakdown per top-level field.
+
+The score measures:
+  - fields: the number of fields
+  - depth: the deepest level of nesting
+  - untyped: escape hatches that accept anything (fields without a type,
+    objects without properties, and x-kubernetes-preserve-unknown-fields)
+  - coverage: the percentage of string and number fields with at least one
+    validation (enum, pattern, format, bounds, CEL rule, etc.)
+
+The complexity score is fields + 2 × depth + 10 × untyped.
+
+Set thresholds to fail in CI when a schema gets too complex or its
+validation coverage drops.`,
+	Example: `  # Score crd.yaml
+  miaka score
+
+  # Fail if the schema is too complex or under-validated
+  miaka score --max-complexity 200 --max-untyped 0 --min-coverage 80
+
+  # Machine-readable output for dashboards
+  miaka score -c crds/my-crd.yaml -o json`,
+	Args: cobra.NoArgs,
+	RunE: runScore,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(scoreCmd)
+
+	scoreCmd.Flags().StringVarP(&scoreCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	scoreCmd.Flags().StringVarP(&scoreOutput, "output", "o", "text", "Output format: text or json")
+	scoreCmd.Flags().IntVar(&scoreMaxComplexity, "max-complexity", -1, "Fail if the complexity score is higher (-1 for no limit)")
+	scoreCmd.Flags().IntVar(&scoreMaxDepth, "max-depth", -1, "Fail if fields are nested deeper (-1 for no limit)")
+	scoreCmd.Flags().IntVar(&scoreMaxUntyped, "max-untyped", -1, "Fail if there are more untyped fields (-1 for no limit)")
+	scoreCmd.Flags().Float64Var(&scoreMinCoverage, "min-coverage", 0, "Fail if the validation coverage percentage is lower")
+}
+
+func runScore(cmd *cobra.Command, _ []string) error {
+	if err := checkOutputFormat(scoreOutput); err != nil {
+		return err
+	}
+
+	props, err := validation.LoadCRDSchema(scoreCRDPath)
+	if err != nil {
+		return fmt.Errorf("failed to load CRD: %w", err)
+	}
+	report := score.Analyze(props)
+
+	out := cmd.OutOrStdout()
+	if scoreOutput == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	} else {
+		printScore(out, report)
+	}
+
+	failures := scoreFailures(report)
+	if len(failures) == 0 {
+		return nil
+	}
+	// Keep JSON output parseable; the error explains the failures
+	if scoreOutput == "text" {
+		fmt.Fprintln(out)
+		for _, failure := range failures {
+			fmt.Fprintf(out, "✗ %s\n", failure)
+		}
+	}
+	return fmt.Errorf("%d score threshold(s) not met", len(failures))
+}
+
+// scoreFailures lists the thresholds the report doesn't meet
+func scoreFailures(report *score.Report) []string {
+	var failures []string
+	if scoreMaxComplexity >= 0 && report.Complexity > scoreMaxComplexity {
+		failures = append(failures, fmt.Sprintf("complexity %d is higher than --max-complexity=%d", report.Complexity, scoreMaxComplexity))
+	}
+	if scoreMaxDepth >= 0 && report.MaxDepth > scoreMaxDepth {
+		failures = append(failures, fmt.Sprintf("depth %d is higher than --max-depth=%d", report.MaxDepth, scoreMaxDepth))
+	}
+	if scoreMaxUntyped >= 0 && report.Untyped > scoreMaxUntyped {
+		failures = append(failures, fmt.Sprintf("%d untyped fields is more than --max-untyped=%d", report.Untyped, scoreMaxUntyped))
+	}
+	if report.Coverage < scoreMinCoverage {
+		failures = append(failures, fmt.Sprintf("validation coverage %.0f%% is lower than --min-coverage=%g", report.Coverage, scoreMinCoverage))
+	}
+	return failures
+}
+
+// printScore prints a human-readable report
+func printScore(out io.Writer, report *score.Report) {
+	fmt.Fprintf(out, "Complexity score: %d\n", report.Complexity)
+	fmt.Fprintf(out, "  Fields:              %d\n", report.Fields)
+	fmt.Fprintf(out, "  Max depth:           %d\n", report.MaxDepth)
+	fmt.Fprintf(out, "  Untyped fields:      %d\n", report.Untyped)
+	fmt.Fprintf(out, "  Validation coverage: %.0f%% (%d/%d)\n", report.Coverage, report.Validated, report.Validatable)
+
+	if len(report.Sections) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SECTION\tSCORE\tFIELDS\tDEPTH\tUNTYPED\tCOVERAGE")
+		for _, section := range report.Sections {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.0f%%\n", section.Name, section.Complexity,
+				section.Fields, section.MaxDepth, section.Untyped, section.Coverage)
+		}
+		w.Flush()
+	}
+
+	if len(report.UntypedPaths) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Untyped fields:")
+		for _, path := range report.UntypedPaths {
+			fmt.Fprintf(out, "  - %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/score"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScoreCommand creates a fresh score command instance for testing
+func newScoreCommand() *cobra.Command {
+	scoreCRDPath = defaultCRDPath
+	scoreOutput = "text"
+	scoreMaxComplexity = -1
+	scoreMaxDepth = -1
+	scoreMaxUntyped = -1
+	scoreMinCoverage = 0
+
+	cmd := &cobra.Command{
+		Use:          "score",
+		Args:         cobra.NoArgs,
+		RunE:         runScore,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&scoreCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
+	cmd.Flags().StringVarP(&scoreOutput, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().IntVar(&scoreMaxComplexity, "max-complexity", -1, "Maximum complexity score")
+	cmd.Flags().IntVar(&scoreMaxDepth, "max-depth", -1, "Maximum depth")
+	cmd.Flags().IntVar(&scoreMaxUntyped, "max-untyped", -1, "Maximum untyped fields")
+	cmd.Flags().Float64Var(&scoreMinCoverage, "min-coverage", 0, "Minimum validation coverage")
+
+	return cmd
+}
+
+func TestScoreCommand(t *testing.T) {
+	cmd := newScoreCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml")})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, out.String(), "Complexity score: 13\n")
+	assert.Contains(t, out.String(), "Validation coverage: 17% (1/6)\n")
+	assert.Contains(t, out.String(), "SECTION   SCORE  FIELDS  DEPTH  UNTYPED  COVERAGE\n")
+	assert.Contains(t, out.String(), "service   7      3       2      0        0%\n")
+}
+
+func TestScoreCommand_JSON(t *testing.T) {
+	cmd := newScoreCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"), "-o", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var report score.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 9, report.Fields)
+	assert.Len(t, report.Sections, 5)
+}
+
+func TestScoreCommand_Thresholds(t *testing.T) {
+	cmd := newScoreCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{
+		"-c", filepath.Join("..", "testdata", "build", "basic", "expected_crd.yaml"),
+		"--max-complexity", "10", "--max-depth", "2", "--min-coverage", "50",
+	})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Equal(t, "2 score threshold(s) not met", err.Error())
+	assert.Contains(t, out.String(), "✗ complexity 13 is higher than --max-complexity=10\n")
+	assert.Contains(t, out.String(), "✗ validation coverage 17% is lower than --min-coverage=50\n")
+	assert.NotContains(t, out.String(), "--max-depth")
+}
+
+func TestScoreCommand_Errors(t *testing.T) {
+	cmd := newScoreCommand()
+	cmd.SetArgs([]string{"-c", filepath.Join(t.TempDir(), "missing.yaml")})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load CRD")
+
+	cmd = newScoreCommand()
+	cmd.SetArgs([]string{"-o", "yaml"})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid output format "yaml" (must be text or json)`)
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/anonymize"
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/crenshaw-dev/miaka/pkg/webhook"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	serveAddr        string
+	serveTLSCertFile string
+	serveTLSKeyFile  string
+	serveCRDPaths    []string
+	serveSchemaPaths []string
+	serveMetricsAddr string
+	serveAuditLog    string
+	serveExamples    []string
+	serveReload      time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run miaka as a server",
+	Long:  `Run miaka as a long-lived server, e.g., to validate resources in a cluster.`,
+}
+
+var serveWebhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Serve a validating admission webhook for generated CRDs",
+	Long: `Run a ValidatingAdmissionWebhook server that validates custom resources
+against generated CRDs, so charts that publish a CRD get runtime validation
+without writing an operator.
+
+Resources are rejected when they don't match the CRD schema for their version,
+or when they contain fields the CRD doesn't declare. The API server silently
+prunes undeclared fields, which hides typos; the webhook rejects them instead.
+A JSON Schema (--schema) can add rules that the CRD can't express.
+
+Endpoints:
+  /validate  AdmissionReview (admission.k8s.io/v1) requests
+  /healthz   liveness probe
+  /readyz    readiness probe
+
+Kubernetes only calls webhooks over HTTPS, so --tls-cert-file and
+--tls-private-key-file are required unless TLS is terminated in front of the
+server. Deletes and kinds without a CRD are always allowed.
+
+CRD and JSON Schema files are checked for changes every --reload-interval
+(including files of mounted ConfigMaps) and reloaded without a restart. If a
+reload fails, the previous schemas are still used and /readyz fails until the
+files load again. Only local files are supported; pull schemas published to
+an OCI registry to files first, e.g., with 'miaka pull' in an init container.
+
+With --metrics-addr, Prometheus metrics are served on a separate plain HTTP
+listener at /metrics:
+  miaka_webhook_validations_total            by kind and result (allowed, denied, error)
+  miaka_webhook_violations_total             by kind, field, and rule
+  miaka_webhook_validation_duration_seconds  by kind
+
+With --audit-log, each rejected resource is written as a JSON line with its
+violations. Fields marked +miaka:secret in a kind's example values file
+(--example) are redacted.`,
+	Example: `  # Serve with TLS
+  miaka serve webhook --crd crd.yaml --tls-cert-file tls.crt --tls-private-key-file tls.key
+
+  # Several CRDs, with an extra JSON Schema for one kind (Kind.group=path)
+  miaka serve webhook --crd a.yaml --crd b.yaml --schema MyApp.myapp.io=values.schema.json \
+    --tls-cert-file tls.crt --tls-private-key-file tls.key
+
+  # Plain HTTP for local testing
+  miaka serve webhook --crd crd.yaml --addr localhost:8080
+
+  # Metrics and an audit log of rejected resources, with secrets redacted
+  miaka serve webhook --crd crd.yaml --metrics-addr :9090 --audit-log - \
+    --example MyApp.myapp.io=example.values.yaml \
+    --tls-cert-file tls.crt --tls-private-key-file tls.key`,
+	Args: cobra.NoArgs,
+	RunE: runServeWebhook,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.AddCommand(serveWebhookCmd)
+
+	serveWebhookCmd.Flags().StringVar(&serveAddr, "addr", ":8443", "Address to listen on")
+	serveWebhookCmd.Flags().StringVar(&serveTLSCertFile, "tls-cert-file", "", "Path to the TLS certificate (if empty, serves plain HTTP)")
+	serveWebhookCmd.Flags().StringVar(&serveTLSKeyFile, "tls-private-key-file", "", "Path to the TLS private key")
+	serveWebhookCmd.Flags().StringArrayVarP(&serveCRDPaths, "crd", "c", nil, "Path to a CRD YAML file (repeatable, required)")
+	serveWebhookCmd.Flags().StringArrayVarP(&serveSchemaPaths, "schema", "s", nil, "Additional JSON Schema for a kind as Kind.group=path (repeatable)")
+	serveWebhookCmd.Flags().StringVar(&serveMetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on (if empty, metrics are disabled)")
+	serveWebhookCmd.Flags().StringVar(&serveAuditLog, "audit-log", "", "File to append rejected resources to as JSON lines (- for stdout)")
+	serveWebhookCmd.Flags().DurationVar(&serveReload, "reload-interval", 10*time.Second, "How often to check the CRD and JSON Schema files for changes (0 to disable)")
+	serveWebhookCmd.Flags().StringArrayVarP(&serveExamples, "example", "e", nil, "Example values file for a kind as Kind.group=path, whose +miaka:secret fields are redacted in the audit log (repeatable)")
+}
+
+func runServeWebhook(cmd *cobra.Command, _ []string) error {
+	if (serveTLSCertFile == "") != (serveTLSKeyFile == "") {
+		return fmt.Errorf("--tls-cert-file and --tls-private-key-file must be set together")
+	}
+
+	reloader, err := webhook.NewReloader(webhookSourcePaths(serveCRDPaths, serveSchemaPaths), func() (*webhook.Validator, error) {
+		return loadWebhookValidator(serveCRDPaths, serveSchemaPaths)
+	})
+	if err != nil {
+		return err
+	}
+
+	auditLog, closeAuditLog, err := openAuditLog(serveAuditLog, serveExamples, reloader.Validator())
+	if err != nil {
+		return err
+	}
+	defer closeAuditLog()
+
+	opts := webhook.HandlerOptions{AuditLog: auditLog}
+	if serveMetricsAddr != "" {
+		opts.Metrics = webhook.NewMetrics()
+	}
+
+	// The webhook server comes first; metrics are served separately, over plain HTTP
+	servers := []*http.Server{{
+		Addr:              serveAddr,
+		Handler:           webhook.NewReloadingHandler(reloader, opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}}
+	if opts.Metrics != nil {
+		servers = append(servers, newMetricsServer(serveMetricsAddr, opts.Metrics.Handler()))
+	}
+
+	ctx := commandContext(cmd)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, len(servers))
+	go func() {
+		if serveTLSCertFile != "" {
+			errCh <- servers[0].ListenAndServeTLS(serveTLSCertFile, serveTLSKeyFile)
+		} else {
+			errCh <- servers[0].ListenAndServe()
+		}
+	}()
+	for _, server := range servers[1:] {
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+	}
+
+	if serveReload > 0 {
+		go reloader.Run(ctx, serveReload, func(err error) {
+			if err != nil {
+				fmt.Printf("⚠️  Failed to reload schemas (still using the previous ones): %v\n", err)
+				return
+			}
+			fmt.Printf("✓ Reloaded schemas for %s\n", formatKinds(reloader.Validator().Kinds()))
+		})
+	}
+
+	for _, gk := range reloader.Validator().Kinds() {
+		fmt.Printf("Validating %s\n", gk)
+	}
+	if serveTLSCertFile == "" {
+		fmt.Println("⚠️  Serving without TLS; Kubernetes only calls webhooks over HTTPS")
+	}
+	fmt.Printf("✓ Listening on %s\n", serveAddr)
+	if serveMetricsAddr != "" {
+		fmt.Printf("✓ Serving metrics on %s%s\n", serveMetricsAddr, metrics.Path)
+	}
+
+	var serveErr error
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr = fmt.Errorf("failed to serve: %w", err)
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil && serveErr == nil {
+			serveErr = fmt.Errorf("failed to shut down: %w", err)
+		}
+	}
+	return serveErr
+}
+
+// newMetricsServer creates a plain HTTP server for metrics on metrics.Path
+func newMetricsServer(addr string, handler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, handler)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// openAuditLog opens the audit log file ("-" for stdout) and loads the secret
+// paths of each Kind.group=path example values file. It returns a nil log if path is empty.
+func openAuditLog(path string, examples []string, validator *webhook.Validator) (*webhook.AuditLog, func(), error) {
+	noop := func() {}
+	if path == "" {
+		if len(examples) > 0 {
+			return nil, noop, fmt.Errorf("--example requires --audit-log")
+		}
+		return nil, noop, nil
+	}
+
+	var out io.Writer = os.Stdout
+	closeLog := noop
+	if path != "-" {
+		// Rejected resources may contain sensitive values that aren't marked secret
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, noop, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		out = file
+		closeLog = func() { _ = file.Close() }
+	}
+
+	auditLog := webhook.NewAuditLog(out)
+	for _, spec := range examples {
+		kind, examplePath, ok := strings.Cut(spec, "=")
+		if !ok || kind == "" || examplePath == "" {
+			closeLog()
+			return nil, noop, fmt.Errorf("invalid --example %q (expected Kind.group=path)", spec)
+		}
+		gk := schema.ParseGroupKind(kind)
+		if !validator.Handles(gk) {
+			closeLog()
+			return nil, noop, fmt.Errorf("invalid --example %q: no CRD for %s", spec, gk)
+		}
+		secretPaths, err := anonymize.FindSecretPathsInFile(examplePath)
+		if err != nil {
+			closeLog()
+			return nil, noop, err
+		}
+		auditLog.SetSecretPaths(gk, secretPaths)
+	}
+	return auditLog, closeLog, nil
+}
+
+// webhookSourcePaths returns the files a webhook validator is loaded from
+func webhookSourcePaths(crdPaths, schemaPaths []string) []string {
+	paths := append([]string{}, crdPaths...)
+	for _, spec := range schemaPaths {
+		// Malformed specs fail when the validator is loaded
+		if _, path, ok := strings.Cut(spec, "="); ok && path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// formatKinds renders kinds as a comma-separated list
+func formatKinds(kinds []schema.GroupKind) string {
+	names := make([]string, 0, len(kinds))
+	for _, gk := range kinds {
+		names = append(names, gk.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// loadWebhookValidator loads CRDs and Kind.group=path JSON Schemas into a validator
+func loadWebhookValidator(crdPaths, schemaPaths []string) (*webhook.Validator, error) {
+	if len(crdPaths) == 0 {
+		return nil, fmt.Errorf("at least one --crd is required")
+	}
+
+	validator := webhook.NewValidator()
+	for _, path := range crdPaths {
+		crd, err := loadCRD(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := validator.AddCRD(crd); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+	}
+
+	for _, spec := range schemaPaths {
+		kind, path, ok := strings.Cut(spec, "=")
+		if !ok || kind == "" || path == "" {
+			return nil, fmt.Errorf("invalid --schema %q (expected Kind.group=path)", spec)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		if err := validator.AddJSONSchema(schema.ParseGroupKind(kind), data); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+	}
+	return validator, nil
+}
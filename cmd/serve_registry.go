@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/crenshaw-dev/miaka/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	registryAddr        string
+	registryDirs        []string
+	registryMetricsAddr string
+)
+
+var serveRegistryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Serve many CRD schemas, keyed by group, kind, and version",
+	Long: `Run a schema registry that hosts the schemas of many CRDs, so tools and
+teams can fetch, validate against, and compare specific schema versions
+without access to a cluster.
+
+CRDs are loaded from the YAML and JSON files under each --dir (searched
+recursively). Files may contain several documents; documents that aren't CRDs
+are skipped. Each version of a CRD is a separate schema.
+
+Endpoints:
+  GET  /schemas                                    list all schemas
+  GET  /schemas/{group}/{kind}                     list the versions of a kind, newest first
+  GET  /schemas/{group}/{kind}/{version}           fetch a schema
+       ?format=openapi (default), jsonschema, or crd
+  POST /schemas/{group}/{kind}/{version}/validate  validate YAML or JSON values
+  GET  /schemas/{group}/{kind}/diff?from=v1&to=v2  compare two versions
+  GET  /healthz                                    liveness probe
+
+With --metrics-addr, Prometheus metrics of the validations are served on a
+separate listener at /metrics.
+
+Validation rejects fields the schema doesn't declare, like the webhook.
+apiVersion and kind may be omitted from the values. Diffs list the changes
+between the JSON Schemas of two versions and whether any of them is breaking,
+like 'miaka upgrade-check'.`,
+	Example: `  # Serve the CRDs in a directory
+  miaka serve registry --dir crds/
+
+  # Validate values against a version
+  curl -X POST --data-binary @values.yaml localhost:8080/schemas/myapp.io/MyApp/v1/validate
+
+  # Compare two versions
+  curl 'localhost:8080/schemas/myapp.io/MyApp/diff?from=v1alpha1&to=v1'`,
+	Args: cobra.NoArgs,
+	RunE: runServeRegistry,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	serveCmd.AddCommand(serveRegistryCmd)
+
+	serveRegistryCmd.Flags().StringVar(&registryAddr, "addr", ":8080", "Address to listen on")
+	serveRegistryCmd.Flags().StringArrayVarP(&registryDirs, "dir", "d", nil, "Directory of CRD files (repeatable, required)")
+	serveRegistryCmd.Flags().StringVar(&registryMetricsAddr, "metrics-addr", "", "Address to serve Prometheus metrics on (if empty, metrics are disabled)")
+}
+
+func runServeRegistry(cmd *cobra.Command, _ []string) error {
+	reg, err := loadRegistry(registryDirs)
+	if err != nil {
+		return err
+	}
+
+	var opts registry.HandlerOptions
+	if registryMetricsAddr != "" {
+		opts.Metrics = metrics.New("registry")
+	}
+	servers := []*http.Server{{
+		Addr:              registryAddr,
+		Handler:           registry.NewHandler(reg, opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}}
+	if opts.Metrics != nil {
+		servers = append(servers, newMetricsServer(registryMetricsAddr, opts.Metrics.Handler()))
+	}
+
+	ctx := commandContext(cmd)
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+	}
+
+	for _, entry := range reg.Entries() {
+		fmt.Printf("Serving %s from %s\n", entry.Key, entry.Source)
+	}
+	fmt.Printf("✓ Listening on %s\n", registryAddr)
+	if registryMetricsAddr != "" {
+		fmt.Printf("✓ Serving metrics on %s%s\n", registryMetricsAddr, metrics.Path)
+	}
+
+	var serveErr error
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr = fmt.Errorf("failed to serve: %w", err)
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil && serveErr == nil {
+			serveErr = fmt.Errorf("failed to shut down: %w", err)
+		}
+	}
+	return serveErr
+}
+
+// loadRegistry loads the CRDs under each directory into a registry
+func loadRegistry(dirs []string) (*registry.Registry, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("at least one --dir is required")
+	}
+
+	reg := registry.New()
+	for _, dir := range dirs {
+		if err := reg.AddDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	if len(reg.Entries()) == 0 {
+		return nil, fmt.Errorf("no CRD schemas found in %v", dirs)
+	}
+	return reg, nil
+}
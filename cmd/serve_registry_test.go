@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	reg, err := loadRegistry([]string{filepath.Join("..", "testdata", "build", "minimal")})
+	require.NoError(t, err)
+
+	entries := reg.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "demo.io/Demo/v1", entries[0].Key.String())
+}
+
+func TestLoadRegistry_Errors(t *testing.T) {
+	emptyDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(emptyDir, "values.yaml"), []byte("a: b\n"), 0644))
+	minimalDir := filepath.Join("..", "testdata", "build", "minimal")
+
+	tests := []struct {
+		name string
+		dirs []string
+		err  string
+	}{
+		{name: "no dirs", err: "at least one --dir is required"},
+		{name: "missing dir", dirs: []string{filepath.Join(emptyDir, "missing")}, err: "failed to read"},
+		{name: "no CRDs", dirs: []string{emptyDir}, err: "no CRD schemas found"},
+		{name: "same dir twice", dirs: []string{minimalDir, minimalDir}, err: "duplicate schema for demo.io/Demo/v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadRegistry(tt.dirs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
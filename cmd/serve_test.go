@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLoadWebhookValidator(t *testing.T) {
+	crdPath := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+	schemaPath := filepath.Join("..", "testdata", "build", "minimal", "expected_schema.json")
+
+	validator, err := loadWebhookValidator([]string{crdPath}, []string{"Demo.demo.io=" + schemaPath})
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupKind{{Group: "demo.io", Kind: "Demo"}}, validator.Kinds())
+
+	messages, err := validator.Validate(schema.GroupKind{Group: "demo.io", Kind: "Demo"},
+		map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, messages)
+}
+
+func TestLoadWebhookValidator_Errors(t *testing.T) {
+	crdPath := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+	invalidSchema := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(invalidSchema, []byte(`{"type": 1}`), 0644))
+
+	tests := []struct {
+		name    string
+		crds    []string
+		schemas []string
+		err     string
+	}{
+		{name: "no CRDs", err: "at least one --crd is required"},
+		{name: "missing CRD", crds: []string{"missing.yaml"}, err: "failed to read CRD file"},
+		{name: "duplicate CRD", crds: []string{crdPath, crdPath}, err: "duplicate CRD"},
+		{name: "malformed schema flag", crds: []string{crdPath}, schemas: []string{"Demo.demo.io"}, err: "expected Kind.group=path"},
+		{name: "schema for unknown kind", crds: []string{crdPath}, schemas: []string{"Other.demo.io=" + invalidSchema}, err: "no CRD for Other.demo.io"},
+		{name: "invalid schema", crds: []string{crdPath}, schemas: []string{"Demo.demo.io=" + invalidSchema}, err: "invalid JSON Schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadWebhookValidator(tt.crds, tt.schemas)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestServeWebhookCommand_TLSFlags(t *testing.T) {
+	serveTLSCertFile, serveTLSKeyFile = "tls.crt", ""
+	defer func() { serveTLSCertFile, serveTLSKeyFile = "", "" }()
+
+	err := runServeWebhook(nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be set together")
+}
+
+func TestOpenAuditLog(t *testing.T) {
+	crdPath := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+	validator, err := loadWebhookValidator([]string{crdPath}, nil)
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(examplePath, []byte("apiVersion: demo.io/v1\nkind: Demo\n# +miaka:secret\nport: 80\n"), 0644))
+
+	auditPath := filepath.Join(tmpDir, "audit.log")
+	auditLog, closeLog, err := openAuditLog(auditPath, []string{"Demo.demo.io=" + examplePath}, validator)
+	require.NoError(t, err)
+	require.NotNil(t, auditLog)
+	closeLog()
+
+	info, err := os.Stat(auditPath)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		// Windows has no Unix permissions to restrict
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	auditLog, closeLog, err = openAuditLog("", nil, validator)
+	require.NoError(t, err)
+	assert.Nil(t, auditLog)
+	closeLog()
+}
+
+func TestOpenAuditLog_Errors(t *testing.T) {
+	crdPath := filepath.Join("..", "testdata", "build", "minimal", "expected_crd.yaml")
+	validator, err := loadWebhookValidator([]string{crdPath}, nil)
+	require.NoError(t, err)
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+
+	tests := []struct {
+		name     string
+		path     string
+		examples []string
+		err      string
+	}{
+		{name: "example without audit log", examples: []string{"Demo.demo.io=example.values.yaml"}, err: "--example requires --audit-log"},
+		{name: "malformed example flag", path: auditPath, examples: []string{"Demo.demo.io"}, err: "expected Kind.group=path"},
+		{name: "example for unknown kind", path: auditPath, examples: []string{"Other.demo.io=example.values.yaml"}, err: "no CRD for Other.demo.io"},
+		{name: "missing example", path: auditPath, examples: []string{"Demo.demo.io=missing.yaml"}, err: "failed to read example values file"},
+		{name: "unwritable audit log", path: filepath.Join(auditPath, "missing", "audit.log"), err: "failed to open audit log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := openAuditLog(tt.path, tt.examples, validator)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestWebhookSourcePaths(t *testing.T) {
+	paths := webhookSourcePaths([]string{"crd.yaml"}, []string{"Demo.demo.io=schema.json", "malformed", "Other.demo.io="})
+	assert.Equal(t, []string{"crd.yaml", "schema.json"}, paths)
+}
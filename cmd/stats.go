@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/stats"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// maxListedPaths is how many undescribed fields the text output lists
+const maxListedPaths = 20
+
+var statsOutput string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [example.values.yaml]",
+	Short: "Print statistics about the schema of an example values file",
+	Long: `Print statistics about the schema of an example values file, as a quick
+quality dashboard:
+  - structs and fields: the number of struct types and fields
+  - nesting depth: the deepest level of nesting
+  - fields of any type: fields typed interface{} or freeform, which accept anything
+  - missing descriptions: fields without a comment describing them
+  - marker coverage: the percentage of string and number fields with at
+    least one validation marker (Enum, Pattern, Minimum, +miaka:format, etc.)
+
+Use --output json to track them over time. Unlike 'miaka score', which measures
+the generated CRD, the statistics come from the example values file, so they
+can be checked before building; they're named apart from the score's metrics,
+which count differently.`,
+	Example: `  # Statistics for example.values.yaml
+  miaka stats
+
+  # Machine-readable output for trend tracking
+  miaka stats charts/myapp/example.values.yaml -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStats,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", outputText, "Output format: text or json")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	if err := checkOutputFormat(statsOutput); err != nil {
+		return err
+	}
+	inputFile := defaultExampleValuesFile
+	if len(args) > 0 {
+		inputFile = args[0]
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", inputFile, err)
+	}
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", inputFile, err)
+	}
+	plain := header.APIVersion == "" && header.Kind == ""
+	s, err := parsing.NewParserWithOptions(parsing.Options{Plain: plain, DuplicateKeys: parsing.DuplicatesLastWins, TemplateStrings: parsing.TemplatesAllow}).Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", inputFile, err)
+	}
+	report := stats.Analyze(s)
+
+	out := cmd.OutOrStdout()
+	if statsOutput == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		return nil
+	}
+	printStats(out, inputFile, report)
+	return nil
+}
+
+// printStats prints a human-readable report
+func printStats(out io.Writer, inputFile string, report *stats.Report) {
+	fmt.Fprintf(out, "Schema statistics for %s\n", inputFile)
+	fmt.Fprintf(out, "  Structs:              %d\n", report.Structs)
+	fmt.Fprintf(out, "  Fields:               %d\n", report.Fields)
+	fmt.Fprintf(out, "  Nesting depth:        %d\n", report.Nesting)
+	fmt.Fprintf(out, "  Fields of any type:   %d\n", report.AnyTyped)
+	fmt.Fprintf(out, "  Missing descriptions: %d\n", report.Undescribed)
+	fmt.Fprintf(out, "  Marker coverage:      %.0f%% (%d/%d)\n", report.MarkerCoverage, report.Marked, report.Markable)
+
+	if len(report.AnyTypedPaths) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Fields of any type:")
+		for _, path := range report.AnyTypedPaths {
+			fmt.Fprintf(out, "  - %s\n", path)
+		}
+	}
+	if len(report.UndescribedPaths) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Fields without a description:")
+		for i, path := range report.UndescribedPaths {
+			if i == maxListedPaths {
+				fmt.Fprintf(out, "  ... and %d more (see --output json)\n", len(report.UndescribedPaths)-maxListedPaths)
+				break
+			}
+			fmt.Fprintf(out, "  - %s\n", path)
+		}
+	}
+}
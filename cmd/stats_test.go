@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/stats"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatsCommand creates a fresh stats command instance for testing
+func newStatsCommand() *cobra.Command {
+	statsOutput = outputText
+
+	cmd := &cobra.Command{
+		Use:          "stats",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runStats,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&statsOutput, "output", "o", outputText, "Output format: text or json")
+
+	return cmd
+}
+
+func TestStatsCommand(t *testing.T) {
+	cmd := newStatsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	input := filepath.Join("..", "testdata", "build", "basic", "input.yaml")
+	cmd.SetArgs([]string{input})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "Schema statistics for "+input+`
+  Structs:              3
+  Fields:               9
+  Nesting depth:        2
+  Fields of any type:   0
+  Missing descriptions: 0
+  Marker coverage:      17% (1/6)
+`, out.String())
+}
+
+func TestStatsCommand_JSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	// Plain values are detected
+	require.NoError(t, os.WriteFile(defaultExampleValuesFile, []byte("image: nginx\n# +miaka:type: interface{}\nconfig: {}\n"), 0644))
+
+	cmd := newStatsCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-o", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var report stats.Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 2, report.Fields)
+	assert.Equal(t, 1, report.AnyTyped)
+	assert.Equal(t, []string{"image", "config"}, report.UndescribedPaths)
+	assert.Equal(t, float64(0), report.MarkerCoverage)
+}
+
+func TestStatsCommand_Errors(t *testing.T) {
+	cmd := newStatsCommand()
+	cmd.SetArgs([]string{"-o", "xml", filepath.Join("..", "testdata", "build", "basic", "input.yaml")})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid output format "xml" (must be text or json)`)
+
+	cmd = newStatsCommand()
+	cmd.SetArgs([]string{filepath.Join(t.TempDir(), "missing.yaml")})
+	err = cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read")
+}
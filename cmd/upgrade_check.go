@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/crenshaw-dev/miaka/pkg/upgrade"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	upgradeCheckDep    string
+	upgradeCheckTo     string
+	upgradeCheckFrom   string
+	upgradeCheckChart  string
+	upgradeCheckOutput string
+)
+
+var upgradeCheckCmd = &cobra.Command{
+	Use:   "upgrade-check [values-file]",
+	Short: "Check values against a new version of a dependency chart",
+	Long: `Check whether a dependency chart upgrade breaks our values.
+
+The dependency's current JSON Schema (values.schema.json) is read from the
+chart's charts/ directory, either as an unpacked chart or a packaged archive.
+It is compared with the schema of the new version to find removed, renamed,
+and retyped properties. The values configured under the dependency's key
+(its alias, if set in Chart.yaml) are then checked against those changes and
+validated against the new schema.
+
+The new version (--to) can be:
+  - a chart directory or packaged .tgz archive
+  - a values.schema.json file
+  - a version such as 18.x, resolved to a <dep>-<version>.tgz archive in
+    charts/ or the current directory (e.g., after 'helm pull redis --version 18.1.0')
+
+The values file defaults to values.yaml in the chart directory.`,
+	Example: `  # Check a pulled archive of the next major version
+  helm pull bitnami/redis --version 18.1.0
+  miaka upgrade-check --dep redis --to 18.x
+
+  # Compare against an unpacked chart
+  miaka upgrade-check --dep redis --to ../redis
+
+  # Check a specific values file
+  miaka upgrade-check --dep redis --to redis-18.1.0.tgz prod-values.yaml
+
+  # A result object with the schema changes and affected values, for tools that wrap miaka
+  miaka upgrade-check --dep redis --to 18.x -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUpgradeCheck,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCheckCmd)
+
+	upgradeCheckCmd.Flags().StringVar(&upgradeCheckDep, "dep", "", "Name of the dependency chart to check (required)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeCheckTo, "to", "", "New dependency version, chart, archive, or schema file (required)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeCheckFrom, "from", "", "Current dependency chart, archive, or schema file (default: found in charts/)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeCheckChart, "chart", ".", "Path to the parent chart directory")
+	upgradeCheckCmd.Flags().StringVarP(&upgradeCheckOutput, "output", "o", outputText, "Output format: text, or json for a result object with the schema changes and affected values")
+	_ = upgradeCheckCmd.MarkFlagRequired("dep")
+	_ = upgradeCheckCmd.MarkFlagRequired("to")
+
+	upgradeCheckCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagValues(upgradeCheckCmd, "output", outputText, outputJSON)
+}
+
+func runUpgradeCheck(_ *cobra.Command, args []string) error {
+	if err := checkOutputFormat(upgradeCheckOutput); err != nil {
+		return err
+	}
+	out := io.Writer(os.Stdout)
+	if upgradeCheckOutput == outputJSON {
+		// The result replaces the report
+		out = io.Discard
+	}
+	result := commandResult{Command: "upgrade-check"}
+	err := upgradeCheck(out, args, &result)
+	if upgradeCheckOutput == outputJSON {
+		return writeResult(os.Stdout, result, err)
+	}
+	return err
+}
+
+// upgradeCheck checks the upgrade, printing the report to out and recording the
+// schema changes and affected values in result
+func upgradeCheck(out io.Writer, args []string, result *commandResult) error {
+	valuesPath := filepath.Join(upgradeCheckChart, chart.ValuesFile)
+	if len(args) > 0 {
+		valuesPath = args[0]
+	}
+
+	valuesKey, err := dependencyValuesKey(upgradeCheckChart, upgradeCheckDep)
+	if err != nil {
+		return err
+	}
+
+	fromPath := upgradeCheckFrom
+	if fromPath == "" {
+		fromPath, err = chart.FindDependency(upgradeCheckChart, upgradeCheckDep)
+		if err != nil {
+			return err
+		}
+	}
+	toPath, err := resolveDependencyVersion(upgradeCheckChart, upgradeCheckDep, upgradeCheckTo)
+	if err != nil {
+		return err
+	}
+
+	oldSchema, err := loadDependencySchema(fromPath)
+	if err != nil {
+		return fmt.Errorf("failed to load current schema: %w", err)
+	}
+	newSchema, err := loadDependencySchema(toPath)
+	if err != nil {
+		return fmt.Errorf("failed to load new schema: %w", err)
+	}
+
+	valuesBytes, err := os.ReadFile(valuesPath)
+	if err != nil {
+		return fmt.Errorf("failed to read values file: %w", err)
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(valuesBytes, &values); err != nil {
+		return fmt.Errorf("failed to parse values file: %w", err)
+	}
+
+	fmt.Fprintf(out, "Comparing %s schemas: %s -> %s\n", upgradeCheckDep, fromPath, toPath)
+	report, err := upgrade.Check(valuesKey, values, oldSchema, newSchema)
+	if err != nil {
+		return fmt.Errorf("failed to check upgrade: %w", err)
+	}
+
+	printUpgradeReport(out, report)
+	result.Changes = report.Changes
+	for _, finding := range report.Findings {
+		result.Errors = append(result.Errors, resultIssue{Message: finding.Message, File: valuesPath, Path: report.Dependency + "." + finding.Path})
+	}
+
+	if report.HasFindings() {
+		return fmt.Errorf("%d value(s) under %q are affected by the upgrade", len(report.Findings), valuesKey)
+	}
+	return nil
+}
+
+// dependencyValuesKey returns the values key of a dependency declared in Chart.yaml.
+// If there is no Chart.yaml or the dependency isn't declared, the name is used.
+func dependencyValuesKey(chartDir, name string) (string, error) {
+	if _, err := os.Stat(filepath.Join(chartDir, chart.MetadataFile)); os.IsNotExist(err) {
+		return name, nil
+	}
+
+	metadata, err := chart.LoadMetadata(chartDir)
+	if err != nil {
+		return "", err
+	}
+	for _, dep := range metadata.Dependencies {
+		if dep.Name == name || dep.Alias == name {
+			return dep.ValuesKey(), nil
+		}
+	}
+	return name, nil
+}
+
+// resolveDependencyVersion resolves --to to a chart, archive, or schema path
+func resolveDependencyVersion(chartDir, name, to string) (string, error) {
+	if _, err := os.Stat(to); err == nil {
+		return to, nil
+	}
+
+	for _, dir := range []string{filepath.Join(chartDir, "charts"), "."} {
+		if archive, err := chart.FindArchive(dir, name, to); err == nil {
+			return archive, nil
+		}
+	}
+	return "", fmt.Errorf("no %s chart matching %q found in %s or the current directory (pull it first with 'helm pull')",
+		name, to, filepath.Join(chartDir, "charts"))
+}
+
+// loadDependencySchema reads a JSON Schema from a schema file, chart directory, or archive
+func loadDependencySchema(path string) ([]byte, error) {
+	if strings.HasSuffix(path, ".json") {
+		return os.ReadFile(path)
+	}
+	return chart.ReadFile(path, chart.SchemaFile)
+}
+
+// printUpgradeReport prints schema changes and affected values to out
+func printUpgradeReport(out io.Writer, report *upgrade.Report) {
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(out, "✓ No schema changes")
+	} else {
+		fmt.Fprintf(out, "\nSchema changes (%d):\n", len(report.Changes))
+		for _, change := range report.Changes {
+			fmt.Fprintf(out, "  - %s\n", change)
+		}
+	}
+
+	if !report.HasFindings() {
+		fmt.Fprintf(out, "\n✓ Values under %q are compatible with the new version\n", report.Dependency)
+		return
+	}
+
+	fmt.Fprintf(out, "\n✗ Affected values under %q:\n", report.Dependency)
+	for _, finding := range report.Findings {
+		fmt.Fprintf(out, "  - %s.%s: %s\n", report.Dependency, finding.Path, finding.Message)
+	}
+}
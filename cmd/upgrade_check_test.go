@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUpgradeCheckCommand creates a fresh upgrade-check command instance for testing
+func newUpgradeCheckCommand() *cobra.Command {
+	upgradeCheckDep = ""
+	upgradeCheckTo = ""
+	upgradeCheckFrom = ""
+	upgradeCheckChart = "."
+	upgradeCheckOutput = outputText
+
+	cmd := &cobra.Command{
+		Use:          "upgrade-check",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runUpgradeCheck,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&upgradeCheckDep, "dep", "", "Name of the dependency chart")
+	cmd.Flags().StringVar(&upgradeCheckTo, "to", "", "New dependency version")
+	cmd.Flags().StringVar(&upgradeCheckFrom, "from", "", "Current dependency")
+	cmd.Flags().StringVar(&upgradeCheckChart, "chart", ".", "Path to the parent chart directory")
+	cmd.Flags().StringVarP(&upgradeCheckOutput, "output", "o", outputText, "Output format: text or json")
+
+	return cmd
+}
+
+// setupUpgradeChart creates a parent chart with an unpacked redis dependency
+// and a new version of the dependency in a separate directory
+func setupUpgradeChart(t *testing.T, values string) (chartDir, newDepDir string) {
+	t.Helper()
+
+	chartDir = t.TempDir()
+	depDir := filepath.Join(chartDir, "charts", "redis")
+	require.NoError(t, os.MkdirAll(depDir, 0755))
+
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.0.0
+dependencies:
+- name: redis
+  version: 17.x
+  alias: cache
+`
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(values), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "values.schema.json"), []byte(`{
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer"},
+    "port": {"type": "integer"}
+  }
+}`), 0644))
+
+	newDepDir = filepath.Join(t.TempDir(), "redis")
+	require.NoError(t, os.MkdirAll(newDepDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(newDepDir, "values.schema.json"), []byte(`{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer"},
+    "port": {"type": "integer"}
+  }
+}`), 0644))
+
+	return chartDir, newDepDir
+}
+
+func TestUpgradeCheckCommand_Compatible(t *testing.T) {
+	chartDir, newDepDir := setupUpgradeChart(t, "cache:\n  port: 6379\n")
+
+	cmd := newUpgradeCheckCommand()
+	cmd.SetArgs([]string{"--dep", "redis", "--to", newDepDir, "--chart", chartDir})
+
+	require.NoError(t, cmd.Execute())
+}
+
+func TestUpgradeCheckCommand_AffectedValues(t *testing.T) {
+	chartDir, newDepDir := setupUpgradeChart(t, "cache:\n  replicas: 3\n")
+
+	cmd := newUpgradeCheckCommand()
+	cmd.SetArgs([]string{"--dep", "redis", "--to", newDepDir, "--chart", chartDir})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `1 value(s) under "cache"`)
+}
+
+func TestUpgradeCheckCommand_JSONOutput(t *testing.T) {
+	chartDir, newDepDir := setupUpgradeChart(t, "cache:\n  replicas: 3\n")
+
+	cmd := newUpgradeCheckCommand()
+	cmd.SetArgs([]string{"--dep", "redis", "--to", newDepDir, "--chart", chartDir, "-o", "json"})
+
+	stdout, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.Error(t, err)
+
+	var result commandResult
+	require.NoError(t, json.Unmarshal([]byte(stdout), &result), stdout)
+	assert.Equal(t, "upgrade-check", result.Command)
+	assert.Equal(t, statusFailed, result.Status)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, filepath.Join(chartDir, "values.yaml"), result.Errors[0].File)
+	assert.Equal(t, "cache.replicas", result.Errors[0].Path)
+	assert.NotEmpty(t, result.Changes)
+}
+
+func TestUpgradeCheckCommand_SchemaFileAndValuesArg(t *testing.T) {
+	chartDir, newDepDir := setupUpgradeChart(t, "cache:\n  port: 6379\n")
+
+	valuesPath := filepath.Join(t.TempDir(), "prod-values.yaml")
+	require.NoError(t, os.WriteFile(valuesPath, []byte("cache:\n  replicas: 3\n"), 0644))
+
+	cmd := newUpgradeCheckCommand()
+	cmd.SetArgs([]string{
+		"--dep", "redis",
+		"--to", filepath.Join(newDepDir, "values.schema.json"),
+		"--chart", chartDir,
+		valuesPath,
+	})
+
+	require.Error(t, cmd.Execute())
+}
+
+func TestUpgradeCheckCommand_VersionNotFound(t *testing.T) {
+	chartDir, _ := setupUpgradeChart(t, "cache:\n  port: 6379\n")
+
+	cmd := newUpgradeCheckCommand()
+	cmd.SetArgs([]string{"--dep", "redis", "--to", "18.x", "--chart", chartDir})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "helm pull")
+}
@@ -2,19 +2,46 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
 	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/crenshaw-dev/miaka/pkg/logging"
+	"github.com/crenshaw-dev/miaka/pkg/webhook"
+	"github.com/crenshaw-dev/miaka/pkg/workspace"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
 var (
 	validateCRDPath    string
 	validateSchemaPath string
+	validateFormat     string
+	validateValues     []string
+	validateRecursive  bool
+	validateSubchart   string
+	validateArgoCD     bool
+	validateFlux       bool
+	validateConfig     string
 )
 
+// validateLog receives the validation report (on the command's output, or
+// nowhere with --format=json or sarif)
+var validateLog = newLogger(os.Stdout)
+
+// validateResult collects the errors and warnings for --format=json and sarif
+var validateResult commandResult
+
 var validateCmd = &cobra.Command{
-	Use:   "validate [values-file]",
+	Use:   "validate [values-file | --recursive dir | --argocd manifest... | --flux manifest...] [-f values-file]...",
 	Short: "Validate a values file against CRD and JSON Schema",
 	Long: `Validate a Helm values file against the generated CRD and JSON Schema.
 
@@ -26,7 +53,51 @@ The command validates against both:
   - JSON Schema for Helm validation
 
 By default, the command looks for crd.yaml and values.schema.json in the
-current directory.`,
+current directory.
+
+Split configurations can be validated together with -f, which merges the
+files in order the way Helm does: objects are merged, other values are
+replaced, and null deletes a key. Each error names the file that set the
+offending value.
+
+With --recursive, each values file under a directory (YAML files whose names
+contain "values", outside hidden directories) is validated on its own, e.g.
+all the values files of a monorepo. The schemas are loaded and compiled once
+and the files are validated in parallel, so thousands of files take seconds.
+
+Each error is reported with its position in the values file, e.g.
+"values.yaml:27:5: controller.replicas: ...". With --format=github, errors
+are printed as GitHub Actions annotations so they show up on pull requests.
+With --format=json, the result is printed as a JSON object with the status,
+errors, and warnings, each with its file, position, and path. With
+--format=sarif, they're printed as a SARIF log for GitHub code scanning and
+other tools that show findings on pull requests.
+
+To validate a subchart's values in the values of an umbrella chart, --subchart
+names the key of the subchart. The values under the key are validated with
+the global values merged in, the way Helm passes them to the subchart, so the
+other keys of the umbrella chart aren't reported as unknown. The global
+values are only validated against the JSON Schema, since Helm adds them to
+the values of every chart.
+
+With --argocd, the arguments are Argo CD Application manifests (or directories
+of them), and the Helm values each Application sets inline (helm.values or
+helm.valuesObject) are validated against the schemas of its chart. The project
+config (--config, default miaka.yaml) maps charts to schemas: a target's
+"charts" are the chart names (Helm repositories) and paths (Git repositories)
+its schema describes, by default the directory of its input. Applications of
+other charts are skipped. Values files the Applications reference aren't read,
+and apiVersion and kind default to those of the CRD.
+
+With --flux, the arguments are Flux HelmRelease manifests (or directories of
+them), and the values of each HelmRelease are validated the same way. They're
+merged the way Flux merges them: the ConfigMaps and Secrets of valuesFrom in
+order, if the manifests contain them, then spec.values. Errors include the path
+in the manifest, e.g. spec.values.replicas.
+
+If the CRD was built with --strict=warn, fields the schema doesn't declare
+are reported as warnings, which don't fail validation. So are fields marked
++miaka:deprecated in the example values.`,
 	Example: `  # Validate values.yaml against default schemas
   miaka validate values.yaml
 
@@ -34,8 +105,38 @@ current directory.`,
   miaka validate values.yaml --crd output/crd.yaml --schema output/values.schema.json
 
   # Validate user-provided values
-  miaka validate user-values.yaml`,
-	Args: cobra.ExactArgs(1),
+  miaka validate user-values.yaml
+
+  # Validate values merged the way "helm install -f base.yaml -f prod.yaml" merges them
+  miaka validate -f base.yaml -f prod.yaml
+
+  # Validate the redis subchart's values in an umbrella chart's values
+  miaka validate umbrella/values.yaml --subchart redis -c redis/crd.yaml -s redis/values.schema.json
+
+  # Validate every values file under environments/
+  miaka validate --recursive environments/
+
+  # Validate the Helm values of the Argo CD Applications under apps/
+  miaka validate --argocd apps/
+
+  # Validate the values of the Flux HelmReleases and their ConfigMaps under clusters/
+  miaka validate --flux clusters/
+
+  # Annotate pull requests in GitHub Actions
+  miaka validate values.yaml --format=github
+
+  # A result object for tools that wrap miaka
+  miaka validate values.yaml -o json
+
+  # Findings for GitHub code scanning
+  miaka validate values.yaml -o sarif > miaka.sarif`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Applications can be spread across any number of manifests
+		if validateArgoCD || validateFlux {
+			return nil
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
 	RunE: runValidate,
 	// SilenceUsage prevents usage from showing on business logic errors
 	SilenceUsage: true,
@@ -44,43 +145,198 @@ current directory.`,
 func init() {
 	validateCmd.Flags().StringVarP(&validateCRDPath, "crd", "c", defaultCRDPath, "Path to CRD YAML file")
 	validateCmd.Flags().StringVarP(&validateSchemaPath, "schema", "s", defaultSchemaPath, "Path to JSON Schema file")
+	validateCmd.Flags().StringArrayVarP(&validateValues, "values", "f", nil, "Values file to merge in order, after the positional values file (repeatable)")
+	validateCmd.Flags().BoolVarP(&validateRecursive, "recursive", "r", false, "Validate each values file under the directory argument on its own")
+	validateCmd.Flags().BoolVar(&validateArgoCD, "argocd", false, "Validate the Helm values of the Argo CD Applications in the manifest arguments (files or directories) against the schemas of their charts in the project config")
+	validateCmd.Flags().BoolVar(&validateFlux, "flux", false, "Validate the values of the Flux HelmReleases in the manifest arguments (files or directories), with those of their ConfigMaps and Secrets, against the schemas of their charts in the project config")
+	validateCmd.Flags().StringVar(&validateConfig, "config", workspace.DefaultFile, "Project config that maps charts to schemas (with --argocd or --flux)")
+	validateCmd.Flags().StringVar(&validateSubchart, "subchart", "", "Validate the values under this key of umbrella chart values, with the global values, as Helm passes them to the subchart")
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "o", outputText, "Output format: text, github (GitHub Actions annotations), json, or sarif")
+
+	validateCmd.ValidArgsFunction = completeYAMLFiles
+	completeFlagValues(validateCmd, "format", outputText, "github", outputJSON, outputSARIF)
+	completeFlagFiles(validateCmd, "crd", yamlExtensions...)
+	completeFlagFiles(validateCmd, "schema", "json")
+	completeFlagFiles(validateCmd, "values", yamlExtensions...)
+	completeFlagFiles(validateCmd, "config", yamlExtensions...)
 }
 
-func runValidate(_ *cobra.Command, args []string) error {
-	valuesPath := args[0]
+func runValidate(cmd *cobra.Command, args []string) error {
+	if validateFormat != outputText && validateFormat != "github" && validateFormat != outputJSON && validateFormat != outputSARIF {
+		return fmt.Errorf("invalid format %q (must be text, github, json, or sarif)", validateFormat)
+	}
+	out := commandOut(cmd)
+	validateLog = newLogger(out)
+	if validateFormat == outputJSON || validateFormat == outputSARIF {
+		// The result replaces the report
+		validateLog = logging.Discard()
+	}
+	validateResult = commandResult{Command: "validate"}
+	err := validate(args)
+	switch validateFormat {
+	case outputJSON:
+		return writeResult(out, validateResult, err)
+	case outputSARIF:
+		return writeSARIF(out, validateResult, err)
+	}
+	return err
+}
 
-	// Check that all required files exist
-	if _, err := os.Stat(valuesPath); os.IsNotExist(err) {
-		return fmt.Errorf("values file not found: %s", valuesPath)
+// validateTarget is values to validate: one values file, or several merged
+type validateTarget struct {
+	sources validation.Sources
+	values  map[string]interface{}
+	// crdPath and schemaPath are the schemas of the values, if they aren't the
+	// --crd and --schema files (--argocd). An empty crdPath means no CRD.
+	crdPath, schemaPath string
+	// untyped values don't set their apiVersion and kind, which are taken from the CRD
+	untyped bool
+}
+
+// targetSchemas are the schemas that validate targets. crdErr and schemaErr
+// are set if they couldn't be loaded, and crd is nil if there's no CRD.
+type targetSchemas struct {
+	crd        *apiextensionsv1.CustomResourceDefinition
+	crdErr     error
+	schemaJSON []byte
+	schemaErr  error
+}
+
+// targetProblems are the problems with the values of one target. crdErr and
+// schemaErr are set if the values couldn't be validated, and err if their
+// deprecated fields couldn't be found.
+type targetProblems struct {
+	crd, unknown, schema, deprecated []validation.Problem
+	crdErr, schemaErr, err           error
+}
+
+// validate validates the values files, recording the problems in validateResult
+func validate(args []string) error {
+	var targets []validateTarget
+	var err error
+	// Manifests map charts to the schemas of the project config
+	manifests := validateArgoCD || validateFlux
+	switch {
+	case validateFlux:
+		targets, err = fluxTargets(args)
+	case validateArgoCD:
+		targets, err = argoCDTargets(args)
+	case validateRecursive:
+		targets, err = recursiveTargets(args)
+	default:
+		targets, err = mergedTarget(args)
 	}
-	if _, err := os.Stat(validateCRDPath); os.IsNotExist(err) {
-		return fmt.Errorf("CRD file not found: %s", validateCRDPath)
+	if err != nil {
+		return err
 	}
-	if _, err := os.Stat(validateSchemaPath); os.IsNotExist(err) {
-		return fmt.Errorf("JSON Schema file not found: %s", validateSchemaPath)
+
+	// The schemas are loaded once, however many targets they validate
+	var crdPaths, schemaPaths []string
+	schemas := map[[2]string]*targetSchemas{}
+	for i := range targets {
+		if !manifests {
+			targets[i].crdPath, targets[i].schemaPath = validateCRDPath, validateSchemaPath
+		}
+		key := [2]string{targets[i].crdPath, targets[i].schemaPath}
+		if schemas[key] != nil {
+			continue
+		}
+		loaded, err := loadTargetSchemas(key[0], key[1])
+		if err != nil {
+			return err
+		}
+		schemas[key] = loaded
+		if key[0] != "" && !slices.Contains(crdPaths, key[0]) {
+			crdPaths = append(crdPaths, key[0])
+		}
+		if !slices.Contains(schemaPaths, key[1]) {
+			schemaPaths = append(schemaPaths, key[1])
+		}
+	}
+
+	results := make([]targetProblems, len(targets))
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for i, target := range targets {
+		g.Go(func() error {
+			results[i] = validateTargetValues(target, schemas[[2]string{target.crdPath, target.schemaPath}])
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	// Recursive and manifest targets are validated on their own
+	independent := validateRecursive || manifests
+	var problems targetProblems
+	for i, result := range results {
+		if result.err != nil {
+			return result.err
+		}
+		if !independent {
+			problems = result
+			break
+		}
+		// Each file is validated on its own, so its errors are problems with the file
+		file := targets[i].sources[0].File
+		problems.crd = append(problems.crd, result.crd...)
+		problems.crd = appendTargetError(problems.crd, file, result.crdErr)
+		problems.unknown = append(problems.unknown, result.unknown...)
+		problems.schema = append(problems.schema, result.schema...)
+		problems.schema = appendTargetError(problems.schema, file, result.schemaErr)
+		problems.deprecated = append(problems.deprecated, result.deprecated...)
+	}
+	if !manifests {
+		// The --crd and --schema files validate every target
+		loaded := schemas[[2]string{validateCRDPath, validateSchemaPath}]
+		if problems.crdErr == nil {
+			problems.crdErr = loaded.crdErr
+		}
+		if problems.schemaErr == nil {
+			problems.schemaErr = loaded.schemaErr
+		}
 	}
 
 	// Track validation results
 	hasErrors := false
 
-	// Validate against CRD
-	fmt.Printf("Validating against CRD (%s)...\n", validateCRDPath)
-	if err := validation.ValidateAgainstCRD(validateCRDPath, valuesPath); err != nil {
-		fmt.Printf("✗ CRD validation failed: %v\n", err)
-		hasErrors = true
-	} else {
-		fmt.Println("✓ CRD validation passed")
-	}
+	if len(crdPaths) > 0 {
+		validateLog.Infof("Validating against CRD (%s)...", strings.Join(crdPaths, ", "))
+		if !printProblems("CRD", "crd-validation", problems.crd, problems.crdErr) {
+			hasErrors = true
+		}
+		printWarnings("unknown field(s) (the CRD was built with --strict=warn)", "unknown-field", problems.unknown)
 
-	fmt.Println()
+		validateLog.Infof("")
+	}
 
-	// Validate against JSON Schema
-	fmt.Printf("Validating against JSON Schema (%s)...\n", validateSchemaPath)
-	if err := validation.ValidateYAML(valuesPath, validateSchemaPath); err != nil {
-		fmt.Printf("✗ JSON Schema validation failed: %v\n", err)
+	validateLog.Infof("Validating against JSON Schema (%s)...", strings.Join(schemaPaths, ", "))
+	if !printProblems("JSON Schema", "schema-validation", problems.schema, problems.schemaErr) {
 		hasErrors = true
-	} else {
-		fmt.Println("✓ JSON Schema validation passed")
+	}
+	printWarnings("deprecated field(s)", "deprecated-field", problems.deprecated)
+
+	if manifests {
+		kind := "Application source(s)"
+		if validateFlux {
+			kind = "HelmRelease(s)"
+		}
+		validateLog.Infof("")
+		if hasErrors {
+			validateLog.Errorf("✗ %d of %d %s failed validation", failedTargets(results), len(targets), kind)
+		} else {
+			validateLog.Infof("✓ %d %s passed validation", len(targets), kind)
+		}
+	} else if validateRecursive {
+		validateLog.Infof("")
+		failed := map[string]bool{}
+		for _, problem := range append(problems.crd, problems.schema...) {
+			failed[problem.File] = true
+		}
+		if hasErrors {
+			validateLog.Errorf("✗ %d of %d values file(s) failed validation", len(failed), len(targets))
+		} else {
+			validateLog.Infof("✓ %d values file(s) passed validation", len(targets))
+		}
 	}
 
 	if hasErrors {
@@ -89,3 +345,287 @@ func runValidate(_ *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// loadTargetSchemas loads the CRD (unless crdPath is empty) and JSON Schema of
+// targets. Errors reading them are recorded in the result, but missing files are
+// returned.
+func loadTargetSchemas(crdPath, schemaPath string) (*targetSchemas, error) {
+	if crdPath != "" {
+		if _, err := os.Stat(crdPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("CRD file not found: %s", crdPath)
+		}
+	}
+	if _, err := os.Stat(schemaPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("JSON Schema file not found: %s", schemaPath)
+	}
+
+	loaded := &targetSchemas{}
+	if crdPath != "" {
+		loaded.crd, loaded.crdErr = loadCRD(crdPath)
+	}
+	loaded.schemaJSON, loaded.schemaErr = os.ReadFile(schemaPath)
+	if loaded.schemaErr != nil {
+		loaded.schemaErr = fmt.Errorf("failed to read schema file: %w", loaded.schemaErr)
+	}
+	return loaded, nil
+}
+
+// failedTargets counts the targets with errors
+func failedTargets(results []targetProblems) int {
+	failed := 0
+	for _, result := range results {
+		if len(result.crd) > 0 || len(result.schema) > 0 || result.crdErr != nil || result.schemaErr != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// mergedTarget merges the values files the way Helm does, remembering where
+// each value came from
+func mergedTarget(args []string) ([]validateTarget, error) {
+	valuesPaths := append(append([]string{}, args...), validateValues...)
+	if len(valuesPaths) == 0 {
+		return nil, fmt.Errorf("no values file given (pass a values file or -f)")
+	}
+
+	// Check that all required files exist
+	for _, valuesPath := range valuesPaths {
+		if _, err := os.Stat(valuesPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("values file not found: %s", valuesPath)
+		}
+	}
+
+	target := validateTarget{sources: make(validation.Sources, 0, len(valuesPaths)), values: map[string]interface{}{}}
+	for _, valuesPath := range valuesPaths {
+		source, err := readSource(valuesPath)
+		if err != nil {
+			return nil, err
+		}
+		target.sources = append(target.sources, source)
+		target.values = chart.CoalesceValues(target.values, source.Values)
+		validateLog.Debugf("Merged %s", valuesPath)
+	}
+	target, err := subchartTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	return []validateTarget{target}, nil
+}
+
+// subchartTarget narrows a target to the values Helm passes to the subchart of
+// --subchart, if any
+func subchartTarget(target validateTarget) (validateTarget, error) {
+	if validateSubchart == "" {
+		return target, nil
+	}
+	values, ok := chart.SubchartValues(target.values, validateSubchart)
+	if !ok {
+		files := make([]string, 0, len(target.sources))
+		for _, source := range target.sources {
+			files = append(files, source.File)
+		}
+		return validateTarget{}, fmt.Errorf("%s: no values for subchart %s (expected an object under the %s key)", strings.Join(files, ", "), validateSubchart, validateSubchart)
+	}
+	sources := make(validation.Sources, 0, len(target.sources))
+	for _, source := range target.sources {
+		sources = append(sources, source.Subchart(validateSubchart))
+	}
+	// Helm takes the subchart's apiVersion and kind from its own defaults
+	return validateTarget{sources: sources, values: values, untyped: true}, nil
+}
+
+// recursiveTargets finds the values files under the directory argument, each
+// of which is validated on its own
+func recursiveTargets(args []string) ([]validateTarget, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("--recursive needs a directory")
+	}
+	if len(validateValues) > 0 {
+		return nil, fmt.Errorf("--recursive validates each values file on its own, so it can't be combined with -f")
+	}
+	paths, err := findValuesFiles(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no values files found in %s", args[0])
+	}
+	validateLog.Infof("Found %d values file(s) in %s", len(paths), args[0])
+
+	targets := make([]validateTarget, 0, len(paths))
+	for _, path := range paths {
+		source, err := readSource(path)
+		if err != nil {
+			return nil, err
+		}
+		target, err := subchartTarget(validateTarget{sources: validation.Sources{source}, values: source.Values})
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// findValuesFiles returns the YAML files under dir whose names contain "values"
+// (values.yaml, values-prod.yaml, prod.values.yml, ...), skipping hidden
+// directories
+func findValuesFiles(dir string) ([]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var paths []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if (ext == ".yaml" || ext == ".yml") && strings.Contains(strings.ToLower(d.Name()), "values") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// readSource reads and parses a values file
+func readSource(path string) (*validation.Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	return validation.ParseSource(path, data)
+}
+
+// validateTargetValues validates the values of a target against the CRD and
+// JSON Schema, which are only used if they were loaded without an error
+func validateTargetValues(target validateTarget, schemas *targetSchemas) targetProblems {
+	var result targetProblems
+	crdDef, schemaJSON := schemas.crd, schemas.schemaJSON
+	if crdDef != nil && schemas.crdErr == nil {
+		crdValues := target.values
+		if validateSubchart != "" {
+			// Helm adds the global values to every chart, so the CRD doesn't describe them
+			crdValues = maps.Clone(crdValues)
+			delete(crdValues, chart.GlobalKey)
+		}
+		if target.untyped {
+			crdValues = withCRDType(crdValues, crdDef)
+		}
+		result.crd, result.crdErr = validation.CRDProblems(crdValues, crdDef, target.sources)
+		// CRDs built with --strict=warn are open, but unknown fields are still worth a look
+		if result.crdErr == nil && crdDef.Annotations[crd.StrictValidationAnnotation] == string(crd.StrictWarn) {
+			result.unknown = unknownFieldWarnings(crdValues, crdDef, target.sources)
+		}
+	}
+	if schemas.schemaErr == nil {
+		result.schema, result.schemaErr = validation.SchemaProblems(target.values, schemaJSON, target.sources)
+		// Deprecated fields still work, so they don't fail validation
+		if result.schemaErr == nil {
+			result.deprecated, result.err = validation.DeprecatedFields(target.values, schemaJSON, target.sources)
+		}
+	}
+	return result
+}
+
+// withCRDType returns values with the apiVersion and kind of the CRD's storage
+// version, unless they set them
+func withCRDType(values map[string]interface{}, crdDef *apiextensionsv1.CustomResourceDefinition) map[string]interface{} {
+	values = maps.Clone(values)
+	for _, version := range crdDef.Spec.Versions {
+		if _, ok := values["apiVersion"]; !ok && version.Storage {
+			values["apiVersion"] = crdDef.Spec.Group + "/" + version.Name
+		}
+	}
+	if _, ok := values["kind"]; !ok {
+		values["kind"] = crdDef.Spec.Names.Kind
+	}
+	return values
+}
+
+// appendTargetError appends the error of a values file that couldn't be
+// validated to problems as a problem with the file
+func appendTargetError(problems []validation.Problem, file string, err error) []validation.Problem {
+	if err == nil {
+		return problems
+	}
+	return append(problems, validation.Problem{File: file, Message: err.Error()})
+}
+
+// unknownFieldWarnings returns a problem for each field of the values that the
+// schema of their CRD version doesn't declare
+func unknownFieldWarnings(values map[string]interface{}, crdDef *apiextensionsv1.CustomResourceDefinition, sources validation.Sources) []validation.Problem {
+	apiVersion, _ := values["apiVersion"].(string)
+	for _, version := range crdDef.Spec.Versions {
+		if crdDef.Spec.Group+"/"+version.Name != apiVersion || version.Schema == nil {
+			continue
+		}
+		wrapped := crd.WrapsSpec(crdDef)
+		if wrapped {
+			values = crd.WrapValues(values)
+		}
+		var warnings []validation.Problem
+		for _, e := range webhook.UnknownFields(values, version.Schema.OpenAPIV3Schema) {
+			path := validation.FieldPath(e.Field)
+			if wrapped {
+				path = validation.UnwrapPath(path)
+			}
+			warnings = append(warnings, sources.Locate(path, e.ErrorBody()))
+		}
+		return warnings
+	}
+	return nil
+}
+
+// printWarnings prints warnings about a kind of field in the --format output format
+func printWarnings(kind, rule string, warnings []validation.Problem) {
+	if len(warnings) == 0 {
+		return
+	}
+	validateLog.Warnf("⚠️  %d %s:", len(warnings), kind)
+	for _, warning := range warnings {
+		if validateFormat == "github" {
+			validateLog.Warnf("%s", warning.GitHubWarning())
+		} else {
+			validateLog.Warnf("  %s", warning)
+		}
+		validateResult.Warnings = append(validateResult.Warnings, problemIssue(rule, warning))
+	}
+}
+
+// printProblems prints the result of validating against one schema in the
+// --format output format, as problems of rule. Returns true if validation passed.
+func printProblems(schemaName, rule string, problems []validation.Problem, err error) bool {
+	if err != nil {
+		validateLog.Errorf("✗ %s validation failed: %v", schemaName, err)
+		validateResult.Errors = append(validateResult.Errors, resultIssue{Rule: rule, Message: fmt.Sprintf("%s validation failed: %v", schemaName, err)})
+		return false
+	}
+	if len(problems) == 0 {
+		validateLog.Infof("✓ %s validation passed", schemaName)
+		return true
+	}
+
+	validateLog.Errorf("✗ %s validation failed:", schemaName)
+	for _, problem := range problems {
+		if validateFormat == "github" {
+			validateLog.Errorf("%s", problem.GitHubAnnotation())
+		} else {
+			validateLog.Errorf("  %s", problem)
+		}
+		validateResult.Errors = append(validateResult.Errors, problemIssue(rule, problem))
+	}
+	return false
+}
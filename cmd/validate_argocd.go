@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// argoCDGroup is the API group of Argo CD resources
+const argoCDGroup = "argoproj.io/"
+
+// argoCDApplication is the part of an Argo CD Application that sets Helm values
+type argoCDApplication struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Source  *argoCDSource  `yaml:"source"`
+		Sources []argoCDSource `yaml:"sources"`
+	} `yaml:"spec"`
+}
+
+// argoCDSource is a source of an Application: a chart in a Helm repository, or
+// a path in a Git repository
+type argoCDSource struct {
+	Chart string `yaml:"chart"`
+	Path  string `yaml:"path"`
+	Helm  *struct {
+		// Values is a YAML string, and ValuesObject an object that takes its place
+		Values       yaml.Node `yaml:"values"`
+		ValuesObject yaml.Node `yaml:"valuesObject"`
+	} `yaml:"helm"`
+}
+
+// argoCDTargets finds the Helm values of the Argo CD Applications in the
+// manifest arguments (files, or directories of YAML files). Each is validated
+// on its own against the schemas of the project config target of its chart.
+func argoCDTargets(args []string) ([]validateTarget, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--argocd needs Application manifests (files or directories)")
+	}
+	if len(validateValues) > 0 || validateRecursive {
+		return nil, fmt.Errorf("--argocd validates the values of each Application on its own, so it can't be combined with -f or --recursive")
+	}
+	config, err := workspace.Load(filesystem.OS(), validateConfig)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := readManifests(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []validateTarget
+	for _, doc := range docs {
+		docTargets, err := applicationTargets(doc, config)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, docTargets...)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no Application in %s sets Helm values for a chart of %s", strings.Join(args, ", "), validateConfig)
+	}
+	validateLog.Infof("Found %d Application source(s) with Helm values", len(targets))
+	return targets, nil
+}
+
+// findManifests returns the manifest arguments, with the YAML files under the
+// directories among them, skipping hidden directories
+func findManifests(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if d.IsDir() {
+				if path != arg && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// manifestDoc is a document of a manifest file
+type manifestDoc struct {
+	file    string
+	content []byte // The whole file
+	node    *yaml.Node
+}
+
+// readManifests reads the documents of the manifest arguments (files, or
+// directories of YAML files)
+func readManifests(args []string) ([]manifestDoc, error) {
+	paths, err := findManifests(args)
+	if err != nil {
+		return nil, err
+	}
+	var docs []manifestDoc
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var node yaml.Node
+			if err := decoder.Decode(&node); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			docs = append(docs, manifestDoc{file: path, content: content, node: &node})
+		}
+	}
+	return docs, nil
+}
+
+// applicationTargets returns the Helm values of a manifest document if it's an
+// Application, skipping charts the project config has no target for
+func applicationTargets(doc manifestDoc, config *workspace.Config) ([]validateTarget, error) {
+	var app argoCDApplication
+	if err := doc.node.Decode(&app); err != nil || !strings.HasPrefix(app.APIVersion, argoCDGroup) || app.Kind != "Application" {
+		// Not an Application, or not one Argo CD would accept
+		return nil, nil
+	}
+
+	sources := app.Spec.Sources
+	if app.Spec.Source != nil {
+		sources = append([]argoCDSource{*app.Spec.Source}, sources...)
+	}
+	var targets []validateTarget
+	for _, source := range sources {
+		if source.Helm == nil {
+			continue
+		}
+		values := &source.Helm.ValuesObject
+		if values.Kind == 0 {
+			values = &source.Helm.Values
+		}
+		if values.Kind == 0 {
+			continue
+		}
+		chartName := source.Chart
+		if chartName == "" {
+			chartName = source.Path
+		}
+		target, ok := config.ForChart(source.Chart, source.Path)
+		if !ok {
+			validateLog.Warnf("⚠️  %s:%d:%d: skipping Application %s: no target of %s has the chart %s", doc.file, values.Line, values.Column, app.Metadata.Name, validateConfig, chartName)
+			continue
+		}
+
+		valuesSource, err := embeddedValues(doc, values)
+		if err != nil {
+			return nil, err
+		}
+		validateLog.Debugf("Validating Application %s (%s) against %s", app.Metadata.Name, chartName, target.Name)
+		vt, err := chartTarget(validation.Sources{valuesSource}, target)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, vt)
+	}
+	return targets, nil
+}
+
+// chartTarget returns the target that validates values from manifests against
+// the schemas of a project config target
+func chartTarget(sources validation.Sources, target *workspace.Target) (validateTarget, error) {
+	values := map[string]interface{}{}
+	for _, source := range sources {
+		values = chart.CoalesceValues(values, source.Values)
+	}
+	vt, err := subchartTarget(validateTarget{sources: sources, values: values})
+	if err != nil {
+		return validateTarget{}, err
+	}
+	vt.schemaPath = target.Schema
+	// Plain targets have no CRD
+	if !target.Plain {
+		vt.crdPath = target.CRD
+	}
+	vt.untyped = true
+	return vt, nil
+}
+
+// embeddedValues parses Helm values in a manifest document: a YAML string (e.g.
+// helm.values of an Application) or an object (helm.valuesObject)
+func embeddedValues(doc manifestDoc, values *yaml.Node) (*validation.Source, error) {
+	switch {
+	case values.Kind == yaml.MappingNode:
+		return validation.NodeSource(doc.file, values)
+	case values.Kind == yaml.ScalarNode && values.Tag == "!!str":
+		return validation.ParseEmbeddedSource(doc.file, doc.content, values)
+	}
+	return nil, fmt.Errorf("%s:%d:%d: the Helm values must be an object or a YAML string", doc.file, values.Line, values.Column)
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// fluxHelmGroup is the API group of Flux HelmReleases
+const fluxHelmGroup = "helm.toolkit.fluxcd.io/"
+
+// fluxDefaultValuesKey is the key of the values in the ConfigMaps and Secrets
+// of valuesFrom, unless it sets valuesKey
+const fluxDefaultValuesKey = "values.yaml"
+
+// fluxHelmRelease is the part of a Flux HelmRelease that sets Helm values
+type fluxHelmRelease struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   fluxObjectMeta `yaml:"metadata"`
+	Spec       struct {
+		Chart *struct {
+			Spec struct {
+				Chart string `yaml:"chart"`
+			} `yaml:"spec"`
+		} `yaml:"chart"`
+		ChartRef *struct {
+			Name string `yaml:"name"`
+		} `yaml:"chartRef"`
+		Values     yaml.Node   `yaml:"values"`
+		ValuesFrom []yaml.Node `yaml:"valuesFrom"`
+	} `yaml:"spec"`
+}
+
+// fluxObjectMeta identifies a resource
+type fluxObjectMeta struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+// fluxValuesReference is an item of valuesFrom: values in a ConfigMap or Secret
+type fluxValuesReference struct {
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+	ValuesKey  string `yaml:"valuesKey"`
+	TargetPath string `yaml:"targetPath"`
+	Optional   bool   `yaml:"optional"`
+}
+
+// fluxValuesObject is a ConfigMap or Secret of the manifests, whose data
+// valuesFrom can refer to
+type fluxValuesObject struct {
+	doc      manifestDoc
+	data     map[string]*yaml.Node
+	encoded  bool // Secret data is base64-encoded
+	dataPath string
+}
+
+// fluxTargets finds the values of the Flux HelmReleases in the manifest
+// arguments (files, or directories of YAML files), including those of the
+// ConfigMaps and Secrets of valuesFrom that the manifests contain. Each is
+// validated on its own against the schemas of the project config target of its
+// chart.
+func fluxTargets(args []string) ([]validateTarget, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--flux needs HelmRelease manifests (files or directories)")
+	}
+	if len(validateValues) > 0 || validateRecursive || validateArgoCD {
+		return nil, fmt.Errorf("--flux validates the values of each HelmRelease on its own, so it can't be combined with -f, --recursive, or --argocd")
+	}
+	config, err := workspace.Load(filesystem.OS(), validateConfig)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := readManifests(args)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := map[string]fluxValuesObject{}
+	for _, doc := range docs {
+		if key, object, ok := fluxObject(doc); ok {
+			objects[key] = object
+		}
+	}
+	var targets []validateTarget
+	for _, doc := range docs {
+		target, ok, err := helmReleaseTarget(doc, config, objects)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no HelmRelease in %s sets values for a chart of %s", strings.Join(args, ", "), validateConfig)
+	}
+	validateLog.Infof("Found %d HelmRelease(s) with values", len(targets))
+	return targets, nil
+}
+
+// fluxObject returns a manifest document if it's a ConfigMap or Secret, keyed
+// by kind, namespace, and name
+func fluxObject(doc manifestDoc) (string, fluxValuesObject, bool) {
+	var object struct {
+		APIVersion string               `yaml:"apiVersion"`
+		Kind       string               `yaml:"kind"`
+		Metadata   fluxObjectMeta       `yaml:"metadata"`
+		Data       map[string]yaml.Node `yaml:"data"`
+		StringData map[string]yaml.Node `yaml:"stringData"`
+	}
+	if err := doc.node.Decode(&object); err != nil || object.APIVersion != "v1" || (object.Kind != "ConfigMap" && object.Kind != "Secret") {
+		return "", fluxValuesObject{}, false
+	}
+
+	values := fluxValuesObject{doc: doc, data: map[string]*yaml.Node{}}
+	for key, node := range object.Data {
+		values.data[key] = &node
+	}
+	values.encoded = object.Kind == "Secret"
+	values.dataPath = "data"
+	if object.Kind == "Secret" && len(object.StringData) > 0 {
+		// The API server writes stringData over data, so manifests set one or the other
+		values.data = map[string]*yaml.Node{}
+		for key, node := range object.StringData {
+			values.data[key] = &node
+		}
+		values.encoded = false
+		values.dataPath = "stringData"
+	}
+	return fluxObjectKey(object.Kind, object.Metadata), values, true
+}
+
+// fluxObjectKey identifies a ConfigMap or Secret
+func fluxObjectKey(kind string, meta fluxObjectMeta) string {
+	return kind + "/" + meta.Namespace + "/" + meta.Name
+}
+
+// helmReleaseTarget returns the values of a manifest document if it's a
+// HelmRelease, merged the way Flux merges them: the values of valuesFrom in
+// order, then spec.values. HelmReleases of charts the project config has no
+// target for are skipped, and so are those without values.
+func helmReleaseTarget(doc manifestDoc, config *workspace.Config, objects map[string]fluxValuesObject) (validateTarget, bool, error) {
+	var release fluxHelmRelease
+	if err := doc.node.Decode(&release); err != nil || !strings.HasPrefix(release.APIVersion, fluxHelmGroup) || release.Kind != "HelmRelease" {
+		return validateTarget{}, false, nil
+	}
+	name := release.Metadata.Name
+	if release.Spec.Values.Kind == 0 && len(release.Spec.ValuesFrom) == 0 {
+		return validateTarget{}, false, nil
+	}
+
+	// Charts from HelmRepositories are named, charts from GitRepositories and
+	// Buckets are paths, and chartRef names the OCIRepository or HelmChart
+	var chartName string
+	switch {
+	case release.Spec.Chart != nil:
+		chartName = release.Spec.Chart.Spec.Chart
+	case release.Spec.ChartRef != nil:
+		chartName = release.Spec.ChartRef.Name
+	}
+	target, ok := config.ForChart(chartName, chartName)
+	if !ok {
+		resource := doc.node.Content[0]
+		validateLog.Warnf("⚠️  %s:%d:%d: skipping HelmRelease %s: no target of %s has the chart %s", doc.file, resource.Line, resource.Column, name, validateConfig, chartName)
+		return validateTarget{}, false, nil
+	}
+
+	var sources validation.Sources
+	for _, node := range release.Spec.ValuesFrom {
+		var ref fluxValuesReference
+		if err := node.Decode(&ref); err != nil {
+			return validateTarget{}, false, fmt.Errorf("%s:%d:%d: invalid valuesFrom of HelmRelease %s: %w", doc.file, node.Line, node.Column, name, err)
+		}
+		source, err := fluxReferencedValues(doc, name, release.Metadata.Namespace, ref, &node, objects)
+		if err != nil {
+			return validateTarget{}, false, err
+		}
+		if source != nil {
+			sources = append(sources, source)
+		}
+	}
+	if release.Spec.Values.Kind != 0 {
+		if release.Spec.Values.Kind != yaml.MappingNode {
+			return validateTarget{}, false, fmt.Errorf("%s:%d:%d: the values of HelmRelease %s must be an object", doc.file, release.Spec.Values.Line, release.Spec.Values.Column, name)
+		}
+		source, err := validation.NodeSource(doc.file, &release.Spec.Values)
+		if err != nil {
+			return validateTarget{}, false, err
+		}
+		source.Prefix = []string{"spec", "values"}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		return validateTarget{}, false, nil
+	}
+
+	validateLog.Debugf("Validating HelmRelease %s (%s) against %s", name, chartName, target.Name)
+	vt, err := chartTarget(sources, target)
+	return vt, err == nil, err
+}
+
+// fluxReferencedValues returns the values of an item of valuesFrom, or nil if
+// the manifests don't contain its ConfigMap or Secret
+func fluxReferencedValues(doc manifestDoc, name, namespace string, ref fluxValuesReference, node *yaml.Node, objects map[string]fluxValuesObject) (*validation.Source, error) {
+	position := fmt.Sprintf("%s:%d:%d", doc.file, node.Line, node.Column)
+	if ref.TargetPath != "" {
+		validateLog.Warnf("⚠️  %s: HelmRelease %s: values of %s %s with a targetPath aren't validated", position, name, ref.Kind, ref.Name)
+		return nil, nil
+	}
+	object, ok := objects[fluxObjectKey(ref.Kind, fluxObjectMeta{Name: ref.Name, Namespace: namespace})]
+	if !ok {
+		if !ref.Optional {
+			validateLog.Warnf("⚠️  %s: HelmRelease %s: %s %s isn't in the manifests, so its values aren't validated", position, name, ref.Kind, ref.Name)
+		}
+		return nil, nil
+	}
+	key := ref.ValuesKey
+	if key == "" {
+		key = fluxDefaultValuesKey
+	}
+	value, ok := object.data[key]
+	if !ok {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: HelmRelease %s: %s %s has no key %s", position, name, ref.Kind, ref.Name, key)
+	}
+	if value.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%s:%d:%d: %s of %s %s must be a string", object.doc.file, value.Line, value.Column, key, ref.Kind, ref.Name)
+	}
+
+	var source *validation.Source
+	var err error
+	if object.encoded {
+		decoded, decodeErr := base64.StdEncoding.DecodeString(value.Value)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("%s:%d:%d: failed to decode %s of %s %s: %w", object.doc.file, value.Line, value.Column, key, ref.Kind, ref.Name, decodeErr)
+		}
+		// The decoded values are positioned at the key's value
+		plain := *value
+		plain.Value, plain.Style = string(decoded), 0
+		source, err = validation.ParseEmbeddedSource(object.doc.file, object.doc.content, &plain)
+	} else {
+		source, err = validation.ParseEmbeddedSource(object.doc.file, object.doc.content, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	source.Prefix = []string{object.dataPath, key}
+	return source, nil
+}
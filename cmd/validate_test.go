@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/sarif"
 	"github.com/spf13/cobra"
 )
 
@@ -110,6 +114,8 @@ func runValidateTestCase(t *testing.T, testDir string) error {
 	// Set flags
 	validateCRDPath = crdPath
 	validateSchemaPath = schemaPath
+	validateFormat = "text"
+	validateValues = nil
 
 	// Set args
 	cmd.SetArgs([]string{valuesPath})
@@ -291,3 +297,670 @@ func TestValidateCommand_NoArgs(t *testing.T) {
 		t.Errorf("Expected 'accepts 1 arg' error, got: %v", err)
 	}
 }
+
+// TestValidateCommand_Positions tests that errors are reported with their position in the values file
+func TestValidateCommand_Positions(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "invalid-both")
+	valuesPath := filepath.Join(testDir, "values.yaml")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	t.Cleanup(func() { validateFormat = "text" })
+
+	for format, want := range map[string][]string{
+		"text": {
+			"  " + valuesPath + ":3:1: replicas: minimum: got 0, want 1\n",
+			"  " + valuesPath + ":7:3: service.port: got string, want integer\n",
+			"  " + valuesPath + ":3:1: replicas: Invalid value: 0",
+		},
+		"github": {
+			"::error file=" + valuesPath + ",line=3,col=1::replicas: minimum: got 0, want 1\n",
+			"::error file=" + valuesPath + ",line=7,col=3::service.port: got string, want integer\n",
+		},
+	} {
+		t.Run(format, func(t *testing.T) {
+			validateFormat = format
+			stdout, _, err := captureStdoutStderr(t, func() error {
+				return runValidate(nil, []string{valuesPath})
+			})
+			if err == nil {
+				t.Fatal("Expected validation to fail")
+			}
+			for _, w := range want {
+				if !strings.Contains(stdout, w) {
+					t.Errorf("Expected %q in output, got:\n%s", w, stdout)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateCommand_UntypedValues tests that values without apiVersion and
+// kind fail CRD validation, rather than the CRD's load error hiding theirs
+func TestValidateCommand_UntypedValues(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "invalid-crd")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("replicas: 0\nappName: myapp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := captureStdoutStderr(t, func() error {
+		return runValidate(nil, []string{valuesPath})
+	})
+	if err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+	if !strings.Contains(stdout, "✗ CRD validation failed") {
+		t.Errorf("Expected CRD validation to fail, got:\n%s", stdout)
+	}
+}
+
+// TestValidateCommand_InvalidFormat tests that unknown output formats are rejected
+func TestValidateCommand_InvalidFormat(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "xml"
+	t.Cleanup(func() { validateFormat = "text" })
+
+	err := runValidate(nil, []string{filepath.Join(testDir, "values.yaml")})
+	if err == nil || !strings.Contains(err.Error(), `invalid format "xml"`) {
+		t.Errorf("Expected invalid format error, got: %v", err)
+	}
+}
+
+// TestValidateCommand_JSONOutput tests that --format=json prints only a result
+// object with the problems of both schemas
+func TestValidateCommand_JSONOutput(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "invalid-both")
+	valuesPath := filepath.Join(testDir, "values.yaml")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "json"
+	t.Cleanup(func() { validateFormat = "text" })
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+
+	var result commandResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Expected only a JSON result on stdout: %v\n%s", err, stdout)
+	}
+	if result.Command != "validate" || result.Status != statusFailed {
+		t.Errorf("Expected a failed validate result, got %+v", result)
+	}
+	for _, want := range []resultIssue{
+		{Rule: "schema-validation", Message: "minimum: got 0, want 1", File: valuesPath, Line: 3, Column: 1, Path: "replicas"},
+		{Rule: "schema-validation", Message: "got string, want integer", File: valuesPath, Line: 7, Column: 3, Path: "service.port"},
+	} {
+		if !slices.Contains(result.Errors, want) {
+			t.Errorf("Expected error %+v, got %+v", want, result.Errors)
+		}
+	}
+}
+
+// TestValidateCommand_JSONOutputValid tests the result of values that pass validation
+func TestValidateCommand_JSONOutputValid(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "json"
+	t.Cleanup(func() { validateFormat = "text" })
+
+	stdout, _, err := captureStdoutStderr(t, func() error {
+		return runValidate(nil, []string{filepath.Join(testDir, "values.yaml")})
+	})
+	if err != nil {
+		t.Fatalf("Expected validation to pass, got: %v\n%s", err, stdout)
+	}
+	want := "{\n  \"command\": \"validate\",\n  \"status\": \"ok\"\n}\n"
+	if stdout != want {
+		t.Errorf("Expected %q, got %q", want, stdout)
+	}
+}
+
+// TestValidateCommand_Quiet tests that --quiet only prints the problems
+func TestValidateCommand_Quiet(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "invalid-both")
+	valuesPath := filepath.Join(testDir, "values.yaml")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "text"
+	logQuiet = true
+	t.Cleanup(func() { logQuiet = false })
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	if err := runValidate(cmd, []string{valuesPath}); err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+	if strings.Contains(out.String(), "Validating against") {
+		t.Errorf("Expected no progress with --quiet, got:\n%s", out.String())
+	}
+	if want := "  " + valuesPath + ":3:1: replicas: minimum: got 0, want 1\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, out.String())
+	}
+}
+
+// TestValidateCommand_MergedValues tests that -f files are merged in Helm order before validation
+func TestValidateCommand_MergedValues(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "text"
+	t.Cleanup(func() { validateValues = nil })
+
+	tmpDir := t.TempDir()
+	basePath := filepath.Join(testDir, "values.yaml")
+	prodPath := filepath.Join(tmpDir, "prod.yaml")
+	if err := os.WriteFile(prodPath, []byte("replicas: 0\nservice:\n  port: null\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+	fixPath := filepath.Join(tmpDir, "fix.yaml")
+	if err := os.WriteFile(fixPath, []byte("replicas: 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	// The invalid replicas value from prod.yaml is reported against prod.yaml
+	validateValues = []string{basePath, prodPath}
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, nil) })
+	if err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+	if !strings.Contains(stdout, "  "+prodPath+":1:1: replicas: minimum: got 0, want 1\n") {
+		t.Errorf("Expected error located in prod.yaml, got:\n%s", stdout)
+	}
+
+	// A later file fixes the value, so validating the files independently would be a false positive
+	validateValues = []string{prodPath, fixPath}
+	stdout, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{basePath}) })
+	if err != nil {
+		t.Fatalf("Expected merged values to be valid, got: %v\n%s", err, stdout)
+	}
+}
+
+// TestValidateCommand_Recursive tests that --recursive validates each values file under a directory on its own
+func TestValidateCommand_Recursive(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "text"
+	validateRecursive = true
+	t.Cleanup(func() { validateRecursive = false })
+
+	valid, err := os.ReadFile(filepath.Join(testDir, "values.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read values file: %v", err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"values.yaml":                 string(valid),
+		"envs/prod/values-prod.yaml":  string(valid),
+		"envs/dev/dev.values.yml":     strings.Replace(string(valid), "replicas: 3", "replicas: 0", 1),
+		"envs/dev/notes.yaml":         "not: values\n",
+		".git/values.yaml":            "replicas: 0\n",
+		"envs/staging/values-old.txt": "replicas: 0\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write values file: %v", err)
+		}
+	}
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{dir}) })
+	if err == nil {
+		t.Fatalf("Expected the invalid values file to fail validation, got:\n%s", stdout)
+	}
+	for _, want := range []string{
+		"Found 3 values file(s) in " + dir,
+		filepath.Join(dir, "envs", "dev", "dev.values.yml") + ":3:1: replicas: minimum: got 0, want 1",
+		"✗ 1 of 3 values file(s) failed validation",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(dir, "envs", "dev", "dev.values.yml")); err != nil {
+		t.Fatalf("Failed to remove values file: %v", err)
+	}
+	stdout, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{dir}) })
+	if err != nil {
+		t.Fatalf("Expected the values files to pass, got: %v\n%s", err, stdout)
+	}
+	if want := "✓ 2 values file(s) passed validation"; !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+}
+
+// TestValidateCommand_Subchart tests that --subchart validates the values of a subchart in umbrella chart values
+func TestValidateCommand_Subchart(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "text"
+	validateSubchart = "example"
+	t.Cleanup(func() {
+		validateSubchart = ""
+		validateValues = nil
+	})
+
+	umbrella := `global:
+  domain: example.com
+frontend:
+  replicas: 2
+example:
+  replicas: 0
+  appName: myapp
+  service:
+    port: 8080
+`
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte(umbrella), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	// The subchart's problems are located in the umbrella chart's values file,
+	// and the other keys of the umbrella chart aren't problems
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err == nil {
+		t.Fatalf("Expected validation to fail, got:\n%s", stdout)
+	}
+	// The subchart's values set no apiVersion or kind, which Helm takes from
+	// the subchart's defaults, so both schemas find the same problem
+	for _, want := range []string{
+		"  " + valuesPath + ":6:3: replicas: minimum: got 0, want 1\n",
+		"  " + valuesPath + ":6:3: replicas: Invalid value: 0",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "frontend") || strings.Contains(stdout, "global") {
+		t.Errorf("Expected only the subchart's values to be validated, got:\n%s", stdout)
+	}
+
+	fixPath := filepath.Join(t.TempDir(), "fix.yaml")
+	if err := os.WriteFile(fixPath, []byte("example:\n  replicas: 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+	validateValues = []string{fixPath}
+	stdout, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err != nil {
+		t.Fatalf("Expected the merged subchart values to be valid, got: %v\n%s", err, stdout)
+	}
+
+	validateValues = nil
+	validateSubchart = "backend"
+	_, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if want := valuesPath + ": no values for subchart backend"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got: %v", want, err)
+	}
+}
+
+// TestValidateCommand_ArgoCD tests that --argocd validates the Helm values of Argo CD Applications
+func TestValidateCommand_ArgoCD(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateFormat = "text"
+	validateArgoCD = true
+	t.Cleanup(func() {
+		validateArgoCD = false
+		validateConfig = "miaka.yaml"
+	})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"miaka.yaml": `targets:
+  - input: charts/example/example.values.yaml
+  - input: charts/web/example.values.yaml
+    plain: true
+    charts: [web]
+`,
+		"apps/example.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: example
+spec:
+  source:
+    repoURL: https://github.com/example/charts
+    path: charts/example
+    helm:
+      values: |
+        replicas: 0
+        appName: myapp
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-an-app
+`,
+		"apps/more.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: web
+spec:
+  sources:
+    - repoURL: https://charts.example.com
+      chart: web
+      helm:
+        valuesObject:
+          replicas: 2
+          service:
+            port: "http"
+    - repoURL: https://charts.example.com
+      chart: unknown
+      helm:
+        valuesObject:
+          replicas: 0
+`,
+		".hidden/app.yaml": "not: [valid",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	for _, chart := range []string{"example", "web"} {
+		if err := os.MkdirAll(filepath.Join(dir, "charts", chart), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		for src, dst := range map[string]string{"crd.yaml": "crd.yaml", "schema.json": "values.schema.json"} {
+			data, err := os.ReadFile(filepath.Join(testDir, src))
+			if err != nil {
+				t.Fatalf("Failed to read schema: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "charts", chart, dst), data, 0644); err != nil {
+				t.Fatalf("Failed to write schema: %v", err)
+			}
+		}
+	}
+	validateConfig = filepath.Join(dir, "miaka.yaml")
+
+	// The values are located in the manifests, and the plain chart is only
+	// validated against its JSON Schema
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{filepath.Join(dir, "apps")}) })
+	if err == nil {
+		t.Fatalf("Expected validation to fail, got:\n%s", stdout)
+	}
+	examplePath := filepath.Join(dir, "apps", "example.yaml")
+	morePath := filepath.Join(dir, "apps", "more.yaml")
+	for _, want := range []string{
+		"Found 2 Application source(s) with Helm values",
+		"Validating against CRD (" + filepath.Join(dir, "charts", "example", "crd.yaml") + ")",
+		"  " + examplePath + ":11:9: replicas: minimum: got 0, want 1\n",
+		"  " + morePath + ":13:13: service.port: got string, want integer\n",
+		"✗ 2 of 2 Application source(s) failed validation",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+		}
+	}
+	if want := morePath + ":18:11: skipping Application web: no target of " + validateConfig + " has the chart unknown"; !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+
+	_, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{filepath.Join(dir, "miaka.yaml")}) })
+	if want := "no Application in"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected error containing %q, got: %v", want, err)
+	}
+}
+
+// TestValidateCommand_Flux tests that --flux validates the values of Flux HelmReleases and their ConfigMaps
+func TestValidateCommand_Flux(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "valid-basic")
+	validateFormat = "text"
+	validateFlux = true
+	t.Cleanup(func() {
+		validateFlux = false
+		validateConfig = "miaka.yaml"
+	})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"miaka.yaml": `targets:
+  - input: charts/example/example.values.yaml
+    charts: [example]
+`,
+		"clusters/example.yaml": `apiVersion: helm.toolkit.fluxcd.io/v2
+kind: HelmRelease
+metadata:
+  name: example
+  namespace: apps
+spec:
+  chart:
+    spec:
+      chart: example
+  valuesFrom:
+    - kind: ConfigMap
+      name: example-values
+    - kind: Secret
+      name: example-secrets
+  values:
+    service:
+      port: http
+`,
+		"clusters/values.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example-values
+  namespace: apps
+data:
+  values.yaml: |
+    replicas: 0
+    appName: myapp
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "charts", "example"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for src, dst := range map[string]string{"crd.yaml": "crd.yaml", "schema.json": "values.schema.json"} {
+		data, err := os.ReadFile(filepath.Join(testDir, src))
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "charts", "example", dst), data, 0644); err != nil {
+			t.Fatalf("Failed to write schema: %v", err)
+		}
+	}
+	validateConfig = filepath.Join(dir, "miaka.yaml")
+
+	// Errors are located in the manifest that set the value, with its path there
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{filepath.Join(dir, "clusters")}) })
+	if err == nil {
+		t.Fatalf("Expected validation to fail, got:\n%s", stdout)
+	}
+	releasePath := filepath.Join(dir, "clusters", "example.yaml")
+	for _, want := range []string{
+		"Found 1 HelmRelease(s) with values",
+		releasePath + ":13:7: HelmRelease example: Secret example-secrets isn't in the manifests, so its values aren't validated",
+		"  " + filepath.Join(dir, "clusters", "values.yaml") + ":8:5: data.values.yaml.replicas: minimum: got 0, want 1\n",
+		"  " + releasePath + ":17:7: spec.values.service.port: got string, want integer\n",
+		"✗ 1 of 1 HelmRelease(s) failed validation",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+		}
+	}
+
+	// spec.values takes precedence over valuesFrom
+	fixed := strings.Replace(files["clusters/example.yaml"], "      port: http\n", "      port: 80\n    replicas: 2\n", 1)
+	if err := os.WriteFile(releasePath, []byte(fixed), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	stdout, _, err = captureStdoutStderr(t, func() error { return runValidate(nil, []string{filepath.Join(dir, "clusters")}) })
+	if err != nil {
+		t.Fatalf("Expected the merged values to be valid, got: %v\n%s", err, stdout)
+	}
+	if want := "✓ 1 HelmRelease(s) passed validation"; !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+}
+
+// TestValidateCommand_RecursiveErrors tests the arguments --recursive needs
+func TestValidateCommand_RecursiveErrors(t *testing.T) {
+	validateRecursive = true
+	t.Cleanup(func() {
+		validateRecursive = false
+		validateValues = nil
+	})
+
+	tests := []struct {
+		name    string
+		args    []string
+		values  []string
+		wantErr string
+	}{
+		{name: "no directory", wantErr: "--recursive needs a directory"},
+		{name: "with -f", args: []string{t.TempDir()}, values: []string{"values.yaml"}, wantErr: "can't be combined with -f"},
+		{name: "no values files", args: []string{t.TempDir()}, wantErr: "no values files found in"},
+		{name: "not a directory", args: []string{filepath.Join("..", "testdata", "validate", "valid-basic", "values.yaml")}, wantErr: "is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validateValues = tt.values
+			_, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, tt.args) })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateCommand_NoValuesFiles tests that at least one values file is required
+func TestValidateCommand_NoValuesFiles(t *testing.T) {
+	validateValues = nil
+	err := runValidate(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "no values file given") {
+		t.Errorf("Expected missing values file error, got: %v", err)
+	}
+}
+
+// TestValidateCommand_DeprecatedFields tests that deprecated fields are reported as warnings
+func TestValidateCommand_DeprecatedFields(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "build", "comprehensive")
+	validateCRDPath = filepath.Join(testDir, "expected_crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "expected_schema.json")
+	validateFormat = "text"
+
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesPath, []byte("apiVersion: example.com/v1alpha1\nkind: MyApp\ndebug: true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err != nil {
+		t.Fatalf("Expected deprecated fields not to fail validation, got: %v\n%s", err, stdout)
+	}
+	want := "⚠️  1 deprecated field(s):\n  " + valuesPath + ":3:1: debug: deprecated: set the LOG_LEVEL environment variable to debug instead\n"
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+}
+
+func TestValidateCommand_CELRules(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "build", "comprehensive")
+	validateCRDPath = filepath.Join(testDir, "expected_crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "expected_schema.json")
+	validateFormat = "text"
+
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	values := "apiVersion: example.com/v1alpha1\nkind: MyApp\nlivenessProbe:\n  periodSeconds: 5\n  timeoutSeconds: 10\n"
+	if err := os.WriteFile(valuesPath, []byte(values), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err == nil {
+		t.Fatalf("Expected the CEL rule to fail validation, got:\n%s", stdout)
+	}
+	want := valuesPath + `:3:1: livenessProbe: Invalid value: "object": timeoutSeconds must be less than periodSeconds`
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+}
+
+func TestValidateCommand_DuplicateListItems(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "build", "comprehensive")
+	validateCRDPath = filepath.Join(testDir, "expected_crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "expected_schema.json")
+	validateFormat = "text"
+
+	valuesPath := filepath.Join(t.TempDir(), "values.yaml")
+	values := "apiVersion: example.com/v1alpha1\nkind: MyApp\nenv:\n- name: LOG_LEVEL\n  value: info\n- name: LOG_LEVEL\n  value: debug\n"
+	if err := os.WriteFile(valuesPath, []byte(values), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err == nil {
+		t.Fatalf("Expected the duplicate env var to fail validation, got:\n%s", stdout)
+	}
+	want := valuesPath + `:6:3: env.1: Duplicate value: {"name":"LOG_LEVEL"}`
+	if !strings.Contains(stdout, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, stdout)
+	}
+}
+
+// TestValidateCommand_SARIFOutput tests that --format=sarif prints the problems
+// as SARIF results located in the values file
+func TestValidateCommand_SARIFOutput(t *testing.T) {
+	testDir := filepath.Join("..", "testdata", "validate", "invalid-both")
+	valuesPath := filepath.Join(testDir, "values.yaml")
+	validateCRDPath = filepath.Join(testDir, "crd.yaml")
+	validateSchemaPath = filepath.Join(testDir, "schema.json")
+	validateFormat = "sarif"
+	t.Cleanup(func() { validateFormat = "text" })
+
+	stdout, _, err := captureStdoutStderr(t, func() error { return runValidate(nil, []string{valuesPath}) })
+	if err == nil {
+		t.Fatal("Expected validation to fail")
+	}
+
+	var log sarif.Log
+	if err := json.Unmarshal([]byte(stdout), &log); err != nil {
+		t.Fatalf("Expected only a SARIF log on stdout: %v\n%s", err, stdout)
+	}
+	if log.Version != sarif.Version || len(log.Runs) != 1 {
+		t.Fatalf("Expected a SARIF %s log with one run, got %+v", sarif.Version, log)
+	}
+	want := sarif.Result{
+		RuleID:  "schema-validation",
+		Level:   sarif.LevelError,
+		Message: sarif.Message{Text: "replicas: minimum: got 0, want 1"},
+		Locations: []sarif.Location{{PhysicalLocation: sarif.PhysicalLocation{
+			ArtifactLocation: sarif.ArtifactLocation{URI: filepath.ToSlash(valuesPath)},
+			Region:           &sarif.Region{StartLine: 3, StartColumn: 1},
+		}}},
+	}
+	found := false
+	for _, result := range log.Runs[0].Results {
+		found = found || reflect.DeepEqual(result, want)
+	}
+	if !found {
+		t.Errorf("Expected result %+v, got %+v", want, log.Runs[0].Results)
+	}
+	if len(log.Runs[0].Results) != 4 {
+		t.Errorf("Expected 4 results (2 per schema), got %d", len(log.Runs[0].Results))
+	}
+}
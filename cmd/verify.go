@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyCRDPath    string
+	verifySchemaPath string
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [example.values.yaml]",
+	Short: "Check that generated schemas are up to date with their input",
+	Long: `Check that the generated CRD and JSON Schema were built from the current
+example values, without regenerating them.
+
+'miaka build' stamps its outputs with the SHA-256 hash of the input file
+(unless --no-provenance is set). verify hashes the input again and fails if
+an output was built from a different input, or has no provenance to compare.
+
+Outputs at the default paths that don't exist are skipped (e.g., plain builds
+have no CRD); outputs given with --crd or --schema must exist.
+
+verify only detects changes to the input. To also catch outputs that were
+edited by hand or built with other flags, use 'miaka build --check', which
+regenerates them in memory and compares the content.`,
+	Example: `  # Check crd.yaml and values.schema.json against example.values.yaml
+  miaka verify
+
+  # Check outputs in other locations in CI
+  miaka verify values/example.yaml -c crds/crd.yaml -s values.schema.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVerify,
+	// SilenceUsage prevents usage from showing on business logic errors
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVarP(&verifyCRDPath, "crd", "c", defaultCRDPath, "Path to the generated CRD YAML file")
+	verifyCmd.Flags().StringVarP(&verifySchemaPath, "schema", "s", defaultSchemaPath, "Path to the generated JSON Schema file")
+}
+
+// verifiedOutput is a generated file checked by verify
+type verifiedOutput struct {
+	flag string
+	path string
+	read func([]byte) (provenance.Provenance, bool, error)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	inputFile := defaultExampleValuesFile
+	if len(args) > 0 {
+		inputFile = args[0]
+	}
+	input, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+	hash := provenance.Hash(input)
+
+	out := cmd.OutOrStdout()
+	checked, stale := 0, 0
+	for _, output := range []verifiedOutput{
+		{flag: "crd", path: verifyCRDPath, read: provenance.ReadCRD},
+		{flag: "schema", path: verifySchemaPath, read: provenance.ReadSchema},
+	} {
+		data, err := os.ReadFile(output.path)
+		if os.IsNotExist(err) && !cmd.Flags().Changed(output.flag) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", output.path, err)
+		}
+		checked++
+		if !verifyOutput(out, output, data, hash, inputFile) {
+			stale++
+		}
+	}
+
+	if checked == 0 {
+		return fmt.Errorf("no generated outputs found (looked for %s and %s)", verifyCRDPath, verifySchemaPath)
+	}
+	if stale > 0 {
+		return fmt.Errorf("%d output(s) are out of date with %s (run 'miaka build')", stale, inputFile)
+	}
+	return nil
+}
+
+// verifyOutput prints whether an output was generated from the input with the
+// given hash. Returns true if it was.
+func verifyOutput(out io.Writer, output verifiedOutput, data []byte, hash, inputFile string) bool {
+	p, ok, err := output.read(data)
+	switch {
+	case err != nil:
+		fmt.Fprintf(out, "✗ %s: %v\n", output.path, err)
+		return false
+	case !ok || p.InputHash == "":
+		fmt.Fprintf(out, "✗ %s has no provenance (it was built with --no-provenance or an older miaka)\n", output.path)
+		return false
+	case p.InputHash != hash:
+		fmt.Fprintf(out, "✗ %s is stale: it was generated from a different version of %s\n", output.path, inputFile)
+		return false
+	}
+	fmt.Fprintf(out, "✓ %s is up to date\n", output.path)
+	return true
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVerifyCommand creates a fresh verify command instance for testing
+func newVerifyCommand() *cobra.Command {
+	verifyCRDPath = defaultCRDPath
+	verifySchemaPath = defaultSchemaPath
+
+	cmd := &cobra.Command{
+		Use:          "verify",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runVerify,
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVarP(&verifyCRDPath, "crd", "c", defaultCRDPath, "Path to the generated CRD YAML file")
+	cmd.Flags().StringVarP(&verifySchemaPath, "schema", "s", defaultSchemaPath, "Path to the generated JSON Schema file")
+	return cmd
+}
+
+// buildForVerify builds input into crd.yaml and values.schema.json in dir
+func buildForVerify(t *testing.T, dir, input string, extraArgs ...string) {
+	t.Helper()
+	cmd := newBuildCommand()
+	args := []string{input, "--in-memory", "-c", filepath.Join(dir, "crd.yaml"), "-s", filepath.Join(dir, "values.schema.json")}
+	cmd.SetArgs(append(args, extraArgs...))
+	_, _, err := captureStdoutStderr(t, cmd.Execute)
+	require.NoError(t, err)
+}
+
+// runVerifyCommand runs verify on the outputs in dir
+func runVerifyCommand(dir, input string, extraArgs ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := newVerifyCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs(append([]string{input, "-c", filepath.Join(dir, "crd.yaml"), "-s", filepath.Join(dir, "values.schema.json")}, extraArgs...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestVerifyCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	buildForVerify(t, dir, input)
+
+	crdContent, err := os.ReadFile(filepath.Join(dir, "crd.yaml"))
+	require.NoError(t, err)
+	p, ok, err := provenance.ReadCRD(crdContent)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, version, p.Version)
+	assert.NotEmpty(t, p.GeneratedAt)
+
+	out, err := runVerifyCommand(dir, input)
+	require.NoError(t, err)
+	assert.Contains(t, out, "crd.yaml is up to date")
+	assert.Contains(t, out, "values.schema.json is up to date")
+
+	// Editing the input makes the outputs stale
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 2\n"), 0644))
+	out, err = runVerifyCommand(dir, input)
+	assert.ErrorContains(t, err, "2 output(s) are out of date")
+	assert.Contains(t, out, "crd.yaml is stale")
+	assert.Contains(t, out, "values.schema.json is stale")
+}
+
+func TestVerifyCommand_NoProvenance(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	buildForVerify(t, dir, input, "--no-provenance")
+
+	schemaContent, err := os.ReadFile(filepath.Join(dir, "values.schema.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(schemaContent), provenance.SchemaKeyword)
+
+	out, err := runVerifyCommand(dir, input)
+	assert.ErrorContains(t, err, "out of date")
+	assert.Contains(t, out, "has no provenance")
+}
+
+func TestVerifyCommand_Deterministic(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"), 0644))
+	buildForVerify(t, dir, input, "--deterministic")
+
+	// Only the input hash is stamped, so the output doesn't depend on the environment
+	crdContent, err := os.ReadFile(filepath.Join(dir, "crd.yaml"))
+	require.NoError(t, err)
+	p, ok, err := provenance.ReadCRD(crdContent)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, provenance.Provenance{InputHash: p.InputHash}, p)
+
+	_, err = runVerifyCommand(dir, input)
+	assert.NoError(t, err)
+}
+
+func TestVerifyCommand_MissingOutputs(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "example.values.yaml")
+	require.NoError(t, os.WriteFile(input, []byte("replicas: 1\n"), 0644))
+
+	// Explicit paths must exist
+	_, err := runVerifyCommand(dir, input)
+	assert.ErrorContains(t, err, "failed to read")
+
+	// Missing outputs at the default paths are skipped
+	t.Chdir(dir)
+	cmd := newVerifyCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{input})
+	assert.ErrorContains(t, cmd.Execute(), "no generated outputs found")
+
+	_, err = runVerifyCommand(dir, filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read input file")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/spf13/cobra"
+)
+
+var versionTools bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version information",
+	Long: `Print the version of miaka.
+
+With --tools, also print the versions of the tools CRD generation depends on:
+controller-gen, which is built into miaka as a library at a pinned version;
+k8s.io/apimachinery, which the generated types are loaded with; and the go
+command controller-gen loads them with. Without a go command at least as new
+as the generated types require, CRDs are generated in memory instead (see
+'miaka build --in-memory'), so builds never download a tool.`,
+	Example: `  # Check the tools of a CI runner
+  miaka version --tools`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		out := commandOut(cmd)
+		fmt.Fprintf(out, "miaka version %s\n", version)
+		fmt.Fprintf(out, "  commit: %s\n", commit)
+		fmt.Fprintf(out, "  built:  %s\n", date)
+		if versionTools {
+			printTools(out)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().BoolVar(&versionTools, "tools", false, "Also print the versions of the tools CRD generation depends on")
+}
+
+// printTools prints the versions of the tools CRD generation depends on
+func printTools(out io.Writer) {
+	fmt.Fprintln(out, "Tools:")
+	fmt.Fprintf(out, "  controller-gen:      %s (built in)\n", crd.ControllerGenVersion())
+	fmt.Fprintf(out, "  k8s.io/apimachinery: %s (module of the generated types)\n", crd.ApimachineryVersion())
+	goCommand, err := crd.FindGoCommand()
+	if err != nil {
+		fmt.Fprintf(out, "  go:                  not usable, so CRDs are generated in memory (%v)\n", err)
+		return
+	}
+	fmt.Fprintf(out, "  go:                  %s (%s, %s or newer required)\n", goCommand.Version, goCommand.Path, crd.ModuleGoVersion())
+}
+
+// cacheVersion identifies this miaka binary in the keys of cached outputs, so
+// a different binary never reuses them (see binaryVersion)
+var cacheVersion = sync.OnceValue(func() string {
+	info, _ := debug.ReadBuildInfo()
+	return binaryVersion(version, commit, info, os.Executable)
+})
+
+// binaryVersion identifies a miaka binary. Releases set version and commit
+// with ldflags. Other builds (go install, go build) are identified by their
+// module version, else by their VCS revision if the checkout was unmodified,
+// else by a hash of the executable.
+func binaryVersion(version, commit string, info *debug.BuildInfo, executable func() (string, error)) string {
+	if version != "dev" {
+		return version + " " + commit
+	}
+	if info != nil {
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version + " " + info.Main.Sum
+		}
+		var revision, modified string
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+		if revision != "" && modified != "true" {
+			return "dev " + revision
+		}
+	}
+	if path, err := executable(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			sum := sha256.Sum256(data)
+			return "dev " + hex.EncodeToString(sum[:])
+		}
+	}
+	// Without the executable, the dependencies at least tell builds apart
+	if info != nil {
+		return "dev " + info.String()
+	}
+	return version + " " + commit
+}
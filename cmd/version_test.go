@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCommand(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetArgs([]string{"version"})
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+	})
+
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, out.String(), "miaka version "+version+"\n")
+	assert.NotContains(t, out.String(), "Tools:")
+}
+
+func TestVersionCommand_Tools(t *testing.T) {
+	var out bytes.Buffer
+	rootCmd.SetArgs([]string{"version", "--tools"})
+	rootCmd.SetOut(&out)
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		versionTools = false
+	})
+
+	require.NoError(t, rootCmd.Execute())
+	output := out.String()
+	assert.Contains(t, output, "controller-gen:      "+crd.ControllerGenVersion()+" (built in)\n")
+	assert.Contains(t, output, "k8s.io/apimachinery: "+crd.ApimachineryVersion())
+	goCommand, err := crd.FindGoCommand()
+	require.NoError(t, err)
+	assert.Contains(t, output, "go:                  "+goCommand.Version+" ("+goCommand.Path)
+}
+
+func TestBinaryVersion(t *testing.T) {
+	noExecutable := func() (string, error) { return "", os.ErrNotExist }
+	settings := func(revision, modified string) *debug.BuildInfo {
+		return &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}, Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: revision},
+			{Key: "vcs.modified", Value: modified},
+		}}
+	}
+
+	// Releases are identified by their ldflags
+	assert.Equal(t, "v1.2.3 abc123", binaryVersion("v1.2.3", "abc123", settings("def456", "false"), noExecutable))
+
+	// go install, by the module version
+	installed := &debug.BuildInfo{Main: debug.Module{Version: "v1.2.3", Sum: "h1:abc="}}
+	assert.Equal(t, "v1.2.3 h1:abc=", binaryVersion("dev", "none", installed, noExecutable))
+
+	// go build in a clean checkout, by the revision
+	assert.Equal(t, "dev def456", binaryVersion("dev", "none", settings("def456", "false"), noExecutable))
+
+	// go build with local changes, by the executable
+	executable := filepath.Join(t.TempDir(), "miaka")
+	require.NoError(t, os.WriteFile(executable, []byte("binary"), 0755))
+	findExecutable := func() (string, error) { return executable, nil }
+	modified := binaryVersion("dev", "none", settings("def456", "true"), findExecutable)
+	assert.NotEqual(t, "dev def456", modified)
+	require.NoError(t, os.WriteFile(executable, []byte("changed binary"), 0755))
+	assert.NotEqual(t, modified, binaryVersion("dev", "none", settings("def456", "true"), findExecutable))
+}
@@ -0,0 +1,231 @@
+// Package anonymize scrubs values files so they can be shared without leaking data.
+package anonymize
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// SecretMarker marks a field in the example values file whose values, and the
+// values beneath them, are always masked
+const SecretMarker = "+miaka:secret"
+
+// mask replaces every character of a secret value
+const mask = '*'
+
+// preservedFormats are string formats whose values are kept as-is because they
+// describe when or how long rather than user data
+var preservedFormats = map[string]bool{
+	"date":      true,
+	"date-time": true,
+	"duration":  true,
+}
+
+// Options configures an Anonymizer
+type Options struct {
+	// Schema is the values schema (the CRD's OpenAPI v3 schema). Optional.
+	// Enum values and structured formats are kept, and scrambled values are
+	// checked against patterns so the output still validates.
+	Schema *apiextensionsv1.JSONSchemaProps
+
+	// SecretPaths are value paths whose values, and those beneath them, are
+	// always masked (see FindSecretPaths and IsSecret)
+	SecretPaths map[string]bool
+
+	// Seed makes the output reproducible. If empty, a random seed is used.
+	Seed string
+}
+
+// Anonymizer replaces string values in a values file while keeping its
+// structure, the length of each value, and its validity against the schema
+type Anonymizer struct {
+	opts Options
+	seed []byte
+}
+
+// NewAnonymizer creates a new anonymizer
+func NewAnonymizer(opts Options) (*Anonymizer, error) {
+	seed := []byte(opts.Seed)
+	if len(seed) == 0 {
+		seed = make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to generate seed: %w", err)
+		}
+	}
+	return &Anonymizer{opts: opts, seed: seed}, nil
+}
+
+// AnonymizeFile anonymizes a values file and returns the YAML output
+func (a *Anonymizer) AnonymizeFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	return a.Anonymize(data)
+}
+
+// Anonymize anonymizes YAML values content and returns the YAML output.
+// Comments are removed since they may contain sensitive information.
+func (a *Anonymizer) Anonymize(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+
+	a.anonymizeNode(doc.Content[0], "", a.opts.Schema)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// anonymizeNode recursively anonymizes a node at the given path
+func (a *Anonymizer) anonymizeNode(node *yaml.Node, path string, schema *apiextensionsv1.JSONSchemaProps) {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			keyNode.HeadComment, keyNode.LineComment, keyNode.FootComment = "", "", ""
+
+			// apiVersion and kind identify the API and carry no user data
+			if path == "" && (keyNode.Value == "apiVersion" || keyNode.Value == "kind") {
+				continue
+			}
+			a.anonymizeNode(valueNode, joinPath(path, keyNode.Value), propertySchema(schema, keyNode.Value))
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			a.anonymizeNode(item, path+"[]", itemSchema(schema))
+		}
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return
+		}
+		if IsSecret(a.opts.SecretPaths, path) {
+			// Numbers and booleans are masked too, so the mask is a string
+			node.Value, node.Tag, node.Style = strings.Repeat(string(mask), len([]rune(node.Value))), "!!str", 0
+			return
+		}
+		if node.Tag != "!!str" {
+			return
+		}
+		node.Value = a.anonymizeString(node.Value, schema)
+	case yaml.AliasNode, yaml.DocumentNode:
+		// Aliases point at nodes anonymized elsewhere
+	}
+}
+
+// anonymizeString returns the replacement for a single string value
+func (a *Anonymizer) anonymizeString(value string, schema *apiextensionsv1.JSONSchemaProps) string {
+	if schema != nil {
+		if len(schema.Enum) > 0 || preservedFormats[schema.Format] {
+			return value
+		}
+		if schema.XIntOrString {
+			// Keep units and suffixes (e.g., "500m", "50%") so the value stays parseable
+			return a.scramble(value, unicode.IsDigit)
+		}
+	}
+
+	scrambled := a.scramble(value, isScrambled)
+	if schema == nil || schema.Pattern == "" {
+		return scrambled
+	}
+
+	// Fall back to scrambling digits only if the result no longer matches the pattern
+	pattern, err := regexp.Compile(schema.Pattern)
+	if err != nil || pattern.MatchString(scrambled) {
+		return scrambled
+	}
+	return a.scramble(value, unicode.IsDigit)
+}
+
+// isScrambled reports whether a character is replaced by default.
+// Punctuation and whitespace are kept so the shape of the value (URLs,
+// hostnames, paths) survives.
+func isScrambled(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scramble deterministically replaces characters selected by replace with
+// others of the same class (lowercase, uppercase, digit)
+func (a *Anonymizer) scramble(value string, replace func(rune) bool) string {
+	h := sha256.New()
+	h.Write(a.seed)
+	h.Write([]byte(value))
+	stream := h.Sum(nil)
+
+	runes := []rune(value)
+	for i, r := range runes {
+		if !replace(r) {
+			continue
+		}
+		// Extend the byte stream as needed for long values
+		for i >= len(stream) {
+			next := sha256.Sum256(stream[len(stream)-sha256.Size:])
+			stream = append(stream, next[:]...)
+		}
+		b := int(stream[i])
+
+		switch {
+		case unicode.IsDigit(r):
+			runes[i] = rune('0' + b%10)
+		case unicode.IsUpper(r):
+			runes[i] = rune('A' + b%26)
+		case unicode.IsLetter(r):
+			runes[i] = rune('a' + b%26)
+		}
+	}
+	return string(runes)
+}
+
+// propertySchema returns the schema of a property of an object schema
+func propertySchema(schema *apiextensionsv1.JSONSchemaProps, name string) *apiextensionsv1.JSONSchemaProps {
+	if schema == nil {
+		return nil
+	}
+	if prop, ok := schema.Properties[name]; ok {
+		return &prop
+	}
+	if schema.AdditionalProperties != nil {
+		return schema.AdditionalProperties.Schema
+	}
+	return nil
+}
+
+// itemSchema returns the schema of the items of an array schema
+func itemSchema(schema *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	if schema == nil || schema.Items == nil {
+		return nil
+	}
+	return schema.Items.Schema
+}
+
+// joinPath appends a key to a dotted value path
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
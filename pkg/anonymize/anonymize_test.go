@@ -0,0 +1,242 @@
+package anonymize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &values))
+	return values
+}
+
+func TestAnonymize_KeepsStructureAndLength(t *testing.T) {
+	input := `apiVersion: example.com/v1
+kind: MyApp
+# Internal hostname
+host: db.internal.example.com
+replicas: 3
+debug: true
+image:
+  tag: V1.2.3-rc1
+args:
+- --verbose
+`
+	a, err := NewAnonymizer(Options{Seed: "test"})
+	require.NoError(t, err)
+
+	output, err := a.Anonymize([]byte(input))
+	require.NoError(t, err)
+	values := decode(t, output)
+
+	assert.Equal(t, "example.com/v1", values["apiVersion"])
+	assert.Equal(t, "MyApp", values["kind"])
+	assert.Equal(t, 3, values["replicas"])
+	assert.Equal(t, true, values["debug"])
+
+	host := values["host"].(string)
+	assert.NotEqual(t, "db.internal.example.com", host)
+	assert.Len(t, host, len("db.internal.example.com"))
+	assert.Equal(t, 3, strings.Count(host, "."))
+
+	tag := values["image"].(map[string]interface{})["tag"].(string)
+	assert.Regexp(t, `^[A-Z][0-9]\.[0-9]\.[0-9]-[a-z]{2}[0-9]$`, tag)
+
+	args := values["args"].([]interface{})
+	assert.True(t, strings.HasPrefix(args[0].(string), "--"))
+
+	// Comments may contain sensitive information
+	assert.NotContains(t, string(output), "Internal hostname")
+}
+
+func TestAnonymize_Deterministic(t *testing.T) {
+	input := []byte("a: secret-value\nb: secret-value\n")
+
+	a, err := NewAnonymizer(Options{Seed: "test"})
+	require.NoError(t, err)
+	first, err := a.Anonymize(input)
+	require.NoError(t, err)
+	second, err := a.Anonymize(input)
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+
+	// Equal values map to equal replacements
+	values := decode(t, first)
+	assert.Equal(t, values["a"], values["b"])
+
+	other, err := NewAnonymizer(Options{Seed: "other"})
+	require.NoError(t, err)
+	third, err := other.Anonymize(input)
+	require.NoError(t, err)
+	assert.NotEqual(t, string(first), string(third))
+}
+
+func TestAnonymize_Secrets(t *testing.T) {
+	a, err := NewAnonymizer(Options{
+		Seed:        "test",
+		SecretPaths: map[string]bool{"auth.password": true, "env[].value": true},
+	})
+	require.NoError(t, err)
+
+	output, err := a.Anonymize([]byte(`auth:
+  username: admin
+  password: hunter2
+env:
+- name: TOKEN
+  value: abc123
+`))
+	require.NoError(t, err)
+	values := decode(t, output)
+
+	auth := values["auth"].(map[string]interface{})
+	assert.Equal(t, "*******", auth["password"])
+	assert.NotEqual(t, "admin", auth["username"])
+
+	env := values["env"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "******", env["value"])
+}
+
+// TestAnonymize_SecretSubtrees tests that every value at or under a secret
+// path is masked, not only strings at the exact paths of the example values
+func TestAnonymize_SecretSubtrees(t *testing.T) {
+	example := []byte(`# +miaka:secret
+pin: 1234
+# +miaka:secret
+secrets: {}
+`)
+	secretPaths, err := FindSecretPaths(example)
+	require.NoError(t, err)
+	a, err := NewAnonymizer(Options{Seed: "test", SecretPaths: secretPaths})
+	require.NoError(t, err)
+
+	output, err := a.Anonymize([]byte(`pin: 987654
+secrets:
+  db: hunter2-password
+  tls:
+    enabled: true
+    keys: [abc]
+port: 8080
+`))
+	require.NoError(t, err)
+	assert.NotContains(t, string(output), "987654")
+	assert.Equal(t, map[string]interface{}{
+		"pin": "******",
+		"secrets": map[string]interface{}{
+			"db":  "****************",
+			"tls": map[string]interface{}{"enabled": "****", "keys": []interface{}{"***"}},
+		},
+		"port": 8080,
+	}, decode(t, output))
+}
+
+func TestIsSecret(t *testing.T) {
+	secretPaths := map[string]bool{"auth.password": true, "secrets": true, "env[].value": true}
+	for path, want := range map[string]bool{
+		"auth.password":     true,
+		"auth.username":     false,
+		"auth":              false,
+		"secrets":           true,
+		"secrets.db":        true,
+		"secrets.tls[].key": true,
+		"secretsExtra":      false,
+		"env[].value":       true,
+		"env[].name":        false,
+		"":                  false,
+	} {
+		assert.Equal(t, want, IsSecret(secretPaths, path), path)
+	}
+}
+
+func TestAnonymize_Schema(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"serviceType": {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"ClusterIP"`)}}},
+			"cpu":         {XIntOrString: true},
+			"created":     {Type: "string", Format: "date-time"},
+			"name":        {Type: "string", Pattern: `^app-[0-9]+$`},
+			"labels": {
+				Type: "object",
+				AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+					Schema: &apiextensionsv1.JSONSchemaProps{Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"prod"`)}}},
+				},
+			},
+		},
+	}
+
+	a, err := NewAnonymizer(Options{Schema: schema, Seed: "test"})
+	require.NoError(t, err)
+
+	output, err := a.Anonymize([]byte(`serviceType: ClusterIP
+cpu: 500m
+created: "2024-01-01T00:00:00Z"
+name: app-42
+labels:
+  env: prod
+`))
+	require.NoError(t, err)
+	values := decode(t, output)
+
+	assert.Equal(t, "ClusterIP", values["serviceType"])
+	assert.Regexp(t, `^[0-9]{3}m$`, values["cpu"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", values["created"])
+	assert.Regexp(t, `^app-[0-9]{2}$`, values["name"])
+	assert.Equal(t, "prod", values["labels"].(map[string]interface{})["env"])
+}
+
+func TestAnonymizeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a: b\n"), 0644))
+
+	a, err := NewAnonymizer(Options{})
+	require.NoError(t, err)
+
+	output, err := a.AnonymizeFile(path)
+	require.NoError(t, err)
+	assert.Regexp(t, `^a: [a-z]\n$`, string(output))
+
+	_, err = a.AnonymizeFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestFindSecretPaths(t *testing.T) {
+	example := `apiVersion: example.com/v1
+kind: MyApp
+auth:
+  username: admin
+  # Admin password
+  # +miaka:secret
+  password: changeme
+# +miaka:secret
+tls:
+  cert: abc
+  key: def
+env:
+- name: TOKEN
+  # +miaka:secret
+  value: xyz
+apiKey: xyz # +miaka:secret
+`
+	paths, err := FindSecretPaths([]byte(example))
+	require.NoError(t, err)
+
+	assert.True(t, paths["auth.password"])
+	assert.True(t, paths["tls"])
+	assert.True(t, paths["tls.cert"])
+	assert.True(t, paths["tls.key"])
+	assert.True(t, paths["env[].value"])
+	assert.True(t, paths["apiKey"])
+	assert.False(t, paths["auth.username"])
+
+	_, err = FindSecretPaths([]byte("a: [b"))
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package anonymize
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FindSecretPathsInFile reads an example values file and returns the paths of
+// fields marked with +miaka:secret
+func FindSecretPathsInFile(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read example values file: %w", err)
+	}
+	return FindSecretPaths(data)
+}
+
+// FindSecretPaths returns the paths of fields marked with +miaka:secret in
+// example values content. Paths are dotted; list items are written as "[]"
+// (e.g., "env[].value").
+func FindSecretPaths(data []byte) (map[string]bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	paths := make(map[string]bool)
+	if len(doc.Content) > 0 {
+		collectSecretPaths(doc.Content[0], "", paths)
+	}
+	return paths, nil
+}
+
+// collectSecretPaths walks a node and records the paths of marked fields
+func collectSecretPaths(node *yaml.Node, path string, paths map[string]bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			fieldPath := joinPath(path, keyNode.Value)
+			if hasSecretMarker(keyNode.HeadComment + "\n" + keyNode.LineComment + "\n" + valueNode.LineComment) {
+				markSecret(valueNode, fieldPath, paths)
+			}
+			collectSecretPaths(valueNode, fieldPath, paths)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			collectSecretPaths(item, path+"[]", paths)
+		}
+	}
+}
+
+// IsSecret reports whether the value at a path is secret: at or under one of
+// secretPaths, whatever its type. Values under a secret path, like the keys of
+// a secret map, needn't be in the example values to be secret.
+func IsSecret(secretPaths map[string]bool, path string) bool {
+	for path != "" {
+		if secretPaths[path] {
+			return true
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return false
+}
+
+// markSecret marks a field and, for objects and lists, every value beneath it
+func markSecret(node *yaml.Node, path string, paths map[string]bool) {
+	paths[path] = true
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			markSecret(node.Content[i+1], joinPath(path, node.Content[i].Value), paths)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			markSecret(item, path+"[]", paths)
+		}
+	}
+}
+
+// hasSecretMarker reports whether a head comment contains the +miaka:secret marker
+func hasSecretMarker(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if line == SecretMarker {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package generation
+
+import (
+	"encoding/json"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+)
+
+// cachedEmitter stores the files of an emitter in a cache
+type cachedEmitter struct {
+	Emitter
+	cache *cache.Cache
+	salt  string
+}
+
+// Cached wraps an emitter so that its files are stored in c, keyed by the
+// schema, the emitter's name, and salt, which must identify everything else the
+// files depend on (e.g., the miaka version and the emitter's options). Emitting
+// a schema that was emitted before returns the stored files without generating
+// them. Errors aren't stored, and a cache that can't be read or written is
+// skipped.
+func Cached(e Emitter, c *cache.Cache, salt string) Emitter {
+	return &cachedEmitter{Emitter: e, cache: c, salt: salt}
+}
+
+func (c *cachedEmitter) Emit(s schema.Schema) ([]OutputFile, error) {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return c.Emitter.Emit(s)
+	}
+	key := cache.Key([]byte("emit"), []byte(c.salt), []byte(c.Name()), encoded)
+	if data, ok := c.cache.Get(key); ok {
+		var files []OutputFile
+		if json.Unmarshal(data, &files) == nil {
+			return files, nil
+		}
+	}
+
+	files, err := c.Emitter.Emit(s)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(files); err == nil {
+		_ = c.cache.Put(key, data)
+	}
+	return files, nil
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gobuffalo/flect"
+	"golang.org/x/tools/go/packages"
 	"sigs.k8s.io/controller-tools/pkg/crd"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/markers"
@@ -20,6 +22,13 @@ var embeddedGoMod string
 //go:embed embedded/gosum.txt
 var embeddedGoSum string
 
+// GoModule returns the go.mod and go.sum of a module that depends on
+// k8s.io/apimachinery, the only dependency of the generated types
+func GoModule(modulePath string) (goMod, goSum []byte) {
+	goMod = []byte(strings.Replace(embeddedGoMod, "module test", "module "+modulePath, 1))
+	return goMod, []byte(embeddedGoSum)
+}
+
 // Options contains configuration for CRD generation
 type Options struct {
 	// Group is the API group (e.g., "example.com")
@@ -34,6 +43,11 @@ type Options struct {
 	// OutputFileName is the name of the output CRD file
 	// If empty, defaults to <group>_<version>_<kind>.yaml
 	OutputFileName string
+
+	// WorkDir is the directory of the intermediate files, each generation in its
+	// own subdirectory. The files of failed generations are kept there for
+	// debugging. If empty, the system temp directory is used and nothing is kept.
+	WorkDir string
 }
 
 // Generator generates CRDs using controller-gen as a library
@@ -52,7 +66,8 @@ func NewGenerator(opts Options) *Generator {
 // All intermediate files are created in a temporary directory to avoid polluting the user's filesystem
 func (g *Generator) Generate(typesFile string, outputDir string) error {
 	// Validate inputs
-	if _, err := os.Stat(typesFile); err != nil {
+	typesCode, err := os.ReadFile(typesFile)
+	if err != nil {
 		return fmt.Errorf("types file not found: %w", err)
 	}
 
@@ -61,29 +76,67 @@ func (g *Generator) Generate(typesFile string, outputDir string) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	crdContent, err := g.GenerateContent(typesCode)
+	if err != nil {
+		return err
+	}
+
+	// Determine final output filename
+	finalFileName := g.opts.OutputFileName
+	if finalFileName == "" {
+		// Use the controller-gen filename
+		finalFileName = crdFileName(g.opts.Group, g.opts.Kind)
+	}
+
+	// Write the generated CRD to the user's output directory
+	if err := os.WriteFile(filepath.Join(outputDir, finalFileName), crdContent, 0644); err != nil {
+		return fmt.Errorf("failed to write CRD to output directory: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateContent creates a CRD from Go types source code and returns its YAML content
+// All intermediate files are created in a temporary directory (see Options.WorkDir)
+func (g *Generator) GenerateContent(typesCode []byte) ([]byte, error) {
+	if g.opts.WorkDir != "" {
+		if err := os.MkdirAll(g.opts.WorkDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create work directory: %w", err)
+		}
+	}
 	// Create temporary directory for all intermediate files
-	tmpDir, err := os.MkdirTemp("", "crdgen-*")
+	tmpDir, err := os.MkdirTemp(g.opts.WorkDir, "crdgen-*")
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
-	defer os.RemoveAll(tmpDir)
 
-	// Copy types.go to temp directory
+	content, err := g.generateIn(tmpDir, typesCode)
+	if err != nil && g.opts.WorkDir != "" {
+		return nil, fmt.Errorf("%w (intermediate files kept in %s)", err, tmpDir)
+	}
+	os.RemoveAll(tmpDir)
+	return content, err
+}
+
+// generateIn runs controller-gen on Go types source code in tmpDir
+func (g *Generator) generateIn(tmpDir string, typesCode []byte) ([]byte, error) {
+
+	// Write types.go to temp directory
 	tmpTypesFile := filepath.Join(tmpDir, "types.go")
-	if err := copyFile(typesFile, tmpTypesFile); err != nil {
-		return fmt.Errorf("failed to copy types file: %w", err)
+	if err := os.WriteFile(tmpTypesFile, typesCode, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write types file: %w", err)
 	}
 
 	// Write embedded go.mod to temp directory
 	goModPath := filepath.Join(tmpDir, "go.mod")
 	if err := os.WriteFile(goModPath, []byte(embeddedGoMod), 0644); err != nil {
-		return fmt.Errorf("failed to create go.mod: %w", err)
+		return nil, fmt.Errorf("failed to create go.mod: %w", err)
 	}
 
 	// Write embedded go.sum to temp directory
 	goSumPath := filepath.Join(tmpDir, "go.sum")
 	if err := os.WriteFile(goSumPath, []byte(embeddedGoSum), 0644); err != nil {
-		return fmt.Errorf("failed to create go.sum: %w", err)
+		return nil, fmt.Errorf("failed to create go.sum: %w", err)
 	}
 
 	// Create doc.go with package-level markers in temp directory
@@ -93,21 +146,23 @@ func (g *Generator) Generate(typesFile string, outputDir string) error {
 package %s
 `, g.opts.Group, g.opts.Version)
 	if err := os.WriteFile(docGoPath, []byte(docGoContent), 0644); err != nil {
-		return fmt.Errorf("failed to create doc.go: %w", err)
+		return nil, fmt.Errorf("failed to create doc.go: %w", err)
 	}
 
 	// Create a subdirectory in temp for controller-gen output
 	tmpOutputDir := filepath.Join(tmpDir, "output")
 	if err := os.MkdirAll(tmpOutputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create temp output directory: %w", err)
+		return nil, fmt.Errorf("failed to create temp output directory: %w", err)
 	}
 
 	// Use the genall framework like controller-gen CLI does
 	// Build the options as if they were command-line arguments
+	// allowDangerousTypes permits the float fields of fractional values. Paths are
+	// quoted, since the option syntax splits Windows paths at the drive colon.
 	options := []string{
-		"crd:crdVersions=v1",
-		"paths=" + tmpDir,
-		"output:crd:dir=" + tmpOutputDir,
+		"crd:crdVersions=v1,allowDangerousTypes=true",
+		"paths=" + strconv.Quote(tmpDir),
+		"output:crd:dir=" + strconv.Quote(tmpOutputDir),
 	}
 
 	// Create options registry (same as controller-gen)
@@ -116,79 +171,64 @@ package %s
 	// Register the CRD generator as a marker
 	crdGenDef := markers.Must(markers.MakeDefinition("crd", markers.DescribesPackage, crd.Generator{}))
 	if err := optionsRegistry.Register(crdGenDef); err != nil {
-		return fmt.Errorf("failed to register crd generator: %w", err)
+		return nil, fmt.Errorf("failed to register crd generator: %w", err)
 	}
 
 	// Register output rules
 	outputDirRule := markers.Must(markers.MakeDefinition("output:crd:dir", markers.DescribesPackage, genall.OutputToDirectory("")))
 	if err := optionsRegistry.Register(outputDirRule); err != nil {
-		return fmt.Errorf("failed to register output rule: %w", err)
+		return nil, fmt.Errorf("failed to register output rule: %w", err)
 	}
 
 	// Register common options (paths, etc)
 	if err := genall.RegisterOptionsMarkers(optionsRegistry); err != nil {
-		return fmt.Errorf("failed to register options markers: %w", err)
+		return nil, fmt.Errorf("failed to register options markers: %w", err)
 	}
 
-	// Create runtime from options (like controller-gen does)
-	rt, err := genall.FromOptions(optionsRegistry, options)
+	// Create runtime from options (like controller-gen does), loading the types
+	// with a go command that never downloads a toolchain (see goEnv)
+	rt, err := genall.FromOptionsWithConfig(&packages.Config{Env: goEnv()}, optionsRegistry, options)
 	if err != nil {
-		return fmt.Errorf("failed to create runtime from options: %w", err)
+		return nil, fmt.Errorf("failed to create runtime from options: %w", err)
 	}
 
 	// Run the generators
 	if hadErrs := rt.Run(); hadErrs {
-		return fmt.Errorf("CRD generation failed - controller-gen encountered errors processing the generated types (run with --keep-types to inspect the generated code)")
+		return nil, fmt.Errorf("CRD generation failed - controller-gen encountered errors processing the generated types (run with --types to inspect the generated code)")
 	}
 
 	// Find the generated CRD file in temp output directory
 	generatedCRDPath, err := findCRDFile(tmpOutputDir, g.opts.Group, g.opts.Kind)
 	if err != nil {
-		return fmt.Errorf("failed to find generated CRD: %w", err)
-	}
-
-	// Determine final output filename
-	finalFileName := g.opts.OutputFileName
-	if finalFileName == "" {
-		// Use the generated filename
-		finalFileName = filepath.Base(generatedCRDPath)
-	}
-	finalOutputPath := filepath.Join(outputDir, finalFileName)
-
-	// Copy the generated CRD to the user's output directory
-	if err := copyFile(generatedCRDPath, finalOutputPath); err != nil {
-		return fmt.Errorf("failed to copy CRD to output directory: %w", err)
+		return nil, fmt.Errorf("failed to find generated CRD: %w", err)
 	}
 
-	return nil
-}
-
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
+	crdContent, err := os.ReadFile(generatedCRDPath)
 	if err != nil {
-		return fmt.Errorf("failed to read source file: %w", err)
-	}
-
-	if err := os.WriteFile(dst, data, 0644); err != nil {
-		return fmt.Errorf("failed to write destination file: %w", err)
+		return nil, fmt.Errorf("failed to read generated CRD: %w", err)
 	}
 
-	return nil
+	return stampVersion(crdContent), nil
 }
 
-// findCRDFile finds the generated CRD file in the output directory.
-// Controller-gen generates files with naming convention: <group>_<plural>.yaml
+// crdFileName returns the name controller-gen uses for a CRD file: <group>_<plural>.yaml
 // Uses flect for proper English pluralization (e.g., "demo" -> "demoes")
-func findCRDFile(outputDir, group, kind string) (string, error) {
-	// Use flect to get the proper plural form
+func crdFileName(group, kind string) string {
 	plural := flect.Pluralize(strings.ToLower(kind))
-	expectedName := fmt.Sprintf("%s_%s.yaml", group, plural)
-	expectedPath := filepath.Join(outputDir, expectedName)
+	return fmt.Sprintf("%s_%s.yaml", group, plural)
+}
 
-	if _, err := os.Stat(expectedPath); err != nil {
-		return "", fmt.Errorf("no CRD file found at %s (controller-gen generates files as <group>_<plural>.yaml)", expectedPath)
+// findCRDFile finds the generated CRD file in the output directory. The plural in
+// its name may differ from the kind's (e.g., with +kubebuilder:resource:path).
+func findCRDFile(outputDir, group, kind string) (string, error) {
+	expectedPath := filepath.Join(outputDir, crdFileName(group, kind))
+	if _, err := os.Stat(expectedPath); err == nil {
+		return expectedPath, nil
 	}
 
-	return expectedPath, nil
+	matches, err := filepath.Glob(filepath.Join(outputDir, group+"_*.yaml"))
+	if err != nil || len(matches) != 1 {
+		return "", fmt.Errorf("no CRD file found at %s (controller-gen generates files as <group>_<plural>.yaml)", expectedPath)
+	}
+	return matches[0], nil
 }
@@ -3,8 +3,12 @@ package crd
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -86,6 +90,54 @@ type ExampleSpec struct {
 	}
 }
 
+// TestGenerator_GenerateContent_WorkDir tests that intermediate files go to the
+// work directory, and are kept there only when generation fails
+func TestGenerator_GenerateContent_WorkDir(t *testing.T) {
+	workDir := filepath.Join(t.TempDir(), "work")
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example", WorkDir: workDir})
+
+	s, err := parsing.NewParser().Parse([]byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"))
+	require.NoError(t, err)
+	typesCode, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+
+	_, err = gen.GenerateContent(typesCode)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(workDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "intermediate files of successful generations should be removed")
+
+	invalid := strings.Replace(string(typesCode), "int `json", "interface{} `json", 1)
+	require.NotEqual(t, string(typesCode), invalid)
+	_, err = gen.GenerateContent([]byte(invalid))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "intermediate files kept in "+workDir)
+	entries, err = os.ReadDir(workDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.FileExists(t, filepath.Join(workDir, entries[0].Name(), "types.go"))
+}
+
+// TestGenerator_GenerateContent_PathCharacters tests temp paths with the
+// characters of Windows paths (drive letters and backslashes), which are
+// quoted for controller-gen
+func TestGenerator_GenerateContent_PathCharacters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows paths can't hold these characters in file names")
+	}
+	workDir := filepath.Join(t.TempDir(), `C:\Users\dev,work`)
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example", WorkDir: workDir})
+
+	s, err := parsing.NewParser().Parse([]byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"))
+	require.NoError(t, err)
+	typesCode, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+
+	content, err := gen.GenerateContent(typesCode)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "replicas:")
+}
+
 // TestOptions tests the Options struct
 func TestOptions(t *testing.T) {
 	opts := Options{
@@ -160,3 +212,13 @@ type MyAppSpec struct {
 	expectedName := "myapp.io_myapps.yaml"
 	assert.Equal(t, expectedName, generatedFile)
 }
+
+// TestGoModule tests that the module has the given path and requires apimachinery
+func TestGoModule(t *testing.T) {
+	goMod, goSum := GoModule("example.com/operator")
+
+	assert.Contains(t, string(goMod), "module example.com/operator\n")
+	assert.Contains(t, string(goMod), "require k8s.io/apimachinery ")
+	assert.NotContains(t, string(goMod), "module test")
+	assert.Contains(t, string(goSum), "k8s.io/apimachinery ")
+}
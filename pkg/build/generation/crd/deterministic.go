@@ -0,0 +1,102 @@
+package crd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// VolatileAnnotations are CRD annotations whose values depend on the build
+// environment rather than the input
+var VolatileAnnotations = []string{"controller-gen.kubebuilder.io/version"}
+
+// Normalize makes CRD content depend only on its schema: volatile annotations
+// are removed, required lists are sorted, and the CRD is re-marshaled with
+// sorted keys
+func Normalize(data []byte) ([]byte, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	for _, annotation := range VolatileAnnotations {
+		delete(crd.Annotations, annotation)
+	}
+	if len(crd.Annotations) == 0 {
+		crd.Annotations = nil
+	}
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Schema != nil {
+			sortRequired(crd.Spec.Versions[i].Schema.OpenAPIV3Schema)
+		}
+	}
+
+	output, err := yaml.Marshal(&crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return output, nil
+}
+
+// sortRequired recursively sorts the required lists of a schema
+func sortRequired(props *apiextensionsv1.JSONSchemaProps) {
+	if props == nil {
+		return
+	}
+	sort.Strings(props.Required)
+
+	for name, prop := range props.Properties {
+		sortRequired(&prop)
+		props.Properties[name] = prop
+	}
+	if props.Items != nil {
+		sortRequired(props.Items.Schema)
+		for i := range props.Items.JSONSchemas {
+			sortRequired(&props.Items.JSONSchemas[i])
+		}
+	}
+	if props.AdditionalProperties != nil {
+		sortRequired(props.AdditionalProperties.Schema)
+	}
+	for _, list := range [][]apiextensionsv1.JSONSchemaProps{props.AllOf, props.AnyOf, props.OneOf} {
+		for i := range list {
+			sortRequired(&list[i])
+		}
+	}
+}
+
+// deterministicEmitter normalizes the output of a CRD emitter
+type deterministicEmitter struct {
+	generation.Emitter
+}
+
+// Deterministic wraps a CRD emitter so that its output is normalized (see
+// Normalize), making it byte-identical for identical input in any environment
+func Deterministic(e generation.Emitter) generation.Emitter {
+	return &deterministicEmitter{Emitter: e}
+}
+
+func (d *deterministicEmitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	files, err := d.Emitter.Emit(s)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]generation.OutputFile, len(files))
+	for i, file := range files {
+		content, err := Normalize(file.Content)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = OrderProperties(content, propertyOrder(s)); err != nil {
+			return nil, err
+		}
+		normalized[i] = file
+		normalized[i].Content = content
+	}
+	return normalized, nil
+}
@@ -0,0 +1,96 @@
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	input := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.19.0
+    example.com/owner: platform
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        required: [zeta, alpha]
+        properties:
+          zeta:
+            type: string
+          alpha:
+            type: object
+            required: [b, a]
+            properties:
+              b:
+                type: string
+              a:
+                type: string
+`
+	output, err := Normalize([]byte(input))
+	require.NoError(t, err)
+
+	content := string(output)
+	assert.NotContains(t, content, "controller-gen.kubebuilder.io/version")
+	assert.Contains(t, content, "example.com/owner: platform")
+	assert.Contains(t, content, "required:\n            - a\n            - b\n")
+	assert.Contains(t, content, "required:\n        - alpha\n        - zeta\n")
+	assert.Less(t, strings.Index(content, "alpha:"), strings.Index(content, "zeta:"))
+
+	// Normalizing is idempotent
+	again, err := Normalize(output)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(again))
+}
+
+func TestNormalize_RemovesEmptyAnnotations(t *testing.T) {
+	output, err := Normalize([]byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  annotations:\n    controller-gen.kubebuilder.io/version: (devel)\n  name: x\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(output), "annotations")
+}
+
+func TestDeterministic(t *testing.T) {
+	s := schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "Example",
+		Package:    "v1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{Name: "Replicas", JSONName: "replicas", Type: "int", Comments: []string{"+kubebuilder:validation:Minimum=1"}},
+					{Name: "Labels", JSONName: "labels", Type: "map[string]string"},
+				},
+			},
+		},
+	}
+
+	e := Deterministic(NewEmitter())
+	assert.Equal(t, TargetName, e.Name())
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "crd.yaml", files[0].Name)
+	assert.NotContains(t, string(files[0].Content), "controller-gen.kubebuilder.io/version")
+
+	// Both backends generate the same bytes
+	inMemory, err := Deterministic(NewInMemoryEmitter()).Emit(s)
+	require.NoError(t, err)
+	assert.Equal(t, string(files[0].Content), string(inMemory[0].Content))
+}
@@ -0,0 +1,128 @@
+package crd
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/logging"
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TargetName is the output target name of the CRD emitter
+const TargetName = "crd"
+
+// Emitter generates a CRD with strict validation from the schema.
+// Go types are generated in memory and passed to controller-gen. Without a go
+// command that can load them (see FindGoCommand), the CRD is generated in
+// memory instead, like NewInMemoryEmitter's, with a warning.
+type Emitter struct {
+	// Strict is the strict validation mode; the default is StrictOn
+	Strict StrictMode
+	// Resource overrides the CRD's names and scope
+	Resource Resource
+	// WorkDir is the directory of controller-gen's intermediate files (see
+	// Options.WorkDir). In-memory emitters write none.
+	WorkDir string
+	// Log receives the warning of generating the CRD in memory without a go
+	// command (default: discarded)
+	Log logging.Logger
+
+	inMemory bool
+	// findGo finds the go command; nil is FindGoCommand
+	findGo func() (GoCommand, error)
+}
+
+// NewEmitter creates a new CRD emitter
+func NewEmitter() *Emitter {
+	return &Emitter{}
+}
+
+// NewInMemoryEmitter creates a CRD emitter that never touches the filesystem
+// (see Generator.GenerateContentInMemory), for read-only and hermetic environments
+func NewInMemoryEmitter() *Emitter {
+	return &Emitter{inMemory: true}
+}
+
+// goCommandErr returns why controller-gen can't load the generated types, if it can't
+func (e *Emitter) goCommandErr() error {
+	findGo := e.findGo
+	if findGo == nil {
+		findGo = FindGoCommand
+	}
+	_, err := findGo()
+	return err
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates crd.yaml from the schema
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	// Parse apiVersion using Kubernetes libraries
+	gv, err := runtimeschema.ParseGroupVersion(s.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion format: %s: %w", s.APIVersion, err)
+	}
+
+	// Plain values are generated as KRM types, since controller-gen needs a root object
+	s.Plain = false
+	s.Package = gv.Version
+
+	typesCode, err := gotypes.NewGenerator(&s).Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Go code: %w", err)
+	}
+
+	gen := NewGenerator(Options{
+		Group:   gv.Group,
+		Version: gv.Version,
+		Kind:    s.Kind,
+		WorkDir: e.WorkDir,
+	})
+	generate := gen.GenerateContent
+	if e.inMemory {
+		generate = gen.GenerateContentInMemory
+	} else if err := e.goCommandErr(); err != nil {
+		if e.Log != nil {
+			e.Log.Warnf("⚠️  Generating the CRD in memory, since controller-gen can't load the generated types: %v", err)
+		}
+		generate = gen.GenerateContentInMemory
+	}
+	content, err := generate(typesCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add strict validation to CRD (additionalProperties: false)
+	content, err = ApplyStrictMode(content, e.Strict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add strict validation to CRD: %w", err)
+	}
+
+	content, err = ApplyResource(content, e.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set CRD names: %w", err)
+	}
+
+	if s.WrapSpec {
+		if content, err = annotateWrapSpec(content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Order the properties like the example values, since marshaling sorts them
+	if content, err = OrderProperties(content, propertyOrder(s)); err != nil {
+		return nil, err
+	}
+
+	// Validate the generated CRD itself
+	if err := ValidateCRDContent(content); err != nil {
+		return nil, fmt.Errorf("generated CRD is invalid: %w", err)
+	}
+
+	return []generation.OutputFile{{Name: "crd.yaml", Content: content}}, nil
+}
@@ -0,0 +1,44 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitter_Emit(t *testing.T) {
+	s := schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "Example",
+		Package:    "v1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{Name: "Replicas", JSONName: "replicas", Type: "int", Comments: []string{"+kubebuilder:validation:Minimum=1"}},
+					{Name: "Labels", JSONName: "labels", Type: "map[string]string"},
+				},
+			},
+		},
+	}
+
+	e := NewEmitter()
+	assert.Equal(t, TargetName, e.Name())
+
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "crd.yaml", files[0].Name)
+
+	content := string(files[0].Content)
+	assert.Contains(t, content, "name: examples.example.com")
+	assert.Contains(t, content, "minimum: 1")
+	assert.NoError(t, ValidateCRDContent(files[0].Content))
+}
+
+func TestEmitter_InvalidAPIVersion(t *testing.T) {
+	_, err := NewEmitter().Emit(schema.Schema{APIVersion: "a/b/c", Kind: "Example"})
+	require.ErrorContains(t, err, "invalid apiVersion")
+}
@@ -0,0 +1,521 @@
+package crd
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	crdmarkers "sigs.k8s.io/controller-tools/pkg/crd/markers"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+	"sigs.k8s.io/yaml"
+)
+
+// Descriptions of the TypeMeta fields, as documented on metav1.TypeMeta
+const (
+	apiVersionDescription = `APIVersion defines the versioned schema of this representation of an object.
+Servers should convert recognized schemas to the latest internal value, and
+may reject unrecognized values.
+More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources`
+	kindDescription = `Kind is a string value representing the REST resource this object represents.
+Servers may infer this from the endpoint the client submits requests to.
+Cannot be updated.
+In CamelCase.
+More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds`
+)
+
+// applyFirstMarker mirrors controller-gen's legacy priority interface for schema markers
+type applyFirstMarker interface {
+	ApplyFirst()
+}
+
+// GenerateContentInMemory creates a CRD from Go types source code without touching the filesystem.
+//
+// Unlike GenerateContent, it doesn't load packages with controller-gen (which requires a
+// temporary Go module and the go command). The types are parsed in memory and converted
+// with controller-gen's marker definitions, producing the same CRD for the types generated
+// by miaka. Only builtin types, types declared in typesCode, slices, maps, the metav1
+// TypeMeta and ObjectMeta embeds, and the semantic types (resource.Quantity,
+// metav1.Duration, and intstr.IntOrString) are supported.
+func (g *Generator) GenerateContentInMemory(typesCode []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "types.go", typesCode, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse types: %w", err)
+	}
+
+	registry := &markers.Registry{}
+	if err := crdmarkers.Register(registry); err != nil {
+		return nil, fmt.Errorf("failed to register CRD markers: %w", err)
+	}
+
+	c := &schemaConverter{
+		registry: registry,
+		types:    make(map[string]typeDecl),
+		visiting: make(map[string]bool),
+	}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && genDecl.Lparen == token.NoPos {
+				doc = genDecl.Doc
+			}
+			c.types[typeSpec.Name.Name] = typeDecl{spec: typeSpec, doc: doc}
+		}
+	}
+
+	root, ok := c.types[g.opts.Kind]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in generated types", g.opts.Kind)
+	}
+	rootSchema, err := c.namedSchema(g.opts.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate schema for %s: %w", g.opts.Kind, err)
+	}
+	if _, ok := rootSchema.Properties["metadata"]; ok {
+		rootSchema.Properties["metadata"] = apiextensionsv1.JSONSchemaProps{Type: "object"}
+	}
+
+	plural := strings.ToLower(flect.Pluralize(g.opts.Kind))
+	result := apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"controller-gen.kubebuilder.io/version": ControllerGenVersion(),
+			},
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: g.opts.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     g.opts.Kind,
+				ListKind: g.opts.Kind + "List",
+				Plural:   plural,
+				Singular: strings.ToLower(g.opts.Kind),
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name:    g.opts.Version,
+				Served:  true,
+				Storage: true,
+				Schema:  &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: rootSchema},
+			}},
+		},
+	}
+
+	// Apply CRD-level markers on the root type (e.g., +kubebuilder:resource:scope=Cluster)
+	rootMarkers, err := c.parseMarkers(root.doc, markers.DescribesType)
+	if err != nil {
+		return nil, err
+	}
+	for _, vals := range rootMarkers {
+		for _, val := range vals {
+			switch m := val.(type) {
+			case crd.SpecMarker:
+				err = m.ApplyToCRD(&result.Spec, g.opts.Version)
+			case crd.Marker:
+				err = m.ApplyToCRD(&result, g.opts.Version)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply marker to %s: %w", g.opts.Kind, err)
+			}
+		}
+	}
+
+	// Like controller-gen, name the CRD after the plural, which markers may set
+	result.Name = result.Spec.Names.Plural + "." + g.opts.Group
+
+	content, err := yaml.Marshal(&result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return content, nil
+}
+
+// typeDecl is a type declared in the generated types
+type typeDecl struct {
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+}
+
+// schemaConverter converts parsed Go types to OpenAPI v3 schemas the way controller-gen does
+type schemaConverter struct {
+	registry *markers.Registry
+	types    map[string]typeDecl
+	visiting map[string]bool
+}
+
+// namedSchema returns the schema for a type declared in the generated types,
+// including its description and type-level markers
+func (c *schemaConverter) namedSchema(name string) (*apiextensionsv1.JSONSchemaProps, error) {
+	decl := c.types[name]
+	if c.visiting[name] {
+		return nil, fmt.Errorf("recursive type %s is not supported", name)
+	}
+	c.visiting[name] = true
+	defer delete(c.visiting, name)
+
+	var props *apiextensionsv1.JSONSchemaProps
+	var err error
+	if structType, ok := decl.spec.Type.(*ast.StructType); ok {
+		props, err = c.structSchema(name, structType)
+	} else {
+		props, err = c.typeSchema(decl.spec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	props.Description = extractDoc(decl.doc)
+
+	typeMarkers, err := c.parseMarkers(decl.doc, markers.DescribesType)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMarkers(typeMarkers, props); err != nil {
+		return nil, fmt.Errorf("type %s: %w", name, err)
+	}
+	return props, nil
+}
+
+// structSchema returns the schema for a struct's fields
+func (c *schemaConverter) structSchema(name string, structType *ast.StructType) (*apiextensionsv1.JSONSchemaProps, error) {
+	props := &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: make(map[string]apiextensionsv1.JSONSchemaProps),
+	}
+
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			return nil, fmt.Errorf("struct field in type %s has no JSON tag", name)
+		}
+		tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		jsonTag, ok := tag.Lookup("json")
+		if !ok {
+			return nil, fmt.Errorf("struct field in type %s has no JSON tag", name)
+		}
+		jsonOpts := strings.Split(jsonTag, ",")
+		if len(jsonOpts) == 1 && jsonOpts[0] == "-" {
+			continue
+		}
+		fieldName := jsonOpts[0]
+		inline, omitEmpty := fieldName == "", false
+		for _, opt := range jsonOpts[1:] {
+			switch opt {
+			case "inline":
+				inline = true
+			case "omitempty":
+				omitEmpty = true
+			}
+		}
+
+		fieldMarkers, err := c.parseMarkers(field.Doc, markers.DescribesField)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case fieldMarkers["kubebuilder:validation:Optional"] != nil, fieldMarkers["optional"] != nil:
+		case fieldMarkers["kubebuilder:validation:Required"] != nil, fieldMarkers["required"] != nil:
+			props.Required = append(props.Required, fieldName)
+		case !inline && !omitEmpty:
+			props.Required = append(props.Required, fieldName)
+		}
+
+		var propSchema *apiextensionsv1.JSONSchemaProps
+		if fieldMarkers[crdmarkers.SchemalessName] != nil {
+			propSchema = &apiextensionsv1.JSONSchemaProps{}
+		} else {
+			propSchema, err = c.typeSchema(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", name, fieldName, err)
+			}
+		}
+		// Field docs replace the description of the referenced type, if any
+		if doc := extractDoc(field.Doc); doc != "" || !c.isNamedType(field.Type) {
+			propSchema.Description = doc
+		}
+		if err := applyMarkers(fieldMarkers, propSchema); err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", name, fieldName, err)
+		}
+
+		if inline {
+			for key, value := range propSchema.Properties {
+				props.Properties[key] = value
+			}
+			props.Required = append(props.Required, propSchema.Required...)
+			continue
+		}
+		props.Properties[fieldName] = *propSchema
+	}
+
+	return props, nil
+}
+
+// typeSchema returns the schema for a type expression
+func (c *schemaConverter) typeSchema(expr ast.Expr) (*apiextensionsv1.JSONSchemaProps, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if _, ok := c.types[t.Name]; ok {
+			return c.namedSchema(t.Name)
+		}
+		return builtinSchema(t.Name)
+	case *ast.SelectorExpr:
+		return knownTypeSchema(t)
+	case *ast.StarExpr:
+		return c.typeSchema(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" && t.Len == nil {
+			// byte slices are represented as base64-encoded strings
+			return &apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte"}, nil
+		}
+		items, err := c.typeSchema(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: items},
+		}, nil
+	case *ast.MapType:
+		if key, ok := t.Key.(*ast.Ident); !ok || key.Name != "string" {
+			return nil, fmt.Errorf("map keys must be strings")
+		}
+		values, err := c.typeSchema(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &apiextensionsv1.JSONSchemaProps{
+			Type:                 "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: values, Allows: true},
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
+
+// isNamedType reports whether a type expression refers to a declared type
+// (possibly through a pointer), whose description is inherited when the field has none
+func (c *schemaConverter) isNamedType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, declared := c.types[ident.Name]
+	return declared
+}
+
+// parseMarkers parses the kubebuilder markers in a doc comment
+func (c *schemaConverter) parseMarkers(doc *ast.CommentGroup, target markers.TargetType) (map[string][]interface{}, error) {
+	result := make(map[string][]interface{})
+	if doc == nil {
+		return result, nil
+	}
+	for _, comment := range doc.List {
+		if !isMarkerComment(comment.Text) {
+			continue
+		}
+		text := strings.TrimSpace(comment.Text[2:])
+		def := c.registry.Lookup(text, target)
+		if def == nil {
+			continue
+		}
+		val, err := def.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse marker %q: %w", text, err)
+		}
+		result[def.Name] = append(result[def.Name], val)
+	}
+	return result, nil
+}
+
+// applyMarkers applies schema markers by priority. Item markers
+// (+kubebuilder:validation:items:...) apply to the items of an array.
+func applyMarkers(markerSet map[string][]interface{}, props *apiextensionsv1.JSONSchemaProps) error {
+	type namedMarker struct {
+		name   string
+		marker crd.SchemaMarker
+	}
+	var schemaMarkers, itemsMarkers []namedMarker
+	for name, vals := range markerSet {
+		for _, val := range vals {
+			schemaMarker, ok := val.(crd.SchemaMarker)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(name, crdmarkers.ValidationItemsPrefix) {
+				itemsMarkers = append(itemsMarkers, namedMarker{name, schemaMarker})
+			} else {
+				schemaMarkers = append(schemaMarkers, namedMarker{name, schemaMarker})
+			}
+		}
+	}
+
+	// Sort by priority, then by name so the result doesn't depend on map order
+	byPriority := func(list []namedMarker) func(i, j int) bool {
+		return func(i, j int) bool {
+			pi, pj := markerPriority(list[i].marker), markerPriority(list[j].marker)
+			if pi != pj {
+				return pi < pj
+			}
+			return list[i].name < list[j].name
+		}
+	}
+	sort.SliceStable(schemaMarkers, byPriority(schemaMarkers))
+	sort.SliceStable(itemsMarkers, byPriority(itemsMarkers))
+
+	for _, m := range schemaMarkers {
+		if err := m.marker.ApplyToSchema(props); err != nil {
+			return fmt.Errorf("marker %s: %w", m.name, err)
+		}
+	}
+	for _, m := range itemsMarkers {
+		if props.Type != "array" || props.Items == nil || props.Items.Schema == nil {
+			return fmt.Errorf("must apply %s to an array value, found %s", m.name, props.Type)
+		}
+		if err := m.marker.ApplyToSchema(props.Items.Schema); err != nil {
+			return fmt.Errorf("marker %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// markerPriority returns the order in which a schema marker is applied
+func markerPriority(marker crd.SchemaMarker) crdmarkers.ApplyPriority {
+	switch m := marker.(type) {
+	case crdmarkers.ApplyPriorityMarker:
+		return m.ApplyPriority()
+	case applyFirstMarker:
+		return crdmarkers.ApplyPriorityFirst
+	}
+	return crdmarkers.ApplyPriorityDefault
+}
+
+// builtinSchema returns the schema for a builtin Go type
+func builtinSchema(name string) (*apiextensionsv1.JSONSchemaProps, error) {
+	switch name {
+	case "bool":
+		return &apiextensionsv1.JSONSchemaProps{Type: "boolean"}, nil
+	case "string":
+		return &apiextensionsv1.JSONSchemaProps{Type: "string"}, nil
+	case "int", "int8", "int16", "uint", "uint8", "uint16", "byte", "uintptr":
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer"}, nil
+	case "int32", "uint32", "rune":
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32"}, nil
+	case "int64", "uint64":
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}, nil
+	case "float32", "float64":
+		// Like controller-gen with allowDangerousTypes, which the generator sets
+		return &apiextensionsv1.JSONSchemaProps{Type: "number"}, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", name)
+}
+
+// quantityPattern is the pattern controller-gen sets for resource.Quantity
+const quantityPattern = `^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$`
+
+// knownTypeSchema returns the schema for the metav1 types embedded in the root
+// type and the semantic types (e.g., resource.Quantity), as controller-gen's
+// known types define them
+func knownTypeSchema(sel *ast.SelectorExpr) (*apiextensionsv1.JSONSchemaProps, error) {
+	intOrString := func() *apiextensionsv1.JSONSchemaProps {
+		return &apiextensionsv1.JSONSchemaProps{
+			XIntOrString: true,
+			AnyOf:        []apiextensionsv1.JSONSchemaProps{{Type: "integer"}, {Type: "string"}},
+		}
+	}
+	switch selectorName(sel) {
+	case "metav1.TypeMeta":
+		return &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"apiVersion": {Type: "string", Description: apiVersionDescription},
+				"kind":       {Type: "string", Description: kindDescription},
+			},
+		}, nil
+	case "metav1.ObjectMeta":
+		return &apiextensionsv1.JSONSchemaProps{Type: "object"}, nil
+	case "metav1.Duration":
+		return &apiextensionsv1.JSONSchemaProps{Type: "string"}, nil
+	case "resource.Quantity":
+		props := intOrString()
+		props.Pattern = quantityPattern
+		return props, nil
+	case "intstr.IntOrString":
+		return intOrString(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", selectorName(sel))
+}
+
+// selectorName formats a selector expression as source (e.g., metav1.Time)
+func selectorName(sel *ast.SelectorExpr) string {
+	if pkg, ok := sel.X.(*ast.Ident); ok {
+		return pkg.Name + "." + sel.Sel.Name
+	}
+	return sel.Sel.Name
+}
+
+// isMarkerComment reports whether a comment is a marker (e.g., "// +kubebuilder:validation:Minimum=1")
+func isMarkerComment(comment string) bool {
+	if !strings.HasPrefix(comment, "//") {
+		return false
+	}
+	stripped := strings.TrimSpace(comment[2:])
+	return strings.HasPrefix(stripped, "+")
+}
+
+// extractDoc returns the description for a doc comment the way controller-gen does:
+// markers are removed, and lines after "---" or starting with "TODO" are ignored
+func extractDoc(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+
+	var filtered ast.CommentGroup
+	for _, comment := range doc.List {
+		if !isMarkerComment(comment.Text) {
+			filtered.List = append(filtered.List, comment)
+		}
+	}
+	if len(filtered.List) == 0 {
+		return ""
+	}
+
+	lines := strings.Split(filtered.Text(), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	outLines := make([]string, 0, len(lines))
+	insideCodeBlock := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			insideCodeBlock = !insideCodeBlock
+		}
+		if !insideCodeBlock {
+			if strings.HasPrefix(line, "TODO") {
+				continue
+			}
+			if strings.HasPrefix(line, "---") {
+				break
+			}
+		}
+		outLines = append(outLines, line)
+	}
+	return strings.Join(outLines, "\n")
+}
@@ -0,0 +1,152 @@
+package crd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var versionAnnotation = regexp.MustCompile(`controller-gen.kubebuilder.io/version: .*`)
+
+func TestGenerateContentInMemory_MatchesControllerGen(t *testing.T) {
+	cases, err := filepath.Glob("../../../../testdata/build/*/input.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, cases)
+
+	for _, input := range cases {
+		dir := filepath.Dir(input)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			expected, err := os.ReadFile(filepath.Join(dir, "expected_crd.yaml"))
+			require.NoError(t, err)
+
+			s, err := parsing.NewParser().ParseFile(input)
+			require.NoError(t, err)
+
+			files, err := NewInMemoryEmitter().Emit(*s)
+			require.NoError(t, err)
+
+			assert.Equal(t,
+				versionAnnotation.ReplaceAllString(string(expected), ""),
+				versionAnnotation.ReplaceAllString(string(files[0].Content), ""))
+		})
+	}
+}
+
+func TestGenerateContentInMemory_Markers(t *testing.T) {
+	typesCode := []byte(`package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=ex,path=exs
+//
+// Example is the Schema for the examples API
+type Example struct {
+	metav1.TypeMeta   ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata,omitempty\"`" + `
+
+	// Name of the thing
+	// +kubebuilder:validation:Required
+	Name string ` + "`json:\"name,omitempty\"`" + `
+
+	// Ports to expose
+	// +kubebuilder:validation:items:Minimum=1
+	// +kubebuilder:validation:MaxItems=3
+	Ports []int32 ` + "`json:\"ports,omitempty\"`" + `
+
+	Data []byte ` + "`json:\"data,omitempty\"`" + `
+
+	Nested NestedConfig ` + "`json:\"nested,omitempty\"`" + `
+}
+
+// NestedConfig defines the nested configuration
+// +kubebuilder:validation:MinProperties=1
+type NestedConfig struct {
+	// Count of things
+	// TODO: this line is dropped
+	Count int64 ` + "`json:\"count,omitempty\"`" + `
+}
+`)
+
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example"})
+	content, err := gen.GenerateContentInMemory(typesCode)
+	require.NoError(t, err)
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(content, &crd))
+	assert.Equal(t, "Cluster", string(crd.Spec.Scope))
+	assert.Equal(t, []string{"ex"}, crd.Spec.Names.ShortNames)
+	assert.Equal(t, "exs", crd.Spec.Names.Plural)
+	assert.Equal(t, "exs.example.com", crd.Name)
+
+	root := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+	assert.Equal(t, "Example is the Schema for the examples API", root.Description)
+	assert.Equal(t, []string{"name"}, root.Required)
+	assert.Equal(t, "object", root.Properties["metadata"].Type)
+
+	ports := root.Properties["ports"]
+	assert.Equal(t, "Ports to expose", ports.Description)
+	assert.Equal(t, int64(3), *ports.MaxItems)
+	assert.Equal(t, "int32", ports.Items.Schema.Format)
+	assert.Equal(t, float64(1), *ports.Items.Schema.Minimum)
+
+	assert.Equal(t, "byte", root.Properties["data"].Format)
+
+	nested := root.Properties["nested"]
+	assert.Equal(t, "NestedConfig defines the nested configuration", nested.Description)
+	assert.Equal(t, int64(1), *nested.MinProperties)
+	assert.Equal(t, "Count of things", nested.Properties["count"].Description)
+	assert.Equal(t, "int64", nested.Properties["count"].Format)
+}
+
+func TestGenerateContentInMemory_Errors(t *testing.T) {
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example"})
+
+	tests := []struct {
+		name      string
+		typesCode string
+		wantErr   string
+	}{
+		{"invalid Go", "package v1\ntype Example struct {", "failed to parse types"},
+		{"missing kind", "package v1\ntype Other struct{}\n", "type Example not found"},
+		{"interface", "package v1\ntype Example struct {\n\tAny interface{} `json:\"any,omitempty\"`\n}\n", "unsupported type"},
+		{"bad marker", "package v1\ntype Example struct {\n\t// +kubebuilder:validation:Minimum=abc\n\tN int `json:\"n,omitempty\"`\n}\n", "failed to parse marker"},
+		{"misapplied marker", "package v1\ntype Example struct {\n\t// +kubebuilder:validation:MaxLength=3\n\tN int `json:\"n,omitempty\"`\n}\n", "maxlength"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gen.GenerateContentInMemory([]byte(tt.typesCode))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestEmitter_InMemory(t *testing.T) {
+	// Point TMPDIR at a missing directory so any temp usage fails
+	t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+
+	s, err := parsing.NewParser().Parse([]byte("apiVersion: example.com/v1\nkind: Example\n# Replica count\nreplicas: 1\n"))
+	require.NoError(t, err)
+
+	typesCode, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example"})
+	_, err = gen.GenerateContent(typesCode)
+	require.Error(t, err, "controller-gen needs a temp directory")
+
+	files, err := NewInMemoryEmitter().Emit(*s)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), "description: Replica count")
+}
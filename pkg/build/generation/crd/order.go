@@ -0,0 +1,89 @@
+package crd
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	yamlv2 "go.yaml.in/yaml/v2"
+)
+
+// OrderProperties orders the properties of the schemas of a CRD like the fields
+// of the example values, rather than alphabetically. The rest of the CRD keeps
+// its layout. CRDs marshaled from Go types must be ordered again.
+func OrderProperties(data []byte, order *schema.PropertyOrder) ([]byte, error) {
+	// sigs.k8s.io/yaml marshals with yaml.v2, so a MapSlice keeps the formatting
+	var crd yamlv2.MapSlice
+	if err := yamlv2.Unmarshal(data, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	metadata, _ := mapValue(crd, "metadata").(yamlv2.MapSlice)
+	annotations, _ := mapValue(metadata, "annotations").(yamlv2.MapSlice)
+	if mapValue(annotations, WrapSpecAnnotation) == "true" {
+		order = &schema.PropertyOrder{
+			Names:      append(append([]string{}, schema.KRMFields...), SpecField, "status"),
+			Properties: map[string]*schema.PropertyOrder{SpecField: order},
+		}
+	}
+
+	spec, _ := mapValue(crd, "spec").(yamlv2.MapSlice)
+	versions, _ := mapValue(spec, "versions").([]interface{})
+	for _, version := range versions {
+		versionMap, _ := version.(yamlv2.MapSlice)
+		versionSchema, _ := mapValue(versionMap, "schema").(yamlv2.MapSlice)
+		if props, ok := mapValue(versionSchema, "openAPIV3Schema").(yamlv2.MapSlice); ok {
+			orderSchema(props, order)
+		}
+	}
+
+	output, err := yamlv2.Marshal(crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return output, nil
+}
+
+// propertyOrder returns the order of the properties of the CRD of a schema. Like
+// the CRD, it has the KRM fields even for plain values.
+func propertyOrder(s schema.Schema) *schema.PropertyOrder {
+	s.Plain = false
+	return s.PropertyOrder()
+}
+
+// orderSchema orders the properties of an object schema in place, and those of
+// the objects in its list items and map values
+func orderSchema(props yamlv2.MapSlice, order *schema.PropertyOrder) {
+	if order == nil {
+		return
+	}
+	if properties, ok := mapValue(props, "properties").(yamlv2.MapSlice); ok {
+		keys := make([]string, 0, len(properties))
+		items := make(map[string]yamlv2.MapItem, len(properties))
+		for _, item := range properties {
+			key := fmt.Sprint(item.Key)
+			keys = append(keys, key)
+			items[key] = item
+		}
+		for i, key := range order.Sort(keys) {
+			properties[i] = items[key]
+			if nested, ok := items[key].Value.(yamlv2.MapSlice); ok {
+				orderSchema(nested, order.Property(key))
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if nested, ok := mapValue(props, key).(yamlv2.MapSlice); ok {
+			orderSchema(nested, order)
+		}
+	}
+}
+
+// mapValue returns the value of a key of a mapping, or nil if it has none
+func mapValue(m yamlv2.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderTestValues = `apiVersion: example.com/v1
+kind: App
+replicas: 1
+image:
+  tag: latest
+  repository: nginx
+containers:
+  - name: app
+    args: [a]
+`
+
+// assertOrder checks that the lines appear in the content in order
+func assertOrder(t *testing.T, content string, lines ...string) {
+	t.Helper()
+	last := -1
+	for _, line := range lines {
+		index := strings.Index(content[last+1:], line)
+		if !assert.GreaterOrEqual(t, index, 0, "expected %q in the order %q in:\n%s", line, lines, content) {
+			return
+		}
+		last += 1 + index
+	}
+}
+
+func TestOrderProperties(t *testing.T) {
+	s, err := parsing.NewParser().Parse([]byte(orderTestValues))
+	require.NoError(t, err)
+
+	files, err := NewInMemoryEmitter().Emit(*s)
+	require.NoError(t, err)
+
+	content := string(files[0].Content)
+	assertOrder(t, content,
+		"\n          apiVersion:", "\n          kind:",
+		"\n          replicas:", "\n          image:",
+		"\n              tag:", "\n              repository:",
+		"\n          containers:",
+		"\n                name:", "\n                args:",
+	)
+}
+
+func TestOrderProperties_WrapSpec(t *testing.T) {
+	s, err := parsing.NewParser().Parse([]byte(orderTestValues))
+	require.NoError(t, err)
+	s.WrapSpec = true
+
+	files, err := NewInMemoryEmitter().Emit(*s)
+	require.NoError(t, err)
+
+	content := string(files[0].Content)
+	assertOrder(t, content,
+		"\n          kind:", "\n          spec:",
+		"\n              replicas:", "\n              image:",
+		"\n          status:",
+	)
+}
+
+func TestOrderProperties_KeepsLayout(t *testing.T) {
+	// Without an order, properties keep their order, and the CRD is unchanged
+	content, err := OrderProperties([]byte(resourceTestCRD), nil)
+	require.NoError(t, err)
+	assert.Equal(t, resourceTestCRD, string(content))
+
+	_, err = OrderProperties([]byte("- not a CRD"), nil)
+	assert.ErrorContains(t, err, "failed to parse CRD")
+}
@@ -0,0 +1,105 @@
+package crd
+
+import (
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Resource overrides the names and scope a CRD derives from its kind. Empty
+// fields keep the generated values.
+type Resource struct {
+	// Scope is Namespaced or Cluster
+	Scope string
+	// Plural is the plural name, used in the CRD name and the resource's URL path
+	Plural string
+	// ListKind is the kind of lists of the resource
+	ListKind string
+	// ShortNames are short names for the resource (e.g., for 'kubectl get')
+	ShortNames []string
+	// Categories are the groups of resources the resource belongs to (e.g., all)
+	Categories []string
+}
+
+// ApplyResource applies the resource overrides to a CRD, and validates its
+// names and scope against the rules of the Kubernetes API server
+func ApplyResource(data []byte, r Resource) ([]byte, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	names := &crd.Spec.Names
+	if r.Scope != "" {
+		crd.Spec.Scope = apiextensionsv1.ResourceScope(r.Scope)
+	}
+	if r.Plural != "" {
+		names.Plural = r.Plural
+	}
+	if r.ListKind != "" {
+		names.ListKind = r.ListKind
+	}
+	if len(r.ShortNames) > 0 {
+		names.ShortNames = r.ShortNames
+	}
+	if len(r.Categories) > 0 {
+		names.Categories = r.Categories
+	}
+	crd.Name = names.Plural + "." + crd.Spec.Group
+
+	if err := validateNames(&crd); err != nil {
+		return nil, err
+	}
+
+	output, err := yaml.Marshal(&crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return output, nil
+}
+
+// validateNames checks the names and scope of a CRD the way the API server does
+func validateNames(crd *apiextensionsv1.CustomResourceDefinition) error {
+	switch crd.Spec.Scope {
+	case apiextensionsv1.NamespaceScoped, apiextensionsv1.ClusterScoped:
+	default:
+		return fmt.Errorf("invalid scope %q (expected %s or %s)", crd.Spec.Scope, apiextensionsv1.NamespaceScoped, apiextensionsv1.ClusterScoped)
+	}
+
+	names := crd.Spec.Names
+	if err := validateLabel("plural", names.Plural); err != nil {
+		return err
+	}
+	if err := validateLabel("singular", names.Singular); err != nil {
+		return err
+	}
+	for _, shortName := range names.ShortNames {
+		if err := validateLabel("short name", shortName); err != nil {
+			return err
+		}
+	}
+	for _, category := range names.Categories {
+		if err := validateLabel("category", category); err != nil {
+			return err
+		}
+	}
+
+	if errs := validation.IsDNS1035Label(strings.ToLower(names.ListKind)); len(errs) > 0 {
+		return fmt.Errorf("invalid list kind %q: %s", names.ListKind, strings.Join(errs, "; "))
+	}
+	if names.ListKind == names.Kind {
+		return fmt.Errorf("list kind %q must differ from the kind", names.ListKind)
+	}
+	return nil
+}
+
+// validateLabel checks that a resource name is a lowercase RFC 1035 label
+func validateLabel(what, name string) error {
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid %s %q: %s", what, name, strings.Join(errs, "; "))
+	}
+	return nil
+}
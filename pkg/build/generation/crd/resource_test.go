@@ -0,0 +1,82 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const resourceTestCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    listKind: ExampleList
+    plural: examples
+    singular: example
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+
+func TestApplyResource(t *testing.T) {
+	content, err := ApplyResource([]byte(resourceTestCRD), Resource{
+		Scope:      "Cluster",
+		Plural:     "exes",
+		ListKind:   "ExampleCollection",
+		ShortNames: []string{"ex"},
+		Categories: []string{"all", "examples"},
+	})
+	require.NoError(t, err)
+
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(content, &crd))
+	assert.Equal(t, "exes.example.com", crd.Name)
+	assert.Equal(t, apiextensionsv1.ClusterScoped, crd.Spec.Scope)
+	assert.Equal(t, apiextensionsv1.CustomResourceDefinitionNames{
+		Kind:       "Example",
+		ListKind:   "ExampleCollection",
+		Plural:     "exes",
+		Singular:   "example",
+		ShortNames: []string{"ex"},
+		Categories: []string{"all", "examples"},
+	}, crd.Spec.Names)
+
+	// Empty overrides keep the generated names
+	content, err = ApplyResource([]byte(resourceTestCRD), Resource{})
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &crd))
+	assert.Equal(t, "examples.example.com", crd.Name)
+	assert.Equal(t, apiextensionsv1.NamespaceScoped, crd.Spec.Scope)
+	assert.Equal(t, "ExampleList", crd.Spec.Names.ListKind)
+}
+
+func TestApplyResource_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		resource Resource
+		wantErr  string
+	}{
+		"scope":            {Resource{Scope: "cluster"}, `invalid scope "cluster"`},
+		"uppercase plural": {Resource{Plural: "Exes"}, `invalid plural "Exes"`},
+		"dotted plural":    {Resource{Plural: "ex.es"}, `invalid plural "ex.es"`},
+		"short name":       {Resource{ShortNames: []string{"ex", "e_x"}}, `invalid short name "e_x"`},
+		"category":         {Resource{Categories: []string{"All"}}, `invalid category "All"`},
+		"list kind":        {Resource{ListKind: "Example-List!"}, `invalid list kind "Example-List!"`},
+		"list kind = kind": {Resource{ListKind: "Example"}, `must differ from the kind`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ApplyResource([]byte(resourceTestCRD), tt.resource)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
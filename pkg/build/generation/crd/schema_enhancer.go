@@ -2,8 +2,8 @@ package crd
 
 import (
 	"fmt"
-	"os"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/yaml"
 )
@@ -11,15 +11,68 @@ import (
 // AddStrictValidation adds additionalProperties: false to all object schemas in a CRD
 // This ensures strict validation that rejects unknown fields
 func AddStrictValidation(crdPath string) error {
-	// Read and parse CRD
-	data, err := os.ReadFile(crdPath)
+	return AddStrictValidationFS(filesystem.OS(), crdPath)
+}
+
+// AddStrictValidationFS is AddStrictValidation for a CRD file in fsys
+func AddStrictValidationFS(fsys filesystem.FS, crdPath string) error {
+	// Read CRD
+	data, err := fsys.ReadFile(crdPath)
 	if err != nil {
 		return fmt.Errorf("failed to read CRD: %w", err)
 	}
 
+	output, err := ApplyStrictValidation(data)
+	if err != nil {
+		return err
+	}
+
+	if err := fsys.WriteFile(crdPath, output, 0644); err != nil {
+		return fmt.Errorf("failed to write CRD: %w", err)
+	}
+
+	return nil
+}
+
+// StrictMode controls whether a CRD rejects fields its schema doesn't declare
+type StrictMode string
+
+// Strict validation modes
+const (
+	// StrictOn adds additionalProperties: false to object schemas without properties
+	StrictOn StrictMode = "true"
+	// StrictOff keeps object schemas open
+	StrictOff StrictMode = "false"
+	// StrictWarn keeps object schemas open, but annotates the CRD so that
+	// 'miaka validate' reports unknown fields as warnings
+	StrictWarn StrictMode = "warn"
+)
+
+// StrictValidationAnnotation records the strict validation mode of a CRD built
+// with --strict=warn
+const StrictValidationAnnotation = "miaka.dev/strict-validation"
+
+// ParseStrictMode parses a strict validation mode (true, false, or warn)
+func ParseStrictMode(s string) (StrictMode, error) {
+	switch mode := StrictMode(s); mode {
+	case StrictOn, StrictOff, StrictWarn:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid strict validation mode %q (must be true, false, or warn)", s)
+}
+
+// ApplyStrictValidation adds additionalProperties: false to all object schemas in CRD content
+// and returns the updated CRD YAML
+func ApplyStrictValidation(data []byte) ([]byte, error) {
+	return ApplyStrictMode(data, StrictOn)
+}
+
+// ApplyStrictMode applies a strict validation mode to CRD content and returns
+// the updated CRD YAML. The empty mode is StrictOn.
+func ApplyStrictMode(data []byte, mode StrictMode) ([]byte, error) {
 	var crd apiextensionsv1.CustomResourceDefinition
 	if err := yaml.Unmarshal(data, &crd); err != nil {
-		return fmt.Errorf("failed to parse CRD: %w", err)
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
 	}
 
 	// Process all versions
@@ -32,21 +85,25 @@ func AddStrictValidation(crdPath string) error {
 				delete(schema.Properties, "metadata")
 			}
 
-			addAdditionalPropertiesFalse(schema)
+			if mode == StrictOn || mode == "" {
+				addAdditionalPropertiesFalse(schema)
+			}
 		}
 	}
 
-	// Write back
-	output, err := yaml.Marshal(&crd)
-	if err != nil {
-		return fmt.Errorf("failed to marshal CRD: %w", err)
+	if mode == StrictWarn {
+		if crd.Annotations == nil {
+			crd.Annotations = make(map[string]string)
+		}
+		crd.Annotations[StrictValidationAnnotation] = string(StrictWarn)
 	}
 
-	if err := os.WriteFile(crdPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write CRD: %w", err)
+	output, err := yaml.Marshal(&crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
 	}
 
-	return nil
+	return output, nil
 }
 
 // addAdditionalPropertiesFalse recursively sets additionalProperties to false for all object types
@@ -59,7 +116,9 @@ func addAdditionalPropertiesFalse(schema *apiextensionsv1.JSONSchemaProps) {
 	// BUT only if:
 	// 1. It doesn't already have properties defined (properties and additionalProperties are mutually exclusive in structural schemas)
 	// 2. It doesn't already have additionalProperties set
-	if schema.Type == "object" && len(schema.Properties) == 0 && schema.AdditionalProperties == nil {
+	// 3. It isn't marked to preserve unknown fields (+miaka:preserveUnknownFields)
+	preserveUnknown := schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields
+	if schema.Type == "object" && len(schema.Properties) == 0 && schema.AdditionalProperties == nil && !preserveUnknown {
 		schema.AdditionalProperties = &apiextensionsv1.JSONSchemaPropsOrBool{
 			Allows: false,
 			Schema: nil,
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -89,6 +90,47 @@ func TestAddStrictValidation_MissingFile(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to read CRD")
 }
 
+func TestAddStrictValidationFS(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{"crds/crd.yaml": []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              config:
+                type: object
+`)})
+
+	require.NoError(t, AddStrictValidationFS(fsys, "crds/crd.yaml"))
+	data, err := fsys.ReadFile("crds/crd.yaml")
+	require.NoError(t, err)
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(data, &crd))
+	config := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["config"]
+	require.NotNil(t, config.AdditionalProperties)
+	assert.False(t, config.AdditionalProperties.Allows)
+
+	require.NoError(t, ValidateCRDFS(fsys, "crds/crd.yaml"))
+	err = ValidateCRDFS(fsys, "crds/missing.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read CRD")
+}
+
 func TestAddStrictValidation_InvalidYAML(t *testing.T) {
 	tmpDir := t.TempDir()
 	crdPath := filepath.Join(tmpDir, "invalid.yaml")
@@ -328,3 +370,76 @@ func TestAddAdditionalPropertiesFalse_WithAdditionalPropertiesSchema(t *testing.
 	require.NotNil(t, nestedProp.AdditionalProperties)
 	assert.False(t, nestedProp.AdditionalProperties.Allows)
 }
+
+func TestAddAdditionalPropertiesFalse_PreserveUnknownFields(t *testing.T) {
+	// Objects that preserve unknown fields are intentionally open
+	preserve := true
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"open":   {Type: "object", XPreserveUnknownFields: &preserve},
+			"closed": {Type: "object"},
+		},
+	}
+
+	addAdditionalPropertiesFalse(schema)
+
+	assert.Nil(t, schema.Properties["open"].AdditionalProperties, "object preserving unknown fields should stay open")
+	require.NotNil(t, schema.Properties["closed"].AdditionalProperties)
+	assert.False(t, schema.Properties["closed"].AdditionalProperties.Allows)
+}
+
+func TestParseStrictMode(t *testing.T) {
+	for _, s := range []string{"true", "false", "warn"} {
+		mode, err := ParseStrictMode(s)
+		require.NoError(t, err)
+		assert.Equal(t, StrictMode(s), mode)
+	}
+
+	_, err := ParseStrictMode("yes")
+	assert.ErrorContains(t, err, `invalid strict validation mode "yes"`)
+}
+
+func TestApplyStrictMode(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name: "v1",
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"metadata": {Type: "object"},
+							"config":   {Type: "object"},
+						},
+					},
+				},
+			}},
+		},
+	}
+	data, err := yaml.Marshal(crd)
+	require.NoError(t, err)
+
+	tests := map[StrictMode]struct {
+		strict     bool
+		annotation string
+	}{
+		"":         {strict: true},
+		StrictOn:   {strict: true},
+		StrictOff:  {strict: false},
+		StrictWarn: {strict: false, annotation: "warn"},
+	}
+	for mode, want := range tests {
+		t.Run(string(mode), func(t *testing.T) {
+			output, err := ApplyStrictMode(data, mode)
+			require.NoError(t, err)
+
+			var result apiextensionsv1.CustomResourceDefinition
+			require.NoError(t, yaml.Unmarshal(output, &result))
+			schema := result.Spec.Versions[0].Schema.OpenAPIV3Schema
+			assert.NotContains(t, schema.Properties, "metadata", "metadata is always removed")
+			assert.Equal(t, want.strict, schema.Properties["config"].AdditionalProperties != nil)
+			assert.Equal(t, want.annotation, result.Annotations[StrictValidationAnnotation])
+		})
+	}
+}
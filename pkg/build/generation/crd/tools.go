@@ -0,0 +1,116 @@
+package crd
+
+import (
+	"fmt"
+	"go/version"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// ControllerToolsModule is the module of controller-gen, which miaka links as
+// a library at the version pinned in its go.mod
+const ControllerToolsModule = "sigs.k8s.io/controller-tools"
+
+// versionAnnotationLine matches the line of the controller-gen version
+// annotation in a generated CRD
+var versionAnnotationLine = regexp.MustCompile(`(?m)^(\s*controller-gen\.kubebuilder\.io/version:).*$`)
+
+// ControllerGenVersion returns the version of controller-gen linked into
+// miaka, which generated CRDs are annotated with. controller-gen's own version
+// package reports the version of the main module instead, which is miaka's.
+func ControllerGenVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(unknown)"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != ControllerToolsModule {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "(unknown)"
+}
+
+// stampVersion sets the controller-gen version annotation of a CRD generated
+// by controller-gen to ControllerGenVersion
+func stampVersion(content []byte) []byte {
+	return versionAnnotationLine.ReplaceAll(content, []byte("${1} "+ControllerGenVersion()))
+}
+
+// ModuleGoVersion returns the minimum Go version of the temporary module
+// controller-gen loads the generated types from (e.g., "go1.25")
+func ModuleGoVersion() string {
+	return "go" + embeddedRequirement("go")
+}
+
+// ApimachineryVersion returns the version of k8s.io/apimachinery, the only
+// dependency of the generated types, in the temporary module
+func ApimachineryVersion() string {
+	return embeddedRequirement("require k8s.io/apimachinery")
+}
+
+// embeddedRequirement returns the value of a directive of the embedded go.mod
+func embeddedRequirement(directive string) string {
+	for _, line := range strings.Split(embeddedGoMod, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), directive+" "); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// GoCommand is the go command controller-gen runs to load the generated types
+type GoCommand struct {
+	// Path is the path of the go command
+	Path string
+	// Version is the version of its toolchain (e.g., "go1.25.1")
+	Version string
+}
+
+// FindGoCommand finds the go command on the PATH and checks that its
+// toolchain can load the temporary module without downloading another
+var FindGoCommand = sync.OnceValues(findGoCommand)
+
+func findGoCommand() (GoCommand, error) {
+	path, err := exec.LookPath("go")
+	if err != nil {
+		return GoCommand{}, fmt.Errorf("the go command isn't installed: %w", err)
+	}
+	command := exec.Command(path, "env", "GOVERSION")
+	command.Env = goEnv()
+	output, err := command.Output()
+	if err != nil {
+		return GoCommand{Path: path}, fmt.Errorf("failed to get the version of %s: %w", path, err)
+	}
+	goCommand := GoCommand{Path: path, Version: strings.TrimSpace(string(output))}
+	if !version.IsValid(goCommand.Version) {
+		return goCommand, fmt.Errorf("%s has an unrecognized version %q", path, goCommand.Version)
+	}
+	if version.Compare(goCommand.Version, ModuleGoVersion()) < 0 {
+		return goCommand, fmt.Errorf("%s is %s, older than the %s the generated types require", path, goCommand.Version, ModuleGoVersion())
+	}
+	return goCommand, nil
+}
+
+// goEnv is the environment of the go command controller-gen runs. It never
+// downloads a toolchain, ignores the user's workspace and -mod flags, which
+// don't apply to the temporary module, and reads the module's complete go.sum
+// without updating it. The user's other GOFLAGS, like -modcacherw, are kept.
+func goEnv() []string {
+	var flags []string
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if !strings.HasPrefix(flag, "-mod=") && !strings.HasPrefix(flag, "--mod=") {
+			flags = append(flags, flag)
+		}
+	}
+	flags = append(flags, "-mod=readonly")
+	return append(os.Environ(), "GOTOOLCHAIN=local", "GOWORK=off", "GOFLAGS="+strings.Join(flags, " "))
+}
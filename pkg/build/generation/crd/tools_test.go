@@ -0,0 +1,111 @@
+package crd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestControllerGenVersion tests that the version is the controller-tools
+// version pinned in go.mod, not miaka's
+func TestControllerGenVersion(t *testing.T) {
+	goMod, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "go.mod"))
+	require.NoError(t, err)
+	pinned := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(ControllerToolsModule) + ` (\S+)`).FindSubmatch(goMod)
+	require.NotNil(t, pinned, "go.mod should require "+ControllerToolsModule)
+
+	assert.Equal(t, string(pinned[1]), ControllerGenVersion())
+}
+
+func TestEmbeddedModuleVersions(t *testing.T) {
+	assert.Equal(t, "v0.34.2", ApimachineryVersion())
+	assert.Regexp(t, `^go1\.\d+`, ModuleGoVersion())
+}
+
+func TestStampVersion(t *testing.T) {
+	content := "metadata:\n  annotations:\n    controller-gen.kubebuilder.io/version: (devel)\n  name: x\n"
+	assert.Equal(t,
+		"metadata:\n  annotations:\n    controller-gen.kubebuilder.io/version: "+ControllerGenVersion()+"\n  name: x\n",
+		string(stampVersion([]byte(content))))
+}
+
+// TestGenerator_GenerateContent_Version tests that both generation paths
+// annotate the CRD with the version of controller-gen
+func TestGenerator_GenerateContent_Version(t *testing.T) {
+	gen := NewGenerator(Options{Group: "example.com", Version: "v1", Kind: "Example"})
+	s, err := parsing.NewParser().Parse([]byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n"))
+	require.NoError(t, err)
+	typesCode, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+
+	want := "controller-gen.kubebuilder.io/version: " + ControllerGenVersion() + "\n"
+	content, err := gen.GenerateContent(typesCode)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), want)
+	content, err = gen.GenerateContentInMemory(typesCode)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), want)
+}
+
+func TestFindGoCommand(t *testing.T) {
+	goCommand, err := FindGoCommand()
+	require.NoError(t, err, "the tests need a go command")
+	assert.FileExists(t, goCommand.Path)
+	assert.True(t, strings.HasPrefix(goCommand.Version, "go1."), goCommand.Version)
+}
+
+// TestEmitter_WithoutGoCommand tests that the CRD is generated in memory,
+// without intermediate files, when there is no usable go command
+func TestEmitter_WithoutGoCommand(t *testing.T) {
+	s := schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "Example",
+		Package:    "v1",
+		Structs: []schema.StructDef{
+			{Name: "Example", Fields: []schema.Field{{Name: "Replicas", JSONName: "replicas", Type: "int"}}},
+		},
+	}
+	workDir := filepath.Join(t.TempDir(), "work")
+
+	var log bytes.Buffer
+	e := NewEmitter()
+	e.WorkDir = workDir
+	e.Log = logging.New(&log, logging.LevelWarn)
+	e.findGo = func() (GoCommand, error) {
+		return GoCommand{}, errors.New("the go command isn't installed")
+	}
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	assert.Contains(t, log.String(), "Generating the CRD in memory, since controller-gen can't load the generated types: the go command isn't installed")
+	require.Len(t, files, 1)
+	assert.Contains(t, string(files[0].Content), "replicas:")
+	assert.NoDirExists(t, workDir)
+
+	withGo := NewEmitter()
+	expected, err := withGo.Emit(s)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected[0].Content), string(files[0].Content))
+}
+
+// TestGoEnv tests that the user's GOFLAGS are kept, except their -mod flag
+func TestGoEnv(t *testing.T) {
+	t.Setenv("GOFLAGS", "-modcacherw -mod=vendor -tags=netgo")
+	env := goEnv()
+	assert.Equal(t, "GOFLAGS=-modcacherw -tags=netgo -mod=readonly", env[len(env)-1])
+	assert.Contains(t, env, "GOTOOLCHAIN=local")
+
+	t.Setenv("GOFLAGS", "")
+	env = goEnv()
+	assert.Equal(t, "GOFLAGS=-mod=readonly", env[len(env)-1])
+}
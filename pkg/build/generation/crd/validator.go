@@ -2,8 +2,8 @@ package crd
 
 import (
 	"fmt"
-	"os"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
@@ -13,12 +13,22 @@ import (
 // ValidateCRD validates a CRD file for structural schema compliance
 // This catches issues like mutually exclusive properties before trying to apply to a cluster
 func ValidateCRD(crdPath string) error {
-	// Read and parse CRD
-	data, err := os.ReadFile(crdPath)
+	return ValidateCRDFS(filesystem.OS(), crdPath)
+}
+
+// ValidateCRDFS is ValidateCRD for a CRD file in fsys
+func ValidateCRDFS(fsys filesystem.FS, crdPath string) error {
+	// Read CRD
+	data, err := fsys.ReadFile(crdPath)
 	if err != nil {
 		return fmt.Errorf("failed to read CRD: %w", err)
 	}
 
+	return ValidateCRDContent(data)
+}
+
+// ValidateCRDContent validates CRD YAML content for structural schema compliance
+func ValidateCRDContent(data []byte) error {
 	var crd apiextensionsv1.CustomResourceDefinition
 	if err := yaml.Unmarshal(data, &crd); err != nil {
 		return fmt.Errorf("failed to parse CRD: %w", err)
@@ -0,0 +1,57 @@
+package crd
+
+import (
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// WrapSpecAnnotation marks a CRD whose resources hold the values in spec (built
+// with --wrap-spec), so that values can be validated against it
+const WrapSpecAnnotation = "miaka.dev/wrap-spec"
+
+// SpecField is the field holding the values of resources whose values are
+// wrapped in spec
+const SpecField = "spec"
+
+// WrapsSpec reports whether the resources of a CRD hold the values in spec
+func WrapsSpec(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	return crd.Annotations[WrapSpecAnnotation] == "true"
+}
+
+// WrapValues returns a resource holding the values in spec. The apiVersion,
+// kind, and metadata stay at the top level.
+func WrapValues(values map[string]interface{}) map[string]interface{} {
+	resource := make(map[string]interface{})
+	spec := make(map[string]interface{})
+	for key, value := range values {
+		switch key {
+		case "apiVersion", "kind", "metadata":
+			resource[key] = value
+		default:
+			spec[key] = value
+		}
+	}
+	resource[SpecField] = spec
+	return resource
+}
+
+// annotateWrapSpec adds WrapSpecAnnotation to CRD content
+func annotateWrapSpec(data []byte) ([]byte, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	if crd.Annotations == nil {
+		crd.Annotations = make(map[string]string)
+	}
+	crd.Annotations[WrapSpecAnnotation] = "true"
+
+	output, err := yaml.Marshal(&crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,39 @@
+package crd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWrapValues(t *testing.T) {
+	values := map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "App",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"replicas":   1,
+		"image":      map[string]interface{}{"repository": "nginx"},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "App",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"replicas": 1,
+			"image":    map[string]interface{}{"repository": "nginx"},
+		},
+	}, WrapValues(values))
+}
+
+func TestAnnotateWrapSpec(t *testing.T) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	assert.False(t, WrapsSpec(&crd))
+
+	content, err := annotateWrapSpec([]byte(resourceTestCRD))
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &crd))
+	assert.True(t, WrapsSpec(&crd))
+}
@@ -0,0 +1,44 @@
+package deepcopy
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// TargetName is the output target name of the DeepCopy emitter
+const TargetName = "deepcopy"
+
+// Emitter generates DeepCopy functions for the Go types produced by another emitter
+type Emitter struct {
+	types generation.Emitter
+}
+
+// NewEmitter creates a DeepCopy emitter for the output of typesEmitter.
+// Wrap typesEmitter with generation.Once to share a single generation within a build.
+func NewEmitter(typesEmitter generation.Emitter) *Emitter {
+	return &Emitter{types: typesEmitter}
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates zz_generated.deepcopy.go from the schema
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	files, err := e.types.Emit(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Go code: %w", err)
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("Go types emitter produced %d files, expected 1", len(files))
+	}
+
+	content, err := Generate(files[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	return []generation.OutputFile{{Name: "zz_generated.deepcopy.go", Content: content}}, nil
+}
@@ -0,0 +1,37 @@
+package deepcopy
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitter_Emit(t *testing.T) {
+	s := schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "Example",
+		Package:    "v1",
+		Structs: []schema.StructDef{
+			{
+				Name:   "Example",
+				Fields: []schema.Field{{Name: "Tags", JSONName: "tags", Type: "[]string", IsSlice: true, ElemType: "string"}},
+			},
+		},
+	}
+
+	e := NewEmitter(generation.Once(gotypes.NewEmitter()))
+	assert.Equal(t, TargetName, e.Name())
+
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "zz_generated.deepcopy.go", files[0].Name)
+	assert.Contains(t, string(files[0].Content), "func (in *Example) DeepCopyInto(out *Example) {")
+
+	_, err = NewEmitter(gotypes.NewEmitter()).Emit(schema.Schema{})
+	require.Error(t, err)
+}
@@ -0,0 +1,334 @@
+// Package deepcopy generates the DeepCopy functions that controllers need for
+// the generated Go types (zz_generated.deepcopy.go).
+package deepcopy
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rootMarker marks types that are API objects, which implement runtime.Object
+const rootMarker = "+kubebuilder:object:root=true"
+
+// runtimeImport is the package of runtime.Object
+const runtimeImport = "k8s.io/apimachinery/pkg/runtime"
+
+// basicTypes are the builtin types, which are copied by assignment
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// shallowExternalTypes are the types from other packages that have no pointers,
+// slices, or maps, and so are copied by assignment. Other external types must
+// have a DeepCopyInto method.
+var shallowExternalTypes = map[string]bool{
+	"metav1.TypeMeta":    true,
+	"metav1.Duration":    true,
+	"intstr.IntOrString": true,
+}
+
+// generator generates DeepCopy functions for the types in a Go source file
+type generator struct {
+	types   map[string]*ast.TypeSpec
+	order   []string
+	roots   map[string]bool
+	imports map[string]string
+}
+
+// Generate generates zz_generated.deepcopy.go for the types generated by miaka
+// (see gotypes.Generator), and any other files of the same package. Like
+// controller-gen's object generator, every type that isn't copied by assignment
+// gets DeepCopyInto and DeepCopy, and root types also get DeepCopyObject.
+func Generate(files ...[]byte) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to generate DeepCopy functions for")
+	}
+
+	g := &generator{
+		types:   make(map[string]*ast.TypeSpec),
+		roots:   make(map[string]bool),
+		imports: make(map[string]string),
+	}
+	fset := token.NewFileSet()
+	packageName := ""
+	for i, code := range files {
+		file, err := parser.ParseFile(fset, fmt.Sprintf("file%d.go", i), code, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse types: %w", err)
+		}
+		if packageName == "" {
+			packageName = file.Name.Name
+		} else if file.Name.Name != packageName {
+			return nil, fmt.Errorf("files are in different packages: %s and %s", packageName, file.Name.Name)
+		}
+		if err := g.addFile(fset, file); err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+	for _, name := range g.order {
+		if err := g.writeFunctions(&body, name); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("//go:build !ignore_autogenerated\n\n")
+	out.WriteString("// Code generated by miaka. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", packageName)
+	g.writeImports(&out, body.String())
+	out.Write(body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("failed to format deepcopy code: %w", err)
+	}
+	return formatted, nil
+}
+
+// addFile adds the imports and types of a file
+func (g *generator) addFile(fset *token.FileSet, file *ast.File) error {
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return fmt.Errorf("invalid import %s: %w", spec.Path.Value, err)
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		g.imports[name] = importPath
+	}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := g.types[typeSpec.Name.Name]; ok {
+				return fmt.Errorf("type %s is declared more than once", typeSpec.Name.Name)
+			}
+			g.types[typeSpec.Name.Name] = typeSpec
+			g.order = append(g.order, typeSpec.Name.Name)
+			if hasRootMarker(fset, file, genDecl) {
+				g.roots[typeSpec.Name.Name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// hasRootMarker reports whether the doc comment of a declaration, or the comment
+// before it separated by a blank line (as kubebuilder writes markers), has rootMarker
+func hasRootMarker(fset *token.FileSet, file *ast.File, decl *ast.GenDecl) bool {
+	start := decl.Pos()
+	if decl.Doc != nil {
+		if strings.Contains(decl.Doc.Text(), rootMarker) {
+			return true
+		}
+		start = decl.Doc.Pos()
+	}
+	line := fset.Position(start).Line
+	for _, comments := range file.Comments {
+		if fset.Position(comments.End()).Line == line-2 {
+			return strings.Contains(comments.Text(), rootMarker)
+		}
+	}
+	return false
+}
+
+// writeImports writes the imports used by the generated functions
+func (g *generator) writeImports(out *bytes.Buffer, body string) {
+	imports := make(map[string]string)
+	for name, importPath := range g.imports {
+		if strings.Contains(body, name+".") {
+			imports[name] = importPath
+		}
+	}
+	if strings.Contains(body, "runtime.Object") {
+		imports["runtime"] = runtimeImport
+	}
+	if len(imports) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	// Sort by path, as goimports does
+	sort.Slice(names, func(i, j int) bool { return imports[names[i]] < imports[names[j]] })
+	out.WriteString("import (\n")
+	for _, name := range names {
+		fmt.Fprintf(out, "\t%s %q\n", name, imports[name])
+	}
+	out.WriteString(")\n\n")
+}
+
+// writeFunctions writes the DeepCopy functions of a type, if it needs them
+func (g *generator) writeFunctions(out *bytes.Buffer, name string) error {
+	typeSpec := g.types[name]
+	var statements []string
+	switch t := typeSpec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			fieldName, err := fieldName(field)
+			if err != nil {
+				return fmt.Errorf("type %s: %w", name, err)
+			}
+			copied, err := g.deepCopy("in."+fieldName, "out."+fieldName, field.Type)
+			if err != nil {
+				return fmt.Errorf("field %s.%s: %w", name, fieldName, err)
+			}
+			statements = append(statements, copied...)
+		}
+	default:
+		shallow, err := g.isShallow(t)
+		if err != nil {
+			return fmt.Errorf("type %s: %w", name, err)
+		}
+		if shallow {
+			return nil
+		}
+		if statements, err = g.deepCopy("(*in)", "(*out)", t); err != nil {
+			return fmt.Errorf("type %s: %w", name, err)
+		}
+	}
+
+	fmt.Fprintf(out, "// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.\n")
+	fmt.Fprintf(out, "func (in *%s) DeepCopyInto(out *%s) {\n*out = *in\n", name, name)
+	for _, statement := range statements {
+		fmt.Fprintln(out, statement)
+	}
+	out.WriteString("}\n\n")
+
+	fmt.Fprintf(out, "// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new %s.\n", name)
+	fmt.Fprintf(out, "func (in *%s) DeepCopy() *%s {\nif in == nil {\nreturn nil\n}\nout := new(%s)\nin.DeepCopyInto(out)\nreturn out\n}\n\n", name, name, name)
+
+	if g.roots[name] {
+		fmt.Fprintf(out, "// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.\n")
+		fmt.Fprintf(out, "func (in *%s) DeepCopyObject() runtime.Object {\nif c := in.DeepCopy(); c != nil {\nreturn c\n}\nreturn nil\n}\n\n", name)
+	}
+	return nil
+}
+
+// fieldName returns the name of a struct field, or of the type of an embedded field
+func fieldName(field *ast.Field) (string, error) {
+	if len(field.Names) == 1 {
+		return field.Names[0].Name, nil
+	}
+	if len(field.Names) == 0 {
+		switch t := field.Type.(type) {
+		case *ast.Ident:
+			return t.Name, nil
+		case *ast.SelectorExpr:
+			return t.Sel.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported field declaration %s", typeString(field.Type))
+}
+
+// deepCopy returns the statements that make out, a copy by assignment of in, a
+// deep copy of in. Both are addressable expressions of type t.
+func (g *generator) deepCopy(in, out string, t ast.Expr) ([]string, error) {
+	shallow, err := g.isShallow(t)
+	if err != nil || shallow {
+		return nil, err
+	}
+
+	switch t := t.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		return []string{fmt.Sprintf("%s.DeepCopyInto(&%s)", in, out)}, nil
+
+	case *ast.StarExpr:
+		elem, err := g.deepCopy("(**in)", "(**out)", t.X)
+		if err != nil {
+			return nil, err
+		}
+		return nested(in, out, append([]string{fmt.Sprintf("*out = new(%s)", typeString(t.X)), "**out = **in"}, elem...)), nil
+
+	case *ast.ArrayType:
+		elem, err := g.deepCopy("(*in)[i]", "(*out)[i]", t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		statements := []string{fmt.Sprintf("*out = make(%s, len(*in))", typeString(t)), "copy(*out, *in)"}
+		if len(elem) > 0 {
+			statements = append(statements, "for i := range *in {")
+			statements = append(statements, elem...)
+			statements = append(statements, "}")
+		}
+		return nested(in, out, statements), nil
+
+	case *ast.MapType:
+		value, err := g.deepCopy("val", "outVal", t.Value)
+		if err != nil {
+			return nil, err
+		}
+		statements := []string{fmt.Sprintf("*out = make(%s, len(*in))", typeString(t)), "for key, val := range *in {"}
+		if len(value) > 0 {
+			statements = append(statements, "outVal := val")
+			statements = append(statements, value...)
+			statements = append(statements, "(*out)[key] = outVal")
+		} else {
+			statements = append(statements, "(*out)[key] = val")
+		}
+		statements = append(statements, "}")
+		return nested(in, out, statements), nil
+	}
+	return nil, fmt.Errorf("can't deep copy type %s", typeString(t))
+}
+
+// nested wraps the statements that copy the value of a non-nil pointer, slice,
+// or map, which refer to it as *in and *out
+func nested(in, out string, statements []string) []string {
+	result := []string{fmt.Sprintf("if %s != nil {", in), fmt.Sprintf("in, out := &%s, &%s", in, out)}
+	result = append(result, statements...)
+	return append(result, "}")
+}
+
+// isShallow reports whether values of a type are copied by assignment
+func (g *generator) isShallow(t ast.Expr) (bool, error) {
+	switch t := t.(type) {
+	case *ast.Ident:
+		if basicTypes[t.Name] {
+			return true, nil
+		}
+		typeSpec, ok := g.types[t.Name]
+		if !ok {
+			return false, fmt.Errorf("unknown type %s", t.Name)
+		}
+		if _, ok := typeSpec.Type.(*ast.StructType); ok {
+			// Structs always get DeepCopyInto, like in controller-gen
+			return false, nil
+		}
+		return g.isShallow(typeSpec.Type)
+	case *ast.SelectorExpr:
+		return shallowExternalTypes[typeString(t)], nil
+	case *ast.StarExpr, *ast.ArrayType, *ast.MapType:
+		return false, nil
+	}
+	return false, fmt.Errorf("can't deep copy type %s", typeString(t))
+}
+
+// typeString returns the source of a type expression
+func typeString(t ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), t); err != nil {
+		return fmt.Sprintf("%T", t)
+	}
+	return buf.String()
+}
@@ -0,0 +1,168 @@
+package deepcopy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testValues has fields of every kind of type that miaka generates
+const testValues = `apiVersion: example.com/v1
+kind: App
+# +miaka:nullable
+enabled: true
+# +miaka:nullable
+# +miaka:type: quantity
+memory: 512Mi
+# +miaka:type: duration
+timeout: 30s
+tags: [a, b]
+# +miaka:type:map[string][]string
+# +miaka:items:kubebuilder:validation:MaxItems=3
+groups: {}
+# +miaka:type:map[string][]string
+teams: {}
+containers:
+  - name: x
+    # +miaka:type: quantity
+    cpu: 100m
+    env:
+      - name: A
+# +miaka:nullable
+image:
+  repository: nginx
+extra:
+  any: thing
+`
+
+// generateTypes generates the Go types of values
+func generateTypes(t *testing.T, values string, opts parsing.Options) []byte {
+	t.Helper()
+	s, err := parsing.NewParserWithOptions(opts).Parse([]byte(values))
+	require.NoError(t, err)
+	code, err := gotypes.NewGenerator(s).Generate()
+	require.NoError(t, err)
+	return code
+}
+
+func TestGenerate(t *testing.T) {
+	code, err := Generate(generateTypes(t, testValues, parsing.Options{Paths: parsing.PathFilter{Exclude: []string{"extra"}}}))
+	require.NoError(t, err)
+
+	output := string(code)
+	assert.Contains(t, output, "// Code generated by miaka. DO NOT EDIT.")
+	assert.Contains(t, output, "\tresource \"k8s.io/apimachinery/pkg/api/resource\"\n\truntime \"k8s.io/apimachinery/pkg/runtime\"\n)")
+	assert.Contains(t, output, "func (in *App) DeepCopyObject() runtime.Object {")
+	assert.NotContains(t, output, "func (in *ImageConfig) DeepCopyObject()")
+
+	// Fields with pointers, slices, or maps are copied deeply; others by assignment
+	assert.Contains(t, output, "in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)")
+	assert.NotContains(t, output, "out.TypeMeta")
+	assert.NotContains(t, output, "out.Timeout")
+	assert.Contains(t, output, "*out = new(bool)\n\t\t**out = **in\n\t}")
+	assert.Contains(t, output, "(**in).DeepCopyInto(&(**out))")
+	assert.Contains(t, output, "*out = make([]string, len(*in))\n\t\tcopy(*out, *in)")
+	assert.Contains(t, output, "(*in)[i].DeepCopyInto(&(*out)[i])")
+	assert.Contains(t, output, "val.DeepCopyInto(&outVal)")
+	assert.Contains(t, output, "in.Extra.DeepCopyInto(&out.Extra)")
+
+	// Named slices need DeepCopyInto too
+	assert.Contains(t, output, "func (in *GroupsValue) DeepCopyInto(out *GroupsValue) {")
+}
+
+func TestGenerate_Plain(t *testing.T) {
+	code, err := Generate(generateTypes(t, "replicas: 1\nimage:\n  tag: v1\n", parsing.Options{Plain: true}))
+	require.NoError(t, err)
+
+	output := string(code)
+	assert.Contains(t, output, "func (in *Values) DeepCopy() *Values {")
+	assert.NotContains(t, output, "DeepCopyObject")
+	assert.NotContains(t, output, "import")
+}
+
+func TestGenerate_MultipleFiles(t *testing.T) {
+	list := []byte(`package v1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +kubebuilder:object:root=true
+
+// AppList contains a list of App
+type AppList struct {
+	metav1.TypeMeta ` + "`json:\",inline\"`" + `
+	metav1.ListMeta ` + "`json:\"metadata,omitempty\"`" + `
+	Items []App     ` + "`json:\"items\"`" + `
+}
+`)
+	code, err := Generate(generateTypes(t, "apiVersion: example.com/v1\nkind: App\nreplicas: 1\n", parsing.Options{}), list)
+	require.NoError(t, err)
+
+	output := string(code)
+	assert.Contains(t, output, "func (in *App) DeepCopyObject() runtime.Object {")
+	assert.Contains(t, output, "func (in *AppList) DeepCopyObject() runtime.Object {")
+	assert.Contains(t, output, "in.ListMeta.DeepCopyInto(&out.ListMeta)")
+	assert.Contains(t, output, "(*in)[i].DeepCopyInto(&(*out)[i])")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate([]byte("package v1\n\ntype App struct {\n\tAny interface{}\n}\n"))
+	assert.ErrorContains(t, err, "field App.Any: can't deep copy type interface{}")
+
+	_, err = Generate([]byte("not go"))
+	assert.ErrorContains(t, err, "failed to parse types")
+
+	_, err = Generate([]byte("package v1\n\ntype App struct{}\n"), []byte("package v2\n\ntype App struct{}\n"))
+	assert.ErrorContains(t, err, "files are in different packages: v1 and v2")
+
+	_, err = Generate([]byte("package v1\n\ntype App struct{}\n"), []byte("package v1\n\ntype App struct{}\n"))
+	assert.ErrorContains(t, err, "type App is declared more than once")
+
+	_, err = Generate()
+	assert.ErrorContains(t, err, "no files")
+}
+
+// TestGenerate_Compiles checks that the generated functions compile with the
+// types and make the root type a runtime.Object
+func TestGenerate_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling with the go command is slow")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	for name, opts := range map[string]parsing.Options{
+		"krm":   {Paths: parsing.PathFilter{Exclude: []string{"extra"}}},
+		"plain": {Plain: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			values := testValues
+			check := "package v1\n\nimport \"k8s.io/apimachinery/pkg/runtime\"\n\nvar _ runtime.Object = &App{}\n"
+			if opts.Plain {
+				values = "replicas: 1\n# +miaka:nullable\nimage:\n  tags: [a]\n"
+				check = "package values\n\nvar _ = (&Values{}).DeepCopy()\n"
+			}
+			types := generateTypes(t, values, opts)
+			code, err := Generate(types)
+			require.NoError(t, err)
+
+			// Directories starting with _ are ignored by ./... patterns
+			dir, err := os.MkdirTemp(".", "_compile-")
+			require.NoError(t, err)
+			t.Cleanup(func() { os.RemoveAll(dir) })
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "types.go"), types, 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "zz_generated.deepcopy.go"), code, 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "check.go"), []byte(check), 0644))
+
+			output, err := exec.Command(goCmd, "vet", "./"+dir).CombinedOutput()
+			assert.NoError(t, err, "generated code doesn't compile:\n%s\n%s", output, code)
+		})
+	}
+}
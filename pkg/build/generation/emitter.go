@@ -0,0 +1,153 @@
+// Package generation defines the common interface implemented by all output generators.
+package generation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"golang.org/x/sync/errgroup"
+)
+
+// OutputFile is a single generated artifact
+type OutputFile struct {
+	// Name is the default file name for the artifact (e.g., "types.go", "crd.yaml")
+	Name string
+
+	// Content is the generated file content
+	Content []byte
+}
+
+// Emitter generates output files from a parsed schema
+type Emitter interface {
+	// Name returns the target name used to select the emitter (e.g., "gotypes")
+	Name() string
+
+	// Emit generates output files from the schema
+	Emit(s schema.Schema) ([]OutputFile, error)
+}
+
+// Registry holds emitters keyed by target name
+type Registry struct {
+	emitters map[string]Emitter
+	order    []string
+}
+
+// NewRegistry creates an empty emitter registry
+func NewRegistry() *Registry {
+	return &Registry{
+		emitters: make(map[string]Emitter),
+	}
+}
+
+// Register adds an emitter to the registry. Target names must be unique.
+func (r *Registry) Register(e Emitter) error {
+	name := e.Name()
+	if _, exists := r.emitters[name]; exists {
+		return fmt.Errorf("emitter %q is already registered", name)
+	}
+	r.emitters[name] = e
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the emitter registered under name
+func (r *Registry) Get(name string) (Emitter, bool) {
+	e, ok := r.emitters[name]
+	return e, ok
+}
+
+// Names returns the registered target names in registration order
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// Emit runs the named emitter and returns its single output file, or the cause
+// of ctx being done if it's done first
+func (r *Registry) Emit(ctx context.Context, name string, s schema.Schema) (OutputFile, error) {
+	e, ok := r.Get(name)
+	if !ok {
+		return OutputFile{}, fmt.Errorf("unknown output target %q (available: %v)", name, r.Names())
+	}
+
+	files, err := emit(ctx, e, s)
+	if err != nil {
+		return OutputFile{}, err
+	}
+	if len(files) != 1 {
+		return OutputFile{}, fmt.Errorf("output target %q produced %d files, expected 1", name, len(files))
+	}
+	return files[0], nil
+}
+
+// Prefetch runs the named emitters concurrently, sharing the schema, and returns
+// the first error. Emitters wrapped with Once keep their result, so later Emit
+// calls return it (including any error) without generating it again.
+func (r *Registry) Prefetch(ctx context.Context, s schema.Schema, names ...string) error {
+	emitters := make([]Emitter, len(names))
+	for i, name := range names {
+		e, ok := r.Get(name)
+		if !ok {
+			return fmt.Errorf("unknown output target %q (available: %v)", name, r.Names())
+		}
+		emitters[i] = e
+	}
+
+	var g errgroup.Group
+	for _, e := range emitters {
+		g.Go(func() error {
+			_, err := emit(ctx, e, s)
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// emit runs an emitter, returning early with the cause of ctx being done. Emitters
+// don't take a context, since their generators (e.g., controller-gen) don't, so an
+// abandoned emitter runs to completion in the background; wrapped with Once, its
+// result is kept for later calls.
+func emit(ctx context.Context, e Emitter, s schema.Schema) ([]OutputFile, error) {
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	type result struct {
+		files []OutputFile
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		files, err := e.Emit(s)
+		done <- result{files: files, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.files, r.err
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// onceEmitter caches the result of the first Emit call
+type onceEmitter struct {
+	Emitter
+	once  sync.Once
+	files []OutputFile
+	err   error
+}
+
+// Once wraps an emitter so that it runs at most once; later calls return the first result.
+// This lets several emitters share an expensive intermediate output (e.g., the CRD) within a build.
+func Once(e Emitter) Emitter {
+	return &onceEmitter{Emitter: e}
+}
+
+func (o *onceEmitter) Emit(s schema.Schema) ([]OutputFile, error) {
+	o.once.Do(func() {
+		o.files, o.err = o.Emitter.Emit(s)
+	})
+	return o.files, o.err
+}
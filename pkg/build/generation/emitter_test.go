@@ -0,0 +1,150 @@
+package generation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmitter returns a fixed set of files and counts calls
+type fakeEmitter struct {
+	name  string
+	files []OutputFile
+	err   error
+	calls int
+}
+
+func (f *fakeEmitter) Name() string {
+	return f.name
+}
+
+func (f *fakeEmitter) Emit(_ schema.Schema) ([]OutputFile, error) {
+	f.calls++
+	return f.files, f.err
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(&fakeEmitter{name: "b", files: []OutputFile{{Name: "b.txt", Content: []byte("b")}}}))
+	require.NoError(t, registry.Register(&fakeEmitter{name: "a", files: []OutputFile{{Name: "a.txt"}, {Name: "a2.txt"}}}))
+	require.Error(t, registry.Register(&fakeEmitter{name: "a"}))
+
+	assert.Equal(t, []string{"b", "a"}, registry.Names())
+
+	_, ok := registry.Get("b")
+	assert.True(t, ok)
+
+	file, err := registry.Emit(context.Background(), "b", schema.Schema{})
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", file.Name)
+	assert.Equal(t, []byte("b"), file.Content)
+
+	_, err = registry.Emit(context.Background(), "a", schema.Schema{})
+	require.ErrorContains(t, err, "produced 2 files")
+
+	_, err = registry.Emit(context.Background(), "missing", schema.Schema{})
+	require.ErrorContains(t, err, `unknown output target "missing"`)
+}
+
+func TestOnce(t *testing.T) {
+	inner := &fakeEmitter{name: "crd", err: errors.New("boom")}
+	e := Once(inner)
+
+	assert.Equal(t, "crd", e.Name())
+
+	_, err := e.Emit(schema.Schema{})
+	require.Error(t, err)
+	_, err = e.Emit(schema.Schema{})
+	require.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestPrefetch(t *testing.T) {
+	types := &fakeEmitter{name: "types", files: []OutputFile{{Name: "types.go"}}}
+	crd := &fakeEmitter{name: "crd", err: errors.New("boom")}
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(Once(types)))
+	require.NoError(t, registry.Register(Once(crd)))
+
+	require.ErrorContains(t, registry.Prefetch(context.Background(), schema.Schema{}, "types", "crd"), "boom")
+
+	// Later calls return the prefetched results
+	file, err := registry.Emit(context.Background(), "types", schema.Schema{})
+	require.NoError(t, err)
+	assert.Equal(t, "types.go", file.Name)
+	_, err = registry.Emit(context.Background(), "crd", schema.Schema{})
+	require.ErrorContains(t, err, "boom")
+	assert.Equal(t, 1, types.calls)
+	assert.Equal(t, 1, crd.calls)
+
+	require.ErrorContains(t, registry.Prefetch(context.Background(), schema.Schema{}, "types", "missing"), `unknown output target "missing"`)
+	assert.Equal(t, 1, types.calls)
+}
+
+// blockingEmitter returns its files once released
+type blockingEmitter struct {
+	release chan struct{}
+}
+
+func (b *blockingEmitter) Name() string {
+	return "slow"
+}
+
+func (b *blockingEmitter) Emit(_ schema.Schema) ([]OutputFile, error) {
+	<-b.release
+	return []OutputFile{{Name: "slow.txt"}}, nil
+}
+
+func TestEmit_Canceled(t *testing.T) {
+	slow := &blockingEmitter{release: make(chan struct{})}
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(Once(slow)))
+
+	// Emitting returns the cause of the context being done, without waiting for the emitter
+	timeout := errors.New("timed out")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go cancel(timeout)
+	_, err := registry.Emit(ctx, "slow", schema.Schema{})
+	require.ErrorIs(t, err, timeout)
+	require.ErrorIs(t, registry.Prefetch(ctx, schema.Schema{}, "slow"), timeout)
+
+	// The abandoned emitter's result is kept
+	close(slow.release)
+	file, err := registry.Emit(context.Background(), "slow", schema.Schema{})
+	require.NoError(t, err)
+	assert.Equal(t, "slow.txt", file.Name)
+}
+
+func TestCached(t *testing.T) {
+	c := cache.New(t.TempDir())
+	inner := &fakeEmitter{name: "types", files: []OutputFile{{Name: "types.go", Content: []byte("package v1")}}}
+	e := Cached(inner, c, "v1")
+
+	for range 2 {
+		files, err := e.Emit(schema.Schema{Kind: "App"})
+		require.NoError(t, err)
+		assert.Equal(t, inner.files, files)
+	}
+	assert.Equal(t, 1, inner.calls)
+
+	// Another schema or salt is generated again
+	_, err := e.Emit(schema.Schema{Kind: "Other"})
+	require.NoError(t, err)
+	_, err = Cached(inner, c, "v2").Emit(schema.Schema{Kind: "App"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.calls)
+
+	// Errors aren't cached
+	failing := &fakeEmitter{name: "crd", err: errors.New("boom")}
+	e = Cached(failing, c, "v1")
+	for range 2 {
+		_, err := e.Emit(schema.Schema{Kind: "App"})
+		require.ErrorContains(t, err, "boom")
+	}
+	assert.Equal(t, 2, failing.calls)
+}
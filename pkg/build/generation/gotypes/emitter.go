@@ -0,0 +1,32 @@
+package gotypes
+
+import (
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// TargetName is the output target name of the Go types emitter
+const TargetName = "gotypes"
+
+// Emitter generates Go type definitions
+type Emitter struct{}
+
+// NewEmitter creates a new Go types emitter
+func NewEmitter() *Emitter {
+	return &Emitter{}
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates types.go from the schema.
+// If the generated code fails to format, the unformatted file is returned along with the error.
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	code, err := NewGenerator(&s).Generate()
+	if len(code) == 0 {
+		return nil, err
+	}
+	return []generation.OutputFile{{Name: "types.go", Content: code}}, err
+}
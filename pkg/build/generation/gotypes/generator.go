@@ -6,13 +6,25 @@ import (
 	"fmt"
 	"go/ast"
 	"go/format"
+	"go/parser"
 	"go/printer"
 	"go/token"
+	"path"
+	"sort"
 	"strings"
 
 	"github.com/crenshaw-dev/miaka/pkg/build/schema"
 )
 
+// semanticImports are the import paths of the packages of the semantic types
+// (e.g., resource.Quantity) and freeform values, by package name
+var semanticImports = map[string]string{
+	"metav1":   "k8s.io/apimachinery/pkg/apis/meta/v1",
+	"resource": "k8s.io/apimachinery/pkg/api/resource",
+	"intstr":   "k8s.io/apimachinery/pkg/util/intstr",
+	"runtime":  "k8s.io/apimachinery/pkg/runtime",
+}
+
 // Generator handles Go code generation using AST
 type Generator struct {
 	schema *schema.Schema
@@ -36,11 +48,25 @@ func (g *Generator) Generate() ([]byte, error) {
 	}
 
 	// Add imports
-	file.Decls = append(file.Decls, g.generateImports())
+	if imports := g.generateImports(); imports != nil {
+		file.Decls = append(file.Decls, imports)
+	}
 
 	// Add main type (e.g., Example)
 	file.Decls = append(file.Decls, g.generateMainType())
 
+	// Values wrapped in Spec are on the Spec type, next to an empty Status type
+	if g.schema.WrapSpec {
+		for _, name := range []string{schema.SpecTypeName(g.schema.Kind), schema.StatusTypeName(g.schema.Kind)} {
+			for _, structDef := range g.schema.Structs {
+				if structDef.Name == name {
+					return nil, fmt.Errorf("can't wrap values in %s: a struct of the values is already named %s", name, name)
+				}
+			}
+		}
+		file.Decls = append(file.Decls, g.generateWrapperTypes()...)
+	}
+
 	// Generate all structs (except the main fields struct which was merged into the main type)
 	for _, structDef := range g.schema.Structs {
 		// Skip the struct that has the same name as Kind - its fields are on the main type
@@ -50,6 +76,11 @@ func (g *Generator) Generate() ([]byte, error) {
 		file.Decls = append(file.Decls, g.generateStruct(structDef))
 	}
 
+	// Generate named non-struct types (e.g., map value types with markers)
+	for _, typeDef := range g.schema.Types {
+		file.Decls = append(file.Decls, g.generateType(typeDef))
+	}
+
 	// Use go/printer to generate the code
 	var buf bytes.Buffer
 	cfg := printer.Config{
@@ -76,24 +107,69 @@ func (g *Generator) Generate() ([]byte, error) {
 	return formatted, nil
 }
 
-// generateImports creates the import declaration
+// generateImports creates the import declaration, including the packages of
+// any semantic types in the schema, or returns nil if nothing is imported
 func (g *Generator) generateImports() *ast.GenDecl {
+	used := g.usedPackages()
+	if !g.schema.Plain {
+		// KRM types embed metav1.TypeMeta and metav1.ObjectMeta
+		used["metav1"] = true
+	}
+
+	var specs []ast.Spec
+	for _, name := range []string{"metav1", "resource", "intstr", "runtime"} {
+		if !used[name] {
+			continue
+		}
+		spec := &ast.ImportSpec{
+			Path: &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: fmt.Sprintf("%q", semanticImports[name]),
+			},
+		}
+		if path.Base(semanticImports[name]) != name {
+			spec.Name = ast.NewIdent(name)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	// Sort by path, as goimports does
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].(*ast.ImportSpec).Path.Value < specs[j].(*ast.ImportSpec).Path.Value
+	})
 	return &ast.GenDecl{
 		Tok:    token.IMPORT,
 		Lparen: 1, // Force parentheses
-		Specs: []ast.Spec{
-			&ast.ImportSpec{
-				Name: ast.NewIdent("metav1"),
-				Path: &ast.BasicLit{
-					Kind:  token.STRING,
-					Value: `"k8s.io/apimachinery/pkg/apis/meta/v1"`,
-				},
-			},
-		},
+		Specs:  specs,
+	}
+}
+
+// usedPackages returns the names of the packages of semantic types in the schema
+func (g *Generator) usedPackages() map[string]bool {
+	used := make(map[string]bool)
+	add := func(goType string) {
+		for name := range semanticImports {
+			if strings.Contains(goType, name+".") {
+				used[name] = true
+			}
+		}
+	}
+	for _, structDef := range g.schema.Structs {
+		for _, field := range structDef.Fields {
+			add(field.Type)
+			add(field.ElemType)
+		}
+	}
+	for _, typeDef := range g.schema.Types {
+		add(typeDef.Type)
 	}
+	return used
 }
 
-// generateMainType generates the main KRM type (e.g., Example)
+// generateMainType generates the main KRM type (e.g., Example), or the type of
+// plain values
 func (g *Generator) generateMainType() *ast.GenDecl {
 	typeName := g.schema.Kind
 
@@ -112,6 +188,13 @@ func (g *Generator) generateMainType() *ast.GenDecl {
 		},
 	}
 
+	// Insert CRD markers (e.g., subresources) after the root marker
+	crdMarkers := make([]*ast.Comment, 0, len(g.schema.CRDMarkers))
+	for _, marker := range g.schema.CRDMarkers {
+		crdMarkers = append(crdMarkers, &ast.Comment{Text: "// " + marker})
+	}
+	doc.List = append(doc.List[:1], append(crdMarkers, doc.List[1:]...)...)
+
 	// Start with KRM metadata fields
 	fields := []*ast.Field{
 		{
@@ -138,20 +221,31 @@ func (g *Generator) generateMainType() *ast.GenDecl {
 		},
 	}
 
-	// Find the main fields struct (same name as Kind)
-	var mainFieldsDef *schema.StructDef
-	for i := range g.schema.Structs {
-		if g.schema.Structs[i].Name == typeName {
-			mainFieldsDef = &g.schema.Structs[i]
-			break
-		}
+	// Plain values have no apiVersion, kind, or metadata, and aren't a root object
+	if g.schema.Plain {
+		doc = &ast.CommentGroup{List: []*ast.Comment{{Text: "// " + schema.PlainDescription(typeName)}}}
+		fields = nil
 	}
 
-	// Add all main fields directly to the type
-	if mainFieldsDef != nil {
-		for _, field := range mainFieldsDef.Fields {
-			fields = append(fields, g.generateField(field))
-		}
+	// Add all main fields directly to the type, or to its Spec type when wrapped
+	if g.schema.WrapSpec {
+		fields = append(fields,
+			g.generateField(schema.Field{
+				Name:     "Spec",
+				JSONName: "spec",
+				Type:     schema.SpecTypeName(typeName),
+				Comments: []string{fmt.Sprintf("Spec is the desired state of the %s", typeName)},
+			}),
+			g.generateField(schema.Field{
+				Name:     "Status",
+				JSONName: "status",
+				Type:     schema.StatusTypeName(typeName),
+				// Controllers own the status, so it keeps the fields they write
+				Comments: []string{fmt.Sprintf("Status is the observed state of the %s", typeName), "+kubebuilder:pruning:PreserveUnknownFields"},
+			}),
+		)
+	} else {
+		fields = append(fields, g.generateMainFields()...)
 	}
 
 	return &ast.GenDecl{
@@ -170,6 +264,44 @@ func (g *Generator) generateMainType() *ast.GenDecl {
 	}
 }
 
+// generateMainFields generates the fields of the main fields struct (the struct
+// with the same name as Kind)
+func (g *Generator) generateMainFields() []*ast.Field {
+	var fields []*ast.Field
+	for _, structDef := range g.schema.Structs {
+		if structDef.Name != g.schema.Kind {
+			continue
+		}
+		for _, field := range structDef.Fields {
+			fields = append(fields, g.generateField(field))
+		}
+		break
+	}
+	return fields
+}
+
+// generateWrapperTypes generates the Spec type holding the main fields and the
+// empty Status type of a main type whose values are wrapped in Spec
+func (g *Generator) generateWrapperTypes() []ast.Decl {
+	kind := g.schema.Kind
+	wrapper := func(name, description string, fields []*ast.Field) ast.Decl {
+		return &ast.GenDecl{
+			Doc: g.createCommentGroup([]string{fmt.Sprintf("%s defines the %s of the %s", name, description, kind)}),
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent(name),
+					Type: &ast.StructType{Fields: &ast.FieldList{List: fields}},
+				},
+			},
+		}
+	}
+	return []ast.Decl{
+		wrapper(schema.SpecTypeName(kind), "desired state", g.generateMainFields()),
+		wrapper(schema.StatusTypeName(kind), "observed state", nil),
+	}
+}
+
 // generateStruct generates a struct definition
 func (g *Generator) generateStruct(structDef schema.StructDef) *ast.GenDecl {
 	// Build doc comments
@@ -212,12 +344,52 @@ func (g *Generator) generateStruct(structDef schema.StructDef) *ast.GenDecl {
 	}
 }
 
+// generateType generates a named non-struct type definition
+func (g *Generator) generateType(typeDef schema.TypeDef) *ast.GenDecl {
+	underlying, err := parser.ParseExpr(typeDef.Type)
+	if err != nil {
+		underlying = ast.NewIdent(typeDef.Type)
+	}
+	return &ast.GenDecl{
+		Doc: g.createCommentGroup(typeDef.Comments),
+		Tok: token.TYPE,
+		Specs: []ast.Spec{
+			&ast.TypeSpec{
+				Name: ast.NewIdent(typeDef.Name),
+				Type: underlying,
+			},
+		},
+	}
+}
+
+// fieldDoc returns the doc comment lines of a field. Deprecated fields get a
+// "Deprecated:" paragraph after their description, before their markers, as Go
+// tools expect.
+func fieldDoc(field schema.Field) []string {
+	if field.Deprecated == "" {
+		return field.Comments
+	}
+	var text, markers []string
+	for _, comment := range field.Comments {
+		if strings.HasPrefix(comment, "+") {
+			markers = append(markers, comment)
+		} else {
+			text = append(text, comment)
+		}
+	}
+	if len(text) > 0 {
+		text = append(text, "")
+	}
+	text = append(text, "Deprecated: "+field.Deprecated)
+	return append(text, markers...)
+}
+
 // generateField generates a field in a struct
 func (g *Generator) generateField(field schema.Field) *ast.Field {
 	// Create doc comment for the field
 	var doc *ast.CommentGroup
-	if len(field.Comments) > 0 {
-		doc = g.createCommentGroup(field.Comments)
+	if comments := fieldDoc(field); len(comments) > 0 {
+		doc = g.createCommentGroup(comments)
 	}
 
 	// Determine field type
@@ -228,6 +400,10 @@ func (g *Generator) generateField(field schema.Field) *ast.Field {
 		}
 	} else {
 		fieldType = ast.NewIdent(field.Type)
+		// Slices, maps, and interfaces are already nil when unset
+		if field.Nullable && !strings.HasPrefix(field.Type, "map[") && field.Type != string(schema.TypeInterface) {
+			fieldType = &ast.StarExpr{X: fieldType}
+		}
 	}
 
 	return &ast.Field{
@@ -251,9 +427,14 @@ func (g *Generator) createCommentGroup(comments []string) *ast.CommentGroup {
 	for i, comment := range comments {
 		// Add slash position to force newline between comments
 		slashPos := token.Pos(i + 1)
+		text := "// " + comment
+		if comment == "" {
+			// Paragraph break
+			text = "//"
+		}
 		commentList = append(commentList, &ast.Comment{
 			Slash: slashPos,
-			Text:  "// " + comment,
+			Text:  text,
 		})
 	}
 
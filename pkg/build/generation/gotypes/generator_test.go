@@ -145,6 +145,199 @@ func TestGenerate_WithKubebuilderTags(t *testing.T) {
 	assert.Contains(t, output, "+kubebuilder:validation:Maximum=65535", "Expected kubebuilder Maximum tag")
 }
 
+func TestGenerate_NamedTypes(t *testing.T) {
+	schema := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Labeled",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Labeled",
+				Fields: []schema.Field{
+					{Name: "Labels", JSONName: "labels", Type: "map[string]LabelsValue"},
+				},
+			},
+		},
+		Types: []schema.TypeDef{
+			{Name: "LabelsValue", Type: "string", Comments: []string{"+kubebuilder:validation:MaxLength=63"}},
+		},
+	}
+
+	g := NewGenerator(schema)
+	code, err := g.Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+
+	assert.Contains(t, output, "map[string]LabelsValue `json:\"labels,omitempty\"`", "Expected Labels field")
+	assert.Contains(t, output, "// +kubebuilder:validation:MaxLength=63\ntype LabelsValue string", "Expected LabelsValue type with its markers")
+}
+
+func TestGenerate_Nullable(t *testing.T) {
+	schema := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Nullable",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Nullable",
+				Fields: []schema.Field{
+					{Name: "Enabled", JSONName: "enabled", Type: "bool", Nullable: true, Comments: []string{"+nullable"}},
+					{Name: "Tags", JSONName: "tags", Type: "[]string", IsSlice: true, ElemType: "string", Nullable: true},
+					{Name: "Labels", JSONName: "labels", Type: "map[string]string", Nullable: true},
+				},
+			},
+		},
+	}
+
+	g := NewGenerator(schema)
+	code, err := g.Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+
+	assert.Contains(t, output, "*bool", "Expected nullable scalar to be a pointer")
+	assert.Contains(t, output, "[]string", "Expected nullable slice not to be a pointer")
+	assert.Contains(t, output, "map[string]string", "Expected nullable map not to be a pointer")
+	assert.NotContains(t, output, "*[]string")
+	assert.NotContains(t, output, "*map")
+}
+
+func TestGenerate_SemanticTypes(t *testing.T) {
+	schema := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Semantic",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Semantic",
+				Fields: []schema.Field{
+					{Name: "Memory", JSONName: "memory", Type: "resource.Quantity", Nullable: true},
+					{Name: "Timeouts", JSONName: "timeouts", Type: "[]metav1.Duration", IsSlice: true, ElemType: "metav1.Duration"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(schema).Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+	assert.Contains(t, output, "\"k8s.io/apimachinery/pkg/api/resource\"\n\tmetav1 \"k8s.io/apimachinery/pkg/apis/meta/v1\"\n)")
+	assert.Contains(t, output, "*resource.Quantity")
+	assert.Contains(t, output, "[]metav1.Duration")
+	assert.NotContains(t, output, "intstr", "Expected unused packages not to be imported")
+}
+
+func TestGenerate_Freeform(t *testing.T) {
+	schema := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Freeform",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Freeform",
+				Fields: []schema.Field{
+					{Name: "Affinity", JSONName: "affinity", Type: "runtime.RawExtension", Comments: []string{"+kubebuilder:validation:Schemaless"}},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(schema).Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+	assert.Contains(t, output, "\"k8s.io/apimachinery/pkg/runtime\"")
+	assert.Contains(t, output, "Affinity runtime.RawExtension")
+}
+
+func TestGenerate_Plain(t *testing.T) {
+	s := &schema.Schema{
+		APIVersion: schema.PlainAPIVersion,
+		Kind:       "ChartValues",
+		Package:    schema.PlainPackage,
+		Plain:      true,
+		Structs: []schema.StructDef{
+			{
+				Name: "ChartValues",
+				Fields: []schema.Field{
+					{Name: "ReplicaCount", JSONName: "replicaCount", Type: "int"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+	assert.Contains(t, output, "package values")
+	assert.Contains(t, output, "// ChartValues is the schema of the chart's values\ntype ChartValues struct {")
+	assert.Contains(t, output, "ReplicaCount int `json:\"replicaCount,omitempty\"`")
+	assert.NotContains(t, output, "import", "Expected no imports without semantic types")
+	assert.NotContains(t, output, "TypeMeta")
+	assert.NotContains(t, output, "+kubebuilder:object:root")
+
+	// Durations still need metav1
+	s.Structs[0].Fields = append(s.Structs[0].Fields, schema.Field{Name: "Timeout", JSONName: "timeout", Type: "metav1.Duration"})
+	code, err = NewGenerator(s).Generate()
+	require.NoError(t, err, "Generate() failed")
+	assert.Contains(t, string(code), "metav1 \"k8s.io/apimachinery/pkg/apis/meta/v1\"")
+}
+
+func TestGenerate_CRDMarkers(t *testing.T) {
+	s := &schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "App",
+		Package:    "v1",
+		CRDMarkers: []string{"+kubebuilder:subresource:status", "+kubebuilder:printcolumn:name=Replicas,JSONPath=.replicas,type=integer"},
+		Structs: []schema.StructDef{
+			{Name: "App", Fields: []schema.Field{{Name: "Replicas", JSONName: "replicas", Type: "int"}}},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err, "Generate() failed")
+	assert.Contains(t, string(code), `// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name=Replicas,JSONPath=.replicas,type=integer
+//
+// App is the Schema for the apps API
+type App struct {`)
+}
+
+func TestGenerate_WrapSpec(t *testing.T) {
+	s := &schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "App",
+		Package:    "v1",
+		WrapSpec:   true,
+		Structs: []schema.StructDef{
+			{Name: "ImageConfig", Fields: []schema.Field{{Name: "Repository", JSONName: "repository", Type: "string"}}},
+			{Name: "App", Fields: []schema.Field{
+				{Name: "Replicas", JSONName: "replicas", Type: "int"},
+				{Name: "Image", JSONName: "image", Type: "ImageConfig"},
+			}},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+	assert.Contains(t, output, "Spec AppSpec `json:\"spec,omitempty\"`")
+	assert.Contains(t, output, "// +kubebuilder:pruning:PreserveUnknownFields\n\tStatus AppStatus `json:\"status,omitempty\"`")
+	assert.Contains(t, output, "// AppSpec defines the desired state of the App\ntype AppSpec struct {\n\tReplicas int")
+	assert.Contains(t, output, "// AppStatus defines the observed state of the App\ntype AppStatus struct {\n}")
+	assert.Contains(t, output, "type ImageConfig struct")
+
+	// The values may not already use the names of the wrapper types
+	s.Structs[0].Name = "AppStatus"
+	_, err = NewGenerator(s).Generate()
+	assert.ErrorContains(t, err, "a struct of the values is already named AppStatus")
+}
+
 func TestGenerateStructDescription(t *testing.T) {
 	g := &Generator{
 		schema: &schema.Schema{
@@ -282,3 +475,28 @@ func TestGetIndent(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerate_Deprecated(t *testing.T) {
+	schema := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Deprecated",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "Deprecated",
+				Fields: []schema.Field{
+					{Name: "Repo", JSONName: "repo", Type: "string", Deprecated: "use Registry instead",
+						Comments: []string{"Repository of the image", "+kubebuilder:validation:MinLength=1"}},
+					{Name: "Tag", JSONName: "tag", Type: "string", Deprecated: "pin images by digest"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(schema).Generate()
+	require.NoError(t, err, "Generate() failed")
+
+	output := string(code)
+	assert.Contains(t, output, "\t// Repository of the image\n\t//\n\t// Deprecated: use Registry instead\n\t// +kubebuilder:validation:MinLength=1\n\tRepo string")
+	assert.Contains(t, output, "\t// Deprecated: pin images by digest\n\tTag string")
+}
@@ -0,0 +1,43 @@
+package helmtemplate
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// TargetName is the output target name of the Helm template emitter
+const TargetName = "helmtemplate"
+
+// Emitter generates a Helm template from the JSON Schema produced by another emitter
+type Emitter struct {
+	jsonSchema generation.Emitter
+}
+
+// NewEmitter creates a Helm template emitter that embeds the output of jsonSchemaEmitter
+func NewEmitter(jsonSchemaEmitter generation.Emitter) *Emitter {
+	return &Emitter{jsonSchema: jsonSchemaEmitter}
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates templates/_schema.yaml from the schema
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	files, err := e.jsonSchema.Emit(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JSON Schema: %w", err)
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("JSON Schema emitter produced %d files, expected 1", len(files))
+	}
+
+	content, err := Generate(s.Kind, files[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	return []generation.OutputFile{{Name: "templates/_schema.yaml", Content: content}}, nil
+}
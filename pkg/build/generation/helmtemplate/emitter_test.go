@@ -0,0 +1,48 @@
+package helmtemplate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticEmitter returns fixed output, standing in for the JSON Schema emitter
+type staticEmitter struct {
+	files []generation.OutputFile
+	err   error
+}
+
+func (s *staticEmitter) Name() string {
+	return "jsonschema"
+}
+
+func (s *staticEmitter) Emit(_ schema.Schema) ([]generation.OutputFile, error) {
+	return s.files, s.err
+}
+
+func TestEmitter_Emit(t *testing.T) {
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "values.schema.json", Content: []byte(testSchema)}}})
+	assert.Equal(t, TargetName, e.Name())
+
+	files, err := e.Emit(schema.Schema{Kind: "MyApp"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "templates/_schema.yaml", files[0].Name)
+	assert.Contains(t, string(files[0].Content), `{{- define "miaka.myapp.schema" -}}`)
+}
+
+func TestEmitter_JSONSchemaError(t *testing.T) {
+	e := NewEmitter(&staticEmitter{err: errors.New("boom")})
+	_, err := e.Emit(schema.Schema{Kind: "MyApp"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate JSON Schema: boom")
+
+	e = NewEmitter(&staticEmitter{})
+	_, err = e.Emit(schema.Schema{Kind: "MyApp"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "produced 0 files")
+}
@@ -0,0 +1,38 @@
+// Package helmtemplate generates a Helm template that embeds the JSON Schema and
+// validates .Values at render time, for charts that can't ship CRDs.
+package helmtemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TemplateName returns the prefix of the named templates generated for a kind (e.g., "miaka.myapp")
+func TemplateName(kind string) string {
+	return "miaka." + strings.ToLower(kind)
+}
+
+// Generate creates the Helm template file from a JSON Schema
+func Generate(kind string, schemaJSON []byte) ([]byte, error) {
+	if kind == "" {
+		return nil, fmt.Errorf("kind is required")
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, schemaJSON, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	// Template delimiters can only appear inside JSON strings, where the
+	// escaped braces decode to the same value
+	schema := strings.NewReplacer("{{", `\u007b\u007b`, "}}", `\u007d\u007d`).Replace(indented.String())
+
+	content := strings.NewReplacer(
+		"__NAME__", TemplateName(kind),
+		"__KIND__", kind,
+		"__SCHEMA__", schema,
+	).Replace(helmTemplate)
+	return []byte(content), nil
+}
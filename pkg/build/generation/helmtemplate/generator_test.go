@@ -0,0 +1,285 @@
+package helmtemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "apiVersion": {"type": "string"},
+    "kind": {"type": "string"},
+    "replicas": {"type": "integer", "minimum": 1, "maximum": 10},
+    "ratio": {"type": "number", "exclusiveMinimum": 0},
+    "image": {
+      "description": "Rendered with {{ .Values.image }} in templates",
+      "properties": {
+        "repository": {"type": "string", "minLength": 1},
+        "tag": {"type": "string", "pattern": "^[a-z0-9.]+$"},
+        "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent"]}
+      },
+      "required": ["repository"],
+      "additionalProperties": false,
+      "type": "object"
+    },
+    "ports": {
+      "items": {"properties": {"port": {"type": "integer"}}, "type": "object"},
+      "maxItems": 2,
+      "type": "array"
+    },
+    "labels": {"additionalProperties": {"type": "string"}, "type": "object"},
+    "owner": {"type": "string", "nullable": true}
+  },
+  "type": "object"
+}`
+
+// sprigFuncs implements the subset of Helm's template functions used by the generated template
+func sprigFuncs(tpl **template.Template) template.FuncMap {
+	toFloat := func(v interface{}) float64 {
+		switch v := v.(type) {
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case float64:
+			return v
+		}
+		return 0
+	}
+	return template.FuncMap{
+		"include": func(name string, data interface{}) (string, error) {
+			var buf bytes.Buffer
+			err := (*tpl).ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"fail": func(msg string) (string, error) { return "", errors.New(msg) },
+		"fromJson": func(s string) map[string]interface{} {
+			m := map[string]interface{}{}
+			_ = json.Unmarshal([]byte(s), &m)
+			return m
+		},
+		"dict": func(v ...interface{}) map[string]interface{} {
+			m := map[string]interface{}{}
+			for i := 0; i+1 < len(v); i += 2 {
+				m[v[i].(string)] = v[i+1]
+			}
+			return m
+		},
+		"set": func(d map[string]interface{}, k string, v interface{}) map[string]interface{} {
+			d[k] = v
+			return d
+		},
+		"get": func(d map[string]interface{}, k string) interface{} {
+			if v, ok := d[k]; ok {
+				return v
+			}
+			return ""
+		},
+		"hasKey": func(d map[string]interface{}, k string) bool {
+			_, ok := d[k]
+			return ok
+		},
+		"keys": func(d map[string]interface{}) []string {
+			keys := make([]string, 0, len(d))
+			for k := range d {
+				keys = append(keys, k)
+			}
+			return keys
+		},
+		"sortAlpha": func(l []string) []string {
+			sort.Strings(l)
+			return l
+		},
+		"list":   func(v ...interface{}) []interface{} { return v },
+		"append": func(l []interface{}, v interface{}) []interface{} { return append(l, v) },
+		"has": func(needle interface{}, haystack []interface{}) bool {
+			for _, v := range haystack {
+				if reflect.DeepEqual(v, needle) {
+					return true
+				}
+			}
+			return false
+		},
+		"kindOf": func(v interface{}) string { return reflect.ValueOf(v).Kind().String() },
+		"kindIs": func(k string, v interface{}) bool { return reflect.ValueOf(v).Kind().String() == k },
+		"default": func(d interface{}, given ...interface{}) interface{} {
+			if len(given) == 0 || given[0] == nil || reflect.ValueOf(given[0]).IsZero() {
+				return d
+			}
+			return given[0]
+		},
+		"float64":    toFloat,
+		"int64":      func(v interface{}) int64 { return int64(toFloat(v)) },
+		"toString":   func(v interface{}) string { return fmt.Sprint(v) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"regexMatch": func(re, s string) bool { return regexp.MustCompile(re).MatchString(s) },
+		"join": func(sep string, l []interface{}) string {
+			parts := make([]string, len(l))
+			for i, v := range l {
+				parts[i] = fmt.Sprint(v)
+			}
+			return strings.Join(parts, sep)
+		},
+		"ternary": func(vt, vf interface{}, c bool) interface{} {
+			if c {
+				return vt
+			}
+			return vf
+		},
+		"trunc": func(n int, s string) string {
+			if len(s) > n {
+				return s[:n]
+			}
+			return s
+		},
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"indent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+	}
+}
+
+// render executes a named template from the generated file the way Helm would
+func render(t *testing.T, content []byte, name string, values map[string]interface{}) (string, error) {
+	t.Helper()
+	var tpl *template.Template
+	tpl = template.Must(template.New("_schema.yaml").Funcs(sprigFuncs(&tpl)).Parse(string(content)))
+
+	var buf bytes.Buffer
+	err := tpl.ExecuteTemplate(&buf, name, map[string]interface{}{
+		"Values":  values,
+		"Release": map[string]interface{}{"Name": "demo", "Service": "Helm"},
+	})
+	return buf.String(), err
+}
+
+func TestGenerate_Schema(t *testing.T) {
+	content, err := Generate("MyApp", []byte(testSchema))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `{{- define "miaka.myapp.validate" -}}`)
+
+	// The embedded schema decodes to the original, including template delimiters in strings
+	out, err := render(t, content, "miaka.myapp.schema", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, testSchema, out)
+}
+
+func TestGenerate_SchemaConfigMap(t *testing.T) {
+	content, err := Generate("MyApp", []byte(`{"type":"object"}`))
+	require.NoError(t, err)
+
+	out, err := render(t, content, "miaka.myapp.schemaConfigMap", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: demo-values-schema
+  labels:
+    app.kubernetes.io/managed-by: Helm
+data:
+  values.schema.json: |-
+    {
+      "type": "object"
+    }`, out)
+}
+
+func TestGenerate_Validate(t *testing.T) {
+	content, err := Generate("MyApp", []byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		values     string
+		wantErrors []string
+	}{
+		{
+			name: "valid",
+			values: `{"apiVersion": "example.com/v1", "kind": "MyApp", "replicas": 3, "ratio": 0.5,
+				"image": {"repository": "nginx", "tag": "1.25", "pullPolicy": "Always"},
+				"ports": [{"port": 80}], "labels": {"app": "web"}, "owner": null}`,
+		},
+		{
+			name:       "wrong type",
+			values:     `{"replicas": "three", "ratio": 1.5}`,
+			wantErrors: []string{"replicas: expected integer, got string"},
+		},
+		{
+			name:       "fractional integer",
+			values:     `{"replicas": 1.5}`,
+			wantErrors: []string{"replicas: expected integer, got number"},
+		},
+		{
+			name:       "bounds",
+			values:     `{"replicas": 11, "ratio": 0}`,
+			wantErrors: []string{"ratio: 0 must be greater than 0", "replicas: 11 is greater than the maximum 10"},
+		},
+		{
+			name:   "nested object",
+			values: `{"image": {"tag": "Latest!", "pullPolicy": "Never", "digest": "sha256"}}`,
+			wantErrors: []string{
+				"image.repository: required value is missing",
+				"image.digest: unknown field",
+				`image.pullPolicy: Never is not one of [Always IfNotPresent]`,
+				`image.tag: "Latest!" does not match pattern "^[a-z0-9.]+$"`,
+			},
+		},
+		{
+			name:       "lists and maps",
+			values:     `{"ports": [{"port": 80}, {"port": "http"}, {}], "labels": {"app": 1}}`,
+			wantErrors: []string{"labels.app: expected string, got integer", "ports: must have at most 2 items", "ports[1].port: expected integer, got string"},
+		},
+		{
+			name:       "empty string",
+			values:     `{"image": {"repository": ""}}`,
+			wantErrors: []string{"image.repository: length must be at least 1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.values), &values))
+
+			_, err := render(t, content, "miaka.myapp.validate", values)
+			if len(tt.wantErrors) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "values don't match the MyApp schema:\n"+strings.Join(tt.wantErrors, "\n"))
+		})
+	}
+}
+
+func TestGenerate_ValidateIntegerValues(t *testing.T) {
+	content, err := Generate("MyApp", []byte(testSchema))
+	require.NoError(t, err)
+
+	// Newer Helm versions decode integers as int64 rather than float64
+	_, err = render(t, content, "miaka.myapp.validate", map[string]interface{}{"replicas": int64(3)})
+	require.NoError(t, err)
+	_, err = render(t, content, "miaka.myapp.validate", map[string]interface{}{"replicas": int64(0)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "replicas: 0 is less than the minimum 1")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate("", []byte(testSchema))
+	require.Error(t, err)
+
+	_, err = Generate("MyApp", []byte("{"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse JSON Schema")
+}
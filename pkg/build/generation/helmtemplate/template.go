@@ -0,0 +1,155 @@
+package helmtemplate
+
+// helmTemplate is the Helm template file. __NAME__ is replaced with the template
+// name prefix, __KIND__ with the kind, and __SCHEMA__ with the JSON Schema.
+const helmTemplate = `{{/*
+Code generated by miaka. DO NOT EDIT.
+
+Render-time validation of __KIND__ values, for library charts and other
+environments that can't ship a CRD or rely on values.schema.json.
+
+Validate .Values from any template (fails rendering on errors):
+
+  {{- include "__NAME__.validate" . }}
+
+Render the JSON Schema as a ConfigMap:
+
+  {{ include "__NAME__.schemaConfigMap" . }}
+*/}}
+
+{{/* __NAME__.schema is the JSON Schema for __KIND__ values */}}
+{{- define "__NAME__.schema" -}}
+__SCHEMA__
+{{- end -}}
+
+{{/* __NAME__.schemaConfigMap is a ConfigMap containing the JSON Schema */}}
+{{- define "__NAME__.schemaConfigMap" -}}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ printf "%s-values-schema" .Release.Name | trunc 63 | trimSuffix "-" }}
+  labels:
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+data:
+  values.schema.json: |-
+{{ include "__NAME__.schema" . | indent 4 }}
+{{- end -}}
+
+{{/* __NAME__.validate fails rendering if .Values doesn't match the schema */}}
+{{- define "__NAME__.validate" -}}
+{{- $state := dict "errors" (list) -}}
+{{- $schema := include "__NAME__.schema" . | fromJson -}}
+{{- include "__NAME__.validateValue" (dict "schema" $schema "value" .Values "path" "" "state" $state) -}}
+{{- if $state.errors -}}
+{{- fail (printf "values don't match the __KIND__ schema:\n%s" (join "\n" $state.errors)) -}}
+{{- end -}}
+{{- end -}}
+
+{{/* __NAME__.validateValue validates .value against .schema, adding errors to .state.errors */}}
+{{- define "__NAME__.validateValue" -}}
+{{- $schema := .schema -}}
+{{- $value := .value -}}
+{{- $state := .state -}}
+{{- $path := .path | default "<root>" -}}
+{{- $kind := kindOf $value -}}
+{{- $type := "null" -}}
+{{- if kindIs "map" $value -}}
+{{- $type = "object" -}}
+{{- else if kindIs "slice" $value -}}
+{{- $type = "array" -}}
+{{- else if kindIs "string" $value -}}
+{{- $type = "string" -}}
+{{- else if kindIs "bool" $value -}}
+{{- $type = "boolean" -}}
+{{- else if has $kind (list "int" "int8" "int16" "int32" "int64" "uint" "uint8" "uint16" "uint32" "uint64" "float32" "float64") -}}
+{{- $type = "number" -}}
+{{- if eq (float64 $value) (float64 (int64 $value)) -}}
+{{- $type = "integer" -}}
+{{- end -}}
+{{- end -}}
+{{- $types := $schema.type -}}
+{{- if kindIs "string" $types -}}
+{{- $types = list $types -}}
+{{- end -}}
+{{- if and (eq $type "null") (or $schema.nullable (has "null" $types)) -}}
+{{- else if and $types (not (has $type $types)) (not (and (eq $type "integer") (has "number" $types))) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: expected %s, got %s" $path (join " or " $types) $type)) -}}
+{{- else -}}
+{{- if hasKey $schema "enum" -}}
+{{- $found := false -}}
+{{- range $schema.enum -}}
+{{- if eq (toString .) (toString $value) -}}
+{{- $found = true -}}
+{{- end -}}
+{{- end -}}
+{{- if not $found -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %v is not one of %v" $path $value $schema.enum)) -}}
+{{- end -}}
+{{- end -}}
+{{- if or (eq $type "number") (eq $type "integer") -}}
+{{- if and (hasKey $schema "minimum") (lt (float64 $value) (float64 $schema.minimum)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %v is less than the minimum %v" $path $value $schema.minimum)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "maximum") (gt (float64 $value) (float64 $schema.maximum)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %v is greater than the maximum %v" $path $value $schema.maximum)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "exclusiveMinimum") (le (float64 $value) (float64 $schema.exclusiveMinimum)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %v must be greater than %v" $path $value $schema.exclusiveMinimum)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "exclusiveMaximum") (ge (float64 $value) (float64 $schema.exclusiveMaximum)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %v must be less than %v" $path $value $schema.exclusiveMaximum)) -}}
+{{- end -}}
+{{- end -}}
+{{- if eq $type "string" -}}
+{{- $length := len (splitList "" $value) -}}
+{{- if and (hasKey $schema "minLength") (lt (float64 $length) (float64 $schema.minLength)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: length must be at least %v" $path $schema.minLength)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "maxLength") (gt (float64 $length) (float64 $schema.maxLength)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: length must be at most %v" $path $schema.maxLength)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "pattern") (not (regexMatch $schema.pattern $value)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: %q does not match pattern %q" $path $value $schema.pattern)) -}}
+{{- end -}}
+{{- end -}}
+{{- if eq $type "array" -}}
+{{- if and (hasKey $schema "minItems") (lt (float64 (len $value)) (float64 $schema.minItems)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: must have at least %v items" $path $schema.minItems)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "maxItems") (gt (float64 (len $value)) (float64 $schema.maxItems)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: must have at most %v items" $path $schema.maxItems)) -}}
+{{- end -}}
+{{- if kindIs "map" $schema.items -}}
+{{- range $i, $item := $value -}}
+{{- include "__NAME__.validateValue" (dict "schema" $schema.items "value" $item "path" (printf "%s[%d]" $path $i) "state" $state) -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- if eq $type "object" -}}
+{{- $prefix := ternary "" (printf "%s." $path) (eq $path "<root>") -}}
+{{- range $schema.required -}}
+{{- if not (hasKey $value .) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s%s: required value is missing" $prefix .)) -}}
+{{- end -}}
+{{- end -}}
+{{- if and (hasKey $schema "minProperties") (lt (float64 (len $value)) (float64 $schema.minProperties)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: must have at least %v properties" $path $schema.minProperties)) -}}
+{{- end -}}
+{{- if and (hasKey $schema "maxProperties") (gt (float64 (len $value)) (float64 $schema.maxProperties)) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: must have at most %v properties" $path $schema.maxProperties)) -}}
+{{- end -}}
+{{- $properties := $schema.properties | default dict -}}
+{{- range $key := keys $value | sortAlpha -}}
+{{- $child := dict "value" (get $value $key) "path" (printf "%s%s" $prefix $key) "state" $state -}}
+{{- if hasKey $properties $key -}}
+{{- include "__NAME__.validateValue" (set $child "schema" (get $properties $key)) -}}
+{{- else if kindIs "map" $schema.additionalProperties -}}
+{{- include "__NAME__.validateValue" (set $child "schema" $schema.additionalProperties) -}}
+{{- else if and (hasKey $schema "additionalProperties") (not $schema.additionalProperties) -}}
+{{- $_ := set $state "errors" (append $state.errors (printf "%s: unknown field" $child.path)) -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+`
@@ -0,0 +1,97 @@
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// TargetName is the output target name of the JSON Schema emitter
+const TargetName = "jsonschema"
+
+// Emitter generates a JSON Schema from the CRD produced by another emitter
+type Emitter struct {
+	crd generation.Emitter
+
+	// HelmCompat adapts the schema to the way Helm validates values (see HelmCompatible)
+	HelmCompat bool
+	// Titles controls the titles of the properties (see AddTitles)
+	Titles TitleMode
+	// KRM keeps the apiVersion, kind, and metadata of resources, to validate full
+	// KRM documents (see KeepKRMFields). Plain values have none.
+	KRM bool
+	// ValuesOnly leaves apiVersion and kind out, for Helm values files whose
+	// resource form the ResourceEmitter's schema validates (see DropKRMFields)
+	ValuesOnly bool
+}
+
+// NewEmitter creates a JSON Schema emitter that converts the output of crdEmitter.
+// Wrap crdEmitter with generation.Once to share a single CRD generation within a build.
+func NewEmitter(crdEmitter generation.Emitter) *Emitter {
+	return &Emitter{crd: crdEmitter}
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates values.schema.json from the schema
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	files, err := e.crd.Emit(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("CRD emitter produced %d files, expected 1", len(files))
+	}
+
+	content, err := GenerateFromCRDContent(files[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	if s.Plain {
+		// The CRD of plain values is generated from KRM types
+		content, err = toPlainSchema(content, schema.PlainDescription(s.Kind))
+		if err != nil {
+			return nil, err
+		}
+	} else if e.ValuesOnly {
+		if content, err = DropKRMFields(content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Aliases of renamed fields copy their deprecations
+	if content, err = MarkDeprecated(content, s.Deprecations()); err != nil {
+		return nil, err
+	}
+	if content, err = AddRenamedProperties(content, s.Renames()); err != nil {
+		return nil, err
+	}
+	if e.KRM && !s.Plain {
+		if content, err = KeepKRMFields(content, s.APIVersion, s.Kind); err != nil {
+			return nil, err
+		}
+	}
+	if content, err = AddTitles(content, e.Titles); err != nil {
+		return nil, err
+	}
+	if e.HelmCompat {
+		if content, err = HelmCompatible(content); err != nil {
+			return nil, err
+		}
+	}
+
+	// Order the properties like the example values, since marshaling sorts them
+	if content, err = OrderProperties(content, s.PropertyOrder()); err != nil {
+		return nil, err
+	}
+
+	// Catch generator bugs here, rather than as confusing errors from Helm or IDEs
+	if err := ValidateMetaSchema(content); err != nil {
+		return nil, fmt.Errorf("generated JSON Schema is invalid: %w", err)
+	}
+	return []generation.OutputFile{{Name: "values.schema.json", Content: content}}, nil
+}
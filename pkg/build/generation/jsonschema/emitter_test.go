@@ -0,0 +1,127 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticEmitter returns fixed output, standing in for the CRD emitter
+type staticEmitter struct {
+	files []generation.OutputFile
+	err   error
+}
+
+func (s *staticEmitter) Name() string {
+	return "crd"
+}
+
+func (s *staticEmitter) Emit(_ schema.Schema) ([]generation.OutputFile, error) {
+	return s.files, s.err
+}
+
+func TestEmitter_Emit(t *testing.T) {
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          replicas:
+            type: integer
+`
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(crdContent)}}})
+	assert.Equal(t, TargetName, e.Name())
+
+	files, err := e.Emit(schema.Schema{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "values.schema.json", files[0].Name)
+	assert.Contains(t, string(files[0].Content), `"replicas"`)
+	assert.Contains(t, string(files[0].Content), `"$schema"`)
+}
+
+func TestEmitter_Plain(t *testing.T) {
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: values.values.miaka.dev
+spec:
+  group: values.miaka.dev
+  names:
+    kind: Values
+    plural: values
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          replicas:
+            type: integer
+`
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(crdContent)}}})
+
+	files, err := e.Emit(schema.Schema{Kind: "Values", Plain: true})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Contains(t, string(files[0].Content), `"replicas"`)
+	assert.Contains(t, string(files[0].Content), `"description": "Values is the schema of the chart's values"`)
+	assert.NotContains(t, string(files[0].Content), `"apiVersion"`)
+	assert.NotContains(t, string(files[0].Content), `"kind"`)
+
+	// KRM values keep them
+	files, err = e.Emit(schema.Schema{})
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), `"apiVersion"`)
+}
+
+func TestEmitter_CRDError(t *testing.T) {
+	e := NewEmitter(&staticEmitter{err: errors.New("controller-gen failed")})
+
+	_, err := e.Emit(schema.Schema{})
+	require.ErrorContains(t, err, "controller-gen failed")
+}
+
+func TestEmitter_InvalidSchema(t *testing.T) {
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          name:
+            type: string
+            pattern: "[a-z"
+`
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(crdContent)}}})
+	_, err := e.Emit(schema.Schema{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "generated JSON Schema is invalid")
+}
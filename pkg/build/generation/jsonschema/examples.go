@@ -0,0 +1,77 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// AddExamples adds the values of example values (YAML) to the properties of a
+// JSON Schema, as the only item of their examples, so editors and documentation
+// generators can show them. Objects with properties get examples for their
+// properties instead, and lists of objects for the properties of their first
+// item. Empty values, and properties that already have examples or refer to
+// another schema (e.g., the schemas of subcharts), are skipped.
+func AddExamples(data, values []byte) ([]byte, error) {
+	jsonValues, err := yaml.YAMLToJSON(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse example values: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonValues))
+	decoder.UseNumber()
+	var example map[string]interface{}
+	if err := decoder.Decode(&example); err != nil {
+		return nil, fmt.Errorf("failed to parse example values: %w", err)
+	}
+	return editSchema(data, func(root map[string]interface{}) {
+		addExamples(root, example)
+	})
+}
+
+// addExamples adds the examples of the properties of an object schema
+func addExamples(schema map[string]interface{}, values map[string]interface{}) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, value := range values {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok || property["$ref"] != nil {
+			continue
+		}
+		addExample(property, value)
+	}
+}
+
+// addExample adds the example of a property, or of the properties nested in it
+func addExample(property map[string]interface{}, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return
+		}
+		if _, ok := property["properties"].(map[string]interface{}); ok {
+			addExamples(property, v)
+			return
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return
+		}
+		items, _ := property["items"].(map[string]interface{})
+		if first, ok := v[0].(map[string]interface{}); ok && items != nil {
+			if _, ok := items["properties"].(map[string]interface{}); ok {
+				addExamples(items, first)
+				return
+			}
+		}
+	}
+	if _, ok := property["examples"]; !ok {
+		property["examples"] = []interface{}{value}
+	}
+}
@@ -0,0 +1,91 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddExamples(t *testing.T) {
+	input := []byte(`{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer"},
+    "image": {
+      "type": "object",
+      "properties": {"repository": {"type": "string"}, "tag": {"type": "string"}}
+    },
+    "env": {
+      "type": "array",
+      "items": {"type": "object", "properties": {"name": {"type": "string"}, "value": {"type": "string"}}}
+    },
+    "args": {"type": "array", "items": {"type": "string"}},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "ratio": {"type": "number", "examples": [0.5]},
+    "subchart": {"$ref": "charts/subchart/values.schema.json"},
+    "nodeSelector": {"type": "object"},
+    "tolerations": {"type": "array"},
+    "annotations": {"type": "string"}
+  }
+}`)
+	values := []byte(`replicaCount: 9007199254740993
+image:
+  repository: nginx
+  tag: ""
+env:
+  - name: LOG_LEVEL
+    value: debug
+  - name: IGNORED
+args: [--verbose]
+labels:
+  app: web
+ratio: 0.25
+subchart:
+  enabled: true
+nodeSelector: {}
+tolerations: []
+annotations: null
+unknown: 1
+`)
+
+	output, err := AddExamples(input, values)
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&schema))
+	properties := schema.Properties
+
+	// Large integers keep their precision
+	assert.Equal(t, []interface{}{json.Number("9007199254740993")}, properties["replicaCount"]["examples"])
+
+	image := properties["image"]["properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"nginx"}, image["repository"].(map[string]interface{})["examples"])
+	assert.NotContains(t, image["tag"], "examples")
+	assert.NotContains(t, properties["image"], "examples")
+
+	// Lists of objects give examples to the properties of their items
+	env := properties["env"]["items"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"LOG_LEVEL"}, env["name"].(map[string]interface{})["examples"])
+	assert.Equal(t, []interface{}{"debug"}, env["value"].(map[string]interface{})["examples"])
+	assert.NotContains(t, properties["env"], "examples")
+
+	assert.Equal(t, []interface{}{[]interface{}{"--verbose"}}, properties["args"]["examples"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"app": "web"}}, properties["labels"]["examples"])
+	assert.Equal(t, []interface{}{json.Number("0.5")}, properties["ratio"]["examples"])
+
+	for _, name := range []string{"subchart", "nodeSelector", "tolerations", "annotations"} {
+		assert.NotContains(t, properties[name], "examples", name)
+	}
+	assert.NotContains(t, properties, "unknown")
+}
+
+func TestAddExamples_InvalidValues(t *testing.T) {
+	_, err := AddExamples([]byte(`{"type": "object"}`), []byte("a: [\n"))
+	require.ErrorContains(t, err, "failed to parse example values")
+}
@@ -4,56 +4,119 @@ package jsonschema
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"sigs.k8s.io/yaml"
 )
 
 // GenerateFromCRD extracts the OpenAPI v3 schema from a CRD and converts it to JSON Schema
 func GenerateFromCRD(crdPath, outputPath string) error {
+	return GenerateFromCRDFS(filesystem.OS(), crdPath, outputPath)
+}
+
+// GenerateFromCRDFS is GenerateFromCRD for files in fsys
+func GenerateFromCRDFS(fsys filesystem.FS, crdPath, outputPath string) error {
 	// Read CRD file
-	crdBytes, err := os.ReadFile(crdPath)
+	crdBytes, err := fsys.ReadFile(crdPath)
 	if err != nil {
 		return fmt.Errorf("failed to read CRD file: %w", err)
 	}
 
-	// Parse CRD
-	var crd apiextensionsv1.CustomResourceDefinition
-	if err := yaml.Unmarshal(crdBytes, &crd); err != nil {
-		return fmt.Errorf("failed to parse CRD YAML: %w", err)
+	jsonBytes, err := GenerateFromCRDContent(crdBytes)
+	if err != nil {
+		return err
 	}
 
-	// Find the first version with a schema
-	var schema *apiextensionsv1.JSONSchemaProps
-	for _, version := range crd.Spec.Versions {
-		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
-			schema = version.Schema.OpenAPIV3Schema
-			break
-		}
+	if err := fsys.WriteFile(outputPath, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON Schema file: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateFromCRDContent converts the OpenAPI v3 schema in CRD YAML content to JSON Schema
+func GenerateFromCRDContent(crdBytes []byte) ([]byte, error) {
+	crdDef, schema, err := resourceSchema(crdBytes)
+	if err != nil {
+		return nil, err
+	}
+	if crd.WrapsSpec(crdDef) {
+		schema = unwrapSpec(schema)
 	}
 
-	if schema == nil {
-		return fmt.Errorf("no schema found in CRD")
+	return GenerateFromSchema(schema)
+}
+
+// resourceSchema parses CRD YAML content and returns the schema of its first
+// version with one
+func resourceSchema(crdBytes []byte) (*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.JSONSchemaProps, error) {
+	var crdDef apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(crdBytes, &crdDef); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CRD YAML: %w", err)
 	}
+	for _, version := range crdDef.Spec.Versions {
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			return &crdDef, version.Schema.OpenAPIV3Schema, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no schema found in CRD")
+}
 
+// GenerateFromSchema converts the OpenAPI v3 schema of a CRD version to JSON Schema
+func GenerateFromSchema(schema *apiextensionsv1.JSONSchemaProps) ([]byte, error) {
 	// Convert to JSON Schema format
 	jsonSchema, err := convertToJSONSchema(schema)
 	if err != nil {
-		return fmt.Errorf("failed to convert to JSON Schema: %w", err)
+		return nil, fmt.Errorf("failed to convert to JSON Schema: %w", err)
 	}
 
-	// Write JSON Schema file
 	jsonBytes, err := json.MarshalIndent(jsonSchema, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON Schema: %w", err)
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
 	}
 
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write JSON Schema file: %w", err)
+	return jsonBytes, nil
+}
+
+// unwrapSpec returns the schema of the values of a resource that holds them in
+// spec: the schema of spec, with the resource's apiVersion and kind
+func unwrapSpec(resource *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	spec, ok := resource.Properties[crd.SpecField]
+	if !ok {
+		return resource
 	}
+	values := spec.DeepCopy()
+	values.Description = resource.Description
+	for _, key := range []string{"apiVersion", "kind"} {
+		if prop, ok := resource.Properties[key]; ok {
+			if values.Properties == nil {
+				values.Properties = make(map[string]apiextensionsv1.JSONSchemaProps)
+			}
+			values.Properties[key] = prop
+		}
+	}
+	return values
+}
 
-	return nil
+// toPlainSchema removes the apiVersion and kind properties of KRM types from a
+// JSON Schema, and replaces the description of the KRM type
+func toPlainSchema(schemaJSON []byte, description string) ([]byte, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		delete(properties, "apiVersion")
+		delete(properties, "kind")
+	}
+	schema["description"] = description
+	jsonBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return jsonBytes, nil
 }
 
 // convertToJSONSchema converts an OpenAPI v3 schema to JSON Schema format
@@ -78,12 +141,117 @@ func convertToJSONSchema(openAPISchema *apiextensionsv1.JSONSchemaProps) (map[st
 		delete(properties, "metadata")
 	}
 
+	// Objects that preserve unknown fields accept any other properties
+	convertPreserveUnknownFields(schema)
+
+	// The items of set lists are unique, which draft-07 checks with uniqueItems
+	convertListSets(schema)
+
 	// Remove Kubernetes-specific extensions if present
 	removeKubernetesExtensions(schema)
 
+	// OpenAPI v3 exclusive bounds are booleans; draft-07 uses the bound itself
+	convertExclusiveBounds(schema)
+
+	// OpenAPI v3 "nullable" isn't a draft-07 keyword; draft-07 adds "null" to the type
+	convertNullable(schema)
+
 	return schema, nil
 }
 
+// convertNullable recursively replaces OpenAPI v3 "nullable: true" with a "null"
+// type (e.g., "type": ["boolean", "null"]), also allowing null in enums. Schemas
+// without a type (e.g., int-or-string) get a "null" alternative in their anyOf.
+func convertNullable(obj interface{}) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		if nullable, ok := v["nullable"].(bool); ok {
+			delete(v, "nullable")
+			if schemaType, hasType := v["type"].(string); nullable && hasType {
+				v["type"] = []interface{}{schemaType, "null"}
+				if enum, ok := v["enum"].([]interface{}); ok {
+					v["enum"] = append(enum, nil)
+				}
+			} else if anyOf, ok := v["anyOf"].([]interface{}); nullable && ok {
+				v["anyOf"] = append(anyOf, map[string]interface{}{"type": "null"})
+			}
+		}
+		for _, value := range v {
+			convertNullable(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			convertNullable(item)
+		}
+	}
+}
+
+// convertPreserveUnknownFields recursively sets "additionalProperties: true" on
+// objects with "x-kubernetes-preserve-unknown-fields: true", the draft-07
+// equivalent, replacing any additionalProperties: false
+func convertPreserveUnknownFields(obj interface{}) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		if preserve, _ := v["x-kubernetes-preserve-unknown-fields"].(bool); preserve && v["type"] == "object" {
+			if _, isSchema := v["additionalProperties"].(map[string]interface{}); !isSchema {
+				v["additionalProperties"] = true
+			}
+		}
+		for _, value := range v {
+			convertPreserveUnknownFields(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			convertPreserveUnknownFields(item)
+		}
+	}
+}
+
+// convertListSets recursively sets "uniqueItems: true" on arrays with
+// "x-kubernetes-list-type: set"
+func convertListSets(obj interface{}) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		if v["x-kubernetes-list-type"] == "set" {
+			v["uniqueItems"] = true
+		}
+		for _, value := range v {
+			convertListSets(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			convertListSets(item)
+		}
+	}
+}
+
+// convertExclusiveBounds recursively replaces OpenAPI v3 "exclusiveMinimum: true" with
+// draft-07 "exclusiveMinimum: <minimum>" (and likewise for maximum)
+func convertExclusiveBounds(obj interface{}) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		for exclusiveKey, boundKey := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+			exclusive, ok := v[exclusiveKey].(bool)
+			if !ok {
+				continue
+			}
+			if bound, hasBound := v[boundKey]; exclusive && hasBound {
+				v[exclusiveKey] = bound
+				delete(v, boundKey)
+			} else {
+				delete(v, exclusiveKey)
+			}
+		}
+		for _, value := range v {
+			convertExclusiveBounds(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			convertExclusiveBounds(item)
+		}
+	}
+}
+
 // removeKubernetesExtensions recursively removes x-kubernetes-* fields
 func removeKubernetesExtensions(obj interface{}) {
 	switch v := obj.(type) {
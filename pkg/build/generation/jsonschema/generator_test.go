@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -335,3 +336,121 @@ func TestConvertToJSONSchema_WithMultipleVersions(t *testing.T) {
 	assert.True(t, hasVersion, "version field from v1 schema should exist")
 }
 
+
+func TestConvertExclusiveBounds(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"ratio": map[string]interface{}{"type": "number", "minimum": 0.0, "exclusiveMinimum": true},
+			"port":  map[string]interface{}{"type": "integer", "maximum": 10.0, "exclusiveMaximum": false},
+			"items": []interface{}{
+				map[string]interface{}{"exclusiveMaximum": true},
+			},
+		},
+	}
+
+	convertExclusiveBounds(schema)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "number", "exclusiveMinimum": 0.0}, properties["ratio"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "maximum": 10.0}, properties["port"])
+	assert.Equal(t, map[string]interface{}{}, properties["items"].([]interface{})[0])
+}
+
+func TestConvertNullable(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"enabled":  map[string]interface{}{"type": "boolean", "nullable": true},
+			"mode":     map[string]interface{}{"type": "string", "nullable": true, "enum": []interface{}{"a", "b"}},
+			"port":     map[string]interface{}{"type": "integer", "nullable": false},
+			"anything": map[string]interface{}{"nullable": true},
+			"quantity": map[string]interface{}{"nullable": true, "anyOf": []interface{}{map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"}}},
+		},
+	}
+
+	convertNullable(schema)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": []interface{}{"boolean", "null"}}, properties["enabled"])
+	assert.Equal(t, map[string]interface{}{"type": []interface{}{"string", "null"}, "enum": []interface{}{"a", "b", nil}}, properties["mode"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["port"])
+	assert.Equal(t, map[string]interface{}{}, properties["anything"])
+	assert.Equal(t, map[string]interface{}{"anyOf": []interface{}{
+		map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "null"},
+	}}, properties["quantity"])
+}
+
+func TestConvertPreserveUnknownFields(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"open":     map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true, "additionalProperties": false},
+			"labels":   map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true, "additionalProperties": map[string]interface{}{"type": "string"}},
+			"freeform": map[string]interface{}{"x-kubernetes-preserve-unknown-fields": true},
+			"strict":   map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": false},
+			"unmarked": map[string]interface{}{"type": "object"},
+		},
+	}
+
+	convertPreserveUnknownFields(schema)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, true, properties["open"].(map[string]interface{})["additionalProperties"])
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["labels"].(map[string]interface{})["additionalProperties"])
+	assert.NotContains(t, properties["freeform"], "additionalProperties")
+	assert.NotContains(t, properties["strict"], "additionalProperties")
+	assert.NotContains(t, properties["unmarked"], "additionalProperties")
+}
+
+func TestConvertListSets(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"tags":  map[string]interface{}{"type": "array", "x-kubernetes-list-type": "set"},
+			"env":   map[string]interface{}{"type": "array", "x-kubernetes-list-type": "map", "x-kubernetes-list-map-keys": []interface{}{"name"}},
+			"args":  map[string]interface{}{"type": "array", "x-kubernetes-list-type": "atomic"},
+			"hosts": map[string]interface{}{"type": "array"},
+		},
+	}
+
+	convertListSets(schema)
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, true, properties["tags"].(map[string]interface{})["uniqueItems"])
+	assert.NotContains(t, properties["env"], "uniqueItems")
+	assert.NotContains(t, properties["args"], "uniqueItems")
+	assert.NotContains(t, properties["hosts"], "uniqueItems")
+}
+
+func TestGenerateFromCRDFS(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{"crds/crd.yaml": []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              config:
+                type: object
+`)})
+
+	require.NoError(t, GenerateFromCRDFS(fsys, "crds/crd.yaml", "values.schema.json"))
+	data, err := fsys.ReadFile("values.schema.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"config"`)
+
+	err = GenerateFromCRDFS(fsys, "crds/missing.yaml", "values.schema.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read CRD file")
+}
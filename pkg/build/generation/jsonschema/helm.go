@@ -0,0 +1,91 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+)
+
+// unsupportedKeywords are the keywords the generated schemas can have that
+// draft-07 doesn't define: OpenAPI annotations, and deprecated (from 2019-09)
+var unsupportedKeywords = []string{"example", "externalDocs", "deprecated"}
+
+// schemaMaps are the keywords whose values are maps of schemas, and schemaLists
+// those whose values are lists of schemas
+var (
+	schemaMaps  = []string{"properties", "patternProperties", "definitions", "dependencies"}
+	schemaLists = []string{"allOf", "anyOf", "oneOf"}
+)
+
+// HelmCompatible adapts a JSON Schema to the way Helm validates values: it removes
+// the keywords draft-07 doesn't define and the formats that validators don't check
+// the way Kubernetes does (see ConversionLosses), and allows the global key, which
+// Helm adds to the values of subcharts, unless the values already define it
+func HelmCompatible(content []byte) ([]byte, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	removeUnsupported(schema)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = make(map[string]interface{})
+		schema["properties"] = properties
+	}
+	if _, ok := properties[chart.GlobalKey]; !ok {
+		properties[chart.GlobalKey] = map[string]interface{}{
+			"description": "Global values, which Helm shares with the chart's subcharts",
+			"type":        "object",
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return jsonBytes, nil
+}
+
+// removeUnsupported recursively removes the unsupported keywords and formats of
+// a schema. Unlike the conversions of the generator, it only walks subschemas, so
+// properties named like keywords are kept.
+func removeUnsupported(obj interface{}) {
+	schema, ok := obj.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, keyword := range unsupportedKeywords {
+		delete(schema, keyword)
+	}
+	if format, ok := schema["format"].(string); ok && !checkedFormats[format] {
+		delete(schema, "format")
+	}
+
+	for _, keyword := range schemaMaps {
+		if subschemas, ok := schema[keyword].(map[string]interface{}); ok {
+			for _, subschema := range subschemas {
+				removeUnsupported(subschema)
+			}
+		}
+	}
+	for _, keyword := range schemaLists {
+		if subschemas, ok := schema[keyword].([]interface{}); ok {
+			for _, subschema := range subschemas {
+				removeUnsupported(subschema)
+			}
+		}
+	}
+	switch items := schema["items"].(type) {
+	case map[string]interface{}:
+		removeUnsupported(items)
+	case []interface{}:
+		for _, item := range items {
+			removeUnsupported(item)
+		}
+	}
+	removeUnsupported(schema["additionalProperties"])
+	removeUnsupported(schema["additionalItems"])
+	removeUnsupported(schema["not"])
+}
@@ -0,0 +1,96 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmCompatible(t *testing.T) {
+	input := `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "timeout": {"type": "string", "format": "duration", "example": "30s"},
+    "since": {"type": "string", "format": "date-time"},
+    "oldPort": {"type": "integer", "format": "int32", "deprecated": true},
+    "example": {"type": "string", "externalDocs": {"url": "https://example.com"}},
+    "env": {"type": "array", "items": {"type": "object", "properties": {"value": {"type": "string", "format": "byte"}}}},
+    "limits": {"type": "object", "additionalProperties": {"anyOf": [{"type": "integer", "format": "int64"}, {"type": "string"}]}}
+  }
+}`
+	output, err := HelmCompatible([]byte(input))
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(output, &got))
+	var want map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "timeout": {"type": "string"},
+    "since": {"type": "string", "format": "date-time"},
+    "oldPort": {"type": "integer"},
+    "example": {"type": "string"},
+    "env": {"type": "array", "items": {"type": "object", "properties": {"value": {"type": "string"}}}},
+    "limits": {"type": "object", "additionalProperties": {"anyOf": [{"type": "integer"}, {"type": "string"}]}},
+    "global": {"type": "object", "description": "Global values, which Helm shares with the chart's subcharts"}
+  }
+}`), &want))
+	assert.Equal(t, want, got)
+	require.NoError(t, ValidateMetaSchema(output))
+}
+
+func TestHelmCompatible_DefinedGlobal(t *testing.T) {
+	// The values' own global key is kept
+	input := `{"type": "object", "properties": {"global": {"type": "object", "properties": {"domain": {"type": "string"}}}}}`
+	output, err := HelmCompatible([]byte(input))
+	require.NoError(t, err)
+	assert.JSONEq(t, input, string(output))
+
+	// Schemas without properties get one
+	output, err = HelmCompatible([]byte(`{"type": "object"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "object", "properties": {"global": {"type": "object", "description": "Global values, which Helm shares with the chart's subcharts"}}}`, string(output))
+
+	_, err = HelmCompatible([]byte("not json"))
+	assert.ErrorContains(t, err, "failed to parse JSON Schema")
+}
+
+func TestEmitter_HelmCompat(t *testing.T) {
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          timeout:
+            type: string
+            format: duration
+`
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(crdContent)}}})
+	e.HelmCompat = true
+
+	files, err := e.Emit(schema.Schema{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.NotContains(t, string(files[0].Content), `"format"`)
+	assert.Contains(t, string(files[0].Content), `"global"`)
+}
@@ -0,0 +1,77 @@
+package jsonschema
+
+// KeepKRMFields makes a JSON Schema validate full KRM documents rather than
+// plain values: apiVersion and kind are required and pinned to the resource's,
+// and metadata, which the CRD leaves to Kubernetes, is added back
+func KeepKRMFields(data []byte, apiVersion, kind string) ([]byte, error) {
+	return editSchema(data, func(root map[string]interface{}) {
+		properties, _ := root["properties"].(map[string]interface{})
+		if properties == nil {
+			properties = make(map[string]interface{})
+			root["properties"] = properties
+		}
+		for name, value := range map[string]string{"apiVersion": apiVersion, "kind": kind} {
+			property, _ := properties[name].(map[string]interface{})
+			if property == nil {
+				property = map[string]interface{}{"type": "string"}
+				properties[name] = property
+			}
+			property["enum"] = []interface{}{value}
+		}
+		if _, ok := properties["metadata"]; !ok {
+			properties["metadata"] = metadataSchema()
+		}
+
+		required, _ := root["required"].([]interface{})
+		for _, name := range []string{"apiVersion", "kind"} {
+			if !containsValue(required, name) {
+				required = append(required, name)
+			}
+		}
+		root["required"] = required
+	})
+}
+
+// DropKRMFields removes the apiVersion and kind properties from a JSON Schema,
+// shaping it for Helm values files whose resource form another schema
+// validates (see ResourceEmitter)
+func DropKRMFields(data []byte) ([]byte, error) {
+	return editSchema(data, func(root map[string]interface{}) {
+		if properties, ok := root["properties"].(map[string]interface{}); ok {
+			delete(properties, "apiVersion")
+			delete(properties, "kind")
+		}
+	})
+}
+
+// metadataSchema is the schema of the metadata of a KRM resource: the fields
+// charts and tools commonly set, and any other field Kubernetes accepts
+func metadataSchema() map[string]interface{} {
+	stringMap := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description":          description,
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		}
+	}
+	return map[string]interface{}{
+		"description": "Standard object metadata",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"name":        map[string]interface{}{"description": "Name of the resource", "type": "string"},
+			"namespace":   map[string]interface{}{"description": "Namespace of the resource", "type": "string"},
+			"labels":      stringMap("Labels of the resource"),
+			"annotations": stringMap("Annotations of the resource"),
+		},
+	}
+}
+
+// containsValue reports whether a decoded JSON list contains a string
+func containsValue(list []interface{}, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepKRMFields(t *testing.T) {
+	input := []byte(`{
+  "type": "object",
+  "required": ["replicas", "kind"],
+  "properties": {
+    "apiVersion": {"type": "string", "description": "APIVersion of the resource"},
+    "replicas": {"type": "integer"}
+  }
+}`)
+	output, err := KeepKRMFields(input, "example.com/v1", "App")
+	require.NoError(t, err)
+
+	var schema struct {
+		Required   []string                          `json:"required"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(output, &schema))
+	assert.Equal(t, []string{"replicas", "kind", "apiVersion"}, schema.Required)
+	assert.Equal(t, []interface{}{"example.com/v1"}, schema.Properties["apiVersion"]["enum"])
+	assert.Equal(t, "APIVersion of the resource", schema.Properties["apiVersion"]["description"])
+	assert.Equal(t, []interface{}{"App"}, schema.Properties["kind"]["enum"])
+	assert.Equal(t, "object", schema.Properties["metadata"]["type"])
+	assert.Contains(t, schema.Properties["metadata"]["properties"], "labels")
+	require.NoError(t, ValidateMetaSchema(output))
+
+	// Documents with other apiVersions or kinds, or without them, are invalid
+	for doc, valid := range map[string]bool{
+		`{"apiVersion": "example.com/v1", "kind": "App", "metadata": {"name": "app", "labels": {"a": "b"}}, "replicas": 1}`: true,
+		`{"apiVersion": "example.com/v2", "kind": "App", "replicas": 1}`:                                                    false,
+		`{"apiVersion": "example.com/v1", "kind": "Other", "replicas": 1}`:                                                  false,
+		`{"kind": "App", "replicas": 1}`: false,
+		`{"apiVersion": "example.com/v1", "kind": "App", "metadata": {"labels": {"a": 1}}, "replicas": 1}`: false,
+	} {
+		var values map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(doc), &values))
+		err := validation.ValidateValues(values, output)
+		assert.Equal(t, valid, err == nil, "%s: %v", doc, err)
+	}
+}
+
+func TestEmitter_KRM(t *testing.T) {
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          replicas:
+            type: integer
+`
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(crdContent)}}})
+	e.KRM = true
+
+	files, err := e.Emit(schema.Schema{APIVersion: "example.com/v1", Kind: "Example"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Contains(t, string(files[0].Content), `"metadata"`)
+	assert.Contains(t, string(files[0].Content), `"Example"`)
+
+	// Plain values have no apiVersion, kind, or metadata to keep
+	files, err = e.Emit(schema.Schema{Plain: true, Kind: "Values"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), `"metadata"`)
+}
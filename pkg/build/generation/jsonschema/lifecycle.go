@@ -0,0 +1,120 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// MarkDeprecated marks the deprecated fields in a JSON Schema with the
+// "deprecated" annotation. Their descriptions already say what to use instead.
+func MarkDeprecated(data []byte, deprecations []schema.Deprecation) ([]byte, error) {
+	if len(deprecations) == 0 {
+		return data, nil
+	}
+	return editSchema(data, func(root map[string]interface{}) {
+		for _, deprecation := range deprecations {
+			properties, _ := objectAt(root, deprecation.Path)["properties"].(map[string]interface{})
+			if property, ok := properties[deprecation.Name].(map[string]interface{}); ok {
+				property["deprecated"] = true
+			}
+		}
+	})
+}
+
+// AddRenamedProperties adds the old names of renamed fields to a JSON Schema, as
+// deprecated aliases with the schema of the new names, so values using them stay
+// valid until they're migrated. A required field may be set under either name.
+func AddRenamedProperties(data []byte, renames []schema.Rename) ([]byte, error) {
+	if len(renames) == 0 {
+		return data, nil
+	}
+	return editSchema(data, func(root map[string]interface{}) {
+		for _, rename := range renames {
+			if object := objectAt(root, rename.Path); object != nil {
+				addRenamedProperty(object, rename.From, rename.To)
+			}
+		}
+	})
+}
+
+// editSchema parses a JSON Schema, edits it, and marshals it again
+func editSchema(data []byte, edit func(root map[string]interface{})) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	edit(root)
+
+	output, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return output, nil
+}
+
+// objectAt returns the object schema at a path of a Rename or Deprecation, or
+// nil if there is none
+func objectAt(object map[string]interface{}, path []string) map[string]interface{} {
+	if len(path) == 0 {
+		return object
+	}
+	var nested interface{}
+	switch path[0] {
+	case schema.ItemsSegment:
+		nested = object["items"]
+	case schema.ValuesSegment:
+		nested = object["additionalProperties"]
+	default:
+		properties, _ := object["properties"].(map[string]interface{})
+		nested = properties[path[0]]
+	}
+	nestedObject, ok := nested.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return objectAt(nestedObject, path[1:])
+}
+
+// addRenamedProperty adds an alias of a property to an object schema. Objects
+// without the property are skipped.
+func addRenamedProperty(object map[string]interface{}, from, to string) {
+	properties, _ := object["properties"].(map[string]interface{})
+	property, ok := properties[to].(map[string]interface{})
+	if _, exists := properties[from]; !ok || exists {
+		return
+	}
+	alias := make(map[string]interface{}, len(property)+1)
+	for key, value := range property {
+		alias[key] = value
+	}
+	alias["deprecated"] = true
+	alias["description"] = fmt.Sprintf("Deprecated: renamed to %s.", to)
+	if description, ok := property["description"].(string); ok && description != "" {
+		alias["description"] = fmt.Sprintf("%s\n\nDeprecated: renamed to %s.", description, to)
+	}
+	properties[from] = alias
+
+	required, _ := object["required"].([]interface{})
+	for i, name := range required {
+		if name != to {
+			continue
+		}
+		object["required"] = append(required[:i:i], required[i+1:]...)
+		if len(required) == 1 {
+			delete(object, "required")
+		}
+		allOf, _ := object["allOf"].([]interface{})
+		object["allOf"] = append(allOf, map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"required": []interface{}{to}},
+				map[string]interface{}{"required": []interface{}{from}},
+			},
+		})
+		break
+	}
+}
@@ -0,0 +1,87 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRenamedProperties(t *testing.T) {
+	input := `{
+  "properties": {
+    "containers": {"type": "array", "items": {"type": "object", "required": ["containerPort"], "properties": {"containerPort": {"type": "integer", "maximum": 65535}}}},
+    "picture": {"type": "object", "description": "The image", "properties": {"repository": {"type": "string"}}}
+  },
+  "type": "object"
+}`
+	renames := []schema.Rename{
+		{From: "image", To: "picture"},
+		{Path: []string{"picture"}, From: "repo", To: "repository"},
+		{Path: []string{"containers", schema.ItemsSegment}, From: "port", To: "containerPort"},
+		{Path: []string{"missing"}, From: "a", To: "b"},
+	}
+
+	output, err := AddRenamedProperties([]byte(input), renames)
+	require.NoError(t, err)
+
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(output, &actual))
+	properties := actual["properties"].(map[string]interface{})
+
+	// The alias of an object has the nested aliases too
+	image := properties["image"].(map[string]interface{})
+	assert.Equal(t, true, image["deprecated"])
+	assert.Equal(t, "The image\n\nDeprecated: renamed to picture.", image["description"])
+	assert.Equal(t, map[string]interface{}{
+		"type":        "string",
+		"deprecated":  true,
+		"description": "Deprecated: renamed to repository.",
+	}, image["properties"].(map[string]interface{})["repo"])
+	assert.NotContains(t, properties["picture"], "deprecated")
+
+	// A required field can be set under either name
+	items := properties["containers"].(map[string]interface{})["items"].(map[string]interface{})
+	assert.NotContains(t, items, "required")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"required": []interface{}{"containerPort"}},
+			map[string]interface{}{"required": []interface{}{"port"}},
+		},
+	}}, items["allOf"])
+	assert.Equal(t, float64(65535), items["properties"].(map[string]interface{})["port"].(map[string]interface{})["maximum"])
+}
+
+func TestAddRenamedProperties_NoRenames(t *testing.T) {
+	input := []byte(`{"type": "object"}`)
+	output, err := AddRenamedProperties(input, nil)
+	require.NoError(t, err)
+	assert.Equal(t, input, output)
+}
+
+func TestMarkDeprecated(t *testing.T) {
+	input := `{
+  "properties": {
+    "ports": {"type": "object", "additionalProperties": {"type": "object", "properties": {"port": {"type": "integer"}}}},
+    "debug": {"type": "boolean"}
+  },
+  "type": "object"
+}`
+	deprecations := []schema.Deprecation{
+		{Name: "debug", Message: "use logLevel instead"},
+		{Path: []string{"ports", schema.ValuesSegment}, Name: "port", Message: "use containerPort instead"},
+		{Path: []string{"missing"}, Name: "a", Message: "use b instead"},
+	}
+
+	output, err := MarkDeprecated([]byte(input), deprecations)
+	require.NoError(t, err)
+
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(output, &actual))
+	properties := actual["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "boolean", "deprecated": true}, properties["debug"])
+	port := properties["ports"].(map[string]interface{})["additionalProperties"].(map[string]interface{})["properties"].(map[string]interface{})["port"]
+	assert.Equal(t, map[string]interface{}{"type": "integer", "deprecated": true}, port)
+}
@@ -0,0 +1,107 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	jsonschemav6 "github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ValidateMetaSchema checks that a JSON Schema has no duplicate keys and is valid
+// against the meta-schema of the draft it declares in $schema
+func ValidateMetaSchema(schemaJSON []byte) error {
+	if err := checkDuplicateKeys(schemaJSON); err != nil {
+		return err
+	}
+
+	doc, err := jsonschemav6.UnmarshalJSON(bytes.NewReader(schemaJSON))
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	// Compiling validates the schema against its meta-schema, like Helm does before validating values
+	compiler := jsonschemav6.NewCompiler()
+	if err := compiler.AddResource("file:///values.schema.json", doc); err != nil {
+		return fmt.Errorf("failed to add schema resource: %w", err)
+	}
+	if _, err := compiler.Compile("file:///values.schema.json"); err != nil {
+		var metaErr *jsonschemav6.SchemaValidationError
+		if errors.As(err, &metaErr) {
+			return fmt.Errorf("schema is not valid against its meta-schema: %w", metaErr.Err)
+		}
+		return fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return nil
+}
+
+// checkDuplicateKeys reports the first object key that appears twice, which
+// encoding/json would silently resolve by keeping the last value
+func checkDuplicateKeys(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := checkValue(decoder, nil); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("failed to parse JSON Schema: unexpected data after the schema")
+	}
+	return nil
+}
+
+// checkValue consumes one JSON value from the decoder, checking objects for duplicate keys
+func checkValue(decoder *json.Decoder, path []string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	switch token {
+	case json.Delim('{'):
+		seen := map[string]bool{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("failed to parse JSON Schema: %w", err)
+			}
+			key := keyToken.(string)
+			if seen[key] {
+				return fmt.Errorf("duplicate key %q at %s", key, jsonPointer(path))
+			}
+			seen[key] = true
+			if err := checkValue(decoder, append(path, key)); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if err := checkValue(decoder, append(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// Consume the closing delimiter
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	return nil
+}
+
+// jsonPointer renders a path as a JSON pointer, e.g. "/properties/port"
+func jsonPointer(path []string) string {
+	if len(path) == 0 {
+		return "/"
+	}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, segment := range path {
+		b.WriteString("/" + escaper.Replace(segment))
+	}
+	return b.String()
+}
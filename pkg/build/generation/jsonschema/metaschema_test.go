@@ -0,0 +1,70 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetaSchema(t *testing.T) {
+	valid := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {"port": {"type": "integer", "minimum": 1}}
+}`
+	require.NoError(t, ValidateMetaSchema([]byte(valid)))
+
+	tests := map[string]struct {
+		schema  string
+		wantErr string
+	}{
+		"duplicate key": {
+			schema:  `{"properties": {"port": {"type": "integer", "type": "string"}}}`,
+			wantErr: `duplicate key "type" at /properties/port`,
+		},
+		"duplicate root key": {
+			schema:  `{"type": "object", "type": "object"}`,
+			wantErr: `duplicate key "type" at /`,
+		},
+		"escaped pointer": {
+			schema:  `{"properties": {"a/b": {"enum": [{"x": 1, "x": 2}]}}}`,
+			wantErr: `duplicate key "x" at /properties/a~1b/enum/0`,
+		},
+		"bad keyword value": {
+			schema:  `{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"port": {"minimum": "1"}}}`,
+			wantErr: "schema is not valid against its meta-schema",
+		},
+		"bad type": {
+			schema:  `{"type": "int"}`,
+			wantErr: "schema is not valid against its meta-schema",
+		},
+		"invalid JSON": {
+			schema:  `{"type": `,
+			wantErr: "failed to parse JSON Schema",
+		},
+		"trailing data": {
+			schema:  `{} {}`,
+			wantErr: "unexpected data after the schema",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateMetaSchema([]byte(tt.schema))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateMetaSchema_Testdata(t *testing.T) {
+	for _, name := range []string{"argo-events", "basic", "comprehensive", "minimal"} {
+		t.Run(name, func(t *testing.T) {
+			schemaJSON, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "testdata", "build", name, "expected_schema.json"))
+			require.NoError(t, err)
+			assert.NoError(t, ValidateMetaSchema(schemaJSON))
+		})
+	}
+}
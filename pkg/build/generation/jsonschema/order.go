@@ -0,0 +1,113 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// OrderProperties orders the properties of a JSON Schema like the fields of the
+// example values, rather than alphabetically. Other keys stay sorted, as
+// json.MarshalIndent writes them. Schemas marshaled from maps must be ordered again.
+func OrderProperties(data []byte, order *schema.PropertyOrder) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	var compact bytes.Buffer
+	if err := writeOrdered(&compact, value, order); err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	var output bytes.Buffer
+	if err := json.Indent(&output, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to indent JSON Schema: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// writeOrdered writes a schema as compact JSON, with its properties in order, and
+// the properties of the objects in its list items and map values in the same order
+func writeOrdered(buf *bytes.Buffer, value interface{}, order *schema.PropertyOrder) error {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return writeJSON(buf, value)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSON(buf, key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+
+		var err error
+		switch key {
+		case "properties":
+			err = writeProperties(buf, object[key], order)
+		case "items", "additionalProperties":
+			err = writeOrdered(buf, object[key], order)
+		default:
+			err = writeJSON(buf, object[key])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeProperties writes the properties of a schema in order
+func writeProperties(buf *bytes.Buffer, value interface{}, order *schema.PropertyOrder) error {
+	properties, ok := value.(map[string]interface{})
+	if !ok {
+		return writeJSON(buf, value)
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, key := range order.Sort(keys) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSON(buf, key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := writeOrdered(buf, properties[key], order.Property(key)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSON writes a value as compact JSON, with sorted keys
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,66 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderProperties(t *testing.T) {
+	input := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "containers": {"type": "array", "items": {"type": "object", "properties": {"image": {"type": "string"}, "name": {"type": "string"}}}},
+    "image": {"type": "object", "properties": {"repository": {"type": "string"}, "tag": {"type": "string"}}},
+    "labels": {"type": "object", "additionalProperties": {"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}}},
+    "replicas": {"type": "integer", "maximum": 1.5e3, "default": {"z": 1, "a": "<b>"}}
+  },
+  "type": "object"
+}`
+	order := &schema.PropertyOrder{
+		Names: []string{"replicas", "image", "containers", "labels"},
+		Properties: map[string]*schema.PropertyOrder{
+			"image":      {Names: []string{"tag", "repository"}},
+			"containers": {Names: []string{"name", "image"}},
+			"labels":     {Names: []string{"b", "a"}},
+		},
+	}
+
+	output, err := OrderProperties([]byte(input), order)
+	require.NoError(t, err)
+
+	// The content doesn't change
+	var expected, actual interface{}
+	require.NoError(t, json.Unmarshal([]byte(input), &expected))
+	require.NoError(t, json.Unmarshal(output, &actual))
+	assert.Equal(t, expected, actual)
+
+	content := string(output)
+	last := -1
+	for _, key := range []string{`"replicas"`, `"a": "\u003cb\u003e"`, `"z": 1`, `"maximum": 1.5e3`, `"image"`, `"tag"`, `"repository"`, `"containers"`, `"name"`, `"image"`, `"labels"`, `"b"`, `"a"`, `"type": "object"`} {
+		index := strings.Index(content[last+1:], key)
+		require.GreaterOrEqual(t, index, 0, "expected %s after position %d in:\n%s", key, last, content)
+		last += 1 + index
+	}
+}
+
+func TestOrderProperties_KeepsFormatting(t *testing.T) {
+	// Without an order, the output is the same as json.MarshalIndent's
+	s := map[string]interface{}{
+		"properties": map[string]interface{}{"b": map[string]interface{}{"enum": []interface{}{}}, "a": map[string]interface{}{}},
+		"required":   []string{"b"},
+	}
+	expected, err := json.MarshalIndent(s, "", "  ")
+	require.NoError(t, err)
+
+	output, err := OrderProperties(expected, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+
+	_, err = OrderProperties([]byte("not json"), nil)
+	assert.ErrorContains(t, err, "failed to parse JSON Schema")
+}
@@ -0,0 +1,141 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LossKind is a kind of CRD construct that JSON Schema can't represent
+type LossKind string
+
+// Kinds of conversion loss
+const (
+	// LossCELRule is an x-kubernetes-validations rule, which JSON Schema can't evaluate
+	LossCELRule LossKind = "cel-rule"
+
+	// LossListType is a map list, whose items must be unique by their keys
+	LossListType LossKind = "list-type"
+
+	// LossEmbeddedResource is an object validated as a Kubernetes resource
+	LossEmbeddedResource LossKind = "embedded-resource"
+
+	// LossFormat is a format that JSON Schema validators don't check, or check differently
+	LossFormat LossKind = "format"
+
+	// LossExclusiveBound is an exclusive bound without a bound, which is dropped
+	LossExclusiveBound LossKind = "exclusive-bound"
+)
+
+// Loss is a construct of a CRD schema that is dropped or weakened in the generated JSON Schema
+type Loss struct {
+	// Path is the dotted field path, with "[]" for list items and "*" for map values
+	Path   string
+	Kind   LossKind
+	Detail string
+}
+
+// String renders the loss for warnings
+func (l Loss) String() string {
+	path := l.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s", path, l.Detail)
+}
+
+// checkedFormats are the formats draft-07 validators check with the same meaning as Kubernetes
+var checkedFormats = map[string]bool{
+	"date": true, "date-time": true, "email": true, "hostname": true, "ipv4": true, "ipv6": true,
+	"uri": true, "uuid": true,
+}
+
+// ConversionLosses lists the constructs of a CRD's schema that can't be represented
+// in JSON Schema, so Helm won't validate them
+func ConversionLosses(crdBytes []byte) ([]Loss, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(crdBytes, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD YAML: %w", err)
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			var losses []Loss
+			collectLosses(version.Schema.OpenAPIV3Schema, "", true, &losses)
+			return losses, nil
+		}
+	}
+	return nil, fmt.Errorf("no schema found in CRD")
+}
+
+// collectLosses walks a schema and records what the conversion loses
+func collectLosses(props *apiextensionsv1.JSONSchemaProps, path string, root bool, losses *[]Loss) {
+	add := func(kind LossKind, detail string) {
+		*losses = append(*losses, Loss{Path: path, Kind: kind, Detail: detail})
+	}
+
+	for _, rule := range props.XValidations {
+		detail := fmt.Sprintf("CEL rule %q is not validated", rule.Rule)
+		if rule.Message != "" {
+			detail += fmt.Sprintf(" (%s)", rule.Message)
+		}
+		add(LossCELRule, detail)
+	}
+	// Sets are converted to uniqueItems, which JSON Schema can't restrict to keys
+	if props.XListType != nil && *props.XListType == "map" {
+		add(LossListType, fmt.Sprintf("uniqueness of items by %s (list-type map) is not validated",
+			strings.Join(props.XListMapKeys, ", ")))
+	}
+	if props.XEmbeddedResource {
+		add(LossEmbeddedResource, "apiVersion, kind, and metadata of the embedded resource are not validated")
+	}
+	if props.Format != "" {
+		switch {
+		case props.Format == "duration":
+			add(LossFormat, `format "duration" means a Go duration (e.g., 72h) in the CRD but ISO 8601 (e.g., PT72H) in JSON Schema`)
+		case !checkedFormats[props.Format]:
+			add(LossFormat, fmt.Sprintf("format %q is not validated", props.Format))
+		}
+	}
+	if props.ExclusiveMinimum && props.Minimum == nil {
+		add(LossExclusiveBound, "exclusiveMinimum without minimum is dropped")
+	}
+	if props.ExclusiveMaximum && props.Maximum == nil {
+		add(LossExclusiveBound, "exclusiveMaximum without maximum is dropped")
+	}
+
+	names := make([]string, 0, len(props.Properties))
+	for name := range props.Properties {
+		// metadata is removed from the JSON Schema on purpose
+		if !(root && name == "metadata") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := props.Properties[name]
+		collectLosses(&prop, joinPath(path, name), false, losses)
+	}
+
+	if props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil {
+		collectLosses(props.AdditionalProperties.Schema, joinPath(path, "*"), false, losses)
+	}
+	if props.Items != nil && props.Items.Schema != nil {
+		collectLosses(props.Items.Schema, path+"[]", false, losses)
+	}
+	for _, group := range [][]apiextensionsv1.JSONSchemaProps{props.AllOf, props.AnyOf, props.OneOf} {
+		for i := range group {
+			collectLosses(&group[i], path, false, losses)
+		}
+	}
+}
+
+// joinPath appends a field name to a dotted path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
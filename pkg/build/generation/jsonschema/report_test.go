@@ -0,0 +1,117 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lossyCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-validations:
+        - rule: self.min <= self.max
+        properties:
+          metadata:
+            type: object
+            nullable: true
+          min:
+            type: integer
+            format: int32
+            exclusiveMinimum: true
+          max:
+            type: integer
+            minimum: 0
+            exclusiveMinimum: true
+          timeout:
+            type: string
+            format: duration
+          created:
+            type: string
+            format: date-time
+          ports:
+            type: array
+            x-kubernetes-list-type: map
+            x-kubernetes-list-map-keys: [name, protocol]
+            items:
+              type: object
+              properties:
+                name:
+                  type: string
+                  nullable: true
+          labels:
+            type: object
+            additionalProperties:
+              type: string
+              x-kubernetes-validations:
+              - rule: size(self) < 64
+                message: too long
+          template:
+            type: object
+            x-kubernetes-embedded-resource: true
+`
+
+func TestConversionLosses(t *testing.T) {
+	losses, err := ConversionLosses([]byte(lossyCRD))
+	require.NoError(t, err)
+
+	var got []string
+	for _, loss := range losses {
+		got = append(got, string(loss.Kind)+" "+loss.String())
+	}
+	assert.Equal(t, []string{
+		`cel-rule (root): CEL rule "self.min <= self.max" is not validated`,
+		`cel-rule labels.*: CEL rule "size(self) < 64" is not validated (too long)`,
+		`format min: format "int32" is not validated`,
+		`exclusive-bound min: exclusiveMinimum without minimum is dropped`,
+		`list-type ports: uniqueness of items by name, protocol (list-type map) is not validated`,
+		`embedded-resource template: apiVersion, kind, and metadata of the embedded resource are not validated`,
+		`format timeout: format "duration" means a Go duration (e.g., 72h) in the CRD but ISO 8601 (e.g., PT72H) in JSON Schema`,
+	}, got)
+}
+
+func TestConversionLosses_None(t *testing.T) {
+	losses, err := ConversionLosses([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          replicas:
+            type: integer
+            minimum: 1
+          tags:
+            type: array
+            x-kubernetes-list-type: set
+            items:
+              type: string
+`))
+	require.NoError(t, err)
+	assert.Empty(t, losses)
+}
+
+func TestConversionLosses_Errors(t *testing.T) {
+	_, err := ConversionLosses([]byte("not: [valid"))
+	assert.ErrorContains(t, err, "failed to parse CRD YAML")
+
+	_, err = ConversionLosses([]byte("spec:\n  versions:\n  - name: v1\n"))
+	assert.ErrorContains(t, err, "no schema found in CRD")
+}
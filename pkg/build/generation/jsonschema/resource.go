@@ -0,0 +1,79 @@
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// ResourceTargetName is the output target name of the resource JSON Schema emitter
+const ResourceTargetName = "resourceschema"
+
+// ResourceEmitter generates a JSON Schema of the resource form of the values
+// (the custom resource operators apply, with apiVersion, kind, metadata, and
+// spec with --wrap-spec) from the CRD produced by another emitter
+type ResourceEmitter struct {
+	crd generation.Emitter
+
+	// Titles controls the titles of the properties (see AddTitles)
+	Titles TitleMode
+}
+
+// NewResourceEmitter creates a resource JSON Schema emitter that converts the
+// output of crdEmitter. Wrap crdEmitter with generation.Once to share a single
+// CRD generation within a build.
+func NewResourceEmitter(crdEmitter generation.Emitter) *ResourceEmitter {
+	return &ResourceEmitter{crd: crdEmitter}
+}
+
+// Name returns the output target name
+func (e *ResourceEmitter) Name() string {
+	return ResourceTargetName
+}
+
+// Emit generates resource.schema.json from the schema
+func (e *ResourceEmitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	if s.Plain {
+		return nil, fmt.Errorf("plain values have no resource form")
+	}
+	files, err := e.crd.Emit(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("CRD emitter produced %d files, expected 1", len(files))
+	}
+
+	crdDef, resource, err := resourceSchema(files[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	content, err := GenerateFromSchema(resource)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = KeepKRMFields(content, s.APIVersion, s.Kind); err != nil {
+		return nil, err
+	}
+	if content, err = AddTitles(content, e.Titles); err != nil {
+		return nil, err
+	}
+
+	order := s.PropertyOrder()
+	if crd.WrapsSpec(crdDef) {
+		order = &schema.PropertyOrder{
+			Names:      append(append([]string{}, schema.KRMFields...), crd.SpecField, "status"),
+			Properties: map[string]*schema.PropertyOrder{crd.SpecField: order},
+		}
+	}
+	if content, err = OrderProperties(content, order); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateMetaSchema(content); err != nil {
+		return nil, fmt.Errorf("generated JSON Schema is invalid: %w", err)
+	}
+	return []generation.OutputFile{{Name: "resource.schema.json", Content: content}}, nil
+}
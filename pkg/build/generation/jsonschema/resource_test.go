@@ -0,0 +1,105 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrappedCRD is the CRD of values nested under spec (--wrap-spec)
+const wrappedCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+  annotations:
+    miaka.dev/wrap-spec: "true"
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          spec:
+            type: object
+            properties:
+              replicas:
+                type: integer
+              image:
+                type: string
+          status:
+            type: object
+`
+
+func TestResourceEmitter_Emit(t *testing.T) {
+	crdEmitter := &staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(wrappedCRD)}}}
+	e := NewResourceEmitter(crdEmitter)
+	assert.Equal(t, ResourceTargetName, e.Name())
+
+	s := schema.Schema{APIVersion: "example.com/v1", Kind: "Example", Structs: []schema.StructDef{{
+		Name:   "Example",
+		Fields: []schema.Field{{JSONName: "replicas", Type: "int"}, {JSONName: "image", Type: "string"}},
+	}}}
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "resource.schema.json", files[0].Name)
+
+	var resource struct {
+		Required   []string                          `json:"required"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(files[0].Content, &resource))
+	assert.Equal(t, []string{"apiVersion", "kind"}, resource.Required)
+	assert.Equal(t, []interface{}{"Example"}, resource.Properties["kind"]["enum"])
+	assert.Contains(t, resource.Properties["metadata"]["properties"], "name")
+	assert.Contains(t, resource.Properties["spec"]["properties"], "replicas")
+
+	// The resource fields come first, and the values under spec keep their order
+	content := string(files[0].Content)
+	assert.Less(t, strings.Index(content, `"metadata"`), strings.Index(content, `"spec"`))
+	assert.Less(t, strings.Index(content, `"replicas"`), strings.Index(content, `"image"`))
+
+	// The values schema of the same CRD holds the values at the root
+	values, err := NewEmitter(crdEmitter).Emit(s)
+	require.NoError(t, err)
+	assert.NotContains(t, string(values[0].Content), `"spec"`)
+}
+
+func TestResourceEmitter_Errors(t *testing.T) {
+	e := NewResourceEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(wrappedCRD)}}})
+	_, err := e.Emit(schema.Schema{Plain: true, Kind: "Values"})
+	assert.EqualError(t, err, "plain values have no resource form")
+
+	e = NewResourceEmitter(&staticEmitter{})
+	_, err = e.Emit(schema.Schema{Kind: "Example"})
+	assert.EqualError(t, err, "CRD emitter produced 0 files, expected 1")
+}
+
+func TestEmitter_ValuesOnly(t *testing.T) {
+	e := NewEmitter(&staticEmitter{files: []generation.OutputFile{{Name: "crd.yaml", Content: []byte(wrappedCRD)}}})
+	e.ValuesOnly = true
+	files, err := e.Emit(schema.Schema{APIVersion: "example.com/v1", Kind: "Example"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), `"apiVersion"`)
+	assert.NotContains(t, string(files[0].Content), `"kind"`)
+	assert.Contains(t, string(files[0].Content), `"replicas"`)
+}
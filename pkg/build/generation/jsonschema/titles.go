@@ -0,0 +1,144 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TitleMode controls the titles of the properties of a JSON Schema, which IDEs
+// show in tooltips and completion lists
+type TitleMode string
+
+// Title modes
+const (
+	// TitlesName titles each property with its name, split into words (e.g.,
+	// "Image Pull Policy" for imagePullPolicy)
+	TitlesName TitleMode = "name"
+	// TitlesComment titles each property with the first sentence of its
+	// description, or its name if it has none
+	TitlesComment TitleMode = "comment"
+	// TitlesNone adds no titles, like miaka versions before titles
+	TitlesNone TitleMode = "none"
+)
+
+// maxCommentTitle is the length above which the first sentence of a
+// description is too long for a title, which then comes from the name
+const maxCommentTitle = 80
+
+// initialisms are words that titles write in capitals (e.g., "API Version"
+// for apiVersion)
+var initialisms = map[string]bool{
+	"api": true, "cpu": true, "dns": true, "gpu": true, "http": true, "https": true,
+	"id": true, "ip": true, "json": true, "oci": true, "sql": true, "ssh": true,
+	"tcp": true, "tls": true, "ttl": true, "udp": true, "uid": true, "uri": true,
+	"url": true, "uuid": true, "yaml": true,
+}
+
+// ParseTitleMode parses a title mode (name, comment, or none)
+func ParseTitleMode(s string) (TitleMode, error) {
+	switch mode := TitleMode(s); mode {
+	case TitlesName, TitlesComment, TitlesNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid title mode %q (must be name, comment, or none)", s)
+}
+
+// AddTitles titles the properties of a JSON Schema, at every level, keeping
+// the titles they already have. The empty mode is TitlesNone.
+func AddTitles(data []byte, mode TitleMode) ([]byte, error) {
+	if mode == "" || mode == TitlesNone {
+		return data, nil
+	}
+	return editSchema(data, func(root map[string]interface{}) {
+		addTitles(root, mode)
+	})
+}
+
+// addTitles titles the properties of an object schema and of the schemas
+// nested in them
+func addTitles(schema map[string]interface{}, mode TitleMode) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, value := range properties {
+		property, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := property["title"]; !ok {
+			property["title"] = title(name, property, mode)
+		}
+		addTitles(property, mode)
+	}
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if nested, ok := schema[keyword].(map[string]interface{}); ok {
+			addTitles(nested, mode)
+		}
+	}
+}
+
+// title returns the title of a property
+func title(name string, property map[string]interface{}, mode TitleMode) string {
+	if mode == TitlesComment {
+		description, _ := property["description"].(string)
+		if sentence := firstSentence(description); sentence != "" && len(sentence) <= maxCommentTitle {
+			return sentence
+		}
+	}
+	return NameTitle(name)
+}
+
+// firstSentence returns the first sentence of the first line of a
+// description, without its period
+func firstSentence(description string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	if sentence, _, ok := strings.Cut(line, ". "); ok {
+		line = sentence
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "."))
+}
+
+// NameTitle splits a field name into capitalized words: at the boundaries of
+// camelCase, and at underscores, hyphens, dots, and spaces. Runs of capitals
+// are kept as acronyms (e.g., "Pod IPs" for podIPs, "TLS Secret" for
+// TLSSecret), and common initialisms are capitalized ("API Version").
+func NameTitle(name string) string {
+	runes := []rune(name)
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			flush()
+			continue
+		case i > 0 && unicode.IsUpper(r):
+			previous := runes[i-1]
+			// A capital starts a word after a lowercase letter or digit, and
+			// ends an acronym when a lowercase letter follows it, unless the
+			// acronym is plural (e.g., IPs)
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			plural := i+1 < len(runes) && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2]))
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower && !plural) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	for i, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			words[i] = strings.ToUpper(w)
+			continue
+		}
+		first := []rune(w)
+		first[0] = unicode.ToUpper(first[0])
+		words[i] = string(first)
+	}
+	return strings.Join(words, " ")
+}
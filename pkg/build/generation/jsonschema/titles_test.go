@@ -0,0 +1,100 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameTitle(t *testing.T) {
+	tests := map[string]string{
+		"replicas":         "Replicas",
+		"imagePullPolicy":  "Image Pull Policy",
+		"apiVersion":       "API Version",
+		"podIPs":           "Pod IPs",
+		"TLSSecret":        "TLS Secret",
+		"enableHTTP2":      "Enable HTTP2",
+		"serviceAccountId": "Service Account ID",
+		"max_size":         "Max Size",
+		"node-selector":    "Node Selector",
+		"app.kubernetes":   "App Kubernetes",
+		"café":             "Café",
+		"1password":        "1password",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, NameTitle(name), name)
+	}
+}
+
+func TestAddTitles(t *testing.T) {
+	input := []byte(`{
+  "type": "object",
+  "properties": {
+    "replicaCount": {"type": "integer", "description": "Number of replicas to deploy. Defaults to 1."},
+    "image": {
+      "type": "object",
+      "title": "Container image",
+      "properties": {"pullPolicy": {"type": "string"}}
+    },
+    "env": {
+      "type": "array",
+      "items": {"type": "object", "properties": {"name": {"type": "string", "description": "` + "Name of the variable, which must be a C identifier and can be much longer than any title should be" + `"}}}
+    },
+    "labels": {"type": "object", "additionalProperties": {"type": "object", "properties": {"value": {"type": "string"}}}}
+  }
+}`)
+
+	titles := func(mode TitleMode) map[string]interface{} {
+		output, err := AddTitles(input, mode)
+		require.NoError(t, err)
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal(output, &schema))
+		properties := schema["properties"].(map[string]interface{})
+		image := properties["image"].(map[string]interface{})
+		env := properties["env"].(map[string]interface{})["items"].(map[string]interface{})
+		labels := properties["labels"].(map[string]interface{})["additionalProperties"].(map[string]interface{})
+		return map[string]interface{}{
+			"replicaCount": properties["replicaCount"].(map[string]interface{})["title"],
+			"image":        image["title"],
+			"pullPolicy":   image["properties"].(map[string]interface{})["pullPolicy"].(map[string]interface{})["title"],
+			"env.name":     env["properties"].(map[string]interface{})["name"].(map[string]interface{})["title"],
+			"labels.value": labels["properties"].(map[string]interface{})["value"].(map[string]interface{})["title"],
+			"root":         schema["title"],
+		}
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": "Replica Count",
+		"image":        "Container image",
+		"pullPolicy":   "Pull Policy",
+		"env.name":     "Name",
+		"labels.value": "Value",
+		"root":         nil,
+	}, titles(TitlesName))
+
+	// Long first sentences fall back to the name
+	assert.Equal(t, map[string]interface{}{
+		"replicaCount": "Number of replicas to deploy",
+		"image":        "Container image",
+		"pullPolicy":   "Pull Policy",
+		"env.name":     "Name",
+		"labels.value": "Value",
+		"root":         nil,
+	}, titles(TitlesComment))
+
+	output, err := AddTitles(input, TitlesNone)
+	require.NoError(t, err)
+	assert.Equal(t, input, output)
+}
+
+func TestParseTitleMode(t *testing.T) {
+	for _, mode := range []string{"name", "comment", "none"} {
+		parsed, err := ParseTitleMode(mode)
+		require.NoError(t, err)
+		assert.Equal(t, TitleMode(mode), parsed)
+	}
+	_, err := ParseTitleMode("names")
+	require.ErrorContains(t, err, `invalid title mode "names"`)
+}
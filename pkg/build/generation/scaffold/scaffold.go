@@ -0,0 +1,143 @@
+// Package scaffold generates a Go module with a ready-to-compile API package for
+// the generated types, with the boilerplate that controllers need.
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"text/template"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/deepcopy"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"golang.org/x/mod/module"
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// docTemplate declares the API group of the package, for controller-gen
+var docTemplate = template.Must(template.New("doc.go").Parse(`// Package {{.Version}} contains the {{.Version}} API types of the {{.Group}} group
+// +kubebuilder:object:generate=true
+// +groupName={{.Group}}
+package {{.Version}}
+`))
+
+// groupVersionTemplate declares the list type and registers the types with a
+// runtime.Scheme, like kubebuilder's groupversion_info.go, but without depending
+// on controller-runtime
+var groupVersionTemplate = template.Must(template.New("groupversion_info.go").Parse(`package {{.Version}}
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// GroupVersion is the group and version of the API types
+	GroupVersion = schema.GroupVersion{Group: "{{.Group}}", Version: "{{.Version}}"}
+
+	// SchemeBuilder registers the API types with a runtime.Scheme
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the API types to a runtime.Scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+// +kubebuilder:object:root=true
+
+// {{.ListKind}} contains a list of {{.Kind}}
+type {{.ListKind}} struct {
+	metav1.TypeMeta ` + "`json:\",inline\"`" + `
+	metav1.ListMeta ` + "`json:\"metadata,omitempty\"`" + `
+	Items []{{.Kind}} ` + "`json:\"items\"`" + `
+}
+
+// addKnownTypes adds {{.Kind}} and {{.ListKind}} to a runtime.Scheme
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion, &{{.Kind}}{}, &{{.ListKind}}{})
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+	return nil
+}
+`))
+
+// templateData is the data of the templates
+type templateData struct {
+	Group    string
+	Version  string
+	Kind     string
+	ListKind string
+}
+
+// PackageDir returns the directory of the API package in the module (api/<version>)
+func PackageDir(s schema.Schema) string {
+	return path.Join("api", s.Package)
+}
+
+// Generate generates a module with the given path holding the API package of
+// the schema: go.mod, go.sum, and in PackageDir, doc.go, types.go,
+// groupversion_info.go, and zz_generated.deepcopy.go. File names are slash
+// separated and relative to the module root.
+func Generate(s schema.Schema, modulePath string) ([]generation.OutputFile, error) {
+	if s.Plain {
+		return nil, fmt.Errorf("plain values have no API group to scaffold a package for")
+	}
+	if err := module.CheckPath(modulePath); err != nil {
+		return nil, fmt.Errorf("invalid module path: %w", err)
+	}
+	gv, err := runtimeschema.ParseGroupVersion(s.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion format: %s: %w", s.APIVersion, err)
+	}
+	data := templateData{Group: gv.Group, Version: gv.Version, Kind: s.Kind, ListKind: s.Kind + "List"}
+	for _, typeDef := range s.Types {
+		if typeDef.Name == data.ListKind {
+			return nil, fmt.Errorf("type %s conflicts with the list type of %s", typeDef.Name, s.Kind)
+		}
+	}
+
+	s.Package = gv.Version
+	types, err := gotypes.NewGenerator(&s).Generate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Go code: %w", err)
+	}
+	doc, err := render(docTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	groupVersion, err := render(groupVersionTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	deepCopy, err := deepcopy.Generate(types, groupVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate DeepCopy functions: %w", err)
+	}
+
+	goMod, goSum := crd.GoModule(modulePath)
+	dir := PackageDir(s)
+	return []generation.OutputFile{
+		{Name: "go.mod", Content: goMod},
+		{Name: "go.sum", Content: goSum},
+		{Name: path.Join(dir, "doc.go"), Content: doc},
+		{Name: path.Join(dir, "types.go"), Content: types},
+		{Name: path.Join(dir, "groupversion_info.go"), Content: groupVersion},
+		{Name: path.Join(dir, "zz_generated.deepcopy.go"), Content: deepCopy},
+	}, nil
+}
+
+// render executes a template and formats the result as Go code
+func render(tmpl *template.Template, data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", tmpl.Name(), err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,127 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testValues = `apiVersion: example.com/v1alpha1
+kind: App
+replicas: 1
+image:
+  repository: nginx
+  tags: [latest]
+`
+
+func parse(t *testing.T, values string, opts parsing.Options) schema.Schema {
+	t.Helper()
+	s, err := parsing.NewParserWithOptions(opts).Parse([]byte(values))
+	require.NoError(t, err)
+	return *s
+}
+
+// filesByName returns the content of the generated files by name
+func filesByName(files []generation.OutputFile) map[string]string {
+	byName := make(map[string]string)
+	for _, file := range files {
+		byName[file.Name] = string(file.Content)
+	}
+	return byName
+}
+
+func TestGenerate(t *testing.T) {
+	s := parse(t, testValues, parsing.Options{})
+	files, err := Generate(s, "example.com/app-operator")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{
+		"go.mod",
+		"go.sum",
+		"api/v1alpha1/doc.go",
+		"api/v1alpha1/types.go",
+		"api/v1alpha1/groupversion_info.go",
+		"api/v1alpha1/zz_generated.deepcopy.go",
+	}, names)
+
+	byName := filesByName(files)
+	assert.Contains(t, byName["go.mod"], "module example.com/app-operator\n")
+	assert.Contains(t, byName["api/v1alpha1/doc.go"], "// +groupName=example.com\npackage v1alpha1\n")
+	assert.Contains(t, byName["api/v1alpha1/types.go"], "type App struct {")
+	assert.Contains(t, byName["api/v1alpha1/groupversion_info.go"], `GroupVersion = schema.GroupVersion{Group: "example.com", Version: "v1alpha1"}`)
+	assert.Contains(t, byName["api/v1alpha1/groupversion_info.go"], "scheme.AddKnownTypes(GroupVersion, &App{}, &AppList{})")
+	assert.Contains(t, byName["api/v1alpha1/zz_generated.deepcopy.go"], "func (in *AppList) DeepCopyObject() runtime.Object {")
+	assert.Contains(t, byName["api/v1alpha1/zz_generated.deepcopy.go"], "func (in *ImageConfig) DeepCopyInto(out *ImageConfig) {")
+}
+
+func TestGenerate_WrapSpec(t *testing.T) {
+	s := parse(t, testValues, parsing.Options{})
+	s.WrapSpec = true
+	files, err := Generate(s, "example.com/app-operator")
+	require.NoError(t, err)
+
+	byName := filesByName(files)
+	assert.Contains(t, byName["api/v1alpha1/types.go"], "type AppSpec struct {")
+	assert.Contains(t, byName["api/v1alpha1/zz_generated.deepcopy.go"], "in.Spec.DeepCopyInto(&out.Spec)")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	_, err := Generate(parse(t, "replicas: 1\n", parsing.Options{Plain: true}), "example.com/values")
+	assert.ErrorContains(t, err, "plain values have no API group")
+
+	_, err = Generate(parse(t, testValues, parsing.Options{}), "not a module path")
+	assert.ErrorContains(t, err, "invalid module path")
+
+	s := parse(t, testValues, parsing.Options{})
+	s.Types = append(s.Types, schema.TypeDef{Name: "AppList", Type: "string"})
+	_, err = Generate(s, "example.com/app-operator")
+	assert.ErrorContains(t, err, "type AppList conflicts with the list type of App")
+}
+
+// TestGenerate_Compiles checks that the scaffolded module builds without
+// network access, with the types registered in a runtime.Scheme
+func TestGenerate_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling with the go command is slow")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	s := parse(t, testValues, parsing.Options{InferSemanticTypes: true})
+	s.WrapSpec = true
+	files, err := Generate(s, "example.com/app-operator")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, file.Content, 0644))
+	}
+	check := `package v1alpha1
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+var _ = AddToScheme(runtime.NewScheme())
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "v1alpha1", "check.go"), []byte(check), 0644))
+
+	cmd := exec.Command(goCmd, "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=readonly", "GOPROXY=off", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, "scaffolded module doesn't compile:\n%s", output)
+}
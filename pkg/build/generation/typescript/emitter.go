@@ -0,0 +1,31 @@
+package typescript
+
+import (
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// TargetName is the output target name of the TypeScript emitter
+const TargetName = "typescript"
+
+// Emitter generates TypeScript declarations
+type Emitter struct{}
+
+// NewEmitter creates a new TypeScript emitter
+func NewEmitter() *Emitter {
+	return &Emitter{}
+}
+
+// Name returns the output target name
+func (e *Emitter) Name() string {
+	return TargetName
+}
+
+// Emit generates values.d.ts from the schema
+func (e *Emitter) Emit(s schema.Schema) ([]generation.OutputFile, error) {
+	code, err := NewGenerator(&s).Generate()
+	if err != nil {
+		return nil, err
+	}
+	return []generation.OutputFile{{Name: "values.d.ts", Content: code}}, nil
+}
@@ -0,0 +1,34 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitter_Emit(t *testing.T) {
+	s := schema.Schema{
+		APIVersion: "example.com/v1",
+		Kind:       "Example",
+		Structs: []schema.StructDef{
+			{
+				Name:   "Example",
+				Fields: []schema.Field{{Name: "Replicas", JSONName: "replicas", Type: "int"}},
+			},
+		},
+	}
+
+	e := NewEmitter()
+	assert.Equal(t, TargetName, e.Name())
+
+	files, err := e.Emit(s)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "values.d.ts", files[0].Name)
+	assert.Contains(t, string(files[0].Content), "replicas?: number;")
+
+	_, err = e.Emit(schema.Schema{})
+	require.Error(t, err)
+}
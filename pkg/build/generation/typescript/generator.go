@@ -0,0 +1,183 @@
+// Package typescript generates TypeScript type declarations from YAML schemas.
+package typescript
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// identifierPattern matches property names that can be used unquoted in TypeScript
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Generator handles TypeScript declaration generation
+type Generator struct {
+	schema *schema.Schema
+}
+
+// NewGenerator creates a new generator instance
+func NewGenerator(schema *schema.Schema) *Generator {
+	return &Generator{
+		schema: schema,
+	}
+}
+
+// Generate generates a TypeScript declaration file (values.d.ts) from the schema
+func (g *Generator) Generate() ([]byte, error) {
+	if g.schema.Kind == "" {
+		return nil, fmt.Errorf("schema has no kind")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by miaka. DO NOT EDIT.\n")
+
+	// Main type first, mirroring the Go types layout
+	g.writeMainInterface(&sb)
+
+	for _, structDef := range g.schema.Structs {
+		// The struct named after Kind holds the main fields, which were written above
+		if structDef.Name == g.schema.Kind {
+			continue
+		}
+		g.writeInterface(&sb, structDef)
+	}
+
+	for _, typeDef := range g.schema.Types {
+		sb.WriteString("\n")
+		writeDocComment(&sb, "", typeDef.Comments)
+		fmt.Fprintf(&sb, "export type %s = %s;\n", typeDef.Name, ToTSType(typeDef.Type))
+	}
+
+	return []byte(sb.String()), nil
+}
+
+// writeMainInterface writes the interface for the main KRM type, or for plain
+// values, which have no apiVersion or kind
+func (g *Generator) writeMainInterface(sb *strings.Builder) {
+	typeName := g.schema.Kind
+
+	sb.WriteString("\n")
+	if g.schema.Plain {
+		writeDocComment(sb, "", []string{schema.PlainDescription(typeName)})
+		fmt.Fprintf(sb, "export interface %s {\n", typeName)
+	} else {
+		writeDocComment(sb, "", []string{
+			fmt.Sprintf("%s is the Schema for the %ss API", typeName, strings.ToLower(typeName)),
+		})
+		fmt.Fprintf(sb, "export interface %s {\n", typeName)
+		sb.WriteString("  apiVersion?: string;\n")
+		sb.WriteString("  kind?: string;\n")
+	}
+
+	for i := range g.schema.Structs {
+		if g.schema.Structs[i].Name != typeName {
+			continue
+		}
+		for _, field := range g.schema.Structs[i].Fields {
+			writeProperty(sb, field)
+		}
+		break
+	}
+
+	sb.WriteString("}\n")
+}
+
+// writeInterface writes an interface for a nested struct
+func (g *Generator) writeInterface(sb *strings.Builder, structDef schema.StructDef) {
+	sb.WriteString("\n")
+	writeDocComment(sb, "", structDef.Comments)
+	fmt.Fprintf(sb, "export interface %s {\n", structDef.Name)
+	for _, field := range structDef.Fields {
+		writeProperty(sb, field)
+	}
+	sb.WriteString("}\n")
+}
+
+// writeProperty writes a single optional property declaration
+func writeProperty(sb *strings.Builder, field schema.Field) {
+	writeDocComment(sb, "  ", field.Comments)
+
+	fieldType := field.Type
+	if field.IsSlice {
+		fieldType = "[]" + field.ElemType
+	}
+
+	tsType := ToTSType(fieldType)
+	if field.Nullable && tsType != "unknown" {
+		tsType += " | null"
+	}
+	fmt.Fprintf(sb, "  %s?: %s;\n", propertyName(field.JSONName), tsType)
+}
+
+// writeDocComment writes a JSDoc block, skipping marker lines (e.g., +kubebuilder:...)
+func writeDocComment(sb *strings.Builder, indent string, comments []string) {
+	var lines []string
+	for _, comment := range comments {
+		if strings.HasPrefix(comment, "+") {
+			continue
+		}
+		lines = append(lines, strings.ReplaceAll(comment, "*/", "*\\/"))
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		sb.WriteString(indent + " * " + line + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+}
+
+// propertyName quotes property names that aren't valid TypeScript identifiers
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// ToTSType converts a Go type expression (as stored in schema.Field) to a TypeScript type
+func ToTSType(goType string) string {
+	goType = strings.TrimSpace(goType)
+
+	switch {
+	case strings.HasPrefix(goType, "[]"):
+		elem := ToTSType(strings.TrimPrefix(goType, "[]"))
+		if strings.Contains(elem, "|") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case strings.HasPrefix(goType, "map["):
+		closing := strings.Index(goType, "]")
+		if closing < 0 {
+			return "unknown"
+		}
+		return fmt.Sprintf("Record<string, %s>", ToTSType(goType[closing+1:]))
+	case strings.HasPrefix(goType, "*"):
+		return ToTSType(strings.TrimPrefix(goType, "*"))
+	}
+
+	switch goType {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return "number"
+	case string(schema.TypeQuantity), string(schema.TypeIntOrString):
+		return "number | string"
+	case string(schema.TypeDuration):
+		return "string"
+	case string(schema.TypeInterface), string(schema.TypeFreeform), "any", "":
+		return "unknown"
+	}
+
+	// Anything else is a generated struct name
+	return goType
+}
@@ -0,0 +1,182 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_MainInterface(t *testing.T) {
+	s := &schema.Schema{
+		APIVersion: "example.com/v1alpha1",
+		Kind:       "Example",
+		Package:    "v1alpha1",
+		Structs: []schema.StructDef{
+			{
+				Name: "ServiceConfig",
+				Fields: []schema.Field{
+					{Name: "Port", JSONName: "port", Type: "int", Comments: []string{"Service port"}},
+				},
+			},
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{
+						Name:     "Replicas",
+						JSONName: "replicas",
+						Type:     "int",
+						Comments: []string{"Number of replicas", "+kubebuilder:validation:Minimum=1"},
+					},
+					{Name: "Debug", JSONName: "debug", Type: "bool"},
+					{Name: "Service", JSONName: "service", Type: "ServiceConfig"},
+					{Name: "Env", JSONName: "env", IsSlice: true, ElemType: "string", Type: "[]string"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err)
+	output := string(code)
+
+	assert.Contains(t, output, "export interface Example {")
+	assert.Contains(t, output, "  apiVersion?: string;")
+	assert.Contains(t, output, "  replicas?: number;")
+	assert.Contains(t, output, "  debug?: boolean;")
+	assert.Contains(t, output, "  service?: ServiceConfig;")
+	assert.Contains(t, output, "  env?: string[];")
+	assert.Contains(t, output, "export interface ServiceConfig {")
+	assert.Contains(t, output, "   * Number of replicas")
+
+	// Markers are Go-specific and should not leak into JSDoc
+	assert.NotContains(t, output, "+kubebuilder")
+
+	// The main fields struct should not be emitted a second time
+	assert.Equal(t, 1, strings.Count(output, "export interface Example {"))
+}
+
+func TestGenerate_NamedTypes(t *testing.T) {
+	s := &schema.Schema{
+		Kind: "Example",
+		Structs: []schema.StructDef{
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{Name: "Labels", JSONName: "labels", Type: "map[string]LabelsValue"},
+				},
+			},
+		},
+		Types: []schema.TypeDef{
+			{Name: "LabelsValue", Type: "string", Comments: []string{"+kubebuilder:validation:MaxLength=63"}},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err)
+	output := string(code)
+
+	assert.Contains(t, output, "  labels?: Record<string, LabelsValue>;")
+	assert.Contains(t, output, "export type LabelsValue = string;")
+	assert.NotContains(t, output, "+kubebuilder")
+}
+
+func TestGenerate_Nullable(t *testing.T) {
+	s := &schema.Schema{
+		Kind: "Example",
+		Structs: []schema.StructDef{
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{Name: "Enabled", JSONName: "enabled", Type: "bool", Nullable: true},
+					{Name: "Config", JSONName: "config", Type: "interface{}", Nullable: true},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err)
+	output := string(code)
+
+	assert.Contains(t, output, "  enabled?: boolean | null;")
+	assert.Contains(t, output, "  config?: unknown;")
+}
+
+func TestGenerate_Plain(t *testing.T) {
+	s := &schema.Schema{
+		Kind:  "Values",
+		Plain: true,
+		Structs: []schema.StructDef{
+			{
+				Name: "Values",
+				Fields: []schema.Field{
+					{Name: "ReplicaCount", JSONName: "replicaCount", Type: "int"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err)
+	output := string(code)
+
+	assert.Contains(t, output, " * Values is the schema of the chart's values\n */\nexport interface Values {\n  replicaCount?: number;\n}")
+	assert.NotContains(t, output, "apiVersion")
+	assert.NotContains(t, output, "kind?")
+}
+
+func TestGenerate_NoKind(t *testing.T) {
+	_, err := NewGenerator(&schema.Schema{}).Generate()
+	require.Error(t, err)
+}
+
+func TestGenerate_QuotesInvalidIdentifiers(t *testing.T) {
+	s := &schema.Schema{
+		Kind: "Example",
+		Structs: []schema.StructDef{
+			{
+				Name: "Example",
+				Fields: []schema.Field{
+					{Name: "KubernetesIoArch", JSONName: "kubernetes.io/arch", Type: "string"},
+				},
+			},
+		},
+	}
+
+	code, err := NewGenerator(s).Generate()
+	require.NoError(t, err)
+	assert.Contains(t, string(code), `  "kubernetes.io/arch"?: string;`)
+}
+
+func TestToTSType(t *testing.T) {
+	tests := []struct {
+		goType   string
+		expected string
+	}{
+		{"string", "string"},
+		{"bool", "boolean"},
+		{"int", "number"},
+		{"float64", "number"},
+		{"interface{}", "unknown"},
+		{"[]string", "string[]"},
+		{"[][]int", "number[][]"},
+		{"map[string]string", "Record<string, string>"},
+		{"map[string][]ServiceConfig", "Record<string, ServiceConfig[]>"},
+		{"[]map[string]string", "Record<string, string>[]"},
+		{"*bool", "boolean"},
+		{"resource.Quantity", "number | string"},
+		{"[]intstr.IntOrString", "(number | string)[]"},
+		{"metav1.Duration", "string"},
+		{"runtime.RawExtension", "unknown"},
+		{"ServiceConfig", "ServiceConfig"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToTSType(tt.goType))
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Package hints suggests and inserts +miaka:type hints for fields whose type can't be inferred.
+package hints
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TypeMarker is the comment marker used for type hints
+const TypeMarker = "+miaka:type:"
+
+// Suggestion is a type hint for a field whose type can't be inferred from its value
+type Suggestion struct {
+	Path   string // Dotted path to the field; list items are written as "[]"
+	Line   int    // Line of the field's key
+	Column int    // Column of the field's key
+	Type   string // Suggested type (e.g., map[string]string)
+
+	// Guessed is true when the type isn't based on a naming convention and should be reviewed
+	Guessed bool
+
+	// Manual is true when the hint can't be inserted automatically because the key
+	// doesn't start its line (e.g., the first key of a list item: "- name: null")
+	Manual bool
+
+	// Empty is the empty value of the suggested type (e.g., "{}") that replaces a
+	// null value, since null doesn't validate against the generated CRD
+	Empty string
+
+	// Position and text of the null value being replaced
+	valueLine   int
+	valueColumn int
+	valueText   string
+}
+
+func (s Suggestion) String() string {
+	return fmt.Sprintf("line %d: %s: # %s %s", s.Line, s.Path, TypeMarker, s.Type)
+}
+
+// Suggest finds fields that would be generated as interface{} (null values and
+// empty lists without a type hint) and suggests a type hint for each
+func Suggest(data []byte) ([]Suggestion, error) {
+	// yaml.v3 misplaces comments between Windows line endings (CRLF), which
+	// would hide existing hints. Removing the carriage returns keeps line numbers.
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("root node must be a mapping")
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var suggestions []Suggestion
+	walkMapping(doc.Content[0], "", lines, nil, &suggestions)
+	return suggestions, nil
+}
+
+// Apply inserts a "# +miaka:type: <type>" comment above each suggested field and
+// replaces null values with an empty value of the suggested type.
+// Manual suggestions are skipped. Formatting and existing comments are preserved.
+func Apply(data []byte, suggestions []Suggestion) []byte {
+	sorted := make([]Suggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		if !s.Manual {
+			sorted = append(sorted, s)
+		}
+	}
+	// Insert from the bottom up so earlier line numbers stay valid
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Line > sorted[j].Line
+	})
+
+	lines := strings.Split(string(data), "\n")
+	for _, s := range sorted {
+		idx := s.Line - 1
+		if idx < 0 || idx >= len(lines) {
+			continue
+		}
+		if s.Empty != "" && s.valueLine-1 < len(lines) {
+			lines[s.valueLine-1] = replaceValue(lines[s.valueLine-1], s.valueColumn, s.valueText, s.Empty)
+		}
+		comment := strings.Repeat(" ", s.Column-1) + "# " + TypeMarker + " " + s.Type
+		if strings.HasSuffix(lines[idx], "\r") {
+			// Keep the Windows line endings of the file
+			comment += "\r"
+		}
+		lines = append(lines[:idx], append([]string{comment}, lines[idx:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// walkMapping checks each field of a mapping. seen tracks keys already checked
+// in earlier items of the same list, since only the first occurrence determines the type.
+func walkMapping(node *yaml.Node, path string, lines []string, seen map[string]bool, suggestions *[]Suggestion) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+
+		// Skip KRM metadata fields
+		if path == "" && (key == "apiVersion" || key == "kind" || key == "metadata") {
+			continue
+		}
+		if seen != nil {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			if valueNode.Tag == "!!null" && !hasTypeHint(keyNode, valueNode) {
+				suggestion := newSuggestion(keyNode, fieldPath, lines, false)
+				suggestion.Empty = emptyValue(suggestion.Type)
+				suggestion.valueLine, suggestion.valueColumn, suggestion.valueText = valueNode.Line, valueNode.Column, valueNode.Value
+				*suggestions = append(*suggestions, suggestion)
+			}
+		case yaml.SequenceNode:
+			if len(valueNode.Content) == 0 {
+				if !hasTypeHint(keyNode, valueNode) {
+					*suggestions = append(*suggestions, newSuggestion(keyNode, fieldPath, lines, true))
+				}
+				continue
+			}
+			if valueNode.Content[0].Kind == yaml.MappingNode {
+				itemKeys := make(map[string]bool)
+				for _, item := range valueNode.Content {
+					if item.Kind == yaml.MappingNode {
+						walkMapping(item, fieldPath+"[]", lines, itemKeys, suggestions)
+					}
+				}
+			}
+		case yaml.MappingNode:
+			walkMapping(valueNode, fieldPath, lines, nil, suggestions)
+		}
+	}
+}
+
+// newSuggestion builds a suggestion for a field
+func newSuggestion(keyNode *yaml.Node, path string, lines []string, isList bool) Suggestion {
+	hintType, guessed := inferType(keyNode.Value, isList)
+
+	manual := false
+	if keyNode.Line-1 < len(lines) {
+		line := lines[keyNode.Line-1]
+		if keyNode.Column-1 <= len(line) && strings.TrimSpace(line[:keyNode.Column-1]) != "" {
+			manual = true
+		}
+	}
+
+	return Suggestion{
+		Path:    path,
+		Line:    keyNode.Line,
+		Column:  keyNode.Column,
+		Type:    hintType,
+		Guessed: guessed,
+		Manual:  manual,
+	}
+}
+
+// inferType guesses a type from the field name using common Kubernetes conventions
+func inferType(key string, isList bool) (hintType string, guessed bool) {
+	lower := strings.ToLower(key)
+	if !isList && (strings.HasSuffix(lower, "annotations") || strings.HasSuffix(lower, "labels") || lower == "nodeselector") {
+		return "map[string]string", false
+	}
+	if isList {
+		return "[]string", true
+	}
+	return "string", true
+}
+
+// emptyValue returns the YAML for an empty value of a type
+func emptyValue(hintType string) string {
+	switch {
+	case strings.HasPrefix(hintType, "map["):
+		return "{}"
+	case strings.HasPrefix(hintType, "[]"):
+		return "[]"
+	case hintType == "string":
+		return `""`
+	}
+	return ""
+}
+
+// replaceValue replaces the null value starting at column in line, keeping any trailing comment.
+// Implicit nulls ("key:") have empty text and start right after the colon.
+func replaceValue(line string, column int, text, value string) string {
+	idx := column - 1
+	if idx < 0 || idx > len(line) {
+		return line
+	}
+	rest := line[idx:]
+	if text != "" && strings.HasPrefix(rest, text) {
+		rest = rest[len(text):]
+	}
+	return strings.TrimRight(line[:idx], " ") + " " + value + rest
+}
+
+// hasTypeHint reports whether a field already has a +miaka:type hint, in the
+// comment above it or after its key or value
+func hasTypeHint(keyNode, valueNode *yaml.Node) bool {
+	comments := keyNode.HeadComment + "\n" + keyNode.LineComment + "\n" + valueNode.LineComment
+	for _, line := range strings.Split(comments, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if strings.HasPrefix(line, TypeMarker) {
+			return true
+		}
+	}
+	return false
+}
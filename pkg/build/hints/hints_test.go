@@ -0,0 +1,130 @@
+package hints
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const input = `apiVersion: example.com/v1
+kind: Example
+metadata:
+  name: ignored
+# Pod annotations
+podAnnotations:
+service:
+  labels: ~
+  # +miaka:type: []int
+  ports: []
+  selector: null
+args: []
+env:
+- name: A
+  value: ~
+- name: B
+  value: ~
+sidecars:
+- image: null
+tags: [] # +miaka:type: []string
+`
+
+func TestSuggest(t *testing.T) {
+	suggestions, err := Suggest([]byte(input))
+	require.NoError(t, err)
+
+	type result struct {
+		Path    string
+		Line    int
+		Column  int
+		Type    string
+		Guessed bool
+		Manual  bool
+		Empty   string
+	}
+	results := make([]result, 0, len(suggestions))
+	for _, s := range suggestions {
+		results = append(results, result{s.Path, s.Line, s.Column, s.Type, s.Guessed, s.Manual, s.Empty})
+	}
+
+	assert.Equal(t, []result{
+		{Path: "podAnnotations", Line: 6, Column: 1, Type: "map[string]string", Empty: "{}"},
+		{Path: "service.labels", Line: 8, Column: 3, Type: "map[string]string", Empty: "{}"},
+		{Path: "service.selector", Line: 11, Column: 3, Type: "string", Guessed: true, Empty: `""`},
+		{Path: "args", Line: 12, Column: 1, Type: "[]string", Guessed: true},
+		{Path: "env[].value", Line: 15, Column: 3, Type: "string", Guessed: true, Empty: `""`},
+		{Path: "sidecars[].image", Line: 19, Column: 3, Type: "string", Guessed: true, Manual: true, Empty: `""`},
+	}, results)
+}
+
+func TestApply(t *testing.T) {
+	suggestions, err := Suggest([]byte(input))
+	require.NoError(t, err)
+
+	output := string(Apply([]byte(input), suggestions))
+	assert.Contains(t, output, "# Pod annotations\n# +miaka:type: map[string]string\npodAnnotations: {}\n")
+	assert.Contains(t, output, "service:\n  # +miaka:type: map[string]string\n  labels: {}\n")
+	assert.Contains(t, output, "  selector: \"\"\n")
+	assert.Contains(t, output, "- name: A\n  # +miaka:type: string\n  value: \"\"\n")
+	assert.Contains(t, output, "- name: B\n  value: ~\n")
+	assert.Contains(t, output, "sidecars:\n- image: null\n")
+
+	// Applying again finds only the manual suggestion
+	remaining, err := Suggest([]byte(output))
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.True(t, remaining[0].Manual)
+
+	// The parser picks up the inserted hints
+	s, err := parsing.NewParser().Parse([]byte(output))
+	require.NoError(t, err)
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if field.JSONName == "podAnnotations" || field.JSONName == "labels" {
+				assert.Equal(t, "map[string]string", field.Type)
+			}
+			if field.JSONName == "value" {
+				assert.Equal(t, "string", field.Type)
+			}
+			if field.JSONName == "args" {
+				assert.Equal(t, "[]string", field.Type)
+			}
+		}
+	}
+	assert.Error(t, schema.ValidateSchema(s), "manual suggestion is still unresolved")
+}
+
+func TestApply_WindowsLineEndings(t *testing.T) {
+	crlf := strings.ReplaceAll(input, "\n", "\r\n")
+	suggestions, err := Suggest([]byte(crlf))
+	require.NoError(t, err)
+	lf, err := Suggest([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, lf, suggestions, "existing hints are found in CRLF files too")
+
+	output := string(Apply([]byte(crlf), suggestions))
+	assert.Contains(t, output, "# Pod annotations\r\n# +miaka:type: map[string]string\r\npodAnnotations: {}\r\n")
+	assert.NotContains(t, strings.ReplaceAll(output, "\r\n", ""), "\n", "every line keeps its Windows line ending")
+}
+
+func TestReplaceValue(t *testing.T) {
+	assert.Equal(t, "a: {}", replaceValue("a:", 3, "", "{}"))
+	assert.Equal(t, "b: {} # comment", replaceValue("b: ~ # comment", 4, "~", "{}"))
+	assert.Equal(t, `  c: ""`, replaceValue("  c: null", 6, "null", `""`))
+}
+
+func TestSuggest_InvalidInput(t *testing.T) {
+	_, err := Suggest([]byte("a: [b"))
+	require.Error(t, err)
+
+	_, err = Suggest([]byte("- a\n- b\n"))
+	require.Error(t, err)
+}
+
+func TestSuggestion_String(t *testing.T) {
+	s := Suggestion{Path: "podAnnotations", Line: 6, Type: "map[string]string"}
+	assert.Equal(t, "line 6: podAnnotations: # +miaka:type: map[string]string", s.String())
+}
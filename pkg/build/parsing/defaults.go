@@ -0,0 +1,277 @@
+package parsing
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"gopkg.in/yaml.v3"
+	crdmarkers "sigs.k8s.io/controller-tools/pkg/crd/markers"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// Markers that set the default of a field. +default takes JSON, so it also sets
+// the defaults of lists and objects, which the syntax of +kubebuilder:default
+// can't always express.
+const (
+	kubebuilderDefaultMarker = "+kubebuilder:default="
+	jsonDefaultMarker        = "+default="
+)
+
+// kubebuilderDefault parses the values of +kubebuilder:default markers
+var kubebuilderDefault = markers.Must(markers.MakeAnyTypeDefinition("kubebuilder:default", markers.DescribesField, crdmarkers.Default{}))
+
+// Defaults are the defaults of fields from a separate file with the shape of the
+// values (see ParseDefaults), so the example values don't need +kubebuilder:default
+// markers. Objects set the defaults of their fields, and other values are the
+// defaults of their field.
+type Defaults struct {
+	entries map[string]defaultEntry // By values path
+}
+
+// defaultEntry is a value of the defaults file
+type defaultEntry struct {
+	path   string
+	line   int // Line of the key
+	node   *yaml.Node
+	parent string // Values path of the object holding the value ("" at the top level)
+}
+
+// ParseDefaults parses a defaults file. apiVersion and kind are ignored, so the
+// file can be a copy of the example values.
+func ParseDefaults(data []byte) (*Defaults, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	d := &Defaults{entries: map[string]defaultEntry{}}
+	if len(node.Content) == 0 {
+		return d, nil
+	}
+	root := node.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("root node must be a mapping")
+	}
+	duplicates, err := resolveWithDuplicates(root, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(duplicates) > 0 {
+		return nil, &DuplicateKeysError{Duplicates: duplicates}
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		if key := root.Content[i].Value; key != "apiVersion" && key != "kind" {
+			d.add(key, "", root.Content[i], root.Content[i+1])
+		}
+	}
+	return d, nil
+}
+
+// add adds the value at path, and the values of its fields if it's an object
+func (d *Defaults) add(path, parent string, key, node *yaml.Node) {
+	d.entries[path] = defaultEntry{path: path, line: key.Line, node: node, parent: parent}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		d.add(path+"."+node.Content[i].Value, path, node.Content[i], node.Content[i+1])
+	}
+}
+
+// DefaultConflict is a field whose default in the defaults file differs from
+// the default of its marker
+type DefaultConflict struct {
+	// Path is the dotted path of the field (e.g., "controller.replicas")
+	Path string
+	// Line is the line of the field in the values, and DefaultsLine its line
+	// in the defaults file
+	Line         int
+	DefaultsLine int
+	// Marker and File are the defaults of the marker and of the defaults file, as JSON
+	Marker string
+	File   string
+}
+
+func (c DefaultConflict) String() string {
+	return fmt.Sprintf("line %d: %s defaults to %s in its marker, but to %s in the defaults file (line %d)",
+		c.Line, c.Path, c.Marker, c.File, c.DefaultsLine)
+}
+
+// DefaultsError is the error for a defaults file that doesn't match the values:
+// defaults that conflict with markers, and values that aren't fields
+type DefaultsError struct {
+	Conflicts []DefaultConflict
+	// Unknown are the messages of the values of the defaults file that aren't fields
+	Unknown []string
+}
+
+func (e *DefaultsError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts)+len(e.Unknown))
+	for _, conflict := range e.Conflicts {
+		lines = append(lines, conflict.String())
+	}
+	lines = append(lines, e.Unknown...)
+	return fmt.Sprintf("%d default(s) don't match the values (remove the markers or the defaults file entries):\n  %s",
+		len(lines), strings.Join(lines, "\n  "))
+}
+
+// applyDefault adds the default of a field from the defaults file to its markers.
+// The defaults of objects typed as structs (nested) are their fields' defaults.
+func (p *Parser) applyDefault(field *schema.Field, yamlPath, valuesPath string, nested bool) error {
+	if p.opts.Defaults == nil {
+		return nil
+	}
+	entry, ok := p.opts.Defaults.entries[valuesPath]
+	if !ok {
+		return nil
+	}
+	p.usedDefaults[valuesPath] = true
+	if nested {
+		if entry.node.Kind != yaml.MappingNode {
+			return fmt.Errorf("field %s: the defaults file sets it to a %s (line %d), but it's an object", yamlPath, nodeKind(entry.node), entry.line)
+		}
+		return nil
+	}
+	p.useDefaults(valuesPath)
+
+	var value interface{}
+	if err := entry.node.Decode(&value); err != nil {
+		return fmt.Errorf("field %s: failed to decode default (line %d): %w", yamlPath, entry.line, err)
+	}
+	if value == nil {
+		return fmt.Errorf("field %s: the defaults file sets it to null (line %d), which can't be a default", yamlPath, entry.line)
+	}
+	value = jsonValue(value)
+	encoded, err := marshalDefault(value)
+	if err != nil {
+		return fmt.Errorf("field %s: failed to encode default (line %d): %w", yamlPath, entry.line, err)
+	}
+
+	marked, ok, err := markerDefault(field.Comments)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", yamlPath, err)
+	}
+	if ok {
+		if !reflect.DeepEqual(marked, value) {
+			markerJSON, _ := marshalDefault(marked)
+			p.defaultConflicts = append(p.defaultConflicts, DefaultConflict{
+				Path: valuesPath, Line: field.Line, DefaultsLine: entry.line, Marker: markerJSON, File: encoded,
+			})
+		}
+		return nil
+	}
+
+	if entry.node.Kind == yaml.ScalarNode {
+		field.Comments = append(field.Comments, kubebuilderDefaultMarker+encoded)
+	} else {
+		field.Comments = append(field.Comments, jsonDefaultMarker+encoded)
+	}
+	return nil
+}
+
+// useDefaults marks the values of the defaults file in the default at valuesPath
+// as used
+func (p *Parser) useDefaults(valuesPath string) {
+	for path := range p.opts.Defaults.entries {
+		if strings.HasPrefix(path, valuesPath+".") {
+			p.usedDefaults[path] = true
+		}
+	}
+}
+
+// checkDefaults fails for the defaults that conflict with markers, and for the
+// values of the defaults file that aren't fields of the values
+func (p *Parser) checkDefaults() error {
+	if p.opts.Defaults == nil {
+		return nil
+	}
+	var unknown []defaultEntry
+	for path, entry := range p.opts.Defaults.entries {
+		// Only the outermost value that isn't a field is reported
+		if !p.usedDefaults[path] && (entry.parent == "" || p.usedDefaults[entry.parent]) {
+			unknown = append(unknown, entry)
+		}
+	}
+	if len(p.defaultConflicts) == 0 && len(unknown) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(unknown, func(a, b defaultEntry) int { return cmp.Compare(a.line, b.line) })
+	messages := make([]string, 0, len(unknown))
+	for _, entry := range unknown {
+		messages = append(messages, fmt.Sprintf("defaults file line %d: %s isn't a field of the values", entry.line, entry.path))
+	}
+	return &DefaultsError{Conflicts: p.defaultConflicts, Unknown: messages}
+}
+
+// markerDefault returns the default of a +kubebuilder:default or +default marker
+func markerDefault(comments []string) (interface{}, bool, error) {
+	for _, comment := range comments {
+		switch {
+		case strings.HasPrefix(comment, kubebuilderDefaultMarker):
+			parsed, err := kubebuilderDefault.Parse(comment)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid marker %q: %w", comment, err)
+			}
+			return normalizeDefault(parsed.(crdmarkers.Default).Value)
+		case strings.HasPrefix(comment, jsonDefaultMarker):
+			var value interface{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(comment, jsonDefaultMarker)), &value); err != nil {
+				return nil, false, fmt.Errorf("invalid marker %q: %w", comment, err)
+			}
+			return value, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// normalizeDefault converts a default to the types of decoded JSON, so defaults
+// compare equal however they were written
+func normalizeDefault(value interface{}) (interface{}, bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode default: %w", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, false, fmt.Errorf("failed to decode default: %w", err)
+	}
+	return normalized, true, nil
+}
+
+// jsonValue converts a value decoded from YAML to the types of decoded JSON
+// (float64 numbers and map[string]interface{} objects)
+func jsonValue(value interface{}) interface{} {
+	normalized, _, err := normalizeDefault(value)
+	if err != nil {
+		return value
+	}
+	return normalized
+}
+
+// marshalDefault encodes a default as compact JSON, without escaping HTML characters
+func marshalDefault(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// nodeKind describes the kind of a YAML node
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.MappingNode:
+		return "object"
+	}
+	return "scalar"
+}
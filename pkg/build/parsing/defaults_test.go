@@ -0,0 +1,133 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// fieldComments returns the comments of the fields of the named struct, by JSON name
+func fieldComments(s *schema.Schema, structName string) map[string][]string {
+	comments := map[string][]string{}
+	for _, structDef := range s.Structs {
+		if structDef.Name != structName {
+			continue
+		}
+		for _, field := range structDef.Fields {
+			comments[field.JSONName] = field.Comments
+		}
+	}
+	return comments
+}
+
+func TestParse_Defaults(t *testing.T) {
+	values := `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+# +kubebuilder:default=3
+replicas: 1
+args: []
+controller:
+  image: nginx
+  # +miaka:type: map[string]string
+  labels: {}
+  # +miaka:type: interface{}
+  config: {}
+`
+	defaults, err := ParseDefaults([]byte(`apiVersion: example.com/v1
+kind: Example
+replicas: 3
+args: [--verbose, "a<b"]
+controller:
+  image: nginx:1.25
+  labels:
+    team: platform
+  config:
+    debug: true
+`))
+	if err != nil {
+		t.Fatalf("ParseDefaults failed: %v", err)
+	}
+	s, err := NewParserWithOptions(Options{Defaults: defaults}).Parse([]byte(values))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	root := fieldComments(s, "Example")
+	// The marker's default is kept when the defaults agree
+	if want := []string{"Number of replicas", "+kubebuilder:default=3"}; !reflect.DeepEqual(root["replicas"], want) {
+		t.Errorf("Expected replicas comments %q, got %q", want, root["replicas"])
+	}
+	if want := []string{`+default=["--verbose","a<b"]`}; !reflect.DeepEqual(root["args"], want) {
+		t.Errorf("Expected args comments %q, got %q", want, root["args"])
+	}
+
+	controller := fieldComments(s, "ControllerConfig")
+	for field, want := range map[string]string{
+		"image":  `+kubebuilder:default="nginx:1.25"`,
+		"labels": `+default={"team":"platform"}`,
+		"config": `+default={"debug":true}`,
+	} {
+		if !hasMarker(controller[field], want) {
+			t.Errorf("Expected %s to have %q, got %q", field, want, controller[field])
+		}
+	}
+}
+
+func TestParse_DefaultsErrors(t *testing.T) {
+	values := "# +kubebuilder:default=TCP\nprotocol: TCP\n# +kubebuilder:default={a,b}\nargs: [a]\nserver:\n  port: 80\n"
+	tests := []struct {
+		name     string
+		defaults string
+		want     string
+	}{
+		{"conflict", "protocol: UDP\n", `line 2: protocol defaults to "TCP" in its marker, but to "UDP" in the defaults file (line 1)`},
+		{"list conflict", "args: [b]\n", `line 4: args defaults to ["a","b"] in its marker, but to ["b"] in the defaults file (line 1)`},
+		{"unknown field", "server:\n  port: 8080\n  host: x\nclient:\n  timeout: 1\n", "defaults file line 3: server.host isn't a field of the values\n  defaults file line 4: client isn't a field of the values"},
+		{"object", "server: 8080\n", "field server: the defaults file sets it to a scalar (line 1), but it's an object"},
+		{"null", "protocol: null\n", "field protocol: the defaults file sets it to null (line 1), which can't be a default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults, err := ParseDefaults([]byte(tt.defaults))
+			if err != nil {
+				t.Fatalf("ParseDefaults failed: %v", err)
+			}
+			_, err = NewParserWithOptions(Options{Plain: true, Defaults: defaults}).Parse([]byte(values))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParse_DefaultsAgree(t *testing.T) {
+	// Defaults equal to the markers' aren't conflicts, however they're written
+	values := "# +kubebuilder:default=8080\nport: 80\n# +kubebuilder:default={a,b}\nargs: [a]\n# +default={\"x\": 1}\n# +miaka:type: map[string]int\nlimits: {}\n"
+	defaults, err := ParseDefaults([]byte("port: 8080\nargs: [a, b]\nlimits: {x: 1}\n"))
+	if err != nil {
+		t.Fatalf("ParseDefaults failed: %v", err)
+	}
+	if _, err := NewParserWithOptions(Options{Plain: true, Defaults: defaults}).Parse([]byte(values)); err != nil {
+		t.Errorf("Expected no conflicts, got: %v", err)
+	}
+}
+
+func TestParseDefaults_Errors(t *testing.T) {
+	for input, want := range map[string]string{
+		"- a\n":         "root node must be a mapping",
+		"a: 1\na: 2\n":  "duplicate key a",
+		"a: [\n":        "failed to parse YAML",
+		"a: *missing\n": "failed to parse YAML",
+	} {
+		if _, err := ParseDefaults([]byte(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseDefaults(%q): expected error containing %q, got: %v", input, want, err)
+		}
+	}
+	// An empty file sets no defaults
+	if _, err := ParseDefaults(nil); err != nil {
+		t.Errorf("Expected an empty file to parse, got: %v", err)
+	}
+}
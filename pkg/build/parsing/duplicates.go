@@ -0,0 +1,160 @@
+package parsing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DuplicateKeyPolicy controls how the parser handles keys that a mapping has
+// more than once, which YAML loaders (and Helm) resolve by taking the last one
+type DuplicateKeyPolicy string
+
+// Duplicate key policies
+const (
+	// DuplicatesError fails parsing, since a duplicated key is almost always
+	// a mistake that silently drops the values of the earlier ones
+	DuplicatesError DuplicateKeyPolicy = "error"
+	// DuplicatesWarn warns about each duplicate key and keeps the last one
+	DuplicatesWarn DuplicateKeyPolicy = "warn"
+	// DuplicatesLastWins keeps the last of the duplicate keys, like YAML loaders
+	DuplicatesLastWins DuplicateKeyPolicy = "last-wins"
+)
+
+// ParseDuplicateKeyPolicy parses a duplicate key policy. "" is DuplicatesError.
+func ParseDuplicateKeyPolicy(policy string) (DuplicateKeyPolicy, error) {
+	switch p := DuplicateKeyPolicy(policy); p {
+	case "":
+		return DuplicatesError, nil
+	case DuplicatesError, DuplicatesWarn, DuplicatesLastWins:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid duplicate key policy %q (must be error, warn, or last-wins)", policy)
+}
+
+// DuplicateKey is a key that a mapping has more than once
+type DuplicateKey struct {
+	// Path is the dotted path of the key (e.g., "controller.replicas")
+	Path string
+	// Line is the line of the duplicate, and FirstLine the line of the first
+	// occurrence of the key in the mapping
+	Line      int
+	FirstLine int
+}
+
+func (d DuplicateKey) String() string {
+	return fmt.Sprintf("line %d: duplicate key %s (first set at line %d)", d.Line, d.Path, d.FirstLine)
+}
+
+// DuplicateKeysError is the error for duplicate keys with DuplicatesError
+type DuplicateKeysError struct {
+	Duplicates []DuplicateKey
+}
+
+func (e *DuplicateKeysError) Error() string {
+	lines := make([]string, 0, len(e.Duplicates))
+	for _, duplicate := range e.Duplicates {
+		lines = append(lines, duplicate.String())
+	}
+	return fmt.Sprintf("%d duplicate key(s), of which Helm would only use the last value:\n  %s", len(e.Duplicates), strings.Join(lines, "\n  "))
+}
+
+// resolveWithDuplicates resolves the aliases and merge keys under root like
+// ResolveMergeKeys, and returns the duplicate keys of its mappings, checked on
+// the way. All but the last of each key are removed if drop is set. Merge keys
+// may repeat, and aliases are checked where their anchors are.
+func resolveWithDuplicates(root *yaml.Node, drop bool) ([]DuplicateKey, error) {
+	c := &duplicateChecker{drop: drop, index: map[string]int{}}
+	if err := newResolver(c).resolve(root); err != nil {
+		return nil, err
+	}
+	return c.duplicates, nil
+}
+
+// duplicateChecker finds the duplicate keys of the mappings a resolver walks
+type duplicateChecker struct {
+	drop       bool
+	duplicates []DuplicateKey
+	// path is the nodes holding the node being resolved, with the index of
+	// each one's child, so paths are only built for duplicates
+	path []pathStep
+	// index maps the keys of the mapping being checked to their positions.
+	// It's emptied after each mapping and reused.
+	index map[string]int
+}
+
+// pathStep is a step of the path of a node: the child at index of parent
+type pathStep struct {
+	parent *yaml.Node
+	index  int
+}
+
+// push and pop add and remove the child at index of parent to the path
+func (c *duplicateChecker) push(parent *yaml.Node, index int) {
+	c.path = append(c.path, pathStep{parent, index})
+}
+
+func (c *duplicateChecker) pop() {
+	c.path = c.path[:len(c.path)-1]
+}
+
+// check records the duplicate keys of a mapping, removing all but the last of
+// each if drop is set
+func (c *duplicateChecker) check(mapping *yaml.Node) {
+	// Deleting the keys keeps emptying the index proportional to the mapping,
+	// unlike clearing it after a large one
+	defer func() {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			delete(c.index, mapping.Content[i].Value)
+		}
+	}()
+	found := false
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if isMergeKey(key) {
+			continue
+		}
+		if first, ok := c.index[key.Value]; ok {
+			c.duplicates = append(c.duplicates, DuplicateKey{Path: c.keyPath(key.Value), Line: key.Line, FirstLine: mapping.Content[first].Line})
+			found = true
+			continue
+		}
+		c.index[key.Value] = i
+	}
+	if !found || !c.drop {
+		return
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		c.index[mapping.Content[i].Value] = i
+	}
+	content := mapping.Content[:0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if isMergeKey(key) || c.index[key.Value] == i {
+			content = append(content, key, value)
+		}
+	}
+	mapping.Content = content
+}
+
+// keyPath returns the dotted path of a key of the mapping being checked, with
+// the indexes of list items (e.g., "env.0.name")
+func (c *duplicateChecker) keyPath(key string) string {
+	var b strings.Builder
+	for _, step := range c.path {
+		switch step.parent.Kind {
+		case yaml.MappingNode:
+			b.WriteString(step.parent.Content[step.index&^1].Value)
+		case yaml.SequenceNode:
+			b.WriteString(strconv.Itoa(step.index))
+		default:
+			continue
+		}
+		b.WriteByte('.')
+	}
+	b.WriteString(key)
+	return b.String()
+}
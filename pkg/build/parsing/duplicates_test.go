@@ -0,0 +1,141 @@
+package parsing
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const duplicatesYAML = `apiVersion: example.com/v1
+kind: Example
+replicas: 3
+controller:
+  image: nginx
+  image: nginx:1.27
+env:
+  - name: A
+    name: B
+replicas: "three"
+`
+
+func TestParse_DuplicateKeysError(t *testing.T) {
+	_, err := NewParser().Parse([]byte(duplicatesYAML))
+	var duplicatesErr *DuplicateKeysError
+	if !errors.As(err, &duplicatesErr) {
+		t.Fatalf("Expected a DuplicateKeysError, got: %v", err)
+	}
+	want := []DuplicateKey{
+		{Path: "controller.image", Line: 6, FirstLine: 5},
+		{Path: "env.0.name", Line: 9, FirstLine: 8},
+		{Path: "replicas", Line: 10, FirstLine: 3},
+	}
+	if !reflect.DeepEqual(duplicatesErr.Duplicates, want) {
+		t.Errorf("Expected %v, got %v", want, duplicatesErr.Duplicates)
+	}
+	if !strings.HasPrefix(err.Error(), "3 duplicate key(s), of which Helm would only use the last value:\n") ||
+		!strings.Contains(err.Error(), "line 10: duplicate key replicas (first set at line 3)") {
+		t.Errorf("Expected the lines of the duplicates in the error, got: %v", err)
+	}
+}
+
+// TestParse_DuplicateKeysAnchors tests that the duplicates of an anchored
+// mapping are reported once, where the anchor is
+func TestParse_DuplicateKeysAnchors(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+base: &base
+  a: 1
+  a: 2
+copy: *base
+merged:
+  <<: *base
+  b: 3
+`
+	_, err := NewParser().Parse([]byte(yamlContent))
+	var duplicatesErr *DuplicateKeysError
+	if !errors.As(err, &duplicatesErr) {
+		t.Fatalf("Expected a DuplicateKeysError, got: %v", err)
+	}
+	want := []DuplicateKey{{Path: "base.a", Line: 5, FirstLine: 4}}
+	if !reflect.DeepEqual(duplicatesErr.Duplicates, want) {
+		t.Errorf("Expected %v, got %v", want, duplicatesErr.Duplicates)
+	}
+
+	s, err := NewParserWithOptions(Options{DuplicateKeys: DuplicatesWarn}).Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(s.Warnings) != 1 {
+		t.Errorf("Expected a single warning, got: %v", s.Warnings)
+	}
+	for _, path := range [][]string{{"base", "a"}, {"copy", "a"}, {"merged", "a"}} {
+		if field, ok := s.FieldAt(path); !ok || field.Line != 5 {
+			t.Errorf("Expected %v to be the a of line 5, got %+v", path, field)
+		}
+	}
+}
+
+func TestParse_DuplicateKeysWarn(t *testing.T) {
+	s, err := NewParserWithOptions(Options{DuplicateKeys: DuplicatesWarn}).Parse([]byte(duplicatesYAML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(s.Warnings) != 3 || !strings.HasPrefix(s.Warnings[2], "line 10: duplicate key replicas") {
+		t.Errorf("Expected a warning for each duplicate, got: %v", s.Warnings)
+	}
+
+	// The last value wins, like in Helm
+	replicas, ok := s.FieldAt([]string{"replicas"})
+	if !ok || replicas.Type != "string" || replicas.Line != 10 {
+		t.Errorf("Expected the string replicas of line 10, got %+v", replicas)
+	}
+}
+
+func TestParse_DuplicateKeysLastWins(t *testing.T) {
+	s, err := NewParserWithOptions(Options{DuplicateKeys: DuplicatesLastWins}).Parse([]byte(duplicatesYAML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(s.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got: %v", s.Warnings)
+	}
+	count := 0
+	for _, field := range s.Structs[len(s.Structs)-1].Fields {
+		if field.JSONName == "replicas" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected a single replicas field, got %d", count)
+	}
+}
+
+// TestParse_DuplicateMergeKeys tests that merge keys aren't duplicates, and
+// neither are the fields they merge
+func TestParse_DuplicateMergeKeys(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+one: &one {a: 1}
+two: &two {b: 2}
+out:
+  <<: *one
+  <<: *two
+  a: 3
+`
+	if _, err := NewParser().Parse([]byte(yamlContent)); err != nil {
+		t.Errorf("Parse failed: %v", err)
+	}
+}
+
+func TestParseDuplicateKeyPolicy(t *testing.T) {
+	for input, want := range map[string]DuplicateKeyPolicy{"": DuplicatesError, "warn": DuplicatesWarn, "last-wins": DuplicatesLastWins} {
+		got, err := ParseDuplicateKeyPolicy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseDuplicateKeyPolicy(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseDuplicateKeyPolicy("first-wins"); err == nil {
+		t.Error("Expected an error for an invalid policy")
+	}
+}
@@ -0,0 +1,112 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markers that make a field freeform: any value is accepted and kept
+const (
+	schemalessMarker       = "+kubebuilder:validation:Schemaless"
+	preserveUnknownsMarker = "+kubebuilder:pruning:PreserveUnknownFields"
+)
+
+// PathFilter selects the values whose fields are typed. Other values are freeform,
+// generated with x-kubernetes-preserve-unknown-fields instead of a schema.
+//
+// Patterns are globs over dotted YAML paths (e.g., "controller.affinity"), where
+// list items are written as "[]" after the list (e.g., "env[].name"). "*" matches
+// any part of a path segment, and "**" matches any number of segments, including
+// none, so "controller.affinity.**" matches controller.affinity and everything in it.
+type PathFilter struct {
+	// Include selects the paths to type; if empty, all paths are typed
+	Include []string
+	// Exclude selects paths to make freeform, even if they're included
+	Exclude []string
+}
+
+// Validate checks that all patterns are well-formed
+func (f PathFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		for _, segment := range strings.Split(pattern, ".") {
+			if segment == "" {
+				return fmt.Errorf("invalid path pattern %q (expected dotted field names, e.g., controller.affinity.**)", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Freeform reports whether the value at path is freeform: it matches an exclude
+// pattern, or there are include patterns and neither the path, its ancestors,
+// nor any of its descendants can match one
+func (f PathFilter) Freeform(path string) bool {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return false
+	}
+	segments := strings.Split(path, ".")
+	for _, pattern := range f.Exclude {
+		if matchSegments(strings.Split(pattern, "."), segments, false) {
+			return true
+		}
+	}
+	if len(f.Include) == 0 {
+		return false
+	}
+	for _, pattern := range f.Include {
+		patternSegments := strings.Split(pattern, ".")
+		// Descendants of the path may match
+		if matchSegments(patternSegments, segments, true) {
+			return false
+		}
+		// The path or one of its ancestors matches
+		for i := 1; i < len(segments); i++ {
+			if matchSegments(patternSegments, segments[:i], false) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchSegments reports whether a pattern matches path segments. With prefix, it
+// also reports whether the pattern could match paths that start with the segments.
+func matchSegments(pattern, segments []string, prefix bool) bool {
+	if len(segments) == 0 {
+		if prefix {
+			return true
+		}
+		for _, p := range pattern {
+			if p != "**" {
+				return false
+			}
+		}
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		return matchSegments(pattern[1:], segments, prefix) || matchSegments(pattern, segments[1:], prefix)
+	}
+	return matchSegment(pattern[0], segments[0]) && matchSegments(pattern[1:], segments[1:], prefix)
+}
+
+// matchSegment reports whether a path segment matches a pattern in which "*"
+// matches any run of characters
+func matchSegment(pattern, segment string) bool {
+	prefix, rest, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return pattern == segment
+	}
+	if !strings.HasPrefix(segment, prefix) {
+		return false
+	}
+	segment = segment[len(prefix):]
+	for i := 0; i <= len(segment); i++ {
+		if matchSegment(rest, segment[i:]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package parsing
+
+import "testing"
+
+// TestPathFilter_Freeform tests matching YAML paths against include and exclude patterns
+func TestPathFilter_Freeform(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   PathFilter
+		path     string
+		freeform bool
+	}{
+		{"no patterns", PathFilter{}, "controller.affinity", false},
+		{"exact exclude", PathFilter{Exclude: []string{"controller.affinity"}}, "controller.affinity", true},
+		{"exclude doesn't match parent", PathFilter{Exclude: []string{"controller.affinity"}}, "controller", false},
+		{"double star matches itself", PathFilter{Exclude: []string{"controller.affinity.**"}}, "controller.affinity", true},
+		{"double star matches descendants", PathFilter{Exclude: []string{"controller.**"}}, "controller.affinity.nodeAffinity", true},
+		{"double star in the middle", PathFilter{Exclude: []string{"**.affinity"}}, "server.pod.affinity", true},
+		{"star matches one segment", PathFilter{Exclude: []string{"*.podAnnotations"}}, "server.podAnnotations", true},
+		{"star doesn't match two segments", PathFilter{Exclude: []string{"*.podAnnotations"}}, "server.pod.podAnnotations", false},
+		{"star within a segment", PathFilter{Exclude: []string{"controller.*Affinity"}}, "controller.podAntiAffinity", true},
+		{"list items", PathFilter{Exclude: []string{"env[].valueFrom"}}, "env[].valueFrom", true},
+		{"included path", PathFilter{Include: []string{"controller.replicas"}}, "controller.replicas", false},
+		{"ancestor of included path", PathFilter{Include: []string{"controller.replicas"}}, "controller", false},
+		{"sibling of included path", PathFilter{Include: []string{"controller.replicas"}}, "controller.affinity", true},
+		{"descendant of included path", PathFilter{Include: []string{"controller"}}, "controller.affinity.nodeAffinity", false},
+		{"not included", PathFilter{Include: []string{"controller.**"}}, "server", true},
+		{"exclude wins over include", PathFilter{Include: []string{"controller.**"}, Exclude: []string{"controller.affinity"}}, "controller.affinity", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Freeform(tt.path); got != tt.freeform {
+				t.Errorf("Freeform(%q) = %v, expected %v", tt.path, got, tt.freeform)
+			}
+		})
+	}
+}
+
+// TestPathFilter_Validate tests that malformed patterns are rejected
+func TestPathFilter_Validate(t *testing.T) {
+	if err := (PathFilter{Include: []string{"controller.**"}, Exclude: []string{"*.affinity"}}).Validate(); err != nil {
+		t.Errorf("Expected valid patterns, got %v", err)
+	}
+	for _, pattern := range []string{"", "controller..affinity", ".controller"} {
+		if err := (PathFilter{Exclude: []string{pattern}}).Validate(); err == nil {
+			t.Errorf("Expected an error for pattern %q", pattern)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package parsing
+
+import "strings"
+
+// helm-docs (https://github.com/norwoodj/helm-docs) starts descriptions with
+// "-- " and documents defaults the values don't show with "@default -- "
+const (
+	HelmDocsDescriptionPrefix = "-- "
+	HelmDocsDefaultPrefix     = "@default -- "
+)
+
+// helmDocsAnnotations are the helm-docs annotations that only lay out its docs
+var helmDocsAnnotations = []string{"@section -- ", "@notationType -- ", "@raw", "@ignored"}
+
+// DocumentedDefaultPrefix introduces a documented default in a description
+// (e.g., "Default: `.Release.Namespace`"). Unlike +kubebuilder:default, it
+// doesn't set a default.
+const DocumentedDefaultPrefix = "Default: "
+
+// HelmDocsComments converts formatted comment lines in helm-docs syntax to
+// miaka's: the "-- " that starts a description is dropped, annotations that
+// only lay out helm-docs output (e.g., "@section -- Ingress") are dropped, and
+// the documented default of "@default -- " is returned apart. Other lines are
+// kept as they are.
+func HelmDocsComments(comments []string) (lines []string, documentedDefault string) {
+	for _, comment := range comments {
+		switch {
+		case strings.HasPrefix(comment, HelmDocsDefaultPrefix):
+			documentedDefault = strings.TrimSpace(strings.TrimPrefix(comment, HelmDocsDefaultPrefix))
+		case isHelmDocsAnnotation(comment):
+		case strings.HasPrefix(comment, HelmDocsDescriptionPrefix):
+			if text := strings.TrimSpace(strings.TrimPrefix(comment, HelmDocsDescriptionPrefix)); text != "" {
+				lines = append(lines, text)
+			}
+		default:
+			lines = append(lines, comment)
+		}
+	}
+	return lines, documentedDefault
+}
+
+// isHelmDocsAnnotation reports whether a comment line is a layout annotation
+func isHelmDocsAnnotation(comment string) bool {
+	for _, annotation := range helmDocsAnnotations {
+		if comment == strings.TrimSpace(annotation) || strings.HasPrefix(comment, annotation) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package parsing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHelmDocsComments(t *testing.T) {
+	tests := []struct {
+		name        string
+		comments    []string
+		wantLines   []string
+		wantDefault string
+	}{
+		{
+			name:      "description",
+			comments:  []string{"-- Override the namespace", "Only for namespaced installs", "+kubebuilder:validation:MinLength=1"},
+			wantLines: []string{"Override the namespace", "Only for namespaced installs", "+kubebuilder:validation:MinLength=1"},
+		},
+		{
+			name:        "documented default",
+			comments:    []string{"-- Override the namespace", "@default -- `.Release.Namespace`"},
+			wantLines:   []string{"Override the namespace"},
+			wantDefault: "`.Release.Namespace`",
+		},
+		{
+			name:      "layout annotations",
+			comments:  []string{"@section -- Ingress", "-- Ingress host", "@notationType -- tpl", "@raw", "@ignored"},
+			wantLines: []string{"Ingress host"},
+		},
+		{
+			name:      "plain comments",
+			comments:  []string{"Port of the service", "-1 disables it"},
+			wantLines: []string{"Port of the service", "-1 disables it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, documentedDefault := HelmDocsComments(tt.comments)
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines = %q, want %q", lines, tt.wantLines)
+			}
+			if documentedDefault != tt.wantDefault {
+				t.Errorf("documentedDefault = %q, want %q", documentedDefault, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestParse_HelmDocs(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# -- Override the namespace
+# @default -- ` + "`.Release.Namespace`" + `
+namespaceOverride: ""
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	field := s.Structs[len(s.Structs)-1].Fields[0]
+	if want := []string{"Override the namespace", "Default: `.Release.Namespace`"}; !reflect.DeepEqual(field.Comments, want) {
+		t.Errorf("Comments = %q, want %q", field.Comments, want)
+	}
+	if field.DocumentedDefault != "`.Release.Namespace`" {
+		t.Errorf("DocumentedDefault = %q, want the documented default", field.DocumentedDefault)
+	}
+}
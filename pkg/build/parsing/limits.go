@@ -0,0 +1,112 @@
+package parsing
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limits bound the values files the parser accepts, so deeply nested or
+// adversarial input (e.g., from an untrusted third-party chart) fails with a
+// clear error instead of exhausting the stack or memory. A zero limit uses its
+// default (see DefaultLimits), and a negative limit disables it.
+type Limits struct {
+	// MaxDepth is how deeply mappings and lists may be nested
+	MaxDepth int
+	// MaxFields is the number of mapping keys and list items, counting the
+	// values of aliases each time they're used
+	MaxFields int
+	// MaxSize is the size of the file in bytes
+	MaxSize int
+}
+
+// DefaultLimits are far above what real values files need
+var DefaultLimits = Limits{
+	MaxDepth:  100,
+	MaxFields: 100000,
+	MaxSize:   10 << 20,
+}
+
+// LimitError is the error for values files that exceed a limit
+type LimitError struct {
+	// Limit is the name of the exceeded limit ("depth", "fields", or "size")
+	Limit string
+	Max   int
+	// Line is where the limit was exceeded (0 for size)
+	Line int
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "depth":
+		return fmt.Sprintf("line %d: values are nested more than %d levels deep", e.Line, e.Max)
+	case "fields":
+		return fmt.Sprintf("line %d: values have more than %d fields", e.Line, e.Max)
+	}
+	return fmt.Sprintf("values file is larger than %d bytes", e.Max)
+}
+
+// withDefaults returns the limits with zero limits set to their defaults
+func (l Limits) withDefaults() Limits {
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultLimits.MaxDepth
+	}
+	if l.MaxFields == 0 {
+		l.MaxFields = DefaultLimits.MaxFields
+	}
+	if l.MaxSize == 0 {
+		l.MaxSize = DefaultLimits.MaxSize
+	}
+	return l
+}
+
+// checkSize checks the size of a values file against MaxSize
+func (l Limits) checkSize(size int64) error {
+	l = l.withDefaults()
+	if l.MaxSize > 0 && size > int64(l.MaxSize) {
+		return &LimitError{Limit: "size", Max: l.MaxSize}
+	}
+	return nil
+}
+
+// checkNodes checks the depth and number of fields of the values under root
+// against MaxDepth and MaxFields. The nodes are walked iteratively, following
+// aliases, and the walk stops at the first exceeded limit, so it's bounded even
+// for alias bombs.
+func (l Limits) checkNodes(root *yaml.Node) error {
+	l = l.withDefaults()
+	type entry struct {
+		node  *yaml.Node
+		depth int
+	}
+	stack := []entry{{root, 0}}
+	fields := 0
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node := current.node
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		if node.Kind != yaml.MappingNode && node.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		depth := current.depth + 1
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return &LimitError{Limit: "depth", Max: l.MaxDepth, Line: node.Line}
+		}
+		step := 1
+		if node.Kind == yaml.MappingNode {
+			step = 2
+		}
+		for i := 0; i < len(node.Content); i += step {
+			fields++
+			if l.MaxFields > 0 && fields > l.MaxFields {
+				return &LimitError{Limit: "fields", Max: l.MaxFields, Line: node.Content[i].Line}
+			}
+			stack = append(stack, entry{node.Content[i+step-1], depth})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package parsing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+)
+
+// nested returns values nested depth mappings deep
+func nested(depth int) string {
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		b.WriteString(strings.Repeat("  ", i) + "a:\n")
+	}
+	b.WriteString(strings.Repeat("  ", depth) + "leaf: 1\n")
+	return b.String()
+}
+
+// aliasBomb returns values whose aliases expand to 10^levels fields
+func aliasBomb(levels int) string {
+	var b strings.Builder
+	b.WriteString("l0: &l0 [x, x, x, x, x, x, x, x, x, x]\n")
+	for i := 1; i <= levels; i++ {
+		prev := "*l" + string(rune('0'+i-1))
+		b.WriteString("l" + string(rune('0'+i)) + ": &l" + string(rune('0'+i)) + " [" + strings.Repeat(prev+", ", 9) + prev + "]\n")
+	}
+	return b.String()
+}
+
+func TestParse_Limits(t *testing.T) {
+	tests := []struct {
+		name   string
+		yaml   string
+		limits Limits
+		want   string
+	}{
+		{"depth", nested(5), Limits{MaxDepth: 4}, "line 5: values are nested more than 4 levels deep"},
+		{"default depth", nested(DefaultLimits.MaxDepth + 1), Limits{}, "values are nested more than 100 levels deep"},
+		{"fields", "a: 1\nb: 2\nc: [1, 2]\n", Limits{MaxFields: 3}, "line 3: values have more than 3 fields"},
+		{"alias bomb", aliasBomb(8), Limits{}, "values have more than 100000 fields"},
+		{"size", "replicas: 1\n", Limits{MaxSize: 8}, "values file is larger than 8 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParserWithOptions(Options{Plain: true, Limits: tt.limits}).Parse([]byte(tt.yaml))
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected a LimitError %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParse_WithinLimits(t *testing.T) {
+	for name, tt := range map[string]struct {
+		yaml   string
+		limits Limits
+	}{
+		"at the limits": {nested(4), Limits{MaxDepth: 5, MaxFields: 5}},
+		"disabled":      {nested(DefaultLimits.MaxDepth + 1), Limits{MaxDepth: -1}},
+		"small aliases": {aliasBomb(2), Limits{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewParserWithOptions(Options{Plain: true, Limits: tt.limits}).Parse([]byte(tt.yaml)); err != nil {
+				t.Errorf("Expected the values to parse, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseFileFS_MaxSize(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{"values.yaml": []byte("replicas: 1\n")})
+	_, err := NewParserWithOptions(Options{Plain: true, Limits: Limits{MaxSize: 4}}).ParseFileFS(fsys, "values.yaml")
+	if err == nil || err.Error() != "values file is larger than 4 bytes" {
+		t.Errorf("Expected a size error, got: %v", err)
+	}
+}
+
+// FuzzParse checks that the parser returns an error rather than panicking or
+// hanging on arbitrary input
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"apiVersion: example.com/v1\nkind: Example\nreplicas: 1\n",
+		"image:\n  repository: nginx\n  tag: latest\nports: [80, 443]\n",
+		"# +miaka:type: map[string]string\nlabels: {}\n",
+		"base: &base {a: 1}\nderived:\n  <<: *base\n  b: 2\n",
+		"items:\n  - name: a\n    value: \"{{ .Release.Name }}\"\n",
+		nested(10),
+		aliasBomb(3),
+		"a: 1\na: 2\n",
+		"? [complex]\n: key\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, opts := range []Options{{InferSemanticTypes: true}, {Plain: true, DuplicateKeys: DuplicatesWarn}} {
+			_, _ = NewParserWithOptions(opts).Parse(data)
+		}
+	})
+}
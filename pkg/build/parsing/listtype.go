@@ -0,0 +1,116 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// ListTypeMarker sets the merge semantics of a list (e.g., "+miaka:listType=map"):
+// atomic lists are replaced as a whole, the items of set lists are unique
+// scalars, and the items of map lists are objects that are unique by the fields
+// of their ListMapKeyMarker markers
+const ListTypeMarker = "+miaka:listType="
+
+// ListMapKeyMarker names a field that identifies the items of a map list (e.g.,
+// "+miaka:listMapKey=name"). It can be repeated for keys of several fields.
+const ListMapKeyMarker = "+miaka:listMapKey="
+
+// requiredMarker makes a field required, which the keys of map lists must be
+const requiredMarker = "+" + validationPrefix + "Required"
+
+// listTypes are the values of ListTypeMarker
+var listTypes = map[string]bool{"atomic": true, "set": true, "map": true}
+
+// applyListType replaces the +miaka:listType= and +miaka:listMapKey= markers of
+// a list with controller-gen's +listType= and +listMapKey= markers, which set
+// x-kubernetes-list-type and x-kubernetes-list-map-keys in the CRD. The key
+// fields of map lists are made required, as the API server requires unless they
+// have a default.
+func (p *Parser) applyListType(field *schema.Field, yamlPath string) error {
+	listType := ""
+	var keys []string
+	comments := make([]string, 0, len(field.Comments))
+	for _, comment := range field.Comments {
+		switch {
+		case strings.HasPrefix(comment, ListTypeMarker):
+			if listType != "" {
+				return fmt.Errorf("field %s: more than one %s marker", yamlPath, ListTypeMarker)
+			}
+			listType = strings.TrimSpace(strings.TrimPrefix(comment, ListTypeMarker))
+			if !listTypes[listType] {
+				return fmt.Errorf("field %s: invalid list type %q (must be atomic, set, or map)", yamlPath, listType)
+			}
+			comments = append(comments, "+listType="+listType)
+		case strings.HasPrefix(comment, ListMapKeyMarker):
+			key := strings.TrimSpace(strings.TrimPrefix(comment, ListMapKeyMarker))
+			if key == "" {
+				return fmt.Errorf("field %s: %s needs the name of a field of the items", yamlPath, ListMapKeyMarker)
+			}
+			keys = append(keys, key)
+			comments = append(comments, "+listMapKey="+key)
+		default:
+			comments = append(comments, comment)
+		}
+	}
+	if listType == "" && len(keys) == 0 {
+		return nil
+	}
+	field.Comments = comments
+
+	if !field.IsSlice {
+		return fmt.Errorf("field %s: %s only applies to lists", yamlPath, ListTypeMarker)
+	}
+	items := p.structDef(field.ElemType)
+	switch {
+	case len(keys) > 0 && listType != "map":
+		return fmt.Errorf("field %s: %s only applies to lists marked %smap", yamlPath, ListMapKeyMarker, ListTypeMarker)
+	case listType == "set" && items != nil:
+		return fmt.Errorf("field %s: the items of %sset lists must be scalars (use %smap with %s for objects)", yamlPath, ListTypeMarker, ListTypeMarker, ListMapKeyMarker)
+	case listType != "map":
+		return nil
+	case items == nil:
+		return fmt.Errorf("field %s: %smap needs an example item that is an object", yamlPath, ListTypeMarker)
+	case len(keys) == 0:
+		return fmt.Errorf("field %s: %smap needs at least one %s marker", yamlPath, ListTypeMarker, ListMapKeyMarker)
+	}
+
+	for _, key := range keys {
+		if err := p.requireKey(items, key); err != nil {
+			return fmt.Errorf("field %s: %w", yamlPath, err)
+		}
+	}
+	return nil
+}
+
+// requireKey marks the key field of the items of a map list required, unless
+// it has a default
+func (p *Parser) requireKey(items *schema.StructDef, key string) error {
+	for i := range items.Fields {
+		keyField := &items.Fields[i]
+		if keyField.JSONName != key {
+			continue
+		}
+		keyType := strings.TrimPrefix(keyField.Type, "*")
+		if keyField.IsSlice || strings.HasPrefix(keyType, "map[") || keyType == string(schema.TypeFreeform) || p.structDef(keyType) != nil {
+			return fmt.Errorf("list map key %s must be a scalar field of the items", key)
+		}
+		if hasMarker(keyField.Comments, "+kubebuilder:default") || hasMarker(keyField.Comments, requiredMarker) {
+			return nil
+		}
+		keyField.Comments = append(keyField.Comments, requiredMarker)
+		return nil
+	}
+	return fmt.Errorf("list map key %s is not a field of the example items", key)
+}
+
+// structDef returns the struct named name, or nil if there is none
+func (p *Parser) structDef(name string) *schema.StructDef {
+	for i := range p.schema.Structs {
+		if p.schema.Structs[i].Name == name {
+			return &p.schema.Structs[i]
+		}
+	}
+	return nil
+}
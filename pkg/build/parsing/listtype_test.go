@@ -0,0 +1,122 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+func TestParse_ListType(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Environment variables of the app
+# +miaka:listType=map
+# +miaka:listMapKey=name
+env:
+  - name: LOG_LEVEL
+    value: info
+# +miaka:listType=map
+# +miaka:listMapKey=containerPort
+# +miaka:listMapKey=protocol
+ports:
+  - containerPort: 80
+    # +kubebuilder:default=TCP
+    protocol: TCP
+# +miaka:listType=set
+imagePullSecrets: [registry]
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"env":              {"Environment variables of the app", "+listType=map", "+listMapKey=name"},
+		"ports":            {"+listType=map", "+listMapKey=containerPort", "+listMapKey=protocol"},
+		"imagePullSecrets": {"+listType=set"},
+		"name":             {"+kubebuilder:validation:Required"},
+		"value":            nil,
+		"containerPort":    {"+kubebuilder:validation:Required"},
+		"protocol":         {"+kubebuilder:default=TCP"},
+	}
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if comments, ok := want[field.JSONName]; ok && !reflect.DeepEqual(markersOf(field), comments) {
+				t.Errorf("%s comments = %v, want %v", field.JSONName, field.Comments, comments)
+			}
+		}
+	}
+}
+
+// markersOf returns the comments of a field, or nil if it has none
+func markersOf(field schema.Field) []string {
+	if len(field.Comments) == 0 {
+		return nil
+	}
+	return field.Comments
+}
+
+func TestParse_ListTypeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "invalid type",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=bag\ntags: [a]\n",
+			wantErr: `field tags: invalid list type "bag" (must be atomic, set, or map)`,
+		},
+		{
+			name:    "two types",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=set\n# +miaka:listType=atomic\ntags: [a]\n",
+			wantErr: "field tags: more than one +miaka:listType= marker",
+		},
+		{
+			name:    "not a list",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=set\ntag: a\n",
+			wantErr: "field tag: +miaka:listType= only applies to lists",
+		},
+		{
+			name:    "set of objects",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=set\nenv:\n  - name: A\n",
+			wantErr: "field env: the items of +miaka:listType=set lists must be scalars",
+		},
+		{
+			name:    "map of scalars",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=map\n# +miaka:listMapKey=name\ntags: [a]\n",
+			wantErr: "field tags: +miaka:listType=map needs an example item that is an object",
+		},
+		{
+			name:    "map without keys",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=map\nenv:\n  - name: A\n",
+			wantErr: "field env: +miaka:listType=map needs at least one +miaka:listMapKey= marker",
+		},
+		{
+			name:    "keys without map",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listMapKey=name\nenv:\n  - name: A\n",
+			wantErr: "field env: +miaka:listMapKey= only applies to lists marked +miaka:listType=map",
+		},
+		{
+			name:    "unknown key",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=map\n# +miaka:listMapKey=id\nenv:\n  - name: A\n",
+			wantErr: "field env: list map key id is not a field of the example items",
+		},
+		{
+			name:    "object key",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:listType=map\n# +miaka:listMapKey=ref\nenv:\n  - ref:\n      name: A\n",
+			wantErr: "field env: list map key ref must be a scalar field of the items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package parsing
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeTag is the tag of YAML merge keys ("<<")
+const mergeTag = "!!merge"
+
+// ResolveMergeKeys resolves the aliases and merge keys under node in place, the
+// way YAML loaders build values: aliases are replaced with the nodes of their
+// anchors, and merge keys (<<: *common, or <<: [*a, *b]) with the fields of the
+// merged mappings that the mapping doesn't set itself. Earlier mappings of a
+// merge list take precedence over later ones. Merged fields keep the nodes of
+// their anchors, with their comments and positions, and take the place of the
+// merge key.
+func ResolveMergeKeys(node *yaml.Node) error {
+	return newResolver(nil).resolve(node)
+}
+
+// resolver resolves aliases and merge keys, checking the mappings for duplicate
+// keys on the way unless duplicates is nil
+type resolver struct {
+	// resolving and resolved track anchored nodes, which are the only ones
+	// aliases lead to: resolving while their children are resolved, to detect
+	// aliases of their own ancestors, and resolved once they're done
+	resolving, resolved map[*yaml.Node]bool
+	duplicates          *duplicateChecker
+}
+
+func newResolver(duplicates *duplicateChecker) *resolver {
+	return &resolver{resolving: map[*yaml.Node]bool{}, resolved: map[*yaml.Node]bool{}, duplicates: duplicates}
+}
+
+// resolve resolves the aliases and merge keys under node
+func (r *resolver) resolve(node *yaml.Node) error {
+	if node.Anchor != "" {
+		if r.resolved[node] {
+			return nil
+		}
+		if r.resolving[node] {
+			return fmt.Errorf("line %d: recursive alias", node.Line)
+		}
+		r.resolving[node] = true
+	}
+	if r.duplicates != nil && node.Kind == yaml.MappingNode {
+		r.duplicates.check(node)
+	}
+
+	for i, child := range node.Content {
+		if child.Kind == yaml.AliasNode {
+			if child.Alias == nil {
+				return fmt.Errorf("line %d: unknown alias %s", child.Line, child.Value)
+			}
+			node.Content[i] = child.Alias
+		}
+		if r.duplicates != nil {
+			r.duplicates.push(node, i)
+		}
+		err := r.resolve(node.Content[i])
+		if r.duplicates != nil {
+			r.duplicates.pop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if node.Kind == yaml.MappingNode {
+		if err := mergeFields(node); err != nil {
+			return err
+		}
+	}
+
+	if node.Anchor != "" {
+		delete(r.resolving, node)
+		r.resolved[node] = true
+	}
+	return nil
+}
+
+// mergeFields replaces the merge keys of a mapping, whose children are resolved,
+// with the fields they merge
+func mergeFields(mapping *yaml.Node) error {
+	hasMerge := false
+	for i := 0; i+1 < len(mapping.Content) && !hasMerge; i += 2 {
+		hasMerge = isMergeKey(mapping.Content[i])
+	}
+	if !hasMerge {
+		return nil
+	}
+	set := map[string]bool{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if !isMergeKey(mapping.Content[i]) {
+			set[mapping.Content[i].Value] = true
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if !isMergeKey(key) {
+			content = append(content, key, value)
+			continue
+		}
+
+		merged := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			merged = value.Content
+		}
+		for _, m := range merged {
+			if m.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: merge key must merge a mapping or a list of mappings", key.Line)
+			}
+			for j := 0; j+1 < len(m.Content); j += 2 {
+				if set[m.Content[j].Value] {
+					continue
+				}
+				set[m.Content[j].Value] = true
+				content = append(content, m.Content[j], m.Content[j+1])
+			}
+		}
+	}
+	mapping.Content = content
+	return nil
+}
+
+// isMergeKey reports whether a mapping key is a merge key: "<<" without quotes
+// or an explicit tag other than !!merge
+func isMergeKey(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.ShortTag() == mergeTag
+}
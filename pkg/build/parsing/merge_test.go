@@ -0,0 +1,137 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mappingKeys returns the keys and scalar values of a mapping as "key=value"
+func mappingKeys(node *yaml.Node) []string {
+	var keys []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value+"="+node.Content[i+1].Value)
+	}
+	return keys
+}
+
+func TestResolveMergeKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "alias",
+			yaml: "base: &base {a: 1, b: 2}\nout:\n  <<: *base\n  c: 3\n",
+			want: []string{"a=1", "b=2", "c=3"},
+		},
+		{
+			name: "explicit fields override merged ones",
+			yaml: "base: &base {a: 1, b: 2}\nout:\n  b: 3\n  <<: *base\n",
+			want: []string{"b=3", "a=1"},
+		},
+		{
+			name: "earlier mappings of a list take precedence",
+			yaml: "one: &one {a: 1}\ntwo: &two {a: 2, b: 2}\nout:\n  <<: [*one, *two]\n",
+			want: []string{"a=1", "b=2"},
+		},
+		{
+			name: "inline mapping",
+			yaml: "out:\n  <<: {a: 1}\n  b: 2\n",
+			want: []string{"a=1", "b=2"},
+		},
+		{
+			name: "nested merge keys",
+			yaml: "base: &base {a: 1}\nmid: &mid\n  <<: *base\n  b: 2\nout:\n  <<: *mid\n",
+			want: []string{"a=1", "b=2"},
+		},
+		{
+			name: "quoted key is a field",
+			yaml: "out:\n  \"<<\": 1\n",
+			want: []string{"<<=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &doc); err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+			root := doc.Content[0]
+			if err := ResolveMergeKeys(root); err != nil {
+				t.Fatalf("ResolveMergeKeys failed: %v", err)
+			}
+			out := root.Content[len(root.Content)-1]
+			if got := mappingKeys(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveMergeKeys_Aliases(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("base: &base\n  a: 1\nout: *base\n"), &doc); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	root := doc.Content[0]
+	if err := ResolveMergeKeys(root); err != nil {
+		t.Fatalf("ResolveMergeKeys failed: %v", err)
+	}
+	if root.Content[3] != root.Content[1] {
+		t.Errorf("Expected the alias to be replaced with its anchor, got %+v", root.Content[3])
+	}
+}
+
+func TestResolveMergeKeys_Errors(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("list: &list [1]\nout:\n  <<: *list\n"), &doc); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	err := ResolveMergeKeys(doc.Content[0])
+	if err == nil || !strings.Contains(err.Error(), "line 3: merge key must merge a mapping") {
+		t.Errorf("Expected an error for merging a list, got: %v", err)
+	}
+}
+
+// TestParse_MergeKeys tests that merged fields are parsed with the types and
+// comments of their anchors, and that no "<<" field is generated
+func TestParse_MergeKeys(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+common: &common
+  # Number of replicas
+  replicas: 3
+  image: nginx
+web:
+  <<: *common
+  port: 80
+worker:
+  <<: [*common]
+  image: worker
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	for _, path := range [][]string{{"web", "replicas"}, {"web", "port"}, {"worker", "replicas"}, {"worker", "image"}} {
+		if _, ok := s.FieldAt(path); !ok {
+			t.Errorf("Expected a field at %s", strings.Join(path, "."))
+		}
+	}
+	replicas, _ := s.FieldAt([]string{"worker", "replicas"})
+	if replicas.Type != "int" || !reflect.DeepEqual(replicas.Comments, []string{"Number of replicas"}) {
+		t.Errorf("Expected an int with the anchor's comment, got %+v", replicas)
+	}
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if field.JSONName == "<<" {
+				t.Errorf("Unexpected merge key field in %s", structDef.Name)
+			}
+		}
+	}
+}
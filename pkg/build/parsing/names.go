@@ -0,0 +1,92 @@
+package parsing
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// NameMarker sets the Go name of a field, for keys that aren't valid Go
+// identifiers (e.g., "+miaka:name: Rocket" for the key "🚀")
+const NameMarker = "+miaka:name:"
+
+// RenamedKey is a key that isn't a valid Go identifier, renamed for its Go field
+// (see schema.PascalCaseName)
+type RenamedKey struct {
+	// Path is the dotted path of the key (e.g., "features.🚀")
+	Path string
+	Line int
+	// Name is the name of the Go field
+	Name string
+}
+
+func (k RenamedKey) String() string {
+	path := k.Path
+	if path == "" || strings.HasSuffix(path, ".") {
+		// Empty keys are quoted, so they show up
+		path += `""`
+	}
+	return fmt.Sprintf("line %d: %s -> %s", k.Line, path, k.Name)
+}
+
+// applyName sets the Go name of a field from its +miaka:name: marker, or from
+// its key, recording keys that had to be renamed
+func (p *Parser) applyName(field *schema.Field, yamlPath, valuesPath string) error {
+	comments := make([]string, 0, len(field.Comments))
+	marked := ""
+	for _, comment := range field.Comments {
+		if !strings.HasPrefix(comment, NameMarker) {
+			comments = append(comments, comment)
+			continue
+		}
+		if marked != "" {
+			return fmt.Errorf("field %s: more than one %s marker", yamlPath, NameMarker)
+		}
+		marked = strings.TrimSpace(strings.TrimPrefix(comment, NameMarker))
+		if !token.IsIdentifier(marked) || !token.IsExported(marked) {
+			return fmt.Errorf("field %s: %s needs an exported Go identifier, got %q", yamlPath, NameMarker, marked)
+		}
+	}
+	field.Comments = comments
+
+	if marked != "" {
+		field.Name = marked
+		return nil
+	}
+	name, renamed := schema.PascalCaseName(field.JSONName)
+	field.Name = name
+	if renamed {
+		p.renamedKeys = append(p.renamedKeys, RenamedKey{Path: valuesPath, Line: field.Line, Name: name})
+	}
+	return nil
+}
+
+// checkRenamedKeys warns about the keys that were renamed for their Go fields,
+// listing them together
+func (p *Parser) checkRenamedKeys() {
+	if len(p.renamedKeys) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(p.renamedKeys))
+	for _, key := range p.renamedKeys {
+		lines = append(lines, key.String())
+	}
+	p.schema.Warnings = append(p.schema.Warnings, fmt.Sprintf("%d key(s) aren't valid Go identifiers and were renamed (choose the names with %s <Name>):\n  %s",
+		len(p.renamedKeys), NameMarker, strings.Join(lines, "\n  ")))
+}
+
+// checkFieldNames ensures that no two keys of an object have the same Go name
+// (e.g., "max_size" and "maxSize"), which would only fail when compiling the
+// Go types
+func checkFieldNames(structDef schema.StructDef) error {
+	keys := make(map[string]string, len(structDef.Fields))
+	for _, field := range structDef.Fields {
+		if other, ok := keys[field.Name]; ok {
+			return fmt.Errorf("keys %q and %q of %s both become the Go field %s (set another name with %s)", other, field.JSONName, structDef.Name, field.Name, NameMarker)
+		}
+		keys[field.Name] = field.JSONName
+	}
+	return nil
+}
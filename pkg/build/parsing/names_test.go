@@ -0,0 +1,69 @@
+package parsing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_RenamedKeys(t *testing.T) {
+	yaml := `features:
+  🚀: true
+  café: latte
+  # +miaka:name: OnePassword
+  1password: vault
+  max_size: 3
+  "": empty
+`
+	s, err := NewParserWithOptions(Options{Plain: true}).Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var names []string
+	for _, structDef := range s.Structs {
+		if structDef.Name != "FeaturesConfig" {
+			continue
+		}
+		for _, field := range structDef.Fields {
+			names = append(names, field.Name+"="+field.JSONName)
+			for _, comment := range field.Comments {
+				if strings.HasPrefix(comment, NameMarker) {
+					t.Errorf("Expected the %s marker to be removed from %s", NameMarker, field.JSONName)
+				}
+			}
+		}
+	}
+	want := []string{"U1F680=🚀", "Cafe=café", "OnePassword=1password", "MaxSize=max_size", "X="}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected fields %v, got %v", want, names)
+	}
+
+	// Keys named with +miaka:name and keys with separators aren't reported
+	wantWarnings := []string{"3 key(s) aren't valid Go identifiers and were renamed (choose the names with +miaka:name: <Name>):\n  line 2: features.🚀 -> U1F680\n  line 3: features.café -> Cafe\n  line 7: features.\"\" -> X"}
+	if !reflect.DeepEqual(s.Warnings, wantWarnings) {
+		t.Errorf("Expected warnings:\n%q\ngot:\n%q", wantWarnings, s.Warnings)
+	}
+}
+
+func TestParse_NameErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"collision", "max_size: 1\nmaxSize: 2\n", `keys "max_size" and "maxSize" of Values both become the Go field MaxSize (set another name with +miaka:name:)`},
+		{"marked collision", "size: 1\n# +miaka:name: Size\nlength: 2\n", `keys "size" and "length" of Values both become the Go field Size`},
+		{"unexported", "# +miaka:name: rocket\n🚀: true\n", `field 🚀: +miaka:name: needs an exported Go identifier, got "rocket"`},
+		{"invalid", "# +miaka:name: Max Size\nmax size: 1\n", `+miaka:name: needs an exported Go identifier, got "Max Size"`},
+		{"twice", "# +miaka:name: A\n# +miaka:name: B\n🚀: true\n", "more than one +miaka:name: marker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParserWithOptions(Options{Plain: true}).Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -2,33 +2,139 @@
 package parsing
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	"gopkg.in/yaml.v3"
 )
 
+// ItemsMarker prefixes markers that apply to the items of a list or the values
+// of a map (e.g., "+miaka:items:kubebuilder:validation:MinLength=1")
+const ItemsMarker = "+miaka:items:"
+
+// NullableMarker makes a field accept null, distinguishing an unset value from
+// the zero value (e.g., a *bool in Go)
+const NullableMarker = "+miaka:nullable"
+
+// PreserveUnknownFieldsMarker keeps the schema of an object but allows (and
+// keeps) fields it doesn't declare, for subtrees that are intentionally open
+const PreserveUnknownFieldsMarker = "+miaka:preserveUnknownFields"
+
+// CRDMarker prefixes document-level markers that configure the generated CRD
+// (e.g., "+miaka:crd:subresource:status")
+const CRDMarker = "+miaka:crd:"
+
+// FormatMarker sets the format of a number field, and so its Go type (e.g.,
+// "+miaka:format:int64"), or a common shape of value (e.g., "+miaka:format:imageref"),
+// which adds the markers of its schema.ValueFormats entry
+const FormatMarker = "+miaka:format:"
+
+// RenamedFromMarker declares the old key of a renamed field (e.g.,
+// "+miaka:renamedFrom: repo"), so values using it can be migrated
+const RenamedFromMarker = "+miaka:renamedFrom:"
+
+// DeprecatedMarker deprecates a field, saying what to use instead (e.g.,
+// "+miaka:deprecated: use image.repository instead")
+const DeprecatedMarker = "+miaka:deprecated:"
+
+// CELMarker adds a CEL validation rule to a field, usually an object relating
+// its fields (e.g., "+miaka:cel: rule=\"self.min <= self.max\",message=\"min must not exceed max\"")
+const CELMarker = "+miaka:cel:"
+
+// numberFormats maps the formats of FormatMarker to their Go types
+var numberFormats = map[string]schema.FieldType{
+	"int32":  schema.TypeInt32,
+	"int64":  schema.TypeInt64,
+	"float":  schema.TypeFloat32,
+	"double": schema.TypeFloat64,
+}
+
+// validationPrefix is the prefix of the markers allowed after ItemsMarker
+const validationPrefix = "kubebuilder:validation:"
+
+// Options control how the parser infers types
+type Options struct {
+	// InferSemanticTypes maps string values that look like quantities, durations,
+	// or int-or-strings to those types without a +miaka:type hint
+	// (see schema.InferSemanticType)
+	InferSemanticTypes bool
+	// Plain parses values without apiVersion or kind, whose type is named
+	// TypeName (schema.DefaultPlainTypeName if empty)
+	Plain    bool
+	TypeName string
+	// Paths selects the values that are typed; others are freeform
+	Paths PathFilter
+	// DuplicateKeys is how keys that a mapping has more than once are handled
+	// (DuplicatesError if empty)
+	DuplicateKeys DuplicateKeyPolicy
+	// TemplateStrings is how example values that are Helm template expressions
+	// are handled (TemplatesWarn if empty). They're typed as opaque strings.
+	TemplateStrings TemplateStringPolicy
+	// Limits bound the size, depth, and number of fields of the values
+	// (DefaultLimits for zero limits)
+	Limits Limits
+	// Defaults are the defaults of fields from a separate file, added to their
+	// markers (see ParseDefaults)
+	Defaults *Defaults
+}
+
 // Parser handles YAML parsing with comment preservation
 type Parser struct {
-	schema      *schema.Schema
-	structNames map[string]bool // Track used struct names to avoid collisions
+	schema    *schema.Schema
+	typeNames map[string]string // Names of the types by their values paths, assigned before parsing
+	requests  []typeRequest     // Types needed by the values, while collecting them
+	opts      Options
+
+	templateStrings []TemplateString // Unmarked template expressions in the values
+	renamedKeys     []RenamedKey     // Keys that aren't valid Go identifiers
+
+	usedDefaults     map[string]bool   // Values paths of the defaults file that are fields
+	defaultConflicts []DefaultConflict // Defaults that differ from the defaults of markers
+}
+
+// typeRequest is a named type needed by the values at a path
+type typeRequest struct {
+	baseName   string // Name of the type, unless another type has it
+	valuesPath string // Path of the values the type holds
+	parentPath string // Path of the object holding the values ("" at the top level)
 }
 
 // NewParser creates a new parser instance
 func NewParser() *Parser {
+	return NewParserWithOptions(Options{})
+}
+
+// NewParserWithOptions creates a new parser instance with options
+func NewParserWithOptions(opts Options) *Parser {
 	return &Parser{
 		schema: &schema.Schema{
 			Structs: make([]schema.StructDef, 0),
 		},
-		structNames: make(map[string]bool),
+		opts:         opts,
+		usedDefaults: make(map[string]bool),
 	}
 }
 
 // ParseFile parses a YAML file and returns a Schema
 func (p *Parser) ParseFile(filename string) (*schema.Schema, error) {
-	data, err := os.ReadFile(filename)
+	return p.ParseFileFS(filesystem.OS(), filename)
+}
+
+// ParseFileFS parses a YAML file in fsys and returns a Schema
+func (p *Parser) ParseFileFS(fsys filesystem.FS, filename string) (*schema.Schema, error) {
+	// Check the size first, so large files aren't read
+	if info, err := fsys.Stat(filename); err == nil {
+		if err := p.opts.Limits.checkSize(info.Size()); err != nil {
+			return nil, err
+		}
+	}
+	data, err := fsys.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -38,6 +144,14 @@ func (p *Parser) ParseFile(filename string) (*schema.Schema, error) {
 
 // Parse parses YAML data and returns a Schema
 func (p *Parser) Parse(data []byte) (*schema.Schema, error) {
+	if err := p.opts.Limits.checkSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	// yaml.v3 misplaces comments between Windows line endings (CRLF), which
+	// would lose their descriptions and markers
+	if bytes.Contains(data, []byte("\r\n")) {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	}
 	var node yaml.Node
 	if err := yaml.Unmarshal(data, &node); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
@@ -47,24 +161,82 @@ func (p *Parser) Parse(data []byte) (*schema.Schema, error) {
 	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
 		return nil, fmt.Errorf("invalid YAML structure")
 	}
+	if err := p.opts.Limits.checkNodes(node.Content[0]); err != nil {
+		return nil, err
+	}
 
 	rootMap := node.Content[0]
 	if rootMap.Kind != yaml.MappingNode {
 		return nil, fmt.Errorf("root node must be a mapping")
 	}
-
-	// Parse top-level fields
-	if err := p.parseRootNode(rootMap); err != nil {
+	if err := p.resolveValues(rootMap); err != nil {
 		return nil, err
 	}
 
+	// Name the types before parsing, so their names depend on the paths of the
+	// values rather than on the order of the fields
+	p.collectTypes(rootMap)
+	p.typeNames = assignTypeNames(p.requests)
+	p.requests = nil
+
+	if err := p.parseDocument(&node, rootMap); err != nil {
+		return nil, err
+	}
+	if err := p.checkTemplateStrings(); err != nil {
+		return nil, err
+	}
+	if err := p.checkDefaults(); err != nil {
+		return nil, err
+	}
+	p.checkRenamedKeys()
 	return p.schema, nil
 }
 
+// resolveValues resolves the aliases and merge keys of the values under root,
+// applying the duplicate key policy on the way: duplicates fail parsing, or all
+// but the last of each key are removed, with a warning for each with DuplicatesWarn
+func (p *Parser) resolveValues(root *yaml.Node) error {
+	policy := p.opts.DuplicateKeys
+	if policy == "" {
+		policy = DuplicatesError
+	}
+	duplicates, err := resolveWithDuplicates(root, policy != DuplicatesError)
+	if err != nil {
+		return fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(duplicates, func(a, b DuplicateKey) int { return cmp.Compare(a.Line, b.Line) })
+	switch policy {
+	case DuplicatesError:
+		return &DuplicateKeysError{Duplicates: duplicates}
+	case DuplicatesWarn:
+		for _, duplicate := range duplicates {
+			p.schema.Warnings = append(p.schema.Warnings, duplicate.String()+", whose value replaces the earlier one")
+		}
+	}
+	return nil
+}
+
+// parseDocument parses the document-level markers and the top-level fields
+func (p *Parser) parseDocument(doc, root *yaml.Node) error {
+	if err := p.parseCRDMarkers(doc, root); err != nil {
+		return err
+	}
+	return p.parseRootNode(root)
+}
+
 // parseRootNode parses the root mapping node
 func (p *Parser) parseRootNode(node *yaml.Node) error {
+	if p.opts.Plain {
+		if err := p.setPlainTypeMeta(node); err != nil {
+			return err
+		}
+	}
+
 	// First pass: collect apiVersion and kind
-	for i := 0; i < len(node.Content); i += 2 {
+	for i := 0; i < len(node.Content) && !p.opts.Plain; i += 2 {
 		keyNode := node.Content[i]
 		valueNode := node.Content[i+1]
 
@@ -87,7 +259,7 @@ func (p *Parser) parseRootNode(node *yaml.Node) error {
 	// These become fields directly on the main type
 	mainFields := &schema.StructDef{
 		Name:     p.schema.Kind,
-		Fields:   []schema.Field{},
+		Fields:   make([]schema.Field, 0, len(node.Content)/2),
 		Comments: []string{},
 	}
 
@@ -103,15 +275,19 @@ func (p *Parser) parseRootNode(node *yaml.Node) error {
 		}
 
 		// Parse this field - it will be added directly to the main type
-		comments := extractComments(keyNode)
-		field, nestedStructs, err := p.parseFieldWithPath(key, key, valueNode, comments)
+		comments := extractComments(keyNode, valueNode)
+		field, err := p.parseFieldWithPath(key, key, key, valueNode, comments)
 		if err != nil {
 			return fmt.Errorf("failed to parse field %s: %w", key, err)
 		}
-		mainFields.Fields = append(mainFields.Fields, *field)
+		mainFields.Fields = append(mainFields.Fields, field)
+	}
 
-		// Add any nested structs to the schema
-		p.schema.Structs = append(p.schema.Structs, nestedStructs...)
+	if err := checkRenames(*mainFields); err != nil {
+		return err
+	}
+	if err := checkFieldNames(*mainFields); err != nil {
+		return err
 	}
 
 	// Add the main fields struct (this will be merged into the main type by the generator)
@@ -120,16 +296,93 @@ func (p *Parser) parseRootNode(node *yaml.Node) error {
 	return nil
 }
 
-// parseObject parses a mapping node into a struct definition
-func (p *Parser) parseObject(node *yaml.Node, structName string, structComments []string) (*schema.StructDef, error) {
+// setPlainTypeMeta names the type of plain values, rejecting the top-level fields
+// that the internal KRM types reserve
+func (p *Parser) setPlainTypeMeta(node *yaml.Node) error {
+	for i := 0; i < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "apiVersion", "kind", "metadata":
+			return fmt.Errorf("top-level field %q is reserved and can't be used in plain values", key)
+		}
+	}
+
+	p.schema.Plain = true
+	p.schema.APIVersion = schema.PlainAPIVersion
+	p.schema.Package = schema.PlainPackage
+	p.schema.Kind = p.opts.TypeName
+	if p.schema.Kind == "" {
+		p.schema.Kind = schema.DefaultPlainTypeName
+	}
+	return nil
+}
+
+// parseCRDMarkers collects the +miaka:crd: markers in the comments at the top of
+// the document (before apiVersion and kind, or the first field) as controller-gen
+// markers for the main type
+func (p *Parser) parseCRDMarkers(doc, root *yaml.Node) error {
+	comments := append(extractHeadComments(doc), extractHeadComments(root)...)
+	for i := 0; i < len(root.Content); i += 2 {
+		// Comments at the top of the document belong to the first key
+		if key := root.Content[i].Value; i == 0 || key == "apiVersion" || key == "kind" {
+			comments = append(comments, extractHeadComments(root.Content[i])...)
+		}
+	}
+
+	for _, comment := range schema.FormatComments(comments) {
+		if !strings.HasPrefix(comment, CRDMarker) {
+			continue
+		}
+		if p.opts.Plain {
+			return fmt.Errorf("%s can't be used in plain values, which have no CRD", comment)
+		}
+		marker, err := crdMarker(strings.TrimPrefix(comment, CRDMarker))
+		if err != nil {
+			return err
+		}
+		p.schema.CRDMarkers = append(p.schema.CRDMarkers, marker)
+	}
+	return nil
+}
+
+// crdMarker translates the body of a +miaka:crd: marker to a controller-gen marker.
+// Printer columns take the path of their value as path= (or JSONPath=), and
+// resources take their plural as plural= (or path=).
+func crdMarker(marker string) (string, error) {
+	switch {
+	case marker == "subresource:status":
+		return "+kubebuilder:subresource:status", nil
+	case strings.HasPrefix(marker, "subresource:scale:"):
+		return "+kubebuilder:" + marker, nil
+	case strings.HasPrefix(marker, "printcolumn:"):
+		return "+kubebuilder:printcolumn:" + renameMarkerArg(strings.TrimPrefix(marker, "printcolumn:"), "path", "JSONPath"), nil
+	case strings.HasPrefix(marker, "resource:"):
+		return "+kubebuilder:resource:" + renameMarkerArg(strings.TrimPrefix(marker, "resource:"), "plural", "path"), nil
+	}
+	return "", fmt.Errorf("unsupported marker %s%s (supported: subresource:status, subresource:scale, printcolumn, resource)", CRDMarker, marker)
+}
+
+// renameMarkerArg renames an argument in the comma-separated arguments of a marker
+func renameMarkerArg(args, from, to string) string {
+	list := strings.Split(args, ",")
+	for i, arg := range list {
+		if strings.HasPrefix(arg, from+"=") {
+			list[i] = to + strings.TrimPrefix(arg, from)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// parseObject parses a mapping node at valuesPath into a struct definition, which
+// is added to the schema after the structs of its fields
+func (p *Parser) parseObject(node *yaml.Node, structName, valuesPath string, structComments []string) error {
 	if node.Kind != yaml.MappingNode {
-		return nil, fmt.Errorf("expected mapping node")
+		return fmt.Errorf("expected mapping node")
 	}
 
-	structDef := &schema.StructDef{
+	structDef := schema.StructDef{
 		Name:     structName,
 		Comments: structComments,
-		Fields:   make([]schema.Field, 0),
+		Fields:   make([]schema.Field, 0, len(node.Content)/2),
 	}
 
 	for i := 0; i < len(node.Content); i += 2 {
@@ -137,64 +390,118 @@ func (p *Parser) parseObject(node *yaml.Node, structName string, structComments
 		valueNode := node.Content[i+1]
 
 		fieldName := keyNode.Value
-		comments := extractComments(keyNode)
+		comments := extractComments(keyNode, valueNode)
 
 		// Build the yaml path for nested structs
-		yamlPath := fmt.Sprintf("%s.%s", structName, fieldName)
-		field, nestedStructs, err := p.parseFieldWithPath(fieldName, yamlPath, valueNode, comments)
+		yamlPath := structName + "." + fieldName
+		field, err := p.parseFieldWithPath(fieldName, yamlPath, valuesPath+"."+fieldName, valueNode, comments)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse field %s: %w", fieldName, err)
+			return fmt.Errorf("failed to parse field %s: %w", fieldName, err)
 		}
 
-		structDef.Fields = append(structDef.Fields, *field)
-
-		// Add nested structs to schema
-		p.schema.Structs = append(p.schema.Structs, nestedStructs...)
+		structDef.Fields = append(structDef.Fields, field)
 	}
 
-	return structDef, nil
+	if err := checkRenames(structDef); err != nil {
+		return err
+	}
+	if err := checkFieldNames(structDef); err != nil {
+		return err
+	}
+	p.schema.Structs = append(p.schema.Structs, structDef)
+	return nil
 }
 
-// parseFieldWithPath parses a field with YAML path tracking
-func (p *Parser) parseFieldWithPath(fieldName string, yamlPath string, valueNode *yaml.Node, comments []string) (*schema.Field, []schema.StructDef, error) {
-	field := &schema.Field{
-		Name:     schema.ToPascalCase(fieldName),
-		JSONName: fieldName,
-		Comments: schema.FormatComments(comments),
-		YAMLPath: yamlPath,
-		Line:     valueNode.Line,
+// parseFieldWithPath parses a field with YAML path tracking. yamlPath names nested
+// types, and valuesPath is the field's path in the values, matched by the path
+// filter. The structs of nested objects are added to the schema.
+func (p *Parser) parseFieldWithPath(fieldName, yamlPath, valuesPath string, valueNode *yaml.Node, comments []string) (schema.Field, error) {
+	fieldComments, documentedDefault := HelmDocsComments(schema.FormatComments(comments))
+	field := schema.Field{
+		JSONName:          fieldName,
+		Comments:          fieldComments,
+		YAMLPath:          yamlPath,
+		Line:              valueNode.Line,
+		DocumentedDefault: documentedDefault,
+	}
+	if documentedDefault != "" {
+		field.Comments = append(field.Comments, DocumentedDefaultPrefix+documentedDefault)
+	}
+	if err := p.applyName(&field, yamlPath, valuesPath); err != nil {
+		return schema.Field{}, err
+	}
+
+	if isLabelKey(fieldName) {
+		p.schema.Warnings = append(p.schema.Warnings, fmt.Sprintf(
+			"line %d: key %q becomes the Go field %s (mark its object +miaka:type: map[string]string if its keys are labels or annotations)",
+			field.Line, fieldName, field.Name))
 	}
 
-	nestedStructs := make([]schema.StructDef, 0)
+	if p.opts.Paths.Freeform(valuesPath) {
+		field.Type = string(schema.TypeFreeform)
+		field.Comments = append(field.Comments, schemalessMarker, preserveUnknownsMarker)
+		if err := p.applyDefault(&field, yamlPath, valuesPath, false); err != nil {
+			return schema.Field{}, err
+		}
+		if err := applyRenamedFrom(&field, yamlPath); err != nil {
+			return schema.Field{}, err
+		}
+		if err := applyDeprecated(&field, yamlPath); err != nil {
+			return schema.Field{}, err
+		}
+		applyNullable(&field)
+		return field, nil
+	}
 
 	// Check for explicit type hint in comments
 	typeHint := extractTypeHint(comments)
+	templated := slices.Contains(field.Comments, TemplateMarker)
+	if templated {
+		field.Comments = slices.DeleteFunc(field.Comments, func(comment string) bool { return comment == TemplateMarker })
+	}
 
+	nested := false
 	switch valueNode.Kind {
 	case yaml.ScalarNode:
 		// Infer type from the scalar value
-		var value interface{}
-		if err := valueNode.Decode(&value); err != nil {
-			return nil, nil, fmt.Errorf("failed to decode scalar: %w", err)
+		value, err := scalarValue(valueNode)
+		if err != nil {
+			return schema.Field{}, fmt.Errorf("failed to decode scalar: %w", err)
 		}
 		field.Type = schema.InferType(value)
+		template, err := p.templateValue(value, templated, valuesPath, valueNode.Line)
+		if err != nil {
+			return schema.Field{}, fmt.Errorf("field %s: %w", yamlPath, err)
+		}
+
+		switch {
+		case template:
+			// Template expressions render to values of any shape, so nothing is
+			// inferred from them
+			field.Type = string(schema.TypeString)
+		case value == nil && typeHint != "":
+			// Null values can't be inferred - use the type hint if there is one,
+			// and keep null valid
+			applyTypeHint(&field, typeHint)
+			field.Nullable = true
+		case value != nil:
+			field.Type = p.semanticType(fieldName, value, typeHint, field.Type)
+		}
 
 	case yaml.MappingNode:
 		// This is a nested object
-		if len(valueNode.Content) == 0 && typeHint != "" {
-			// Empty object with type hint (e.g., +miaka:type:map[string]string)
-			field.Type = typeHint
+		if mapType, ok := mappingType(valueNode, typeHint); ok {
+			field.Type = mapType
 		} else {
 			// Non-empty object or no type hint
-			structName := p.generateUniqueStructName(fieldName, yamlPath)
+			structName := p.generateUniqueStructName(fieldName, valuesPath)
 			field.Type = structName
+			nested = true
 
 			structComments := extractCommentsForStruct(valueNode)
-			nestedStruct, err := p.parseObject(valueNode, structName, structComments)
-			if err != nil {
-				return nil, nil, err
+			if err := p.parseObject(valueNode, structName, valuesPath, structComments); err != nil {
+				return schema.Field{}, err
 			}
-			nestedStructs = append(nestedStructs, *nestedStruct)
 		}
 
 	case yaml.SequenceNode:
@@ -203,66 +510,271 @@ func (p *Parser) parseFieldWithPath(fieldName string, yamlPath string, valueNode
 
 		if len(valueNode.Content) == 0 {
 			// Handle empty list
-			handleEmptyList(field, typeHint)
+			handleEmptyList(&field, typeHint)
 		} else {
 			// Handle non-empty list
-			nested, err := p.handleNonEmptyList(field, valueNode, fieldName, yamlPath)
-			if err != nil {
-				return nil, nil, err
+			if err := p.handleNonEmptyList(&field, valueNode, fieldName, yamlPath, valuesPath, typeHint, templated); err != nil {
+				return schema.Field{}, err
 			}
-			nestedStructs = append(nestedStructs, nested...)
 		}
 	}
+	if templated && field.Type != string(schema.TypeString) && field.Type != "[]"+string(schema.TypeString) {
+		return schema.Field{}, fmt.Errorf("field %s: %s only applies to strings and lists of strings", yamlPath, TemplateMarker)
+	}
+
+	if err := p.applyDefault(&field, yamlPath, valuesPath, nested); err != nil {
+		return schema.Field{}, err
+	}
+	if err := p.applyItemMarkers(&field, fieldName, yamlPath, valuesPath); err != nil {
+		return schema.Field{}, err
+	}
+	if err := p.applyListType(&field, yamlPath); err != nil {
+		return schema.Field{}, err
+	}
+	if err := applyFormat(&field, yamlPath); err != nil {
+		return schema.Field{}, err
+	}
+	if err := applyRenamedFrom(&field, yamlPath); err != nil {
+		return schema.Field{}, err
+	}
+	if err := applyDeprecated(&field, yamlPath); err != nil {
+		return schema.Field{}, err
+	}
+	if err := applyCELRules(&field, yamlPath); err != nil {
+		return schema.Field{}, err
+	}
+	applyNullable(&field)
+	applyPreserveUnknownFields(&field)
+	applyDurationPattern(&field)
 
-	return field, nestedStructs, nil
+	return field, nil
 }
 
-// generateUniqueStructName creates a unique struct name, adding prefixes if there's a collision
-func (p *Parser) generateUniqueStructName(fieldName string, yamlPath string) string {
-	baseName := schema.GenerateStructName(fieldName)
+// isDecimal reports whether s is a decimal integer without a plus sign or
+// leading zeros, which YAML reads as octal
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" || (s[0] == '0' && len(s) > 1) {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
 
-	// If no collision, use the base name
-	if !p.structNames[baseName] {
-		p.structNames[baseName] = true
-		return baseName
+// mappingType returns the type of an object that isn't a struct: a map of labels
+// or annotations, or the type hint of an empty object or a map
+func mappingType(node *yaml.Node, typeHint string) (string, bool) {
+	if mapType, ok := labelMapType(node); typeHint == "" && ok {
+		// Keys like kubernetes.io/arch are labels or annotations, not fields
+		return mapType, true
 	}
+	if typeHint != "" && (len(node.Content) == 0 || strings.HasPrefix(typeHint, "map[string]")) {
+		// Empty object or map with type hint (e.g., +miaka:type:map[string]string)
+		return typeHint, true
+	}
+	return "", false
+}
 
-	// Collision detected - use the parent path to make it unique
-	// Extract parent from yamlPath (e.g., "configs.nats.versions" -> "Nats")
-	pathParts := strings.Split(yamlPath, ".")
-	if len(pathParts) > 1 {
-		// Use the parent field name as prefix
-		parentName := schema.ToPascalCase(pathParts[len(pathParts)-2])
-		uniqueName := parentName + baseName
+// scalarValue returns the value of a scalar node. Plain strings, nulls, booleans,
+// and decimal integers, the most common scalars, are resolved from the node's tag
+// without a decoder.
+func scalarValue(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case "!!str":
+		return node.Value, nil
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		switch node.Value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case "!!int":
+		if isDecimal(node.Value) {
+			if value, err := strconv.Atoi(node.Value); err == nil {
+				return value, nil
+			}
+		}
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// generateUniqueStructName returns the unique name of the struct of the values of
+// a field at valuesPath
+func (p *Parser) generateUniqueStructName(fieldName, valuesPath string) string {
+	return p.uniqueTypeName(schema.GenerateStructName(fieldName), fieldName, valuesPath)
+}
 
-		// If still collision, add more context
-		counter := 2
-		originalUniqueName := uniqueName
-		for p.structNames[uniqueName] {
-			uniqueName = fmt.Sprintf("%s%d", originalUniqueName, counter)
-			counter++
+// uniqueTypeName returns the name assigned to the type of the values of a field
+// at valuesPath. While collecting the types, it records the type and returns baseName.
+func (p *Parser) uniqueTypeName(baseName, fieldName, valuesPath string) string {
+	parentPath := strings.TrimSuffix(strings.TrimSuffix(valuesPath, fieldName), ".")
+	request := typeRequest{
+		baseName:   baseName,
+		valuesPath: valuesPath,
+		parentPath: strings.TrimSuffix(parentPath, "[]"),
+	}
+	if name, ok := p.typeNames[valuesPath]; ok {
+		return name
+	}
+	p.requests = append(p.requests, request)
+	return baseName
+}
+
+// collectTypes records the types that the fields of the root mapping need,
+// following the choices of parseFieldWithPath without parsing the values, so
+// the types can be named before they're parsed
+func (p *Parser) collectTypes(root *yaml.Node) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if key := root.Content[i].Value; key != "apiVersion" && key != "kind" && key != "metadata" {
+			p.collectFieldTypes(key, key, root.Content[i], root.Content[i+1])
 		}
+	}
+}
 
-		p.structNames[uniqueName] = true
-		return uniqueName
+// collectFieldTypes records the types that the values of a field at valuesPath need
+func (p *Parser) collectFieldTypes(fieldName, valuesPath string, keyNode, valueNode *yaml.Node) {
+	if p.opts.Paths.Freeform(valuesPath) {
+		return
 	}
+	comments := extractComments(keyNode, valueNode)
+	typeHint := extractTypeHint(comments)
 
-	// Fallback: add numeric suffix
-	counter := 2
-	uniqueName := fmt.Sprintf("%s%d", baseName, counter)
-	for p.structNames[uniqueName] {
-		counter++
-		uniqueName = fmt.Sprintf("%s%d", baseName, counter)
+	var goType string
+	switch valueNode.Kind {
+	case yaml.ScalarNode:
+		if valueNode.ShortTag() != "!!null" {
+			return
+		}
+		goType = typeHint
+	case yaml.MappingNode:
+		mapType, ok := mappingType(valueNode, typeHint)
+		if !ok {
+			p.generateUniqueStructName(fieldName, valuesPath)
+			for i := 0; i+1 < len(valueNode.Content); i += 2 {
+				key := valueNode.Content[i]
+				p.collectFieldTypes(key.Value, valuesPath+"."+key.Value, key, valueNode.Content[i+1])
+			}
+			return
+		}
+		goType = mapType
+	case yaml.SequenceNode:
+		if len(valueNode.Content) == 0 || valueNode.Content[0].Kind != yaml.MappingNode {
+			return
+		}
+		// The fields of list items are parsed where they first appear (see mergeListItems)
+		p.generateUniqueStructName(fieldName, valuesPath)
+		seen := make(map[string]bool)
+		for _, item := range valueNode.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				key := item.Content[i]
+				if !seen[key.Value] {
+					seen[key.Value] = true
+					p.collectFieldTypes(key.Value, valuesPath+"[]."+key.Value, key, item.Content[i+1])
+				}
+			}
+		}
+		return
+	}
+
+	// Maps with item markers have a named value type (see applyItemMarkers)
+	if _, ok := mapValueType(goType); ok {
+		fieldComments, _ := HelmDocsComments(schema.FormatComments(comments))
+		if hasMarker(fieldComments, ItemsMarker) {
+			p.uniqueTypeName(schema.ToPascalCase(fieldName)+"Value", fieldName, valuesPath)
+		}
+	}
+}
+
+// assignTypeNames names the requested types. Each type gets its base name, or if
+// other types have the same one, the type of the shallowest path (then the first
+// alphabetically) keeps it, and the others are prefixed with the name of the
+// struct holding them, or numbered at the top level or if that's taken too. Names
+// only depend on the paths of the values, so reordering fields never renames types,
+// but a new type with the same base name as another type, at a shallower path
+// (or the same depth and first alphabetically), takes its name. The names are
+// keyed by the values paths of the types, since each path holds a single type.
+func assignTypeNames(requests []typeRequest) map[string]string {
+	sorted := slices.Clone(requests)
+	slices.SortFunc(sorted, func(a, b typeRequest) int {
+		return cmp.Or(
+			cmp.Compare(strings.Count(a.valuesPath, "."), strings.Count(b.valuesPath, ".")),
+			cmp.Compare(a.valuesPath, b.valuesPath),
+			cmp.Compare(a.baseName, b.baseName),
+		)
+	})
+
+	names := make(map[string]string, len(sorted))
+	used := make(map[string]bool, len(sorted))
+	var collisions []typeRequest
+	for _, request := range sorted {
+		if _, ok := names[request.valuesPath]; ok {
+			continue
+		}
+		if used[request.baseName] {
+			collisions = append(collisions, request)
+			continue
+		}
+		names[request.valuesPath] = request.baseName
+		used[request.baseName] = true
+	}
+
+	// Parents are shallower, so they're named before the types they hold
+	nextSuffix := make(map[string]int)
+	for _, request := range collisions {
+		if _, ok := names[request.valuesPath]; ok {
+			continue
+		}
+		name := request.baseName
+		if parentName, ok := names[request.parentPath]; ok && request.parentPath != "" {
+			name = parentName + name
+		}
+		if used[name] {
+			name = suffixedName(name, used, nextSuffix)
+		}
+		names[request.valuesPath] = name
+		used[name] = true
 	}
+	return names
+}
 
-	p.structNames[uniqueName] = true
+// suffixedName returns name with the lowest numeric suffix (from 2) that isn't
+// used yet. Names are never released, so the search resumes where the last one
+// for the same name stopped, keeping many collisions linear.
+func suffixedName(name string, used map[string]bool, nextSuffix map[string]int) string {
+	counter := max(nextSuffix[name], 2)
+	uniqueName := name + strconv.Itoa(counter)
+	for used[uniqueName] {
+		counter++
+		uniqueName = name + strconv.Itoa(counter)
+	}
+	nextSuffix[name] = counter + 1
 	return uniqueName
 }
 
-// mergeListItems merges fields from all items in a list to create a single struct
-func (p *Parser) mergeListItems(sequenceNode *yaml.Node, structName string, structComments []string) (*schema.StructDef, error) {
-	mergedFields := make(map[string]*schema.Field)
-	var fieldOrder []string
+// mergeListItems merges fields from all items in the list at valuesPath to create
+// a single struct, which is added to the schema after the structs of its fields
+func (p *Parser) mergeListItems(sequenceNode *yaml.Node, structName, valuesPath string, structComments []string) error {
+	structDef := schema.StructDef{
+		Name:     structName,
+		Comments: structComments,
+		Fields:   make([]schema.Field, 0),
+	}
+	fieldIndex := make(map[string]int)
+	fieldComments := make(map[string]string)
 
 	for _, itemNode := range sequenceNode.Content {
 		if itemNode.Kind != yaml.MappingNode {
@@ -274,43 +786,410 @@ func (p *Parser) mergeListItems(sequenceNode *yaml.Node, structName string, stru
 			valueNode := itemNode.Content[i+1]
 
 			fieldName := keyNode.Value
-			comments := extractComments(keyNode)
+			comments := extractComments(keyNode, valueNode)
 
-			if existingField, exists := mergedFields[fieldName]; exists {
+			if _, exists := fieldIndex[fieldName]; exists {
 				// Field already exists, verify comments match
-				existingComments := strings.Join(existingField.Comments, "\n")
-				newComments := strings.Join(schema.FormatComments(comments), "\n")
-				if existingComments != newComments && len(comments) > 0 {
-					return nil, fmt.Errorf("conflicting comments for field %s in list items", fieldName)
+				// Compare the source head comments, since parsing rewrites some markers.
+				// Line and foot comments often describe an item's example value, so
+				// those of later items are ignored.
+				headComments := extractHeadComments(keyNode)
+				newComments := strings.Join(schema.FormatComments(headComments), "\n")
+				if fieldComments[fieldName] != newComments && len(headComments) > 0 {
+					return fmt.Errorf("conflicting comments for field %s in list items", fieldName)
 				}
 			} else {
 				// New field - build yaml path
-				yamlPath := fmt.Sprintf("%s.%s", structName, fieldName)
-				field, nestedStructs, err := p.parseFieldWithPath(fieldName, yamlPath, valueNode, comments)
+				yamlPath := structName + "." + fieldName
+				field, err := p.parseFieldWithPath(fieldName, yamlPath, valuesPath+"[]."+fieldName, valueNode, comments)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				mergedFields[fieldName] = field
-				fieldOrder = append(fieldOrder, fieldName)
+				fieldIndex[fieldName] = len(structDef.Fields)
+				fieldComments[fieldName] = strings.Join(schema.FormatComments(extractHeadComments(keyNode)), "\n")
+				structDef.Fields = append(structDef.Fields, field)
+			}
+		}
+	}
+
+	if err := checkRenames(structDef); err != nil {
+		return err
+	}
+	p.schema.Structs = append(p.schema.Structs, structDef)
+	return nil
+}
+
+// applyItemMarkers moves +miaka:items: markers from a list or map field to its
+// elements. List items use controller-gen's items: marker prefix; map values get
+// a named type with the markers.
+func (p *Parser) applyItemMarkers(field *schema.Field, fieldName, yamlPath, valuesPath string) error {
+	var itemMarkers []string
+	found := false
+	comments := make([]string, 0, len(field.Comments))
+	for _, comment := range field.Comments {
+		if !strings.HasPrefix(comment, ItemsMarker) {
+			comments = append(comments, comment)
+			continue
+		}
+		found = true
+		marker := strings.TrimSpace(strings.TrimPrefix(comment, ItemsMarker))
+		if !strings.HasPrefix(marker, validationPrefix) {
+			return fmt.Errorf("field %s: %s only supports kubebuilder:validation markers, got %q", yamlPath, ItemsMarker, marker)
+		}
+		if field.IsSlice {
+			// Keep the marker's position among the field's comments
+			comments = append(comments, "+"+validationPrefix+"items:"+strings.TrimPrefix(marker, validationPrefix))
+			continue
+		}
+		itemMarkers = append(itemMarkers, "+"+marker)
+	}
+	if !found {
+		return nil
+	}
+	field.Comments = comments
+	if field.IsSlice {
+		return nil
+	}
 
-				// Add nested structs
-				p.schema.Structs = append(p.schema.Structs, nestedStructs...)
+	valueType, ok := mapValueType(field.Type)
+	if !ok {
+		return fmt.Errorf("field %s: %s markers only apply to lists and maps", yamlPath, ItemsMarker)
+	}
+	typeName := p.uniqueTypeName(schema.ToPascalCase(fieldName)+"Value", fieldName, valuesPath)
+	p.schema.Types = append(p.schema.Types, schema.TypeDef{
+		Name:     typeName,
+		Type:     valueType,
+		Comments: itemMarkers,
+	})
+	field.Type = "map[string]" + typeName
+	return nil
+}
+
+// applyNullable marks fields with +miaka:nullable or +nullable as nullable, and
+// replaces +miaka:nullable with controller-gen's +nullable marker
+func applyNullable(field *schema.Field) {
+	comments := make([]string, 0, len(field.Comments)+1)
+	hasMarker := false
+	for _, comment := range field.Comments {
+		switch comment {
+		case NullableMarker, "+nullable":
+			field.Nullable = true
+			if hasMarker {
+				continue
 			}
+			hasMarker = true
+			comment = "+nullable"
 		}
+		comments = append(comments, comment)
+	}
+	if field.Nullable && !hasMarker {
+		comments = append(comments, "+nullable")
 	}
+	field.Comments = comments
+}
 
-	// Build the struct with fields in order
-	structDef := &schema.StructDef{
-		Name:     structName,
-		Comments: structComments,
-		Fields:   make([]schema.Field, 0, len(mergedFields)),
+// applyPreserveUnknownFields replaces +miaka:preserveUnknownFields with
+// controller-gen's +kubebuilder:pruning:PreserveUnknownFields marker
+func applyPreserveUnknownFields(field *schema.Field) {
+	comments := make([]string, 0, len(field.Comments))
+	hasMarker := false
+	for _, comment := range field.Comments {
+		switch comment {
+		case PreserveUnknownFieldsMarker, preserveUnknownsMarker:
+			if hasMarker {
+				continue
+			}
+			hasMarker = true
+			comment = preserveUnknownsMarker
+		}
+		comments = append(comments, comment)
+	}
+	field.Comments = comments
+}
+
+// semanticType returns the type of a non-null scalar (or list item): the semantic
+// type from its type hint, the semantic type its value looks like if inference is
+// enabled, or else the type inferred from the value
+func (p *Parser) semanticType(fieldName string, value interface{}, typeHint, inferred string) string {
+	if typeHint != "" {
+		if semantic := strings.TrimPrefix(typeHint, "[]"); schema.IsSemanticType(semantic) {
+			return semantic
+		}
+		return inferred
+	}
+	if p.opts.InferSemanticTypes {
+		if semantic := schema.InferSemanticType(fieldName, value); semantic != "" {
+			return string(semantic)
+		}
 	}
+	return inferred
+}
+
+// applyDurationPattern adds a Pattern marker to duration fields (or the items of
+// duration lists), since controller-gen doesn't validate their format. The Type
+// marker lets controller-gen apply the pattern before it resolves metav1.Duration.
+// Fields with their own Pattern marker are left alone.
+func applyDurationPattern(field *schema.Field) {
+	prefix := "+" + validationPrefix
+	switch {
+	case field.IsSlice && field.ElemType == string(schema.TypeDuration):
+		prefix += "items:"
+	case field.IsSlice || field.Type != string(schema.TypeDuration):
+		return
+	}
+	for _, comment := range field.Comments {
+		if strings.HasPrefix(comment, prefix+"Pattern=") {
+			return
+		}
+	}
+	field.Comments = append(field.Comments, prefix+"Type=string", prefix+"Pattern=`"+schema.DurationPattern+"`")
+}
+
+// applyRenamedFrom sets the old name of a field from its +miaka:renamedFrom: marker
+func applyRenamedFrom(field *schema.Field, yamlPath string) error {
+	comments := make([]string, 0, len(field.Comments))
+	for _, comment := range field.Comments {
+		if !strings.HasPrefix(comment, RenamedFromMarker) {
+			comments = append(comments, comment)
+			continue
+		}
+		if field.RenamedFrom != "" {
+			return fmt.Errorf("field %s: more than one %s marker", yamlPath, RenamedFromMarker)
+		}
+		field.RenamedFrom = strings.TrimSpace(strings.TrimPrefix(comment, RenamedFromMarker))
+		if field.RenamedFrom == "" || field.RenamedFrom == field.JSONName {
+			return fmt.Errorf("field %s: %s needs the old name of the field", yamlPath, RenamedFromMarker)
+		}
+	}
+	field.Comments = comments
+	return nil
+}
 
-	for _, fieldName := range fieldOrder {
-		structDef.Fields = append(structDef.Fields, *mergedFields[fieldName])
+// applyDeprecated sets what to use instead of a field from its +miaka:deprecated:
+// marker
+func applyDeprecated(field *schema.Field, yamlPath string) error {
+	// A marker without a message has no colon
+	name := strings.TrimSuffix(DeprecatedMarker, ":")
+	comments := make([]string, 0, len(field.Comments))
+	for _, comment := range field.Comments {
+		if comment != name && !strings.HasPrefix(comment, DeprecatedMarker) {
+			comments = append(comments, comment)
+			continue
+		}
+		if field.Deprecated != "" {
+			return fmt.Errorf("field %s: more than one %s marker", yamlPath, DeprecatedMarker)
+		}
+		field.Deprecated = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(comment, name), ":"))
+		if field.Deprecated == "" {
+			return fmt.Errorf("field %s: %s needs to say what to use instead (e.g., %s use other.field instead)", yamlPath, DeprecatedMarker, DeprecatedMarker)
+		}
 	}
+	field.Comments = comments
+	return nil
+}
 
-	return structDef, nil
+// applyCELRules replaces +miaka:cel: markers with controller-gen's XValidation
+// marker, which takes the same arguments (rule, message, messageExpression,
+// reason, fieldPath)
+func applyCELRules(field *schema.Field, yamlPath string) error {
+	for i, comment := range field.Comments {
+		if !strings.HasPrefix(comment, CELMarker) {
+			continue
+		}
+		args := strings.TrimSpace(strings.TrimPrefix(comment, CELMarker))
+		if !strings.HasPrefix(args, "rule=") && !strings.Contains(args, ",rule=") {
+			return fmt.Errorf("field %s: %s needs a rule (e.g., %s rule=\"self.min <= self.max\")", yamlPath, CELMarker, CELMarker)
+		}
+		field.Comments[i] = "+" + validationPrefix + "XValidation:" + args
+	}
+	return nil
+}
+
+// checkRenames rejects renames from the names of fields a struct still has, or
+// from the same name twice
+func checkRenames(structDef schema.StructDef) error {
+	names := make(map[string]bool, len(structDef.Fields))
+	for _, field := range structDef.Fields {
+		names[field.JSONName] = true
+	}
+	renamed := make(map[string]string)
+	for _, field := range structDef.Fields {
+		if field.RenamedFrom == "" {
+			continue
+		}
+		if names[field.RenamedFrom] {
+			return fmt.Errorf("field %s: renamed from %s, which is still a field", field.YAMLPath, field.RenamedFrom)
+		}
+		if other, ok := renamed[field.RenamedFrom]; ok {
+			return fmt.Errorf("field %s: renamed from %s, like %s", field.YAMLPath, field.RenamedFrom, other)
+		}
+		renamed[field.RenamedFrom] = field.JSONName
+	}
+	return nil
+}
+
+// applyFormat sets the Go type of a number field (or the items of a number list)
+// from its +miaka:format: marker, and adds a Format marker to floats, since
+// controller-gen only sets the format of integers. Fields with their own Format
+// marker are left alone. Value formats (e.g., imageref) add their markers instead.
+func applyFormat(field *schema.Field, yamlPath string) error {
+	format := ""
+	comments := make([]string, 0, len(field.Comments))
+	for _, comment := range field.Comments {
+		if !strings.HasPrefix(comment, FormatMarker) {
+			comments = append(comments, comment)
+			continue
+		}
+		if format != "" {
+			return fmt.Errorf("field %s: more than one %s marker", yamlPath, FormatMarker)
+		}
+		format = strings.TrimSpace(strings.TrimPrefix(comment, FormatMarker))
+	}
+	field.Comments = comments
+
+	elemType, prefix := field.Type, "+"+validationPrefix
+	if field.IsSlice {
+		elemType, prefix = field.ElemType, prefix+"items:"
+	}
+	if valueFormat, ok := schema.ValueFormats[format]; ok {
+		if err := applyValueFormat(field, elemType, prefix, format, valueFormat); err != nil {
+			return fmt.Errorf("field %s: %w", yamlPath, err)
+		}
+		return nil
+	}
+	if format != "" {
+		goType, err := formatType(elemType, format)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", yamlPath, err)
+		}
+		elemType = goType
+		if field.IsSlice {
+			field.ElemType, field.Type = goType, "[]"+goType
+		} else {
+			field.Type = goType
+		}
+	}
+
+	var floatFormat string
+	switch schema.FieldType(elemType) {
+	case schema.TypeFloat32:
+		floatFormat = "float"
+	case schema.TypeFloat64:
+		floatFormat = "double"
+	default:
+		return nil
+	}
+	for _, comment := range field.Comments {
+		if strings.HasPrefix(comment, prefix+"Format=") {
+			return nil
+		}
+	}
+	field.Comments = append(field.Comments, prefix+"Format="+floatFormat)
+	return nil
+}
+
+// applyValueFormat sets the Go type of a field (or its items) with a value format
+// and adds its markers, except those the field already has (e.g., its own Pattern)
+func applyValueFormat(field *schema.Field, elemType, prefix, format string, valueFormat schema.ValueFormat) error {
+	switch valueFormat.Type {
+	case schema.TypeString:
+		if schema.FieldType(elemType) != schema.TypeString {
+			return fmt.Errorf("%s%s only applies to strings and lists of strings", FormatMarker, format)
+		}
+	case schema.TypeInt:
+		if t := schema.FieldType(elemType); t != schema.TypeInt && t != schema.TypeInt32 {
+			return fmt.Errorf("%s%s only applies to integers and lists of integers", FormatMarker, format)
+		}
+		if field.IsSlice {
+			field.ElemType, field.Type = string(valueFormat.Type), "[]"+string(valueFormat.Type)
+		} else {
+			field.Type = string(valueFormat.Type)
+		}
+	}
+
+	for _, marker := range valueFormat.Markers {
+		name, _, _ := strings.Cut(marker, "=")
+		if !hasMarker(field.Comments, prefix+name+"=") {
+			field.Comments = append(field.Comments, prefix+marker)
+		}
+	}
+	return nil
+}
+
+// hasMarker reports whether any comment starts with a marker prefix
+func hasMarker(comments []string, prefix string) bool {
+	for _, comment := range comments {
+		if strings.HasPrefix(comment, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatType returns the Go type of a number of the inferred type with a format
+// of FormatMarker. Integer formats need integer values that fit them.
+func formatType(inferred, format string) (string, error) {
+	goType, ok := numberFormats[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported format %s%s (supported: int32, int64, float, double, %s)", FormatMarker, format, strings.Join(schema.ValueFormatNames(), ", "))
+	}
+	switch schema.FieldType(inferred) {
+	case schema.TypeInt, schema.TypeInt32:
+	case schema.TypeInt64:
+		if goType == schema.TypeInt32 {
+			return "", fmt.Errorf("%s%s doesn't fit the value, which is beyond the int32 range", FormatMarker, format)
+		}
+	case schema.TypeFloat32, schema.TypeFloat64:
+		if goType == schema.TypeInt32 || goType == schema.TypeInt64 {
+			return "", fmt.Errorf("%s%s needs an integer value", FormatMarker, format)
+		}
+	default:
+		return "", fmt.Errorf("%s markers only apply to numbers and lists of numbers", FormatMarker)
+	}
+	return string(goType), nil
+}
+
+// isLabelKey reports whether a key looks like a label or annotation key (e.g.,
+// kubernetes.io/arch), which isn't the name of a field
+func isLabelKey(key string) bool {
+	return strings.ContainsAny(key, "./")
+}
+
+// labelMapType returns the map type of an object with label or annotation keys,
+// if all its values are scalars of the same type
+func labelMapType(node *yaml.Node) (string, bool) {
+	hasLabelKey := false
+	for i := 0; i < len(node.Content) && !hasLabelKey; i += 2 {
+		hasLabelKey = isLabelKey(node.Content[i].Value)
+	}
+	if !hasLabelKey {
+		return "", false
+	}
+	valueType := ""
+	for i := 0; i < len(node.Content); i += 2 {
+		valueNode := node.Content[i+1]
+		if valueNode.Kind != yaml.ScalarNode {
+			return "", false
+		}
+		value, err := scalarValue(valueNode)
+		if err != nil || value == nil {
+			return "", false
+		}
+		inferred := schema.InferType(value)
+		if valueType != "" && inferred != valueType {
+			return "", false
+		}
+		valueType = inferred
+	}
+	return "map[string]" + valueType, true
+}
+
+// mapValueType returns the value type of a map[string]T type
+func mapValueType(goType string) (string, bool) {
+	if !strings.HasPrefix(goType, "map[string]") {
+		return "", false
+	}
+	return strings.TrimPrefix(goType, "map[string]"), true
 }
 
 // extractTypeHint looks for +miaka:type:<type> marker in comments
@@ -326,15 +1205,28 @@ func extractTypeHint(comments []string) string {
 			typeStr := strings.TrimPrefix(trimmed, "+miaka:type:")
 			typeStr = strings.TrimSpace(typeStr) // Allow space after colon
 			if typeStr != "" {
-				return typeStr
+				return schema.ResolveTypeHint(typeStr)
 			}
 		}
 	}
 	return ""
 }
 
-// extractComments extracts head comments from a node
-func extractComments(node *yaml.Node) []string {
+// extractComments extracts the comments documenting a field: the head comment
+// above the key, the line comments after the key or value (e.g., "port: 80 # Port"),
+// and the foot comment below the field, in that order
+func extractComments(keyNode, valueNode *yaml.Node) []string {
+	comments := extractHeadComments(keyNode)
+	for _, comment := range []string{keyNode.LineComment, valueNode.LineComment, keyNode.FootComment} {
+		if comment != "" {
+			comments = append(comments, strings.Split(comment, "\n")...)
+		}
+	}
+	return comments
+}
+
+// extractHeadComments extracts head comments from a node
+func extractHeadComments(node *yaml.Node) []string {
 	comments := make([]string, 0)
 	if node.HeadComment != "" {
 		lines := strings.Split(node.HeadComment, "\n")
@@ -387,10 +1279,19 @@ func handleEmptyList(field *schema.Field, typeHint string) {
 	}
 }
 
-// handleNonEmptyList handles type inference for non-empty lists
-func (p *Parser) handleNonEmptyList(field *schema.Field, valueNode *yaml.Node, fieldName, yamlPath string) ([]schema.StructDef, error) {
-	var nestedStructs []schema.StructDef
+// applyTypeHint sets a field's type from a +miaka:type hint (e.g., map[string]string or []string)
+func applyTypeHint(field *schema.Field, typeHint string) {
+	field.Type = typeHint
+	if strings.HasPrefix(typeHint, "[]") {
+		field.IsSlice = true
+		field.ElemType = strings.TrimPrefix(typeHint, "[]")
+	}
+}
 
+// handleNonEmptyList handles type inference for non-empty lists. Lists of
+// scalars with a template expression, or templated (marked +miaka:template),
+// are lists of opaque strings.
+func (p *Parser) handleNonEmptyList(field *schema.Field, valueNode *yaml.Node, fieldName, yamlPath, valuesPath, typeHint string, templated bool) error {
 	// Examine the first element to determine type
 	firstElem := valueNode.Content[0]
 
@@ -399,27 +1300,35 @@ func (p *Parser) handleNonEmptyList(field *schema.Field, valueNode *yaml.Node, f
 
 	switch firstElem.Kind {
 	case yaml.ScalarNode:
-		var value interface{}
-		if err := firstElem.Decode(&value); err != nil {
-			return nil, fmt.Errorf("failed to decode list element: %w", err)
+		value, err := scalarValue(firstElem)
+		if err != nil {
+			return fmt.Errorf("failed to decode list element: %w", err)
 		}
 		elemType := schema.InferType(value)
+		if value != nil {
+			elemType = p.semanticType(fieldName, value, typeHint, elemType)
+		}
+		template, err := p.templateItems(valueNode, templated, valuesPath)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", yamlPath, err)
+		}
+		if template {
+			elemType = string(schema.TypeString)
+		}
 		field.ElemType = elemType
 		field.Type = "[]" + elemType
 
 	case yaml.MappingNode:
 		// List of objects - need to merge fields from all elements
-		structName := p.generateUniqueStructName(fieldName, yamlPath)
+		structName := p.generateUniqueStructName(fieldName, valuesPath)
 		field.ElemType = structName
 		field.Type = "[]" + structName
 
 		// Merge all fields from all list items
-		mergedStruct, err := p.mergeListItems(valueNode, structName, structComments)
-		if err != nil {
-			return nil, err
+		if err := p.mergeListItems(valueNode, structName, valuesPath, structComments); err != nil {
+			return err
 		}
-		nestedStructs = append(nestedStructs, *mergedStruct)
 	}
 
-	return nestedStructs, nil
+	return nil
 }
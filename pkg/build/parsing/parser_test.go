@@ -1,12 +1,17 @@
 package parsing
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"gopkg.in/yaml.v3"
 )
 
 const testKindName = "Example"
@@ -20,9 +25,6 @@ func TestNewParser(t *testing.T) {
 	if p.schema == nil {
 		t.Error("Parser schema is nil")
 	}
-	if p.structNames == nil {
-		t.Error("Parser structNames map is nil")
-	}
 	if len(p.schema.Structs) != 0 {
 		t.Errorf("Expected empty structs, got %d", len(p.schema.Structs))
 	}
@@ -59,6 +61,25 @@ name: test
 	}
 }
 
+// TestParseFileFS tests parsing from a file in memory
+func TestParseFileFS(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{
+		"chart/example.yaml": []byte("apiVersion: example.com/v1\nkind: Example\nreplicas: 3\n"),
+	})
+
+	s, err := NewParser().ParseFileFS(fsys, "./chart/example.yaml")
+	if err != nil {
+		t.Fatalf("ParseFileFS failed: %v", err)
+	}
+	if s.Kind != testKindName {
+		t.Errorf("Expected kind '%s', got '%s'", testKindName, s.Kind)
+	}
+
+	if _, err := NewParser().ParseFileFS(fsys, "example.yaml"); err == nil || !strings.Contains(err.Error(), "failed to read file") {
+		t.Errorf("Expected 'failed to read file' error, got: %v", err)
+	}
+}
+
 // TestParseFile_NonExistent tests error handling for non-existent files
 func TestParseFile_NonExistent(t *testing.T) {
 	p := NewParser()
@@ -72,6 +93,21 @@ func TestParseFile_NonExistent(t *testing.T) {
 }
 
 // TestParse_BasicTypes tests parsing of basic scalar types
+func TestParse_WindowsLineEndings(t *testing.T) {
+	yamlContent := "apiVersion: example.com/v1\nkind: Example\n# The number of replicas\n# +kubebuilder:validation:Minimum=1\nreplicas: 3\nimage:\n  # The image repository\n  repository: nginx\n"
+	lf, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	crlf, err := NewParser().Parse([]byte(strings.ReplaceAll(yamlContent, "\n", "\r\n")))
+	if err != nil {
+		t.Fatalf("Parse of CRLF input failed: %v", err)
+	}
+	if !reflect.DeepEqual(lf, crlf) {
+		t.Errorf("Expected CRLF input to parse like LF input:\nLF:   %+v\nCRLF: %+v", lf.Structs, crlf.Structs)
+	}
+}
+
 func TestParse_BasicTypes(t *testing.T) {
 	yamlContent := `apiVersion: example.com/v1
 kind: Example
@@ -387,6 +423,34 @@ ports: []
 	}
 }
 
+// TestParse_TypeHintsOnNull tests that type hints apply to null values
+func TestParse_TypeHintsOnNull(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# +miaka:type: map[string]string
+podAnnotations:
+# +miaka:type: []string
+args: null
+untyped: ~
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fields := s.Structs[0].Fields
+	if fields[0].Type != "map[string]string" {
+		t.Errorf("podAnnotations: expected type 'map[string]string', got '%s'", fields[0].Type)
+	}
+	if fields[1].Type != "[]string" || !fields[1].IsSlice || fields[1].ElemType != "string" {
+		t.Errorf("args: expected slice of string, got type '%s' (elem '%s')", fields[1].Type, fields[1].ElemType)
+	}
+	if fields[2].Type != string(schema.TypeInterface) {
+		t.Errorf("untyped: expected type '%s', got '%s'", schema.TypeInterface, fields[2].Type)
+	}
+}
+
 // TestParse_Comments tests comment extraction
 func TestParse_Comments(t *testing.T) {
 	yamlContent := `apiVersion: example.com/v1
@@ -444,6 +508,63 @@ name: myapp
 	}
 }
 
+// TestParse_LineAndFootComments tests that line and foot comments are merged into field docs
+func TestParse_LineAndFootComments(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+replicas: 3 # Must be positive
+service: # Service settings
+  port: 80 # +kubebuilder:validation:Minimum=1
+  # Defaults to ClusterIP
+  type: ClusterIP
+  # One of ClusterIP, NodePort, or LoadBalancer
+tags: [] # +miaka:type: []string
+ports:
+- name: http # First port
+- name: metrics # Second port
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fields := make(map[string]schema.Field)
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			fields[field.YAMLPath] = field
+		}
+	}
+
+	tests := []struct {
+		path     string
+		comments []string
+	}{
+		{path: "replicas", comments: []string{"Number of replicas", "Must be positive"}},
+		{path: "service", comments: []string{"Service settings"}},
+		{path: "ServiceConfig.port", comments: []string{"+kubebuilder:validation:Minimum=1"}},
+		{path: "ServiceConfig.type", comments: []string{"Defaults to ClusterIP", "One of ClusterIP, NodePort, or LoadBalancer"}},
+		{path: "tags", comments: []string{"+miaka:type: []string"}},
+		// Line comments of later list items don't conflict with the first item's
+		{path: "PortsConfig.name", comments: []string{"First port"}},
+	}
+	for _, tt := range tests {
+		field, ok := fields[tt.path]
+		if !ok {
+			t.Errorf("Field %s not found", tt.path)
+			continue
+		}
+		if strings.Join(field.Comments, "\n") != strings.Join(tt.comments, "\n") {
+			t.Errorf("Field %s: expected comments %q, got %q", tt.path, tt.comments, field.Comments)
+		}
+	}
+
+	if tags := fields["tags"]; tags.Type != "[]string" {
+		t.Errorf("Expected the type hint in a line comment to apply, got type %s", tags.Type)
+	}
+}
+
 // TestParse_DeeplyNested tests deeply nested structures
 func TestParse_DeeplyNested(t *testing.T) {
 	yamlContent := `apiVersion: example.com/v1
@@ -521,6 +642,162 @@ database:
 	}
 }
 
+// TestAssignTypeNames_Suffixes tests the prefixes and numeric suffixes of
+// repeated collisions
+func TestAssignTypeNames_Suffixes(t *testing.T) {
+	requests := []typeRequest{
+		{baseName: "LimitsConfig", valuesPath: "config.limits[].limits", parentPath: "config.limits"},
+		{baseName: "LimitsConfig", valuesPath: "config.limits", parentPath: "config"},
+		{baseName: "ConfigLimitsConfig", valuesPath: "configLimits", parentPath: ""},
+		{baseName: "LimitsConfig", valuesPath: "other.limits", parentPath: "other"},
+		{baseName: "LimitsConfig", valuesPath: "limits", parentPath: ""},
+		{baseName: "ConfigConfig", valuesPath: "config", parentPath: ""},
+		{baseName: "LimitsConfig", valuesPath: "extra", parentPath: ""},
+	}
+
+	names := assignTypeNames(requests)
+	expected := []string{"ConfigConfigLimitsConfigLimitsConfig", "ConfigConfigLimitsConfig", "ConfigLimitsConfig", "LimitsConfig3", "LimitsConfig2", "ConfigConfig", "LimitsConfig"}
+	for i, request := range requests {
+		if names[request.valuesPath] != expected[i] {
+			t.Errorf("%s: name = %q, want %q", request.valuesPath, names[request.valuesPath], expected[i])
+		}
+	}
+}
+
+// TestParse_StructNamesIgnoreOrder tests that reordering fields never renames
+// the structs of other fields
+func TestParse_StructNamesIgnoreOrder(t *testing.T) {
+	documents := []string{
+		`apiVersion: example.com/v1
+kind: Example
+nats:
+  config:
+    port: 4222
+config:
+  debug: true
+redis:
+  config:
+    port: 6379
+`,
+		`apiVersion: example.com/v1
+kind: Example
+redis:
+  config:
+    port: 6379
+config:
+  debug: true
+nats:
+  config:
+    port: 4222
+`,
+	}
+
+	var results []map[string]string
+	for _, document := range documents {
+		s, err := NewParser().Parse([]byte(document))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		types := make(map[string]string)
+		for _, structDef := range s.Structs {
+			for _, field := range structDef.Fields {
+				types[field.YAMLPath] = field.Type
+			}
+		}
+		results = append(results, types)
+	}
+
+	expected := map[string]string{
+		"config":             "Config",
+		"nats":               "NatsConfig",
+		"NatsConfig.config":  "NatsConfigConfig",
+		"redis":              "RedisConfig",
+		"RedisConfig.config": "RedisConfigConfig",
+	}
+	for _, types := range results {
+		for path, want := range expected {
+			if types[path] != want {
+				t.Errorf("%s: Type = %q, want %q (all types: %v)", path, types[path], want, types)
+			}
+		}
+	}
+}
+
+// TestParse_StructNamesNewShallowerKey documents that names only depend on the
+// paths of the values: a new key whose type has the base name of a deeper type
+// takes the name, and the deeper type is renamed
+func TestParse_StructNamesNewShallowerKey(t *testing.T) {
+	before := `apiVersion: example.com/v1
+kind: Example
+nats:
+  config:
+    port: 4222
+`
+	after := before + `config:
+  debug: true
+`
+	for document, want := range map[string]string{before: "Config", after: "NatsConfigConfig"} {
+		s, err := NewParser().Parse([]byte(document))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if field, ok := s.FieldAt([]string{"nats", "config"}); !ok || field.Type != want {
+			t.Errorf("nats.config: Type = %q, want %q", field.Type, want)
+		}
+	}
+}
+
+// TestParse_CollectTypes tests that the types collected before parsing are the
+// types the values need
+func TestParse_CollectTypes(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+metadata:
+  labels:
+    app: example
+nats:
+  config:
+    port: 4222
+# +miaka:items:kubebuilder:validation:MaxLength=63
+nodeSelector:
+  kubernetes.io/os: linux
+# +miaka:type: map[string]string
+# +miaka:items:kubebuilder:validation:MinLength=1
+annotations: ~
+# +miaka:type: map[string]int
+# +miaka:items:kubebuilder:validation:Minimum=0
+ports: {}
+# +miaka:type: map[string]string
+env:
+  A: a
+containers:
+  - name: a
+  - name: b
+    resources:
+      limits:
+        cpu: "1"
+  - resources:
+      requests:
+        cpu: "1"
+tags: [a, b]
+rawConfig:
+  nested:
+    value: 1
+`
+	p := NewParserWithOptions(Options{Paths: PathFilter{Exclude: []string{"rawConfig"}}})
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(p.requests) > 0 {
+		t.Errorf("Expected every type to be collected before parsing, missed %v", p.requests)
+	}
+	// Every type but the root struct is named
+	if want := len(s.Structs) - 1 + len(s.Types); len(p.typeNames) != want {
+		t.Errorf("Expected %d collected types, got %v", want, p.typeNames)
+	}
+}
+
 // TestParse_MetadataIgnored tests that metadata field is ignored
 func TestParse_MetadataIgnored(t *testing.T) {
 	yamlContent := `apiVersion: example.com/v1
@@ -807,3 +1084,865 @@ field3: value3
 		}
 	}
 }
+
+// TestParse_ItemMarkers tests that +miaka:items: markers move to list items and map values
+func TestParse_ItemMarkers(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Tags
+# +miaka:items:kubebuilder:validation:MinLength=1
+tags:
+- a
+# +miaka:type:map[string]string
+# +miaka:items: kubebuilder:validation:MaxLength=63
+labels: {}
+ports:
+- # +miaka:items:kubebuilder:validation:Minimum=1
+  nums: [1]
+- # +miaka:items:kubebuilder:validation:Minimum=1
+  nums: [2]
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fields := make(map[string]schema.Field)
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			fields[field.JSONName] = field
+		}
+	}
+
+	if got := strings.Join(fields["tags"].Comments, "\n"); got != "Tags\n+kubebuilder:validation:items:MinLength=1" {
+		t.Errorf("tags comments = %q", got)
+	}
+	if got := strings.Join(fields["nums"].Comments, "\n"); got != "+kubebuilder:validation:items:Minimum=1" {
+		t.Errorf("nums comments = %q", got)
+	}
+
+	labels := fields["labels"]
+	if labels.Type != "map[string]LabelsValue" {
+		t.Errorf("labels type = %q, want map[string]LabelsValue", labels.Type)
+	}
+	if got := strings.Join(labels.Comments, "\n"); got != "+miaka:type:map[string]string" {
+		t.Errorf("labels comments = %q", got)
+	}
+	if len(s.Types) != 1 {
+		t.Fatalf("Expected 1 named type, got %d", len(s.Types))
+	}
+	valueType := s.Types[0]
+	if valueType.Name != "LabelsValue" || valueType.Type != "string" {
+		t.Errorf("Expected type LabelsValue string, got type %s %s", valueType.Name, valueType.Type)
+	}
+	if got := strings.Join(valueType.Comments, "\n"); got != "+kubebuilder:validation:MaxLength=63" {
+		t.Errorf("LabelsValue comments = %q", got)
+	}
+}
+
+// TestParse_ItemMarkersErrors tests invalid uses of +miaka:items: markers
+func TestParse_ItemMarkersErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "scalar field",
+			yaml: `apiVersion: example.com/v1
+kind: Example
+# +miaka:items:kubebuilder:validation:MinLength=1
+name: a
+`,
+			wantErr: "field name: +miaka:items: markers only apply to lists and maps",
+		},
+		{
+			name: "non-validation marker",
+			yaml: `apiVersion: example.com/v1
+kind: Example
+# +miaka:items:kubebuilder:default=a
+tags: [a]
+`,
+			wantErr: `field tags: +miaka:items: only supports kubebuilder:validation markers, got "kubebuilder:default=a"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParse_Nullable tests that +miaka:nullable and null example values make fields nullable
+func TestParse_Nullable(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Mount the token
+# +miaka:nullable
+automount: false
+# +miaka:type: int
+replicas: null
+# +nullable
+# +miaka:nullable
+mode: a
+enabled: true
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		nullable bool
+		comments string
+	}{
+		"automount": {true, "Mount the token\n+nullable"},
+		"replicas":  {true, "+miaka:type: int\n+nullable"},
+		"mode":      {true, "+nullable"},
+		"enabled":   {false, ""},
+	}
+	for _, field := range s.Structs[0].Fields {
+		want := tests[field.JSONName]
+		if field.Nullable != want.nullable {
+			t.Errorf("%s: Nullable = %v, want %v", field.JSONName, field.Nullable, want.nullable)
+		}
+		if got := strings.Join(field.Comments, "\n"); got != want.comments {
+			t.Errorf("%s: comments = %q, want %q", field.JSONName, got, want.comments)
+		}
+	}
+}
+
+// TestParse_NumberFormats tests that large integers are int64, and that
+// +miaka:format: markers set the Go type of numbers and the format of floats
+func TestParse_NumberFormats(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+port: 8080
+maxBytes: 10737418240
+ratio: 0.5
+# +miaka:format:int32
+retries: 3
+# +miaka:format:float
+scale: 2
+# +miaka:format:int64
+# +kubebuilder:validation:items:Minimum=0
+thresholds: [100, 200]
+# +kubebuilder:validation:Format=float
+weight: 1.5
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		goType   string
+		comments string
+	}{
+		"port":       {"int", ""},
+		"maxBytes":   {"int64", ""},
+		"ratio":      {"float64", "+kubebuilder:validation:Format=double"},
+		"retries":    {"int32", ""},
+		"scale":      {"float32", "+kubebuilder:validation:Format=float"},
+		"thresholds": {"[]int64", "+kubebuilder:validation:items:Minimum=0"},
+		"weight":     {"float64", "+kubebuilder:validation:Format=float"},
+	}
+	for _, field := range s.Structs[0].Fields {
+		want := tests[field.JSONName]
+		if field.Type != want.goType {
+			t.Errorf("%s: Type = %q, want %q", field.JSONName, field.Type, want.goType)
+		}
+		if got := strings.Join(field.Comments, "\n"); got != want.comments {
+			t.Errorf("%s: comments = %q, want %q", field.JSONName, got, want.comments)
+		}
+	}
+	if thresholds := s.Structs[0].Fields[5]; thresholds.ElemType != "int64" {
+		t.Errorf("thresholds: ElemType = %q, want %q", thresholds.ElemType, "int64")
+	}
+}
+
+// TestParse_ValueFormats tests that +miaka:format: markers of value shapes add
+// their validation markers, keeping the field's own
+func TestParse_ValueFormats(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# +miaka:format:imageref
+image: nginx:1.25
+# +miaka:format:cidr
+# +kubebuilder:validation:items:MaxLength=18
+allowedCIDRs: [10.0.0.0/8]
+# +miaka:format:port
+ports: [80, 443]
+# +miaka:format:duration
+timeout: 30s
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := map[string]struct {
+		goType   string
+		comments []string
+	}{
+		"image":        {"string", []string{"+kubebuilder:validation:Pattern=`" + schema.ImageRefPattern + "`"}},
+		"allowedCIDRs": {"[]string", []string{"+kubebuilder:validation:items:MaxLength=18", "+kubebuilder:validation:items:Pattern=`" + schema.CIDRPattern + "`"}},
+		"ports":        {"[]int", []string{"+kubebuilder:validation:items:Minimum=1", "+kubebuilder:validation:items:Maximum=65535"}},
+		"timeout":      {"string", []string{"+kubebuilder:validation:Pattern=`" + schema.DurationPattern + "`"}},
+	}
+	for _, field := range s.Structs[0].Fields {
+		want := tests[field.JSONName]
+		if field.Type != want.goType {
+			t.Errorf("%s: Type = %q, want %q", field.JSONName, field.Type, want.goType)
+		}
+		if !reflect.DeepEqual(field.Comments, want.comments) {
+			t.Errorf("%s: comments = %q, want %q", field.JSONName, field.Comments, want.comments)
+		}
+	}
+}
+
+func TestParse_NumberFormatsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "unsupported format",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:uint8\nport: 80\n",
+			wantErr: "field port: unsupported format +miaka:format:uint8 (supported: int32, int64, float, double, cidr, duration, imageref, port)",
+		},
+		{
+			name:    "string field",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:int64\nname: a\n",
+			wantErr: "field name: +miaka:format: markers only apply to numbers and lists of numbers",
+		},
+		{
+			name:    "fractional value",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:int64\nratio: 0.5\n",
+			wantErr: "field ratio: +miaka:format:int64 needs an integer value",
+		},
+		{
+			name:    "value beyond int32",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:int32\nmaxBytes: 10737418240\n",
+			wantErr: "field maxBytes: +miaka:format:int32 doesn't fit the value, which is beyond the int32 range",
+		},
+		{
+			name:    "string format on a number",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:imageref\nport: 80\n",
+			wantErr: "field port: +miaka:format:imageref only applies to strings and lists of strings",
+		},
+		{
+			name:    "port format on a string",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:port\nport: http\n",
+			wantErr: "field port: +miaka:format:port only applies to integers and lists of integers",
+		},
+		{
+			name:    "two markers",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:int32\n# +miaka:format:int64\nport: 80\n",
+			wantErr: "field port: more than one +miaka:format: marker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParse_LabelKeys tests that objects with keys like kubernetes.io/arch are
+// maps, and that such keys that still become struct fields are reported
+func TestParse_LabelKeys(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+nodeSelector:
+  kubernetes.io/arch: amd64
+  node-role: app
+ports:
+  example.com/http: 80
+mixed:
+  example.com/name: a
+  example.com/count: 1
+# +miaka:type: map[string]string
+podLabels:
+  app: web
+resources:
+  limits: {}
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"nodeSelector": "map[string]string",
+		"ports":        "map[string]int",
+		"mixed":        "MixedConfig",
+		"podLabels":    "map[string]string",
+		"resources":    "ResourcesConfig",
+	}
+	mainStruct := s.Structs[len(s.Structs)-1]
+	for _, field := range mainStruct.Fields {
+		if want := tests[field.JSONName]; field.Type != want {
+			t.Errorf("%s: Type = %q, want %q", field.JSONName, field.Type, want)
+		}
+	}
+
+	wantWarnings := []string{
+		`line 9: key "example.com/name" becomes the Go field ExampleComName (mark its object +miaka:type: map[string]string if its keys are labels or annotations)`,
+		`line 10: key "example.com/count" becomes the Go field ExampleComCount (mark its object +miaka:type: map[string]string if its keys are labels or annotations)`,
+	}
+	if got := strings.Join(s.Warnings, "\n"); got != strings.Join(wantWarnings, "\n") {
+		t.Errorf("Warnings = %q, want %q", s.Warnings, wantWarnings)
+	}
+}
+
+// TestParse_PreserveUnknownFields tests that +miaka:preserveUnknownFields is
+// replaced with controller-gen's marker and keeps the object typed
+func TestParse_PreserveUnknownFields(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Passed through to the plugin
+# +miaka:preserveUnknownFields
+plugin:
+  name: foo
+# +kubebuilder:pruning:PreserveUnknownFields
+# +miaka:preserveUnknownFields
+open: {}
+strict:
+  name: bar
+`
+	p := NewParser()
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	root := s.Structs[len(s.Structs)-1]
+	tests := map[string]struct {
+		typ      string
+		comments string
+	}{
+		"plugin": {"PluginConfig", "Passed through to the plugin\n+kubebuilder:pruning:PreserveUnknownFields"},
+		"open":   {"OpenConfig", "+kubebuilder:pruning:PreserveUnknownFields"},
+		"strict": {"StrictConfig", ""},
+	}
+	for _, field := range root.Fields {
+		want, ok := tests[field.JSONName]
+		if !ok {
+			continue
+		}
+		if field.Type != want.typ {
+			t.Errorf("%s: Type = %q, want %q", field.JSONName, field.Type, want.typ)
+		}
+		if got := strings.Join(field.Comments, "\n"); got != want.comments {
+			t.Errorf("%s: comments = %q, want %q", field.JSONName, got, want.comments)
+		}
+	}
+}
+
+func TestParse_SemanticTypes(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# +miaka:type: quantity
+cpu: 500m
+memory: 512Mi
+# +miaka:type: duration
+# +kubebuilder:validation:Pattern=^[0-9]+s$
+timeout: 30s
+# +miaka:type: []duration
+intervals: [1s, 1m]
+targetPort: http
+# +miaka:type: int-or-string
+limit: null
+`
+	tests := []struct {
+		name    string
+		opts    Options
+		types   map[string]string
+		markers map[string]string
+	}{
+		{
+			name: "hints",
+			types: map[string]string{
+				"cpu": "resource.Quantity", "memory": "string", "timeout": "metav1.Duration",
+				"intervals": "[]metav1.Duration", "targetPort": "string", "limit": "intstr.IntOrString",
+			},
+			markers: map[string]string{
+				"timeout":   "+kubebuilder:validation:Pattern=^[0-9]+s$",
+				"intervals": "+kubebuilder:validation:items:Pattern=`" + schema.DurationPattern + "`",
+			},
+		},
+		{
+			name: "inferred",
+			opts: Options{InferSemanticTypes: true},
+			types: map[string]string{
+				"cpu": "resource.Quantity", "memory": "resource.Quantity", "timeout": "metav1.Duration",
+				"intervals": "[]metav1.Duration", "targetPort": "intstr.IntOrString", "limit": "intstr.IntOrString",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewParserWithOptions(tt.opts).Parse([]byte(yamlContent))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			for _, field := range s.Structs[0].Fields {
+				fieldType := field.Type
+				if field.IsSlice {
+					fieldType = "[]" + field.ElemType
+				}
+				if fieldType != tt.types[field.JSONName] {
+					t.Errorf("%s: type = %q, want %q", field.JSONName, fieldType, tt.types[field.JSONName])
+				}
+				marker, ok := tt.markers[field.JSONName]
+				if !ok {
+					continue
+				}
+				patterns := 0
+				for _, comment := range field.Comments {
+					if strings.Contains(comment, "Pattern=") {
+						patterns++
+					}
+				}
+				if patterns != 1 || !strings.Contains(strings.Join(field.Comments, "\n"), marker) {
+					t.Errorf("%s: comments = %q, want a single Pattern marker %q", field.JSONName, field.Comments, marker)
+				}
+			}
+		})
+	}
+}
+
+// TestParse_PathFilter tests that filtered paths become freeform fields
+func TestParse_PathFilter(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+controller:
+  replicas: 1
+  # Affinity rules
+  affinity:
+    nodeAffinity:
+      required: true
+env:
+  - name: A
+    valueFrom:
+      secretKeyRef: x
+`
+	p := NewParserWithOptions(Options{Paths: PathFilter{Exclude: []string{"controller.affinity.**", "env[].valueFrom"}}})
+	s, err := p.Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	fields := make(map[string]schema.Field)
+	for _, st := range s.Structs {
+		if strings.Contains(st.Name, "Affinity") || strings.Contains(st.Name, "ValueFrom") {
+			t.Errorf("Expected no struct for a freeform value, got %s", st.Name)
+		}
+		for _, field := range st.Fields {
+			fields[field.JSONName] = field
+		}
+	}
+
+	for _, name := range []string{"affinity", "valueFrom"} {
+		field := fields[name]
+		if field.Type != string(schema.TypeFreeform) {
+			t.Errorf("%s: expected type %s, got %s", name, schema.TypeFreeform, field.Type)
+		}
+		comments := strings.Join(field.Comments, "\n")
+		if !strings.Contains(comments, schemalessMarker) || !strings.Contains(comments, preserveUnknownsMarker) {
+			t.Errorf("%s: expected freeform markers, got %q", name, field.Comments)
+		}
+	}
+	if !strings.Contains(strings.Join(fields["affinity"].Comments, "\n"), "Affinity rules") {
+		t.Errorf("Expected the description of a freeform field to be kept, got %q", fields["affinity"].Comments)
+	}
+	if fields["replicas"].Type != "int" {
+		t.Errorf("Expected replicas to stay typed, got %s", fields["replicas"].Type)
+	}
+}
+
+// TestParse_Plain tests that plain values need no apiVersion or kind
+func TestParse_Plain(t *testing.T) {
+	yamlContent := `# Number of replicas
+replicaCount: 1
+image:
+  repository: nginx
+`
+	s, err := NewParserWithOptions(Options{Plain: true}).Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !s.Plain || s.Kind != schema.DefaultPlainTypeName || s.APIVersion != schema.PlainAPIVersion || s.Package != schema.PlainPackage {
+		t.Errorf("Expected plain type meta, got plain=%v kind=%q apiVersion=%q package=%q", s.Plain, s.Kind, s.APIVersion, s.Package)
+	}
+
+	var mainStruct *schema.StructDef
+	for i := range s.Structs {
+		if s.Structs[i].Name == s.Kind {
+			mainStruct = &s.Structs[i]
+		}
+	}
+	if mainStruct == nil || len(mainStruct.Fields) != 2 {
+		t.Fatalf("Expected a %s struct with 2 fields, got %+v", s.Kind, mainStruct)
+	}
+
+	s, err = NewParserWithOptions(Options{Plain: true, TypeName: "ChartValues"}).Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if s.Kind != "ChartValues" {
+		t.Errorf("Expected kind ChartValues, got %q", s.Kind)
+	}
+
+	for _, key := range []string{"apiVersion", "kind", "metadata"} {
+		_, err := NewParserWithOptions(Options{Plain: true}).Parse([]byte(key + ": x\n"))
+		if err == nil || !strings.Contains(err.Error(), "is reserved") {
+			t.Errorf("Expected %s to be reserved, got %v", key, err)
+		}
+	}
+}
+
+func TestParse_CRDMarkers(t *testing.T) {
+	yamlContent := `# Values of the App resource
+# +miaka:crd:subresource:status
+# +miaka:crd:printcolumn:name=Replicas,path=.replicas,type=integer
+
+apiVersion: example.com/v1
+# +miaka:crd:subresource:scale:specpath=.replicas,statuspath=.status.replicas
+# +miaka:crd:resource:scope=Cluster,plural=applications,shortName=app;apps
+kind: App
+# +kubebuilder:validation:Minimum=0
+replicas: 1
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []string{
+		"+kubebuilder:subresource:status",
+		"+kubebuilder:printcolumn:name=Replicas,JSONPath=.replicas,type=integer",
+		"+kubebuilder:subresource:scale:specpath=.replicas,statuspath=.status.replicas",
+		"+kubebuilder:resource:scope=Cluster,path=applications,shortName=app;apps",
+	}
+	if got := strings.Join(s.CRDMarkers, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("CRDMarkers = %q, want %q", s.CRDMarkers, want)
+	}
+
+	tests := map[string]struct {
+		yaml    string
+		opts    Options
+		wantErr string
+	}{
+		"unsupported marker": {
+			yaml:    "# +miaka:crd:storageversion\napiVersion: example.com/v1\nkind: App\n",
+			wantErr: "unsupported marker +miaka:crd:storageversion",
+		},
+		"plain values": {
+			yaml:    "# +miaka:crd:subresource:status\nreplicas: 1\n",
+			opts:    Options{Plain: true},
+			wantErr: "plain values",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewParserWithOptions(tt.opts).Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// largeValues returns a values file with the given number of services, each
+// about 40 lines of commented scalars, nested objects, and lists
+func largeValues(services int) []byte {
+	var b strings.Builder
+	b.WriteString("apiVersion: example.com/v1\nkind: Umbrella\n")
+	for i := 0; i < services; i++ {
+		fmt.Fprintf(&b, `# Service %[1]d
+service%[1]d:
+  # Whether the service is deployed
+  enabled: true
+  # +kubebuilder:validation:Minimum=1
+  replicas: 3 # Number of pods
+  image:
+    # Image repository
+    repository: example.com/service%[1]d
+    tag: v1.2.3
+    pullPolicy: IfNotPresent
+  resources:
+    limits:
+      cpu: 500m
+      memory: 512Mi
+    requests:
+      cpu: 100m
+      memory: 128Mi
+  # Extra environment variables
+  env:
+    - name: LOG_LEVEL
+      value: info
+    - name: PORT
+      value: "8080"
+  ports:
+    - 8080
+    - 9090
+  # +miaka:type: map[string]string
+  podAnnotations: {}
+  probes:
+    liveness:
+      path: /healthz
+      initialDelaySeconds: 10
+      timeout: 1.5
+    readiness:
+      path: /ready
+      initialDelaySeconds: 5
+      timeout: 2.5
+  nodeSelector:
+    kubernetes.io/os: linux
+  tolerations: []
+`, i)
+	}
+	return []byte(b.String())
+}
+
+// BenchmarkParse measures parsing time and allocations for small and large values files
+func BenchmarkParse(b *testing.B) {
+	for _, services := range []int{10, 1000} {
+		data := largeValues(services)
+		b.Run(fmt.Sprintf("lines=%d", strings.Count(string(data), "\n")), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				if _, err := NewParser().Parse(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestScalarValue tests that the scalars resolved without a decoder have the
+// values the decoder gives them
+func TestScalarValue(t *testing.T) {
+	for _, scalar := range []string{"text", "~", "null", "true", "false", "True", "0", "-0", "42", "-42", "010", "0o10", "0x1F", "+5", "1_000", "9223372036854775808", "1.5", ".inf"} {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(scalar), &doc); err != nil {
+			t.Fatalf("Failed to parse %q: %v", scalar, err)
+		}
+		node := doc.Content[0]
+		var want interface{}
+		if err := node.Decode(&want); err != nil {
+			t.Fatalf("Failed to decode %q: %v", scalar, err)
+		}
+		got, err := scalarValue(node)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("scalarValue(%q) = %#v, %v; want %#v", scalar, got, err, want)
+		}
+	}
+}
+
+// TestParse_MemoryBudget guards the memory of parsing a large values file, which
+// took 50 MB before the parser's allocations were reduced and 45 MB since. Most
+// of it is yaml.v3's node tree, which the parser needs for comments.
+func TestParse_MemoryBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("parses a 41k line values file")
+	}
+	data := largeValues(1000)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := NewParser().Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+
+	const budget = 48e6
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > budget {
+		t.Errorf("Parsing %d lines allocated %.1f MB, over the budget of %.0f MB (see BenchmarkParse)",
+			strings.Count(string(data), "\n"), float64(allocated)/1e6, budget/1e6)
+	}
+}
+
+func TestParse_RenamedFrom(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+image:
+  # Repository of the image
+  # +miaka:renamedFrom: repo
+  repository: nginx
+containers:
+  - # +miaka:renamedFrom:port
+    containerPort: 80
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []schema.Rename{
+		{Path: []string{"image"}, From: "repo", To: "repository"},
+		{Path: []string{"containers", "[]"}, From: "port", To: "containerPort"},
+	}
+	if renames := s.Renames(); !reflect.DeepEqual(renames, want) {
+		t.Errorf("Renames() = %v, want %v", renames, want)
+	}
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if field.JSONName == "repository" && !reflect.DeepEqual(field.Comments, []string{"Repository of the image"}) {
+				t.Errorf("expected the marker to be removed from the comments, got %v", field.Comments)
+			}
+		}
+	}
+}
+
+func TestParse_RenamedFromErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "no old name",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:renamedFrom:\nport: 80\n",
+			wantErr: "field port: +miaka:renamedFrom: needs the old name of the field",
+		},
+		{
+			name:    "own name",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:renamedFrom: port\nport: 80\n",
+			wantErr: "field port: +miaka:renamedFrom: needs the old name of the field",
+		},
+		{
+			name:    "old name still a field",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\nimage:\n  repo: a\n  # +miaka:renamedFrom: repo\n  repository: b\n",
+			wantErr: "field ImageConfig.repository: renamed from repo, which is still a field",
+		},
+		{
+			name:    "old name renamed twice",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:renamedFrom: port\nhttpPort: 80\n# +miaka:renamedFrom: port\nhttpsPort: 443\n",
+			wantErr: "field httpsPort: renamed from port, like httpPort",
+		},
+		{
+			name:    "two markers",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:renamedFrom: a\n# +miaka:renamedFrom: b\nport: 80\n",
+			wantErr: "field port: more than one +miaka:renamedFrom: marker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_Deprecated(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+image:
+  # Repository of the image
+  # +miaka:deprecated: use registry instead
+  repository: nginx
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []schema.Deprecation{{Path: []string{"image"}, Name: "repository", Message: "use registry instead"}}
+	if deprecations := s.Deprecations(); !reflect.DeepEqual(deprecations, want) {
+		t.Errorf("Deprecations() = %v, want %v", deprecations, want)
+	}
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if field.JSONName == "repository" && !reflect.DeepEqual(field.Comments, []string{"Repository of the image"}) {
+				t.Errorf("expected the marker to be removed from the comments, got %v", field.Comments)
+			}
+		}
+	}
+}
+
+func TestParse_DeprecatedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "no message",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:deprecated\nport: 80\n",
+			wantErr: "field port: +miaka:deprecated: needs to say what to use instead",
+		},
+		{
+			name:    "empty message",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:deprecated:\nport: 80\n",
+			wantErr: "field port: +miaka:deprecated: needs to say what to use instead",
+		},
+		{
+			name:    "two markers",
+			yaml:    "apiVersion: example.com/v1\nkind: Example\n# +miaka:deprecated: use a\n# +miaka:deprecated: use b\nport: 80\n",
+			wantErr: "field port: more than one +miaka:deprecated: marker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_CEL(t *testing.T) {
+	yamlContent := `apiVersion: example.com/v1
+kind: Example
+# Autoscaling of the app
+# +miaka:cel: rule="self.maxReplicas >= self.minReplicas",message="maxReplicas must be at least minReplicas"
+autoscaling:
+  minReplicas: 1
+  maxReplicas: 3
+`
+	s, err := NewParser().Parse([]byte(yamlContent))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []string{
+		"Autoscaling of the app",
+		`+kubebuilder:validation:XValidation:rule="self.maxReplicas >= self.minReplicas",message="maxReplicas must be at least minReplicas"`,
+	}
+	for _, structDef := range s.Structs {
+		for _, field := range structDef.Fields {
+			if field.JSONName == "autoscaling" && !reflect.DeepEqual(field.Comments, want) {
+				t.Errorf("Comments = %v, want %v", field.Comments, want)
+			}
+		}
+	}
+}
+
+func TestParse_CELWithoutRule(t *testing.T) {
+	yamlContent := "apiVersion: example.com/v1\nkind: Example\n# +miaka:cel: message=\"min must not exceed max\"\nautoscaling:\n  min: 1\n"
+	_, err := NewParser().Parse([]byte(yamlContent))
+	if err == nil || !strings.Contains(err.Error(), "field autoscaling: +miaka:cel: needs a rule") {
+		t.Errorf("Parse() error = %v, want a missing rule error", err)
+	}
+}
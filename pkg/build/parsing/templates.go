@@ -0,0 +1,128 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateMarker declares that a string field (or the items of a list of
+// strings) holds Helm template expressions that the chart renders with tpl
+// (e.g., "{{ .Release.Name }}-config"), so it's typed as an opaque string
+// without a warning
+const TemplateMarker = "+miaka:template"
+
+// TemplateStringPolicy controls how the parser handles example values that are
+// Helm template expressions without a TemplateMarker
+type TemplateStringPolicy string
+
+// Template string policies
+const (
+	// TemplatesAllow types template expressions as opaque strings silently
+	TemplatesAllow TemplateStringPolicy = "allow"
+	// TemplatesWarn types template expressions as opaque strings with a warning
+	// for each, since a template in values.yaml is usually a mistake unless the
+	// chart renders it with tpl
+	TemplatesWarn TemplateStringPolicy = "warn"
+	// TemplatesError fails parsing
+	TemplatesError TemplateStringPolicy = "error"
+)
+
+// ParseTemplateStringPolicy parses a template string policy. "" is TemplatesWarn.
+func ParseTemplateStringPolicy(policy string) (TemplateStringPolicy, error) {
+	switch p := TemplateStringPolicy(policy); p {
+	case "":
+		return TemplatesWarn, nil
+	case TemplatesAllow, TemplatesWarn, TemplatesError:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid template string policy %q (must be allow, warn, or error)", policy)
+}
+
+// IsTemplateString reports whether s contains a Helm template expression
+// ("{{ ... }}")
+func IsTemplateString(s string) bool {
+	_, after, ok := strings.Cut(s, "{{")
+	return ok && strings.Contains(after, "}}")
+}
+
+// TemplateString is an example value that is a Helm template expression
+type TemplateString struct {
+	// Path is the dotted path of the value (e.g., "image.tag")
+	Path string
+	Line int
+}
+
+func (t TemplateString) String() string {
+	return fmt.Sprintf("line %d: %s is a Helm template expression", t.Line, t.Path)
+}
+
+// TemplateStringsError is the error for template expressions with TemplatesError
+type TemplateStringsError struct {
+	Strings []TemplateString
+}
+
+func (e *TemplateStringsError) Error() string {
+	lines := make([]string, 0, len(e.Strings))
+	for _, template := range e.Strings {
+		lines = append(lines, template.String())
+	}
+	return fmt.Sprintf("%d value(s) are Helm template expressions (mark them %s if the chart renders them with tpl):\n  %s", len(e.Strings), TemplateMarker, strings.Join(lines, "\n  "))
+}
+
+// templateValue reports whether a scalar is typed as an opaque string: a value
+// of a field marked with TemplateMarker, which must be a string, or a template
+// expression, which is recorded for the policy
+func (p *Parser) templateValue(value interface{}, marked bool, valuesPath string, line int) (bool, error) {
+	s, ok := value.(string)
+	switch {
+	case marked && !ok:
+		return false, fmt.Errorf("%s only applies to strings, got %v", TemplateMarker, value)
+	case marked:
+		return true, nil
+	case ok && IsTemplateString(s):
+		p.templateStrings = append(p.templateStrings, TemplateString{Path: valuesPath, Line: line})
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkTemplateStrings applies the template string policy to the unmarked
+// template expressions found while parsing
+func (p *Parser) checkTemplateStrings() error {
+	if len(p.templateStrings) == 0 {
+		return nil
+	}
+	switch p.opts.TemplateStrings {
+	case TemplatesAllow:
+	case TemplatesError:
+		return &TemplateStringsError{Strings: p.templateStrings}
+	default:
+		for _, template := range p.templateStrings {
+			p.schema.Warnings = append(p.schema.Warnings, fmt.Sprintf("%s, typed as an opaque string (mark it %s if the chart renders it with tpl)", template, TemplateMarker))
+		}
+	}
+	return nil
+}
+
+// templateItems reports whether a list of scalars is a list of opaque strings,
+// checking each of its items like templateValue
+func (p *Parser) templateItems(sequenceNode *yaml.Node, marked bool, valuesPath string) (bool, error) {
+	template := false
+	for _, item := range sequenceNode.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		value, err := scalarValue(item)
+		if err != nil {
+			return false, fmt.Errorf("failed to decode list element: %w", err)
+		}
+		isTemplate, err := p.templateValue(value, marked, valuesPath+"[]", item.Line)
+		if err != nil {
+			return false, err
+		}
+		template = template || isTemplate
+	}
+	return template, nil
+}
@@ -0,0 +1,125 @@
+package parsing
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const templatesYAML = `apiVersion: example.com/v1
+kind: Example
+servicePort: "{{ .Values.global.port }}"
+# +miaka:template
+configName: "{{ .Release.Name }}-config"
+timeout: 30s
+args:
+  - --verbose
+  - "--name={{ .Release.Name }}"
+env:
+  - name: HOST
+    value: "{{ include \"app.fullname\" . }}"
+`
+
+func TestIsTemplateString(t *testing.T) {
+	for s, want := range map[string]bool{
+		"{{ .Release.Name }}":         true,
+		"prefix-{{.Values.x}}-suffix": true,
+		"{{ unclosed":                 false,
+		"closed }} {{":                false,
+		"plain":                       false,
+		"":                            false,
+	} {
+		if got := IsTemplateString(s); got != want {
+			t.Errorf("IsTemplateString(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestParse_TemplateStringsWarn(t *testing.T) {
+	s, err := NewParserWithOptions(Options{InferSemanticTypes: true}).Parse([]byte(templatesYAML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Template expressions are opaque strings, even where a semantic type would be inferred
+	for _, path := range [][]string{{"servicePort"}, {"configName"}} {
+		field, ok := s.FieldAt(path)
+		if !ok || field.Type != "string" {
+			t.Errorf("Expected %v to be a string, got %+v", path, field)
+		}
+		for _, comment := range field.Comments {
+			if comment == TemplateMarker {
+				t.Errorf("Expected the %s marker to be removed from %v", TemplateMarker, path)
+			}
+		}
+	}
+	if timeout, _ := s.FieldAt([]string{"timeout"}); timeout.Type != "metav1.Duration" {
+		t.Errorf("Expected other values to be inferred as usual, got %+v", timeout)
+	}
+	if args, _ := s.FieldAt([]string{"args"}); args.Type != "[]string" {
+		t.Errorf("Expected a list of strings, got %+v", args)
+	}
+
+	want := []string{
+		"line 3: servicePort is a Helm template expression, typed as an opaque string (mark it +miaka:template if the chart renders it with tpl)",
+		"line 9: args[] is a Helm template expression, typed as an opaque string (mark it +miaka:template if the chart renders it with tpl)",
+		"line 12: env[].value is a Helm template expression, typed as an opaque string (mark it +miaka:template if the chart renders it with tpl)",
+	}
+	if !reflect.DeepEqual(s.Warnings, want) {
+		t.Errorf("Expected warnings for the unmarked template expressions:\n%q\ngot:\n%q", want, s.Warnings)
+	}
+}
+
+func TestParse_TemplateStringsAllow(t *testing.T) {
+	s, err := NewParserWithOptions(Options{TemplateStrings: TemplatesAllow}).Parse([]byte(templatesYAML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(s.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got: %v", s.Warnings)
+	}
+}
+
+func TestParse_TemplateStringsError(t *testing.T) {
+	_, err := NewParserWithOptions(Options{TemplateStrings: TemplatesError}).Parse([]byte(templatesYAML))
+	var templatesErr *TemplateStringsError
+	if !errors.As(err, &templatesErr) {
+		t.Fatalf("Expected a TemplateStringsError, got: %v", err)
+	}
+	if len(templatesErr.Strings) != 3 || templatesErr.Strings[0] != (TemplateString{Path: "servicePort", Line: 3}) {
+		t.Errorf("Expected the three unmarked template expressions, got %v", templatesErr.Strings)
+	}
+	if !strings.HasPrefix(err.Error(), "3 value(s) are Helm template expressions (mark them +miaka:template") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParse_TemplateMarkerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"number", "# +miaka:template\nreplicas: 3\n", "field replicas: +miaka:template only applies to strings, got 3"},
+		{"object", "# +miaka:template\nimage:\n  tag: latest\n", "field image: +miaka:template only applies to strings and lists of strings"},
+		{"list of numbers", "# +miaka:template\nports: [80, 443]\n", "field ports: +miaka:template only applies to strings, got 80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParserWithOptions(Options{Plain: true}).Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseTemplateStringPolicy(t *testing.T) {
+	if policy, err := ParseTemplateStringPolicy(""); err != nil || policy != TemplatesWarn {
+		t.Errorf("Expected warn by default, got %q, %v", policy, err)
+	}
+	if _, err := ParseTemplateStringPolicy("ignore"); err == nil || !strings.Contains(err.Error(), "invalid template string policy") {
+		t.Errorf("Expected an invalid policy error, got: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package schema
+
+import "sort"
+
+// ValueFormat is a common shape of value (e.g., an image reference) that a
+// +miaka:format: marker expands to validation markers
+type ValueFormat struct {
+	// Type is the Go type of the values: TypeString, or TypeInt for ports, whose
+	// bounds make a format (e.g., int32) unnecessary
+	Type FieldType
+	// Markers are the kubebuilder:validation markers checking the shape, without
+	// their prefix (e.g., "Maximum=65535")
+	Markers []string
+}
+
+// ImageRefPattern matches container image references (e.g.,
+// "ghcr.io/org/app:v1.2.3" or "nginx@sha256:..."), as the distribution
+// reference grammar defines them
+const ImageRefPattern = `^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?/)?[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(@[a-zA-Z][a-zA-Z0-9]*([-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?$`
+
+// CIDRPattern matches IPv4 and IPv6 CIDRs (e.g., "10.0.0.0/8" or "fd00::/64")
+const CIDRPattern = `^((((25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])/(3[0-2]|[12]?[0-9]))|(([0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}/(12[0-8]|1[01][0-9]|[1-9]?[0-9])))$`
+
+// ValueFormats are the shapes of values +miaka:format: accepts besides number
+// formats, by name
+var ValueFormats = map[string]ValueFormat{
+	"imageref": {Type: TypeString, Markers: []string{"Pattern=`" + ImageRefPattern + "`"}},
+	"duration": {Type: TypeString, Markers: []string{"Pattern=`" + DurationPattern + "`"}},
+	"cidr":     {Type: TypeString, Markers: []string{"Pattern=`" + CIDRPattern + "`", "MaxLength=43"}},
+	"port":     {Type: TypeInt, Markers: []string{"Minimum=1", "Maximum=65535"}},
+}
+
+// ValueFormatNames returns the names of the ValueFormats in order
+func ValueFormatNames() []string {
+	names := make([]string, 0, len(ValueFormats))
+	for name := range ValueFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestValueFormatPatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{ImageRefPattern, "nginx", true},
+		{ImageRefPattern, "nginx:1.25-alpine", true},
+		{ImageRefPattern, "ghcr.io/org/app:v1.2.3", true},
+		{ImageRefPattern, "localhost:5000/team/app_name", true},
+		{ImageRefPattern, "registry.k8s.io/pause@sha256:7031c1b283388d2c2e09b57badb803c05ebed362dc88d84b480cc47f72a21097", true},
+		{ImageRefPattern, "Nginx", false},
+		{ImageRefPattern, "nginx:", false},
+		{ImageRefPattern, "nginx@sha256:abc", false},
+		{ImageRefPattern, "ghcr.io/org/app:v1 2", false},
+		{CIDRPattern, "10.0.0.0/8", true},
+		{CIDRPattern, "192.168.1.0/24", true},
+		{CIDRPattern, "0.0.0.0/0", true},
+		{CIDRPattern, "fd00::/64", true},
+		{CIDRPattern, "2001:db8:0:0:0:0:0:1/128", true},
+		{CIDRPattern, "10.0.0.0", false},
+		{CIDRPattern, "10.0.0.256/8", false},
+		{CIDRPattern, "10.0.0.0/33", false},
+		{CIDRPattern, "fd00::/129", false},
+	}
+
+	for _, tt := range tests {
+		if got := regexp.MustCompile(tt.pattern).MatchString(tt.value); got != tt.want {
+			t.Errorf("pattern match of %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValueFormatNames(t *testing.T) {
+	want := []string{"cidr", "duration", "imageref", "port"}
+	if got := ValueFormatNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValueFormatNames() = %v, want %v", got, want)
+	}
+}
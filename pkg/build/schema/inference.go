@@ -3,23 +3,28 @@ package schema
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"golang.org/x/text/unicode/norm"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // InferType determines the Go type from a YAML value
 func InferType(value interface{}) string {
 	switch v := value.(type) {
-	case int, int64:
-		return "int"
+	case int:
+		return intType(int64(v))
+	case int64:
+		return intType(v)
 	case float64:
 		// Check if it's actually an integer
-		if v == float64(int(v)) {
-			return "int"
+		if v == float64(int64(v)) {
+			return intType(int64(v))
 		}
-		return "float64"
+		return string(TypeFloat64)
 	case string:
 		return "string"
 	case bool:
@@ -29,60 +34,96 @@ func InferType(value interface{}) string {
 	}
 }
 
+// intType returns the Go type of an integer: int in the int32 range, which the
+// CRD leaves without a format, and int64 beyond it
+func intType(v int64) string {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return string(TypeInt64)
+	}
+	return string(TypeInt)
+}
+
 // ToPascalCase converts a camelCase or snake_case string to PascalCase
-// Also sanitizes invalid Go identifier characters (., /, :, etc.)
+// Also sanitizes invalid Go identifier characters (see PascalCaseName)
 func ToPascalCase(s string) string {
-	if s == "" {
-		return ""
-	}
+	name, _ := PascalCaseName(s)
+	return name
+}
 
-	// Split on common separators: _, -, ., /, :
-	parts := strings.FieldsFunc(s, func(r rune) bool {
-		return r == '_' || r == '-' || r == '.' || r == '/' || r == ':'
-	})
+// nameSeparators split the words of keys, which are joined in PascalCase
+const nameSeparators = "_-./:"
 
-	if len(parts) == 0 {
-		// Shouldn't happen but handle gracefully
-		return sanitizeIdentifier(s)
-	}
+// transliterations are ASCII spellings of letters that don't decompose into
+// a letter and diacritics
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "TH", 'ð': "d", 'Ð': "D",
+}
 
-	// Convert each part to title case
-	var result strings.Builder
-	for _, part := range parts {
-		if part == "" {
-			continue
-		}
-		runes := []rune(part)
+// PascalCaseName converts a key to an exported Go identifier in PascalCase,
+// splitting words on _, -, ., /, and :. It reports whether the key was renamed
+// beyond that, because it isn't a valid identifier otherwise:
+//   - letters with diacritics are transliterated (e.g., "café" -> "Cafe")
+//   - other non-ASCII letters and symbols become their code points (e.g., "🚀" -> "U1F680")
+//   - other characters (e.g., spaces) separate words
+//   - names starting with a digit (or empty names) are prefixed with X
+func PascalCaseName(s string) (string, bool) {
+	if isASCIIWord(s) {
+		// Most keys are camelCase already
+		return strings.ToUpper(s[:1]) + s[1:], false
+	}
+	var result, word strings.Builder
+	renamed := false
+	endWord := func() {
+		runes := []rune(word.String())
 		if len(runes) > 0 {
 			runes[0] = unicode.ToUpper(runes[0])
 			result.WriteString(string(runes))
 		}
+		word.Reset()
 	}
-
-	resultStr := result.String()
-	if resultStr == "" {
-		// Fallback if all parts were empty
-		return sanitizeIdentifier(s)
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+		case strings.ContainsRune(nameSeparators, r):
+			endWord()
+		case unicode.Is(unicode.Mn, r):
+			// Diacritics of decomposed letters
+			renamed = true
+		case transliterations[r] != "":
+			word.WriteString(transliterations[r])
+			renamed = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSymbol(r):
+			endWord()
+			fmt.Fprintf(&result, "U%04X", r)
+			renamed = true
+		default:
+			endWord()
+			renamed = true
+		}
 	}
+	endWord()
 
-	return resultStr
+	name := result.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		return "X" + name, true
+	}
+	return name, renamed
 }
 
-// sanitizeIdentifier removes or replaces invalid Go identifier characters
-func sanitizeIdentifier(s string) string {
-	// Replace common invalid chars with nothing
-	s = strings.ReplaceAll(s, ".", "")
-	s = strings.ReplaceAll(s, "/", "")
-	s = strings.ReplaceAll(s, ":", "")
-	s = strings.ReplaceAll(s, "-", "")
-
-	// Capitalize first letter
-	runes := []rune(s)
-	if len(runes) > 0 {
-		runes[0] = unicode.ToUpper(runes[0])
+// isASCIIWord reports whether s is ASCII letters and digits, starting with a letter
+func isASCIIWord(s string) bool {
+	if s == "" {
+		return false
 	}
-
-	return string(runes)
+	for i, c := range []byte(s) {
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
 }
 
 // GenerateStructName creates a struct name from a field name
@@ -20,11 +20,26 @@ func TestInferType(t *testing.T) {
 			value:    int64(42),
 			expected: "int",
 		},
+		{
+			name:     "int beyond int32",
+			value:    3000000000,
+			expected: "int64",
+		},
+		{
+			name:     "negative int beyond int32",
+			value:    int64(-3000000000),
+			expected: "int64",
+		},
 		{
 			name:     "float64 that is actually int",
 			value:    float64(42),
 			expected: "int",
 		},
+		{
+			name:     "float64 that is an int beyond int32",
+			value:    float64(1e10),
+			expected: "int64",
+		},
 		{
 			name:     "float64",
 			value:    3.14,
@@ -121,7 +136,7 @@ func TestToPascalCase(t *testing.T) {
 		{
 			name:     "empty string",
 			input:    "",
-			expected: "",
+			expected: "X",
 		},
 		{
 			name:     "multiple separators in a row",
@@ -133,6 +148,36 @@ func TestToPascalCase(t *testing.T) {
 			input:    "example.com/v1alpha1",
 			expected: "ExampleComV1alpha1",
 		},
+		{
+			name:     "diacritics",
+			input:    "café_größe",
+			expected: "CafeGrosse",
+		},
+		{
+			name:     "emoji",
+			input:    "🚀launch",
+			expected: "U1F680Launch",
+		},
+		{
+			name:     "non-Latin letters",
+			input:    "名前",
+			expected: "U540DU524D",
+		},
+		{
+			name:     "spaces",
+			input:    "max size",
+			expected: "MaxSize",
+		},
+		{
+			name:     "leading digit",
+			input:    "1password",
+			expected: "X1password",
+		},
+		{
+			name:     "only separators",
+			input:    "--",
+			expected: "X",
+		},
 	}
 
 	for _, tt := range tests {
@@ -145,6 +190,24 @@ func TestToPascalCase(t *testing.T) {
 	}
 }
 
+func TestPascalCaseName(t *testing.T) {
+	for key, wantRenamed := range map[string]bool{
+		"image":           false,
+		"example.com/v1":  false,
+		"max_size":        false,
+		"café":            true,
+		"🚀":               true,
+		"max size":        true,
+		"1password":       true,
+		"":                true,
+		"kubernetes.io/x": false,
+	} {
+		if _, renamed := PascalCaseName(key); renamed != wantRenamed {
+			t.Errorf("PascalCaseName(%q) renamed = %v, want %v", key, renamed, wantRenamed)
+		}
+	}
+}
+
 func TestGenerateStructName(t *testing.T) {
 	tests := []struct {
 		name     string
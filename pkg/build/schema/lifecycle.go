@@ -0,0 +1,153 @@
+package schema
+
+import "strings"
+
+// Path segments of Rename and Deprecation for the items of a list and the values of a map
+const (
+	ItemsSegment  = "[]"
+	ValuesSegment = "*"
+)
+
+// Rename is a field of the values renamed with +miaka:renamedFrom
+type Rename struct {
+	// Path holds the JSON names of the objects holding the field, from the top
+	// level, with ItemsSegment and ValuesSegment for list items and map values
+	Path []string
+	From string // Old JSON name
+	To   string // New JSON name
+}
+
+// Deprecation is a field of the values marked +miaka:deprecated
+type Deprecation struct {
+	// Path holds the JSON names of the objects holding the field, like in Rename
+	Path    []string
+	Name    string // JSON name
+	Message string // What to use instead (e.g., "use image.repository instead")
+}
+
+// Renames returns the renamed fields of the values, parents first
+func (s *Schema) Renames() []Rename {
+	var renames []Rename
+	s.walkFields(func(path []string, field Field) {
+		if field.RenamedFrom != "" {
+			renames = append(renames, Rename{Path: path, From: field.RenamedFrom, To: field.JSONName})
+		}
+	})
+	return renames
+}
+
+// Deprecations returns the deprecated fields of the values, parents first
+func (s *Schema) Deprecations() []Deprecation {
+	var deprecations []Deprecation
+	s.walkFields(func(path []string, field Field) {
+		if field.Deprecated != "" {
+			deprecations = append(deprecations, Deprecation{Path: path, Name: field.JSONName, Message: field.Deprecated})
+		}
+	})
+	return deprecations
+}
+
+// walkFields calls fn with each field of the values and the path of the object
+// holding it, the fields of an object before those of the objects in them
+func (s *Schema) walkFields(fn func(path []string, field Field)) {
+	structs := make(map[string]StructDef, len(s.Structs))
+	for _, structDef := range s.Structs {
+		structs[structDef.Name] = structDef
+	}
+	types := make(map[string]string, len(s.Types))
+	for _, typeDef := range s.Types {
+		types[typeDef.Name] = typeDef.Type
+	}
+	walkStruct(s.Kind, nil, structs, types, map[string]bool{}, fn)
+}
+
+// walkStruct calls fn with the fields of a struct at path, and those of the
+// structs in its fields. seen holds the structs being walked, to stop at
+// recursive types.
+func walkStruct(typeName string, path []string, structs map[string]StructDef, types map[string]string, seen map[string]bool, fn func(path []string, field Field)) {
+	structDef, ok := structs[typeName]
+	if !ok || seen[typeName] {
+		return
+	}
+	seen[typeName] = true
+	defer delete(seen, typeName)
+
+	for _, field := range structDef.Fields {
+		fn(path, field)
+	}
+	for _, field := range structDef.Fields {
+		fieldPath := append(append([]string{}, path...), field.JSONName)
+		elemType, segments := elemPath(field.Type, types)
+		walkStruct(elemType, append(fieldPath, segments...), structs, types, seen, fn)
+	}
+}
+
+// elemPath returns the name of the type held by a Go type, and the path segments
+// of the lists and maps it's in
+func elemPath(typeName string, types map[string]string) (string, []string) {
+	var segments []string
+	for range len(types) + 1 {
+		for {
+			switch {
+			case strings.HasPrefix(typeName, "*"):
+				typeName = strings.TrimPrefix(typeName, "*")
+				continue
+			case strings.HasPrefix(typeName, "[]"):
+				typeName = strings.TrimPrefix(typeName, "[]")
+				segments = append(segments, ItemsSegment)
+				continue
+			case strings.HasPrefix(typeName, "map[string]"):
+				typeName = strings.TrimPrefix(typeName, "map[string]")
+				segments = append(segments, ValuesSegment)
+				continue
+			}
+			break
+		}
+		underlying, ok := types[typeName]
+		if !ok {
+			break
+		}
+		typeName = underlying
+	}
+	return typeName, segments
+}
+
+// FieldAt returns the field at a path of the values, whose segments are JSON
+// names, list indexes, and map keys (e.g., ["containers", "0", "image"])
+func (s *Schema) FieldAt(path []string) (Field, bool) {
+	structs := make(map[string]StructDef, len(s.Structs))
+	for _, structDef := range s.Structs {
+		structs[structDef.Name] = structDef
+	}
+	types := make(map[string]string, len(s.Types))
+	for _, typeDef := range s.Types {
+		types[typeDef.Name] = typeDef.Type
+	}
+
+	var field Field
+	typeName, found := s.Kind, false
+	for i := 0; i < len(path); i++ {
+		structDef, ok := structs[typeName]
+		if !ok {
+			return Field{}, false
+		}
+		found = false
+		for _, f := range structDef.Fields {
+			if f.JSONName == path[i] {
+				field, found = f, true
+				break
+			}
+		}
+		if !found {
+			return Field{}, false
+		}
+		// List indexes and map keys select the elements of the field
+		var segments []string
+		typeName, segments = elemPath(field.Type, types)
+		if i+len(segments) >= len(path) {
+			break
+		}
+		i += len(segments)
+	}
+	return field, found
+}
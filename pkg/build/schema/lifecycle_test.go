@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenamesAndDeprecations(t *testing.T) {
+	s := Schema{
+		Kind: "App",
+		Structs: []StructDef{
+			{Name: "App", Fields: []Field{
+				{JSONName: "picture", RenamedFrom: "image", Type: "*ImageConfig"},
+				{JSONName: "containers", Type: "[]ContainersConfig"},
+				{JSONName: "ports", Type: "PortsValue"},
+				{JSONName: "node", Type: "NodeConfig"},
+			}},
+			{Name: "ImageConfig", Fields: []Field{
+				{JSONName: "repository", RenamedFrom: "repo", Type: "string"},
+			}},
+			{Name: "ContainersConfig", Fields: []Field{
+				{JSONName: "image", Type: "ImageConfig"},
+			}},
+			{Name: "PortConfig", Fields: []Field{
+				{JSONName: "containerPort", RenamedFrom: "port", Type: "int", Deprecated: "use targetPort instead"},
+			}},
+			{Name: "NodeConfig", Fields: []Field{
+				{JSONName: "children", Type: "[]NodeConfig"},
+			}},
+		},
+		Types: []TypeDef{
+			{Name: "PortsValue", Type: "map[string]PortConfig"},
+		},
+	}
+
+	want := []Rename{
+		{Path: nil, From: "image", To: "picture"},
+		{Path: []string{"picture"}, From: "repo", To: "repository"},
+		{Path: []string{"containers", ItemsSegment, "image"}, From: "repo", To: "repository"},
+		{Path: []string{"ports", ValuesSegment}, From: "port", To: "containerPort"},
+	}
+	if renames := s.Renames(); !reflect.DeepEqual(renames, want) {
+		t.Errorf("Renames() = %v, want %v", renames, want)
+	}
+
+	wantDeprecations := []Deprecation{{Path: []string{"ports", ValuesSegment}, Name: "containerPort", Message: "use targetPort instead"}}
+	if deprecations := s.Deprecations(); !reflect.DeepEqual(deprecations, wantDeprecations) {
+		t.Errorf("Deprecations() = %v, want %v", deprecations, wantDeprecations)
+	}
+}
+
+func TestPropertyOrder_RenamedFrom(t *testing.T) {
+	s := Schema{
+		Kind:  "App",
+		Plain: true,
+		Structs: []StructDef{
+			{Name: "App", Fields: []Field{
+				{JSONName: "picture", RenamedFrom: "image", Type: "ImageConfig"},
+				{JSONName: "replicas", Type: "int"},
+			}},
+			{Name: "ImageConfig", Fields: []Field{
+				{JSONName: "tag", Type: "string"},
+			}},
+		},
+	}
+
+	// The deprecated alias follows the new name, and holds the same properties
+	order := s.PropertyOrder()
+	if want := []string{"picture", "image", "replicas"}; !reflect.DeepEqual(order.Names, want) {
+		t.Errorf("expected order %v, got %v", want, order.Names)
+	}
+	if order.Property("image") != order.Property("picture") {
+		t.Errorf("expected image to have the order of picture")
+	}
+}
+
+func TestFieldAt(t *testing.T) {
+	tests := []struct {
+		path []string
+		want string
+		ok   bool
+	}{
+		{path: []string{"replicas"}, want: "replicas", ok: true},
+		{path: []string{"image", "tag"}, want: "tag", ok: true},
+		{path: []string{"containers", "0"}, want: "containers", ok: true},
+		{path: []string{"containers", "1", "image", "repository"}, want: "repository", ok: true},
+		{path: []string{"ports", "http", "protocol"}, want: "protocol", ok: true},
+		{path: []string{"image", "missing"}},
+		{path: []string{"replicas", "nested"}},
+		{path: nil},
+	}
+	for _, tt := range tests {
+		field, ok := orderTestSchema.FieldAt(tt.path)
+		if ok != tt.ok || field.JSONName != tt.want {
+			t.Errorf("FieldAt(%v) = %q, %v, want %q, %v", tt.path, field.JSONName, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package schema
+
+import "strings"
+
+// KRMFields are the fields of every KRM resource, which come first in its schema
+var KRMFields = []string{"apiVersion", "kind", "metadata"}
+
+// PropertyOrder is the order of the properties of an object in the example
+// values, and of the objects nested in them
+type PropertyOrder struct {
+	// Names are the JSON names of the properties, in source order
+	Names []string
+	// Properties are the orders of the objects in each property, directly or in
+	// its list items or map values
+	Properties map[string]*PropertyOrder
+}
+
+// PropertyOrder returns the source order of the properties of the values. The
+// fields of KRM resources (apiVersion, kind, metadata) come first.
+func (s *Schema) PropertyOrder() *PropertyOrder {
+	structs := make(map[string]StructDef, len(s.Structs))
+	for _, structDef := range s.Structs {
+		structs[structDef.Name] = structDef
+	}
+	types := make(map[string]string, len(s.Types))
+	for _, typeDef := range s.Types {
+		types[typeDef.Name] = typeDef.Type
+	}
+
+	order := structOrder(s.Kind, structs, types, map[string]bool{})
+	if order == nil {
+		order = &PropertyOrder{}
+	}
+	if !s.Plain {
+		order.Names = append(append([]string{}, KRMFields...), order.Names...)
+	}
+	return order
+}
+
+// structOrder returns the order of the fields of a struct, or nil if typeName
+// isn't a struct. seen holds the structs being ordered, to stop at recursive types.
+func structOrder(typeName string, structs map[string]StructDef, types map[string]string, seen map[string]bool) *PropertyOrder {
+	typeName = elemTypeName(typeName, types)
+	structDef, ok := structs[typeName]
+	if !ok || seen[typeName] {
+		return nil
+	}
+	seen[typeName] = true
+	defer delete(seen, typeName)
+
+	order := &PropertyOrder{Properties: make(map[string]*PropertyOrder)}
+	for _, field := range structDef.Fields {
+		// Deprecated aliases of renamed fields follow their new names
+		names := []string{field.JSONName}
+		if field.RenamedFrom != "" {
+			names = append(names, field.RenamedFrom)
+		}
+		order.Names = append(order.Names, names...)
+		if nested := structOrder(field.Type, structs, types, seen); nested != nil {
+			for _, name := range names {
+				order.Properties[name] = nested
+			}
+		}
+	}
+	return order
+}
+
+// elemTypeName returns the name of the type of the objects a Go type holds,
+// through pointers, slices, maps, and named non-struct types
+func elemTypeName(typeName string, types map[string]string) string {
+	for range len(types) + 1 {
+		for {
+			trimmed := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(typeName, "*"), "[]"), "map[string]")
+			if trimmed == typeName {
+				break
+			}
+			typeName = trimmed
+		}
+		underlying, ok := types[typeName]
+		if !ok {
+			break
+		}
+		typeName = underlying
+	}
+	return typeName
+}
+
+// Sort returns keys in the order of the properties: the names in Names first,
+// then the other keys in the order they're given. A nil order keeps the keys.
+func (o *PropertyOrder) Sort(keys []string) []string {
+	if o == nil {
+		return keys
+	}
+	present := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		present[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	listed := make(map[string]bool, len(o.Names))
+	for _, name := range o.Names {
+		if present[name] && !listed[name] {
+			sorted = append(sorted, name)
+			listed[name] = true
+		}
+	}
+	for _, key := range keys {
+		if !listed[key] {
+			sorted = append(sorted, key)
+		}
+	}
+	return sorted
+}
+
+// Property returns the order of the objects in a property, or nil if there is none
+func (o *PropertyOrder) Property(name string) *PropertyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.Properties[name]
+}
@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+// orderTestSchema has nested structs in a list, a map, and a named map type
+var orderTestSchema = Schema{
+	APIVersion: "example.com/v1",
+	Kind:       "App",
+	Structs: []StructDef{
+		{Name: "App", Fields: []Field{
+			{JSONName: "replicas", Type: "int"},
+			{JSONName: "image", Type: "*ImageConfig"},
+			{JSONName: "containers", Type: "[]ContainersConfig"},
+			{JSONName: "ports", Type: "PortsValue"},
+		}},
+		{Name: "ImageConfig", Fields: []Field{
+			{JSONName: "tag", Type: "string"},
+			{JSONName: "repository", Type: "string"},
+		}},
+		{Name: "ContainersConfig", Fields: []Field{
+			{JSONName: "name", Type: "string"},
+			{JSONName: "image", Type: "ImageConfig"},
+		}},
+		{Name: "PortConfig", Fields: []Field{
+			{JSONName: "port", Type: "int"},
+			{JSONName: "protocol", Type: "string"},
+		}},
+	},
+	Types: []TypeDef{
+		{Name: "PortsValue", Type: "map[string]PortConfig"},
+	},
+}
+
+func TestPropertyOrder(t *testing.T) {
+	order := orderTestSchema.PropertyOrder()
+
+	if want := []string{"apiVersion", "kind", "metadata", "replicas", "image", "containers", "ports"}; !reflect.DeepEqual(order.Names, want) {
+		t.Errorf("expected root order %v, got %v", want, order.Names)
+	}
+	if want := []string{"tag", "repository"}; !reflect.DeepEqual(order.Property("image").Names, want) {
+		t.Errorf("expected image order %v, got %v", want, order.Property("image").Names)
+	}
+	if want := []string{"tag", "repository"}; !reflect.DeepEqual(order.Property("containers").Property("image").Names, want) {
+		t.Errorf("expected containers[].image order %v, got %v", want, order.Property("containers").Property("image").Names)
+	}
+	if want := []string{"port", "protocol"}; !reflect.DeepEqual(order.Property("ports").Names, want) {
+		t.Errorf("expected ports order %v, got %v", want, order.Property("ports").Names)
+	}
+	if order.Property("replicas") != nil {
+		t.Errorf("expected no order for a scalar property")
+	}
+
+	plain := orderTestSchema
+	plain.Plain = true
+	if names := plain.PropertyOrder().Names; names[0] != "replicas" {
+		t.Errorf("expected plain values to have no KRM fields, got %v", names)
+	}
+}
+
+func TestPropertyOrder_Recursive(t *testing.T) {
+	s := Schema{Kind: "Tree", Plain: true, Structs: []StructDef{
+		{Name: "Tree", Fields: []Field{{JSONName: "node", Type: "NodeConfig"}}},
+		{Name: "NodeConfig", Fields: []Field{{JSONName: "value", Type: "int"}, {JSONName: "children", Type: "[]NodeConfig"}}},
+	}}
+
+	order := s.PropertyOrder()
+	if order.Property("node").Property("children") != nil {
+		t.Errorf("expected recursion to stop at the recursive type")
+	}
+}
+
+func TestPropertyOrder_Sort(t *testing.T) {
+	order := &PropertyOrder{Names: []string{"replicas", "image", "missing"}}
+
+	got := order.Sort([]string{"apiVersion", "image", "other", "replicas"})
+	if want := []string{"replicas", "image", "apiVersion", "other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	var none *PropertyOrder
+	if got := none.Sort([]string{"b", "a"}); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("expected a nil order to keep the keys, got %v", got)
+	}
+	if none.Property("a") != nil {
+		t.Errorf("expected a nil order to have no properties")
+	}
+}
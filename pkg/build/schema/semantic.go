@@ -0,0 +1,123 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Semantic types are Kubernetes types for strings with a meaning (e.g., "512Mi"),
+// which the CRD validates beyond their type
+const (
+	TypeQuantity    FieldType = "resource.Quantity"
+	TypeDuration    FieldType = "metav1.Duration"
+	TypeIntOrString FieldType = "intstr.IntOrString"
+)
+
+// DurationPattern matches the Go durations metav1.Duration accepts (e.g., "72h" or "1h30m").
+// controller-gen doesn't validate durations, so the parser adds it as a Pattern marker.
+const DurationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// semanticTypeNames are the +miaka:type names of the semantic types
+var semanticTypeNames = map[string]FieldType{
+	"quantity":      TypeQuantity,
+	"duration":      TypeDuration,
+	"int-or-string": TypeIntOrString,
+}
+
+var (
+	// binaryQuantityPattern matches quantities with a binary suffix (e.g., "512Mi"), which are never anything else
+	binaryQuantityPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)[KMGTPE]i$`)
+	// decimalQuantityPattern matches quantities with a decimal suffix (e.g., "500m" or "2G")
+	decimalQuantityPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)[numkMGTPE]$`)
+	durationPattern        = regexp.MustCompile(DurationPattern)
+	// minutesPattern matches durations in minutes (e.g., "5m"), which look like milli-quantities
+	minutesPattern = regexp.MustCompile(`^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)m$`)
+	// percentPattern matches percentages (e.g., "25%"), which Kubernetes accepts in place of counts
+	percentPattern = regexp.MustCompile(`^[0-9]+%$`)
+)
+
+// quantityKeys are the field name suffixes of decimal quantities
+var quantityKeys = []string{"cpu", "memory", "storage", "size"}
+
+// durationKeys are the field name parts of durations in minutes
+var durationKeys = []string{"timeout", "interval", "period", "duration", "ttl", "delay", "deadline", "backoff", "every", "age", "wait", "retention"}
+
+// SemanticType returns the Go type of a semantic type name (quantity, duration, or
+// int-or-string) used in +miaka:type hints
+func SemanticType(name string) (FieldType, bool) {
+	fieldType, ok := semanticTypeNames[strings.ToLower(strings.TrimSpace(name))]
+	return fieldType, ok
+}
+
+// ResolveTypeHint replaces a semantic type name in a type hint with its Go type,
+// including in list and map hints (e.g., "[]quantity" -> "[]resource.Quantity")
+func ResolveTypeHint(hint string) string {
+	prefix := ""
+	base := hint
+	for _, p := range []string{"[]", "map[string]"} {
+		if strings.HasPrefix(base, p) {
+			prefix, base = p, strings.TrimPrefix(base, p)
+			break
+		}
+	}
+	if fieldType, ok := SemanticType(base); ok {
+		return prefix + string(fieldType)
+	}
+	return hint
+}
+
+// IsSemanticType reports whether a Go type is one of the semantic types
+func IsSemanticType(goType string) bool {
+	switch FieldType(goType) {
+	case TypeQuantity, TypeDuration, TypeIntOrString:
+		return true
+	}
+	return false
+}
+
+// InferSemanticType guesses the semantic type of a string value from the value and
+// its field name, or returns "" if it looks like a plain string:
+//   - quantities with a binary suffix ("512Mi"), or a decimal suffix ("500m") in
+//     fields named like cpu, memory, storage, or size
+//   - durations ("72h"), except minutes ("5m") in fields not named like timeouts
+//     or intervals, which are ambiguous with milli-quantities
+//   - percentages ("25%") and named ports ("http" in a field ending with port) as
+//     int-or-string
+func InferSemanticType(key string, value interface{}) FieldType {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return ""
+	}
+	lower := strings.ToLower(key)
+	switch {
+	case binaryQuantityPattern.MatchString(s):
+		return TypeQuantity
+	case decimalQuantityPattern.MatchString(s) && hasAnySuffix(lower, quantityKeys):
+		return TypeQuantity
+	case durationPattern.MatchString(s) && s != "0" && (!minutesPattern.MatchString(s) || containsAny(lower, durationKeys)):
+		return TypeDuration
+	case percentPattern.MatchString(s), strings.HasSuffix(lower, "port"):
+		return TypeIntOrString
+	}
+	return ""
+}
+
+// hasAnySuffix reports whether s ends with one of suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether s contains one of parts
+func containsAny(s string, parts []string) bool {
+	for _, part := range parts {
+		if strings.Contains(s, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSemanticType(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected FieldType
+		ok       bool
+	}{
+		{"quantity", TypeQuantity, true},
+		{"duration", TypeDuration, true},
+		{"int-or-string", TypeIntOrString, true},
+		{" Quantity ", TypeQuantity, true},
+		{"string", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SemanticType(tt.name)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("SemanticType(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResolveTypeHint(t *testing.T) {
+	tests := map[string]string{
+		"quantity":              "resource.Quantity",
+		"[]duration":            "[]metav1.Duration",
+		"map[string]quantity":   "map[string]resource.Quantity",
+		"int-or-string":         "intstr.IntOrString",
+		"map[string]string":     "map[string]string",
+		"[]string":              "[]string",
+		"int":                   "int",
+		"map[string][]quantity": "map[string][]quantity",
+	}
+
+	for hint, expected := range tests {
+		if got := ResolveTypeHint(hint); got != expected {
+			t.Errorf("ResolveTypeHint(%q) = %q, want %q", hint, got, expected)
+		}
+	}
+}
+
+func TestInferSemanticType(t *testing.T) {
+	tests := []struct {
+		key      string
+		value    interface{}
+		expected FieldType
+	}{
+		{"memory", "512Mi", TypeQuantity},
+		{"volume", "10Gi", TypeQuantity},
+		{"cpu", "500m", TypeQuantity},
+		{"cacheSize", "2G", TypeQuantity},
+		{"name", "2G", ""},
+		{"timeout", "30s", TypeDuration},
+		{"anything", "1h30m", TypeDuration},
+		{"syncInterval", "5m", TypeDuration},
+		{"name", "5m", ""},
+		{"name", "0", ""},
+		{"maxSurge", "25%", TypeIntOrString},
+		{"targetPort", "http", TypeIntOrString},
+		{"port", 8080, ""},
+		{"name", "hello", ""},
+		{"memory", 512, ""},
+		{"timeout", "", ""},
+	}
+
+	for _, tt := range tests {
+		got := InferSemanticType(tt.key, tt.value)
+		if got != tt.expected {
+			t.Errorf("InferSemanticType(%q, %v) = %q, want %q", tt.key, tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestDurationPattern(t *testing.T) {
+	pattern := regexp.MustCompile(DurationPattern)
+	for _, valid := range []string{"0", "72h", "1h30m", "1.5s", "300ms", "-5m", "10µs"} {
+		if !pattern.MatchString(valid) {
+			t.Errorf("Expected %q to match", valid)
+		}
+	}
+	for _, invalid := range []string{"", "5", "1d", "h", "PT72H", "1h 30m"} {
+		if pattern.MatchString(invalid) {
+			t.Errorf("Expected %q not to match", invalid)
+		}
+	}
+}
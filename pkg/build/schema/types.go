@@ -6,10 +6,15 @@ type FieldType string
 // Field type constants
 const (
 	TypeInt       FieldType = "int"
+	TypeInt32     FieldType = "int32"
+	TypeInt64     FieldType = "int64"
+	TypeFloat32   FieldType = "float32"
 	TypeFloat64   FieldType = "float64"
 	TypeString    FieldType = "string"
 	TypeBool      FieldType = "bool"
 	TypeInterface FieldType = "interface{}"
+	// TypeFreeform holds values of any shape, which the CRD keeps without a schema
+	TypeFreeform FieldType = "runtime.RawExtension"
 )
 
 // Field represents a single field in a struct
@@ -22,6 +27,14 @@ type Field struct {
 	ElemType string   // Element type if IsSlice is true
 	YAMLPath string   // Path in YAML (e.g., "global.imagePullSecrets")
 	Line     int      // Line number in source YAML file
+	Nullable bool     // Whether null is a valid value (a pointer type in Go)
+	// JSON name of the field before it was renamed, if it was (+miaka:renamedFrom)
+	RenamedFrom string
+	// What to use instead of the field, if it's deprecated (+miaka:deprecated)
+	Deprecated string
+	// Default documented for the field (helm-docs "@default -- "), which is
+	// only described, unlike a +kubebuilder:default
+	DocumentedDefault string
 }
 
 // StructDef represents a Go struct definition
@@ -31,10 +44,51 @@ type StructDef struct {
 	Fields   []Field  // Fields in the struct
 }
 
+// TypeDef represents a named non-struct Go type (e.g., the value type of a map
+// whose values have markers)
+type TypeDef struct {
+	Name     string   // Type name (PascalCase)
+	Type     string   // Underlying Go type
+	Comments []string // Comments for the type (including kubebuilder tags)
+}
+
 // Schema represents the complete parsed schema
 type Schema struct {
 	APIVersion string      // Kubernetes apiVersion
 	Kind       string      // Kubernetes kind
 	Package    string      // Go package name
 	Structs    []StructDef // All struct definitions
+	Types      []TypeDef   // Named non-struct type definitions
+	Plain      bool        // Whether the values have no apiVersion or kind (no CRD is published)
+	CRDMarkers []string    // Controller-gen markers for the main type (e.g., subresources and printer columns)
+	WrapSpec   bool        // Whether the main type holds the values in Spec, next to an empty Status
+	Warnings   []string    // Problems with the example values that don't stop the build
+}
+
+// Plain values are generated as KRM types internally, under PlainAPIVersion,
+// since the JSON Schema is derived from a CRD
+const (
+	// DefaultPlainTypeName is the default name of the type of plain values
+	DefaultPlainTypeName = "Values"
+	// PlainAPIVersion is the internal apiVersion of plain values, which never appears in outputs
+	PlainAPIVersion = "values.miaka.dev/v1"
+	// PlainPackage is the Go package name of the types of plain values
+	PlainPackage = "values"
+)
+
+// SpecTypeName returns the name of the type holding the values of a kind whose
+// values are wrapped in Spec
+func SpecTypeName(kind string) string {
+	return kind + "Spec"
+}
+
+// StatusTypeName returns the name of the status type of a kind whose values are
+// wrapped in Spec
+func StatusTypeName(kind string) string {
+	return kind + "Status"
+}
+
+// PlainDescription returns the description of the type of plain values
+func PlainDescription(typeName string) string {
+	return typeName + " is the schema of the chart's values"
 }
@@ -2,10 +2,16 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/structdiff"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/crdify/pkg/config"
@@ -16,24 +22,46 @@ import (
 // CheckBreakingChanges compares an existing CRD file with a newly generated CRD
 // and returns an error if breaking changes are detected.
 // If the old CRD file doesn't exist, no error is returned (first-time generation).
-func CheckBreakingChanges(oldCRDPath string, newCRDContent []byte) error {
+// Renamed fields are renamed in the old CRD first, with their new descriptions,
+// so they aren't reported as removed; their paths are relative to the root of
+// the CRD's schemas.
+func CheckBreakingChanges(oldCRDPath string, newCRDContent []byte, renames ...schema.Rename) error {
+	return CheckBreakingChangesFS(filesystem.OS(), oldCRDPath, newCRDContent, renames...)
+}
+
+// CheckBreakingChangesFS is CheckBreakingChanges for an existing CRD file in fsys
+func CheckBreakingChangesFS(fsys filesystem.FS, oldCRDPath string, newCRDContent []byte, renames ...schema.Rename) error {
 	// Check if old CRD exists
-	if _, err := os.Stat(oldCRDPath); os.IsNotExist(err) {
+	if _, err := fsys.Stat(oldCRDPath); errors.Is(err, fs.ErrNotExist) {
 		// No existing CRD, skip validation
 		return nil
 	}
 
 	// Load old CRD
-	oldCRD, err := loadCRDFromFile(oldCRDPath)
+	oldCRD, err := loadCRDFromFile(fsys, oldCRDPath)
 	if err != nil {
 		return fmt.Errorf("failed to load existing CRD from %s: %w", oldCRDPath, err)
 	}
+	return checkBreakingChanges(oldCRD, newCRDContent, renames)
+}
+
+// CheckBreakingChangesContent is CheckBreakingChanges for an existing CRD in memory
+func CheckBreakingChangesContent(oldCRDContent, newCRDContent []byte, renames ...schema.Rename) error {
+	oldCRD := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(oldCRDContent, oldCRD); err != nil {
+		return fmt.Errorf("failed to unmarshal existing CRD: %w", err)
+	}
+	return checkBreakingChanges(oldCRD, newCRDContent, renames)
+}
 
+// checkBreakingChanges compares an existing CRD with a newly generated CRD
+func checkBreakingChanges(oldCRD *apiextensionsv1.CustomResourceDefinition, newCRDContent []byte, renames []schema.Rename) error {
 	// Parse new CRD from content
 	newCRD := &apiextensionsv1.CustomResourceDefinition{}
 	if err := yaml.Unmarshal(newCRDContent, newCRD); err != nil {
 		return fmt.Errorf("failed to unmarshal new CRD: %w", err)
 	}
+	renameProperties(oldCRD, newCRD, renames)
 
 	// Create default config for crdify
 	cfg := &config.Config{
@@ -52,17 +80,79 @@ func CheckBreakingChanges(oldCRDPath string, newCRDContent []byte) error {
 
 	// Check for breaking changes (errors)
 	if results.HasFailures() {
-		// Format the results as plain text for error message
-		output := renderErrorsOnly(results)
-		return fmt.Errorf("breaking changes detected:\n%s", output)
+		changes := breakingChanges(results)
+		diffBreakingChanges(oldCRD, newCRD, changes)
+		return &BreakingChangesError{Changes: changes}
 	}
 
 	return nil
 }
 
+// renameProperties renames the renamed fields in the schemas of the old CRD's
+// versions that the new CRD still has
+func renameProperties(oldCRD, newCRD *apiextensionsv1.CustomResourceDefinition, renames []schema.Rename) {
+	for _, oldVersion := range oldCRD.Spec.Versions {
+		for _, newVersion := range newCRD.Spec.Versions {
+			if oldVersion.Name != newVersion.Name || oldVersion.Schema == nil || newVersion.Schema == nil ||
+				oldVersion.Schema.OpenAPIV3Schema == nil || newVersion.Schema.OpenAPIV3Schema == nil {
+				continue
+			}
+			for _, rename := range renames {
+				renameProperty(oldVersion.Schema.OpenAPIV3Schema, newVersion.Schema.OpenAPIV3Schema, rename)
+			}
+		}
+	}
+}
+
+// renameProperty renames a property of the old object schemas at the path of a
+// rename, and its entry in their required properties. The renamed property takes
+// the description of the new one, which changes with the name of its type.
+// Properties missing from either schema are skipped.
+func renameProperty(oldProps, newProps *apiextensionsv1.JSONSchemaProps, rename schema.Rename) {
+	if len(rename.Path) > 0 {
+		nested := rename
+		nested.Path = rename.Path[1:]
+		switch rename.Path[0] {
+		case schema.ItemsSegment:
+			if oldProps.Items != nil && oldProps.Items.Schema != nil && newProps.Items != nil && newProps.Items.Schema != nil {
+				renameProperty(oldProps.Items.Schema, newProps.Items.Schema, nested)
+			}
+		case schema.ValuesSegment:
+			if oldProps.AdditionalProperties != nil && oldProps.AdditionalProperties.Schema != nil &&
+				newProps.AdditionalProperties != nil && newProps.AdditionalProperties.Schema != nil {
+				renameProperty(oldProps.AdditionalProperties.Schema, newProps.AdditionalProperties.Schema, nested)
+			}
+		default:
+			// Properties are held by value, so the renamed copy is stored back
+			oldProperty, oldOK := oldProps.Properties[rename.Path[0]]
+			newProperty, newOK := newProps.Properties[rename.Path[0]]
+			if oldOK && newOK {
+				renameProperty(&oldProperty, &newProperty, nested)
+				oldProps.Properties[rename.Path[0]] = oldProperty
+			}
+		}
+		return
+	}
+
+	from, to := rename.From, rename.To
+	property, ok := oldProps.Properties[from]
+	newProperty, renamed := newProps.Properties[to]
+	if _, exists := oldProps.Properties[to]; !ok || !renamed || exists {
+		return
+	}
+	property.Description = newProperty.Description
+	delete(oldProps.Properties, from)
+	oldProps.Properties[to] = property
+	for i, required := range oldProps.Required {
+		if required == from {
+			oldProps.Required[i] = to
+		}
+	}
+}
+
 // loadCRDFromFile loads a CRD from a file path
-func loadCRDFromFile(filePath string) (*apiextensionsv1.CustomResourceDefinition, error) {
-	fileBytes, err := os.ReadFile(filePath)
+func loadCRDFromFile(fsys filesystem.FS, filePath string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	fileBytes, err := fsys.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -75,45 +165,170 @@ func loadCRDFromFile(filePath string) (*apiextensionsv1.CustomResourceDefinition
 	return crd, nil
 }
 
-// renderErrorsOnly renders only the validation errors, not all passing checks
-// Based on crdify's RenderPlainText but filtered to errors only
-func renderErrorsOnly(results *runner.Results) string {
-	var out strings.Builder
+// BreakingChange is a change of a CRD that breaks resources or clients of the
+// previous one, found by one of crdify's validations
+type BreakingChange struct {
+	// Version is the CRD version of the change, or "" for changes of the whole CRD
+	Version string
+	// Property is the changed property (e.g., "^.spec.ports[*].name"), or "" for
+	// changes of the whole CRD
+	Property string
+	// Validation is the name of the crdify validation that found the change (e.g., "type")
+	Validation string
+	Message    string
+	// Diff lists the keywords of the property's schema that changed (e.g.,
+	// "replicas: type changed integer -> string"), on the first change of
+	// each property
+	Diff []structdiff.Change
+}
 
-	// CRD Validations
-	renderCRDValidationErrors(&out, results.CRDValidation)
+// String renders the change as a line of the breaking change report
+func (c BreakingChange) String() string {
+	if c.Version == "" {
+		return fmt.Sprintf("%s - %s", c.Validation, c.Message)
+	}
+	return fmt.Sprintf("%s - %s - %s - %s", c.Version, c.Property, c.Validation, c.Message)
+}
 
-	// Same Version Validations
-	renderVersionValidationErrors(&out, results.SameVersionValidation)
+// Path returns the path of the changed property in a resource, with "*" for
+// list items and map values. Changes of the whole CRD that name a property,
+// like removed fields ("removed field : v1.^.spec.name"), return its path.
+func (c BreakingChange) Path() []string {
+	property := c.Property
+	if property == "" {
+		index := strings.Index(c.Message, "^")
+		if index < 0 {
+			return nil
+		}
+		property = c.Message[index:]
+	}
+	return FieldPath(strings.TrimPrefix(property, "^"))
+}
 
-	// Served Version Validations
-	renderVersionValidationErrors(&out, results.ServedVersionValidation)
+// BreakingChangesError is the error for breaking changes between two CRDs
+type BreakingChangesError struct {
+	Changes []BreakingChange
+}
 
+func (e *BreakingChangesError) Error() string {
+	var out strings.Builder
+	out.WriteString("breaking changes detected:\n")
+	for _, change := range e.Changes {
+		fmt.Fprintf(&out, "- %s\n", change)
+		for _, diff := range change.Diff {
+			fmt.Fprintf(&out, "    %s\n", diff)
+		}
+	}
 	return out.String()
 }
 
-// renderCRDValidationErrors renders CRD validation errors
-func renderCRDValidationErrors(out *strings.Builder, validationResults []validations.ComparisonResult) {
-	for _, result := range validationResults {
-		if len(result.Errors) > 0 {
-			for _, err := range result.Errors {
-				fmt.Fprintf(out, "- %s - %s\n", result.Name, err)
-			}
+// breakingChanges returns the errors of crdify's results: changes of the whole
+// CRD first, then changes of each version by property
+func breakingChanges(results *runner.Results) []BreakingChange {
+	var changes []BreakingChange
+	for _, result := range results.CRDValidation {
+		for _, err := range result.Errors {
+			changes = append(changes, BreakingChange{Validation: result.Name, Message: err})
 		}
 	}
-}
 
-// renderVersionValidationErrors renders version validation errors
-func renderVersionValidationErrors(out *strings.Builder, validationResults map[string]map[string][]validations.ComparisonResult) {
-	for version, versionResults := range validationResults {
-		for property, propertyResults := range versionResults {
-			for _, propertyResult := range propertyResults {
-				if len(propertyResult.Errors) > 0 {
-					for _, err := range propertyResult.Errors {
-						fmt.Fprintf(out, "- %s - %s - %s - %s\n", version, property, propertyResult.Name, err)
+	var versionChanges []BreakingChange
+	for _, versionResults := range []map[string]map[string][]validations.ComparisonResult{results.SameVersionValidation, results.ServedVersionValidation} {
+		for version, propertyResults := range versionResults {
+			for property, propertyResult := range propertyResults {
+				for _, result := range propertyResult {
+					for _, err := range result.Errors {
+						versionChanges = append(versionChanges, BreakingChange{Version: version, Property: property, Validation: result.Name, Message: err})
 					}
 				}
 			}
 		}
 	}
+	sort.SliceStable(versionChanges, func(i, j int) bool {
+		if versionChanges[i].Version != versionChanges[j].Version {
+			return versionChanges[i].Version < versionChanges[j].Version
+		}
+		return versionChanges[i].Property < versionChanges[j].Property
+	})
+	return append(changes, versionChanges...)
+}
+
+// nestedKeywords are the keywords of a schema that hold the schemas of its
+// fields, whose changes are reported as changes of the fields
+var nestedKeywords = []string{"properties", "items", "additionalProperties"}
+
+// diffBreakingChanges sets the diff of the changed property's schema on the
+// first change of each property
+func diffBreakingChanges(oldCRD, newCRD *apiextensionsv1.CustomResourceDefinition, changes []BreakingChange) {
+	diffed := map[[2]string]bool{}
+	for i, change := range changes {
+		key := [2]string{change.Version, change.Property}
+		if change.Property == "" || diffed[key] {
+			continue
+		}
+		diffed[key] = true
+		path := FieldPath(strings.TrimPrefix(change.Property, "^"))
+		oldProps := propertySchema(namedVersionSchema(oldCRD, change.Version), path)
+		newProps := propertySchema(namedVersionSchema(newCRD, change.Version), path)
+		if oldProps == nil || newProps == nil {
+			continue
+		}
+		oldValue, oldErr := keywords(oldProps)
+		newValue, newErr := keywords(newProps)
+		if oldErr != nil || newErr != nil {
+			continue
+		}
+		for _, diff := range structdiff.Compare(oldValue, newValue) {
+			diff.Path = append(slices.Clone(path), diff.Path...)
+			changes[i].Diff = append(changes[i].Diff, diff)
+		}
+	}
+}
+
+// namedVersionSchema returns the schema of the version of a CRD with a name, or nil
+func namedVersionSchema(crd *apiextensionsv1.CustomResourceDefinition, version string) *apiextensionsv1.JSONSchemaProps {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Schema != nil {
+			return v.Schema.OpenAPIV3Schema
+		}
+	}
+	return nil
+}
+
+// propertySchema returns the schema of the property at a path of field names,
+// with "*" for list items and map values, or nil
+func propertySchema(props *apiextensionsv1.JSONSchemaProps, path []string) *apiextensionsv1.JSONSchemaProps {
+	for _, segment := range path {
+		if props == nil {
+			return nil
+		}
+		switch {
+		case segment == "*" && props.Items != nil:
+			props = props.Items.Schema
+		case segment == "*" && props.AdditionalProperties != nil:
+			props = props.AdditionalProperties.Schema
+		default:
+			property, ok := props.Properties[segment]
+			if !ok {
+				return nil
+			}
+			props = &property
+		}
+	}
+	return props
+}
+
+// keywords returns the keywords of a schema as decoded JSON, without those
+// holding the schemas of its fields
+func keywords(props *apiextensionsv1.JSONSchemaProps) (interface{}, error) {
+	value, err := structdiff.Normalize(props)
+	if err != nil {
+		return nil, err
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, keyword := range nestedKeywords {
+			delete(object, keyword)
+		}
+	}
+	return value, nil
 }
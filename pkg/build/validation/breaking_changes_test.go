@@ -1,9 +1,16 @@
 package validation
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 )
 
 func TestCheckBreakingChanges_NoExistingCRD(t *testing.T) {
@@ -235,6 +242,20 @@ spec:
 	if err := CheckBreakingChanges(oldCRDPath, newCRD); err == nil {
 		t.Error("Expected error for field removal, got none")
 	}
+	if err := CheckBreakingChangesContent(oldCRD, newCRD); err == nil {
+		t.Error("Expected error for field removal from an existing CRD in memory, got none")
+	}
+	if err := CheckBreakingChangesContent(oldCRD, oldCRD); err != nil {
+		t.Errorf("Expected no error for an unchanged CRD in memory, got: %v", err)
+	}
+
+	fsys := filesystem.NewMemory(map[string][]byte{"crds/old.yaml": oldCRD})
+	if err := CheckBreakingChangesFS(fsys, "crds/old.yaml", newCRD); err == nil {
+		t.Error("Expected error for field removal from a CRD file in memory, got none")
+	}
+	if err := CheckBreakingChangesFS(fsys, "crds/missing.yaml", newCRD); err != nil {
+		t.Errorf("Expected no error when the old CRD doesn't exist in memory, got: %v", err)
+	}
 }
 
 func TestCheckBreakingChanges_RealFilesystem(t *testing.T) {
@@ -380,3 +401,187 @@ spec:
 		t.Error("Expected error for type change, got none")
 	}
 }
+
+func TestCheckBreakingChanges_Renames(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldCRDPath := filepath.Join(tmpDir, "crd.yaml")
+
+	oldCRD := []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          image:
+            description: ImageConfig defines the image configuration
+            type: object
+            properties:
+              repo:
+                type: string
+          containers:
+            type: array
+            items:
+              type: object
+              required:
+              - port
+              properties:
+                port:
+                  type: integer
+`)
+	if err := os.WriteFile(oldCRDPath, oldCRD, 0644); err != nil {
+		t.Fatalf("Failed to write old CRD: %v", err)
+	}
+
+	newCRD := []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          picture:
+            description: PictureConfig defines the picture configuration
+            type: object
+            properties:
+              repository:
+                type: string
+          containers:
+            type: array
+            items:
+              type: object
+              required:
+              - containerPort
+              properties:
+                containerPort:
+                  type: integer
+`)
+
+	// Without the renames, the old fields were removed
+	if err := CheckBreakingChanges(oldCRDPath, newCRD); err == nil {
+		t.Error("Expected error for removed fields, got none")
+	}
+
+	renames := []schema.Rename{
+		{From: "image", To: "picture"},
+		{Path: []string{"picture"}, From: "repo", To: "repository"},
+		{Path: []string{"containers", schema.ItemsSegment}, From: "port", To: "containerPort"},
+	}
+	if err := CheckBreakingChanges(oldCRDPath, newCRD, renames...); err != nil {
+		t.Errorf("Expected no error for renamed fields, got: %v", err)
+	}
+
+	// A renamed field that changed type is still a breaking change
+	retyped := bytes.Replace(newCRD, []byte("containerPort:\n                  type: integer"), []byte("containerPort:\n                  type: string"), 1)
+	if err := CheckBreakingChanges(oldCRDPath, retyped, renames...); err == nil {
+		t.Error("Expected error for renamed field that changed type, got none")
+	}
+}
+
+func TestCheckBreakingChanges_Changes(t *testing.T) {
+	crd := func(properties string) []byte {
+		return []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+` + properties)
+	}
+	oldCRD := crd(`          replicas:
+            type: integer
+          ports:
+            type: array
+            items:
+              type: object
+              properties:
+                name:
+                  type: string
+`)
+	newCRD := crd(`          replicas:
+            type: string
+          ports:
+            type: array
+            items:
+              type: object
+`)
+
+	err := CheckBreakingChangesContent(oldCRD, newCRD)
+	var breakingErr *BreakingChangesError
+	if !errors.As(err, &breakingErr) {
+		t.Fatalf("Expected a BreakingChangesError, got: %v", err)
+	}
+
+	paths := map[string]bool{}
+	for _, change := range breakingErr.Changes {
+		paths[strings.Join(change.Path(), ".")] = true
+		if !strings.Contains(err.Error(), "- "+change.String()+"\n") {
+			t.Errorf("Expected %q in the error, got: %s", change, err)
+		}
+	}
+	for _, want := range []string{"replicas", "ports.*.name"} {
+		if !paths[want] {
+			t.Errorf("Expected a change at %s, got: %v", want, breakingErr.Changes)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "breaking changes detected:\n") {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	// The changed keywords of each property are listed under its first change
+	if !strings.Contains(err.Error(), "\n    replicas: type changed integer -> string\n") {
+		t.Errorf("Expected the diff of replicas in the error, got: %s", err)
+	}
+	if strings.Count(err.Error(), "replicas: type changed") != 1 {
+		t.Errorf("Expected the diff of replicas once, got: %s", err)
+	}
+}
+
+func TestBreakingChange_Path(t *testing.T) {
+	tests := []struct {
+		change BreakingChange
+		want   []string
+	}{
+		{BreakingChange{Version: "v1", Property: "^.spec.ports[*].name", Validation: "type"}, []string{"spec", "ports", "*", "name"}},
+		{BreakingChange{Validation: "existingFieldRemoval", Message: "removed field : v1.^.spec.name"}, []string{"spec", "name"}},
+		{BreakingChange{Validation: "scope", Message: "scope changed"}, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.change.Path(); !slices.Equal(got, tt.want) {
+			t.Errorf("Path() of %v = %v, want %v", tt.change, got, tt.want)
+		}
+	}
+}
@@ -1,21 +1,35 @@
 package validation
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 
+	crdgen "github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/listtype"
 	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
 	"sigs.k8s.io/yaml"
 )
 
 // ValidateAgainstCRD validates a resource YAML file against a CRD
 // Returns an error if validation fails
 func ValidateAgainstCRD(crdPath, resourcePath string) error {
+	return ValidateAgainstCRDFS(filesystem.OS(), crdPath, resourcePath)
+}
+
+// ValidateAgainstCRDFS is ValidateAgainstCRD for files in fsys
+func ValidateAgainstCRDFS(fsys filesystem.FS, crdPath, resourcePath string) error {
 	// Load and unmarshal CRD
-	crdData, err := os.ReadFile(crdPath)
+	crdData, err := fsys.ReadFile(crdPath)
 	if err != nil {
 		return fmt.Errorf("failed to read CRD file: %w", err)
 	}
@@ -26,7 +40,7 @@ func ValidateAgainstCRD(crdPath, resourcePath string) error {
 	}
 
 	// Load and unmarshal resource
-	resourceData, err := os.ReadFile(resourcePath)
+	resourceData, err := fsys.ReadFile(resourcePath)
 	if err != nil {
 		return fmt.Errorf("failed to read resource file: %w", err)
 	}
@@ -36,21 +50,122 @@ func ValidateAgainstCRD(crdPath, resourcePath string) error {
 		return fmt.Errorf("failed to unmarshal resource: %w", err)
 	}
 
+	schemaValidator, err := newSchemaValidator(crd, resource.GetAPIVersion())
+	if err != nil {
+		return err
+	}
+	if crdgen.WrapsSpec(crd) {
+		resource.Object = crdgen.WrapValues(resource.Object)
+	}
+
+	// Validate the resource
+	result := schemaValidator.Validate(resource.Object)
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("resource validation failed:\n%v", result.Errors)
+	}
+
+	// Note: We ignore warnings for now, only fail on errors
+	return nil
+}
+
+// ValidateResource validates a resource against the schema for its version in a CRD,
+// including the uniqueness of the items of set and map lists and its CEL rules
+// (x-kubernetes-validations).
+// Validation failures are returned as field errors; err is only set if the resource can't be validated.
+func ValidateResource(crd *apiextensionsv1.CustomResourceDefinition, obj map[string]interface{}) (field.ErrorList, error) {
+	resource := &unstructured.Unstructured{Object: obj}
+	internalSchema, err := versionSchema(crd, resource.GetAPIVersion())
+	if err != nil {
+		return nil, err
+	}
+	schemaValidator, _, err := validation.NewSchemaValidator(internalSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema validator: %w", err)
+	}
+	errs := validation.ValidateCustomResource(nil, obj, schemaValidator)
+	if hasBlockingErrors(errs) {
+		return errs, nil
+	}
+
+	structural, err := structuralschema.NewStructural(internalSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build structural schema: %w", err)
+	}
+	// Decode the numbers like the API server does, so whole numbers are integers
+	decoded, err := decodeResource(obj)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, listtype.ValidateListSetsAndMaps(nil, structural, decoded)...)
+	return append(errs, validateCELRules(structural, decoded)...), nil
+}
+
+// validateCELRules evaluates the x-kubernetes-validations rules of a schema against
+// a decoded resource, within the cost limits of the API server
+func validateCELRules(structural *structuralschema.Structural, obj map[string]interface{}) field.ErrorList {
+	celValidator := cel.NewValidator(structural, true, celconfig.PerCallLimit)
+	if celValidator == nil {
+		// The schema has no rules
+		return nil
+	}
+	errs, _ := celValidator.Validate(context.Background(), nil, structural, obj, nil, celconfig.RuntimeCELCostBudget)
+	return errs
+}
+
+// decodeResource returns a copy of a resource with its numbers decoded like the
+// API server does
+func decodeResource(obj map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := utiljson.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode resource: %w", err)
+	}
+	return decoded, nil
+}
+
+// hasBlockingErrors reports whether a resource is too far from its schema for its
+// CEL rules to be evaluated, as the API server decides
+func hasBlockingErrors(errs field.ErrorList) bool {
+	for _, err := range errs {
+		switch err.Type {
+		case field.ErrorTypeNotSupported, field.ErrorTypeRequired, field.ErrorTypeTooLong, field.ErrorTypeTooMany, field.ErrorTypeTypeInvalid:
+			return true
+		}
+	}
+	return false
+}
+
+// newSchemaValidator creates a validator for the schema of a CRD version
+func newSchemaValidator(crd *apiextensionsv1.CustomResourceDefinition, apiVersion string) (validation.SchemaValidator, error) {
+	internalSchema, err := versionSchema(crd, apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	schemaValidator, _, err := validation.NewSchemaValidator(internalSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema validator: %w", err)
+	}
+	return schemaValidator, nil
+}
+
+// versionSchema returns the schema of a CRD version, converted for validation
+func versionSchema(crd *apiextensionsv1.CustomResourceDefinition, apiVersion string) (*apiextensions.JSONSchemaProps, error) {
 	// Find the schema for the resource's version
 	var schema *apiextensionsv1.CustomResourceValidation
-	resourceVersion := resource.GetAPIVersion()
-
 	for _, version := range crd.Spec.Versions {
 		// Check if this version matches the resource
 		expectedAPIVersion := crd.Spec.Group + "/" + version.Name
-		if expectedAPIVersion == resourceVersion {
+		if expectedAPIVersion == apiVersion {
 			schema = version.Schema
 			break
 		}
 	}
 
 	if schema == nil || schema.OpenAPIV3Schema == nil {
-		return fmt.Errorf("no schema found for version %s in CRD", resourceVersion)
+		return nil, fmt.Errorf("no schema found for version %s in CRD", apiVersion)
 	}
 
 	// Convert the v1 schema to internal schema for validation
@@ -60,21 +175,28 @@ func ValidateAgainstCRD(crdPath, resourcePath string) error {
 		internalSchema,
 		nil,
 	); err != nil {
-		return fmt.Errorf("failed to convert schema: %w", err)
+		return nil, fmt.Errorf("failed to convert schema: %w", err)
 	}
+	return internalSchema, nil
+}
 
-	// Create schema validator
-	schemaValidator, _, err := validation.NewSchemaValidator(internalSchema)
+// LoadCRDSchema reads a CRD file and returns the OpenAPI v3 schema of its first version
+func LoadCRDSchema(crdPath string) (*apiextensionsv1.JSONSchemaProps, error) {
+	return LoadCRDSchemaFS(filesystem.OS(), crdPath)
+}
+
+// LoadCRDSchemaFS is LoadCRDSchema for a CRD file in fsys
+func LoadCRDSchemaFS(fsys filesystem.FS, crdPath string) (*apiextensionsv1.JSONSchemaProps, error) {
+	crd, err := loadCRDFromFile(fsys, crdPath)
 	if err != nil {
-		return fmt.Errorf("failed to create schema validator: %w", err)
+		return nil, err
 	}
 
-	// Validate the resource
-	result := schemaValidator.Validate(resource.Object)
-	if len(result.Errors) > 0 {
-		return fmt.Errorf("resource validation failed:\n%v", result.Errors)
+	for _, version := range crd.Spec.Versions {
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			return version.Schema.OpenAPIV3Schema, nil
+		}
 	}
 
-	// Note: We ignore warnings for now, only fail on errors
-	return nil
+	return nil, fmt.Errorf("no schema found in CRD")
 }
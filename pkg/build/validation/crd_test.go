@@ -7,6 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
 )
 
 const testCRDContent = `apiVersion: apiextensions.k8s.io/v1
@@ -211,3 +214,195 @@ replicas: 3
 	err = ValidateAgainstCRD(crdPath, resourcePath)
 	assert.Error(t, err, "Expected validation to fail with missing CRD file")
 }
+
+func TestLoadCRDSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	crdPath := filepath.Join(tmpDir, "crd.yaml")
+	crdContent := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          replicas:
+            type: integer
+`
+	require.NoError(t, os.WriteFile(crdPath, []byte(crdContent), 0644))
+
+	schema, err := LoadCRDSchema(crdPath)
+	require.NoError(t, err)
+	assert.Contains(t, schema.Properties, "replicas")
+
+	_, err = LoadCRDSchema(filepath.Join(tmpDir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+// TestValidateResource tests validating a parsed resource and reporting field paths
+func TestValidateResource(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal([]byte(testCRDContent), crd))
+
+	errs, err := ValidateResource(crd, map[string]interface{}{
+		"apiVersion": "example.com/v1alpha1",
+		"kind":       "Example",
+		"replicas":   int64(3),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = ValidateResource(crd, map[string]interface{}{
+		"apiVersion": "example.com/v1alpha1",
+		"kind":       "Example",
+		"replicas":   int64(0),
+		"appName":    int64(1),
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	fields := []string{errs[0].Field, errs[1].Field}
+	assert.ElementsMatch(t, []string{"replicas", "appName"}, fields)
+
+	_, err = ValidateResource(crd, map[string]interface{}{"apiVersion": "example.com/v2", "kind": "Example"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no schema found for version example.com/v2")
+}
+
+const celCRDContent = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          autoscaling:
+            type: object
+            properties:
+              minReplicas:
+                type: integer
+              maxReplicas:
+                type: integer
+            x-kubernetes-validations:
+            - rule: self.maxReplicas >= self.minReplicas
+              message: maxReplicas must be at least minReplicas
+`
+
+func TestValidateResource_CELRules(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal([]byte(celCRDContent), crd))
+
+	values := func(autoscaling string) map[string]interface{} {
+		obj := map[string]interface{}{}
+		// Numbers are float64 when values come from YAML
+		require.NoError(t, yaml.Unmarshal([]byte("apiVersion: example.com/v1alpha1\nkind: Example\nautoscaling: "+autoscaling), &obj))
+		return obj
+	}
+
+	errs, err := ValidateResource(crd, values("{minReplicas: 1, maxReplicas: 3}"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = ValidateResource(crd, values("{minReplicas: 5, maxReplicas: 3}"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "autoscaling", errs[0].Field)
+	assert.Equal(t, "maxReplicas must be at least minReplicas", errs[0].Detail)
+
+	// Like the API server, rules aren't evaluated on values of the wrong type
+	errs, err = ValidateResource(crd, values("{minReplicas: five, maxReplicas: 3}"))
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "autoscaling.minReplicas", errs[0].Field)
+}
+
+const listTypeCRDContent = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          env:
+            type: array
+            x-kubernetes-list-type: map
+            x-kubernetes-list-map-keys: [name]
+            items:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                value:
+                  type: string
+          ports:
+            type: array
+            x-kubernetes-list-type: set
+            items:
+              type: integer
+`
+
+func TestValidateResource_ListTypes(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal([]byte(listTypeCRDContent), crd))
+
+	values := func(lists string) map[string]interface{} {
+		obj := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte("apiVersion: example.com/v1alpha1\nkind: Example\n"+lists), &obj))
+		return obj
+	}
+
+	errs, err := ValidateResource(crd, values("env: [{name: A}, {name: B}]\nports: [80, 443]"))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	errs, err = ValidateResource(crd, values("env: [{name: A, value: one}, {name: A, value: two}]\nports: [80, 80]"))
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, field.ErrorTypeDuplicate, errs[0].Type)
+	assert.Equal(t, field.ErrorTypeDuplicate, errs[1].Type)
+	assert.ElementsMatch(t, []string{"env[1]", "ports[1]"}, []string{errs[0].Field, errs[1].Field})
+}
@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DeprecatedFields returns a warning for each field set in merged values that a
+// JSON Schema marks deprecated, located in the source that set it
+func DeprecatedFields(values map[string]interface{}, schemaJSON []byte, sources Sources) ([]Problem, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+
+	var warnings []Problem
+	var walk func(value interface{}, object map[string]interface{}, path []string)
+	walk = func(value interface{}, object map[string]interface{}, path []string) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			properties, _ := object["properties"].(map[string]interface{})
+			additional, _ := object["additionalProperties"].(map[string]interface{})
+			for _, key := range sortedKeys(value) {
+				property, ok := properties[key].(map[string]interface{})
+				if !ok {
+					property = additional
+				}
+				if property == nil {
+					continue
+				}
+				fieldPath := append(append([]string{}, path...), key)
+				if deprecated, _ := property["deprecated"].(bool); deprecated && value[key] != nil {
+					warnings = append(warnings, sources.Locate(fieldPath, deprecationMessage(property)))
+				}
+				walk(value[key], property, fieldPath)
+			}
+		case []interface{}:
+			items, _ := object["items"].(map[string]interface{})
+			for i, item := range value {
+				walk(item, items, append(append([]string{}, path...), strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(values, root, nil)
+	sortProblems(warnings)
+	return warnings, nil
+}
+
+// deprecationMessage returns the last "Deprecated:" paragraph of the description
+// of a deprecated field, which says what to use instead. The aliases of renamed
+// fields add theirs after the description of the new name.
+func deprecationMessage(property map[string]interface{}) string {
+	description, _ := property["description"].(string)
+	message := "deprecated"
+	for _, paragraph := range strings.Split(description, "\n\n") {
+		if text, ok := strings.CutPrefix(paragraph, "Deprecated:"); ok {
+			message = "deprecated: " + strings.Join(strings.Fields(text), " ")
+		}
+	}
+	return message
+}
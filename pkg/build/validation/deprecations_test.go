@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedFields(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "debug": {"type": "boolean", "deprecated": true, "description": "Enable debug mode\n\nDeprecated: use logLevel\ninstead"},
+    "env": {"type": "array", "items": {"type": "object", "properties": {
+      "value": {"type": "string"},
+      "val": {"type": "string", "deprecated": true, "description": "The value\n\nDeprecated: renamed to value."}
+    }}},
+    "ports": {"type": "object", "additionalProperties": {"type": "object", "properties": {"port": {"type": "integer", "deprecated": true}}}},
+    "unset": {"type": "string", "deprecated": true}
+  }
+}`
+	values, sources := parseSources(t, "values.yaml", `debug: true
+env:
+- value: a
+- val: b
+ports:
+  http:
+    port: 80
+unset: null
+`)
+	warnings, err := DeprecatedFields(values, []byte(schema), sources)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, warning := range warnings {
+		messages = append(messages, warning.String())
+	}
+	assert.Equal(t, []string{
+		"values.yaml:1:1: debug: deprecated: use logLevel instead",
+		"values.yaml:4:3: env.1.val: deprecated: renamed to value.",
+		"values.yaml:7:5: ports.http.port: deprecated",
+	}, messages)
+}
+
+func TestDeprecatedFields_InvalidSchema(t *testing.T) {
+	values, sources := parseSources(t, "values.yaml", "a: b")
+	_, err := DeprecatedFields(values, []byte("{"), sources)
+	assert.ErrorContains(t, err, "failed to parse JSON Schema")
+}
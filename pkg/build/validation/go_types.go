@@ -0,0 +1,261 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+)
+
+// goTypeDecl is a type declared in a types.go: a struct, or a named type with an
+// underlying type
+type goTypeDecl struct {
+	underlying string             // Underlying type of a named non-struct type
+	fields     map[string]goField // Fields of a struct, by Go name
+	isStruct   bool
+}
+
+// goField is a field of a struct in a types.go
+type goField struct {
+	goType   string
+	jsonName string
+}
+
+// CheckGoTypesBreakingChanges compares an existing types.go with newly generated
+// Go types and returns an error listing the removed, renamed, or retyped types and
+// fields, which break packages importing the types even if the CRD is compatible.
+// If the old file doesn't exist, no error is returned (first-time generation).
+func CheckGoTypesBreakingChanges(oldTypesPath string, newTypesContent []byte) error {
+	return CheckGoTypesBreakingChangesFS(filesystem.OS(), oldTypesPath, newTypesContent)
+}
+
+// CheckGoTypesBreakingChangesFS is CheckGoTypesBreakingChanges for an existing
+// types.go in fsys
+func CheckGoTypesBreakingChangesFS(fsys filesystem.FS, oldTypesPath string, newTypesContent []byte) error {
+	oldContent, err := fsys.ReadFile(oldTypesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read existing Go types from %s: %w", oldTypesPath, err)
+	}
+	return checkGoTypesBreakingChanges(oldTypesPath, oldContent, newTypesContent)
+}
+
+// CheckGoTypesBreakingChangesContent is CheckGoTypesBreakingChanges for existing
+// Go types in memory
+func CheckGoTypesBreakingChangesContent(oldTypesContent, newTypesContent []byte) error {
+	return checkGoTypesBreakingChanges("types.go", oldTypesContent, newTypesContent)
+}
+
+// checkGoTypesBreakingChanges compares existing Go types, read from oldTypesPath,
+// with newly generated ones
+func checkGoTypesBreakingChanges(oldTypesPath string, oldContent, newTypesContent []byte) error {
+	oldTypes, err := parseGoTypes(oldTypesPath, oldContent)
+	if err != nil {
+		return fmt.Errorf("failed to parse existing Go types: %w", err)
+	}
+	newTypes, err := parseGoTypes("types.go", newTypesContent)
+	if err != nil {
+		return fmt.Errorf("failed to parse new Go types: %w", err)
+	}
+
+	if changes := goTypesBreakingChanges(oldTypes, newTypes); len(changes) > 0 {
+		return fmt.Errorf("breaking changes to the Go types detected:\n- %s", strings.Join(changes, "\n- "))
+	}
+	return nil
+}
+
+// goTypesBreakingChanges lists the changes from the old to the new types that
+// break code using the old ones, sorted by type and field
+func goTypesBreakingChanges(oldTypes, newTypes map[string]goTypeDecl) []string {
+	renamedTypes := renamedGoTypes(oldTypes, newTypes)
+	oldNames := make(map[string]string, len(renamedTypes))
+	for oldName, newName := range renamedTypes {
+		oldNames[newName] = oldName
+	}
+
+	var changes []string
+	for _, name := range sortedKeys(oldTypes) {
+		oldDecl := oldTypes[name]
+		newName := name
+		if renamed, ok := renamedTypes[name]; ok {
+			changes = append(changes, fmt.Sprintf("type %s was renamed to %s", name, renamed))
+			newName = renamed
+		}
+		newDecl, ok := newTypes[newName]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("type %s was removed", name))
+			continue
+		}
+		if oldDecl.isStruct != newDecl.isStruct || oldDecl.underlying != withOldNames(newDecl.underlying, oldNames) {
+			changes = append(changes, fmt.Sprintf("type %s changed from %s to %s", name, oldDecl.describe(), newDecl.describe()))
+			continue
+		}
+		changes = append(changes, fieldChanges(name, oldDecl, newDecl, oldNames)...)
+	}
+	return changes
+}
+
+// fieldChanges lists the removed, renamed, and retyped fields of a struct
+func fieldChanges(typeName string, oldDecl, newDecl goTypeDecl, oldNames map[string]string) []string {
+	byJSONName := make(map[string]string, len(newDecl.fields))
+	for name, field := range newDecl.fields {
+		if field.jsonName != "" {
+			byJSONName[field.jsonName] = name
+		}
+	}
+
+	var changes []string
+	for _, name := range sortedKeys(oldDecl.fields) {
+		oldField := oldDecl.fields[name]
+		newField, ok := newDecl.fields[name]
+		if !ok {
+			renamed, found := byJSONName[oldField.jsonName]
+			if _, exists := oldDecl.fields[renamed]; !found || oldField.jsonName == "" || exists {
+				changes = append(changes, fmt.Sprintf("field %s.%s was removed", typeName, name))
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("field %s.%s was renamed to %s", typeName, name, renamed))
+			newField = newDecl.fields[renamed]
+		}
+		if withOldNames(newField.goType, oldNames) != oldField.goType {
+			changes = append(changes, fmt.Sprintf("field %s.%s changed type from %s to %s", typeName, name, oldField.goType, newField.goType))
+		}
+	}
+	return changes
+}
+
+// renamedGoTypes finds the removed types whose fields now hold a new type of the
+// same kind, by comparing the types of the fields both versions have. The types
+// in renamed types are compared again, until no more renames are found.
+func renamedGoTypes(oldTypes, newTypes map[string]goTypeDecl) map[string]string {
+	renamed := make(map[string]string)
+	for found := true; found; {
+		found = false
+		for _, name := range sortedKeys(oldTypes) {
+			newName, ok := renamed[name]
+			if !ok {
+				newName = name
+			}
+			oldFields, newFields := oldTypes[name].fields, newTypes[newName].fields
+			for _, fieldName := range sortedKeys(oldFields) {
+				newField, ok := newFields[fieldName]
+				if !ok {
+					continue
+				}
+				oldElem, newElem := elemName(oldFields[fieldName].goType), elemName(newField.goType)
+				oldDecl, wasType := oldTypes[oldElem]
+				newDecl, isType := newTypes[newElem]
+				_, kept := newTypes[oldElem]
+				_, existed := oldTypes[newElem]
+				_, seen := renamed[oldElem]
+				if wasType && isType && !kept && !existed && !seen && oldDecl.isStruct == newDecl.isStruct {
+					renamed[oldElem] = newElem
+					found = true
+				}
+			}
+		}
+	}
+	return renamed
+}
+
+// withOldNames replaces the name of a renamed type held by a Go type with its
+// old name
+func withOldNames(goType string, oldNames map[string]string) string {
+	elem := elemName(goType)
+	if oldName, ok := oldNames[elem]; ok {
+		return strings.TrimSuffix(goType, elem) + oldName
+	}
+	return goType
+}
+
+// elemName returns the name of the type held by a Go type, through pointers,
+// slices, and maps
+func elemName(goType string) string {
+	for {
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(goType, "*"), "[]"), "map[string]")
+		if trimmed == goType {
+			return goType
+		}
+		goType = trimmed
+	}
+}
+
+// describe names the kind of a type for messages
+func (d goTypeDecl) describe() string {
+	if d.isStruct {
+		return "a struct"
+	}
+	return d.underlying
+}
+
+// parseGoTypes returns the types declared in a Go file
+func parseGoTypes(filename string, content []byte) (map[string]goTypeDecl, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	decls := make(map[string]goTypeDecl)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				decls[typeSpec.Name.Name] = goTypeDecl{underlying: types.ExprString(typeSpec.Type)}
+				continue
+			}
+			decls[typeSpec.Name.Name] = goTypeDecl{isStruct: true, fields: structFields(structType)}
+		}
+	}
+	return decls, nil
+}
+
+// structFields returns the fields of a struct by name. Embedded fields are named
+// after their type, like in Go.
+func structFields(structType *ast.StructType) map[string]goField {
+	fields := make(map[string]goField)
+	for _, field := range structType.Fields.List {
+		goType := types.ExprString(field.Type)
+		jsonName := ""
+		if field.Tag != nil {
+			tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+			jsonName, _, _ = strings.Cut(tag.Get("json"), ",")
+		}
+		if len(field.Names) == 0 {
+			embedded := elemName(goType)
+			if _, name, ok := strings.Cut(embedded, "."); ok {
+				embedded = name
+			}
+			fields[embedded] = goField{goType: goType, jsonName: jsonName}
+			continue
+		}
+		for _, name := range field.Names {
+			fields[name.Name] = goField{goType: goType, jsonName: jsonName}
+		}
+	}
+	return fields
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldGoTypes = `package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Example struct {
+	metav1.TypeMeta   ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata,omitempty\"`" + `
+
+	Replicas int            ` + "`json:\"replicas,omitempty\"`" + `
+	Image    ImageConfig    ` + "`json:\"image,omitempty\"`" + `
+	Service  ServiceConfig  ` + "`json:\"service,omitempty\"`" + `
+	Labels   LabelsValue    ` + "`json:\"labels,omitempty\"`" + `
+	Hosts    []string       ` + "`json:\"hosts,omitempty\"`" + `
+}
+
+type ImageConfig struct {
+	Registry RegistryConfig ` + "`json:\"registry,omitempty\"`" + `
+	Tag      string         ` + "`json:\"tag,omitempty\"`" + `
+}
+
+type RegistryConfig struct {
+	Host string ` + "`json:\"host,omitempty\"`" + `
+}
+
+type ServiceConfig struct {
+	Port int ` + "`json:\"port,omitempty\"`" + `
+}
+
+type LabelsValue string
+`
+
+func TestCheckGoTypesBreakingChanges(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "types.go")
+	require.NoError(t, os.WriteFile(oldPath, []byte(oldGoTypes), 0644))
+
+	newTypes := `package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Example struct {
+	metav1.TypeMeta   ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata,omitempty\"`" + `
+
+	Replicas int64               ` + "`json:\"replicas,omitempty\"`" + `
+	Image    ExampleImageConfig  ` + "`json:\"image,omitempty\"`" + `
+	Labels   map[string]string   ` + "`json:\"labels,omitempty\"`" + `
+	HostList []string            ` + "`json:\"hosts,omitempty\"`" + `
+	Debug    bool                ` + "`json:\"debug,omitempty\"`" + `
+}
+
+type ExampleImageConfig struct {
+	Registry ExampleRegistryConfig ` + "`json:\"registry,omitempty\"`" + `
+}
+
+type ExampleRegistryConfig struct {
+	Host string ` + "`json:\"host,omitempty\"`" + `
+}
+
+type LabelsValue struct{}
+`
+
+	err := CheckGoTypesBreakingChanges(oldPath, []byte(newTypes))
+	require.Error(t, err)
+	assert.Equal(t, `breaking changes to the Go types detected:
+- field Example.Hosts was renamed to HostList
+- field Example.Labels changed type from LabelsValue to map[string]string
+- field Example.Replicas changed type from int to int64
+- field Example.Service was removed
+- type ImageConfig was renamed to ExampleImageConfig
+- field ImageConfig.Tag was removed
+- type LabelsValue changed from string to a struct
+- type RegistryConfig was renamed to ExampleRegistryConfig
+- type ServiceConfig was removed`, err.Error())
+}
+
+func TestCheckGoTypesBreakingChanges_Compatible(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "types.go")
+	require.NoError(t, os.WriteFile(oldPath, []byte(oldGoTypes), 0644))
+
+	// New fields and types don't break code using the old ones
+	newTypes := oldGoTypes + `
+type ExtraConfig struct {
+	Enabled bool ` + "`json:\"enabled,omitempty\"`" + `
+}
+`
+	assert.NoError(t, CheckGoTypesBreakingChanges(oldPath, []byte(newTypes)))
+
+	// Without an existing file, there's nothing to break
+	assert.NoError(t, CheckGoTypesBreakingChanges(filepath.Join(dir, "missing.go"), []byte(newTypes)))
+}
+
+func TestCheckGoTypesBreakingChangesContent(t *testing.T) {
+	assert.NoError(t, CheckGoTypesBreakingChangesContent([]byte(oldGoTypes), []byte(oldGoTypes)))
+
+	newTypes := strings.Replace(oldGoTypes, "type ServiceConfig struct", "type ServiceSettings struct", 1)
+	assert.ErrorContains(t, CheckGoTypesBreakingChangesContent([]byte(oldGoTypes), []byte(newTypes)), "breaking changes to the Go types detected")
+}
+
+func TestCheckGoTypesBreakingChanges_InvalidGo(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "types.go")
+	require.NoError(t, os.WriteFile(oldPath, []byte("package v1\ntype Example struct {"), 0644))
+
+	err := CheckGoTypesBreakingChanges(oldPath, []byte(oldGoTypes))
+	assert.ErrorContains(t, err, "failed to parse existing Go types")
+}
@@ -2,9 +2,13 @@ package validation
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	"github.com/santhosh-tekuri/jsonschema/v6"
 	"sigs.k8s.io/yaml"
 )
@@ -12,8 +16,13 @@ import (
 // ValidateYAML validates a YAML file against a JSON Schema file
 // Uses the same validation method as Helm for maximum compatibility
 func ValidateYAML(yamlPath, schemaPath string) error {
+	return ValidateYAMLFS(filesystem.OS(), yamlPath, schemaPath)
+}
+
+// ValidateYAMLFS is ValidateYAML for files in fsys
+func ValidateYAMLFS(fsys filesystem.FS, yamlPath, schemaPath string) error {
 	// Read and parse YAML file
-	yamlBytes, err := os.ReadFile(yamlPath)
+	yamlBytes, err := fsys.ReadFile(yamlPath)
 	if err != nil {
 		return fmt.Errorf("failed to read YAML file: %w", err)
 	}
@@ -25,7 +34,7 @@ func ValidateYAML(yamlPath, schemaPath string) error {
 	}
 
 	// Read JSON Schema
-	schemaBytes, err := os.ReadFile(schemaPath)
+	schemaBytes, err := fsys.ReadFile(schemaPath)
 	if err != nil {
 		return fmt.Errorf("failed to read schema file: %w", err)
 	}
@@ -34,14 +43,104 @@ func ValidateYAML(yamlPath, schemaPath string) error {
 	return validateAgainstSchema(values, schemaBytes)
 }
 
-// validateAgainstSchema checks that values conform to the JSON Schema
+// ValidateValues validates already-parsed values against JSON Schema content
+func ValidateValues(values map[string]interface{}, schemaJSON []byte) error {
+	return validateAgainstSchema(values, schemaJSON)
+}
+
+// Violation is a single JSON Schema validation failure
+type Violation struct {
+	Path    []string // Location of the offending value, e.g. ["service", "port"]
+	Keyword string   // The keyword that failed, e.g. "minimum"
+	Message string
+}
+
+// Violations extracts the individual failures from a JSON Schema validation error.
+// Returns nil if err is not a validation failure (e.g., the schema failed to compile).
+func Violations(err error) []Violation {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	var violations []Violation
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		var path []string
+		if location := strings.TrimPrefix(unit.InstanceLocation, "/"); location != "" {
+			path = strings.Split(location, "/")
+		}
+		keyword := unit.KeywordLocation[strings.LastIndex(unit.KeywordLocation, "/")+1:]
+		violations = append(violations, Violation{Path: path, Keyword: keyword, Message: unit.Error.String()})
+	}
+	return violations
+}
+
+// maxCachedSchemas bounds the compiled schemas kept by NewValidator, for
+// long-running processes whose schemas change (e.g., the language server)
+const maxCachedSchemas = 16
+
+// compiledSchemas caches the validators of schemas by the SHA-256 of their content
+var compiledSchemas = struct {
+	sync.Mutex
+	validators map[[sha256.Size]byte]*Validator
+	order      [][sha256.Size]byte // oldest first
+}{validators: map[[sha256.Size]byte]*Validator{}}
+
+// Validator validates values against a compiled JSON Schema. It's safe for
+// concurrent use, so one validator can check any number of values files.
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// NewValidator compiles a JSON Schema the way Helm does. Schemas are compiled
+// once per content, so validating many values files against the same schema
+// only pays for compiling it the first time.
+func NewValidator(schemaJSON []byte) (*Validator, error) {
+	key := sha256.Sum256(schemaJSON)
+	compiledSchemas.Lock()
+	validator, ok := compiledSchemas.validators[key]
+	compiledSchemas.Unlock()
+	if ok {
+		return validator, nil
+	}
+
+	validator, err := compileSchema(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledSchemas.Lock()
+	defer compiledSchemas.Unlock()
+	if _, ok := compiledSchemas.validators[key]; !ok {
+		if len(compiledSchemas.order) == maxCachedSchemas {
+			delete(compiledSchemas.validators, compiledSchemas.order[0])
+			compiledSchemas.order = compiledSchemas.order[1:]
+		}
+		compiledSchemas.validators[key] = validator
+		compiledSchemas.order = append(compiledSchemas.order, key)
+	}
+	return validator, nil
+}
+
+// Validate checks that values conform to the schema
+func (v *Validator) Validate(values map[string]interface{}) error {
+	if err := v.schema.Validate(values); err != nil {
+		return fmt.Errorf("JSON Schema validation failed: %w", err)
+	}
+	return nil
+}
+
+// compileSchema compiles a JSON Schema
 // This follows Helm's exact validation pattern from:
 // https://github.com/helm/helm/blob/main/pkg/chart/common/util/jsonschema.go
-func validateAgainstSchema(values map[string]interface{}, schemaJSON []byte) error {
+func compileSchema(schemaJSON []byte) (*Validator, error) {
 	// Unmarshal schema (Helm uses UnmarshalJSON which leverages UseNumber)
 	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaJSON))
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal JSON Schema: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal JSON Schema: %w", err)
 	}
 
 	// Create compiler (following Helm's pattern)
@@ -50,20 +149,22 @@ func validateAgainstSchema(values map[string]interface{}, schemaJSON []byte) err
 	// Add schema resource
 	err = compiler.AddResource("file:///values.schema.json", schema)
 	if err != nil {
-		return fmt.Errorf("failed to add schema resource: %w", err)
+		return nil, fmt.Errorf("failed to add schema resource: %w", err)
 	}
 
 	// Compile schema
-	validator, err := compiler.Compile("file:///values.schema.json")
+	compiled, err := compiler.Compile("file:///values.schema.json")
 	if err != nil {
-		return fmt.Errorf("failed to compile schema: %w", err)
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
 	}
+	return &Validator{schema: compiled}, nil
+}
 
-	// Validate values
-	err = validator.Validate(values)
+// validateAgainstSchema checks that values conform to the JSON Schema
+func validateAgainstSchema(values map[string]interface{}, schemaJSON []byte) error {
+	validator, err := NewValidator(schemaJSON)
 	if err != nil {
-		return fmt.Errorf("JSON Schema validation failed: %w", err)
+		return err
 	}
-
-	return nil
+	return validator.Validate(values)
 }
@@ -1,10 +1,12 @@
 package validation
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -44,6 +46,20 @@ count: 5
 	assert.NoError(t, err, "ValidateYAML() expected no error for valid data")
 }
 
+func TestValidateYAMLFS(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{
+		"values.schema.json": []byte(`{"type": "object", "properties": {"count": {"type": "integer", "minimum": 0}}}`),
+		"valid.yaml":         []byte("count: 5\n"),
+		"invalid.yaml":       []byte("count: -1\n"),
+	})
+
+	assert.NoError(t, ValidateYAMLFS(fsys, "valid.yaml", "values.schema.json"))
+	assert.Error(t, ValidateYAMLFS(fsys, "invalid.yaml", "values.schema.json"))
+	err := ValidateYAMLFS(fsys, "missing.yaml", "values.schema.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read YAML file")
+}
+
 func TestValidateYAML_InvalidData_MissingRequired(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -219,3 +235,102 @@ func TestValidateYAML_InvalidSchema(t *testing.T) {
 	err = ValidateYAML(yamlPath, schemaPath)
 	assert.Error(t, err, "ValidateYAML() expected error for invalid schema")
 }
+
+func TestViolations(t *testing.T) {
+	schema := []byte(`{
+  "type": "object",
+  "properties": {
+    "service": {
+      "type": "object",
+      "properties": {
+        "port": {"type": "integer", "minimum": 1}
+      },
+      "additionalProperties": false
+    }
+  }
+}`)
+
+	err := ValidateValues(map[string]interface{}{
+		"service": map[string]interface{}{"port": 0, "name": "web"},
+	}, schema)
+	require.Error(t, err)
+
+	violations := Violations(err)
+	require.Len(t, violations, 2)
+	assert.Contains(t, violations, Violation{Path: []string{"service", "port"}, Keyword: "minimum", Message: "minimum: got 0, want 1"})
+	assert.Contains(t, violations, Violation{Path: []string{"service"}, Keyword: "additionalProperties", Message: "additional properties 'name' not allowed"})
+
+	require.NoError(t, ValidateValues(map[string]interface{}{"service": map[string]interface{}{"port": 80}}, schema))
+	assert.Nil(t, Violations(assert.AnError))
+}
+
+func TestNewValidator(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"replicas": {"type": "integer", "minimum": 1}}}`)
+
+	validator, err := NewValidator(schema)
+	require.NoError(t, err)
+	require.NoError(t, validator.Validate(map[string]interface{}{"replicas": 3}))
+	err = validator.Validate(map[string]interface{}{"replicas": 0})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JSON Schema validation failed")
+
+	// The same content reuses the compiled schema
+	again, err := NewValidator(append([]byte{}, schema...))
+	require.NoError(t, err)
+	assert.Same(t, validator, again)
+
+	_, err = NewValidator([]byte(`{"type": 5}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile schema")
+}
+
+func TestNewValidator_CacheLimit(t *testing.T) {
+	first, err := NewValidator([]byte(`{"minProperties": 0}`))
+	require.NoError(t, err)
+	for i := 1; i <= maxCachedSchemas; i++ {
+		_, err := NewValidator([]byte(fmt.Sprintf(`{"minProperties": %d}`, i)))
+		require.NoError(t, err)
+	}
+
+	compiledSchemas.Lock()
+	cached := len(compiledSchemas.validators)
+	compiledSchemas.Unlock()
+	assert.LessOrEqual(t, cached, maxCachedSchemas)
+
+	// The oldest schema was evicted, so it's compiled again
+	again, err := NewValidator([]byte(`{"minProperties": 0}`))
+	require.NoError(t, err)
+	assert.NotSame(t, first, again)
+}
+
+// BenchmarkValidateValues measures validating values files against the schema
+// of a large chart, compiling the schema for each file or once for all of them
+func BenchmarkValidateValues(b *testing.B) {
+	schema, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "build", "comprehensive", "expected_schema.json"))
+	if err != nil {
+		b.Fatalf("Failed to read schema: %v", err)
+	}
+	values := map[string]interface{}{"replicas": 3, "image": map[string]interface{}{"repository": "nginx", "tag": "1.27"}}
+
+	b.Run("compile", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			validator, err := compileSchema(schema)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := validator.Validate(values); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := ValidateValues(values, schema); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
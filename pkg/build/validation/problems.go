@@ -0,0 +1,391 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	crdgen "github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Problem is a validation failure located in a YAML source file
+type Problem struct {
+	File    string
+	Line    int // 1-based; 0 if unknown
+	Column  int // 1-based; 0 if unknown
+	Path    []string
+	Message string
+}
+
+// String renders the problem as "file:line:column: path: message"
+func (p Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", p.Line, p.Column)
+	}
+	b.WriteString(": ")
+	if len(p.Path) > 0 {
+		b.WriteString(strings.Join(p.Path, ".") + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// GitHubAnnotation renders the problem as a GitHub Actions error annotation
+func (p Problem) GitHubAnnotation() string {
+	return p.gitHubCommand("error")
+}
+
+// GitHubWarning renders the problem as a GitHub Actions warning annotation
+func (p Problem) GitHubWarning() string {
+	return p.gitHubCommand("warning")
+}
+
+// gitHubCommand renders the problem as a GitHub Actions workflow command
+func (p Problem) gitHubCommand(command string) string {
+	props := []string{"file=" + escapeGitHubProperty(p.File)}
+	if p.Line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", p.Line), fmt.Sprintf("col=%d", p.Column))
+	}
+	message := p.Message
+	if len(p.Path) > 0 {
+		message = strings.Join(p.Path, ".") + ": " + message
+	}
+	return fmt.Sprintf("::%s %s::%s", command, strings.Join(props, ","), escapeGitHubData(message))
+}
+
+// escapeGitHubData escapes the message of a workflow command
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// Source is a parsed values file that remembers the position of each value
+type Source struct {
+	File   string
+	Values map[string]interface{}
+	// Prefix is the path of the values in the file, if they're embedded in a
+	// resource (e.g., spec.values of a Flux HelmRelease), which problems include
+	Prefix []string
+	root   *yaml.Node
+}
+
+// ParseSource parses a YAML values file, resolving its merge keys (<<: *common)
+func ParseSource(file string, data []byte) (*Source, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	values := map[string]interface{}{}
+	if err := sigsyaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	// Values set by merge keys are located at the fields of their anchors
+	if err := parsing.ResolveMergeKeys(root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return &Source{File: file, Values: values, root: root}, nil
+}
+
+// ParseEmbeddedSource parses values embedded in a string of a YAML file, e.g.
+// the Helm values of an Argo CD Application, positioning them in the file.
+// content is the file and value the string's node. Only literal block scalars
+// (|) keep the values' layout, so the values of other strings are positioned at
+// the string.
+func ParseEmbeddedSource(file string, content []byte, value *yaml.Node) (*Source, error) {
+	source, err := ParseSource(file, []byte(value.Value))
+	if err != nil {
+		return nil, err
+	}
+	if value.Style != yaml.LiteralStyle {
+		positionNodes(source.root, map[*yaml.Node]bool{}, func(node *yaml.Node) {
+			node.Line, node.Column = value.Line, value.Column
+		})
+		return source, nil
+	}
+	// The values start on the line after the indicator, at the indentation of
+	// the block
+	indent := blockIndent(content, value.Line)
+	positionNodes(source.root, map[*yaml.Node]bool{}, func(node *yaml.Node) {
+		node.Line += value.Line
+		node.Column += indent
+	})
+	return source, nil
+}
+
+// NodeSource returns the values of a mapping node of a YAML file, e.g. the
+// helm.valuesObject of an Argo CD Application
+func NodeSource(file string, node *yaml.Node) (*Source, error) {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values of %s: %w", file, err)
+	}
+	values := map[string]interface{}{}
+	if err := sigsyaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse values of %s: %w", file, err)
+	}
+	if err := parsing.ResolveMergeKeys(node); err != nil {
+		return nil, fmt.Errorf("failed to parse values of %s: %w", file, err)
+	}
+	return &Source{File: file, Values: values, root: node}, nil
+}
+
+// positionNodes calls position for each node of a tree once, even if aliases
+// share it
+func positionNodes(node *yaml.Node, seen map[*yaml.Node]bool, position func(*yaml.Node)) {
+	if node == nil || seen[node] {
+		return
+	}
+	seen[node] = true
+	position(node)
+	for _, child := range node.Content {
+		positionNodes(child, seen, position)
+	}
+	positionNodes(node.Alias, seen, position)
+}
+
+// blockIndent returns the indentation of the first non-blank line of the block
+// scalar whose indicator is on line
+func blockIndent(content []byte, line int) int {
+	lines := strings.Split(string(content), "\n")
+	for i := line; i < len(lines); i++ {
+		if trimmed := strings.TrimLeft(lines[i], " "); strings.TrimSpace(trimmed) != "" {
+			return len(lines[i]) - len(trimmed)
+		}
+	}
+	return 0
+}
+
+// Subchart returns the values the source sets for the subchart under key, with
+// the global values (see chart.SubchartValues), positioned in the source's file.
+// The source has no values if it doesn't set the key.
+func (s *Source) Subchart(key string) *Source {
+	values, ok := chart.SubchartValues(s.Values, key)
+	if !ok {
+		values = map[string]interface{}{}
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if s.root.Kind == yaml.MappingNode {
+		// The parent's globals come first, since they take precedence
+		if globalKey, global := child(s.root, chart.GlobalKey); global != nil {
+			root.Content = append(root.Content, globalKey, global)
+		}
+		if _, subchart := child(s.root, key); subchart != nil && subchart.Kind == yaml.MappingNode {
+			root.Content = append(root.Content, subchart.Content...)
+		}
+	}
+	return &Source{File: s.File, Values: values, Prefix: s.Prefix, root: root}
+}
+
+// Sources are values files merged in order, the way Helm merges "-f" files:
+// objects are merged, other values are replaced, and null deletes a key
+type Sources []*Source
+
+// SchemaProblems validates merged values against a JSON Schema and locates each
+// violation in the source that set the offending value. err is only set if the
+// values can't be validated.
+func SchemaProblems(values map[string]interface{}, schemaJSON []byte, sources Sources) ([]Problem, error) {
+	err := validateAgainstSchema(values, schemaJSON)
+	if err == nil {
+		return nil, nil
+	}
+	violations := Violations(err)
+	if violations == nil {
+		return nil, err
+	}
+
+	problems := make([]Problem, 0, len(violations))
+	for _, v := range violations {
+		problems = append(problems, sources.Locate(v.Path, v.Message))
+	}
+	sortProblems(problems)
+	return problems, nil
+}
+
+// CRDProblems validates merged values against a CRD and locates each field error
+// in the source that set the offending value. err is only set if the values
+// can't be validated.
+func CRDProblems(values map[string]interface{}, crd *apiextensionsv1.CustomResourceDefinition, sources Sources) ([]Problem, error) {
+	wrapped := crdgen.WrapsSpec(crd)
+	if wrapped {
+		values = crdgen.WrapValues(values)
+	}
+	errs, err := ValidateResource(crd, values)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]Problem, 0, len(errs))
+	for _, e := range errs {
+		path := FieldPath(e.Field)
+		if wrapped {
+			path = UnwrapPath(path)
+		}
+		problems = append(problems, sources.Locate(path, e.ErrorBody()))
+	}
+	sortProblems(problems)
+	return problems, nil
+}
+
+// located is the node a source has for a path
+type located struct {
+	source *Source
+	key    *yaml.Node // key node for object fields, item node for list items
+	value  *yaml.Node
+}
+
+// Locate creates a problem positioned at the value for path in the source that
+// set it. If the path doesn't exist (e.g., a missing required field), the
+// problem is positioned at its closest existing parent.
+func (s Sources) Locate(path []string, message string) Problem {
+	var candidates []located
+	for _, source := range s {
+		if source.root.Kind == yaml.MappingNode {
+			candidates = append(candidates, located{source: source, key: source.root, value: source.root})
+		}
+	}
+	if len(candidates) == 0 {
+		problem := Problem{Path: path, Message: message}
+		if len(s) > 0 {
+			problem.File = s[len(s)-1].File
+			problem.Path = prefixed(s[len(s)-1].Prefix, path)
+		}
+		return problem
+	}
+
+	for _, segment := range path {
+		var next []located
+		for _, c := range candidates {
+			key, value := child(c.value, segment)
+			if value == nil {
+				continue
+			}
+			if value.Tag == "!!null" {
+				// null deletes the value set by earlier files
+				next = nil
+				continue
+			}
+			next = append(next, located{source: c.source, key: key, value: value})
+		}
+		if len(next) == 0 {
+			break
+		}
+		// Only objects are merged; anything else is owned by the last file that set it
+		if last := next[len(next)-1]; last.value.Kind != yaml.MappingNode {
+			next = []located{last}
+		}
+		candidates = next
+	}
+
+	owner := candidates[len(candidates)-1]
+	return Problem{
+		File:    owner.source.File,
+		Line:    owner.key.Line,
+		Column:  owner.key.Column,
+		Path:    prefixed(owner.source.Prefix, path),
+		Message: message,
+	}
+}
+
+// prefixed returns path in the file of a source with prefix
+func prefixed(prefix, path []string) []string {
+	if len(prefix) == 0 {
+		return path
+	}
+	return append(slices.Clone(prefix), path...)
+}
+
+// child returns the key and value nodes of a field or list item
+func child(node *yaml.Node, segment string) (*yaml.Node, *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i], node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i], node.Content[i]
+		}
+	}
+	return nil, nil
+}
+
+// sortProblems orders problems by their position in the file
+func sortProblems(problems []Problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+}
+
+// NodeAt returns the node for an instance path: the key node for object fields
+// and the item node for list items. Returns the deepest node found if the path
+// doesn't exist (e.g., a missing required field).
+func NodeAt(node *yaml.Node, path []string) *yaml.Node {
+	found := node
+	for _, segment := range path {
+		key, value := child(node, segment)
+		if value == nil {
+			return found
+		}
+		found, node = key, value
+	}
+	return found
+}
+
+// UnwrapPath returns the path of a value in the values file from its path in a
+// resource that holds the values in spec (see crd.WrapValues)
+func UnwrapPath(path []string) []string {
+	if len(path) > 0 && path[0] == crdgen.SpecField {
+		return path[1:]
+	}
+	return path
+}
+
+// FieldPath splits a Kubernetes field path (e.g., "env[0].name" or
+// "labels[app.kubernetes.io/name]") into its segments
+func FieldPath(path string) []string {
+	var segments []string
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return append(segments, path)
+			}
+			segments = append(segments, path[1:end])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	return segments
+}
@@ -0,0 +1,338 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yamlv3 "gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const problemsValues = `apiVersion: example.com/v1
+kind: Example
+controller:
+  replicas: "three"
+env:
+- name: A
+- name: 5
+`
+
+// parseSources parses a single values file for validation
+func parseSources(t *testing.T, file, content string) (map[string]interface{}, Sources) {
+	t.Helper()
+	source, err := ParseSource(file, []byte(content))
+	require.NoError(t, err)
+	return source.Values, Sources{source}
+}
+
+func TestSchemaProblems(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "controller": {"type": "object", "properties": {"replicas": {"type": "integer"}}},
+    "env": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}}}}
+  }
+}`
+	values, sources := parseSources(t, "values.yaml", problemsValues)
+	problems, err := SchemaProblems(values, []byte(schema), sources)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+
+	assert.Equal(t, "values.yaml:4:3: controller.replicas: got string, want integer", problems[0].String())
+	assert.Equal(t, []string{"env", "1", "name"}, problems[1].Path)
+	assert.Equal(t, 7, problems[1].Line)
+	assert.Equal(t, 3, problems[1].Column)
+}
+
+// TestSchemaProblems_MergeKeys tests that values set by merge keys are
+// validated and located at the fields of their anchors
+func TestSchemaProblems_MergeKeys(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "web": {"type": "object", "properties": {"replicas": {"type": "integer"}, "port": {"type": "integer"}}, "additionalProperties": false}
+  }
+}`
+	values, sources := parseSources(t, "values.yaml", `common: &common
+  replicas: "three"
+web:
+  <<: *common
+  port: 80
+`)
+	problems, err := SchemaProblems(values, []byte(schema), sources)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "values.yaml:2:3: web.replicas: got string, want integer", problems[0].String())
+}
+
+func TestSchemaProblems_Errors(t *testing.T) {
+	_, err := ParseSource("values.yaml", []byte("a: [b"))
+	assert.ErrorContains(t, err, "failed to parse values.yaml")
+
+	values, sources := parseSources(t, "values.yaml", "a: b")
+	_, err = SchemaProblems(values, []byte(`{"type": 5}`), sources)
+	assert.ErrorContains(t, err, "failed to compile schema")
+
+	problems, err := SchemaProblems(values, []byte(`{"type": "object"}`), sources)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestCRDProblems(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: example.com
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          controller:
+            type: object
+            properties:
+              replicas:
+                type: integer
+          env:
+            type: array
+            items:
+              type: object
+              properties:
+                name:
+                  type: string
+`), crd))
+
+	values, sources := parseSources(t, "values.yaml", problemsValues)
+	problems, err := CRDProblems(values, crd, sources)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+
+	assert.Equal(t, []string{"controller", "replicas"}, problems[0].Path)
+	assert.Equal(t, 4, problems[0].Line)
+	assert.Contains(t, problems[0].String(), "values.yaml:4:3: controller.replicas: Invalid value")
+	assert.Equal(t, []string{"env", "1", "name"}, problems[1].Path)
+	assert.Equal(t, 7, problems[1].Line)
+
+	values, sources = parseSources(t, "values.yaml", "apiVersion: example.com/v2\n")
+	_, err = CRDProblems(values, crd, sources)
+	assert.ErrorContains(t, err, "no schema found for version example.com/v2")
+}
+
+func TestCRDProblems_WrapSpec(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    miaka.dev/wrap-spec: "true"
+spec:
+  group: example.com
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              controller:
+                type: object
+                properties:
+                  replicas:
+                    type: integer
+`), crd))
+
+	// Values are validated as the spec of a resource, but located in the values file
+	values, sources := parseSources(t, "values.yaml", problemsValues)
+	problems, err := CRDProblems(values, crd, sources)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, []string{"controller", "replicas"}, problems[0].Path)
+	assert.Equal(t, 4, problems[0].Line)
+}
+
+func TestProblem_GitHubAnnotation(t *testing.T) {
+	p := Problem{File: "charts/a,b.yaml", Line: 3, Column: 5, Path: []string{"port"}, Message: "100% wrong\nreally"}
+	assert.Equal(t, "::error file=charts/a%2Cb.yaml,line=3,col=5::port: 100%25 wrong%0Areally", p.GitHubAnnotation())
+
+	p = Problem{File: "values.yaml", Message: "invalid"}
+	assert.Equal(t, "::error file=values.yaml::invalid", p.GitHubAnnotation())
+	assert.Equal(t, "::warning file=values.yaml::invalid", p.GitHubWarning())
+	assert.Equal(t, "values.yaml: invalid", p.String())
+}
+
+func TestNodeAt_MissingField(t *testing.T) {
+	source, err := ParseSource("values.yaml", []byte("service:\n  port: 80\n"))
+	require.NoError(t, err)
+
+	// A missing field is reported on its closest existing parent
+	node := NodeAt(source.root, []string{"service", "type"})
+	require.NotNil(t, node)
+	assert.Equal(t, "service", node.Value)
+	assert.Equal(t, 1, node.Line)
+}
+
+func TestFieldPath(t *testing.T) {
+	tests := map[string][]string{
+		"":                               nil,
+		"replicas":                       {"replicas"},
+		"service.port":                   {"service", "port"},
+		"env[0].name":                    {"env", "0", "name"},
+		"labels[app.kubernetes.io/name]": {"labels", "app.kubernetes.io/name"},
+		"matrix[1][2]":                   {"matrix", "1", "2"},
+		"broken[1":                       {"broken", "[1"},
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, FieldPath(path), path)
+	}
+}
+
+func TestSources_Locate(t *testing.T) {
+	base := `apiVersion: example.com/v1
+kind: Example
+image:
+  repository: nginx
+  tag: latest
+env:
+- name: A
+- name: B
+resources:
+  cpu: 1
+`
+	prod := `image:
+  tag: 1.2.3
+env:
+- name: C
+resources: null
+`
+	override := `resources:
+  memory: 1Gi
+`
+	var sources Sources
+	for _, f := range []struct{ file, content string }{{"base.yaml", base}, {"prod.yaml", prod}, {"override.yaml", override}} {
+		source, err := ParseSource(f.file, []byte(f.content))
+		require.NoError(t, err)
+		sources = append(sources, source)
+	}
+
+	tests := []struct {
+		path       []string
+		file       string
+		line, col  int
+		wantReason string
+	}{
+		{[]string{"image", "repository"}, "base.yaml", 4, 3, "only set in base"},
+		{[]string{"image", "tag"}, "prod.yaml", 2, 3, "overridden in prod"},
+		{[]string{"image"}, "prod.yaml", 1, 1, "object set in both files"},
+		{[]string{"env", "0", "name"}, "prod.yaml", 4, 3, "lists are replaced, not merged"},
+		{[]string{"env", "1", "name"}, "prod.yaml", 3, 1, "item only exists in the replaced list"},
+		{[]string{"resources", "memory"}, "override.yaml", 2, 3, "set again after null"},
+		{[]string{"resources", "cpu"}, "override.yaml", 1, 1, "deleted by null"},
+		{[]string{"kind"}, "base.yaml", 2, 1, "top-level field"},
+		{nil, "override.yaml", 1, 1, "root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantReason, func(t *testing.T) {
+			p := sources.Locate(tt.path, "message")
+			assert.Equal(t, tt.file, p.File)
+			assert.Equal(t, tt.line, p.Line)
+			assert.Equal(t, tt.col, p.Column)
+			assert.Equal(t, tt.path, p.Path)
+		})
+	}
+}
+
+func TestSource_Subchart(t *testing.T) {
+	parent := `global:
+  domain: example.com
+frontend:
+  replicas: 2
+redis:
+  port: 6379
+  auth:
+    enabled: true
+`
+	source, err := ParseSource("values.yaml", []byte(parent))
+	require.NoError(t, err)
+
+	subchart := source.Subchart("redis")
+	assert.Equal(t, "values.yaml", subchart.File)
+	assert.Equal(t, map[string]interface{}{
+		"port":   float64(6379),
+		"auth":   map[string]interface{}{"enabled": true},
+		"global": map[string]interface{}{"domain": "example.com"},
+	}, subchart.Values)
+
+	// Problems are positioned in the parent's file
+	sources := Sources{subchart}
+	for _, tt := range []struct {
+		path      []string
+		line, col int
+	}{
+		{[]string{"auth", "enabled"}, 8, 5},
+		{[]string{"port"}, 6, 3},
+		{[]string{"global", "domain"}, 2, 3},
+	} {
+		problem := sources.Locate(tt.path, "message")
+		assert.Equal(t, [2]int{tt.line, tt.col}, [2]int{problem.Line, problem.Column}, tt.path)
+	}
+
+	// Sources without the subchart have no values
+	assert.Empty(t, source.Subchart("postgres").Values)
+}
+
+func TestParseEmbeddedSource(t *testing.T) {
+	manifest := `spec:
+  helm:
+    values: |
+      replicas: 0
+
+      auth: &auth
+        enabled: true
+      other: *auth
+    inline: "replicas: 0"
+    object:
+      replicas: 0
+`
+	var doc yamlv3.Node
+	require.NoError(t, yamlv3.Unmarshal([]byte(manifest), &doc))
+	_, helm := child(doc.Content[0], "spec")
+	_, helm = child(helm, "helm")
+
+	// Block scalars keep the layout of the values
+	_, values := child(helm, "values")
+	source, err := ParseEmbeddedSource("app.yaml", []byte(manifest), values)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), source.Values["replicas"])
+	sources := Sources{source}
+	for _, tt := range []struct {
+		path      []string
+		line, col int
+	}{
+		{[]string{"replicas"}, 4, 7},
+		{[]string{"auth", "enabled"}, 7, 9},
+		{[]string{"other", "enabled"}, 7, 9},
+	} {
+		problem := sources.Locate(tt.path, "message")
+		assert.Equal(t, [2]int{tt.line, tt.col}, [2]int{problem.Line, problem.Column}, tt.path)
+	}
+
+	// Other strings are positioned at the string
+	_, inline := child(helm, "inline")
+	source, err = ParseEmbeddedSource("app.yaml", []byte(manifest), inline)
+	require.NoError(t, err)
+	problem := Sources{source}.Locate([]string{"replicas"}, "message")
+	assert.Equal(t, [2]int{9, 13}, [2]int{problem.Line, problem.Column})
+
+	_, object := child(helm, "object")
+	source, err = NodeSource("app.yaml", object)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": float64(0)}, source.Values)
+	problem = Sources{source}.Locate([]string{"replicas"}, "message")
+	assert.Equal(t, [2]int{11, 7}, [2]int{problem.Line, problem.Column})
+}
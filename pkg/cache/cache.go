@@ -0,0 +1,131 @@
+// Package cache stores generated artifacts on disk, keyed by a hash of
+// everything that determines them, so builds of unchanged inputs don't
+// generate them again.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DirEnv overrides the directory of the build cache (see DefaultDir)
+const DirEnv = "MIAKA_CACHE_DIR"
+
+// Cache is a directory of artifacts, each in a file named by its key. Entries
+// are written atomically, so concurrent builds can share a cache. A nil Cache
+// stores nothing.
+type Cache struct {
+	dir string
+}
+
+// New returns the cache in dir, which is created when an entry is first stored
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the directory of the build cache: $MIAKA_CACHE_DIR, or
+// miaka in the user's cache directory (e.g., ~/.cache/miaka)
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the cache directory (set %s): %w", DirEnv, err)
+	}
+	return filepath.Join(dir, "miaka"), nil
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key hashes the parts of a key. Each part is prefixed with its length, so
+// moving bytes from one part to another changes the key.
+func Key(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(strconv.Itoa(len(part)) + ":"))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the artifact stored under key, if any
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores an artifact under key
+func (c *Cache) Put(key string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	// Write to a temporary file first, so readers never see a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Clean removes every entry of the cache, and returns how many there were
+func (c *Cache) Clean() (int, error) {
+	entries := 0
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == c.dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			entries++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		return 0, fmt.Errorf("failed to remove cache: %w", err)
+	}
+	return entries, nil
+}
+
+// path is the file of an entry. Entries are spread over directories by the
+// first two characters of their key, so no directory gets too large.
+func (c *Cache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key)
+	}
+	return filepath.Join(c.dir, key[:2], key)
+}
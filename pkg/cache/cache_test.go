@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := New(dir)
+	assert.Equal(t, dir, c.Dir())
+
+	key := Key([]byte("a"), []byte("b"))
+	_, ok := c.Get(key)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Put(key, []byte("artifact")))
+	data, ok := c.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, []byte("artifact"), data)
+
+	// Entries are replaced
+	require.NoError(t, c.Put(key, []byte("other")))
+	data, _ = c.Get(key)
+	assert.Equal(t, []byte("other"), data)
+
+	require.NoError(t, c.Put(Key([]byte("c")), nil))
+	entries, err := c.Clean()
+	require.NoError(t, err)
+	assert.Equal(t, 2, entries)
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+	_, ok = c.Get(key)
+	assert.False(t, ok)
+
+	// Cleaning a cache that doesn't exist removes nothing
+	entries, err = c.Clean()
+	require.NoError(t, err)
+	assert.Equal(t, 0, entries)
+}
+
+func TestCache_Nil(t *testing.T) {
+	var c *Cache
+	require.NoError(t, c.Put("key", []byte("artifact")))
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key([]byte("ab"), []byte("c")), Key([]byte("ab"), []byte("c")))
+	// Moving bytes between parts changes the key
+	assert.NotEqual(t, Key([]byte("ab"), []byte("c")), Key([]byte("a"), []byte("bc")))
+	assert.NotEqual(t, Key([]byte("abc")), Key([]byte("ab"), []byte("c")))
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv(DirEnv, "/tmp/miaka-cache")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/miaka-cache", dir)
+
+	t.Setenv(DirEnv, "")
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg")
+	t.Setenv("HOME", "/tmp/home")
+	dir, err = DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, "miaka", filepath.Base(dir))
+}
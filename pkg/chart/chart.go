@@ -0,0 +1,229 @@
+// Package chart provides helpers for reading Helm chart directories and packaged archives.
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Well-known files inside a chart
+const (
+	MetadataFile = "Chart.yaml"
+	ValuesFile   = "values.yaml"
+	SchemaFile   = "values.schema.json"
+)
+
+// ErrFileNotFound is returned when a file does not exist in a chart
+var ErrFileNotFound = errors.New("file not found in chart")
+
+// Metadata is the subset of Chart.yaml that miaka uses
+type Metadata struct {
+	APIVersion   string       `json:"apiVersion"`
+	Name         string       `json:"name"`
+	Version      string       `json:"version"`
+	AppVersion   string       `json:"appVersion,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// Dependency is a chart dependency declared in Chart.yaml
+type Dependency struct {
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+	Condition  string `json:"condition,omitempty"`
+}
+
+// ValuesKey returns the top-level values key the dependency is configured under
+func (d Dependency) ValuesKey() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// IsArchive reports whether a path looks like a packaged chart archive
+func IsArchive(chartPath string) bool {
+	return strings.HasSuffix(chartPath, ".tgz") || strings.HasSuffix(chartPath, ".tar.gz")
+}
+
+// ReadFile reads a top-level file (e.g., values.yaml) from a chart.
+// chartPath may be a chart directory or a packaged .tgz archive.
+func ReadFile(chartPath, name string) ([]byte, error) {
+	if !IsArchive(chartPath) {
+		data, err := os.ReadFile(filepath.Join(chartPath, name))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", name, ErrFileNotFound)
+		}
+		return data, err
+	}
+
+	f, err := os.Open(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chart archive: %w", err)
+	}
+	defer f.Close()
+
+	return ReadArchiveFile(f, name)
+}
+
+// ReadArchiveFile reads a top-level file from a gzipped chart archive stream.
+// Chart archives contain a single root directory named after the chart.
+func ReadArchiveFile(r io.Reader, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chart archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chart archive: %w", err)
+		}
+
+		// Entries look like "<chart>/values.yaml"; skip files of nested subcharts
+		parts := strings.SplitN(path.Clean(header.Name), "/", 2)
+		if len(parts) != 2 || parts[1] != name {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from chart archive: %w", name, err)
+		}
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", name, ErrFileNotFound)
+}
+
+// LoadMetadata reads Chart.yaml from a chart directory or archive
+func LoadMetadata(chartPath string) (*Metadata, error) {
+	data, err := ReadFile(chartPath, MetadataFile)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetadata(data)
+}
+
+// ParseMetadata parses the content of a Chart.yaml
+func ParseMetadata(data []byte) (*Metadata, error) {
+	var metadata Metadata
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MetadataFile, err)
+	}
+	return &metadata, nil
+}
+
+// FindDependency locates a dependency chart under <chartDir>/charts, either as an
+// unpacked directory or as a packaged <name>-<version>.tgz archive
+func FindDependency(chartDir, name string) (string, error) {
+	chartsDir := filepath.Join(chartDir, "charts")
+
+	dir := filepath.Join(chartsDir, name)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, nil
+	}
+
+	archive, err := FindArchive(chartsDir, name, "")
+	if err != nil {
+		return "", fmt.Errorf("dependency %q not found in %s (run 'helm dependency build' first)", name, chartsDir)
+	}
+	return archive, nil
+}
+
+// FindArchive locates a packaged <name>-<version>.tgz archive in dir.
+// version may be empty or a wildcard such as "18.x" or "18.*"; when several
+// archives match, the highest version is returned.
+func FindArchive(dir, name, version string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var best, bestVersion string
+	for _, entry := range entries {
+		archiveVersion, ok := archiveVersion(entry.Name(), name)
+		if !ok || !MatchVersion(version, archiveVersion) {
+			continue
+		}
+		if best == "" || CompareVersions(archiveVersion, bestVersion) > 0 {
+			best, bestVersion = filepath.Join(dir, entry.Name()), archiveVersion
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no archive for chart %q matching version %q in %s", name, version, dir)
+	}
+	return best, nil
+}
+
+// archiveVersion extracts the version from a "<name>-<version>.tgz" file name
+func archiveVersion(fileName, name string) (string, bool) {
+	if !IsArchive(fileName) || !strings.HasPrefix(fileName, name+"-") {
+		return "", false
+	}
+	version := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(fileName, name+"-"), ".tgz"), ".tar.gz")
+
+	// Versions start with a digit; this keeps "redis" from matching "redis-cluster-1.0.0.tgz"
+	version = strings.TrimPrefix(version, "v")
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return "", false
+	}
+	return version, true
+}
+
+// MatchVersion reports whether version satisfies a simple wildcard pattern.
+// Patterns match segment by segment: "18.x", "18.*" and "18" all match "18.1.2".
+// An empty pattern matches any version.
+func MatchVersion(pattern, version string) bool {
+	pattern = strings.TrimPrefix(pattern, "v")
+	if pattern == "" {
+		return true
+	}
+
+	want := strings.Split(pattern, ".")
+	have := strings.Split(version, ".")
+	for i, segment := range want {
+		if segment == "x" || segment == "X" || segment == "*" {
+			continue
+		}
+		if i >= len(have) || have[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareVersions compares dotted versions numerically, segment by segment
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.SplitN(as[i], "-", 2)[0])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(strings.SplitN(bs[i], "-", 2)[0])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return strings.Compare(a, b)
+}
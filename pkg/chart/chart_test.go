@@ -0,0 +1,139 @@
+package chart
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchive creates a chart archive containing the given files under a root directory
+func writeArchive(t *testing.T, path, root string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: root + "/" + name,
+			Mode: 0644,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestReadFile_Directory(t *testing.T) {
+	chartDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, ValuesFile), []byte("a: 1\n"), 0644))
+
+	data, err := ReadFile(chartDir, ValuesFile)
+	require.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(data))
+
+	_, err = ReadFile(chartDir, SchemaFile)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+}
+
+func TestReadFile_Archive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "redis-18.1.0.tgz")
+	writeArchive(t, archive, "redis", map[string]string{
+		SchemaFile:                    `{"type": "object"}`,
+		"charts/common/" + SchemaFile: `{"nested": true}`,
+	})
+
+	data, err := ReadFile(archive, SchemaFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "object"}`, string(data))
+
+	_, err = ReadFile(archive, ValuesFile)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+}
+
+func TestLoadMetadata(t *testing.T) {
+	chartDir := t.TempDir()
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.0.0
+dependencies:
+- name: redis
+  version: 17.x
+  repository: https://charts.bitnami.com/bitnami
+  alias: cache
+`
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, MetadataFile), []byte(chartYAML), 0644))
+
+	metadata, err := LoadMetadata(chartDir)
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", metadata.Name)
+	require.Len(t, metadata.Dependencies, 1)
+	assert.Equal(t, "cache", metadata.Dependencies[0].ValuesKey())
+	assert.Equal(t, "redis", Dependency{Name: "redis"}.ValuesKey())
+}
+
+func TestFindDependency(t *testing.T) {
+	chartDir := t.TempDir()
+	chartsDir := filepath.Join(chartDir, "charts")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartsDir, "postgresql"), 0755))
+	writeArchive(t, filepath.Join(chartsDir, "redis-cluster-9.0.0.tgz"), "redis-cluster", nil)
+	writeArchive(t, filepath.Join(chartsDir, "redis-17.3.0.tgz"), "redis", nil)
+
+	path, err := FindDependency(chartDir, "postgresql")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(chartsDir, "postgresql"), path)
+
+	path, err = FindDependency(chartDir, "redis")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(chartsDir, "redis-17.3.0.tgz"), path)
+
+	_, err = FindDependency(chartDir, "mysql")
+	require.Error(t, err)
+}
+
+func TestFindArchive(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"redis-17.3.0.tgz", "redis-18.2.0.tgz", "redis-18.10.1.tgz"} {
+		writeArchive(t, filepath.Join(dir, name), "redis", nil)
+	}
+
+	path, err := FindArchive(dir, "redis", "18.x")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "redis-18.10.1.tgz"), path)
+
+	path, err = FindArchive(dir, "redis", "17")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "redis-17.3.0.tgz"), path)
+
+	_, err = FindArchive(dir, "redis", "19.x")
+	require.Error(t, err)
+}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		pattern string
+		version string
+		want    bool
+	}{
+		{"", "1.2.3", true},
+		{"18.x", "18.1.2", true},
+		{"18.*", "18.1.2", true},
+		{"v18", "18.1.2", true},
+		{"18.1.x", "18.2.0", false},
+		{"18.x", "17.9.9", false},
+		{"18.1.2", "18.1.2", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchVersion(tt.pattern, tt.version), "MatchVersion(%q, %q)", tt.pattern, tt.version)
+	}
+}
@@ -0,0 +1,71 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LoadValues reads the default values of a chart directory or archive.
+// A chart without values.yaml has no defaults.
+func LoadValues(chartPath string) (map[string]interface{}, error) {
+	data, err := ReadFile(chartPath, ValuesFile)
+	if errors.Is(err, ErrFileNotFound) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ValuesFile, err)
+	}
+	return values, nil
+}
+
+// CoalesceValues merges override into base the way Helm merges values files:
+// objects are merged key by key, other values (including lists) are replaced,
+// and a null override deletes the key. Neither argument is modified.
+func CoalesceValues(base, override map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range override {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		baseMap, baseIsMap := result[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			result[key] = CoalesceValues(baseMap, overrideMap)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// GlobalKey is the top-level key of the values that Helm shares with every
+// subchart
+const GlobalKey = "global"
+
+// SubchartValues returns the values Helm passes to the subchart under key: the
+// values under the key, with the global values of the parent merged over the
+// subchart's own. Returns false if the values have no object under the key.
+func SubchartValues(values map[string]interface{}, key string) (map[string]interface{}, bool) {
+	subchart, ok := values[key].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	result := CoalesceValues(map[string]interface{}{}, subchart)
+	if global, ok := values[GlobalKey].(map[string]interface{}); ok {
+		own, _ := subchart[GlobalKey].(map[string]interface{})
+		result[GlobalKey] = CoalesceValues(own, global)
+	}
+	return result, true
+}
@@ -0,0 +1,79 @@
+package chart
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadValues(t *testing.T) {
+	dir := t.TempDir()
+
+	values, err := LoadValues(dir)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ValuesFile), []byte("replicas: 1\n"), 0644))
+	values, err = LoadValues(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": float64(1)}, values)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ValuesFile), []byte("replicas: [\n"), 0644))
+	_, err = LoadValues(dir)
+	assert.Error(t, err)
+}
+
+func TestCoalesceValues(t *testing.T) {
+	base := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+		"args":     []interface{}{"a", "b"},
+		"debug":    map[string]interface{}{"enabled": true},
+	}
+	override := map[string]interface{}{
+		"image": map[string]interface{}{"tag": "2.0"},
+		"args":  []interface{}{"c"},
+		"debug": nil,
+		"extra": "x",
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "2.0"},
+		"args":     []interface{}{"c"},
+		"extra":    "x",
+	}, CoalesceValues(base, override))
+
+	// Inputs are unchanged
+	assert.Equal(t, map[string]interface{}{"repository": "nginx", "tag": "1.0"}, base["image"])
+	assert.Contains(t, base, "debug")
+}
+
+func TestSubchartValues(t *testing.T) {
+	values := map[string]interface{}{
+		"replicas": 1,
+		"global":   map[string]interface{}{"domain": "example.com", "env": "prod"},
+		"redis": map[string]interface{}{
+			"port":   6379,
+			"global": map[string]interface{}{"env": "dev", "region": "eu"},
+		},
+		"disabled": false,
+	}
+
+	subchart, ok := SubchartValues(values, "redis")
+	require.True(t, ok)
+	// The parent's globals take precedence over the subchart's
+	assert.Equal(t, map[string]interface{}{
+		"port":   6379,
+		"global": map[string]interface{}{"domain": "example.com", "env": "prod", "region": "eu"},
+	}, subchart)
+	assert.Equal(t, map[string]interface{}{"env": "dev", "region": "eu"}, values["redis"].(map[string]interface{})["global"])
+
+	_, ok = SubchartValues(values, "postgres")
+	assert.False(t, ok)
+	_, ok = SubchartValues(values, "disabled")
+	assert.False(t, ok)
+}
@@ -0,0 +1,314 @@
+// Package corpus generates and stores a regression corpus of valid values
+// documents, so schema edits that invalidate previously-valid values are caught.
+package corpus
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Defaults for values the schema doesn't constrain
+const (
+	maxExtraItems    = 3
+	maxMapEntries    = 3
+	defaultNumRange  = 100
+	defaultMaxLength = 12
+
+	// includeOptional is the probability of setting an optional field
+	includeOptional = 0.6
+)
+
+const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Generator produces random values documents from a CRD schema, using an
+// example document for values it can't generate (e.g., strings with a pattern)
+type Generator struct {
+	schema  *apiextensionsv1.JSONSchemaProps
+	example map[string]interface{}
+	rand    *rand.Rand
+}
+
+// NewGenerator creates a generator. The same seed always produces the same documents.
+func NewGenerator(schema *apiextensionsv1.JSONSchemaProps, example map[string]interface{}, seed int64) *Generator {
+	return &Generator{schema: schema, example: example, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Document generates a values document. apiVersion and kind are copied from the
+// example, and metadata is never set. Documents aren't guaranteed to be valid
+// (e.g., CEL rules aren't considered), so callers should validate them.
+func (g *Generator) Document() map[string]interface{} {
+	doc, _ := g.object(g.schema, g.example, true).(map[string]interface{})
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	for _, key := range []string{"apiVersion", "kind"} {
+		if value, ok := g.example[key]; ok {
+			doc[key] = value
+		}
+	}
+	return doc
+}
+
+// value generates a value for a schema. example is the value at the same path in the example document, if any.
+func (g *Generator) value(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	if len(props.Enum) > 0 {
+		var value interface{}
+		if err := json.Unmarshal(props.Enum[g.rand.Intn(len(props.Enum))].Raw, &value); err == nil {
+			return value
+		}
+	}
+	if props.XIntOrString {
+		if example != nil && g.rand.Intn(2) == 0 {
+			return example
+		}
+		return int64(g.rand.Intn(defaultNumRange) + 1)
+	}
+
+	switch props.Type {
+	case "object":
+		return g.object(props, example, false)
+	case "array":
+		return g.array(props, example)
+	case "string":
+		return g.string(props, example)
+	case "integer":
+		return g.integer(props, example)
+	case "number":
+		return g.number(props, example)
+	case "boolean":
+		return g.rand.Intn(2) == 0
+	default:
+		return fallback(props, example)
+	}
+}
+
+// object generates an object with all required properties and a random subset of the others
+func (g *Generator) object(props *apiextensionsv1.JSONSchemaProps, example interface{}, root bool) interface{} {
+	exampleMap, _ := example.(map[string]interface{})
+	if len(props.XValidations) > 0 && exampleMap != nil {
+		// Values passing CEL rules can't be generated, but the example passes them
+		return exampleMap
+	}
+	preserve := props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields
+	if len(props.Properties) == 0 && (preserve || props.AdditionalProperties == nil) {
+		if exampleMap != nil {
+			return exampleMap
+		}
+		return map[string]interface{}{}
+	}
+
+	result := map[string]interface{}{}
+	if len(props.Properties) == 0 {
+		// Map with arbitrary keys
+		n := g.between(int(valueOr(props.MinProperties, 0)), int(valueOr(props.MaxProperties, int64(maxMapEntries))))
+		// Take the example of the first key, so documents are the same for a seed
+		keys := make([]string, 0, len(exampleMap))
+		for key := range exampleMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var itemExample interface{}
+		if len(keys) > 0 {
+			itemExample = exampleMap[keys[0]]
+		}
+		for i := 0; i < n; i++ {
+			if props.AdditionalProperties.Schema != nil {
+				result[g.word(3, 8)] = g.value(props.AdditionalProperties.Schema, itemExample)
+			} else {
+				result[g.word(3, 8)] = g.word(1, defaultMaxLength)
+			}
+		}
+		return result
+	}
+
+	required := make(map[string]bool, len(props.Required))
+	for _, name := range props.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(props.Properties))
+	for name := range props.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if root && (name == "metadata" || name == "apiVersion" || name == "kind") {
+			continue
+		}
+		if !required[name] && g.rand.Float64() >= includeOptional {
+			continue
+		}
+		prop := props.Properties[name]
+		if value := g.value(&prop, exampleMap[name]); value != nil {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// array generates a list within the schema's length bounds
+func (g *Generator) array(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	exampleList, _ := example.([]interface{})
+	if props.Items == nil || props.Items.Schema == nil {
+		return fallback(props, example)
+	}
+
+	minItems := int(valueOr(props.MinItems, 0))
+	maxItems := int(valueOr(props.MaxItems, int64(minItems+maxExtraItems)))
+	n := g.between(minItems, min(maxItems, minItems+maxExtraItems))
+
+	var itemExample interface{}
+	if len(exampleList) > 0 {
+		itemExample = exampleList[0]
+	}
+
+	unique := props.XListType != nil && *props.XListType != "atomic"
+	seen := make(map[string]bool)
+	result := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item := g.value(props.Items.Schema, itemExample)
+		if unique {
+			key, _ := json.Marshal(item)
+			if seen[string(key)] {
+				continue
+			}
+			seen[string(key)] = true
+		}
+		result = append(result, item)
+	}
+	if len(result) < minItems && exampleList != nil {
+		return exampleList
+	}
+	return result
+}
+
+// string generates a random string, or uses the example when the format can't be generated
+func (g *Generator) string(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	if props.Pattern != "" || props.Format != "" {
+		return fallback(props, example)
+	}
+	if s, ok := example.(string); ok && g.rand.Intn(4) == 0 {
+		return s
+	}
+	minLength := int(valueOr(props.MinLength, 1))
+	maxLength := int(valueOr(props.MaxLength, int64(max(minLength, defaultMaxLength))))
+	return g.word(minLength, maxLength)
+}
+
+// integer generates an integer within the schema's bounds
+func (g *Generator) integer(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	lo, hi, ok := bounds(props)
+	if !ok {
+		return fallback(props, example)
+	}
+	low, high := int64(math.Ceil(lo)), int64(math.Floor(hi))
+	if props.MultipleOf != nil && *props.MultipleOf >= 1 {
+		m := int64(*props.MultipleOf)
+		low, high = ceilDiv(low, m), floorDiv(high, m)
+		if low > high {
+			return fallback(props, example)
+		}
+		return (low + g.rand.Int63n(high-low+1)) * m
+	}
+	if low > high {
+		return fallback(props, example)
+	}
+	return low + g.rand.Int63n(high-low+1)
+}
+
+// number generates a number within the schema's bounds, in steps of 0.5
+func (g *Generator) number(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	if props.MultipleOf != nil {
+		return g.integer(props, example)
+	}
+	lo, hi, ok := bounds(props)
+	if !ok || hi < lo {
+		return fallback(props, example)
+	}
+	steps := int64((hi - lo) * 2)
+	if steps <= 0 {
+		return lo
+	}
+	return lo + float64(g.rand.Int63n(steps+1))/2
+}
+
+// bounds returns the inclusive range of a numeric schema
+func bounds(props *apiextensionsv1.JSONSchemaProps) (lo, hi float64, ok bool) {
+	switch {
+	case props.Minimum != nil && props.Maximum != nil:
+		lo, hi = *props.Minimum, *props.Maximum
+	case props.Minimum != nil:
+		lo = *props.Minimum
+		hi = lo + defaultNumRange
+	case props.Maximum != nil:
+		hi = *props.Maximum
+		lo = min(0, hi-defaultNumRange)
+	default:
+		lo, hi = 0, defaultNumRange
+	}
+	if props.ExclusiveMinimum {
+		lo++
+	}
+	if props.ExclusiveMaximum {
+		hi--
+	}
+	return lo, hi, lo <= hi
+}
+
+// fallback returns the example value, or the schema default when there is no example
+func fallback(props *apiextensionsv1.JSONSchemaProps, example interface{}) interface{} {
+	if example != nil {
+		return example
+	}
+	if props.Default != nil {
+		var value interface{}
+		if err := json.Unmarshal(props.Default.Raw, &value); err == nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// word generates a random lowercase alphanumeric string
+func (g *Generator) word(minLength, maxLength int) string {
+	n := g.between(minLength, maxLength)
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[g.rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// between returns a random integer in [lo, hi], or lo if the range is empty
+func (g *Generator) between(lo, hi int) int {
+	if hi <= lo {
+		return lo
+	}
+	return lo + g.rand.Intn(hi-lo+1)
+}
+
+// valueOr dereferences an optional bound
+func valueOr(v *int64, def int64) int64 {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+// ceilDiv divides rounding towards positive infinity
+func ceilDiv(a, b int64) int64 {
+	return -floorDiv(-a, b)
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
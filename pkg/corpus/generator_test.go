@@ -0,0 +1,123 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// loadTestdata reads the CRD and example of a build test case
+func loadTestdata(t *testing.T, name string) (*apiextensionsv1.CustomResourceDefinition, map[string]interface{}) {
+	t.Helper()
+	dir := filepath.Join("..", "..", "testdata", "build", name)
+
+	data, err := os.ReadFile(filepath.Join(dir, "expected_crd.yaml"))
+	require.NoError(t, err)
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal(data, crd))
+
+	data, err = os.ReadFile(filepath.Join(dir, "input.yaml"))
+	require.NoError(t, err)
+	example := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &example))
+	return crd, example
+}
+
+func TestGenerator_Testdata(t *testing.T) {
+	for _, name := range []string{"minimal", "basic", "comprehensive", "argo-events"} {
+		t.Run(name, func(t *testing.T) {
+			crd, example := loadTestdata(t, name)
+			g := NewGenerator(crd.Spec.Versions[0].Schema.OpenAPIV3Schema, example, 1)
+
+			valid := 0
+			for i := 0; i < 20; i++ {
+				doc := g.Document()
+				assert.Equal(t, example["apiVersion"], doc["apiVersion"])
+				assert.NotContains(t, doc, "metadata")
+
+				errs, err := validation.ValidateResource(crd, doc)
+				require.NoError(t, err)
+				if len(errs) == 0 {
+					valid++
+				}
+			}
+			// Most documents are valid; the rest are discarded by callers
+			assert.GreaterOrEqual(t, valid, 10, "valid documents")
+		})
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	crd, example := loadTestdata(t, "comprehensive")
+	schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+
+	a, b := NewGenerator(schema, example, 7), NewGenerator(schema, example, 7)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, a.Document(), b.Document())
+	}
+}
+
+func TestGenerator_Constraints(t *testing.T) {
+	minimum, maximum, multiple := 10.0, 20.0, 5.0
+	minLength, maxLength := int64(3), int64(5)
+	minItems, maxItems := int64(2), int64(2)
+	setType := "set"
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"port", "ratio", "name", "tags", "mode", "id"},
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"port":  {Type: "integer", Minimum: &minimum, Maximum: &maximum, MultipleOf: &multiple},
+			"ratio": {Type: "number", Minimum: &minimum, Maximum: &maximum, ExclusiveMaximum: true},
+			"name":  {Type: "string", MinLength: &minLength, MaxLength: &maxLength},
+			"tags": {Type: "array", MinItems: &minItems, MaxItems: &maxItems, XListType: &setType,
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			"mode": {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"a"`)}, {Raw: []byte(`"b"`)}}},
+			"id":   {Type: "string", Pattern: "^[0-9]+$"},
+		},
+	}
+	example := map[string]interface{}{"id": "42"}
+
+	g := NewGenerator(schema, example, 1)
+	for i := 0; i < 50; i++ {
+		doc := g.Document()
+		assert.Contains(t, []int64{10, 15, 20}, doc["port"])
+		assert.GreaterOrEqual(t, doc["ratio"], minimum)
+		assert.Less(t, doc["ratio"], maximum)
+		assert.GreaterOrEqual(t, len(doc["name"].(string)), 3)
+		assert.LessOrEqual(t, len(doc["name"].(string)), 5)
+		assert.Len(t, doc["tags"], 2)
+		assert.Contains(t, []interface{}{"a", "b"}, doc["mode"])
+		assert.Equal(t, "42", doc["id"])
+	}
+}
+
+func TestGenerator_CELRules(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"autoscaling"},
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"autoscaling": {
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"minReplicas": {Type: "integer"},
+					"maxReplicas": {Type: "integer"},
+				},
+				XValidations: apiextensionsv1.ValidationRules{{Rule: "self.maxReplicas >= self.minReplicas"}},
+			},
+		},
+	}
+	autoscaling := map[string]interface{}{"minReplicas": float64(1), "maxReplicas": float64(3)}
+	example := map[string]interface{}{"autoscaling": autoscaling}
+
+	// Objects with CEL rules keep their example, which passes them
+	g := NewGenerator(schema, example, 1)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, autoscaling, g.Document()["autoscaling"])
+	}
+}
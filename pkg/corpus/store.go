@@ -0,0 +1,90 @@
+package corpus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// hashLength is the number of hex characters of the content hash used in file names
+const hashLength = 16
+
+// Entry is a document stored in a corpus directory
+type Entry struct {
+	// Name is the file name, derived from the hash of the content (e.g., "0123456789abcdef.yaml")
+	Name string
+
+	// Values is the parsed document
+	Values map[string]interface{}
+}
+
+// Encode renders a document as YAML with sorted keys and returns its file name
+func Encode(values map[string]interface{}) (string, []byte, error) {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:hashLength] + ".yaml", data, nil
+}
+
+// Load reads every document in a corpus directory, sorted by name.
+// A missing directory is an empty corpus.
+func Load(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".yaml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read corpus document: %w", err)
+		}
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse corpus document %s: %w", file.Name(), err)
+		}
+		entries = append(entries, Entry{Name: file.Name(), Values: values})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// Write stores a document in a corpus directory and returns its file name
+func Write(dir string, values map[string]interface{}) (string, error) {
+	name, data, err := Encode(values)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write corpus document: %w", err)
+	}
+	return name, nil
+}
+
+// Remove deletes a document from a corpus directory
+func Remove(dir, name string) error {
+	if err := os.Remove(filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("failed to remove corpus document: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	name, data, err := Encode(map[string]interface{}{"b": 1, "a": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "a: x\nb: 1\n", string(data))
+	assert.Regexp(t, `^[0-9a-f]{16}\.yaml$`, name)
+
+	// Key order doesn't affect the name
+	same, _, err := Encode(map[string]interface{}{"a": "x", "b": 1})
+	require.NoError(t, err)
+	assert.Equal(t, name, same)
+}
+
+func TestStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+
+	entries, err := Load(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	first, err := Write(dir, map[string]interface{}{"port": 80})
+	require.NoError(t, err)
+	second, err := Write(dir, map[string]interface{}{"port": 443})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0644))
+
+	entries, err = Load(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	names := []string{entries[0].Name, entries[1].Name}
+	assert.ElementsMatch(t, []string{first, second}, names)
+	assert.Less(t, names[0], names[1])
+
+	require.NoError(t, Remove(dir, first))
+	entries, err = Load(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"port": float64(443)}, entries[0].Values)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("port: [\n"), 0644))
+	_, err = Load(dir)
+	assert.Error(t, err)
+}
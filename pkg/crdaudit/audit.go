@@ -0,0 +1,369 @@
+// Package crdaudit compares generated CRDs with the copies shipped in a Helm
+// chart's crds/ and templates/ directories, reporting schemas that have drifted.
+package crdaudit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ShippedDirs are the chart directories searched for shipped CRDs
+var ShippedDirs = []string{"crds", "templates"}
+
+// Shipped is a CRD shipped in a chart
+type Shipped struct {
+	// Source is the file the CRD was found in
+	Source string
+	CRD    *apiextensionsv1.CustomResourceDefinition
+}
+
+// Difference is a schema keyword that differs between a generated and a shipped CRD
+type Difference struct {
+	// Field is the path of the field in the schema (e.g., image.tag), or "" for the root
+	Field string `json:"field"`
+	// Keyword is the schema keyword that differs, or "" if the field is missing from one CRD
+	Keyword   string      `json:"keyword,omitempty"`
+	Generated interface{} `json:"generated,omitempty"`
+	Shipped   interface{} `json:"shipped,omitempty"`
+}
+
+func (d Difference) String() string {
+	field := d.Field
+	if field == "" {
+		field = "(root)"
+	}
+	switch {
+	case d.Keyword != "":
+		return fmt.Sprintf("%s: %s is %s in the shipped CRD, %s in the generated CRD", field, d.Keyword, formatValue(d.Shipped), formatValue(d.Generated))
+	case d.Shipped == nil:
+		return fmt.Sprintf("%s: missing from the shipped CRD", field)
+	default:
+		return fmt.Sprintf("%s: not in the generated CRD", field)
+	}
+}
+
+// Drift is a version of a shipped CRD that doesn't match the generated CRD
+type Drift struct {
+	Group   string `json:"group"`
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+	// Source is the file the shipped CRD was found in
+	Source string `json:"source"`
+	// Message describes drift that isn't a schema difference (e.g., a missing version)
+	Message     string       `json:"message,omitempty"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// Report is the result of an audit
+type Report struct {
+	// Compared lists the files of shipped CRDs that were compared with generated CRDs
+	Compared []string `json:"compared"`
+	// Drifts lists the shipped CRD versions that don't match the generated CRDs
+	Drifts []Drift `json:"drifts"`
+	// Skipped lists templates that look like CRDs but couldn't be parsed
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// HasDrift reports whether any shipped CRD has drifted
+func (r *Report) HasDrift() bool {
+	return len(r.Drifts) > 0
+}
+
+// LoadFile reads the CRDs in a YAML or JSON file. Documents that aren't CRDs are skipped.
+func LoadFile(path string) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	crds, err := parseCRDs(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return crds, nil
+}
+
+// LoadShipped finds the CRDs shipped under a chart's crds/ and templates/
+// directories. Templates are parsed after removing lines that only hold template
+// actions (e.g., {{- if .Values.installCRDs }}); the paths of templates that
+// mention CustomResourceDefinition but still can't be parsed are returned as skipped.
+func LoadShipped(chartDir string) ([]Shipped, []string, error) {
+	var shipped []Shipped
+	var skipped []string
+	for _, dir := range ShippedDirs {
+		root := filepath.Join(chartDir, dir)
+		if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+			default:
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			if !bytes.Contains(data, []byte("CustomResourceDefinition")) {
+				return nil
+			}
+
+			crds, ok := parseTemplate(data)
+			if !ok {
+				skipped = append(skipped, path)
+			}
+			for _, crd := range crds {
+				shipped = append(shipped, Shipped{Source: path, CRD: crd})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return shipped, skipped, nil
+}
+
+// Audit compares each version of the generated CRDs with the shipped CRDs of
+// the same group and kind. Shipped CRDs of other kinds are ignored.
+func Audit(generated []*apiextensionsv1.CustomResourceDefinition, shipped []Shipped) *Report {
+	report := &Report{Compared: []string{}, Drifts: []Drift{}}
+	compared := make(map[string]bool)
+	for _, gen := range generated {
+		for _, s := range shipped {
+			if s.CRD.Spec.Group != gen.Spec.Group || s.CRD.Spec.Names.Kind != gen.Spec.Names.Kind {
+				continue
+			}
+			if !compared[s.Source] {
+				compared[s.Source] = true
+				report.Compared = append(report.Compared, s.Source)
+			}
+			report.Drifts = append(report.Drifts, compareCRDs(gen, s)...)
+		}
+	}
+	return report
+}
+
+// compareCRDs compares the versions of a generated CRD and a shipped copy
+func compareCRDs(generated *apiextensionsv1.CustomResourceDefinition, shipped Shipped) []Drift {
+	newDrift := func(version string) Drift {
+		return Drift{Group: generated.Spec.Group, Kind: generated.Spec.Names.Kind, Version: version, Source: shipped.Source}
+	}
+
+	var drifts []Drift
+	shippedVersions := make(map[string]*apiextensionsv1.CustomResourceDefinitionVersion)
+	for i := range shipped.CRD.Spec.Versions {
+		shippedVersions[shipped.CRD.Spec.Versions[i].Name] = &shipped.CRD.Spec.Versions[i]
+	}
+
+	generatedVersions := make(map[string]bool)
+	for _, genVersion := range generated.Spec.Versions {
+		generatedVersions[genVersion.Name] = true
+		shippedVersion, ok := shippedVersions[genVersion.Name]
+		if !ok {
+			drift := newDrift(genVersion.Name)
+			drift.Message = "version is missing from the shipped CRD"
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		differences := diffSchemas("", toMap(versionSchema(&genVersion)), toMap(versionSchema(shippedVersion)))
+		if len(differences) > 0 {
+			drift := newDrift(genVersion.Name)
+			drift.Differences = differences
+			drifts = append(drifts, drift)
+		}
+	}
+
+	for _, shippedVersion := range shipped.CRD.Spec.Versions {
+		if !generatedVersions[shippedVersion.Name] {
+			drift := newDrift(shippedVersion.Name)
+			drift.Message = "version is not in the generated CRD"
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// diffSchemas compares two schemas as generic maps, recursing into properties,
+// items, and additionalProperties
+func diffSchemas(field string, generated, shipped map[string]interface{}) []Difference {
+	var differences []Difference
+	for _, keyword := range unionKeys(generated, shipped) {
+		genValue, shippedValue := generated[keyword], shipped[keyword]
+		genMap, genIsMap := genValue.(map[string]interface{})
+		shippedMap, shippedIsMap := shippedValue.(map[string]interface{})
+		if keyword == "properties" {
+			// Compare each property, even if one schema has none
+			if genValue == nil {
+				genMap, genIsMap = map[string]interface{}{}, true
+			}
+			if shippedValue == nil {
+				shippedMap, shippedIsMap = map[string]interface{}{}, true
+			}
+		}
+
+		switch {
+		case keyword == "properties" && genIsMap && shippedIsMap:
+			for _, name := range unionKeys(genMap, shippedMap) {
+				child := joinField(field, name)
+				genProp, inGenerated := genMap[name].(map[string]interface{})
+				shippedProp, inShipped := shippedMap[name].(map[string]interface{})
+				switch {
+				case !inShipped:
+					differences = append(differences, Difference{Field: child, Generated: genProp})
+				case !inGenerated:
+					differences = append(differences, Difference{Field: child, Shipped: shippedProp})
+				default:
+					differences = append(differences, diffSchemas(child, genProp, shippedProp)...)
+				}
+			}
+		case keyword == "items" && genIsMap && shippedIsMap:
+			differences = append(differences, diffSchemas(field+"[]", genMap, shippedMap)...)
+		case keyword == "additionalProperties" && genIsMap && shippedIsMap:
+			differences = append(differences, diffSchemas(field+"[*]", genMap, shippedMap)...)
+		case !reflect.DeepEqual(genValue, shippedValue):
+			differences = append(differences, Difference{Field: field, Keyword: keyword, Generated: genValue, Shipped: shippedValue})
+		}
+	}
+	return differences
+}
+
+// templateAction matches lines that only hold template actions
+var templateAction = regexp.MustCompile(`^\s*\{\{.*\}\}\s*$`)
+
+// documentSeparator matches YAML document separators
+var documentSeparator = regexp.MustCompile(`(?m)^---.*$`)
+
+// parseTemplate parses the CRDs in a file that may contain template actions.
+// Each document is parsed on its own, so one templated document doesn't hide
+// the others. ok is false if a document mentioning CustomResourceDefinition
+// couldn't be parsed.
+func parseTemplate(data []byte) (crds []*apiextensionsv1.CustomResourceDefinition, ok bool) {
+	ok = true
+	for _, doc := range documentSeparator.Split(string(data), -1) {
+		var lines []string
+		for _, line := range strings.Split(doc, "\n") {
+			if !templateAction.MatchString(line) {
+				lines = append(lines, line)
+			}
+		}
+
+		docCRDs, err := parseCRDs([]byte(strings.Join(lines, "\n")))
+		if err != nil {
+			if strings.Contains(doc, "CustomResourceDefinition") {
+				ok = false
+			}
+			continue
+		}
+		crds = append(crds, docCRDs...)
+	}
+	return crds, ok
+}
+
+// parseCRDs decodes the CRDs in YAML or JSON documents, skipping other documents
+func parseCRDs(data []byte) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		// Decode generically first, so documents that aren't CRDs can have any shape
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return crds, nil
+			}
+			return nil, err
+		}
+		if doc["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+
+		docJSON, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := json.Unmarshal(docJSON, crd); err != nil {
+			return nil, fmt.Errorf("failed to parse CRD: %w", err)
+		}
+		crds = append(crds, crd)
+	}
+}
+
+// versionSchema returns the schema of a CRD version, or nil if it has none
+func versionSchema(version *apiextensionsv1.CustomResourceDefinitionVersion) *apiextensionsv1.JSONSchemaProps {
+	if version.Schema == nil {
+		return nil
+	}
+	return version.Schema.OpenAPIV3Schema
+}
+
+// toMap converts a schema to a generic map
+func toMap(props *apiextensionsv1.JSONSchemaProps) map[string]interface{} {
+	result := map[string]interface{}{}
+	if props == nil {
+		return result
+	}
+	data, err := json.Marshal(props)
+	if err != nil {
+		return result
+	}
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// formatValue formats a schema value compactly, or "unset" if it's missing
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "unset"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,187 @@
+package crdaudit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          replicas:
+            type: integer
+            minimum: 1
+          image:
+            type: object
+            properties:
+              tag:
+                type: string
+`
+
+// writeFiles writes files into a temp directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestAudit_NoDrift(t *testing.T) {
+	chartDir := writeFiles(t, map[string]string{
+		"crd.yaml":                  widgetCRD,
+		"crds/widget.yaml":          widgetCRD,
+		"templates/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Release.Name }}\n",
+	})
+
+	generated, err := LoadFile(filepath.Join(chartDir, "crd.yaml"))
+	require.NoError(t, err)
+	shipped, skipped, err := LoadShipped(chartDir)
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	report := Audit(generated, shipped)
+	assert.False(t, report.HasDrift())
+	assert.Equal(t, []string{filepath.Join(chartDir, "crds", "widget.yaml")}, report.Compared)
+}
+
+func TestAudit_Drift(t *testing.T) {
+	stale := strings.NewReplacer(
+		"minimum: 1", "minimum: 0",
+		"              tag:\n                type: string\n", "              tag:\n                type: integer\n              pullPolicy:\n                type: string\n",
+	).Replace(widgetCRD)
+	// Wrapped in template actions, with a v1alpha1 version that's no longer generated
+	templated := "{{- if .Values.installCRDs }}\n" + stale + `  - name: v1alpha1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+{{- end }}
+`
+	chartDir := writeFiles(t, map[string]string{
+		"crd.yaml":               widgetCRD,
+		"templates/widgets.yaml": templated,
+	})
+
+	generated, err := LoadFile(filepath.Join(chartDir, "crd.yaml"))
+	require.NoError(t, err)
+	shipped, _, err := LoadShipped(chartDir)
+	require.NoError(t, err)
+
+	report := Audit(generated, shipped)
+	require.True(t, report.HasDrift())
+	require.Len(t, report.Drifts, 2)
+
+	v1 := report.Drifts[0]
+	assert.Equal(t, "v1", v1.Version)
+	assert.Equal(t, filepath.Join(chartDir, "templates", "widgets.yaml"), v1.Source)
+	var differences []string
+	for _, difference := range v1.Differences {
+		differences = append(differences, difference.String())
+	}
+	assert.Equal(t, []string{
+		"image.pullPolicy: not in the generated CRD",
+		`image.tag: type is "integer" in the shipped CRD, "string" in the generated CRD`,
+		"replicas: minimum is 0 in the shipped CRD, 1 in the generated CRD",
+	}, differences)
+
+	assert.Equal(t, "v1alpha1", report.Drifts[1].Version)
+	assert.Equal(t, "version is not in the generated CRD", report.Drifts[1].Message)
+}
+
+func TestAudit_OtherKinds(t *testing.T) {
+	gadget := strings.NewReplacer("Widget", "Gadget", "widgets", "gadgets").Replace(widgetCRD)
+	chartDir := writeFiles(t, map[string]string{"crds/gadget.yaml": gadget})
+
+	generated, err := parseCRDs([]byte(widgetCRD))
+	require.NoError(t, err)
+	shipped, _, err := LoadShipped(chartDir)
+	require.NoError(t, err)
+	require.Len(t, shipped, 1)
+
+	report := Audit(generated, shipped)
+	assert.False(t, report.HasDrift())
+	assert.Empty(t, report.Compared)
+}
+
+func TestAudit_MissingVersion(t *testing.T) {
+	v2 := strings.Replace(widgetCRD, "  - name: v1\n", "  - name: v2\n", 1)
+	generated, err := parseCRDs([]byte(widgetCRD + "---\n" + v2))
+	require.NoError(t, err)
+	shipped, err := parseCRDs([]byte(v2))
+	require.NoError(t, err)
+
+	report := Audit(generated[:1], []Shipped{{Source: "crds/widget.yaml", CRD: shipped[0]}})
+	require.Len(t, report.Drifts, 2)
+	assert.Equal(t, "v1", report.Drifts[0].Version)
+	assert.Equal(t, "version is missing from the shipped CRD", report.Drifts[0].Message)
+	assert.Equal(t, "v2", report.Drifts[1].Version)
+	assert.Equal(t, "version is not in the generated CRD", report.Drifts[1].Message)
+}
+
+func TestLoadShipped_Skipped(t *testing.T) {
+	chartDir := writeFiles(t, map[string]string{
+		// The template action inside a value can't be removed
+		"templates/crd.yaml":  strings.Replace(widgetCRD, "name: widgets.example.com", "name: {{ .Values.name }}\n  labels: {{ toYaml .Values.labels }}", 1),
+		"templates/NOTES.txt": "CustomResourceDefinition",
+	})
+
+	shipped, skipped, err := LoadShipped(chartDir)
+	require.NoError(t, err)
+	assert.Empty(t, shipped)
+	assert.Equal(t, []string{filepath.Join(chartDir, "templates", "crd.yaml")}, skipped)
+}
+
+func TestDiffSchemas(t *testing.T) {
+	differences := diffSchemas("",
+		map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+			"properties": map[string]interface{}{
+				"ports": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+			},
+		},
+		map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "integer"},
+			"properties": map[string]interface{}{
+				"ports": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer", "maximum": float64(65535)}},
+			},
+			"description": "Values",
+		},
+	)
+
+	var messages []string
+	for _, difference := range differences {
+		messages = append(messages, difference.String())
+	}
+	assert.Equal(t, []string{
+		`[*]: type is "integer" in the shipped CRD, "string" in the generated CRD`,
+		`(root): description is "Values" in the shipped CRD, unset in the generated CRD`,
+		`ports[]: maximum is 65535 in the shipped CRD, unset in the generated CRD`,
+	}, messages)
+}
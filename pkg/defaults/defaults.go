@@ -0,0 +1,165 @@
+// Package defaults materializes the default values of a schema.
+package defaults
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Materialize returns a copy of example with every field set to its schema default.
+// Fields without a default keep their example value, and fields missing from
+// example are added if they have a default. Defaults are applied recursively,
+// including inside defaulted objects, the way the Kubernetes API server does.
+func Materialize(example map[string]interface{}, props *apiextensionsv1.JSONSchemaProps) (map[string]interface{}, error) {
+	result, err := materialize(example, props, "")
+	if err != nil {
+		return nil, err
+	}
+	values, _ := result.(map[string]interface{})
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// materialize applies the defaults of a schema to a value
+func materialize(value interface{}, props *apiextensionsv1.JSONSchemaProps, path string) (interface{}, error) {
+	if props == nil {
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			fieldProps := fieldSchema(props, key)
+			if fieldProps != nil && fieldProps.Default != nil {
+				def, err := decodeDefault(fieldProps, joinPath(path, key))
+				if err != nil {
+					return nil, err
+				}
+				fieldValue = def
+			}
+			materialized, err := materialize(fieldValue, fieldProps, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = materialized
+		}
+
+		// Add fields the example doesn't set that have a default
+		for key := range props.Properties {
+			fieldProps := props.Properties[key]
+			if _, ok := v[key]; ok || fieldProps.Default == nil {
+				continue
+			}
+			def, err := decodeDefault(&fieldProps, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			materialized, err := materialize(def, &fieldProps, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = materialized
+		}
+		return result, nil
+	case []interface{}:
+		if props.Items == nil || props.Items.Schema == nil {
+			return v, nil
+		}
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			materialized, err := materialize(item, props.Items.Schema, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = materialized
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+// fieldSchema returns the schema of a field of an object, or nil if it has none
+func fieldSchema(props *apiextensionsv1.JSONSchemaProps, key string) *apiextensionsv1.JSONSchemaProps {
+	if prop, ok := props.Properties[key]; ok {
+		return &prop
+	}
+	if props.AdditionalProperties != nil {
+		return props.AdditionalProperties.Schema
+	}
+	return nil
+}
+
+// decodeDefault decodes the default value of a schema
+func decodeDefault(props *apiextensionsv1.JSONSchemaProps, path string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(props.Default.Raw, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode default of %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// Change is a value that differs from its default
+type Change struct {
+	Path []string `json:"path"`
+	// Default is the default value, or nil if the defaults don't set the field
+	Default interface{} `json:"default"`
+	Value   interface{} `json:"value"`
+}
+
+// Diff lists the values that override their defaults. Objects are compared
+// field by field; other values, including lists, are compared as a whole,
+// since Helm replaces them rather than merging them.
+func Diff(defaults, values map[string]interface{}) []Change {
+	var changes []Change
+	diff(defaults, values, nil, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return strings.Join(changes[i].Path, ".") < strings.Join(changes[j].Path, ".")
+	})
+	return changes
+}
+
+// diff adds the fields of values that differ from defaults to changes
+func diff(defaults, values map[string]interface{}, path []string, changes *[]Change) {
+	for key, value := range values {
+		fieldPath := append(append([]string{}, path...), key)
+		def, hasDefault := defaults[key]
+
+		defMap, defIsMap := def.(map[string]interface{})
+		valueMap, valueIsMap := value.(map[string]interface{})
+		if defIsMap && valueIsMap {
+			diff(defMap, valueMap, fieldPath, changes)
+			continue
+		}
+		if hasDefault && equal(def, value) {
+			continue
+		}
+		*changes = append(*changes, Change{Path: fieldPath, Default: def, Value: value})
+	}
+}
+
+// equal compares decoded values, treating numbers of different types as equal
+func equal(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aJSON) == string(bJSON)
+}
+
+// joinPath appends a field name to a path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
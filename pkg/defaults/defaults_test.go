@@ -0,0 +1,138 @@
+package defaults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testSchema = `type: object
+properties:
+  replicas:
+    type: integer
+    default: 2
+  service:
+    type: object
+    properties:
+      type:
+        type: string
+        default: ClusterIP
+      port:
+        type: integer
+  logging:
+    type: object
+    default: {}
+    properties:
+      level:
+        type: string
+        default: info
+      format:
+        type: string
+  env:
+    type: array
+    items:
+      type: object
+      properties:
+        name:
+          type: string
+        value:
+          type: string
+          default: "1"
+  labels:
+    type: object
+    additionalProperties:
+      type: string
+      default: "true"
+  timeout:
+    type: string
+    default: 30s
+`
+
+// mustYAML parses YAML test input
+func mustYAML[T any](t *testing.T, data string) T {
+	t.Helper()
+	var v T
+	require.NoError(t, yaml.Unmarshal([]byte(data), &v))
+	return v
+}
+
+func TestMaterialize(t *testing.T) {
+	props := mustYAML[apiextensionsv1.JSONSchemaProps](t, testSchema)
+	example := mustYAML[map[string]interface{}](t, `apiVersion: demo.io/v1
+kind: Demo
+replicas: 5
+service:
+  type: LoadBalancer
+  port: 80
+logging:
+  level: debug
+  format: json
+env:
+- name: A
+  value: x
+labels:
+  team: a
+`)
+
+	values, err := Materialize(example, &props)
+	require.NoError(t, err)
+
+	assert.Equal(t, mustYAML[map[string]interface{}](t, `apiVersion: demo.io/v1
+kind: Demo
+replicas: 2
+service:
+  type: ClusterIP
+  port: 80
+logging:
+  level: info
+env:
+- name: A
+  value: "1"
+labels:
+  team: "true"
+timeout: 30s
+`), values)
+
+	// The example is not modified
+	assert.Equal(t, float64(5), example["replicas"])
+}
+
+func TestMaterialize_InvalidDefault(t *testing.T) {
+	props := apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"port": {Type: "integer", Default: &apiextensionsv1.JSON{Raw: []byte("{")}},
+		},
+	}
+	_, err := Materialize(map[string]interface{}{"port": 80}, &props)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode default of port")
+}
+
+func TestDiff(t *testing.T) {
+	defaults := mustYAML[map[string]interface{}](t, `replicas: 2
+service:
+  type: ClusterIP
+  port: 80
+env:
+- name: A
+`)
+	values := mustYAML[map[string]interface{}](t, `replicas: 2
+service:
+  type: LoadBalancer
+  extra: true
+env:
+- name: B
+`)
+
+	assert.Equal(t, []Change{
+		{Path: []string{"env"}, Default: defaults["env"], Value: values["env"]},
+		{Path: []string{"service", "extra"}, Default: nil, Value: true},
+		{Path: []string{"service", "type"}, Default: "ClusterIP", Value: "LoadBalancer"},
+	}, Diff(defaults, values))
+
+	assert.Empty(t, Diff(defaults, defaults))
+}
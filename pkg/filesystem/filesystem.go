@@ -0,0 +1,79 @@
+// Package filesystem abstracts the files miaka reads inputs from and writes
+// outputs to, so builds and validations can run on the OS filesystem, embedded
+// files, or files in memory.
+package filesystem
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FS reads and writes files by path, as the os package names them (relative or absolute)
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Stat(name string) (fs.FileInfo, error)
+	MkdirAll(name string, perm fs.FileMode) error
+}
+
+// OS returns the OS filesystem
+func OS() FS {
+	return osFS{}
+}
+
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// ReadOnly returns a filesystem reading from fsys (e.g., an embed.FS). Writes
+// fail with fs.ErrPermission.
+func ReadOnly(fsys fs.FS) FS {
+	return readOnlyFS{fsys: fsys}
+}
+
+type readOnlyFS struct {
+	fsys fs.FS
+}
+
+func (r readOnlyFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.fsys, fsPath(name))
+}
+
+func (r readOnlyFS) WriteFile(name string, _ []byte, _ fs.FileMode) error {
+	return &fs.PathError{Op: "write", Path: name, Err: fs.ErrPermission}
+}
+
+func (r readOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.fsys, fsPath(name))
+}
+
+func (r readOnlyFS) MkdirAll(name string, _ fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrPermission}
+}
+
+// fsPath converts a path as the os package names it to an io/fs path, which is
+// slash-separated and unrooted (e.g., "./crds/app.yaml" to "crds/app.yaml")
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
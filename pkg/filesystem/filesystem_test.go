@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(map[string][]byte{"chart/values.yaml": []byte("replicas: 1\n")})
+
+	data, err := m.ReadFile("./chart/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 1\n", string(data))
+
+	// Reads and writes copy the content
+	data[0] = 'X'
+	input := []byte("kind: App\n")
+	require.NoError(t, m.WriteFile("chart/crds/app.yaml", input, 0644))
+	input[0] = 'X'
+	data, err = m.ReadFile("chart/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 1\n", string(data))
+	data, err = m.ReadFile("chart/crds/app.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "kind: App\n", string(data))
+
+	assert.Equal(t, []string{"chart/crds/app.yaml", "chart/values.yaml"}, m.Files())
+}
+
+func TestMemory_Stat(t *testing.T) {
+	m := NewMemory(map[string][]byte{"chart/values.yaml": []byte("replicas: 1\n")})
+
+	info, err := m.Stat("chart/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "values.yaml", info.Name())
+	assert.Equal(t, int64(12), info.Size())
+	assert.False(t, info.IsDir())
+
+	info, err = m.Stat("chart")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	_, err = m.Stat("chart/crd.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = m.ReadFile("chart/crd.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMemory_Directories(t *testing.T) {
+	m := NewMemory(map[string][]byte{"chart/values.yaml": nil})
+
+	require.NoError(t, m.MkdirAll("chart/crds", 0755))
+	assert.ErrorIs(t, m.MkdirAll("chart/values.yaml", 0755), fs.ErrExist)
+	assert.ErrorIs(t, m.WriteFile("chart", nil, 0644), fs.ErrInvalid)
+}
+
+func TestReadOnly(t *testing.T) {
+	r := ReadOnly(fstest.MapFS{"chart/values.yaml": {Data: []byte("replicas: 1\n")}})
+
+	data, err := r.ReadFile("./chart/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 1\n", string(data))
+	_, err = r.Stat("/chart/values.yaml")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, r.WriteFile("chart/crd.yaml", nil, 0644), fs.ErrPermission)
+	assert.ErrorIs(t, r.MkdirAll("chart/crds", 0755), fs.ErrPermission)
+}
+
+func TestOS(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "chart")
+	o := OS()
+
+	require.NoError(t, o.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, "values.yaml")
+	require.NoError(t, o.WriteFile(path, []byte("replicas: 1\n"), 0644))
+	data, err := o.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 1\n", string(data))
+	_, err = o.Stat(filepath.Join(dir, "crd.yaml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
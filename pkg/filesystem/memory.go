@@ -0,0 +1,117 @@
+package filesystem
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is a filesystem in memory, for tests and embedding. Paths are cleaned,
+// so "./values.yaml" and "values.yaml" are the same file, and parent directories
+// exist implicitly. It's safe for concurrent use.
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemory creates a filesystem in memory holding files, by path
+func NewMemory(files map[string][]byte) *Memory {
+	m := &Memory{files: map[string]memoryFile{}}
+	for name, data := range files {
+		_ = m.WriteFile(name, data, 0644)
+	}
+	return m
+}
+
+// ReadFile returns a copy of the content of a file
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	file, ok := m.files[fsPath(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), file.data...), nil
+}
+
+// WriteFile creates or replaces a file with a copy of data
+func (m *Memory) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := fsPath(name)
+	if key == "." || m.isDir(key) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.files[key] = memoryFile{data: append([]byte(nil), data...), mode: perm, modTime: time.Now()}
+	return nil
+}
+
+// Stat describes a file, or a directory holding files
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key := fsPath(name)
+	if file, ok := m.files[key]; ok {
+		return memoryFileInfo{name: key, file: file}, nil
+	}
+	if key == "." || m.isDir(key) {
+		return memoryFileInfo{name: key, file: memoryFile{mode: fs.ModeDir | 0755}}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// MkdirAll does nothing, since directories exist implicitly, unless a file has the path
+func (m *Memory) MkdirAll(name string, _ fs.FileMode) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.files[fsPath(name)]; ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	return nil
+}
+
+// Files returns the paths of the files, sorted
+func (m *Memory) Files() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isDir reports whether any file is below a path
+func (m *Memory) isDir(key string) bool {
+	for name := range m.files {
+		if strings.HasPrefix(name, key+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryFileInfo describes a file or directory of a Memory filesystem
+type memoryFileInfo struct {
+	name string
+	file memoryFile
+}
+
+func (i memoryFileInfo) Name() string {
+	return i.name[strings.LastIndex(i.name, "/")+1:]
+}
+
+func (i memoryFileInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i memoryFileInfo) Mode() fs.FileMode  { return i.file.mode }
+func (i memoryFileInfo) ModTime() time.Time { return i.file.modTime }
+func (i memoryFileInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i memoryFileInfo) Sys() any           { return nil }
@@ -0,0 +1,237 @@
+// Package fn runs miaka validation as a KRM function, for kustomize and kpt pipelines.
+// See https://github.com/kubernetes-sigs/kustomize/blob/master/cmd/config/docs/api-conventions/functions-spec.md
+package fn
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Annotations that record where a resource was read from
+const (
+	pathAnnotation       = "config.kubernetes.io/path"
+	indexAnnotation      = "config.kubernetes.io/index"
+	legacyPathAnnotation = "internal.config.kubernetes.io/path"
+)
+
+// Result is a single entry of a ResourceList's results, in the KRM Functions results format
+type Result struct {
+	Message     string       `yaml:"message"`
+	Severity    string       `yaml:"severity"`
+	ResourceRef *ResourceRef `yaml:"resourceRef,omitempty"`
+	Field       *Field       `yaml:"field,omitempty"`
+	File        *File        `yaml:"file,omitempty"`
+}
+
+// ResourceRef identifies the resource a result applies to
+type ResourceRef struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty"`
+}
+
+// Field identifies the field a result applies to
+type Field struct {
+	Path string `yaml:"path"`
+}
+
+// File identifies the file a resource was read from
+type File struct {
+	Path  string `yaml:"path"`
+	Index int    `yaml:"index,omitempty"`
+}
+
+// Output is the processed ResourceList and its validation results
+type Output struct {
+	// ResourceList is the input ResourceList with results added; items are unchanged
+	ResourceList []byte
+
+	// Results are the validation failures, one per invalid field
+	Results []Result
+}
+
+// FunctionConfigCRD returns the CRD passed as the functionConfig of a ResourceList, or nil if
+// the functionConfig isn't a CustomResourceDefinition
+func FunctionConfigCRD(input []byte) (*apiextensionsv1.CustomResourceDefinition, error) {
+	list, err := parseResourceList(input)
+	if err != nil {
+		return nil, err
+	}
+	config := mappingValue(list, "functionConfig")
+	if config == nil || scalarValue(config, "kind") != "CustomResourceDefinition" {
+		return nil, nil
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := decodeNode(config, crd); err != nil {
+		return nil, fmt.Errorf("failed to parse functionConfig CRD: %w", err)
+	}
+	return crd, nil
+}
+
+// Process validates every item of a ResourceList whose kind matches the CRD
+func Process(input []byte, crd *apiextensionsv1.CustomResourceDefinition) (*Output, error) {
+	list, err := parseResourceList(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	if items := mappingValue(list, "items"); items != nil {
+		for i, item := range items.Content {
+			itemResults, err := validateItem(item, crd)
+			if err != nil {
+				return nil, fmt.Errorf("failed to validate item %d: %w", i, err)
+			}
+			results = append(results, itemResults...)
+		}
+	}
+
+	// Keep the results of earlier functions in the pipeline
+	if len(results) > 0 {
+		encoded := &yaml.Node{}
+		if err := encoded.Encode(results); err != nil {
+			return nil, fmt.Errorf("failed to encode results: %w", err)
+		}
+		existing := mappingValue(list, "results")
+		if existing == nil || existing.Kind != yaml.SequenceNode {
+			setMappingValue(list, "results", encoded)
+		} else {
+			existing.Style = 0
+			existing.Content = append(existing.Content, encoded.Content...)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(list); err != nil {
+		return nil, fmt.Errorf("failed to encode ResourceList: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode ResourceList: %w", err)
+	}
+	return &Output{ResourceList: buf.Bytes(), Results: results}, nil
+}
+
+// validateItem validates a single resource if its kind matches the CRD
+func validateItem(item *yaml.Node, crd *apiextensionsv1.CustomResourceDefinition) ([]Result, error) {
+	resource := &unstructured.Unstructured{}
+	if err := decodeNode(item, resource); err != nil {
+		return nil, err
+	}
+	if !matches(crd, resource.GetAPIVersion(), resource.GetKind()) {
+		return nil, nil
+	}
+
+	errs, err := validation.ValidateResource(crd, resource.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := &ResourceRef{
+		APIVersion: resource.GetAPIVersion(),
+		Kind:       resource.GetKind(),
+		Name:       resource.GetName(),
+		Namespace:  resource.GetNamespace(),
+	}
+	file := resourceFile(resource.GetAnnotations())
+
+	results := make([]Result, 0, len(errs))
+	for _, e := range errs {
+		result := Result{Message: e.ErrorBody(), Severity: "error", ResourceRef: ref, File: file}
+		if e.Field != "" && e.Field != "<nil>" {
+			result.Field = &Field{Path: e.Field}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// matches reports whether a resource is an instance of the CRD
+func matches(crd *apiextensionsv1.CustomResourceDefinition, apiVersion, kind string) bool {
+	if kind != crd.Spec.Names.Kind {
+		return false
+	}
+	for _, version := range crd.Spec.Versions {
+		if crd.Spec.Group+"/"+version.Name == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceFile returns the file a resource was read from, if kustomize or kpt recorded it
+func resourceFile(annotations map[string]string) *File {
+	path := annotations[pathAnnotation]
+	if path == "" {
+		path = annotations[legacyPathAnnotation]
+	}
+	if path == "" {
+		return nil
+	}
+	index, _ := strconv.Atoi(annotations[indexAnnotation])
+	return &File{Path: path, Index: index}
+}
+
+// parseResourceList parses a ResourceList and returns its root mapping
+func parseResourceList(input []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse ResourceList: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse ResourceList: expected a YAML object")
+	}
+	list := doc.Content[0]
+	if kind := scalarValue(list, "kind"); kind != "ResourceList" {
+		return nil, fmt.Errorf("expected kind ResourceList, got %q", kind)
+	}
+	return list, nil
+}
+
+// decodeNode decodes a YAML node into a Kubernetes object, using JSON number semantics
+func decodeNode(node *yaml.Node, out interface{}) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return sigsyaml.Unmarshal(data, out)
+}
+
+// mappingValue returns the value of key in a mapping node, or nil if it isn't set
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the scalar value of key in a mapping node, or ""
+func scalarValue(mapping *yaml.Node, key string) string {
+	if value := mappingValue(mapping, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+// setMappingValue sets key in a mapping node, replacing any existing value
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
@@ -0,0 +1,157 @@
+package fn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          replicas:
+            type: integer
+            minimum: 1
+`
+
+func parseCRD(t *testing.T) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, sigsyaml.Unmarshal([]byte(testCRD), crd))
+	return crd
+}
+
+func TestProcess(t *testing.T) {
+	input := `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: example.com/v1
+  kind: Example
+  metadata:
+    name: good
+  replicas: 3 # comments are kept
+- apiVersion: example.com/v1
+  kind: Example
+  metadata:
+    name: bad
+    namespace: prod
+    annotations:
+      config.kubernetes.io/path: prod/example.yaml
+      config.kubernetes.io/index: "1"
+  replicas: 0
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: ignored
+  data:
+    replicas: "zero"
+results:
+- message: from an earlier function
+  severity: info
+`
+	output, err := Process([]byte(input), parseCRD(t))
+	require.NoError(t, err)
+
+	require.Len(t, output.Results, 1)
+	assert.Equal(t, Result{
+		Message:     "Invalid value: 0: replicas in body should be greater than or equal to 1",
+		Severity:    "error",
+		ResourceRef: &ResourceRef{APIVersion: "example.com/v1", Kind: "Example", Name: "bad", Namespace: "prod"},
+		Field:       &Field{Path: "replicas"},
+		File:        &File{Path: "prod/example.yaml", Index: 1},
+	}, output.Results[0])
+
+	assert.Contains(t, string(output.ResourceList), "  replicas: 3 # comments are kept\n")
+	assert.Contains(t, string(output.ResourceList), `results:
+  - message: from an earlier function
+    severity: info
+  - message: 'Invalid value: 0: replicas in body should be greater than or equal to 1'
+    severity: error
+    resourceRef:
+      apiVersion: example.com/v1
+      kind: Example
+      name: bad
+      namespace: prod
+    field:
+      path: replicas
+    file:
+      path: prod/example.yaml
+      index: 1
+`)
+}
+
+func TestProcess_NoResults(t *testing.T) {
+	input := "apiVersion: config.kubernetes.io/v1\nkind: ResourceList\nitems: []\n"
+	output, err := Process([]byte(input), parseCRD(t))
+	require.NoError(t, err)
+	assert.Empty(t, output.Results)
+	assert.Equal(t, input, string(output.ResourceList))
+}
+
+func TestProcess_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"invalid YAML", "kind: [", "failed to parse ResourceList"},
+		{"not an object", "- a\n", "expected a YAML object"},
+		{"wrong kind", "kind: List\n", `expected kind ResourceList, got "List"`},
+		{"other versions are skipped", "kind: ResourceList\nitems:\n- apiVersion: example.com/v2\n  kind: Example\n  replicas: 0\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Process([]byte(tt.input), parseCRD(t))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestFunctionConfigCRD(t *testing.T) {
+	crd, err := FunctionConfigCRD([]byte("kind: ResourceList\nitems: []\n"))
+	require.NoError(t, err)
+	assert.Nil(t, crd)
+
+	crd, err = FunctionConfigCRD([]byte("kind: ResourceList\nfunctionConfig:\n  kind: ConfigMap\n"))
+	require.NoError(t, err)
+	assert.Nil(t, crd)
+
+	input := "kind: ResourceList\nfunctionConfig:\n"
+	for _, line := range strings.Split(strings.TrimSuffix(testCRD, "\n"), "\n") {
+		input += "  " + line + "\n"
+	}
+	crd, err = FunctionConfigCRD([]byte(input))
+	require.NoError(t, err)
+	require.NotNil(t, crd)
+	assert.Equal(t, "Example", crd.Spec.Names.Kind)
+}
@@ -0,0 +1,94 @@
+// Package history keeps snapshots of the CRD of each released version of a
+// chart, so builds can check compatibility with every release line that's still
+// supported, not only the last build.
+//
+// Snapshots are stored as <dir>/<version>/crd.yaml.
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+)
+
+// DefaultDir is the history directory of a chart, relative to the chart
+const DefaultDir = ".miaka/history"
+
+// CRDFile is the file name of a snapshot's CRD
+const CRDFile = "crd.yaml"
+
+// Snapshot is the CRD of a released version
+type Snapshot struct {
+	Version string
+	// Path is the path of the snapshot's CRD
+	Path string
+}
+
+// List returns the snapshots in dir, sorted by version. A missing dir has none.
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name(), CRDFile)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Version: entry.Name(), Path: path})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return chart.CompareVersions(snapshots[i].Version, snapshots[j].Version) < 0
+	})
+	return snapshots, nil
+}
+
+// Supported returns the snapshots whose versions match any of the patterns
+// (e.g., "2.x" or "3.1.*", see chart.MatchVersion), or all of them if there
+// are no patterns
+func Supported(snapshots []Snapshot, patterns []string) []Snapshot {
+	if len(patterns) == 0 {
+		return snapshots
+	}
+	var supported []Snapshot
+	for _, snapshot := range snapshots {
+		for _, pattern := range patterns {
+			if chart.MatchVersion(pattern, snapshot.Version) {
+				supported = append(supported, snapshot)
+				break
+			}
+		}
+	}
+	return supported
+}
+
+// Save writes the CRD of a version to dir, replacing its snapshot if it has
+// one, and returns the snapshot. A leading "v" is trimmed from the version.
+func Save(dir, version string, crd []byte) (Snapshot, error) {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" || version == "." || version == ".." || strings.ContainsAny(version, `/\`) {
+		return Snapshot{}, fmt.Errorf("invalid version %q", version)
+	}
+	versionDir := filepath.Join(dir, version)
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create %s: %w", versionDir, err)
+	}
+	path := filepath.Join(versionDir, CRDFile)
+	if err := os.WriteFile(path, crd, 0644); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return Snapshot{Version: version, Path: path}, nil
+}
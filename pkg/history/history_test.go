@@ -0,0 +1,55 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndList(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DefaultDir)
+
+	snapshots, err := List(dir)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	for _, version := range []string{"10.0.0", "v2.1.0", "2.0.0"} {
+		snapshot, err := Save(dir, version, []byte("kind: CustomResourceDefinition\n"))
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimPrefix(version, "v"), snapshot.Version)
+	}
+	// Directories without a CRD aren't snapshots
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "3.0.0"), 0755))
+
+	snapshots, err = List(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{
+		{Version: "2.0.0", Path: filepath.Join(dir, "2.0.0", CRDFile)},
+		{Version: "2.1.0", Path: filepath.Join(dir, "2.1.0", CRDFile)},
+		{Version: "10.0.0", Path: filepath.Join(dir, "10.0.0", CRDFile)},
+	}, snapshots)
+
+	data, err := os.ReadFile(snapshots[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "kind: CustomResourceDefinition\n", string(data))
+}
+
+func TestSave_InvalidVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"", "..", "1.0/2"} {
+		_, err := Save(dir, version, nil)
+		assert.ErrorContains(t, err, "invalid version", version)
+	}
+}
+
+func TestSupported(t *testing.T) {
+	snapshots := []Snapshot{{Version: "1.4.0"}, {Version: "2.0.0"}, {Version: "2.1.0"}, {Version: "3.0.0"}}
+
+	assert.Equal(t, snapshots, Supported(snapshots, nil))
+	assert.Equal(t, []Snapshot{{Version: "2.0.0"}, {Version: "2.1.0"}, {Version: "3.0.0"}}, Supported(snapshots, []string{"2.x", "3.x"}))
+	assert.Empty(t, Supported(snapshots, []string{"4.x"}))
+}
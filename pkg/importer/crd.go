@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// crdDocument is the subset of a CustomResourceDefinition needed to import it.
+// The schema is decoded directly from YAML so property order is kept.
+type crdDocument struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Spec       struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+		Versions []crdVersion `yaml:"versions"`
+	} `yaml:"spec"`
+}
+
+// crdVersion is a single version of a CustomResourceDefinition
+type crdVersion struct {
+	Name    string `yaml:"name"`
+	Served  bool   `yaml:"served"`
+	Storage bool   `yaml:"storage"`
+	Schema  struct {
+		OpenAPIV3Schema *Schema `yaml:"openAPIV3Schema"`
+	} `yaml:"schema"`
+}
+
+// errNoCRD is returned by findCRD for YAML without a CustomResourceDefinition
+var errNoCRD = errors.New("no CustomResourceDefinition found")
+
+// crdSkippedFields are root fields of a custom resource that don't belong in an example values file
+var crdSkippedFields = map[string]bool{
+	"metadata": true,
+	"status":   true,
+}
+
+// FromCRD converts a CustomResourceDefinition into an example values file.
+// The version defaults to the storage version. apiVersion and kind come from
+// the CRD, and defaults are kept as +kubebuilder:default markers.
+func FromCRD(data []byte, version string) (*Result, error) {
+	doc, err := findCRD(data)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := selectVersion(doc.Spec.Versions, version)
+	if err != nil {
+		return nil, err
+	}
+	if v.Schema.OpenAPIV3Schema == nil {
+		return nil, fmt.Errorf("version %s of the CRD has no openAPIV3Schema", v.Name)
+	}
+
+	root := *v.Schema.OpenAPIV3Schema
+	root.Properties = nil
+	for _, prop := range v.Schema.OpenAPIV3Schema.Properties {
+		if !crdSkippedFields[prop.Name] {
+			root.Properties = append(root.Properties, prop)
+		}
+	}
+
+	return Import(&root, Options{
+		APIVersion:     doc.Spec.Group + "/" + v.Name,
+		Kind:           doc.Spec.Names.Kind,
+		DefaultMarkers: true,
+	})
+}
+
+// findCRD returns the first CustomResourceDefinition in a (possibly multi-document) YAML file
+func findCRD(data []byte) (*crdDocument, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc crdDocument
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil, errNoCRD
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CRD: %w", err)
+		}
+		if doc.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		if doc.APIVersion != "apiextensions.k8s.io/v1" {
+			return nil, fmt.Errorf("unsupported CRD apiVersion %s (only apiextensions.k8s.io/v1 is supported)", doc.APIVersion)
+		}
+		return &doc, nil
+	}
+}
+
+// selectVersion returns the named version, or the storage version if name is empty
+func selectVersion(versions []crdVersion, name string) (*crdVersion, error) {
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("CRD has no versions")
+	}
+	for i := range versions {
+		if (name == "" && versions[i].Storage) || (name != "" && versions[i].Name == name) {
+			return &versions[i], nil
+		}
+	}
+	if name == "" {
+		return &versions[0], nil
+	}
+	return nil, fmt.Errorf("version %s not found in CRD", name)
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const widgetCRD = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: widgets
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          size:
+            type: string
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        description: Widget is the Schema for the widgets API
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          spec:
+            description: Desired state of the widget
+            type: object
+            required:
+            - size
+            properties:
+              size:
+                type: string
+                enum: [small, large]
+                default: small
+              version:
+                type: string
+                default: "1.0"
+              replicas:
+                type: integer
+                minimum: 0
+                default: 2
+              ports:
+                type: array
+                x-kubernetes-list-type: map
+                x-kubernetes-list-map-keys: [name]
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+                    port:
+                      type: integer
+              selector:
+                type: object
+                x-kubernetes-map-type: atomic
+                additionalProperties:
+                  type: string
+              options:
+                type: object
+                additionalProperties: false
+              owner:
+                type: string
+                nullable: true
+                x-kubernetes-validations:
+                - rule: self.size() > 0
+                  message: owner must not be empty
+          status:
+            type: object
+            properties:
+              ready:
+                type: boolean
+`
+
+func TestFromCRD(t *testing.T) {
+	result, err := FromCRD([]byte(widgetCRD), "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+
+	assert.Equal(t, `## Widget is the Schema for the widgets API
+apiVersion: example.com/v1
+kind: Widget
+# Desired state of the widget
+spec:
+  # +kubebuilder:validation:Enum=small;large
+  # +kubebuilder:default=small
+  # +kubebuilder:validation:Required
+  size: small
+  # +kubebuilder:default="1.0"
+  version: "1.0"
+  # +kubebuilder:validation:Minimum=0
+  # +kubebuilder:default=2
+  replicas: 2
+  # +listType=map
+  # +listMapKey=name
+  ports:
+    - name: ""
+      port: 0
+  # +miaka:type: map[string]string
+  # +mapType=atomic
+  selector: {}
+  options: {}
+  # +nullable
+  # +kubebuilder:validation:XValidation:rule="self.size() > 0",message="owner must not be empty"
+  owner: ""
+`, string(result.Content))
+}
+
+func TestFromCRD_Version(t *testing.T) {
+	result, err := FromCRD([]byte(widgetCRD), "v1beta1")
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion: example.com/v1beta1\nkind: Widget\nsize: \"\"\n", string(result.Content))
+}
+
+func TestFromCRD_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		crd     string
+		version string
+		wantErr string
+	}{
+		{"invalid YAML", "kind: [", "", "failed to parse CRD"},
+		{"no CRD", "apiVersion: v1\nkind: Namespace\n", "", "no CustomResourceDefinition found"},
+		{"v1beta1 CRD", "apiVersion: apiextensions.k8s.io/v1beta1\nkind: CustomResourceDefinition\n", "", "unsupported CRD apiVersion"},
+		{"no versions", "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n", "", "CRD has no versions"},
+		{"missing version", widgetCRD, "v2", "version v2 not found"},
+		{"no schema", "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  versions:\n  - name: v1\n", "", "has no openAPIV3Schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromCRD([]byte(tt.crd), tt.version)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,787 @@
+// Package importer converts existing schemas into miaka example values files.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options configures an import
+type Options struct {
+	// APIVersion and Kind of the generated example values file (e.g., "myapp.io/v1", "MyApp")
+	APIVersion string
+	Kind       string
+
+	// DefaultMarkers adds +kubebuilder:default markers for scalar defaults, so
+	// defaulting by the API server is kept when the CRD is rebuilt
+	DefaultMarkers bool
+}
+
+// Result is an imported example values file
+type Result struct {
+	// Content is the example values file, with descriptions as comments and
+	// constraints as kubebuilder markers
+	Content []byte
+
+	// Warnings lists fields that couldn't be represented exactly and should be reviewed
+	Warnings []string
+}
+
+// formatExamples are example values for string formats without a default
+var formatExamples = map[string]string{
+	"date":      "2024-01-01",
+	"date-time": "2024-01-01T00:00:00Z",
+	"duration":  "1h",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+}
+
+// FromJSONSchema converts a JSON Schema (e.g., a chart's values.schema.json) into an example values file
+func FromJSONSchema(data []byte, opts Options) (*Result, error) {
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, err
+	}
+	return Import(s, opts)
+}
+
+// Import converts a parsed schema into an example values file.
+// Example values come from defaults, examples, and enums where available, and
+// are otherwise chosen to satisfy the schema's constraints.
+func Import(s *Schema, opts Options) (*Result, error) {
+	if opts.APIVersion == "" || opts.Kind == "" {
+		return nil, fmt.Errorf("apiVersion and kind are required")
+	}
+
+	c := &converter{root: s, opts: opts, visiting: make(map[string]bool)}
+	root, err := c.resolve(s, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(root.Properties) == 0 && root.Type.Primary() != "" && root.Type.Primary() != "object" {
+		return nil, fmt.Errorf("root schema must be an object, got %s", root.Type.Primary())
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content,
+		scalarNode("apiVersion"), scalarNode(opts.APIVersion),
+		scalarNode("kind"), scalarNode(opts.Kind),
+	)
+	if doc := description(root); doc != "" {
+		mapping.Content[0].HeadComment = commentLines(strings.Split(doc, "\n"), "## ")
+	}
+	if err := c.addProperties(mapping, root, ""); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	return &Result{Content: buf.Bytes(), Warnings: c.warnings}, nil
+}
+
+// converter builds example values from a schema
+type converter struct {
+	root     *Schema
+	opts     Options
+	visiting map[string]bool
+	warnings []string
+}
+
+// warn records a field that needs manual review
+func (c *converter) warn(path, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, path+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows $ref and merges allOf, and picks the first non-null anyOf/oneOf
+// alternative when the schema has no type of its own
+func (c *converter) resolve(s *Schema, path string) (*Schema, error) {
+	if s.Ref != "" {
+		if c.visiting[s.Ref] {
+			return nil, fmt.Errorf("%s: recursive $ref %q is not supported", displayPath(path), s.Ref)
+		}
+		target, err := c.root.resolveRef(s.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+		}
+		c.visiting[s.Ref] = true
+		resolved, err := c.resolve(target, path)
+		delete(c.visiting, s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		// Keywords next to $ref (e.g., a description) take precedence
+		local := *s
+		local.Ref = ""
+		return merge(&local, resolved), nil
+	}
+
+	result := s
+	if len(s.AllOf) > 0 {
+		merged := *s
+		merged.AllOf = nil
+		result = &merged
+		for _, sub := range s.AllOf {
+			resolved, err := c.resolve(sub, path)
+			if err != nil {
+				return nil, err
+			}
+			result = merge(result, resolved)
+		}
+	}
+
+	if result.Type.Primary() == "" && len(result.Properties) == 0 {
+		for _, alternatives := range [][]*Schema{result.AnyOf, result.OneOf} {
+			for _, alt := range alternatives {
+				resolved, err := c.resolve(alt, path)
+				if err != nil {
+					return nil, err
+				}
+				if resolved.Type.Primary() == "" && len(resolved.Properties) == 0 {
+					continue
+				}
+				if len(alternatives) > 1 {
+					c.warn(displayPath(path), "only the first alternative of anyOf/oneOf was imported")
+				}
+				return merge(result, resolved), nil
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// addProperties adds a field to mapping for each property of an object schema
+func (c *converter) addProperties(mapping *yaml.Node, s *Schema, path string) error {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	for _, prop := range s.Properties {
+		// apiVersion and kind are set from the options
+		if path == "" && (prop.Name == "apiVersion" || prop.Name == "kind") {
+			continue
+		}
+		propPath := joinPath(path, prop.Name)
+		resolved, err := c.resolve(prop.Schema, propPath)
+		if err != nil {
+			return err
+		}
+
+		value, fieldMarkers, err := c.value(resolved, propPath)
+		if err != nil {
+			return err
+		}
+		fieldMarkers = append(fieldMarkers, extensionMarkers(resolved)...)
+		if required[prop.Name] {
+			fieldMarkers = append(fieldMarkers, "+kubebuilder:validation:Required")
+		}
+
+		var lines []string
+		if doc := description(resolved); doc != "" {
+			lines = append(lines, strings.Split(doc, "\n")...)
+		}
+		lines = append(lines, fieldMarkers...)
+
+		key := scalarNode(prop.Name)
+		key.HeadComment = commentLines(lines, "# ")
+		mapping.Content = append(mapping.Content, key, value)
+	}
+	return nil
+}
+
+// value returns the example value node for a schema and the markers for its field
+func (c *converter) value(s *Schema, path string) (*yaml.Node, []string, error) {
+	typ := schemaType(s)
+	if s.XIntOrString || (len(s.Type) > 1 && s.Type.Primary() == "integer" && containsType(s.Type, "string")) {
+		c.warn(displayPath(path), "int-or-string was imported as a string")
+		typ = "string"
+	}
+
+	switch typ {
+	case "object":
+		return c.objectValue(s, path)
+	case "array":
+		return c.arrayValue(s, path)
+	case "string", "integer", "number", "boolean":
+		return c.scalarValue(s, typ, path)
+	}
+
+	c.warn(displayPath(path), "type could not be determined; add a +miaka:type hint")
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil, nil
+}
+
+// schemaType returns the type of a schema, inferred from its keywords and
+// values when it has no type
+func schemaType(s *Schema) string {
+	if typ := s.Type.Primary(); typ != "" {
+		return typ
+	}
+	switch {
+	case len(s.Properties) > 0 || s.AdditionalProperties != nil:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	case s.Const != nil:
+		return jsonType(s.Const)
+	case len(s.Enum) > 0:
+		return jsonType(s.Enum[0])
+	case s.Default != nil:
+		return jsonType(s.Default)
+	}
+	return ""
+}
+
+// objectValue returns a nested mapping for objects with properties, or an empty map with a type hint
+func (c *converter) objectValue(s *Schema, path string) (*yaml.Node, []string, error) {
+	markers := objectMarkers(s)
+
+	if len(s.Properties) > 0 {
+		mapping := &yaml.Node{Kind: yaml.MappingNode}
+		if err := c.addProperties(mapping, s, path); err != nil {
+			return nil, nil, err
+		}
+		return mapping, markers, nil
+	}
+
+	// Objects that allow no properties at all are empty structs
+	if s.AdditionalProperties != nil && !s.AdditionalProperties.Allowed {
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}, markers, nil
+	}
+
+	valueType := "string"
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		valueSchema, err := c.resolve(s.AdditionalProperties.Schema, path+".*")
+		if err != nil {
+			return nil, nil, err
+		}
+		valueType = c.goType(valueSchema, path)
+	} else {
+		c.warn(displayPath(path), "free-form object was imported as map[string]string")
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+	if defaults, ok := s.Default.(map[string]interface{}); ok && len(defaults) > 0 {
+		if err := mapping.Encode(defaults); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to encode default: %w", displayPath(path), err)
+		}
+	}
+	return mapping, append([]string{"+miaka:type: map[string]" + valueType}, markers...), nil
+}
+
+// arrayValue returns a list with one example item for lists of objects, and the
+// default (or an empty list with a type hint) for lists of scalars
+func (c *converter) arrayValue(s *Schema, path string) (*yaml.Node, []string, error) {
+	markers := arrayMarkers(s)
+	itemPath := path + "[]"
+
+	items := &Schema{}
+	if s.Items != nil {
+		resolved, err := c.resolve(s.Items, itemPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = resolved
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	if len(items.Properties) > 0 {
+		item, _, err := c.objectValue(items, itemPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		list.Content = append(list.Content, item)
+		return list, markers, nil
+	}
+
+	if defaults, ok := s.Default.([]interface{}); ok && len(defaults) > 0 {
+		if err := list.Encode(defaults); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to encode default: %w", displayPath(path), err)
+		}
+		return list, markers, nil
+	}
+
+	// Lists that must not be empty get example items so the example validates
+	if s.MinItems != nil && *s.MinItems > 0 {
+		for i := int64(0); i < *s.MinItems; i++ {
+			item, _, err := c.value(items, itemPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			list.Content = append(list.Content, item)
+		}
+		return list, markers, nil
+	}
+
+	list.Style = yaml.FlowStyle
+	return list, append([]string{"+miaka:type: []" + c.goType(items, itemPath)}, markers...), nil
+}
+
+// scalarValue returns an example scalar that satisfies the schema
+func (c *converter) scalarValue(s *Schema, typ, path string) (*yaml.Node, []string, error) {
+	markers := scalarMarkers(s, typ)
+
+	example, found := exampleValue(s)
+	if found && jsonType(example) != typ && !(typ == "number" && jsonType(example) == "integer") {
+		found = false
+	}
+	if !found {
+		switch typ {
+		case "string":
+			example = exampleString(s)
+			if s.Pattern != "" {
+				c.warn(displayPath(path), "example value may not match pattern %q", s.Pattern)
+			}
+		case "integer", "number":
+			example = exampleNumber(s)
+		case "boolean":
+			example = false
+		}
+	}
+
+	if typ == "number" {
+		// CRDs don't support floats in Go types, so numbers are declared as
+		// integers with a number schema type
+		if f, ok := toFloat(example); ok && f != math.Trunc(f) {
+			c.warn(displayPath(path), "fractional example %v was rounded to %v", f, math.Round(f))
+			example = int64(math.Round(f))
+		}
+		markers = append([]string{"+kubebuilder:validation:Type=number"}, markers...)
+	}
+	if c.opts.DefaultMarkers && s.Default != nil && jsonType(s.Default) != "null" {
+		markers = append(markers, "+kubebuilder:default="+defaultValue(s.Default, typ))
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(example); err != nil {
+		return nil, nil, fmt.Errorf("%s: failed to encode example: %w", displayPath(path), err)
+	}
+	return node, markers, nil
+}
+
+// goType returns the Go type used in +miaka:type hints for a schema
+func (c *converter) goType(s *Schema, path string) string {
+	switch s.Type.Primary() {
+	case "string":
+		return "string"
+	case "boolean":
+		return "bool"
+	case "integer", "number":
+		switch s.Format {
+		case "int32":
+			return "int32"
+		case "int64":
+			return "int64"
+		}
+		return "int"
+	case "array":
+		if s.Items != nil {
+			items, err := c.resolve(s.Items, path+"[]")
+			if err == nil {
+				return "[]" + c.goType(items, path+"[]")
+			}
+		}
+	}
+	c.warn(displayPath(path), "values of type %q were imported as strings", strings.Join(s.Type, ","))
+	return "string"
+}
+
+// objectMarkers returns the kubebuilder markers for object constraints
+func objectMarkers(s *Schema) []string {
+	var markers []string
+	if s.MinProperties != nil {
+		markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MinProperties=%d", *s.MinProperties))
+	}
+	if s.MaxProperties != nil {
+		markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MaxProperties=%d", *s.MaxProperties))
+	}
+	if s.XMapType != "" {
+		markers = append(markers, "+mapType="+s.XMapType)
+	}
+	return markers
+}
+
+// arrayMarkers returns the kubebuilder markers for array constraints
+func arrayMarkers(s *Schema) []string {
+	var markers []string
+	if s.MinItems != nil {
+		markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MinItems=%d", *s.MinItems))
+	}
+	if s.MaxItems != nil {
+		markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MaxItems=%d", *s.MaxItems))
+	}
+	switch {
+	case s.XListType != "":
+		markers = append(markers, "+listType="+s.XListType)
+	case s.UniqueItems:
+		// Kubernetes doesn't allow uniqueItems; sets are the equivalent
+		markers = append(markers, "+listType=set")
+	}
+	for _, key := range s.XListMapKeys {
+		markers = append(markers, "+listMapKey="+key)
+	}
+	return markers
+}
+
+// extensionMarkers returns the markers for Kubernetes extensions that apply to any type
+func extensionMarkers(s *Schema) []string {
+	var markers []string
+	if s.Nullable {
+		markers = append(markers, "+nullable")
+	}
+	for _, v := range s.XValidations {
+		marker := "+kubebuilder:validation:XValidation:rule=" + strconv.Quote(v.Rule)
+		if v.Message != "" {
+			marker += ",message=" + strconv.Quote(v.Message)
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// scalarMarkers returns the kubebuilder markers for scalar constraints
+func scalarMarkers(s *Schema, typ string) []string {
+	var markers []string
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			if v != nil {
+				values = append(values, markerValue(v))
+			}
+		}
+		markers = append(markers, "+kubebuilder:validation:Enum="+strings.Join(values, ";"))
+	}
+	if s.Const != nil && len(s.Enum) == 0 {
+		markers = append(markers, "+kubebuilder:validation:Enum="+markerValue(s.Const))
+	}
+
+	if typ == "string" {
+		if s.MinLength != nil {
+			markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MinLength=%d", *s.MinLength))
+		}
+		if s.MaxLength != nil {
+			markers = append(markers, fmt.Sprintf("+kubebuilder:validation:MaxLength=%d", *s.MaxLength))
+		}
+		if s.Pattern != "" {
+			markers = append(markers, "+kubebuilder:validation:Pattern="+markerString(s.Pattern))
+		}
+		if s.Format != "" {
+			markers = append(markers, "+kubebuilder:validation:Format="+s.Format)
+		}
+		return markers
+	}
+
+	if typ == "integer" || typ == "number" {
+		minimum, exclusiveMin := bound(s.Minimum, s.ExclusiveMinimum)
+		if minimum != nil {
+			markers = append(markers, "+kubebuilder:validation:Minimum="+formatNumber(*minimum))
+			if exclusiveMin {
+				markers = append(markers, "+kubebuilder:validation:ExclusiveMinimum=true")
+			}
+		}
+		maximum, exclusiveMax := bound(s.Maximum, s.ExclusiveMaximum)
+		if maximum != nil {
+			markers = append(markers, "+kubebuilder:validation:Maximum="+formatNumber(*maximum))
+			if exclusiveMax {
+				markers = append(markers, "+kubebuilder:validation:ExclusiveMaximum=true")
+			}
+		}
+		if s.MultipleOf != nil {
+			markers = append(markers, "+kubebuilder:validation:MultipleOf="+formatNumber(*s.MultipleOf))
+		}
+	}
+	return markers
+}
+
+// bound returns a minimum or maximum and whether it's exclusive. Draft-04 uses a
+// boolean exclusive flag; later drafts use a number in place of the bound.
+func bound(inclusive *float64, exclusive interface{}) (*float64, bool) {
+	switch e := exclusive.(type) {
+	case bool:
+		return inclusive, e && inclusive != nil
+	case nil:
+		return inclusive, false
+	}
+	if f, ok := toFloat(exclusive); ok {
+		return &f, true
+	}
+	return inclusive, false
+}
+
+// exampleValue returns the default, first example, const, or first enum value
+func exampleValue(s *Schema) (interface{}, bool) {
+	switch {
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Examples) > 0 && s.Examples[0] != nil:
+		return s.Examples[0], true
+	case s.Const != nil:
+		return s.Const, true
+	}
+	for _, v := range s.Enum {
+		if v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// exampleString returns a string satisfying the format and length constraints
+func exampleString(s *Schema) string {
+	example := formatExamples[s.Format]
+	if s.MinLength != nil && int64(len(example)) < *s.MinLength {
+		example += strings.Repeat("x", int(*s.MinLength)-len(example))
+	}
+	if s.MaxLength != nil && int64(len(example)) > *s.MaxLength {
+		example = example[:*s.MaxLength]
+	}
+	return example
+}
+
+// exampleNumber returns the integer closest to zero that satisfies the bounds
+func exampleNumber(s *Schema) int64 {
+	var example float64
+	if minimum, exclusive := bound(s.Minimum, s.ExclusiveMinimum); minimum != nil && example <= *minimum {
+		example = math.Ceil(*minimum)
+		if exclusive && example == *minimum {
+			example++
+		}
+	}
+	if maximum, exclusive := bound(s.Maximum, s.ExclusiveMaximum); maximum != nil && example >= *maximum {
+		example = math.Floor(*maximum)
+		if exclusive && example == *maximum {
+			example--
+		}
+	}
+	return int64(example)
+}
+
+// merge fills the unset keywords of dst from src and combines their properties
+func merge(dst, src *Schema) *Schema {
+	result := *dst
+	if len(result.Type) == 0 {
+		result.Type = src.Type
+	}
+	if result.Title == "" {
+		result.Title = src.Title
+	}
+	if result.Description == "" {
+		result.Description = src.Description
+	}
+	if result.Default == nil {
+		result.Default = src.Default
+	}
+	if len(result.Examples) == 0 {
+		result.Examples = src.Examples
+	}
+	if result.Const == nil {
+		result.Const = src.Const
+	}
+	if len(result.Enum) == 0 {
+		result.Enum = src.Enum
+	}
+	if result.Format == "" {
+		result.Format = src.Format
+	}
+	if result.AdditionalProperties == nil {
+		result.AdditionalProperties = src.AdditionalProperties
+	}
+	if result.Items == nil {
+		result.Items = src.Items
+	}
+	if result.Pattern == "" {
+		result.Pattern = src.Pattern
+	}
+	for _, ptr := range []struct{ dst, src **int64 }{
+		{&result.MinProperties, &src.MinProperties}, {&result.MaxProperties, &src.MaxProperties},
+		{&result.MinItems, &src.MinItems}, {&result.MaxItems, &src.MaxItems},
+		{&result.MinLength, &src.MinLength}, {&result.MaxLength, &src.MaxLength},
+	} {
+		if *ptr.dst == nil {
+			*ptr.dst = *ptr.src
+		}
+	}
+	for _, ptr := range []struct{ dst, src **float64 }{
+		{&result.Minimum, &src.Minimum}, {&result.Maximum, &src.Maximum}, {&result.MultipleOf, &src.MultipleOf},
+	} {
+		if *ptr.dst == nil {
+			*ptr.dst = *ptr.src
+		}
+	}
+	if result.ExclusiveMinimum == nil {
+		result.ExclusiveMinimum = src.ExclusiveMinimum
+	}
+	if result.ExclusiveMaximum == nil {
+		result.ExclusiveMaximum = src.ExclusiveMaximum
+	}
+	result.UniqueItems = result.UniqueItems || src.UniqueItems
+	result.XIntOrString = result.XIntOrString || src.XIntOrString
+	result.XPreserveUnknownFields = result.XPreserveUnknownFields || src.XPreserveUnknownFields
+	result.Nullable = result.Nullable || src.Nullable
+	if result.XListType == "" {
+		result.XListType = src.XListType
+	}
+	if len(result.XListMapKeys) == 0 {
+		result.XListMapKeys = src.XListMapKeys
+	}
+	if result.XMapType == "" {
+		result.XMapType = src.XMapType
+	}
+	result.XValidations = append(append([]Validation{}, result.XValidations...), src.XValidations...)
+
+	existing := make(map[string]bool, len(result.Properties))
+	for _, prop := range result.Properties {
+		existing[prop.Name] = true
+	}
+	result.Properties = append(Properties{}, result.Properties...)
+	for _, prop := range src.Properties {
+		if !existing[prop.Name] {
+			result.Properties = append(result.Properties, prop)
+		}
+	}
+	result.Required = append(append([]string{}, result.Required...), src.Required...)
+	return &result
+}
+
+// description returns the description of a schema, falling back to its title
+func description(s *Schema) string {
+	if s.Description != "" {
+		return strings.TrimSpace(s.Description)
+	}
+	return strings.TrimSpace(s.Title)
+}
+
+// commentLines formats lines as a YAML head comment
+func commentLines(lines []string, prefix string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(out, "\n")
+}
+
+// markerValue formats an enum value for a kubebuilder marker
+func markerValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return markerString(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// defaultValue formats a default for a +kubebuilder:default marker. Strings that
+// would otherwise be read as numbers or booleans are quoted.
+func defaultValue(v interface{}, typ string) string {
+	str, ok := v.(string)
+	if !ok || typ != "string" {
+		return markerValue(v)
+	}
+	if _, err := strconv.ParseFloat(str, 64); err == nil || str == "true" || str == "false" || str == "" {
+		return strconv.Quote(str)
+	}
+	return markerString(str)
+}
+
+// markerString quotes a marker string argument if it contains separators
+func markerString(s string) string {
+	if !strings.ContainsAny(s, ",;\"` ") && s != "" {
+		return s
+	}
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// formatNumber formats a number without a trailing ".0"
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// jsonType returns the JSON Schema type of a decoded value
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// toFloat converts a decoded number to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// containsType reports whether a type list contains a type
+func containsType(types TypeList, typ string) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarNode creates a plain string scalar node
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// joinPath appends a key to a dotted value path
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// displayPath returns a path for messages, naming the root explicitly
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var opts = Options{APIVersion: "example.com/v1", Kind: "MyChart"}
+
+const chartSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "My chart values",
+  "type": "object",
+  "required": ["image"],
+  "properties": {
+    "replicaCount": {"type": "integer", "description": "Number of replicas", "minimum": 1, "maximum": 10, "default": 1},
+    "image": {
+      "type": "object",
+      "description": "Image settings",
+      "properties": {
+        "repository": {"type": "string", "minLength": 1, "default": "nginx"},
+        "tag": {"type": "string", "pattern": "^[a-z0-9.]+$", "default": "1.25.0"},
+        "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent", "Never"]}
+      }
+    },
+    "podAnnotations": {"type": "object", "additionalProperties": {"type": "string"}},
+    "args": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+    "env": {"type": "array", "items": {"$ref": "#/definitions/envVar"}},
+    "port": {"$ref": "#/definitions/port", "description": "Service port"}
+  },
+  "definitions": {
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535, "default": 80},
+    "envVar": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "value": {"type": ["string", "null"]}}}
+  }
+}`
+
+func TestFromJSONSchema(t *testing.T) {
+	result, err := FromJSONSchema([]byte(chartSchema), opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+
+	assert.Equal(t, `## My chart values
+apiVersion: example.com/v1
+kind: MyChart
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+# +kubebuilder:validation:Maximum=10
+replicaCount: 1
+# Image settings
+# +kubebuilder:validation:Required
+image:
+  # +kubebuilder:validation:MinLength=1
+  repository: nginx
+  # +kubebuilder:validation:Pattern=^[a-z0-9.]+$
+  tag: 1.25.0
+  # +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+  pullPolicy: Always
+# +miaka:type: map[string]string
+podAnnotations: {}
+# +miaka:type: []string
+# +listType=set
+args: []
+env:
+  - # +kubebuilder:validation:Required
+    name: ""
+    value: ""
+# Service port
+# +kubebuilder:validation:Minimum=1
+# +kubebuilder:validation:Maximum=65535
+port: 80
+`, string(result.Content))
+
+	// The output is a valid example values file
+	s, err := parsing.NewParser().Parse(result.Content)
+	require.NoError(t, err)
+	require.NoError(t, schema.ValidateSchema(s))
+}
+
+func TestFromJSONSchema_Constraints(t *testing.T) {
+	result, err := FromJSONSchema([]byte(`{
+  "properties": {
+    "ratio": {"type": "number", "exclusiveMinimum": 0, "default": 0.5},
+    "legacy": {"type": "integer", "minimum": 5, "exclusiveMinimum": true},
+    "created": {"type": "string", "format": "date-time"},
+    "code": {"type": "string", "minLength": 3, "maxLength": 5},
+    "sep": {"type": "string", "pattern": "^a,b$"},
+    "hosts": {"type": "array", "items": {"type": "string"}, "minItems": 2},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}, "default": {"app": "web"}},
+    "mode": {"const": "fast"}
+  }
+}`), opts)
+	require.NoError(t, err)
+	content := string(result.Content)
+
+	assert.Contains(t, content, "# +kubebuilder:validation:Type=number\n# +kubebuilder:validation:Minimum=0\n# +kubebuilder:validation:ExclusiveMinimum=true\nratio: 1\n")
+	assert.Contains(t, content, "# +kubebuilder:validation:Minimum=5\n# +kubebuilder:validation:ExclusiveMinimum=true\nlegacy: 6\n")
+	assert.Contains(t, content, "# +kubebuilder:validation:Format=date-time\ncreated: \"2024-01-01T00:00:00Z\"\n")
+	assert.Contains(t, content, "code: xxx\n")
+	assert.Contains(t, content, "# +kubebuilder:validation:Pattern=`^a,b$`\nsep: \"\"\n")
+	assert.Contains(t, content, "hosts:\n  - \"\"\n  - \"\"\n")
+	assert.Contains(t, content, "# +miaka:type: map[string]string\nlabels:\n  app: web\n")
+	assert.Contains(t, content, "# +kubebuilder:validation:Enum=fast\nmode: fast\n")
+
+	assert.Equal(t, []string{
+		"ratio: fractional example 0.5 was rounded to 1",
+		`sep: example value may not match pattern "^a,b$"`,
+	}, result.Warnings)
+}
+
+func TestFromJSONSchema_Composition(t *testing.T) {
+	result, err := FromJSONSchema([]byte(`{
+  "allOf": [
+    {"properties": {"a": {"type": "string", "description": "From allOf"}}},
+    {"properties": {"b": {"type": "boolean"}}, "required": ["b"]}
+  ],
+  "properties": {
+    "choice": {"anyOf": [{"type": "null"}, {"type": "integer"}, {"type": "string"}]},
+    "free": {"type": "object"},
+    "unknown": {}
+  }
+}`), opts)
+	require.NoError(t, err)
+	content := string(result.Content)
+
+	assert.Contains(t, content, "# From allOf\na: \"\"\n")
+	assert.Contains(t, content, "# +kubebuilder:validation:Required\nb: false\n")
+	assert.Contains(t, content, "choice: 0\n")
+	assert.Contains(t, content, "# +miaka:type: map[string]string\nfree: {}\n")
+	assert.Contains(t, content, "unknown: null\n")
+
+	assert.Equal(t, []string{
+		"choice: only the first alternative of anyOf/oneOf was imported",
+		"free: free-form object was imported as map[string]string",
+		"unknown: type could not be determined; add a +miaka:type hint",
+	}, result.Warnings)
+}
+
+func TestFromJSONSchema_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		opts    Options
+		wantErr string
+	}{
+		{"invalid JSON", `{"type": `, opts, "failed to parse JSON Schema"},
+		{"missing kind", `{}`, Options{APIVersion: "example.com/v1"}, "apiVersion and kind are required"},
+		{"non-object root", `{"type": "string"}`, opts, "root schema must be an object"},
+		{"missing ref", `{"properties": {"a": {"$ref": "#/definitions/a"}}}`, opts, `a: $ref "#/definitions/a" not found`},
+		{"recursive ref", `{"properties": {"a": {"$ref": "#/definitions/a"}}, "definitions": {"a": {"$ref": "#/definitions/a"}}}`, opts, "recursive $ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromJSONSchema([]byte(tt.schema), tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
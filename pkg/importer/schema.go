@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the subset of JSON Schema (draft-04 through 2020-12) used to build example values
+type Schema struct {
+	Ref         string        `yaml:"$ref"`
+	Type        TypeList      `yaml:"type"`
+	Title       string        `yaml:"title"`
+	Description string        `yaml:"description"`
+	Default     interface{}   `yaml:"default"`
+	Examples    []interface{} `yaml:"examples"`
+	Const       interface{}   `yaml:"const"`
+	Enum        []interface{} `yaml:"enum"`
+	Format      string        `yaml:"format"`
+	Deprecated  bool          `yaml:"deprecated"`
+
+	Properties           Properties            `yaml:"properties"`
+	Required             []string              `yaml:"required"`
+	AdditionalProperties *AdditionalProperties `yaml:"additionalProperties"`
+	MinProperties        *int64                `yaml:"minProperties"`
+	MaxProperties        *int64                `yaml:"maxProperties"`
+
+	Items       *Schema `yaml:"items"`
+	MinItems    *int64  `yaml:"minItems"`
+	MaxItems    *int64  `yaml:"maxItems"`
+	UniqueItems bool    `yaml:"uniqueItems"`
+
+	MinLength *int64 `yaml:"minLength"`
+	MaxLength *int64 `yaml:"maxLength"`
+	Pattern   string `yaml:"pattern"`
+
+	Minimum          *float64    `yaml:"minimum"`
+	Maximum          *float64    `yaml:"maximum"`
+	ExclusiveMinimum interface{} `yaml:"exclusiveMinimum"` // bool in draft-04, number since draft-06
+	ExclusiveMaximum interface{} `yaml:"exclusiveMaximum"` // bool in draft-04, number since draft-06
+	MultipleOf       *float64    `yaml:"multipleOf"`
+
+	AllOf []*Schema `yaml:"allOf"`
+	AnyOf []*Schema `yaml:"anyOf"`
+	OneOf []*Schema `yaml:"oneOf"`
+
+	Definitions map[string]*Schema `yaml:"definitions"`
+	Defs        map[string]*Schema `yaml:"$defs"`
+
+	Nullable               bool         `yaml:"nullable"`
+	XIntOrString           bool         `yaml:"x-kubernetes-int-or-string"`
+	XPreserveUnknownFields bool         `yaml:"x-kubernetes-preserve-unknown-fields"`
+	XListType              string       `yaml:"x-kubernetes-list-type"`
+	XListMapKeys           []string     `yaml:"x-kubernetes-list-map-keys"`
+	XMapType               string       `yaml:"x-kubernetes-map-type"`
+	XValidations           []Validation `yaml:"x-kubernetes-validations"`
+}
+
+// Validation is a CEL validation rule from x-kubernetes-validations
+type Validation struct {
+	Rule    string `yaml:"rule"`
+	Message string `yaml:"message"`
+}
+
+// TypeList is a JSON Schema type, which may be a single type or a list of types
+type TypeList []string
+
+// UnmarshalYAML accepts both "type: string" and "type: [string, null]"
+func (t *TypeList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*t = TypeList{value.Value}
+		return nil
+	}
+	var types []string
+	if err := value.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+	return nil
+}
+
+// Primary returns the first non-null type, or "" if there is none
+func (t TypeList) Primary() string {
+	for _, typ := range t {
+		if typ != "null" {
+			return typ
+		}
+	}
+	return ""
+}
+
+// Property is a named property of an object schema
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are the properties of an object schema in the order they're declared
+type Properties []Property
+
+// UnmarshalYAML keeps the declaration order of properties, since it becomes
+// the order of fields in the example values file
+func (p *Properties) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties must be an object", value.Line)
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		var s Schema
+		if err := value.Content[i+1].Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, Property{Name: value.Content[i].Value, Schema: &s})
+	}
+	return nil
+}
+
+// AdditionalProperties is either a boolean or a schema for additional properties
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalYAML accepts both "additionalProperties: false" and a schema
+func (a *AdditionalProperties) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!bool" {
+		return value.Decode(&a.Allowed)
+	}
+	a.Allowed = true
+	a.Schema = &Schema{}
+	return value.Decode(a.Schema)
+}
+
+// ParseSchema parses a JSON Schema document (JSON or YAML)
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	return &s, nil
+}
+
+// resolveRef resolves a local reference (e.g., "#/definitions/port" or "#/$defs/port")
+func (s *Schema) resolveRef(ref string) (*Schema, error) {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q (only local references are supported)", ref)
+	}
+	section, name, ok := strings.Cut(pointer, "/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+
+	var defs map[string]*Schema
+	switch section {
+	case "definitions":
+		defs = s.Definitions
+	case "$defs":
+		defs = s.Defs
+	default:
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	def, ok := defs[name]
+	if !ok {
+		return nil, fmt.Errorf("$ref %q not found", ref)
+	}
+	return def, nil
+}
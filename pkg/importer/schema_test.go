@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchema(t *testing.T) {
+	s, err := ParseSchema([]byte(`{
+  "type": ["string", "null"],
+  "properties": {"b": {"type": "integer"}, "a": {"type": "string"}},
+  "additionalProperties": false,
+  "items": {"additionalProperties": {"type": "string"}}
+}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "string", s.Type.Primary())
+	require.Len(t, s.Properties, 2)
+	assert.Equal(t, "b", s.Properties[0].Name, "declaration order is kept")
+	assert.Equal(t, "a", s.Properties[1].Name)
+	assert.False(t, s.AdditionalProperties.Allowed)
+	assert.Equal(t, "string", s.Items.AdditionalProperties.Schema.Type.Primary())
+
+	_, err = ParseSchema([]byte(`{"properties": []}`))
+	require.Error(t, err)
+}
+
+func TestResolveRef(t *testing.T) {
+	s, err := ParseSchema([]byte(`{
+  "definitions": {"port": {"type": "integer"}},
+  "$defs": {"a/b": {"type": "string"}}
+}`))
+	require.NoError(t, err)
+
+	port, err := s.resolveRef("#/definitions/port")
+	require.NoError(t, err)
+	assert.Equal(t, "integer", port.Type.Primary())
+
+	escaped, err := s.resolveRef("#/$defs/a~1b")
+	require.NoError(t, err)
+	assert.Equal(t, "string", escaped.Type.Primary())
+
+	for _, ref := range []string{"other.json#/definitions/port", "#/definitions/missing", "#/properties/port", "#/definitions"} {
+		_, err := s.resolveRef(ref)
+		assert.Error(t, err, ref)
+	}
+}
@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValuesOptions configures a starter values file
+type ValuesOptions struct {
+	// OnlyRequired only includes required fields, within objects that are
+	// required themselves
+	OnlyRequired bool
+
+	// SkipDefaulted leaves out fields with a default, which apply without being set
+	SkipDefaulted bool
+
+	// Version is the CRD version to use (default: the storage version)
+	Version string
+}
+
+// valuesSkippedFields are root fields that chart consumers don't set in a values file
+var valuesSkippedFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+	"status":     true,
+}
+
+// NewValues generates a starter values file from a schema (a chart's
+// values.schema.json or a CustomResourceDefinition), for consumers to bootstrap
+// their configuration. Each field is set to its default or an example value
+// that satisfies the schema, with its description as a comment. Required fields
+// are marked, and deprecated fields are left out unless they're required.
+func NewValues(data []byte, opts ValuesOptions) (*Result, error) {
+	s, err := valuesSchema(data, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &converter{root: s, visiting: make(map[string]bool)}
+	root, err := c.resolve(s, "")
+	if err != nil {
+		return nil, err
+	}
+	if typ := schemaType(root); typ != "" && typ != "object" {
+		return nil, fmt.Errorf("root schema must be an object, got %s", typ)
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	if err := c.addStarterFields(mapping, root, "", true, opts); err != nil {
+		return nil, err
+	}
+	if len(mapping.Content) == 0 {
+		return &Result{Content: []byte("{}\n")}, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return &Result{Content: buf.Bytes()}, nil
+}
+
+// valuesSchema returns the schema of a JSON Schema document, or of a version
+// of the first CustomResourceDefinition in a YAML file
+func valuesSchema(data []byte, version string) (*Schema, error) {
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := findCRD(data)
+	if errors.Is(err, errNoCRD) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := selectVersion(doc.Spec.Versions, version)
+	if err != nil {
+		return nil, err
+	}
+	if v.Schema.OpenAPIV3Schema == nil {
+		return nil, fmt.Errorf("version %s of the CRD has no openAPIV3Schema", v.Name)
+	}
+	return v.Schema.OpenAPIV3Schema, nil
+}
+
+// addStarterFields adds the fields of an object schema that opts include to
+// mapping. requiredChain is whether the object itself is required, all the way
+// up to the root.
+func (c *converter) addStarterFields(mapping *yaml.Node, s *Schema, path string, requiredChain bool, opts ValuesOptions) error {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	for _, prop := range s.Properties {
+		if path == "" && valuesSkippedFields[prop.Name] {
+			continue
+		}
+		isRequired := requiredChain && required[prop.Name]
+		if opts.OnlyRequired && !isRequired {
+			continue
+		}
+		propPath := joinPath(path, prop.Name)
+		resolved, err := c.resolve(prop.Schema, propPath)
+		if err != nil {
+			return err
+		}
+		if (resolved.Deprecated && !required[prop.Name]) || (opts.SkipDefaulted && resolved.Default != nil) {
+			continue
+		}
+
+		value, err := c.starterValue(resolved, propPath, isRequired, opts)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+
+		key := scalarNode(prop.Name)
+		if doc := description(resolved); doc != "" {
+			key.HeadComment = commentLines(strings.Split(doc, "\n"), "# ")
+		}
+		if required[prop.Name] {
+			// Comments on the keys of flow values are dropped when encoding
+			if value.Kind == yaml.ScalarNode || value.Style == yaml.FlowStyle {
+				value.LineComment = "# required"
+			} else {
+				key.LineComment = "# required"
+			}
+		}
+		mapping.Content = append(mapping.Content, key, value)
+	}
+	return nil
+}
+
+// starterValue returns the starter value of a field, or nil for an optional
+// object none of whose fields are included
+func (c *converter) starterValue(s *Schema, path string, required bool, opts ValuesOptions) (*yaml.Node, error) {
+	typ := schemaType(s)
+	if typ == "" && s.XIntOrString {
+		typ = "string"
+	}
+
+	switch typ {
+	case "object":
+		return c.starterObject(s, path, required, opts)
+	case "array":
+		return c.starterArray(s, path, opts)
+	case "string", "integer", "number", "boolean":
+		return starterScalar(s, typ, path)
+	}
+	if s.XPreserveUnknownFields {
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+}
+
+// starterObject returns a nested mapping for objects with properties, and the
+// default (or an empty map) for maps
+func (c *converter) starterObject(s *Schema, path string, required bool, opts ValuesOptions) (*yaml.Node, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	if len(s.Properties) > 0 {
+		if err := c.addStarterFields(mapping, s, path, required, opts); err != nil {
+			return nil, err
+		}
+		if len(mapping.Content) > 0 {
+			return mapping, nil
+		}
+		if !required {
+			return nil, nil
+		}
+	}
+
+	mapping.Style = yaml.FlowStyle
+	if defaults, ok := s.Default.(map[string]interface{}); ok && len(defaults) > 0 {
+		if err := mapping.Encode(defaults); err != nil {
+			return nil, fmt.Errorf("%s: failed to encode default: %w", displayPath(path), err)
+		}
+	}
+	return mapping, nil
+}
+
+// starterArray returns the default of a list, example items for lists that
+// must not be empty, or an empty list
+func (c *converter) starterArray(s *Schema, path string, opts ValuesOptions) (*yaml.Node, error) {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	if defaults, ok := s.Default.([]interface{}); ok && len(defaults) > 0 {
+		if err := list.Encode(defaults); err != nil {
+			return nil, fmt.Errorf("%s: failed to encode default: %w", displayPath(path), err)
+		}
+		return list, nil
+	}
+
+	if s.MinItems != nil && *s.MinItems > 0 {
+		itemPath := path + "[]"
+		items := &Schema{}
+		if s.Items != nil {
+			resolved, err := c.resolve(s.Items, itemPath)
+			if err != nil {
+				return nil, err
+			}
+			items = resolved
+		}
+		for i := int64(0); i < *s.MinItems; i++ {
+			item, err := c.starterValue(items, itemPath, true, opts)
+			if err != nil {
+				return nil, err
+			}
+			list.Content = append(list.Content, item)
+		}
+		return list, nil
+	}
+
+	list.Style = yaml.FlowStyle
+	return list, nil
+}
+
+// starterScalar returns the default, an example, or a value that satisfies the
+// constraints of a scalar field
+func starterScalar(s *Schema, typ, path string) (*yaml.Node, error) {
+	example, found := exampleValue(s)
+	if found && !s.XIntOrString && jsonType(example) != typ && !(typ == "number" && jsonType(example) == "integer") {
+		found = false
+	}
+	if !found {
+		switch typ {
+		case "string":
+			example = exampleString(s)
+		case "integer", "number":
+			example = exampleNumber(s)
+		case "boolean":
+			example = false
+		}
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(example); err != nil {
+		return nil, fmt.Errorf("%s: failed to encode example: %w", displayPath(path), err)
+	}
+	return node, nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const consumerSchema = `{
+  "type": "object",
+  "required": ["host", "database"],
+  "properties": {
+    "replicaCount": {"type": "integer", "description": "Number of replicas", "default": 1},
+    "host": {"type": "string", "description": "Public hostname", "format": "hostname"},
+    "legacyHost": {"type": "string", "deprecated": true},
+    "database": {
+      "type": "object",
+      "required": ["password", "port"],
+      "properties": {
+        "password": {"type": "string", "minLength": 8},
+        "port": {"type": "integer", "minimum": 1024, "default": 5432},
+        "options": {"type": "object", "additionalProperties": {"type": "string"}}
+      }
+    },
+    "ingress": {
+      "type": "object",
+      "required": ["className"],
+      "properties": {"className": {"type": "string"}}
+    },
+    "servers": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/server"}}
+  },
+  "definitions": {
+    "server": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "weight": {"type": "number"}}}
+  }
+}`
+
+func TestNewValues(t *testing.T) {
+	result, err := NewValues([]byte(consumerSchema), ValuesOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `# Number of replicas
+replicaCount: 1
+# Public hostname
+host: example.com # required
+database: # required
+  password: xxxxxxxx # required
+  port: 5432 # required
+  options: {}
+ingress:
+  className: "" # required
+servers:
+  - name: "" # required
+    weight: 0
+`, string(result.Content))
+}
+
+func TestNewValues_OnlyRequired(t *testing.T) {
+	// Required fields of optional objects are left out
+	result, err := NewValues([]byte(consumerSchema), ValuesOptions{OnlyRequired: true})
+	require.NoError(t, err)
+	assert.Equal(t, `# Public hostname
+host: example.com # required
+database: # required
+  password: xxxxxxxx # required
+  port: 5432 # required
+`, string(result.Content))
+
+	// With SkipDefaulted, only the fields consumers have to set are left
+	result, err = NewValues([]byte(consumerSchema), ValuesOptions{OnlyRequired: true, SkipDefaulted: true})
+	require.NoError(t, err)
+	assert.Equal(t, `# Public hostname
+host: example.com # required
+database: # required
+  password: xxxxxxxx # required
+`, string(result.Content))
+
+	result, err = NewValues([]byte(`{"type": "object", "properties": {"debug": {"type": "boolean"}}}`), ValuesOptions{OnlyRequired: true})
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(result.Content))
+}
+
+func TestNewValues_CRD(t *testing.T) {
+	result, err := NewValues([]byte(widgetCRD), ValuesOptions{SkipDefaulted: true})
+	require.NoError(t, err)
+	assert.Equal(t, `# Desired state of the widget
+spec:
+  ports: []
+  selector: {}
+  options: {}
+  owner: ""
+`, string(result.Content))
+
+	result, err = NewValues([]byte(widgetCRD), ValuesOptions{Version: "v1beta1"})
+	require.NoError(t, err)
+	assert.Equal(t, "size: \"\"\n", string(result.Content))
+
+	_, err = NewValues([]byte(widgetCRD), ValuesOptions{Version: "v2"})
+	assert.EqualError(t, err, "version v2 not found in CRD")
+}
+
+func TestNewValues_Errors(t *testing.T) {
+	_, err := NewValues([]byte(`{"type": "string"}`), ValuesOptions{})
+	assert.EqualError(t, err, "root schema must be an object, got string")
+
+	_, err = NewValues([]byte(`{"type": [`), ValuesOptions{})
+	assert.ErrorContains(t, err, "failed to parse JSON Schema")
+}
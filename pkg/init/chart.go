@@ -0,0 +1,37 @@
+package init
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+)
+
+// DefaultDomain is the domain of the proposed API group if none is given
+const DefaultDomain = "example.com"
+
+// ProposeKRMFields proposes the apiVersion and kind of a chart's values from its
+// Chart.yaml. The kind is the chart's name in PascalCase (e.g., "my-app" to
+// "MyApp"), and the apiVersion is "<name>.<domain>/v<major version>" (e.g.,
+// "my-app.myorg.io/v2"), or v1alpha1 for charts before 1.0.0, whose API isn't
+// stable yet. domain defaults to DefaultDomain.
+func ProposeKRMFields(metadata *chart.Metadata, domain string) (apiVersion, kind string) {
+	if domain == "" {
+		domain = DefaultDomain
+	}
+	name := strings.ReplaceAll(strings.ToLower(metadata.Name), "_", "-")
+	return fmt.Sprintf("%s.%s/%s", name, domain, chartAPIVersion(metadata.Version)), schema.ToPascalCase(metadata.Name)
+}
+
+// chartAPIVersion returns the API version for a chart version: v<major>, or
+// v1alpha1 before 1.0.0 or if the version isn't semantic
+func chartAPIVersion(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil || n < 1 {
+		return "v1alpha1"
+	}
+	return "v" + strconv.Itoa(n)
+}
@@ -0,0 +1,57 @@
+package init
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/chart"
+)
+
+func TestProposeKRMFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		metadata       chart.Metadata
+		domain         string
+		wantAPIVersion string
+		wantKind       string
+	}{
+		{
+			name:           "major version",
+			metadata:       chart.Metadata{Name: "my-app", Version: "2.3.1"},
+			domain:         "myorg.io",
+			wantAPIVersion: "my-app.myorg.io/v2",
+			wantKind:       "MyApp",
+		},
+		{
+			name:           "default domain",
+			metadata:       chart.Metadata{Name: "redis", Version: "v18.1.0"},
+			wantAPIVersion: "redis.example.com/v18",
+			wantKind:       "Redis",
+		},
+		{
+			name:           "pre-release chart",
+			metadata:       chart.Metadata{Name: "cert_manager", Version: "0.4.0"},
+			domain:         "myorg.io",
+			wantAPIVersion: "cert-manager.myorg.io/v1alpha1",
+			wantKind:       "CertManager",
+		},
+		{
+			name:           "no version",
+			metadata:       chart.Metadata{Name: "App"},
+			domain:         "myorg.io",
+			wantAPIVersion: "app.myorg.io/v1alpha1",
+			wantKind:       "App",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiVersion, kind := ProposeKRMFields(&tt.metadata, tt.domain)
+			if apiVersion != tt.wantAPIVersion {
+				t.Errorf("Expected apiVersion %q, got %q", tt.wantAPIVersion, apiVersion)
+			}
+			if kind != tt.wantKind {
+				t.Errorf("Expected kind %q, got %q", tt.wantKind, kind)
+			}
+		})
+	}
+}
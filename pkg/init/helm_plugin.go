@@ -0,0 +1,51 @@
+package init
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HelmPluginFile is the manifest file of a Helm plugin
+const HelmPluginFile = "plugin.yaml"
+
+// helmPluginManifest runs 'miaka helm-validate' from a binary installed next to
+// the manifest, so 'helm miaka <chart> -f values.yaml' validates before install
+const helmPluginManifest = `name: miaka
+version: "%s"
+usage: "Validate values against a chart's schema before install"
+description: |-
+  Validate the values a chart would be installed with against the chart's
+  values.schema.json, or a schema generated from its example.values.yaml.
+
+  Usage: helm miaka <chart> [-f values.yaml]...
+command: "$HELM_PLUGIN_DIR/miaka helm-validate"
+`
+
+// WriteHelmPlugin writes a Helm plugin manifest for miaka to dir and returns its path.
+// An existing manifest is not overwritten.
+func WriteHelmPlugin(dir, version string) (string, error) {
+	path := filepath.Join(dir, HelmPluginFile)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+	content := fmt.Sprintf(helmPluginManifest, pluginVersion(version))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", HelmPluginFile, err)
+	}
+	return path, nil
+}
+
+// pluginVersion converts a miaka version to the SemVer version Helm expects
+func pluginVersion(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" || version == "dev" {
+		return "0.0.0-dev"
+	}
+	return version
+}
@@ -0,0 +1,59 @@
+package init
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteHelmPlugin(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "helm-miaka")
+
+	path, err := WriteHelmPlugin(dir, "v1.2.3")
+	if err != nil {
+		t.Fatalf("WriteHelmPlugin failed: %v", err)
+	}
+	if path != filepath.Join(dir, HelmPluginFile) {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read plugin manifest: %v", err)
+	}
+	var manifest map[string]string
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Plugin manifest is not valid YAML: %v", err)
+	}
+	if manifest["name"] != "miaka" {
+		t.Errorf("expected name miaka, got %q", manifest["name"])
+	}
+	if manifest["version"] != "1.2.3" {
+		t.Errorf("expected version 1.2.3, got %q", manifest["version"])
+	}
+	if !strings.HasSuffix(manifest["command"], "miaka helm-validate") {
+		t.Errorf("expected command to run helm-validate, got %q", manifest["command"])
+	}
+
+	// An existing manifest is never overwritten
+	if _, err := WriteHelmPlugin(dir, "v1.2.4"); err == nil {
+		t.Error("expected error for existing manifest")
+	}
+}
+
+func TestPluginVersion(t *testing.T) {
+	tests := map[string]string{
+		"v0.5.0": "0.5.0",
+		"0.5.0":  "0.5.0",
+		"dev":    "0.0.0-dev",
+		"":       "0.0.0-dev",
+	}
+	for version, expected := range tests {
+		if got := pluginVersion(version); got != expected {
+			t.Errorf("pluginVersion(%q) = %q, want %q", version, got, expected)
+		}
+	}
+}
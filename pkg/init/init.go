@@ -14,7 +14,11 @@ func CheckKRMFields(inputFile string) (hasAPIVersion, hasKind bool) {
 	if err != nil {
 		return false, false
 	}
+	return CheckKRMFieldsContent(data)
+}
 
+// CheckKRMFieldsContent checks if YAML content has apiVersion and kind fields
+func CheckKRMFieldsContent(data []byte) (hasAPIVersion, hasKind bool) {
 	var rootNode yaml.Node
 	if err := yaml.Unmarshal(data, &rootNode); err != nil {
 		return false, false
@@ -53,10 +57,27 @@ func CheckKRMFields(inputFile string) (hasAPIVersion, hasKind bool) {
 // If inputFile already has apiVersion/kind, they are preserved and the provided values are ignored (can be empty).
 // When apiVersion and kind are required but not provided, returns an error.
 func ConvertToKRM(inputFile, outputFile, apiVersion, kind string) error {
-	contentNode, rootNode, err := prepareYAMLContent(inputFile, apiVersion, kind)
+	data, err := readInput(inputFile)
 	if err != nil {
 		return err
 	}
+	output, err := ConvertToKRMContent(data, apiVersion, kind)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFile, output, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// ConvertToKRMContent is ConvertToKRM for values content, returning the output.
+// Nil content creates an empty KRM-compliant YAML.
+func ConvertToKRMContent(data []byte, apiVersion, kind string) ([]byte, error) {
+	contentNode, rootNode, err := prepareYAMLContent(data, apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if apiVersion or kind already exist in the input
 	var hasAPIVersion, hasKind bool
@@ -79,15 +100,15 @@ func ConvertToKRM(inputFile, outputFile, apiVersion, kind string) error {
 		// Handle cases where one or both are missing
 		if hasAPIVersion {
 			// Only apiVersion exists - missing kind
-			return fmt.Errorf("file has apiVersion but missing kind - provide --kind flag or run interactively")
+			return nil, fmt.Errorf("file has apiVersion but missing kind - provide --kind flag or run interactively")
 		}
 		if hasKind {
 			// Only kind exists - missing apiVersion
-			return fmt.Errorf("file has kind but missing apiVersion - provide --api-version flag or run interactively")
+			return nil, fmt.Errorf("file has kind but missing apiVersion - provide --api-version flag or run interactively")
 		}
 		// Neither exist, must provide both
 		if finalAPIVersion == "" || finalKind == "" {
-			return fmt.Errorf("apiVersion and kind are required (provide via flags or run interactively)")
+			return nil, fmt.Errorf("apiVersion and kind are required (provide via flags or run interactively)")
 		}
 
 		// Create new nodes for apiVersion and kind
@@ -121,24 +142,66 @@ func ConvertToKRM(inputFile, outputFile, apiVersion, kind string) error {
 	// Marshal back to YAML
 	output, err := yaml.Marshal(&rootNode)
 	if err != nil {
-		return fmt.Errorf("failed to marshal YAML: %w", err)
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
 	}
+	return output, nil
+}
 
-	// Write to output file
+// ConvertToPlain copies a Helm values.yaml for 'miaka build --plain', which needs
+// no apiVersion or kind. The file is copied as-is, so comments and formatting are
+// kept exactly. If inputFile is empty, an empty values file is created.
+func ConvertToPlain(inputFile, outputFile string) error {
+	data, err := readInput(inputFile)
+	if err != nil {
+		return err
+	}
+	output, err := ConvertToPlainContent(data)
+	if err != nil {
+		return err
+	}
 	if err := os.WriteFile(outputFile, output, 0644); err != nil {
 		return fmt.Errorf("failed to write output file: %w", err)
 	}
-
 	return nil
 }
 
-// prepareYAMLContent prepares YAML content node from input file or creates an empty one
-func prepareYAMLContent(inputFile, apiVersion, kind string) (*yaml.Node, yaml.Node, error) {
+// ConvertToPlainContent is ConvertToPlain for values content, returning the
+// output. Nil content creates empty values.
+func ConvertToPlainContent(data []byte) ([]byte, error) {
+	if data == nil {
+		return []byte("{}\n"), nil
+	}
+	hasAPIVersion, hasKind := CheckKRMFieldsContent(data)
+	if hasAPIVersion || hasKind {
+		return nil, fmt.Errorf("file already has apiVersion or kind, which plain values can't have")
+	}
+	// Only validate the structure; the original bytes are written
+	if _, _, err := prepareYAMLContent(data, "", ""); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readInput reads an input file, or returns nil if inputFile is empty
+func readInput(inputFile string) ([]byte, error) {
+	if inputFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	return data, nil
+}
+
+// prepareYAMLContent prepares YAML content node from input content or, if it's
+// nil, creates an empty one
+func prepareYAMLContent(data []byte, apiVersion, kind string) (*yaml.Node, yaml.Node, error) {
 	var contentNode *yaml.Node
 	var rootNode yaml.Node
 
-	// Handle empty input file (create new empty KRM YAML)
-	if inputFile == "" {
+	// Handle no input (create new empty KRM YAML)
+	if data == nil {
 		if apiVersion == "" || kind == "" {
 			return nil, rootNode, fmt.Errorf("apiVersion and kind are required (provide via --api-version and --kind flags)")
 		}
@@ -155,12 +218,6 @@ func prepareYAMLContent(inputFile, apiVersion, kind string) (*yaml.Node, yaml.No
 		return contentNode, rootNode, nil
 	}
 
-	// Read input file
-	data, err := os.ReadFile(inputFile)
-	if err != nil {
-		return nil, rootNode, fmt.Errorf("failed to read input file: %w", err)
-	}
-
 	// Parse YAML with full node structure to preserve comments
 	if err := yaml.Unmarshal(data, &rootNode); err != nil {
 		return nil, rootNode, fmt.Errorf("failed to parse YAML: %w", err)
@@ -402,6 +402,74 @@ image: nginx:latest
 	}
 }
 
+func TestConvertToPlain(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "values.yaml")
+	outputFile := filepath.Join(tmpDir, "example.values.yaml")
+
+	inputContent := `# Number of replicas
+replicaCount: 3 # Must be positive
+
+service:
+  port: 80
+`
+	if err := os.WriteFile(inputFile, []byte(inputContent), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+
+	if err := ConvertToPlain(inputFile, outputFile); err != nil {
+		t.Fatalf("ConvertToPlain failed: %v", err)
+	}
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(output) != inputContent {
+		t.Errorf("Expected the values to be copied exactly, got:\n%s", output)
+	}
+
+	// Without an input file, an empty values file is created
+	if err := ConvertToPlain("", outputFile); err != nil {
+		t.Fatalf("ConvertToPlain failed: %v", err)
+	}
+	output, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if string(output) != "{}\n" {
+		t.Errorf("Expected an empty values file, got: %q", output)
+	}
+}
+
+func TestConvertToPlain_Errors(t *testing.T) {
+	tmpDir := t.TempDir()
+	krmFile := filepath.Join(tmpDir, "krm.yaml")
+	if err := os.WriteFile(krmFile, []byte("apiVersion: myapp.io/v1\nkind: MyApp\n"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+	listFile := filepath.Join(tmpDir, "list.yaml")
+	if err := os.WriteFile(listFile, []byte("- a\n"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"KRM fields": {input: krmFile, err: "already has apiVersion or kind"},
+		"not object": {input: listFile, err: "must be an object"},
+		"missing":    {input: filepath.Join(tmpDir, "missing.yaml"), err: "failed to read input file"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ConvertToPlain(tt.input, filepath.Join(tmpDir, "out.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
 func TestCheckKRMFields_BothPresent(t *testing.T) {
 	tmpDir := t.TempDir()
 	inputFile := filepath.Join(tmpDir, "test.yaml")
@@ -513,3 +581,27 @@ func TestCheckKRMFields_InvalidYAML(t *testing.T) {
 		t.Error("Expected hasKind to be false for invalid YAML")
 	}
 }
+
+// TestConvertContent tests converting values content, such as those read from
+// a chart archive, without files
+func TestConvertContent(t *testing.T) {
+	output, err := ConvertToKRMContent([]byte("# Replicas\nreplicas: 1\n"), "test.io/v1", "Test")
+	if err != nil {
+		t.Fatalf("ConvertToKRMContent failed: %v", err)
+	}
+	if want := "apiVersion: test.io/v1\nkind: Test\n# Replicas\nreplicas: 1\n"; string(output) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, output)
+	}
+
+	output, err = ConvertToPlainContent(nil)
+	if err != nil || string(output) != "{}\n" {
+		t.Errorf("Expected empty values, got %q, %v", output, err)
+	}
+	if _, err := ConvertToPlainContent([]byte("kind: Test\n")); err == nil {
+		t.Error("Expected plain values with a kind to be rejected")
+	}
+
+	if hasAPIVersion, hasKind := CheckKRMFieldsContent([]byte("apiVersion: v1\n")); !hasAPIVersion || hasKind {
+		t.Errorf("Expected only apiVersion, got %v, %v", hasAPIVersion, hasKind)
+	}
+}
@@ -0,0 +1,181 @@
+package init
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// krmKeys are the top-level keys of example values that upstream values don't have
+var krmKeys = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// MergeReport lists the changes of an upstream values.yaml since example values
+// were made from it, by dotted path (e.g., "service.annotations")
+type MergeReport struct {
+	// Added are the keys upstream has that the example values didn't, which the
+	// merge added
+	Added []string
+	// Removed are the keys of the example values upstream no longer has, which
+	// the merge kept, since they may have been added to the example on purpose
+	Removed []string
+}
+
+// MergeUpstream merges an updated upstream values.yaml into example values and
+// writes them to outputFile if keys were added (see MergeUpstreamContent)
+func MergeUpstream(exampleFile, upstreamFile, outputFile string) (MergeReport, error) {
+	upstream, err := os.ReadFile(upstreamFile)
+	if err != nil {
+		return MergeReport{}, fmt.Errorf("failed to read upstream values: %w", err)
+	}
+	return MergeUpstreamValues(exampleFile, upstream, outputFile)
+}
+
+// MergeUpstreamValues is MergeUpstream for upstream values content
+func MergeUpstreamValues(exampleFile string, upstream []byte, outputFile string) (MergeReport, error) {
+	example, err := os.ReadFile(exampleFile)
+	if err != nil {
+		return MergeReport{}, fmt.Errorf("failed to read example values: %w", err)
+	}
+
+	output, report, err := MergeUpstreamContent(example, upstream)
+	if err != nil {
+		return MergeReport{}, err
+	}
+	if len(report.Added) == 0 && exampleFile == outputFile {
+		return report, nil
+	}
+	if err := os.WriteFile(outputFile, output, 0644); err != nil {
+		return MergeReport{}, fmt.Errorf("failed to write output file: %w", err)
+	}
+	return report, nil
+}
+
+// MergeUpstreamContent adds the keys of an upstream values.yaml that example
+// values don't have, with their comments, after the keys that precede them
+// upstream. Keys the example has are kept as they are, with their values,
+// markers, and comments, and lists are examples of their own, so their items
+// aren't merged. The example's indentation is kept, but other formatting may
+// be normalized.
+func MergeUpstreamContent(example, upstream []byte) ([]byte, MergeReport, error) {
+	exampleRoot, err := parseMapping(example)
+	if err != nil {
+		return nil, MergeReport{}, fmt.Errorf("failed to parse example values: %w", err)
+	}
+	upstreamRoot, err := parseMapping(upstream)
+	if err != nil {
+		return nil, MergeReport{}, fmt.Errorf("failed to parse upstream values: %w", err)
+	}
+
+	var report MergeReport
+	mergeMapping(exampleRoot.Content[0], upstreamRoot.Content[0], "", &report)
+	if len(report.Added) == 0 {
+		return example, report, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectIndent(example))
+	if err := encoder.Encode(exampleRoot); err != nil {
+		return nil, MergeReport{}, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, MergeReport{}, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	return buf.Bytes(), report, nil
+}
+
+// parseMapping parses a values file, whose root must be a mapping. Empty files
+// are empty mappings.
+func parseMapping(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}, nil
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("root YAML node must be an object")
+	}
+	return &doc, nil
+}
+
+// mergeMapping adds the keys of upstream that example doesn't have, and reports
+// the keys of example that upstream doesn't have. prefix is the path of the mapping.
+func mergeMapping(example, upstream *yaml.Node, prefix string, report *MergeReport) {
+	// insertAt is where the next added key goes: after the last key of example
+	// that precedes it upstream, or after the KRM keys at the top level
+	insertAt := 0
+	if prefix == "" {
+		for insertAt < len(example.Content) && krmKeys[example.Content[insertAt].Value] {
+			insertAt += 2
+		}
+	}
+
+	for i := 0; i+1 < len(upstream.Content); i += 2 {
+		key, value := upstream.Content[i], upstream.Content[i+1]
+		path := joinPath(prefix, key.Value)
+		index := keyIndex(example, key.Value)
+		if index < 0 {
+			example.Content = append(example.Content[:insertAt], append([]*yaml.Node{key, value}, example.Content[insertAt:]...)...)
+			insertAt += 2
+			report.Added = append(report.Added, path)
+			continue
+		}
+
+		exampleValue := example.Content[index+1]
+		if exampleValue.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeMapping(exampleValue, value, path, report)
+		}
+		insertAt = index + 2
+	}
+
+	for i := 0; i+1 < len(example.Content); i += 2 {
+		key := example.Content[i].Value
+		if (prefix == "" && krmKeys[key]) || keyIndex(upstream, key) >= 0 {
+			continue
+		}
+		report.Removed = append(report.Removed, joinPath(prefix, key))
+	}
+}
+
+// keyIndex returns the index of a key in a mapping's content, or -1
+func keyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// joinPath returns the dotted path of a key of the mapping at prefix
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// detectIndent returns the indentation of a values file: the least indentation
+// of its nested keys, or 2 if it has none
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+		if n == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if indent == 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 2
+	}
+	return indent
+}
@@ -0,0 +1,171 @@
+package init
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeUpstreamContent(t *testing.T) {
+	example := `apiVersion: myapp.io/v1
+kind: MyApp
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 3
+service:
+  # Service type, edited by hand
+  type: LoadBalancer
+# A key only the example has
+legacy: true
+`
+	upstream := `# Number of replicas
+replicas: 1
+# Image to run
+image:
+  # Image repository
+  repository: nginx
+service:
+  type: ClusterIP
+  # Service port
+  port: 80
+`
+	expected := `apiVersion: myapp.io/v1
+kind: MyApp
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 3
+# Image to run
+image:
+  # Image repository
+  repository: nginx
+service:
+  # Service type, edited by hand
+  type: LoadBalancer
+  # Service port
+  port: 80
+# A key only the example has
+legacy: true
+`
+
+	output, report, err := MergeUpstreamContent([]byte(example), []byte(upstream))
+	if err != nil {
+		t.Fatalf("MergeUpstreamContent failed: %v", err)
+	}
+	if string(output) != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+	if want := []string{"image", "service.port"}; !reflect.DeepEqual(report.Added, want) {
+		t.Errorf("Expected added %v, got %v", want, report.Added)
+	}
+	if want := []string{"legacy"}; !reflect.DeepEqual(report.Removed, want) {
+		t.Errorf("Expected removed %v, got %v", want, report.Removed)
+	}
+}
+
+func TestMergeUpstreamContent_Order(t *testing.T) {
+	tests := []struct {
+		name     string
+		example  string
+		upstream string
+		expected string
+	}{
+		{
+			name:     "first key goes after apiVersion and kind",
+			example:  "apiVersion: myapp.io/v1\nkind: MyApp\nb: 2\n",
+			upstream: "a: 1\nb: 2\n",
+			expected: "apiVersion: myapp.io/v1\nkind: MyApp\na: 1\nb: 2\n",
+		},
+		{
+			name:     "keys go after their upstream predecessor",
+			example:  "a: 1\nc: 3\n",
+			upstream: "a: 1\nb: 2\nc: 3\nd: 4\n",
+			expected: "a: 1\nb: 2\nc: 3\nd: 4\n",
+		},
+		{
+			name:     "indentation is kept",
+			example:  "a:\n    b: 1\n",
+			upstream: "a:\n  b: 1\n  c: 2\n",
+			expected: "a:\n    b: 1\n    c: 2\n",
+		},
+		{
+			name:     "lists aren't merged",
+			example:  "hosts:\n  - name: a\n",
+			upstream: "hosts:\n  - name: b\n    port: 80\nport: 80\n",
+			expected: "hosts:\n  - name: a\nport: 80\n",
+		},
+		{
+			name:     "scalars aren't replaced by mappings",
+			example:  "resources: null\n",
+			upstream: "resources:\n  cpu: 100m\n",
+			expected: "resources: null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := MergeUpstreamContent([]byte(tt.example), []byte(tt.upstream))
+			if err != nil {
+				t.Fatalf("MergeUpstreamContent failed: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Unexpected output:\n%s\nexpected:\n%s", output, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeUpstreamContent_UpToDate(t *testing.T) {
+	// Unchanged examples are returned as they are, not reformatted
+	example := "apiVersion: myapp.io/v1\nkind: MyApp\nreplicas:   3 # three\n"
+	output, report, err := MergeUpstreamContent([]byte(example), []byte("replicas: 1\n"))
+	if err != nil {
+		t.Fatalf("MergeUpstreamContent failed: %v", err)
+	}
+	if string(output) != example {
+		t.Errorf("Expected the example unchanged, got:\n%s", output)
+	}
+	if len(report.Added) != 0 || len(report.Removed) != 0 {
+		t.Errorf("Expected no changes, got %+v", report)
+	}
+}
+
+func TestMergeUpstreamContent_Errors(t *testing.T) {
+	if _, _, err := MergeUpstreamContent([]byte("- a\n"), []byte("a: 1\n")); err == nil {
+		t.Error("Expected an error for example values that aren't an object")
+	}
+	if _, _, err := MergeUpstreamContent([]byte("a: 1\n"), []byte("a: [\n")); err == nil {
+		t.Error("Expected an error for invalid upstream values")
+	}
+}
+
+func TestMergeUpstream(t *testing.T) {
+	tmpDir := t.TempDir()
+	examplePath := filepath.Join(tmpDir, "example.values.yaml")
+	upstreamPath := filepath.Join(tmpDir, "values.yaml")
+	if err := os.WriteFile(examplePath, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write example: %v", err)
+	}
+	if err := os.WriteFile(upstreamPath, []byte("a: 1\nb: 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write upstream: %v", err)
+	}
+
+	report, err := MergeUpstream(examplePath, upstreamPath, examplePath)
+	if err != nil {
+		t.Fatalf("MergeUpstream failed: %v", err)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(report.Added, want) {
+		t.Errorf("Expected added %v, got %v", want, report.Added)
+	}
+	output, err := os.ReadFile(examplePath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(output) != "a: 1\nb: 2\n" {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	if _, err := MergeUpstream(filepath.Join(tmpDir, "missing.yaml"), upstreamPath, examplePath); err == nil {
+		t.Error("Expected an error for missing example values")
+	}
+}
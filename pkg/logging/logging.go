@@ -0,0 +1,60 @@
+// Package logging prints the messages of miaka's commands at the level chosen
+// with --quiet and --verbose, so callers can redirect or silence them
+package logging
+
+import (
+	"fmt"
+	"io"
+)
+
+// Level is the importance of a message
+type Level int
+
+// Levels of messages, from least to most important
+const (
+	// LevelDebug is for details of each step, printed with --verbose
+	LevelDebug Level = iota
+	// LevelInfo is for progress and next steps, hidden by --quiet
+	LevelInfo
+	// LevelWarn is for problems that don't fail the command
+	LevelWarn
+	// LevelError is for the problems a command fails with
+	LevelError
+)
+
+// Logger prints messages at their level. Each message is printed on its own
+// line; an empty message prints a blank line.
+type Logger interface {
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// New returns a Logger printing the messages at level or above to w
+func New(w io.Writer, level Level) Logger {
+	return &writerLogger{w: w, level: level}
+}
+
+// Discard returns a Logger that prints nothing
+func Discard() Logger {
+	return New(io.Discard, LevelError)
+}
+
+// writerLogger prints messages to a writer
+type writerLogger struct {
+	w     io.Writer
+	level Level
+}
+
+func (l *writerLogger) Debugf(format string, args ...any) { l.logf(LevelDebug, format, args...) }
+func (l *writerLogger) Infof(format string, args ...any)  { l.logf(LevelInfo, format, args...) }
+func (l *writerLogger) Warnf(format string, args ...any)  { l.logf(LevelWarn, format, args...) }
+func (l *writerLogger) Errorf(format string, args ...any) { l.logf(LevelError, format, args...) }
+
+func (l *writerLogger) logf(level Level, format string, args ...any) {
+	if level < l.level {
+		return
+	}
+	fmt.Fprintf(l.w, format+"\n", args...)
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	for _, tt := range []struct {
+		level Level
+		want  string
+	}{
+		{LevelDebug, "debug 1\ninfo 2\n\nwarn 3\nerror 4\n"},
+		{LevelInfo, "info 2\n\nwarn 3\nerror 4\n"},
+		{LevelWarn, "warn 3\nerror 4\n"},
+		{LevelError, "error 4\n"},
+	} {
+		var buf bytes.Buffer
+		log := New(&buf, tt.level)
+		log.Debugf("debug %d", 1)
+		log.Infof("info %d", 2)
+		log.Infof("")
+		log.Warnf("warn %d", 3)
+		log.Errorf("error %d", 4)
+		assert.Equal(t, tt.want, buf.String(), "level %d", tt.level)
+	}
+}
@@ -0,0 +1,201 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// itemSegment is the path segment for the items of a list
+const itemSegment = "[]"
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// documentKind returns the kind of a values document, or "" if it has none
+func documentKind(text string) string {
+	var doc struct {
+		Kind string `json:"kind"`
+	}
+	if err := sigsyaml.Unmarshal([]byte(text), &doc); err != nil {
+		return ""
+	}
+	return doc.Kind
+}
+
+// diagnose validates a document against a JSON Schema and returns its problems.
+// Each violation is placed on the key of the offending value.
+func diagnose(text string, schemaJSON []byte) []Diagnostic {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		return []Diagnostic{newDiagnostic(errorLine(err), fmt.Sprintf("invalid YAML: %v", err))}
+	}
+
+	values := map[string]interface{}{}
+	if err := sigsyaml.Unmarshal([]byte(text), &values); err != nil {
+		return []Diagnostic{newDiagnostic(0, fmt.Sprintf("values must be an object: %v", err))}
+	}
+
+	err := validation.ValidateValues(values, schemaJSON)
+	if err == nil {
+		return []Diagnostic{}
+	}
+	violations := validation.Violations(err)
+	if violations == nil {
+		return []Diagnostic{newDiagnostic(0, err.Error())}
+	}
+
+	diagnostics := make([]Diagnostic, 0, len(violations))
+	for _, v := range violations {
+		line := 0
+		message := v.Message
+		if node := validation.NodeAt(contentNode(&root), v.Path); node != nil {
+			line = node.Line - 1
+		}
+		if len(v.Path) > 0 {
+			message = strings.Join(v.Path, ".") + ": " + message
+		}
+		diagnostics = append(diagnostics, newDiagnostic(line, message))
+	}
+	return diagnostics
+}
+
+// newDiagnostic creates an error diagnostic covering a whole line
+func newDiagnostic(line int, message string) Diagnostic {
+	return Diagnostic{
+		Range:    Range{Start: Position{Line: line}, End: Position{Line: line + 1}},
+		Severity: SeverityError,
+		Source:   "miaka",
+		Message:  message,
+	}
+}
+
+// errorLine extracts the zero-based line of a YAML parse error
+func errorLine(err error) int {
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		if line, err := strconv.Atoi(match[1]); err == nil && line > 0 {
+			return line - 1
+		}
+	}
+	return 0
+}
+
+// contentNode returns the root mapping of a document node
+func contentNode(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// keyAt returns the schema path of the key under the cursor and the key node.
+// List items are represented by itemSegment.
+func keyAt(text string, pos Position) ([]string, *yaml.Node) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		return nil, nil
+	}
+	return findKey(contentNode(&root), nil, pos)
+}
+
+// findKey searches a node for the key at a position
+func findKey(node *yaml.Node, path []string, pos Position) ([]string, *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			keyPath := append(append([]string{}, path...), key.Value)
+			if key.Line-1 == pos.Line && pos.Character >= key.Column-1 && pos.Character <= key.Column-1+len(key.Value) {
+				return keyPath, key
+			}
+			if found, node := findKey(node.Content[i+1], keyPath, pos); found != nil {
+				return found, node
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if found, node := findKey(item, append(append([]string{}, path...), itemSegment), pos); found != nil {
+				return found, node
+			}
+		}
+	}
+	return nil, nil
+}
+
+var keyPattern = regexp.MustCompile(`^([^\s:#'"\[\]{}][^:#]*?):(\s|$)`)
+
+// lineInfo describes the structure of a line of YAML
+type lineInfo struct {
+	indent int    // Column of the first non-space character
+	dash   bool   // The line starts a list item
+	keyCol int    // Column of the key (after any dash)
+	key    string // The key, if the line has one
+}
+
+// parseLine finds the list dash and key of a line
+func parseLine(line string) lineInfo {
+	content := strings.TrimLeft(line, " ")
+	info := lineInfo{indent: len(line) - len(content)}
+	info.keyCol = info.indent
+	if content == "-" || strings.HasPrefix(content, "- ") {
+		info.dash = true
+		rest := strings.TrimLeft(content[1:], " ")
+		info.keyCol += len(content) - len(rest)
+		content = rest
+	}
+	if match := keyPattern.FindStringSubmatch(content); match != nil {
+		info.key = strings.TrimSpace(match[1])
+	}
+	return info
+}
+
+// completionContext determines what to complete at a position, using only the
+// text above the cursor so it works while the document is being edited.
+// Returns the schema path of the enclosing object, and the key when the cursor
+// is in the value of a field.
+func completionContext(text string, pos Position) (parent []string, key string) {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return nil, ""
+	}
+	current := lines[pos.Line]
+	if pos.Character < len(current) {
+		current = current[:pos.Character]
+	}
+
+	info := parseLine(current)
+	key = info.key
+	col, inItem, dashCol := info.keyCol, info.dash, info.indent
+
+	for i := pos.Line - 1; i >= 0 && (col > 0 || inItem); i-- {
+		content := strings.TrimSpace(lines[i])
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		line := parseLine(lines[i])
+
+		if inItem {
+			// Skip sibling items and their contents until the list's key
+			if line.indent > dashCol || (line.dash && line.indent == dashCol) || line.key == "" || line.keyCol > dashCol {
+				continue
+			}
+			parent = append([]string{line.key, itemSegment}, parent...)
+		} else {
+			if line.dash && line.keyCol == col {
+				// The first key of the item we're in
+				inItem, dashCol = true, line.indent
+				continue
+			}
+			if line.key == "" || line.keyCol >= col {
+				continue
+			}
+			parent = append([]string{line.key}, parent...)
+		}
+		col, inItem, dashCol = line.keyCol, line.dash, line.indent
+	}
+	return parent, key
+}
@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "image": {
+      "type": "object",
+      "description": "Container image",
+      "properties": {
+        "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent"]},
+        "tag": {"type": "string", "description": "Image tag", "default": "latest"}
+      }
+    },
+    "replicas": {"type": "integer", "description": "Number of replicas", "minimum": 1},
+    "debug": {"type": "boolean"},
+    "env": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string"},
+          "value": {"type": "string"}
+        }
+      }
+    },
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+  }
+}`
+
+const testDocument = `image:
+  tag: v1
+  pullPolicy: Sometimes
+replicas: 0
+env:
+- name: A
+  value: a
+- value: b
+`
+
+func TestDiagnose(t *testing.T) {
+	diagnostics := diagnose(testDocument, []byte(testSchema))
+
+	lines := map[int]string{}
+	for _, d := range diagnostics {
+		assert.Equal(t, SeverityError, d.Severity)
+		assert.Equal(t, "miaka", d.Source)
+		lines[d.Range.Start.Line] = d.Message
+	}
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "image.pullPolicy: ")
+	assert.Contains(t, lines[3], "replicas: ")
+	assert.Contains(t, lines[7], "env.1: ")
+	assert.Contains(t, lines[7], "name")
+
+	assert.Empty(t, diagnose("replicas: 2\n", []byte(testSchema)))
+}
+
+func TestDiagnose_InvalidYAML(t *testing.T) {
+	diagnostics := diagnose("replicas: 1\nimage: [\n", []byte(testSchema))
+	require.Len(t, diagnostics, 1)
+	assert.Contains(t, diagnostics[0].Message, "invalid YAML")
+	assert.Equal(t, 1, diagnostics[0].Range.Start.Line)
+}
+
+func TestKeyAt(t *testing.T) {
+	tests := []struct {
+		pos  Position
+		path []string
+	}{
+		{pos: Position{Line: 1, Character: 3}, path: []string{"image", "tag"}},
+		{pos: Position{Line: 3, Character: 0}, path: []string{"replicas"}},
+		{pos: Position{Line: 6, Character: 4}, path: []string{"env", "[]", "value"}},
+		{pos: Position{Line: 3, Character: 11}, path: nil},
+	}
+	for _, tt := range tests {
+		path, _ := keyAt(testDocument, tt.pos)
+		assert.Equal(t, tt.path, path, "%+v", tt.pos)
+	}
+}
+
+func TestCompletionContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		pos    Position
+		parent []string
+		key    string
+	}{
+		{name: "root", text: "replicas: 1\n", pos: Position{Line: 1, Character: 0}},
+		{name: "nested", text: "image:\n  tag: v1\n  \n", pos: Position{Line: 2, Character: 2}, parent: []string{"image"}},
+		{name: "value", text: "image:\n  pullPolicy: \n", pos: Position{Line: 1, Character: 14}, parent: []string{"image"}, key: "pullPolicy"},
+		{name: "new list item", text: "env:\n- \n", pos: Position{Line: 1, Character: 2}, parent: []string{"env", "[]"}},
+		{name: "second key of item", text: "env:\n- name: A\n  \n", pos: Position{Line: 2, Character: 2}, parent: []string{"env", "[]"}},
+		{name: "after items", text: "env:\n- name: A\n  value: a\n- name: B\n  \n", pos: Position{Line: 4, Character: 2}, parent: []string{"env", "[]"}},
+		{name: "back at root", text: "image:\n  tag: v1\n\n", pos: Position{Line: 2, Character: 0}},
+		{name: "nested lists", text: "a:\n- b:\n  - c: 1\n    \n", pos: Position{Line: 3, Character: 4}, parent: []string{"a", "[]", "b", "[]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, key := completionContext(tt.text, tt.pos)
+			assert.Equal(t, tt.parent, parent)
+			assert.Equal(t, tt.key, key)
+		})
+	}
+}
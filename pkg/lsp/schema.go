@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/importer"
+)
+
+// schemaFile is a JSON Schema file, reloaded when it changes (e.g., after 'miaka build')
+type schemaFile struct {
+	path    string
+	modTime time.Time
+	data    []byte
+	schema  *importer.Schema
+}
+
+// load reads the schema file if it changed since it was last read
+func (f *schemaFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+	if f.schema != nil && info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+	s, err := importer.ParseSchema(data)
+	if err != nil {
+		return err
+	}
+	f.data, f.schema, f.modTime = data, s, info.ModTime()
+	return nil
+}
+
+// lookup returns the schema at a path, where itemSegment selects list items
+func lookup(s *importer.Schema, path []string) *importer.Schema {
+	for _, segment := range path {
+		if s == nil {
+			return nil
+		}
+		if segment == itemSegment {
+			s = s.Items
+			continue
+		}
+		s = property(s, segment)
+	}
+	return s
+}
+
+// property returns the schema of a named property, or of additional properties for maps
+func property(s *importer.Schema, name string) *importer.Schema {
+	for _, p := range s.Properties {
+		if p.Name == name {
+			return p.Schema
+		}
+	}
+	if s.AdditionalProperties != nil {
+		return s.AdditionalProperties.Schema
+	}
+	return nil
+}
+
+// typeName describes the type of a schema (e.g., "string" or "[]integer")
+func typeName(s *importer.Schema) string {
+	typ := s.Type.Primary()
+	if typ == "array" && s.Items != nil {
+		return "[]" + typeName(s.Items)
+	}
+	if typ == "object" && len(s.Properties) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return "map[string]" + typeName(s.AdditionalProperties.Schema)
+	}
+	if typ == "" {
+		return "any"
+	}
+	return typ
+}
+
+// documentation renders a schema's description, default, and allowed values as markdown
+func documentation(name string, s *importer.Schema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** `%s`", name, typeName(s))
+	if s.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", s.Description)
+	}
+	if s.Default != nil {
+		fmt.Fprintf(&b, "\n\nDefault: `%s`", formatValue(s.Default))
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, "`"+formatValue(v)+"`")
+		}
+		fmt.Fprintf(&b, "\n\nAllowed values: %s", strings.Join(values, ", "))
+	}
+	return b.String()
+}
+
+// formatValue renders a value as it would be written in YAML
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,225 @@
+// Package lsp implements a minimal language server for values files, with
+// diagnostics, hovers, and completions backed by a generated JSON Schema.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+)
+
+// Server is a language server for values files. Documents are validated
+// against a JSON Schema file, which is reloaded whenever it changes.
+type Server struct {
+	schema   schemaFile
+	docs     map[string]string
+	out      io.Writer
+	shutdown bool
+	opts     Options
+}
+
+// Options configure the server
+type Options struct {
+	// Metrics records each validation of a document, by its kind, if set
+	Metrics *metrics.Metrics
+}
+
+// NewServer creates a server that validates documents against the JSON Schema at schemaPath
+func NewServer(schemaPath string, opts Options) *Server {
+	return &Server{schema: schemaFile{path: schemaPath}, docs: make(map[string]string), opts: opts}
+}
+
+// Serve handles messages from r and writes responses to w until the client
+// sends "exit" or r is closed
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.respondError(nil, codeInvalidRequest, fmt.Sprintf("invalid request: %v", err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a request or notification. Only write failures are returned.
+func (s *Server) handle(req *request) error {
+	if s.shutdown && req.ID != nil {
+		return s.respondError(req.ID, codeInvalidRequest, "server is shutting down")
+	}
+
+	switch req.Method {
+	case "initialize":
+		var result initializeResult
+		result.Capabilities.TextDocumentSync = textDocumentSyncFull
+		result.Capabilities.HoverProvider = true
+		result.Capabilities.CompletionProvider.TriggerCharacters = []string{":", " "}
+		result.ServerInfo.Name = "miaka"
+		return s.respond(req.ID, result)
+	case "shutdown":
+		s.shutdown = true
+		return s.respond(req.ID, nil)
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publishDiagnostics(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.respondError(req.ID, codeInvalidParams, err.Error())
+		}
+		return s.respond(req.ID, s.hover(params.TextDocument.URI, params.Position))
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.respondError(req.ID, codeInvalidParams, err.Error())
+		}
+		return s.respond(req.ID, s.complete(params.TextDocument.URI, params.Position))
+	default:
+		// Notifications (e.g., "initialized") without a handler are ignored
+		if req.ID == nil {
+			return nil
+		}
+		return s.respondError(req.ID, codeMethodNotFound, fmt.Sprintf("method %s not supported", req.Method))
+	}
+}
+
+// publishDiagnostics validates a document and sends its problems to the client
+func (s *Server) publishDiagnostics(uri string) error {
+	var diagnostics []Diagnostic
+	start, result := time.Now(), metrics.ResultValid
+	if err := s.schema.load(); err != nil {
+		diagnostics = []Diagnostic{newDiagnostic(0, fmt.Sprintf("%v (run 'miaka build' to generate it)", err))}
+		result = metrics.ResultError
+	} else {
+		diagnostics = diagnose(s.docs[uri], s.schema.data)
+		if len(diagnostics) > 0 {
+			result = metrics.ResultInvalid
+		}
+	}
+	s.opts.Metrics.Observe(documentKind(s.docs[uri]), result, time.Since(start))
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+// hover documents the field under the cursor, or returns nil if there is none
+func (s *Server) hover(uri string, pos Position) *Hover {
+	text, ok := s.docs[uri]
+	if !ok || s.schema.load() != nil {
+		return nil
+	}
+	path, key := keyAt(text, pos)
+	if key == nil {
+		return nil
+	}
+	fieldSchema := lookup(s.schema.schema, path)
+	if fieldSchema == nil {
+		return nil
+	}
+
+	start := Position{Line: key.Line - 1, Character: key.Column - 1}
+	end := Position{Line: start.Line, Character: start.Character + len(key.Value)}
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: documentation(key.Value, fieldSchema)},
+		Range:    &Range{Start: start, End: end},
+	}
+}
+
+// complete suggests field names, or values when the cursor is after a key
+func (s *Server) complete(uri string, pos Position) []CompletionItem {
+	items := []CompletionItem{}
+	text, ok := s.docs[uri]
+	if !ok || s.schema.load() != nil {
+		return items
+	}
+
+	parent, key := completionContext(text, pos)
+	parentSchema := lookup(s.schema.schema, parent)
+	if parentSchema == nil {
+		return items
+	}
+
+	if key != "" {
+		fieldSchema := property(parentSchema, key)
+		if fieldSchema == nil {
+			return items
+		}
+		for _, v := range fieldSchema.Enum {
+			items = append(items, CompletionItem{Label: formatValue(v), Kind: CompletionKindEnumMember})
+		}
+		if len(fieldSchema.Enum) == 0 && fieldSchema.Type.Primary() == "boolean" {
+			items = append(items,
+				CompletionItem{Label: "true", Kind: CompletionKindValue},
+				CompletionItem{Label: "false", Kind: CompletionKindValue})
+		}
+		return items
+	}
+
+	for _, p := range parentSchema.Properties {
+		items = append(items, CompletionItem{
+			Label:         p.Name,
+			Kind:          CompletionKindField,
+			Detail:        typeName(p.Schema),
+			Documentation: &MarkupContent{Kind: "markdown", Value: documentation(p.Name, p.Schema)},
+			InsertText:    p.Name + ": ",
+		})
+	}
+	return items
+}
+
+// respond sends the result of a request
+func (s *Server) respond(id *json.RawMessage, result interface{}) error {
+	return writeMessage(s.out, map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+// respondError sends an error response to a request
+func (s *Server) respondError(id *json.RawMessage, code int, message string) error {
+	return writeMessage(s.out, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   responseError{Code: code, Message: message},
+	})
+}
+
+// notify sends a notification to the client
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
@@ -0,0 +1,202 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/importer"
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// session runs a server over a sequence of messages and returns the messages it sent
+func session(t *testing.T, schemaPath string, messages ...interface{}) []map[string]interface{} {
+	t.Helper()
+	var in bytes.Buffer
+	for _, m := range messages {
+		require.NoError(t, writeMessage(&in, m))
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, NewServer(schemaPath, Options{}).Serve(&in, &out))
+
+	var sent []map[string]interface{}
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &msg))
+		sent = append(sent, msg)
+	}
+	return sent
+}
+
+// rpc builds a request (with an id) or notification (id 0)
+func rpc(id int, method string, params interface{}) map[string]interface{} {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id != 0 {
+		msg["id"] = id
+	}
+	return msg
+}
+
+func position(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func TestServer(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "values.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0644))
+	uri := "file:///values.yaml"
+
+	sent := session(t, schemaPath,
+		rpc(1, "initialize", map[string]interface{}{}),
+		rpc(0, "initialized", map[string]interface{}{}),
+		rpc(0, "textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "yaml", "version": 1, "text": testDocument},
+		}),
+		rpc(2, "textDocument/hover", position(uri, 3, 2)),
+		rpc(3, "textDocument/completion", position(uri, 2, 14)),
+		rpc(0, "textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+			"contentChanges": []interface{}{map[string]interface{}{"text": "image:\n  tag: v1\n  \n"}},
+		}),
+		rpc(4, "textDocument/completion", position(uri, 2, 2)),
+		rpc(5, "workspace/symbol", map[string]interface{}{}),
+		rpc(6, "shutdown", nil),
+		rpc(0, "exit", nil),
+	)
+	require.Len(t, sent, 8)
+
+	capabilities := sent[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, capabilities["hoverProvider"])
+
+	assert.Equal(t, "textDocument/publishDiagnostics", sent[1]["method"])
+	assert.Len(t, sent[1]["params"].(map[string]interface{})["diagnostics"], 3)
+
+	hover := sent[2]["result"].(map[string]interface{})["contents"].(map[string]interface{})
+	assert.Equal(t, "**replicas** `integer`\n\nNumber of replicas", hover["value"])
+
+	var labels []string
+	for _, item := range sent[3]["result"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	assert.Equal(t, []string{"Always", "IfNotPresent"}, labels)
+
+	assert.Empty(t, sent[4]["params"].(map[string]interface{})["diagnostics"])
+
+	labels = nil
+	for _, item := range sent[5]["result"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	assert.Equal(t, []string{"pullPolicy", "tag"}, labels)
+
+	assert.Equal(t, float64(codeMethodNotFound), sent[6]["error"].(map[string]interface{})["code"])
+	assert.Contains(t, sent[7], "result")
+}
+
+func TestServer_MissingSchema(t *testing.T) {
+	uri := "file:///values.yaml"
+	sent := session(t, filepath.Join(t.TempDir(), "missing.json"),
+		rpc(0, "textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "text": "replicas: 1\n"},
+		}),
+		rpc(1, "textDocument/hover", position(uri, 0, 0)),
+	)
+	require.Len(t, sent, 2)
+
+	diagnostics := sent[0]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	require.Len(t, diagnostics, 1)
+	assert.Contains(t, diagnostics[0].(map[string]interface{})["message"], "miaka build")
+	assert.Nil(t, sent[1]["result"])
+}
+
+func TestServer_Metrics(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "values.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0644))
+	uri := "file:///values.yaml"
+
+	var in bytes.Buffer
+	for _, m := range []interface{}{
+		rpc(0, "textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "text": "kind: Example\n" + testDocument},
+		}),
+		rpc(0, "textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+			"contentChanges": []interface{}{map[string]interface{}{"text": "image:\n  tag: v1\n"}},
+		}),
+	} {
+		require.NoError(t, writeMessage(&in, m))
+	}
+	m := metrics.New("lsp")
+	require.NoError(t, NewServer(schemaPath, Options{Metrics: m}).Serve(&in, io.Discard))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	assert.Contains(t, rec.Body.String(), `miaka_lsp_validations_total{kind="Example",result="invalid"} 1`)
+	assert.Contains(t, rec.Body.String(), `miaka_lsp_validations_total{kind="",result="valid"} 1`)
+}
+
+func TestSchemaReload(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "values.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"properties": {"a": {"type": "string"}}}`), 0644))
+
+	f := schemaFile{path: schemaPath}
+	require.NoError(t, f.load())
+	assert.NotNil(t, lookup(f.schema, []string{"a"}))
+
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"properties": {"b": {"type": "string"}}}`), 0644))
+	stat, err := os.Stat(schemaPath)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(schemaPath, stat.ModTime(), stat.ModTime().Add(1e9)))
+	require.NoError(t, f.load())
+	assert.Nil(t, lookup(f.schema, []string{"a"}))
+	assert.NotNil(t, lookup(f.schema, []string{"b"}))
+}
+
+func TestReadMessage(t *testing.T) {
+	body := `{"jsonrpc":"2.0"}`
+	reader := bufio.NewReader(strings.NewReader(fmt.Sprintf("Content-Length: %d\r\nContent-Type: application/json\r\n\r\n%s", len(body), body)))
+	data, err := readMessage(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	_, err = readMessage(bufio.NewReader(strings.NewReader("Content-Type: x\r\n\r\n")))
+	assert.Error(t, err)
+}
+
+func TestDocumentation(t *testing.T) {
+	s := lookup(mustParse(t, testSchema), []string{"image", "tag"})
+	assert.Equal(t, "**tag** `string`\n\nImage tag\n\nDefault: `latest`", documentation("tag", s))
+
+	s = lookup(mustParse(t, testSchema), []string{"labels"})
+	assert.Equal(t, "map[string]string", typeName(s))
+	s = lookup(mustParse(t, testSchema), []string{"labels", "team"})
+	assert.Equal(t, "string", typeName(s))
+	s = lookup(mustParse(t, testSchema), []string{"env"})
+	assert.Equal(t, "[]object", typeName(s))
+}
+
+// mustParse parses a JSON Schema
+func mustParse(t *testing.T, schema string) *importer.Schema {
+	t.Helper()
+	s, err := importer.ParseSchema([]byte(schema))
+	require.NoError(t, err)
+	return s
+}
@@ -0,0 +1,192 @@
+package markers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromGoTypes returns the markers in the doc comments of the fields of Go types
+// (e.g., types generated by 'miaka build' and edited since), by the path of the
+// field in the values, starting at the struct named root. Fields of types from
+// other packages and values of maps aren't followed.
+func FromGoTypes(src []byte, root string) (map[string][]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "types.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Go types: %w", err)
+	}
+	structs := map[string]*ast.StructType{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					structs[typeSpec.Name.Name] = structType
+				}
+			}
+		}
+	}
+	if structs[root] == nil {
+		return nil, fmt.Errorf("type %s not found", root)
+	}
+
+	markers := map[string][]string{}
+	collectGoMarkers(structs, root, "", map[string]bool{}, markers)
+	return markers, nil
+}
+
+// collectGoMarkers records the markers of the fields of a struct, and of the
+// structs they hold, under prefix. visiting guards against recursive types.
+func collectGoMarkers(structs map[string]*ast.StructType, name, prefix string, visiting map[string]bool, markers map[string][]string) {
+	if visiting[name] {
+		return
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	for _, field := range structs[name].Fields.List {
+		key := jsonName(field)
+		if key == "" {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if field.Doc != nil {
+			for _, comment := range field.Doc.List {
+				if marker := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")); strings.HasPrefix(marker, "+") {
+					markers[path] = append(markers[path], marker)
+				}
+			}
+		}
+
+		typeName, suffix := elemTypeName(field.Type)
+		if _, ok := structs[typeName]; ok {
+			collectGoMarkers(structs, typeName, path+suffix, visiting, markers)
+		}
+	}
+}
+
+// jsonName returns the JSON name of a field, or "" if it has none (e.g., an
+// embedded metav1.TypeMeta)
+func jsonName(field *ast.Field) string {
+	if field.Tag == nil || len(field.Names) == 0 {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// elemTypeName returns the name of the local type a field holds, through
+// pointers and slices, and the path suffix of the slices ("[]" per slice)
+func elemTypeName(expr ast.Expr) (string, string) {
+	suffix := ""
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ArrayType:
+			expr = t.Elt
+			suffix += "[]"
+		case *ast.Ident:
+			return t.Name, suffix
+		default:
+			return "", ""
+		}
+	}
+}
+
+// Change is a marker a write-back added to or removed from a field
+type Change struct {
+	Path    string
+	Marker  string
+	Removed bool
+}
+
+// WriteBack writes the markers of edited Go types back to the fields of example
+// values, as collected by FromGoTypes: markers of edited that the types
+// generated from the values (generated) don't have are set, replacing markers of
+// the same name in the values if edited has only one of that name, or else
+// added. Markers of generated that edited doesn't have any of by name are
+// removed from the values if the values have them, as opposed to markers that
+// miaka markers (e.g., +miaka:format) generate.
+func WriteBack(data []byte, generated, edited map[string][]string) ([]byte, []Change, error) {
+	paths := make([]string, 0, len(edited)+len(generated))
+	for path := range edited {
+		paths = append(paths, path)
+	}
+	for path := range generated {
+		if _, ok := edited[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []Change
+	for _, path := range paths {
+		counts := map[string]int{}
+		for _, marker := range edited[path] {
+			counts[Name(marker)]++
+		}
+		var existing []string
+		if len(edited[path]) > 0 || len(generated[path]) > 0 {
+			var err error
+			if existing, err = List(data, path); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		for _, marker := range generated[path] {
+			if counts[Name(marker)] > 0 || !contains(existing, marker) {
+				continue
+			}
+			var err error
+			if data, err = Remove(data, path, marker); err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, Change{Path: path, Marker: marker, Removed: true})
+		}
+
+		for _, marker := range edited[path] {
+			if contains(generated[path], marker) || contains(existing, marker) {
+				continue
+			}
+			write := Add
+			if counts[Name(marker)] == 1 {
+				write = Set
+			}
+			var err error
+			if data, err = write(data, path, marker); err != nil {
+				return nil, nil, err
+			}
+			changes = append(changes, Change{Path: path, Marker: marker})
+		}
+	}
+	return data, changes, nil
+}
+
+// contains reports whether markers has marker
+func contains(markers []string, marker string) bool {
+	for _, m := range markers {
+		if m == marker {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package markers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goTypes = `package v1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +kubebuilder:object:root=true
+type Example struct {
+	metav1.TypeMeta   ` + "`json:\",inline\"`" + `
+	metav1.ObjectMeta ` + "`json:\"metadata,omitempty\"`" + `
+
+	// Number of replicas
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	Replicas int ` + "`json:\"replicas,omitempty\"`" + `
+
+	Image *ImageConfig ` + "`json:\"image,omitempty\"`" + `
+
+	Env []EnvConfig ` + "`json:\"env,omitempty\"`" + `
+
+	Labels map[string]EnvConfig ` + "`json:\"labels,omitempty\"`" + `
+}
+
+type ImageConfig struct {
+	// +kubebuilder:validation:MinLength=1
+	Tag string ` + "`json:\"tag,omitempty\"`" + `
+}
+
+type EnvConfig struct {
+	// +kubebuilder:validation:Pattern=^[A-Z_]+$
+	Name string ` + "`json:\"name,omitempty\"`" + `
+}
+`
+
+func TestFromGoTypes(t *testing.T) {
+	markers, err := FromGoTypes([]byte(goTypes), "Example")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"replicas":   {"+kubebuilder:validation:Minimum=1", "+kubebuilder:validation:Maximum=10"},
+		"image.tag":  {"+kubebuilder:validation:MinLength=1"},
+		"env[].name": {"+kubebuilder:validation:Pattern=^[A-Z_]+$"},
+	}, markers)
+
+	_, err = FromGoTypes([]byte(goTypes), "Missing")
+	assert.ErrorContains(t, err, "type Missing not found")
+	_, err = FromGoTypes([]byte("package v1\ntype"), "Example")
+	assert.ErrorContains(t, err, "failed to parse Go types")
+}
+
+func TestWriteBack(t *testing.T) {
+	generated := map[string][]string{
+		"replicas":        {"+kubebuilder:validation:Minimum=1"},
+		"image.tag":       {"+kubebuilder:validation:MaxLength=10"},
+		"sidecars[].name": {"+kubebuilder:validation:MinLength=1"},
+	}
+	edited := map[string][]string{
+		"replicas":   {"+kubebuilder:validation:Minimum=2", "+kubebuilder:validation:Maximum=10"},
+		"env[].name": {"+kubebuilder:validation:MinLength=1"},
+	}
+
+	output, changes, err := WriteBack([]byte(input), generated, edited)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+# +kubebuilder:validation:Minimum=2
+# +kubebuilder:validation:Maximum=10
+replicas: 1 # inline comment
+image:
+  # Image tag
+  tag: latest
+env:
+- # +kubebuilder:validation:MinLength=1
+  name: A
+  value: a
+sidecars:
+- # Sidecar name
+  name: proxy
+`, string(output))
+	assert.Equal(t, []Change{
+		{Path: "env[].name", Marker: "+kubebuilder:validation:MinLength=1"},
+		{Path: "replicas", Marker: "+kubebuilder:validation:Minimum=2"},
+		{Path: "replicas", Marker: "+kubebuilder:validation:Maximum=10"},
+		{Path: "sidecars[].name", Marker: "+kubebuilder:validation:MinLength=1", Removed: true},
+	}, changes)
+
+	_, _, err = WriteBack([]byte(input), nil, map[string][]string{"missing": {"+kubebuilder:validation:MinLength=1"}})
+	assert.Error(t, err)
+}
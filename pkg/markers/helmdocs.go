@@ -0,0 +1,87 @@
+package markers
+
+import (
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertHelmDocs rewrites the comments of a values file from helm-docs syntax
+// to miaka's, without changing other lines, and returns the number of comment
+// lines changed. The "-- " that starts a description is dropped, and a
+// documented default is converted to a +kubebuilder:default marker if it's a
+// literal (e.g., "@default -- `8080`"), or else described (e.g., "Default:
+// `.Release.Namespace`"). Annotations that only lay out helm-docs output (e.g.,
+// "@section -- Ingress") are removed.
+func ConvertHelmDocs(data []byte) ([]byte, int) {
+	lines := strings.Split(string(data), "\n")
+	converted := make([]string, 0, len(lines))
+	changed := 0
+	for _, line := range lines {
+		// Keep the Windows line endings of the file
+		trimmed, cr := strings.CutSuffix(line, "\r")
+		lineEnd := ""
+		if cr {
+			lineEnd = "\r"
+		}
+		prefix, text, ok := splitComment(trimmed)
+		if !ok {
+			converted = append(converted, line)
+			continue
+		}
+
+		comments, documentedDefault := parsing.HelmDocsComments([]string{text})
+		switch {
+		case documentedDefault != "":
+			converted = append(converted, prefix+defaultComment(documentedDefault)+lineEnd)
+		case len(comments) == 0:
+			// A layout annotation, or an empty description
+		case comments[0] != text:
+			converted = append(converted, prefix+comments[0]+lineEnd)
+		default:
+			converted = append(converted, line)
+			continue
+		}
+		changed++
+	}
+	return []byte(strings.Join(converted, "\n")), changed
+}
+
+// splitComment splits a comment line into its comment syntax (e.g., "  # ")
+// and its text
+func splitComment(line string) (prefix, text string, ok bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	text = strings.TrimLeft(strings.TrimLeft(trimmed, "#"), " ")
+	return line[:len(line)-len(text)], text, true
+}
+
+// defaultComment converts a documented default to a +kubebuilder:default marker
+// if it's a literal number, boolean, or double-quoted string in backticks, or
+// else to a description of the default
+func defaultComment(documentedDefault string) string {
+	if literal, ok := backticked(documentedDefault); ok {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(literal), &node); err == nil && len(node.Content) == 1 {
+			value := node.Content[0]
+			switch {
+			case value.Kind != yaml.ScalarNode:
+			case value.ShortTag() == "!!int", value.ShortTag() == "!!float", value.ShortTag() == "!!bool",
+				value.ShortTag() == "!!str" && value.Style == yaml.DoubleQuotedStyle:
+				return "+kubebuilder:default=" + literal
+			}
+		}
+	}
+	return parsing.DocumentedDefaultPrefix + documentedDefault
+}
+
+// backticked returns the text in backticks, if s is only that (e.g., "`8080`")
+func backticked(s string) (string, bool) {
+	if len(s) < 3 || s[0] != '`' || s[len(s)-1] != '`' || strings.Contains(s[1:len(s)-1], "`") {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
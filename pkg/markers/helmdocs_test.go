@@ -0,0 +1,70 @@
+package markers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertHelmDocs(t *testing.T) {
+	data := "apiVersion: example.com/v1\n" +
+		"kind: Example\n" +
+		"# @section -- Service\n" +
+		"service:\n" +
+		"  # -- Port of the service\n" +
+		"  # @default -- `8080`\n" +
+		"  port: 8080\n" +
+		"  # -- Pull policy\n" +
+		"  # @default -- `\"IfNotPresent\"`\n" +
+		"  pullPolicy: IfNotPresent\n" +
+		"# -- Override the namespace\n" +
+		"# @default -- `.Release.Namespace`\n" +
+		"namespaceOverride: \"\" # not a -- description\n" +
+		"## Ref: https://example.com\n" +
+		"# +kubebuilder:validation:MinLength=1\n" +
+		"name: app\n"
+
+	output, changed := ConvertHelmDocs([]byte(data))
+	assert.Equal(t, "apiVersion: example.com/v1\n"+
+		"kind: Example\n"+
+		"service:\n"+
+		"  # Port of the service\n"+
+		"  # +kubebuilder:default=8080\n"+
+		"  port: 8080\n"+
+		"  # Pull policy\n"+
+		"  # +kubebuilder:default=\"IfNotPresent\"\n"+
+		"  pullPolicy: IfNotPresent\n"+
+		"# Override the namespace\n"+
+		"# Default: `.Release.Namespace`\n"+
+		"namespaceOverride: \"\" # not a -- description\n"+
+		"## Ref: https://example.com\n"+
+		"# +kubebuilder:validation:MinLength=1\n"+
+		"name: app\n", string(output))
+	assert.Equal(t, 7, changed)
+
+	// Files with Windows line endings keep them
+	crlf, changed := ConvertHelmDocs([]byte(toCRLF(data)))
+	assert.Equal(t, toCRLF(string(output)), string(crlf))
+	assert.Equal(t, 7, changed)
+}
+
+func TestConvertHelmDocs_NoHelmDocs(t *testing.T) {
+	output, changed := ConvertHelmDocs([]byte(input))
+	assert.Equal(t, input, string(output))
+	assert.Zero(t, changed)
+}
+
+func TestDefaultComment(t *testing.T) {
+	tests := map[string]string{
+		"`true`":                                "+kubebuilder:default=true",
+		"`1.5`":                                 "+kubebuilder:default=1.5",
+		"`\"\"`":                                "+kubebuilder:default=\"\"",
+		"`nginx`":                               "Default: `nginx`",
+		"`[]`":                                  "Default: `[]`",
+		"See [values.yaml]":                     "Default: See [values.yaml]",
+		"`\"\"` (defaults to global.image.tag)": "Default: `\"\"` (defaults to global.image.tag)",
+	}
+	for documentedDefault, want := range tests {
+		assert.Equal(t, want, defaultComment(documentedDefault), documentedDefault)
+	}
+}
@@ -0,0 +1,305 @@
+// Package markers adds, replaces, and removes comment markers on fields of an
+// example values file, without changing any other comments or formatting.
+//
+// Fields are selected by dotted path, with "[]" selecting the items of a list
+// (e.g., "image.tag" or "env[].name"). For lists of objects, markers go on the
+// first item that has the key, since it defines the type.
+package markers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// field is the location of a field's key and the marker comments above it
+type field struct {
+	lines []string
+	key   int // Index of the key's line
+	col   int // Column (0-based) of the key
+
+	// inline is true when the key doesn't start its line (e.g., "- name: x")
+	inline bool
+
+	// comments are the indexes of the comment lines directly above the key
+	comments []int
+}
+
+// Name returns the name of a marker, without its value
+// (e.g., "+kubebuilder:validation:Minimum" for "+kubebuilder:validation:Minimum=1")
+func Name(marker string) string {
+	marker = normalize(marker)
+	end := len(marker)
+	for _, sep := range []string{"=", ": "} {
+		if i := strings.Index(marker, sep); i >= 0 && i < end {
+			end = i
+		}
+	}
+	if end < len(marker) {
+		name := marker[:end]
+		// Markers with named arguments (e.g., "+kubebuilder:validation:XValidation:rule=...")
+		// end in a lowercase argument after an uppercase marker name
+		segments := strings.Split(name, ":")
+		if n := len(segments); n > 2 && isUpper(segments[n-2]) && !isUpper(segments[n-1]) {
+			return strings.Join(segments[:n-1], ":")
+		}
+		return name
+	}
+
+	// miaka markers also allow "+miaka:type:string" without a space
+	if parts := strings.SplitN(marker, ":", 3); len(parts) == 3 && parts[0] == "+miaka" {
+		return parts[0] + ":" + parts[1]
+	}
+	return marker
+}
+
+// List returns the markers on a field, in order
+func List(data []byte, path string) ([]string, error) {
+	f, err := locate(data, path)
+	if err != nil {
+		return nil, err
+	}
+	var markers []string
+	for _, i := range f.comments {
+		if marker, ok := commentMarker(f.lines[i]); ok {
+			markers = append(markers, marker)
+		}
+	}
+	return markers, nil
+}
+
+// Add adds a marker directly above a field. Nothing changes if the field already has the marker.
+func Add(data []byte, path, marker string) ([]byte, error) {
+	marker, err := validate(marker)
+	if err != nil {
+		return nil, err
+	}
+	f, err := locate(data, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range f.comments {
+		if existing, ok := commentMarker(f.lines[i]); ok && existing == marker {
+			return data, nil
+		}
+	}
+	return f.insert(marker), nil
+}
+
+// Set replaces the markers with the same name as marker, keeping the position of
+// the first one, or adds the marker if the field has none
+func Set(data []byte, path, marker string) ([]byte, error) {
+	marker, err := validate(marker)
+	if err != nil {
+		return nil, err
+	}
+	f, err := locate(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := f.matching(Name(marker))
+	if len(matches) == 0 {
+		return f.insert(marker), nil
+	}
+	first := f.lines[matches[0]]
+	f.lines[matches[0]] = first[:strings.Index(first, "#")] + "# " + marker + f.lineEnd(matches[0])
+	return []byte(strings.Join(f.remove(matches[1:]), "\n")), nil
+}
+
+// Remove removes every marker with the given name from a field
+func Remove(data []byte, path, name string) ([]byte, error) {
+	name, err := validate(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := locate(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := f.matching(Name(name))
+	if len(matches) == 0 {
+		return data, nil
+	}
+	return []byte(strings.Join(f.remove(matches), "\n")), nil
+}
+
+// matching returns the indexes of the marker lines with the given name
+func (f *field) matching(name string) []int {
+	var matches []int
+	for _, i := range f.comments {
+		if marker, ok := commentMarker(f.lines[i]); ok && Name(marker) == name {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// insert returns the file with a marker comment added directly above the key
+func (f *field) insert(marker string) []byte {
+	lines := f.lines
+	indent := strings.Repeat(" ", f.col)
+	comment := "# " + marker + f.lineEnd(f.key)
+	if f.inline {
+		// "- name: x" becomes "- # marker" followed by "  name: x"
+		line := lines[f.key]
+		lines[f.key] = line[:f.col] + comment
+		lines = insertLine(lines, f.key+1, indent+line[f.col:])
+	} else {
+		lines = insertLine(lines, f.key, indent+comment)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// lineEnd returns the "\r" that ends line i of a file with Windows line
+// endings, so lines written in its place keep them
+func (f *field) lineEnd(i int) string {
+	if strings.HasSuffix(f.lines[i], "\r") {
+		return "\r"
+	}
+	return ""
+}
+
+// remove returns the lines with the given comment lines removed. A removed
+// "- # comment" line hands its dash to the line below it.
+func (f *field) remove(indexes []int) []string {
+	lines := f.lines
+	for n := len(indexes) - 1; n >= 0; n-- {
+		i := indexes[n]
+		line := lines[i]
+		if dash := strings.Index(line, "- #"); dash >= 0 && strings.TrimSpace(line[:dash]) == "" && i+1 < len(lines) {
+			lines[i+1] = line[:dash+2] + strings.TrimLeft(lines[i+1], " ")
+		}
+		lines = append(lines[:i], lines[i+1:]...)
+	}
+	return lines
+}
+
+// locate finds the key of a field and the comment lines directly above it
+func locate(data []byte, path string) (*field, error) {
+	if path == "" {
+		return nil, fmt.Errorf("field path is required")
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("root node must be a mapping")
+	}
+
+	key, err := findKey(doc.Content[0], strings.Split(path, "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	f := &field{lines: strings.Split(string(data), "\n"), key: key.Line - 1, col: key.Column - 1}
+	if f.key >= len(f.lines) || f.col > len(f.lines[f.key]) {
+		return nil, fmt.Errorf("%s: key position is out of range", path)
+	}
+	f.inline = strings.TrimSpace(f.lines[f.key][:f.col]) != ""
+	if f.inline {
+		return f, nil
+	}
+
+	// Comment lines at the key's indentation, up to and including a "- # comment" line
+	for i := f.key - 1; i >= 0; i-- {
+		line := f.lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if indent == f.col && strings.HasPrefix(trimmed, "#") {
+			f.comments = append([]int{i}, f.comments...)
+			continue
+		}
+		if indent == f.col-2 && strings.HasPrefix(trimmed, "- #") {
+			f.comments = append([]int{i}, f.comments...)
+		}
+		break
+	}
+	return f, nil
+}
+
+// findKey walks a mapping along a path and returns the key node of the last segment
+func findKey(node *yaml.Node, segments []string) (*yaml.Node, error) {
+	segment := segments[0]
+	name, isList := strings.CutSuffix(segment, "[]")
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != name {
+			continue
+		}
+		if len(segments) == 1 {
+			if isList {
+				return nil, fmt.Errorf("path must end with a field name")
+			}
+			return key, nil
+		}
+
+		rest := segments[1:]
+		if !isList {
+			if value.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s is not an object", name)
+			}
+			return findKey(value, rest)
+		}
+
+		if value.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s is not a list", name)
+		}
+		var lastErr error = fmt.Errorf("no item of %s has field %s", name, rest[0])
+		for _, item := range value.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			found, err := findKey(item, rest)
+			if err == nil {
+				return found, nil
+			}
+			if len(rest) > 1 {
+				lastErr = err
+			}
+		}
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("field %s not found", name)
+}
+
+// commentMarker returns the marker in a comment line, if it has one
+func commentMarker(line string) (string, bool) {
+	text := strings.TrimSpace(line)
+	text = strings.TrimPrefix(text, "- ")
+	text = strings.TrimSpace(strings.TrimPrefix(text, "#"))
+	return text, strings.HasPrefix(text, "+")
+}
+
+// validate normalizes a marker and checks that it is one
+func validate(marker string) (string, error) {
+	marker = normalize(marker)
+	if !strings.HasPrefix(marker, "+") || len(marker) == 1 || strings.Contains(marker, "\n") {
+		return "", fmt.Errorf("invalid marker %q (expected e.g. +kubebuilder:validation:Minimum=1)", marker)
+	}
+	return marker, nil
+}
+
+// normalize strips comment syntax and surrounding space from a marker
+func normalize(marker string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(marker), "#"))
+}
+
+// isUpper reports whether s starts with an uppercase letter
+func isUpper(s string) bool {
+	return s != "" && unicode.IsUpper(rune(s[0]))
+}
+
+// insertLine inserts a line before index i
+func insertLine(lines []string, i int, line string) []string {
+	lines = append(lines, "")
+	copy(lines[i+1:], lines[i:])
+	lines[i] = line
+	return lines
+}
@@ -0,0 +1,212 @@
+package markers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const input = `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 1 # inline comment
+image:
+  # Image tag
+  tag: latest
+env:
+- name: A
+  value: a
+sidecars:
+- # Sidecar name
+  # +kubebuilder:validation:MinLength=1
+  name: proxy
+`
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"+kubebuilder:validation:Minimum=1":                 "+kubebuilder:validation:Minimum",
+		"+kubebuilder:validation:XValidation:rule=\"a: b\"": "+kubebuilder:validation:XValidation",
+		"+miaka:type: map[string]string":                    "+miaka:type",
+		"+miaka:type:string":                                "+miaka:type",
+		"+nullable":                                         "+nullable",
+		"# +kubebuilder:validation:Optional":                "+kubebuilder:validation:Optional",
+		"+miaka:items:kubebuilder:validation:MinLength=1":   "+miaka:items:kubebuilder:validation:MinLength",
+	}
+	for marker, expected := range tests {
+		assert.Equal(t, expected, Name(marker), marker)
+	}
+}
+
+func TestList(t *testing.T) {
+	markers, err := List([]byte(input), "replicas")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+kubebuilder:validation:Minimum=1"}, markers)
+
+	markers, err = List([]byte(input), "image.tag")
+	require.NoError(t, err)
+	assert.Empty(t, markers)
+
+	markers, err = List([]byte(input), "sidecars[].name")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+kubebuilder:validation:MinLength=1"}, markers)
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		marker   string
+		expected string
+	}{
+		{
+			name:   "after description",
+			path:   "image.tag",
+			marker: "+kubebuilder:validation:MinLength=1",
+			expected: `  # Image tag
+  # +kubebuilder:validation:MinLength=1
+  tag: latest`,
+		},
+		{
+			name:   "after existing markers",
+			path:   "replicas",
+			marker: "# +kubebuilder:validation:Maximum=10",
+			expected: `# +kubebuilder:validation:Minimum=1
+# +kubebuilder:validation:Maximum=10
+replicas: 1 # inline comment`,
+		},
+		{
+			name:   "first key of list item",
+			path:   "env[].name",
+			marker: "+kubebuilder:validation:Pattern=`^[A-Z_]+$`",
+			expected: `env:
+- # +kubebuilder:validation:Pattern=` + "`^[A-Z_]+$`" + `
+  name: A
+  value: a`,
+		},
+		{
+			name:   "second key of list item",
+			path:   "env[].value",
+			marker: "+nullable",
+			expected: `- name: A
+  # +nullable
+  value: a`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Add([]byte(input), tt.path, tt.marker)
+			require.NoError(t, err)
+			assert.Contains(t, string(result), tt.expected)
+
+			markers, err := List(result, tt.path)
+			require.NoError(t, err)
+			assert.Contains(t, markers, normalize(tt.marker))
+
+			// Removing the marker restores the original file
+			restored, err := Remove(result, tt.path, Name(tt.marker))
+			require.NoError(t, err)
+			assert.Equal(t, input, string(restored))
+
+			// Files with Windows line endings keep them
+			crlf, err := Add([]byte(toCRLF(input)), tt.path, tt.marker)
+			require.NoError(t, err)
+			assert.Equal(t, toCRLF(string(result)), string(crlf))
+		})
+	}
+}
+
+func TestAdd_AlreadyPresent(t *testing.T) {
+	result, err := Add([]byte(input), "replicas", "+kubebuilder:validation:Minimum=1")
+	require.NoError(t, err)
+	assert.Equal(t, input, string(result))
+}
+
+func TestSet(t *testing.T) {
+	data := `# Port
+# +kubebuilder:validation:Minimum=1
+# +kubebuilder:validation:Maximum=65535
+# +kubebuilder:validation:Minimum=0
+port: 80
+`
+	result, err := Set([]byte(data), "port", "+kubebuilder:validation:Minimum=1024")
+	require.NoError(t, err)
+	assert.Equal(t, `# Port
+# +kubebuilder:validation:Minimum=1024
+# +kubebuilder:validation:Maximum=65535
+port: 80
+`, string(result))
+
+	result, err = Set(result, "port", "+kubebuilder:default=8080")
+	require.NoError(t, err)
+	assert.Equal(t, `# Port
+# +kubebuilder:validation:Minimum=1024
+# +kubebuilder:validation:Maximum=65535
+# +kubebuilder:default=8080
+port: 80
+`, string(result))
+
+	crlf, err := Set([]byte(toCRLF(data)), "port", "+kubebuilder:validation:Minimum=1024")
+	require.NoError(t, err)
+	assert.Equal(t, toCRLF(`# Port
+# +kubebuilder:validation:Minimum=1024
+# +kubebuilder:validation:Maximum=65535
+port: 80
+`), string(crlf))
+}
+
+func TestRemove(t *testing.T) {
+	result, err := Remove([]byte(input), "sidecars[].name", "+kubebuilder:validation:MinLength")
+	require.NoError(t, err)
+	assert.Contains(t, string(result), `sidecars:
+- # Sidecar name
+  name: proxy`)
+
+	// Removing the only comment of a list item's first key moves the key up to the dash
+	result, err = Remove([]byte(`items:
+- # +nullable
+  name: a
+`), "items[].name", "+nullable")
+	require.NoError(t, err)
+	assert.Equal(t, `items:
+- name: a
+`, string(result))
+
+	// Description comments are never removed
+	result, err = Remove([]byte(input), "image.tag", "+kubebuilder:validation:MinLength")
+	require.NoError(t, err)
+	assert.Equal(t, input, string(result))
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		marker string
+		err    string
+	}{
+		{name: "empty path", path: "", marker: "+nullable", err: "field path is required"},
+		{name: "missing field", path: "image.repository", marker: "+nullable", err: "field repository not found"},
+		{name: "not an object", path: "replicas.count", marker: "+nullable", err: "replicas is not an object"},
+		{name: "not a list", path: "image[].tag", marker: "+nullable", err: "image is not a list"},
+		{name: "missing item field", path: "env[].valueFrom", marker: "+nullable", err: "no item of env has field valueFrom"},
+		{name: "path ends with list", path: "env[]", marker: "+nullable", err: "path must end with a field name"},
+		{name: "not a marker", path: "replicas", marker: "Number of replicas", err: "invalid marker"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Add([]byte(input), tt.path, tt.marker)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+// toCRLF converts a file to Windows line endings
+func toCRLF(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
@@ -0,0 +1,439 @@
+// Package merge provides schema-aware three-way merging of values files.
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Conflict describes a value that was changed differently on both sides
+type Conflict struct {
+	Path   string      // Dotted path to the conflicting value (e.g., "service.port")
+	Base   interface{} // Value in the common ancestor (nil if absent)
+	Ours   interface{} // Value on our side (nil if deleted)
+	Theirs interface{} // Value on their side (nil if deleted)
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: base=%s ours=%s theirs=%s", c.Path, formatValue(c.Base), formatValue(c.Ours), formatValue(c.Theirs))
+}
+
+// ConflictError is returned when a merge produced conflicts
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d merge conflict(s):\n", len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		sb.WriteString("  - " + c.String() + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Merger performs three-way merges guided by an optional structural schema
+type Merger struct {
+	schema    *apiextensionsv1.JSONSchemaProps
+	conflicts []Conflict
+}
+
+// NewMerger creates a merger. The schema may be nil, in which case lists are treated as atomic.
+func NewMerger(schema *apiextensionsv1.JSONSchemaProps) *Merger {
+	return &Merger{schema: schema}
+}
+
+// MergeFiles merges three values files and returns the merged YAML.
+// Comments and ordering from "ours" are preserved. When conflicts are found,
+// the merged output (keeping our side for conflicting values) is returned
+// together with a *ConflictError.
+func (m *Merger) MergeFiles(basePath, oursPath, theirsPath string) ([]byte, error) {
+	base, err := readDocument(basePath)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := readDocument(oursPath)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := readDocument(theirsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Merge(base, ours, theirs)
+}
+
+// Merge merges three parsed YAML documents
+func (m *Merger) Merge(base, ours, theirs *yaml.Node) ([]byte, error) {
+	m.conflicts = nil
+
+	merged := m.mergeNode(contentOf(base), contentOf(ours), contentOf(theirs), nil, m.schema)
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	if ours != nil {
+		doc.HeadComment = ours.HeadComment
+		doc.FootComment = ours.FootComment
+	}
+	if merged != nil {
+		doc.Content = []*yaml.Node{merged}
+	}
+
+	output, err := encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.conflicts) > 0 {
+		return output, &ConflictError{Conflicts: m.conflicts}
+	}
+	return output, nil
+}
+
+// mergeNode merges a single node. Any of base, ours, theirs may be nil (absent).
+func (m *Merger) mergeNode(base, ours, theirs *yaml.Node, path []string, props *apiextensionsv1.JSONSchemaProps) *yaml.Node {
+	switch {
+	case nodesEqual(ours, theirs):
+		return ours
+	case nodesEqual(base, ours):
+		return theirs
+	case nodesEqual(base, theirs):
+		return ours
+	}
+
+	// Both sides changed the value differently
+	if ours != nil && theirs != nil && ours.Kind == theirs.Kind {
+		switch ours.Kind {
+		case yaml.MappingNode:
+			if props == nil || props.XMapType == nil || *props.XMapType != "atomic" {
+				return m.mergeMapping(base, ours, theirs, path, props)
+			}
+		case yaml.SequenceNode:
+			if merged, ok := m.mergeSequence(base, ours, theirs, path, props); ok {
+				return merged
+			}
+		}
+	}
+
+	m.conflicts = append(m.conflicts, Conflict{
+		Path:   formatPath(path),
+		Base:   decode(base),
+		Ours:   decode(ours),
+		Theirs: decode(theirs),
+	})
+	return ours
+}
+
+// mergeMapping merges two mappings key by key, keeping our key order
+func (m *Merger) mergeMapping(base, ours, theirs *yaml.Node, path []string, props *apiextensionsv1.JSONSchemaProps) *yaml.Node {
+	if base != nil && base.Kind != yaml.MappingNode {
+		base = nil
+	}
+
+	result := &yaml.Node{
+		Kind:        yaml.MappingNode,
+		Tag:         ours.Tag,
+		Style:       ours.Style,
+		HeadComment: ours.HeadComment,
+		LineComment: ours.LineComment,
+		FootComment: ours.FootComment,
+	}
+
+	// Keys in our order, then keys only present on their side
+	keys := mappingKeys(ours)
+	for _, key := range mappingKeys(theirs) {
+		if _, ok := mappingValue(ours, key); !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		baseValue, _ := mappingValue(base, key)
+		oursValue, _ := mappingValue(ours, key)
+		theirsValue, _ := mappingValue(theirs, key)
+
+		merged := m.mergeNode(baseValue, oursValue, theirsValue, appendPath(path, key), childSchema(props, key))
+		if merged == nil {
+			continue
+		}
+
+		keyNode := mappingKey(ours, key)
+		if keyNode == nil {
+			keyNode = mappingKey(theirs, key)
+		}
+		result.Content = append(result.Content, keyNode, merged)
+	}
+
+	return result
+}
+
+// mergeSequence merges lists according to x-kubernetes-list-type.
+// Returns false when the list is atomic and cannot be merged.
+func (m *Merger) mergeSequence(base, ours, theirs *yaml.Node, path []string, props *apiextensionsv1.JSONSchemaProps) (*yaml.Node, bool) {
+	if props == nil || props.XListType == nil {
+		return nil, false
+	}
+	if base != nil && base.Kind != yaml.SequenceNode {
+		base = nil
+	}
+
+	var itemSchema *apiextensionsv1.JSONSchemaProps
+	if props.Items != nil {
+		itemSchema = props.Items.Schema
+	}
+
+	switch *props.XListType {
+	case "set":
+		return m.mergeSet(base, ours, theirs), true
+	case "map":
+		if len(props.XListMapKeys) == 0 {
+			return nil, false
+		}
+		return m.mergeListMap(base, ours, theirs, path, props.XListMapKeys, itemSchema), true
+	}
+
+	return nil, false
+}
+
+// mergeSet merges lists with set semantics: additions from both sides are kept,
+// removals from either side are applied
+func (m *Merger) mergeSet(base, ours, theirs *yaml.Node) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.SequenceNode, Tag: ours.Tag, Style: ours.Style}
+
+	removedByTheirs := func(item *yaml.Node) bool {
+		return containsNode(base, item) && !containsNode(theirs, item)
+	}
+
+	for _, item := range ours.Content {
+		if !removedByTheirs(item) {
+			result.Content = append(result.Content, item)
+		}
+	}
+	for _, item := range theirs.Content {
+		if !containsNode(ours, item) && !containsNode(base, item) {
+			result.Content = append(result.Content, item)
+		}
+	}
+
+	return result
+}
+
+// mergeListMap merges lists of objects keyed by the given list-map keys
+func (m *Merger) mergeListMap(base, ours, theirs *yaml.Node, path []string, mapKeys []string, itemSchema *apiextensionsv1.JSONSchemaProps) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.SequenceNode, Tag: ours.Tag, Style: ours.Style}
+
+	seen := make(map[string]bool)
+	var order []string
+	for _, seq := range []*yaml.Node{ours, theirs} {
+		for _, item := range seq.Content {
+			id := itemKey(item, mapKeys)
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+	}
+
+	for _, id := range order {
+		merged := m.mergeNode(
+			findItem(base, id, mapKeys),
+			findItem(ours, id, mapKeys),
+			findItem(theirs, id, mapKeys),
+			appendPath(path, "["+id+"]"),
+			itemSchema,
+		)
+		if merged != nil {
+			result.Content = append(result.Content, merged)
+		}
+	}
+
+	return result
+}
+
+// childSchema returns the schema for a property of an object schema
+func childSchema(props *apiextensionsv1.JSONSchemaProps, key string) *apiextensionsv1.JSONSchemaProps {
+	if props == nil {
+		return nil
+	}
+	if child, ok := props.Properties[key]; ok {
+		return &child
+	}
+	if props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil {
+		return props.AdditionalProperties.Schema
+	}
+	return nil
+}
+
+// itemKey builds an identity string for a list-map item from its key fields
+func itemKey(item *yaml.Node, mapKeys []string) string {
+	parts := make([]string, 0, len(mapKeys))
+	for _, key := range mapKeys {
+		value, _ := mappingValue(item, key)
+		if value == nil {
+			parts = append(parts, key+"=")
+			continue
+		}
+		parts = append(parts, key+"="+value.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// findItem finds a list-map item by its identity string
+func findItem(seq *yaml.Node, id string, mapKeys []string) *yaml.Node {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range seq.Content {
+		if itemKey(item, mapKeys) == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// containsNode reports whether a sequence contains an item equal to the given node
+func containsNode(seq, item *yaml.Node) bool {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false
+	}
+	for _, candidate := range seq.Content {
+		if nodesEqual(candidate, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingKeys returns the keys of a mapping node in order
+func mappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// mappingKey returns the key node for a key in a mapping node
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node for a key in a mapping node
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, bool) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// nodesEqual compares two nodes by their decoded values (ignoring comments and style)
+func nodesEqual(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.DeepEqual(decode(a), decode(b))
+}
+
+// decode converts a node to a plain Go value
+func decode(node *yaml.Node) interface{} {
+	if node == nil {
+		return nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+	return value
+}
+
+// contentOf returns the content node of a document node
+func contentOf(doc *yaml.Node) *yaml.Node {
+	if doc == nil {
+		return nil
+	}
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// readDocument reads and parses a YAML file into a node tree
+func readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &doc, nil
+}
+
+// encode marshals a document node with the two-space indentation used by values files
+func encode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal merged YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal merged YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// appendPath returns a copy of path with an extra element
+func appendPath(path []string, elem string) []string {
+	result := make([]string, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, elem)
+}
+
+// formatPath renders a path as a dotted string
+func formatPath(path []string) string {
+	if len(path) == 0 {
+		return "<root>"
+	}
+	return strings.ReplaceAll(strings.Join(path, "."), ".[", "[")
+}
+
+// formatValue renders a value for conflict messages
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "<absent>"
+	case string:
+		return strconv.Quote(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
@@ -0,0 +1,190 @@
+package merge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func parse(t *testing.T, content string) *yaml.Node {
+	t.Helper()
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(content), &doc))
+	return &doc
+}
+
+func decodeOutput(t *testing.T, output []byte) map[string]interface{} {
+	t.Helper()
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(output, &values))
+	return values
+}
+
+func TestMerge_NonConflictingChanges(t *testing.T) {
+	base := parse(t, `replicas: 1
+image:
+  tag: v1
+  pullPolicy: IfNotPresent
+`)
+	ours := parse(t, `# Replica count
+replicas: 3
+image:
+  tag: v1
+  pullPolicy: IfNotPresent
+`)
+	theirs := parse(t, `replicas: 1
+image:
+  tag: v2
+  pullPolicy: IfNotPresent
+debug: true
+`)
+
+	output, err := NewMerger(nil).Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	values := decodeOutput(t, output)
+	assert.Equal(t, 3, values["replicas"])
+	assert.Equal(t, "v2", values["image"].(map[string]interface{})["tag"])
+	assert.Equal(t, true, values["debug"])
+
+	// Comments from our side are preserved
+	assert.Contains(t, string(output), "# Replica count")
+}
+
+func TestMerge_Deletion(t *testing.T) {
+	base := parse(t, "a: 1\nb: 2\n")
+	ours := parse(t, "a: 1\n")
+	theirs := parse(t, "a: 1\nb: 2\nc: 3\n")
+
+	output, err := NewMerger(nil).Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	values := decodeOutput(t, output)
+	assert.NotContains(t, values, "b")
+	assert.Equal(t, 3, values["c"])
+}
+
+func TestMerge_ScalarConflict(t *testing.T) {
+	base := parse(t, "service:\n  port: 80\n")
+	ours := parse(t, "service:\n  port: 8080\n")
+	theirs := parse(t, "service:\n  port: 9090\n")
+
+	output, err := NewMerger(nil).Merge(base, ours, theirs)
+	require.Error(t, err)
+
+	var conflictErr *ConflictError
+	require.True(t, errors.As(err, &conflictErr))
+	require.Len(t, conflictErr.Conflicts, 1)
+	assert.Equal(t, "service.port", conflictErr.Conflicts[0].Path)
+	assert.Equal(t, 80, conflictErr.Conflicts[0].Base)
+	assert.Equal(t, 8080, conflictErr.Conflicts[0].Ours)
+	assert.Equal(t, 9090, conflictErr.Conflicts[0].Theirs)
+
+	// Our side wins in the output for conflicting values
+	values := decodeOutput(t, output)
+	assert.Equal(t, 8080, values["service"].(map[string]interface{})["port"])
+}
+
+func TestMerge_AtomicListConflict(t *testing.T) {
+	base := parse(t, "args: [a]\n")
+	ours := parse(t, "args: [a, b]\n")
+	theirs := parse(t, "args: [a, c]\n")
+
+	_, err := NewMerger(nil).Merge(base, ours, theirs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "args")
+}
+
+func TestMerge_ListTypeSet(t *testing.T) {
+	listType := "set"
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"args": {Type: "array", XListType: &listType},
+		},
+	}
+
+	base := parse(t, "args: [a, x]\n")
+	ours := parse(t, "args: [a, x, b]\n")
+	theirs := parse(t, "args: [a, c]\n")
+
+	output, err := NewMerger(schema).Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	values := decodeOutput(t, output)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, values["args"])
+}
+
+func TestMerge_ListTypeMap(t *testing.T) {
+	listType := "map"
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"env": {
+				Type:         "array",
+				XListType:    &listType,
+				XListMapKeys: []string{"name"},
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"},
+				},
+			},
+		},
+	}
+
+	base := parse(t, `env:
+- name: A
+  value: "1"
+- name: B
+  value: "1"
+`)
+	ours := parse(t, `env:
+- name: A
+  value: "2"
+- name: B
+  value: "1"
+`)
+	theirs := parse(t, `env:
+- name: A
+  value: "1"
+- name: B
+  value: "3"
+- name: C
+  value: "1"
+`)
+
+	output, err := NewMerger(schema).Merge(base, ours, theirs)
+	require.NoError(t, err)
+
+	values := decodeOutput(t, output)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "A", "value": "2"},
+		map[string]interface{}{"name": "B", "value": "3"},
+		map[string]interface{}{"name": "C", "value": "1"},
+	}, values["env"])
+}
+
+func TestMergeFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	basePath := write("base.yaml", "a: 1\n")
+	oursPath := write("ours.yaml", "a: 1\nb: 2\n")
+	theirsPath := write("theirs.yaml", "a: 5\n")
+
+	output, err := NewMerger(nil).MergeFiles(basePath, oursPath, theirsPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 5, "b": 2}, decodeOutput(t, output))
+
+	_, err = NewMerger(nil).MergeFiles(filepath.Join(tmpDir, "missing.yaml"), oursPath, theirsPath)
+	require.Error(t, err)
+}
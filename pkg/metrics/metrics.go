@@ -0,0 +1,72 @@
+// Package metrics records the validations of miaka's long-running modes (serve
+// webhook, serve registry, lsp) in Prometheus metrics
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is the path metrics are served on
+const Path = "/metrics"
+
+// Results of a validation, for modes without their own (the webhook allows or denies)
+const (
+	ResultValid   = "valid"
+	ResultInvalid = "invalid"
+	ResultError   = "error"
+)
+
+// Metrics records the validations of a mode as miaka_<mode>_validations_total, by
+// kind and result, and miaka_<mode>_validation_duration_seconds, by kind
+type Metrics struct {
+	registry    *prometheus.Registry
+	validations *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+}
+
+// New creates the metrics of a mode (e.g., "registry"), along with Go runtime and
+// process metrics
+func New(mode string) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "miaka_" + mode + "_validations_total",
+			Help: "Values validated, by kind and result.",
+		}, []string{"kind", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "miaka_" + mode + "_validation_duration_seconds",
+			Help:    "Time to validate values, by kind.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"kind"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.validations, m.duration,
+	)
+	return m
+}
+
+// MustRegister adds metrics of the mode beyond validations and their duration
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Observe records a validation. Nothing is recorded if m is nil.
+func (m *Metrics) Observe(kind, result string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.validations.WithLabelValues(kind, result).Inc()
+	m.duration.WithLabelValues(kind).Observe(duration.Seconds())
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New("test")
+	m.Observe("Demo.demo.io", ResultValid, 10*time.Millisecond)
+	m.Observe("Demo.demo.io", ResultInvalid, 20*time.Millisecond)
+	m.Observe("Demo.demo.io", ResultInvalid, 30*time.Millisecond)
+
+	extra := prometheus.NewCounter(prometheus.CounterOpts{Name: "miaka_test_extra_total", Help: "Extra."})
+	m.MustRegister(extra)
+	extra.Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	output := rec.Body.String()
+
+	assert.Contains(t, output, `miaka_test_validations_total{kind="Demo.demo.io",result="valid"} 1`)
+	assert.Contains(t, output, `miaka_test_validations_total{kind="Demo.demo.io",result="invalid"} 2`)
+	assert.Contains(t, output, `miaka_test_validation_duration_seconds_count{kind="Demo.demo.io"} 3`)
+	assert.Contains(t, output, `miaka_test_validation_duration_seconds_bucket{kind="Demo.demo.io",le="0.025"} 2`)
+	assert.Contains(t, output, "miaka_test_extra_total 1")
+	assert.Contains(t, output, "go_goroutines")
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() { m.Observe("Demo.demo.io", ResultValid, time.Second) })
+}
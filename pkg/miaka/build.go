@@ -0,0 +1,357 @@
+// Package miaka builds the outputs of example values in memory, for tools that
+// embed miaka (e.g., chart scaffolders and CI bots) instead of running the
+// miaka binary. 'miaka build' uses it, then writes the outputs to files.
+package miaka
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/deepcopy"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/gotypes"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/helmtemplate"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/typescript"
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/crenshaw-dev/miaka/pkg/logging"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultInputName names the example values in errors if BuildOptions.InputName is empty
+const DefaultInputName = "example.values.yaml"
+
+// BuildOptions configure a build. The zero value, with Input set, builds like
+// 'miaka build --in-memory'.
+type BuildOptions struct {
+	// Input is the content of the example values file
+	Input []byte
+	// InputName names the input in errors and problems (default: DefaultInputName)
+	InputName string
+
+	// Parsing controls how types are inferred from the values (see 'miaka build
+	// --plain', --infer-semantic-types, --include, and --exclude)
+	Parsing parsing.Options
+	// WrapSpec nests the values under spec in the Go types and CRD
+	WrapSpec bool
+
+	// Strict is the strict validation mode of the CRD; the default is crd.StrictOn
+	Strict crd.StrictMode
+	// Resource overrides the CRD's names and scope
+	Resource crd.Resource
+	// Deterministic strips volatile annotations from the CRD and sorts its
+	// required fields, so identical input gives identical output
+	Deterministic bool
+	// HelmCompat adapts the JSON Schema to the way Helm validates values, allowing
+	// the global key of subcharts (see jsonschema.HelmCompatible)
+	HelmCompat bool
+	// Titles controls the titles of the JSON Schema's properties (none if empty)
+	Titles jsonschema.TitleMode
+	// Examples adds the example values to the JSON Schema's properties as
+	// examples (see jsonschema.AddExamples)
+	Examples bool
+	// KRM keeps the apiVersion, kind, and metadata properties in the JSON
+	// Schema, to validate full KRM documents (see jsonschema.KeepKRMFields)
+	KRM bool
+	// ValuesOnly leaves apiVersion and kind out of the JSON Schema, for builds
+	// that validate the resource form with the resourceschema target
+	ValuesOnly bool
+	// TempModule generates the CRD with controller-gen in a temporary Go module,
+	// which needs the go command and a writable temp directory, instead of in memory
+	TempModule bool
+	// WorkDir is the directory of the intermediate files of TempModule builds
+	// (default: the system temp directory), which keeps those of failed builds
+	WorkDir string
+	// Log receives the warnings of generating the outputs, such as generating
+	// the CRD of a TempModule build in memory without a go command (default:
+	// discarded)
+	Log logging.Logger
+
+	// PreviousCRD is the CRD of an earlier build; changes that break it fail the
+	// build. Nil skips the check.
+	PreviousCRD []byte
+	// PreviousTypes are the Go types of an earlier build; removed, renamed, or
+	// retyped types and fields fail the build. Nil skips the check.
+	PreviousTypes []byte
+	// Provenance stamps the CRD and JSON Schema, if set
+	Provenance *provenance.Provenance
+
+	// Targets are additional outputs to generate, by emitter name (e.g., "typescript")
+	Targets []string
+
+	// Cache stores the generated outputs, keyed by the parsed values, these
+	// options, and CacheVersion, so building unchanged values again doesn't
+	// generate them. Nil generates every output.
+	Cache *cache.Cache
+	// CacheVersion identifies the miaka build in cache keys, since another one
+	// can generate different outputs
+	CacheVersion string
+}
+
+// BuildResult holds the outputs of a build
+type BuildResult struct {
+	// Schema is the schema parsed from the example values
+	Schema *schema.Schema
+	// Types is the source of the Go types
+	Types []byte
+	// CRD is the CustomResourceDefinition, and CRDYAML its YAML. Both are nil for
+	// plain values.
+	CRD     *apiextensionsv1.CustomResourceDefinition
+	CRDYAML []byte
+	// JSONSchema is the JSON Schema for Helm (values.schema.json)
+	JSONSchema []byte
+	// Outputs are the additional outputs of BuildOptions.Targets, by emitter name
+	Outputs map[string][]byte
+	// Warnings are the problems of the example values that don't fail the build
+	Warnings []string
+	// ConversionLosses are the CRD constructs the JSON Schema can't represent
+	ConversionLosses []jsonschema.Loss
+}
+
+// Build parses the example values and generates the Go types, CRD, JSON Schema,
+// and additional targets in memory. It fails if the example values break their
+// own markers (see ExampleError) or the outputs break the previous ones. It
+// returns the cause of ctx being done if it's done before the build is.
+func Build(ctx context.Context, opts BuildOptions) (BuildResult, error) {
+	name := opts.InputName
+	if name == "" {
+		name = DefaultInputName
+	}
+	if err := opts.Parsing.Paths.Validate(); err != nil {
+		return BuildResult{}, err
+	}
+	s, err := parsing.NewParserWithOptions(opts.Parsing).Parse(opts.Input)
+	if err != nil {
+		return BuildResult{}, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if !opts.Parsing.Plain && s.APIVersion == "" && s.Kind == "" {
+		return BuildResult{}, fmt.Errorf("%s has no apiVersion or kind", name)
+	}
+	s.WrapSpec = opts.WrapSpec
+	result := BuildResult{Schema: s, Warnings: s.Warnings, Outputs: map[string][]byte{}}
+
+	registry, err := NewRegistry(opts)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	targets := []string{gotypes.TargetName, jsonschema.TargetName, crd.TargetName}
+	for _, target := range opts.Targets {
+		if _, ok := registry.Get(target); !ok {
+			return BuildResult{}, fmt.Errorf("unknown output target %q (available: %v)", target, registry.Names())
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	// Generate all outputs concurrently; the steps below report errors in order
+	_ = registry.Prefetch(ctx, *s, targets...)
+
+	if err := CheckExample(ctx, registry, s, name, opts.Input); err != nil {
+		return BuildResult{}, err
+	}
+	if ctx.Err() != nil {
+		return BuildResult{}, context.Cause(ctx)
+	}
+
+	if result.Types, err = buildTypes(ctx, registry, s, opts.PreviousTypes); err != nil {
+		return BuildResult{}, err
+	}
+
+	crdFile, err := registry.Emit(ctx, crd.TargetName, *s)
+	if err != nil {
+		return BuildResult{}, fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	if !opts.Parsing.Plain {
+		if result.CRDYAML, result.CRD, err = buildCRD(crdFile.Content, s, opts); err != nil {
+			return BuildResult{}, err
+		}
+	}
+	if ctx.Err() != nil {
+		return BuildResult{}, context.Cause(ctx)
+	}
+
+	if result.JSONSchema, err = buildJSONSchema(ctx, registry, s, opts); err != nil {
+		return BuildResult{}, err
+	}
+	if result.ConversionLosses, err = jsonschema.ConversionLosses(crdFile.Content); err != nil {
+		return BuildResult{}, fmt.Errorf("failed to compare JSON Schema with CRD: %w", err)
+	}
+
+	for _, target := range opts.Targets {
+		file, err := registry.Emit(ctx, target, *s)
+		if err != nil {
+			return BuildResult{}, fmt.Errorf("failed to generate %s output: %w", target, err)
+		}
+		result.Outputs[target] = file.Content
+	}
+	return result, nil
+}
+
+// NewRegistry registers all output targets for a single build. Every emitter
+// runs at most once, so outputs can be prefetched concurrently, and the CRD
+// emitter is shared with the JSON Schema emitter so controller-gen runs once.
+func NewRegistry(opts BuildOptions) (*generation.Registry, error) {
+	strict := opts.Strict
+	if strict == "" {
+		strict = crd.StrictOn
+	}
+	if _, err := crd.ParseStrictMode(string(strict)); err != nil {
+		return nil, err
+	}
+	crdBackend := crd.NewInMemoryEmitter()
+	if opts.TempModule {
+		crdBackend = crd.NewEmitter()
+		crdBackend.WorkDir = opts.WorkDir
+		crdBackend.Log = opts.Log
+	}
+	crdBackend.Strict = strict
+	crdBackend.Resource = opts.Resource
+
+	// Outputs are cached below Once, so a build reads each at most once
+	cached := func(e generation.Emitter) generation.Emitter {
+		if opts.Cache == nil {
+			return e
+		}
+		return generation.Cached(e, opts.Cache, opts.cacheSalt(strict))
+	}
+
+	var baseCRDEmitter generation.Emitter = crdBackend
+	if opts.Deterministic {
+		baseCRDEmitter = crd.Deterministic(baseCRDEmitter)
+	}
+	crdEmitter := generation.Once(cached(baseCRDEmitter))
+	jsonSchemaBackend := jsonschema.NewEmitter(crdEmitter)
+	jsonSchemaBackend.HelmCompat = opts.HelmCompat
+	jsonSchemaBackend.Titles = opts.Titles
+	jsonSchemaBackend.KRM = opts.KRM
+	jsonSchemaBackend.ValuesOnly = opts.ValuesOnly
+	resourceSchemaBackend := jsonschema.NewResourceEmitter(crdEmitter)
+	resourceSchemaBackend.Titles = opts.Titles
+	jsonSchemaEmitter := generation.Once(cached(jsonSchemaBackend))
+	typesEmitter := generation.Once(cached(gotypes.NewEmitter()))
+
+	registry := generation.NewRegistry()
+	for _, e := range []generation.Emitter{
+		typesEmitter,
+		generation.Once(cached(typescript.NewEmitter())),
+		crdEmitter,
+		jsonSchemaEmitter,
+		generation.Once(cached(resourceSchemaBackend)),
+		generation.Once(cached(helmtemplate.NewEmitter(jsonSchemaEmitter))),
+		generation.Once(cached(deepcopy.NewEmitter(typesEmitter))),
+	} {
+		if err := registry.Register(e); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// cacheSalt identifies the options of the emitters in cache keys. The parsing
+// options are part of the schema the keys include.
+func (opts BuildOptions) cacheSalt(strict crd.StrictMode) string {
+	return fmt.Sprintf("%s|%s|%+v|%t|%t|%s|%t|%t|%t", opts.CacheVersion, strict, opts.Resource, opts.Deterministic, opts.HelmCompat, opts.Titles, opts.KRM, opts.ValuesOnly, opts.TempModule)
+}
+
+// buildTypes generates the Go types, checking them against the previous ones
+func buildTypes(ctx context.Context, registry *generation.Registry, s *schema.Schema, previous []byte) ([]byte, error) {
+	file, err := registry.Emit(ctx, gotypes.TargetName, *s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Go code: %w", err)
+	}
+	if previous != nil {
+		if err := validation.CheckGoTypesBreakingChangesContent(previous, file.Content); err != nil {
+			return nil, fmt.Errorf("failed to generate Go code: %w", err)
+		}
+	}
+	if err := schema.ValidateSchema(s); err != nil {
+		return nil, err
+	}
+	return file.Content, nil
+}
+
+// buildCRD checks the CRD against the previous one and stamps it
+func buildCRD(content []byte, s *schema.Schema, opts BuildOptions) ([]byte, *apiextensionsv1.CustomResourceDefinition, error) {
+	if opts.PreviousCRD != nil {
+		if err := validation.CheckBreakingChangesContent(opts.PreviousCRD, content, CRDRenames(s)...); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate CRD: %w", err)
+		}
+	}
+	content, err := StampCRD(content, s, opts.Provenance)
+	if err != nil {
+		return nil, nil, err
+	}
+	crdDef := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(content, crdDef); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse generated CRD: %w", err)
+	}
+	return content, crdDef, nil
+}
+
+// buildJSONSchema generates and stamps the JSON Schema, and validates the
+// example values against it
+func buildJSONSchema(ctx context.Context, registry *generation.Registry, s *schema.Schema, opts BuildOptions) ([]byte, error) {
+	file, err := registry.Emit(ctx, jsonschema.TargetName, *s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JSON Schema: %w", err)
+	}
+	content := file.Content
+	if opts.Examples {
+		if content, err = jsonschema.AddExamples(content, opts.Input); err != nil {
+			return nil, err
+		}
+	}
+	if content, err = StampJSONSchema(content, s, opts.Provenance); err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(opts.Input, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if err := validation.ValidateValues(values, content); err != nil {
+		return nil, fmt.Errorf("JSON Schema validation failed: %w", err)
+	}
+	return content, nil
+}
+
+// CRDRenames returns the renamed fields of the values, with their paths in the CRD
+func CRDRenames(s *schema.Schema) []schema.Rename {
+	renames := s.Renames()
+	if s.WrapSpec {
+		for i := range renames {
+			renames[i].Path = append([]string{crd.SpecField}, renames[i].Path...)
+		}
+	}
+	return renames
+}
+
+// StampCRD stamps a CRD with the provenance, if any, keeping the order of its properties
+func StampCRD(content []byte, s *schema.Schema, stamp *provenance.Provenance) ([]byte, error) {
+	if stamp == nil {
+		return content, nil
+	}
+	content, err := provenance.StampCRD(content, *stamp)
+	if err != nil {
+		return nil, err
+	}
+	return crd.OrderProperties(content, s.PropertyOrder())
+}
+
+// StampJSONSchema stamps a JSON Schema with the provenance, if any, and orders
+// its properties like the example values
+func StampJSONSchema(content []byte, s *schema.Schema, stamp *provenance.Provenance) ([]byte, error) {
+	if stamp != nil {
+		var err error
+		if content, err = provenance.StampSchema(content, *stamp); err != nil {
+			return nil, err
+		}
+	}
+	return jsonschema.OrderProperties(content, s.PropertyOrder())
+}
@@ -0,0 +1,177 @@
+package miaka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInput = `apiVersion: example.com/v1
+kind: App
+# The number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 3
+image:
+  # The image repository
+  repository: nginx
+`
+
+func TestBuild(t *testing.T) {
+	result, err := Build(context.Background(), BuildOptions{
+		Input:   []byte(testInput),
+		Targets: []string{"typescript"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "App", result.Schema.Kind)
+	assert.Contains(t, string(result.Types), "type App struct")
+	require.NotNil(t, result.CRD)
+	assert.Equal(t, "apps.example.com", result.CRD.Name)
+	assert.Contains(t, string(result.CRDYAML), "kind: CustomResourceDefinition")
+	assert.Contains(t, string(result.JSONSchema), `"replicas"`)
+	assert.Contains(t, string(result.Outputs["typescript"]), "replicas")
+	assert.Len(t, result.Outputs, 1)
+}
+
+func TestBuild_Plain(t *testing.T) {
+	result, err := Build(context.Background(), BuildOptions{
+		Input:   []byte("replicas: 3\n"),
+		Parsing: parsing.Options{Plain: true},
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, result.CRD)
+	assert.Nil(t, result.CRDYAML)
+	assert.Contains(t, string(result.Types), "Replicas")
+	assert.Contains(t, string(result.JSONSchema), `"replicas"`)
+}
+
+func TestBuild_NoKind(t *testing.T) {
+	_, err := Build(context.Background(), BuildOptions{Input: []byte("replicas: 3\n")})
+	require.EqualError(t, err, "example.values.yaml has no apiVersion or kind")
+}
+
+func TestBuild_ExampleBreaksMarkers(t *testing.T) {
+	input := `apiVersion: example.com/v1
+kind: App
+# +kubebuilder:validation:Minimum=1
+replicas: 0
+`
+	_, err := Build(context.Background(), BuildOptions{Input: []byte(input), InputName: "values.yaml"})
+	var exampleErr *ExampleError
+	require.True(t, errors.As(err, &exampleErr), "got %v", err)
+	assert.Equal(t, "values.yaml", exampleErr.Input)
+	require.Len(t, exampleErr.Problems, 1)
+	assert.Equal(t, []string{"replicas"}, exampleErr.Problems[0].Path)
+	assert.Equal(t, 4, exampleErr.Problems[0].Line)
+	assert.Contains(t, exampleErr.Problems[0].Message, "+kubebuilder:validation:Minimum=1")
+}
+
+func TestBuild_PreviousCRD(t *testing.T) {
+	previous, err := Build(context.Background(), BuildOptions{Input: []byte(testInput)})
+	require.NoError(t, err)
+
+	// Unchanged values don't break the previous CRD
+	_, err = Build(context.Background(), BuildOptions{Input: []byte(testInput), PreviousCRD: previous.CRDYAML, PreviousTypes: previous.Types})
+	require.NoError(t, err)
+
+	removed := "apiVersion: example.com/v1\nkind: App\nreplicas: 3\n"
+	_, err = Build(context.Background(), BuildOptions{Input: []byte(removed), PreviousCRD: previous.CRDYAML})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate CRD")
+	assert.Contains(t, err.Error(), "image")
+}
+
+func TestBuild_PreviousTypes(t *testing.T) {
+	previous, err := Build(context.Background(), BuildOptions{Input: []byte(testInput)})
+	require.NoError(t, err)
+
+	removed := "apiVersion: example.com/v1\nkind: App\nreplicas: 3\n"
+	_, err = Build(context.Background(), BuildOptions{Input: []byte(removed), PreviousTypes: previous.Types})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate Go code")
+}
+
+func TestBuild_UnknownTarget(t *testing.T) {
+	_, err := Build(context.Background(), BuildOptions{Input: []byte(testInput), Targets: []string{"cobol"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown output target "cobol"`)
+}
+
+func TestBuild_InvalidStrict(t *testing.T) {
+	_, err := Build(context.Background(), BuildOptions{Input: []byte(testInput), Strict: "sometimes"})
+	require.Error(t, err)
+}
+
+func TestBuild_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Build(ctx, BuildOptions{Input: []byte(testInput)})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBuild_Provenance(t *testing.T) {
+	stamp := provenance.Provenance{Version: "v1.2.3", InputHash: provenance.Hash([]byte(testInput))}
+	result, err := Build(context.Background(), BuildOptions{Input: []byte(testInput), Provenance: &stamp})
+	require.NoError(t, err)
+
+	fromCRD, ok, err := provenance.ReadCRD(result.CRDYAML)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, stamp, fromCRD)
+	assert.Equal(t, "v1.2.3", result.CRD.Annotations[provenance.VersionAnnotation])
+
+	fromSchema, ok, err := provenance.ReadSchema(result.JSONSchema)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, stamp, fromSchema)
+}
+
+func TestBuild_Deterministic(t *testing.T) {
+	first, err := Build(context.Background(), BuildOptions{Input: []byte(testInput), Deterministic: true})
+	require.NoError(t, err)
+	second, err := Build(context.Background(), BuildOptions{Input: []byte(testInput), Deterministic: true})
+	require.NoError(t, err)
+	assert.Equal(t, string(first.CRDYAML), string(second.CRDYAML))
+	assert.NotContains(t, string(first.CRDYAML), "controller-gen.kubebuilder.io/version")
+}
+
+func TestBuild_Examples(t *testing.T) {
+	result, err := Build(context.Background(), BuildOptions{Input: []byte(testInput)})
+	require.NoError(t, err)
+	assert.NotContains(t, string(result.JSONSchema), `"examples"`)
+
+	result, err = Build(context.Background(), BuildOptions{Input: []byte(testInput), Examples: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(result.JSONSchema), `"nginx"`)
+}
+
+func TestBuild_ResourceSchema(t *testing.T) {
+	result, err := Build(context.Background(), BuildOptions{
+		Input:      []byte(testInput),
+		Targets:    []string{"resourceschema"},
+		ValuesOnly: true,
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(result.JSONSchema), `"apiVersion"`)
+	assert.Contains(t, string(result.Outputs["resourceschema"]), `"metadata"`)
+	assert.Contains(t, string(result.Outputs["resourceschema"]), `"example.com/v1"`)
+}
+
+// TestBuild_PortFormat tests that ports are bounded integers, without an
+// int32 format the JSON Schema would report as a conversion loss
+func TestBuild_PortFormat(t *testing.T) {
+	input := "apiVersion: example.com/v1\nkind: Example\n# +miaka:format:port\nport: 8080\n"
+	result, err := Build(context.Background(), BuildOptions{Input: []byte(input)})
+	require.NoError(t, err)
+	assert.Empty(t, result.ConversionLosses)
+	assert.Contains(t, string(result.JSONSchema), `"maximum": 65535`)
+	port := result.CRD.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["port"]
+	assert.Equal(t, "integer", port.Type)
+	assert.Empty(t, port.Format)
+}
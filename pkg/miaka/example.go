@@ -0,0 +1,83 @@
+package miaka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation"
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/crd"
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ExampleError is the error of example values that break their own markers
+type ExampleError struct {
+	// Input names the example values
+	Input string
+	// Problems are the values breaking their markers. Their messages end with
+	// the field's validation markers.
+	Problems []validation.Problem
+}
+
+func (e *ExampleError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		lines = append(lines, problem.String())
+	}
+	return fmt.Sprintf("%s breaks its own markers:\n  %s", e.Input, strings.Join(lines, "\n  "))
+}
+
+// CheckExample validates the example values, named name, against the CRD of
+// their schema, and returns an *ExampleError listing the values that break their
+// own markers. If the CRD can't be generated, the CRD step of the build reports why.
+func CheckExample(ctx context.Context, registry *generation.Registry, s *schema.Schema, name string, data []byte) error {
+	file, err := registry.Emit(ctx, crd.TargetName, *s)
+	if err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return nil
+	}
+	crdDef := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(file.Content, crdDef); err != nil {
+		return fmt.Errorf("failed to parse generated CRD: %w", err)
+	}
+	source, err := validation.ParseSource(name, data)
+	if err != nil {
+		return err
+	}
+
+	// Plain values have no apiVersion or kind, but the CRD of their KRM type does
+	values := source.Values
+	values["apiVersion"], values["kind"] = s.APIVersion, s.Kind
+	problems, err := validation.CRDProblems(values, crdDef, validation.Sources{source})
+	if err != nil {
+		return fmt.Errorf("failed to validate %s: %w", name, err)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	for i, problem := range problems {
+		if field, ok := s.FieldAt(problem.Path); ok {
+			if markers := validationMarkers(field); len(markers) > 0 {
+				problems[i].Message += " (" + strings.Join(markers, ", ") + ")"
+			}
+		}
+	}
+	return &ExampleError{Input: name, Problems: problems}
+}
+
+// validationMarkers returns the kubebuilder validation markers of a field
+func validationMarkers(field schema.Field) []string {
+	var markers []string
+	for _, comment := range field.Comments {
+		if strings.HasPrefix(comment, "+kubebuilder:validation:") {
+			markers = append(markers, comment)
+		}
+	}
+	return markers
+}
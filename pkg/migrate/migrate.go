@@ -0,0 +1,189 @@
+// Package migrate renames the keys of values files after fields of the example
+// values are renamed (+miaka:renamedFrom), without changing any comments or
+// formatting.
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a key renamed in a values file, by its old and new paths
+// (e.g., "containers[0].image.repo" and "containers[0].image.repository")
+type Change struct {
+	From string
+	To   string
+}
+
+// keyEdit replaces the text of a key at a position in the file
+type keyEdit struct {
+	line, col int // 0-based
+	old, new  string
+}
+
+// Migrate renames the old keys of renamed fields in a values file to their new
+// names. Renames are applied in order, so the paths of nested renames use the
+// new names of their parents, as schema.Schema.Renames returns them. Objects
+// setting both the old and the new key are an error, since either value may be
+// the intended one.
+func Migrate(data []byte, renames []schema.Rename) ([]byte, []Change, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("root node must be a mapping")
+	}
+
+	var changes []Change
+	var edits []keyEdit
+	for _, rename := range renames {
+		for _, object := range objectsAt(doc.Content[0], "", rename.Path) {
+			from, to := mappingKey(object.node, rename.From), mappingKey(object.node, rename.To)
+			if from == nil {
+				// Including anchored objects renamed where they're defined
+				continue
+			}
+			if to != nil {
+				return nil, nil, fmt.Errorf("both %s and %s, its new name, are set", object.join(rename.From), object.join(rename.To))
+			}
+			edit, err := renameKey(from, rename.To)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", object.join(rename.From), err)
+			}
+			edits = append(edits, edit)
+			changes = append(changes, Change{From: object.join(rename.From), To: object.join(rename.To)})
+		}
+	}
+	if len(edits) == 0 {
+		return data, nil, nil
+	}
+	output, err := applyEdits(data, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return output, changes, nil
+}
+
+// object is a mapping in a values file and its path (e.g., "containers[0].image")
+type object struct {
+	node   *yaml.Node
+	prefix string
+}
+
+// join returns the path of a key of the object
+func (o object) join(key string) string {
+	if o.prefix == "" {
+		return key
+	}
+	return o.prefix + "." + key
+}
+
+// objectsAt returns the mappings at a path of a Rename below node, whose path is
+// prefix. Values that aren't mappings, lists, or maps where expected are skipped.
+func objectsAt(node *yaml.Node, prefix string, path []string) []object {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if len(path) == 0 {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		return []object{{node: node, prefix: prefix}}
+	}
+
+	var objects []object
+	switch path[0] {
+	case schema.ItemsSegment:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			objects = append(objects, objectsAt(item, fmt.Sprintf("%s[%d]", prefix, i), path[1:])...)
+		}
+	case schema.ValuesSegment:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			objects = append(objects, objectsAt(node.Content[i+1], object{prefix: prefix}.join(node.Content[i].Value), path[1:])...)
+		}
+	default:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		if key := mappingKey(node, path[0]); key != nil {
+			objects = objectsAt(mappingValue(node, key), object{prefix: prefix}.join(path[0]), path[1:])
+		}
+	}
+	return objects
+}
+
+// mappingKey returns the key node of a key of a mapping, or nil if it has none
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node of a key node of a mapping
+func mappingValue(node, key *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i] == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// renameKey returns the edit renaming a key node, keeping its quotes, and renames
+// the node so later renames find it by its new name
+func renameKey(key *yaml.Node, name string) (keyEdit, error) {
+	edit := keyEdit{line: key.Line - 1, col: key.Column - 1, old: key.Value, new: name}
+	switch key.Style {
+	case 0:
+	case yaml.DoubleQuotedStyle:
+		edit.old, edit.new = `"`+key.Value+`"`, `"`+name+`"`
+	case yaml.SingleQuotedStyle:
+		edit.old, edit.new = "'"+key.Value+"'", "'"+name+"'"
+	default:
+		return keyEdit{}, fmt.Errorf("unsupported key style")
+	}
+	key.Value = name
+	return edit, nil
+}
+
+// applyEdits replaces the keys in the file, checking each one is where the
+// parser found it
+func applyEdits(data []byte, edits []keyEdit) ([]byte, error) {
+	// Later edits on a line go first, so the columns of earlier ones stay valid
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line < edits[j].line
+		}
+		return edits[i].col > edits[j].col
+	})
+
+	lines := strings.Split(string(data), "\n")
+	for _, edit := range edits {
+		if edit.line >= len(lines) || !strings.HasPrefix(lines[edit.line][min(edit.col, len(lines[edit.line])):], edit.old) {
+			return nil, fmt.Errorf("line %d: can't find key %s to rename", edit.line+1, edit.old)
+		}
+		line := lines[edit.line]
+		lines[edit.line] = line[:edit.col] + edit.new + line[edit.col+len(edit.old):]
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRenames = []schema.Rename{
+	{From: "image", To: "picture"},
+	{Path: []string{"picture"}, From: "repo", To: "repository"},
+	{Path: []string{"containers", schema.ItemsSegment}, From: "port", To: "containerPort"},
+	{Path: []string{"ports", schema.ValuesSegment}, From: "port", To: "containerPort"},
+}
+
+func TestMigrate(t *testing.T) {
+	input := `# Production values
+image:
+  # The registry
+  'repo': nginx # pinned
+  tag: "1.0"
+containers:
+  - name: app
+    port: 80
+  - {name: sidecar, port: 9090}
+ports:
+  http:
+    port: 8080
+`
+	output, changes, err := Migrate([]byte(input), testRenames)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# Production values
+picture:
+  # The registry
+  'repository': nginx # pinned
+  tag: "1.0"
+containers:
+  - name: app
+    containerPort: 80
+  - {name: sidecar, containerPort: 9090}
+ports:
+  http:
+    containerPort: 8080
+`, string(output))
+	assert.Equal(t, []Change{
+		{From: "image", To: "picture"},
+		{From: "picture.repo", To: "picture.repository"},
+		{From: "containers[0].port", To: "containers[0].containerPort"},
+		{From: "containers[1].port", To: "containers[1].containerPort"},
+		{From: "ports.http.port", To: "ports.http.containerPort"},
+	}, changes)
+}
+
+func TestMigrate_UpToDate(t *testing.T) {
+	input := []byte("picture:\n  repository: nginx\n")
+	output, changes, err := Migrate(input, testRenames)
+	require.NoError(t, err)
+	assert.Equal(t, input, output)
+	assert.Empty(t, changes)
+
+	output, changes, err = Migrate([]byte{}, testRenames)
+	require.NoError(t, err)
+	assert.Empty(t, output)
+	assert.Empty(t, changes)
+}
+
+func TestMigrate_Anchors(t *testing.T) {
+	input := `defaults: &image
+  repo: nginx
+image: *image
+`
+	output, changes, err := Migrate([]byte(input), []schema.Rename{{Path: []string{"image"}, From: "repo", To: "repository"}})
+	require.NoError(t, err)
+	assert.Equal(t, "defaults: &image\n  repository: nginx\nimage: *image\n", string(output))
+	assert.Equal(t, []Change{{From: "image.repo", To: "image.repository"}}, changes)
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	_, _, err := Migrate([]byte("image:\n  repo: a\n  repository: b\n"), []schema.Rename{{Path: []string{"image"}, From: "repo", To: "repository"}})
+	assert.EqualError(t, err, "both image.repo and image.repository, its new name, are set")
+
+	_, _, err = Migrate([]byte("image: a\npicture: b\n"), testRenames)
+	assert.EqualError(t, err, "both image and picture, its new name, are set")
+
+	_, _, err = Migrate([]byte("- a\n"), testRenames)
+	assert.EqualError(t, err, "root node must be a mapping")
+}
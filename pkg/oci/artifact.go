@@ -0,0 +1,117 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// TitleAnnotation names the file a layer was pushed from
+const TitleAnnotation = ocispec.AnnotationTitle
+
+// Media types of schema artifacts pushed by 'miaka push'
+const (
+	SchemaArtifactType  = "application/vnd.miaka.schema.v1"
+	CRDMediaType        = "application/vnd.miaka.crd.v1+yaml"
+	JSONSchemaMediaType = "application/vnd.miaka.jsonschema.v1+json"
+)
+
+// File is a file stored as a layer of an artifact
+type File struct {
+	// Name is the file name, stored in the layer's title annotation
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
+// PushArtifact pushes files as the layers of an artifact with an empty config,
+// tagged with ref.Tag. It returns the digest of the manifest.
+func (c *Client) PushArtifact(ctx context.Context, ref Reference, artifactType string, files []File) (string, error) {
+	if ref.Tag == "" {
+		return "", fmt.Errorf("%s has no tag to push to", ref)
+	}
+	repository, err := c.repository(ref)
+	if err != nil {
+		return "", err
+	}
+
+	layers := make([]ocispec.Descriptor, 0, len(files))
+	for _, file := range files {
+		layer := content.NewDescriptorFromBytes(file.MediaType, file.Data)
+		layer.Annotations = map[string]string{TitleAnnotation: file.Name}
+		if err := pushBlob(ctx, repository, layer, file.Data); err != nil {
+			return "", err
+		}
+		layers = append(layers, layer)
+	}
+
+	manifest, err := oras.PackManifest(ctx, repository, oras.PackManifestVersion1_1, artifactType, oras.PackManifestOptions{Layers: layers})
+	if err != nil {
+		return "", fmt.Errorf("failed to push manifest to %s: %w", ref, err)
+	}
+	if err := repository.Tag(ctx, manifest, ref.Tag); err != nil {
+		return "", fmt.Errorf("failed to tag %s: %w", ref, err)
+	}
+	return manifest.Digest.String(), nil
+}
+
+// PullArtifact pulls the layers of an artifact as files. The artifact must
+// have the given artifact type.
+func (c *Client) PullArtifact(ctx context.Context, ref Reference, artifactType string) ([]File, error) {
+	repository, err := c.repository(ref)
+	if err != nil {
+		return nil, err
+	}
+	_, data, err := oras.FetchBytes(ctx, repository, ref.manifestReference(), oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest of %s: %w", ref, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s: %w", ref, err)
+	}
+	if manifest.ArtifactType != artifactType {
+		return nil, fmt.Errorf("%s has artifact type %q, expected %q", ref, manifest.ArtifactType, artifactType)
+	}
+
+	files := make([]File, 0, len(manifest.Layers))
+	for _, layer := range manifest.Layers {
+		name := layer.Annotations[TitleAnnotation]
+		// Names must be plain file names, so pulling can't write outside a directory
+		if name == "" || name != path.Base(name) || name == "." || name == ".." {
+			return nil, fmt.Errorf("layer %s of %s has an invalid file name %q", layer.Digest, ref, name)
+		}
+		if layer.Size > maxBlobBytes {
+			return nil, fmt.Errorf("layer %s of %s is too large (%d bytes)", layer.Digest, ref, layer.Size)
+		}
+		// FetchAll verifies the size and digest of the layer
+		data, err := content.FetchAll(ctx, repository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch layer %s of %s: %w", layer.Digest, ref, err)
+		}
+		files = append(files, File{Name: name, MediaType: layer.MediaType, Data: data})
+	}
+	return files, nil
+}
+
+// pushBlob uploads a blob, unless the repository already has it
+func pushBlob(ctx context.Context, repository *remote.Repository, desc ocispec.Descriptor, data []byte) error {
+	exists, err := repository.Exists(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed to check blob %s: %w", desc.Digest, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := repository.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", desc.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testArtifactType = "application/vnd.example.test.v1"
+
+func TestPushPullArtifact(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	client := NewClient()
+	client.PlainHTTP = true
+	ref := Reference{Registry: registry.Host(), Repository: "org/schemas/myapp", Tag: "1.0.0"}
+
+	files := []File{
+		{Name: "crd.yaml", MediaType: "application/vnd.example.crd+yaml", Data: []byte("kind: CustomResourceDefinition\n")},
+		{Name: "values.schema.json", MediaType: "application/vnd.example.schema+json", Data: []byte("{}")},
+	}
+	digest, err := client.PushArtifact(context.Background(), ref, testArtifactType, files)
+	require.NoError(t, err)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, digest)
+	// The empty config and the second file have the same content, so it's uploaded once
+	assert.Equal(t, 2, registry.Uploads())
+
+	pulled, err := client.PullArtifact(context.Background(), ref, testArtifactType)
+	require.NoError(t, err)
+	assert.Equal(t, files, pulled)
+
+	// By digest
+	byDigest := Reference{Registry: ref.Registry, Repository: ref.Repository, Digest: digest}
+	pulled, err = client.PullArtifact(context.Background(), byDigest, testArtifactType)
+	require.NoError(t, err)
+	assert.Len(t, pulled, 2)
+
+	// Pushing again doesn't upload existing blobs
+	_, err = client.PushArtifact(context.Background(), ref, testArtifactType, files)
+	require.NoError(t, err)
+	assert.Equal(t, 2, registry.Uploads())
+}
+
+func TestPullArtifact_Errors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	client := NewClient()
+	client.PlainHTTP = true
+	ref := Reference{Registry: registry.Host(), Repository: "myapp", Tag: "1.0.0"}
+
+	_, err := client.PushArtifact(context.Background(), ref, "application/vnd.example.other.v1", nil)
+	require.NoError(t, err)
+	_, err = client.PullArtifact(context.Background(), ref, testArtifactType)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `has artifact type "application/vnd.example.other.v1"`)
+
+	// Layers can't be written outside the output directory
+	blob := []byte("x")
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		ArtifactType: testArtifactType,
+		Layers: []ocispec.Descriptor{{
+			MediaType:   "text/plain",
+			Digest:      digest.Digest(registry.SetBlob(blob)),
+			Size:        1,
+			Annotations: map[string]string{TitleAnnotation: "../escape"},
+		}},
+	})
+	require.NoError(t, err)
+	registry.SetManifest("myapp", "evil", manifest)
+	ref.Tag = "evil"
+	_, err = client.PullArtifact(context.Background(), ref, testArtifactType)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid file name "../escape"`)
+
+	_, err = client.PushArtifact(context.Background(), Reference{Registry: registry.Host(), Repository: "myapp", Digest: digest.FromBytes(blob).String()}, testArtifactType, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has no tag to push to")
+}
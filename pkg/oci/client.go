@@ -0,0 +1,108 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"helm.sh/helm/v3/pkg/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// Media types of Helm chart artifacts
+const (
+	HelmChartConfigMediaType  = registry.ConfigMediaType
+	HelmChartContentMediaType = registry.ChartLayerMediaType
+)
+
+// maxBlobBytes limits the size of pulled layers
+const maxBlobBytes int64 = 512 * 1024 * 1024
+
+// Client pulls and pushes artifacts in OCI registries: Helm charts with Helm's
+// registry client, and other artifacts with ORAS, which Helm's client is built
+// on. Registries are accessed with Username and Password if set, else with the
+// credentials in Credentials, and anonymously otherwise.
+type Client struct {
+	// HTTPClient sends requests (default: http.DefaultClient)
+	HTTPClient *http.Client
+	// PlainHTTP uses HTTP instead of HTTPS, for local registries
+	PlainHTTP bool
+	// Username and Password authenticate to the registry
+	Username string
+	Password string
+	// Credentials holds the credentials of registries (see NewCredentialsStore)
+	Credentials credentials.Store
+}
+
+// NewClient creates a client that uses HTTPS
+func NewClient() *Client {
+	return &Client{HTTPClient: http.DefaultClient}
+}
+
+// PullChart pulls a Helm chart stored as an OCI artifact, returning the
+// packaged chart archive (.tgz)
+func (c *Client) PullChart(ctx context.Context, ref Reference) ([]byte, error) {
+	options := []registry.ClientOption{
+		registry.ClientOptHTTPClient(c.httpClient()),
+		registry.ClientOptAuthorizer(*c.authClient()),
+	}
+	if c.PlainHTTP {
+		options = append(options, registry.ClientOptPlainHTTP())
+	}
+	client, err := registry.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	// Helm's client doesn't take a context, so cancellation is only checked first
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := client.Pull(ref.String(), registry.PullOptWithChart(true))
+	if err != nil {
+		return nil, err
+	}
+	return result.Chart.Data, nil
+}
+
+// repository returns the repository of ref
+func (c *Client) repository(ref Reference) (*remote.Repository, error) {
+	repository, err := remote.NewRepository(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository in %s: %w", ref, err)
+	}
+	repository.PlainHTTP = c.PlainHTTP
+	repository.Client = c.authClient()
+	return repository, nil
+}
+
+// authClient returns a client that answers the auth challenges of registries
+func (c *Client) authClient() *auth.Client {
+	client := &auth.Client{
+		Client:     c.httpClient(),
+		Cache:      auth.NewCache(),
+		Credential: c.credential,
+	}
+	client.SetUserAgent("miaka")
+	return client
+}
+
+// credential returns the credentials of a registry
+func (c *Client) credential(ctx context.Context, hostport string) (auth.Credential, error) {
+	if c.Username != "" || c.Password != "" {
+		return auth.Credential{Username: c.Username, Password: c.Password}, nil
+	}
+	if c.Credentials == nil {
+		return auth.EmptyCredential, nil
+	}
+	return credentials.Credential(c.Credentials)(ctx, hostport)
+}
+
+// httpClient returns the HTTP client, or the default one
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,88 @@
+package oci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullChart(t *testing.T) {
+	for _, token := range []string{"", "secret"} {
+		t.Run("token="+token, func(t *testing.T) {
+			registry := ocitest.NewRegistry(t)
+			registry.RequireToken(token)
+			registry.SetChart("charts/mychart", "1.0.0", []byte("chart archive"))
+
+			client := NewClient()
+			client.PlainHTTP = true
+			data, err := client.PullChart(context.Background(), Reference{Registry: registry.Host(), Repository: "charts/mychart", Tag: "1.0.0"})
+			require.NoError(t, err)
+			assert.Equal(t, "chart archive", string(data))
+		})
+	}
+}
+
+func TestPullChart_ByDigest(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	digest := registry.SetChart("charts/mychart", "1.0.0", []byte("chart archive"))
+	client := NewClient()
+	client.PlainHTTP = true
+
+	data, err := client.PullChart(context.Background(), Reference{Registry: registry.Host(), Repository: "charts/mychart", Digest: digest})
+	require.NoError(t, err)
+	assert.Equal(t, "chart archive", string(data))
+}
+
+func TestPullChart_Errors(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.SetChart("charts/mychart", "1.0.0", []byte("chart archive"))
+	client := NewClient()
+	client.PlainHTTP = true
+	ref := Reference{Registry: registry.Host(), Repository: "charts/mychart", Tag: "1.0.0"}
+
+	missing := ref
+	missing.Tag = "2.0.0"
+	_, err := client.PullChart(context.Background(), missing)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	wrongDigest := ref
+	wrongDigest.Tag, wrongDigest.Digest = "", "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	_, err = client.PullChart(context.Background(), wrongDigest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	// Schema artifacts aren't charts
+	schemas := Reference{Registry: registry.Host(), Repository: "schemas/myapp", Tag: "1.0.0"}
+	_, err = client.PushArtifact(context.Background(), schemas, SchemaArtifactType, []File{{Name: "crd.yaml", MediaType: CRDMediaType, Data: []byte("kind: CustomResourceDefinition\n")}})
+	require.NoError(t, err)
+	_, err = client.PullChart(context.Background(), schemas)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "minimum number of descriptors")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.PullChart(ctx, ref)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBasicAuth(t *testing.T) {
+	registry := ocitest.NewRegistry(t)
+	registry.RequireBasicAuth("alice", "secret")
+	registry.SetChart("myapp", "1.0.0", []byte("chart archive"))
+	ref := Reference{Registry: registry.Host(), Repository: "myapp", Tag: "1.0.0"}
+
+	client := NewClient()
+	client.PlainHTTP = true
+	_, err := client.PullChart(context.Background(), ref)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "credential not found")
+
+	client.Username, client.Password = "alice", "secret"
+	data, err := client.PullChart(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "chart archive", string(data))
+}
@@ -0,0 +1,53 @@
+package oci
+
+import (
+	"fmt"
+	"os"
+
+	"helm.sh/helm/v3/pkg/helmpath"
+	"helm.sh/helm/v3/pkg/registry"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// Environment variables that set registry credentials, taking precedence over
+// the credentials store
+const (
+	UsernameEnv = "MIAKA_REGISTRY_USERNAME"
+	PasswordEnv = "MIAKA_REGISTRY_PASSWORD"
+)
+
+// HelmRegistryConfigEnv overrides the path of Helm's registry config, as in Helm
+const HelmRegistryConfigEnv = "HELM_REGISTRY_CONFIG"
+
+// HelmRegistryConfig returns the path of the registry config 'helm registry
+// login' writes: $HELM_REGISTRY_CONFIG, or config.json in Helm's config
+// directory (e.g., ~/.config/helm/registry/config.json)
+func HelmRegistryConfig() string {
+	if path := os.Getenv(HelmRegistryConfigEnv); path != "" {
+		return path
+	}
+	return helmpath.ConfigPath(registry.CredentialsFileBasename)
+}
+
+// NewCredentialsStore returns the store of registry credentials Helm uses: the
+// Helm registry config, then the Docker config ($DOCKER_CONFIG/config.json or
+// ~/.docker/config.json), as written by 'helm registry login' and 'docker
+// login'. Credential helpers (credsStore and credHelpers) are run like Docker
+// runs them, so logins to cloud registries such as ECR, GCR, and ACR work.
+func NewCredentialsStore() (credentials.Store, error) {
+	options := credentials.StoreOptions{DetectDefaultNativeStore: true}
+	path := HelmRegistryConfig()
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		// Helm leaves an empty config after logging out of every registry
+		path = ""
+	}
+	helmStore, err := credentials.NewStore(path, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", HelmRegistryConfig(), err)
+	}
+	dockerStore, err := credentials.NewStoreFromDocker(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Docker config: %w", err)
+	}
+	return credentials.NewStoreWithFallbacks(helmStore, dockerStore), nil
+}
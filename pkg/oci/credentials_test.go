@@ -0,0 +1,97 @@
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/oci/ocitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// credentialsTestRegistry serves a chart that requires alice's credentials,
+// with the Helm and Docker configs pointed at dir
+func credentialsTestRegistry(t *testing.T, dir string) (*ocitest.Registry, Reference) {
+	t.Helper()
+	t.Setenv("DOCKER_CONFIG", filepath.Join(dir, "docker"))
+	t.Setenv(HelmRegistryConfigEnv, filepath.Join(dir, "helm.json"))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docker"), 0755))
+
+	registry := ocitest.NewRegistry(t)
+	registry.RequireBasicAuth("alice", "secret")
+	registry.SetChart("myapp", "1.0.0", []byte("chart archive"))
+	return registry, Reference{Registry: registry.Host(), Repository: "myapp", Tag: "1.0.0"}
+}
+
+// pullWithStore pulls ref with the credentials store
+func pullWithStore(t *testing.T, ref Reference) ([]byte, error) {
+	t.Helper()
+	store, err := NewCredentialsStore()
+	require.NoError(t, err)
+	client := NewClient()
+	client.PlainHTTP = true
+	client.Credentials = store
+	return client.PullChart(context.Background(), ref)
+}
+
+func TestNewCredentialsStore(t *testing.T) {
+	dir := t.TempDir()
+	registry, ref := credentialsTestRegistry(t, dir)
+
+	// Without credentials
+	_, err := pullWithStore(t, ref)
+	require.Error(t, err)
+
+	// From the Docker config ("alice:secret")
+	dockerConfig := `{"auths": {"` + registry.Host() + `": {"auth": "YWxpY2U6c2VjcmV0"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker", "config.json"), []byte(dockerConfig), 0600))
+	data, err := pullWithStore(t, ref)
+	require.NoError(t, err)
+	assert.Equal(t, "chart archive", string(data))
+
+	// The Helm registry config takes precedence ("alice:wrong")
+	helmConfig := `{"auths": {"` + registry.Host() + `": {"auth": "YWxpY2U6d3Jvbmc="}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "helm.json"), []byte(helmConfig), 0600))
+	_, err = pullWithStore(t, ref)
+	require.Error(t, err)
+
+	// An empty Helm registry config, as Helm leaves after logging out, falls back to Docker's
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "helm.json"), nil, 0600))
+	_, err = pullWithStore(t, ref)
+	require.NoError(t, err)
+}
+
+// TestNewCredentialsStore_CredentialHelper tests credentials from a Docker
+// credential helper, as cloud registries configure them
+func TestNewCredentialsStore_CredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test credential helper is a shell script")
+	}
+	dir := t.TempDir()
+	registry, ref := credentialsTestRegistry(t, dir)
+
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	helper := "#!/bin/sh\nread server\necho '{\"ServerURL\": \"'\"$server\"'\", \"Username\": \"alice\", \"Secret\": \"secret\"}'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "docker-credential-miakatest"), []byte(helper), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dockerConfig := `{"credHelpers": {"` + registry.Host() + `": "miakatest"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker", "config.json"), []byte(dockerConfig), 0600))
+	data, err := pullWithStore(t, ref)
+	require.NoError(t, err)
+	assert.Equal(t, "chart archive", string(data))
+}
+
+func TestNewCredentialsStore_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	credentialsTestRegistry(t, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "helm.json"), []byte("{"), 0600))
+
+	_, err := NewCredentialsStore()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "helm.json")
+}
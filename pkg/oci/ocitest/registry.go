@@ -0,0 +1,215 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Registry is an in-memory OCI registry serving the distribution API over
+// plain HTTP. Blobs and manifests are shared by all repositories.
+type Registry struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+
+	// username and password are required with basic auth if set
+	username, password string
+	// token is required as a bearer token if set, and issued at /token
+	token string
+}
+
+// NewRegistry starts a registry that's closed when the test ends
+func NewRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := &Registry{blobs: make(map[string][]byte), manifests: make(map[string][]byte)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", r.serve)
+	mux.HandleFunc("/token", r.serveToken)
+	r.Server = httptest.NewServer(mux)
+	t.Cleanup(r.Close)
+	return r
+}
+
+// Host returns the host and port of the registry, for oci:// references
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+// RequireBasicAuth makes the registry require credentials with basic auth
+func (r *Registry) RequireBasicAuth(username, password string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.username, r.password = username, password
+}
+
+// RequireToken makes the registry require a bearer token, which it issues to
+// anyone at /token, like public registries
+func (r *Registry) RequireToken(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = token
+}
+
+// Uploads returns the number of blobs uploaded
+func (r *Registry) Uploads() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.uploads
+}
+
+// SetManifest stores a manifest for repository:reference, e.g. to serve
+// artifacts pushed by other tools
+func (r *Registry) SetManifest(repository, reference string, manifest []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manifests[repository+":"+reference] = manifest
+	r.manifests[repository+":"+digestOf(manifest)] = manifest
+}
+
+// SetBlob stores a blob and returns its digest
+func (r *Registry) SetBlob(data []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := digestOf(data)
+	r.blobs[digest] = data
+	return digest
+}
+
+// SetChart stores a packaged chart (.tgz) as Helm pushes it, at
+// repository:tag, and returns the digest of its manifest
+func (r *Registry) SetChart(repository, tag string, archive []byte) string {
+	config := []byte(`{"name": "chart", "version": "1.0.0"}`)
+	manifest := fmt.Sprintf(`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", `+
+		`"config": {"mediaType": "application/vnd.cncf.helm.config.v1+json", "digest": %q, "size": %d}, `+
+		`"layers": [{"mediaType": "application/vnd.cncf.helm.chart.content.v1.tar+gzip", "digest": %q, "size": %d}]}`,
+		r.SetBlob(config), len(config), r.SetBlob(archive), len(archive))
+	r.SetManifest(repository, tag, []byte(manifest))
+	return digestOf([]byte(manifest))
+}
+
+// serve routes /v2/<repository>/{manifests,blobs}/... requests. Repository
+// names contain slashes, so they're split off at the last API segment.
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(w, req) {
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	for _, segment := range []string{"/blobs/uploads/", "/manifests/", "/blobs/"} {
+		i := strings.LastIndex(path, segment)
+		if i < 0 {
+			continue
+		}
+		repository, rest := path[:i], path[i+len(segment):]
+		switch {
+		case segment == "/blobs/uploads/" && req.Method == http.MethodPost && rest == "":
+			w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/session")
+			w.WriteHeader(http.StatusAccepted)
+		case segment == "/blobs/uploads/" && req.Method == http.MethodPut:
+			r.serveUpload(w, req)
+		case segment == "/manifests/" && req.Method == http.MethodPut:
+			r.servePutManifest(w, req, repository, rest)
+		case segment == "/manifests/" && req.Method == http.MethodGet:
+			r.serveGet(w, r.manifests, repository+":"+rest, "application/vnd.oci.image.manifest.v1+json")
+		case segment == "/blobs/" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+			r.serveGet(w, r.blobs, rest, "application/octet-stream")
+		default:
+			http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// authorized checks the credentials of a request, challenging the client if
+// they're missing or wrong
+func (r *Registry) authorized(w http.ResponseWriter, req *http.Request) bool {
+	r.mu.Lock()
+	username, password, token := r.username, r.password, r.token
+	r.mu.Unlock()
+
+	switch {
+	case token != "":
+		if req.Header.Get("Authorization") == "Bearer "+token {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.URL+`/token",service="ocitest"`)
+	case username != "":
+		if u, p, ok := req.BasicAuth(); ok && u == username && p == password {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="ocitest"`)
+	default:
+		return true
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// serveToken issues the bearer token
+func (r *Registry) serveToken(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	token := r.token
+	r.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"token": %q}`, token)
+}
+
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	digest := req.URL.Query().Get("digest")
+	if digest != digestOf(data) {
+		http.Error(w, fmt.Sprintf("digest %s doesn't match the content", digest), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.blobs[digest] = data
+	r.uploads++
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *Registry) servePutManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.SetManifest(repository, reference, data)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (r *Registry) serveGet(w http.ResponseWriter, content map[string][]byte, key, contentType string) {
+	r.mu.Lock()
+	data, ok := content[key]
+	r.mu.Unlock()
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data)
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,81 @@
+// Package oci pulls Helm charts stored as OCI artifacts with Helm's registry
+// client, and pushes and pulls schema artifacts with ORAS.
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scheme prefixes OCI references (e.g., oci://ghcr.io/org/charts/mychart:1.0.0)
+const Scheme = "oci://"
+
+// Reference identifies an artifact in an OCI registry
+type Reference struct {
+	// Registry is the registry host, with an optional port (e.g., ghcr.io)
+	Registry string
+	// Repository is the path of the repository in the registry (e.g., org/charts/mychart)
+	Repository string
+	// Tag is the tag of the artifact (e.g., 1.0.0)
+	Tag string
+	// Digest is the digest of the artifact's manifest, and takes precedence over Tag
+	Digest string
+}
+
+// IsReference reports whether s is an oci:// reference
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, Scheme)
+}
+
+// ParseReference parses an oci:// reference. A tag or digest is required.
+func ParseReference(s string) (Reference, error) {
+	if !IsReference(s) {
+		return Reference{}, fmt.Errorf("%q is not an OCI reference (expected %s<registry>/<repository>:<tag>)", s, Scheme)
+	}
+
+	registry, rest, ok := strings.Cut(strings.TrimPrefix(s, Scheme), "/")
+	if !ok || registry == "" || rest == "" {
+		return Reference{}, fmt.Errorf("%q has no repository (expected %s<registry>/<repository>:<tag>)", s, Scheme)
+	}
+
+	ref := Reference{Registry: registry}
+	if repository, digest, ok := strings.Cut(rest, "@"); ok {
+		rest, ref.Digest = repository, digest
+		if !strings.Contains(digest, ":") {
+			return Reference{}, fmt.Errorf("%q has an invalid digest %q", s, digest)
+		}
+	}
+	// A colon after the last slash separates the tag
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, ref.Tag = rest[:i], rest[i+1:]
+	}
+	ref.Repository = rest
+
+	if ref.Repository == "" {
+		return Reference{}, fmt.Errorf("%q has no repository", s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		return Reference{}, fmt.Errorf("%q has no tag or digest (e.g., %s:1.0.0)", s, s)
+	}
+	return ref, nil
+}
+
+// String returns the reference in oci:// form
+func (r Reference) String() string {
+	s := Scheme + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifestReference returns the digest or tag to fetch the manifest by
+func (r Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
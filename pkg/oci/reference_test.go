@@ -0,0 +1,67 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected Reference
+	}{
+		{
+			ref:      "oci://ghcr.io/org/charts/mychart:1.2.3",
+			expected: Reference{Registry: "ghcr.io", Repository: "org/charts/mychart", Tag: "1.2.3"},
+		},
+		{
+			ref:      "oci://localhost:5000/mychart:0.1.0",
+			expected: Reference{Registry: "localhost:5000", Repository: "mychart", Tag: "0.1.0"},
+		},
+		{
+			ref:      "oci://ghcr.io/org/mychart@sha256:abc",
+			expected: Reference{Registry: "ghcr.io", Repository: "org/mychart", Digest: "sha256:abc"},
+		},
+		{
+			ref:      "oci://ghcr.io/org/mychart:1.0.0@sha256:abc",
+			expected: Reference{Registry: "ghcr.io", Repository: "org/mychart", Tag: "1.0.0", Digest: "sha256:abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseReference(tt.ref)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ref)
+			assert.Equal(t, tt.ref, ref.String())
+		})
+	}
+}
+
+func TestParseReference_Errors(t *testing.T) {
+	tests := []struct {
+		ref string
+		err string
+	}{
+		{ref: "ghcr.io/org/mychart:1.0.0", err: "is not an OCI reference"},
+		{ref: "oci://ghcr.io", err: "has no repository"},
+		{ref: "oci://ghcr.io/:1.0.0", err: "has no repository"},
+		{ref: "oci://localhost:5000/mychart", err: "has no tag or digest"},
+		{ref: "oci://ghcr.io/mychart@abc", err: "invalid digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			_, err := ParseReference(tt.ref)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("oci://ghcr.io/org/mychart:1.0.0"))
+	assert.False(t, IsReference("mychart-1.0.0.tgz"))
+}
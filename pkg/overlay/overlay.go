@@ -0,0 +1,178 @@
+// Package overlay generates starter values files for new environments.
+package overlay
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// skippedFields are the root fields that come from the base values file
+var skippedFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// Options control which fields an overlay includes
+type Options struct {
+	// Environment is the name of the environment, used in placeholder comments
+	Environment string
+	// OnlyRequired skips optional fields, even if they have no safe default
+	OnlyRequired bool
+	// SecretPaths are the dotted paths of fields marked +miaka:secret, which
+	// never have a safe default and whose example values are never copied
+	SecretPaths map[string]bool
+}
+
+// Result is a generated overlay values file
+type Result struct {
+	Content []byte
+	// Fields are the paths of the placeholder fields
+	Fields []string
+}
+
+// Generate creates an overlay values file containing the required fields of a
+// schema and, unless opts.OnlyRequired is set, the fields without a safe default:
+// secret fields and fields with no schema default whose example value is empty.
+// Each field is set to a placeholder (its example value, or the zero value of
+// its type) marked TODO.
+func Generate(props *apiextensionsv1.JSONSchemaProps, example map[string]interface{}, opts Options) (*Result, error) {
+	g := &generator{opts: opts}
+	root := g.object(props, example, "", true, true)
+	if root == nil {
+		root = &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to encode overlay: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode overlay: %w", err)
+	}
+	return &Result{Content: buf.Bytes(), Fields: g.fields}, nil
+}
+
+// generator builds the nodes of an overlay
+type generator struct {
+	opts   Options
+	fields []string
+}
+
+// object returns a mapping of the included fields of an object schema, or nil if none are included.
+// requiredChain is set if the object itself must be present.
+func (g *generator) object(props *apiextensionsv1.JSONSchemaProps, example map[string]interface{}, path string, requiredChain, root bool) *yaml.Node {
+	required := map[string]bool{}
+	for _, name := range props.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(props.Properties))
+	for name := range props.Properties {
+		if !(root && skippedFields[name]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range names {
+		prop := props.Properties[name]
+		value := g.field(&prop, example[name], joinPath(path, name), requiredChain && required[name])
+		if value == nil {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: name, HeadComment: comment(prop.Description)}
+		mapping.Content = append(mapping.Content, key, value)
+	}
+	if len(mapping.Content) == 0 {
+		return nil
+	}
+	return mapping
+}
+
+// field returns the node for a field, or nil if the overlay doesn't need it
+func (g *generator) field(props *apiextensionsv1.JSONSchemaProps, example interface{}, path string, required bool) *yaml.Node {
+	// Kubernetes fills in defaults, so they're always safe
+	if props.Default != nil {
+		return nil
+	}
+
+	if props.Type == "object" && len(props.Properties) > 0 {
+		exampleMap, _ := example.(map[string]interface{})
+		if node := g.object(props, exampleMap, path, required, false); node != nil {
+			return node
+		}
+	} else if !required && !g.opts.OnlyRequired && (isEmpty(example) || g.opts.SecretPaths[path]) {
+		return g.placeholder(props, example, path)
+	}
+
+	if required {
+		return g.placeholder(props, example, path)
+	}
+	return nil
+}
+
+// placeholder returns a value for a field, marked with a TODO comment
+func (g *generator) placeholder(props *apiextensionsv1.JSONSchemaProps, example interface{}, path string) *yaml.Node {
+	g.fields = append(g.fields, path)
+
+	node := &yaml.Node{}
+	if isEmpty(example) || g.opts.SecretPaths[path] || props.Type == "object" || props.Type == "array" {
+		example = zeroValue(props)
+	}
+	if err := node.Encode(example); err != nil {
+		node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	node.LineComment = "# TODO: set for " + g.opts.Environment
+	return node
+}
+
+// zeroValue returns the zero value of a schema's type
+func zeroValue(props *apiextensionsv1.JSONSchemaProps) interface{} {
+	switch props.Type {
+	case "string":
+		return ""
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	default:
+		return nil
+	}
+}
+
+// isEmpty reports whether an example value is missing or an empty string
+func isEmpty(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+// comment formats a description as a head comment
+func comment(description string) string {
+	if description == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("# "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinPath appends a field name to a dotted path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
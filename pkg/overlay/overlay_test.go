@@ -0,0 +1,142 @@
+package overlay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testSchema = `type: object
+required: [database, image]
+properties:
+  apiVersion:
+    type: string
+  kind:
+    type: string
+  replicas:
+    type: integer
+  image:
+    type: object
+    required: [repository, tag]
+    properties:
+      repository:
+        type: string
+        default: nginx
+      tag:
+        description: Image tag to deploy
+        type: string
+      pullPolicy:
+        type: string
+  database:
+    type: object
+    required: [host, port, options]
+    properties:
+      host:
+        type: string
+      port:
+        type: integer
+      options:
+        type: object
+        additionalProperties:
+          type: string
+  ingress:
+    type: object
+    required: [host]
+    properties:
+      host:
+        type: string
+      className:
+        description: |-
+          Ingress class.
+          Leave empty for the cluster default.
+        type: string
+  tolerations:
+    type: array
+    items:
+      type: string
+`
+
+const testExample = `apiVersion: demo.io/v1
+kind: Demo
+replicas: 3
+image:
+  repository: nginx
+  tag: ""
+  pullPolicy: IfNotPresent
+database:
+  host: db.example.com
+  port: 5432
+  options:
+    sslmode: require
+ingress:
+  host: app.example.com
+  className: ""
+tolerations: []
+`
+
+// generate runs Generate on the test schema and example
+func generate(t *testing.T, opts Options) *Result {
+	t.Helper()
+	var props apiextensionsv1.JSONSchemaProps
+	require.NoError(t, yaml.Unmarshal([]byte(testSchema), &props))
+	var example map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(testExample), &example))
+
+	result, err := Generate(&props, example, opts)
+	require.NoError(t, err)
+	return result
+}
+
+func TestGenerate(t *testing.T) {
+	result := generate(t, Options{Environment: "prod"})
+
+	assert.Equal(t, `database:
+  host: db.example.com # TODO: set for prod
+  options: {} # TODO: set for prod
+  port: 5432 # TODO: set for prod
+image:
+  # Image tag to deploy
+  tag: "" # TODO: set for prod
+ingress:
+  # Ingress class.
+  # Leave empty for the cluster default.
+  className: "" # TODO: set for prod
+`, string(result.Content))
+	assert.Equal(t, []string{"database.host", "database.options", "database.port", "image.tag", "ingress.className"}, result.Fields)
+}
+
+func TestGenerate_OnlyRequired(t *testing.T) {
+	result := generate(t, Options{Environment: "staging", OnlyRequired: true})
+
+	assert.Equal(t, `database:
+  host: db.example.com # TODO: set for staging
+  options: {} # TODO: set for staging
+  port: 5432 # TODO: set for staging
+image:
+  # Image tag to deploy
+  tag: "" # TODO: set for staging
+`, string(result.Content))
+}
+
+func TestGenerate_NothingRequired(t *testing.T) {
+	props := apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{"replicas": {Type: "integer"}},
+	}
+	result, err := Generate(&props, map[string]interface{}{"replicas": 1}, Options{Environment: "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(result.Content))
+	assert.Empty(t, result.Fields)
+}
+
+func TestGenerate_Secrets(t *testing.T) {
+	result := generate(t, Options{Environment: "prod", SecretPaths: map[string]bool{"database.host": true, "image.pullPolicy": true}})
+
+	// Secret example values are never copied into the overlay
+	assert.Contains(t, string(result.Content), "  host: \"\" # TODO: set for prod\n")
+	assert.Contains(t, string(result.Content), "  pullPolicy: \"\" # TODO: set for prod\n")
+	assert.NotContains(t, string(result.Content), "db.example.com")
+}
@@ -0,0 +1,135 @@
+// Package provenance stamps generated CRDs and JSON Schemas with the miaka
+// version and the hash of their input, so stale outputs can be detected
+// without regenerating them.
+package provenance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// CRD annotations holding the provenance of a generated CRD
+const (
+	VersionAnnotation     = "miaka.dev/version"
+	InputHashAnnotation   = "miaka.dev/input-sha256"
+	GeneratedAtAnnotation = "miaka.dev/generated-at"
+)
+
+// SchemaKeyword is the top-level JSON Schema keyword holding the provenance of
+// a generated schema. Validators ignore unknown keywords.
+const SchemaKeyword = "x-miaka-provenance"
+
+// Provenance records how an output was generated. Empty fields are left out.
+type Provenance struct {
+	// Version is the version of miaka that generated the output
+	Version string `json:"version,omitempty"`
+	// InputHash is the hex-encoded SHA-256 hash of the input file
+	InputHash string `json:"inputSHA256,omitempty"`
+	// GeneratedAt is the generation time in RFC 3339 format
+	GeneratedAt string `json:"generatedAt,omitempty"`
+}
+
+// New creates the provenance of outputs generated from input at the given time
+func New(version string, input []byte, now time.Time) Provenance {
+	return Provenance{
+		Version:     version,
+		InputHash:   Hash(input),
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+	}
+}
+
+// Hash returns the hex-encoded SHA-256 hash of an input file
+func Hash(input []byte) string {
+	sum := sha256.Sum256(input)
+	return hex.EncodeToString(sum[:])
+}
+
+// annotations returns the non-empty fields as CRD annotations
+func (p Provenance) annotations() map[string]string {
+	annotations := make(map[string]string)
+	for key, value := range map[string]string{
+		VersionAnnotation:     p.Version,
+		InputHashAnnotation:   p.InputHash,
+		GeneratedAtAnnotation: p.GeneratedAt,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// StampCRD adds the provenance to the annotations of a CRD
+func StampCRD(data []byte, p Provenance) ([]byte, error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		return nil, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	if crd.Annotations == nil {
+		crd.Annotations = make(map[string]string)
+	}
+	for key, value := range p.annotations() {
+		crd.Annotations[key] = value
+	}
+
+	output, err := yaml.Marshal(&crd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CRD: %w", err)
+	}
+	return output, nil
+}
+
+// StampSchema adds the provenance to a JSON Schema as SchemaKeyword
+func StampSchema(data []byte, p Provenance) ([]byte, error) {
+	// Numbers are kept as written, rather than converted to float64
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var schema map[string]interface{}
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	schema[SchemaKeyword] = p
+
+	output, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return output, nil
+}
+
+// ReadCRD returns the provenance of a CRD. ok is false if it has none.
+func ReadCRD(data []byte) (p Provenance, ok bool, err error) {
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		return Provenance{}, false, fmt.Errorf("failed to parse CRD: %w", err)
+	}
+
+	p = Provenance{
+		Version:     crd.Annotations[VersionAnnotation],
+		InputHash:   crd.Annotations[InputHashAnnotation],
+		GeneratedAt: crd.Annotations[GeneratedAtAnnotation],
+	}
+	return p, p != Provenance{}, nil
+}
+
+// ReadSchema returns the provenance of a JSON Schema. ok is false if it has none.
+func ReadSchema(data []byte) (p Provenance, ok bool, err error) {
+	var schema struct {
+		Provenance *Provenance `json:"x-miaka-provenance"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return Provenance{}, false, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	if schema.Provenance == nil {
+		return Provenance{}, false, nil
+	}
+	return *schema.Provenance, true, nil
+}
@@ -0,0 +1,97 @@
+package provenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.19.0
+  name: examples.example.com
+spec:
+  group: example.com
+  names:
+    kind: Example
+    plural: examples
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+`
+
+func TestNew(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	p := New("v1.2.3", []byte("replicas: 1\n"), now)
+
+	assert.Equal(t, "v1.2.3", p.Version)
+	assert.Equal(t, Hash([]byte("replicas: 1\n")), p.InputHash)
+	assert.Len(t, p.InputHash, 64)
+	assert.Equal(t, "2025-01-02T02:04:05Z", p.GeneratedAt)
+	assert.NotEqual(t, Hash([]byte("replicas: 2\n")), p.InputHash)
+}
+
+func TestStampCRD(t *testing.T) {
+	p := Provenance{Version: "v1.2.3", InputHash: "abc", GeneratedAt: "2025-01-02T03:04:05Z"}
+	stamped, err := StampCRD([]byte(testCRD), p)
+	require.NoError(t, err)
+	assert.Contains(t, string(stamped), "controller-gen.kubebuilder.io/version: v0.19.0")
+	assert.Contains(t, string(stamped), "miaka.dev/input-sha256: abc")
+
+	read, ok, err := ReadCRD(stamped)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, p, read)
+
+	// Empty fields aren't stamped
+	stamped, err = StampCRD([]byte(testCRD), Provenance{InputHash: "abc"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(stamped), VersionAnnotation)
+	assert.NotContains(t, string(stamped), GeneratedAtAnnotation)
+
+	_, ok, err = ReadCRD([]byte(testCRD))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = StampCRD([]byte("not: [valid"), p)
+	assert.ErrorContains(t, err, "failed to parse CRD")
+}
+
+func TestStampSchema(t *testing.T) {
+	schema := `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "replicas": {
+      "maximum": 9007199254740993,
+      "type": "integer"
+    }
+  },
+  "type": "object"
+}`
+	p := Provenance{InputHash: "abc"}
+	stamped, err := StampSchema([]byte(schema), p)
+	require.NoError(t, err)
+	assert.Equal(t, schema[:len(schema)-2]+`,
+  "x-miaka-provenance": {
+    "inputSHA256": "abc"
+  }
+}`, string(stamped))
+
+	read, ok, err := ReadSchema(stamped)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, p, read)
+
+	_, ok, err = ReadSchema([]byte(schema))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = StampSchema([]byte("{"), p)
+	assert.ErrorContains(t, err, "failed to parse JSON Schema")
+}
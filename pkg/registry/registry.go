@@ -0,0 +1,226 @@
+// Package registry hosts the schemas of many CRDs, keyed by group, kind, and
+// version, and serves them over HTTP as a lightweight schema registry.
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/generation/jsonschema"
+	"github.com/crenshaw-dev/miaka/pkg/webhook"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// Key identifies a schema in the registry
+type Key struct {
+	Group   string `json:"group"`
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+func (k Key) String() string {
+	return k.Group + "/" + k.Kind + "/" + k.Version
+}
+
+// APIVersion returns the apiVersion of resources with the schema (e.g., example.com/v1)
+func (k Key) APIVersion() string {
+	return k.Group + "/" + k.Version
+}
+
+// Entry is the schema of one version of a kind
+type Entry struct {
+	Key
+	// Source is the file the CRD was loaded from
+	Source string
+	// CRD is the CRD the schema came from, with only this version
+	CRD *apiextensionsv1.CustomResourceDefinition
+
+	validator *webhook.Validator
+}
+
+// Schema returns the OpenAPI v3 schema of the version
+func (e *Entry) Schema() *apiextensionsv1.JSONSchemaProps {
+	return e.CRD.Spec.Versions[0].Schema.OpenAPIV3Schema
+}
+
+// JSONSchema returns the schema converted to JSON Schema, as in values.schema.json
+func (e *Entry) JSONSchema() ([]byte, error) {
+	return jsonschema.GenerateFromSchema(e.Schema())
+}
+
+// Validate checks values against the schema, rejecting fields it doesn't declare
+// like the webhook does. apiVersion and kind default to the entry's, and must match
+// it if set. err is only set if the values can't be validated.
+func (e *Entry) Validate(values map[string]interface{}) ([]webhook.Violation, error) {
+	obj := make(map[string]interface{}, len(values)+2)
+	for key, value := range values {
+		obj[key] = value
+	}
+	for field, expected := range map[string]string{"apiVersion": e.APIVersion(), "kind": e.Kind} {
+		value, ok := obj[field]
+		if !ok {
+			obj[field] = expected
+			continue
+		}
+		if value != expected {
+			return nil, fmt.Errorf("%s %v doesn't match %s", field, value, expected)
+		}
+	}
+	return e.validator.Violations(schema.GroupKind{Group: e.Group, Kind: e.Kind}, obj)
+}
+
+// Registry is a set of schemas keyed by group, kind, and version
+type Registry struct {
+	entries map[Key]*Entry
+}
+
+// New creates an empty registry
+func New() *Registry {
+	return &Registry{entries: make(map[Key]*Entry)}
+}
+
+// LoadDir creates a registry from the CRDs in the YAML and JSON files under dir.
+// Files may contain several documents; documents that aren't CRDs are skipped.
+func LoadDir(dir string) (*Registry, error) {
+	r := New()
+	if err := r.AddDir(dir); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// AddDir adds the CRDs in the YAML and JSON files under dir
+func (r *Registry) AddDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			return r.AddFile(path)
+		}
+		return nil
+	})
+}
+
+// AddFile adds the CRDs in a YAML or JSON file
+func (r *Registry) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		// Decode generically first, so documents that aren't CRDs can have any shape
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if doc["kind"] != "CustomResourceDefinition" {
+			continue
+		}
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := convert(doc, &crd); err != nil {
+			return fmt.Errorf("failed to parse CRD in %s: %w", path, err)
+		}
+		if err := r.AddCRD(&crd, path); err != nil {
+			return err
+		}
+	}
+}
+
+// AddCRD adds each version of a CRD that has a schema. source names where the
+// CRD came from in errors.
+func (r *Registry) AddCRD(crd *apiextensionsv1.CustomResourceDefinition, source string) error {
+	if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+		return fmt.Errorf("CRD %q in %s has no group or kind", crd.Name, source)
+	}
+	for _, crdVersion := range crd.Spec.Versions {
+		if crdVersion.Schema == nil || crdVersion.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		key := Key{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind, Version: crdVersion.Name}
+		if existing, ok := r.entries[key]; ok {
+			return fmt.Errorf("duplicate schema for %s in %s and %s", key, existing.Source, source)
+		}
+
+		versionCRD := crd.DeepCopy()
+		versionCRD.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{*crdVersion.DeepCopy()}
+		validator := webhook.NewValidator()
+		if err := validator.AddCRD(versionCRD); err != nil {
+			return fmt.Errorf("failed to load %s: %w", source, err)
+		}
+		r.entries[key] = &Entry{Key: key, Source: source, CRD: versionCRD, validator: validator}
+	}
+	return nil
+}
+
+// Get returns the entry for a key
+func (r *Registry) Get(key Key) (*Entry, bool) {
+	entry, ok := r.entries[key]
+	return entry, ok
+}
+
+// Entries returns all entries, sorted by group and kind, then from the newest
+// version to the oldest (e.g., v2, v1, v1beta1)
+func (r *Registry) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Versions returns the entries of a kind, from the newest version to the oldest
+func (r *Registry) Versions(group, kind string) []*Entry {
+	var entries []*Entry
+	for key, entry := range r.entries {
+		if key.Group == group && key.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// convert decodes a generic document into a typed object
+func convert(doc map[string]interface{}, out interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// sortEntries sorts entries by group and kind, then by Kubernetes version priority
+func sortEntries(entries []*Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].Key, entries[j].Key
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return version.CompareKubeAwareVersionStrings(a.Version, b.Version) > 0
+	})
+}
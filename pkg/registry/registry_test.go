@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// widgetCRD has two versions: v1alpha1 with a string size, and v1, which adds
+// a replicas field and makes size an integer
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          size:
+            type: string
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          size:
+            type: integer
+          replicas:
+            type: integer
+            minimum: 1
+`
+
+const gadgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gadget
+    plural: gadgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          name:
+            type: string
+`
+
+// writeFiles writes files into a temp directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"widget.yaml": widgetCRD,
+		// Several documents, one of which isn't a CRD
+		"nested/all.yml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\ndata:\n  a: b\n---\n" + gadgetCRD,
+		"README.md":      "not a CRD",
+	})
+
+	r, err := LoadDir(dir)
+	require.NoError(t, err)
+
+	var keys []string
+	for _, entry := range r.Entries() {
+		keys = append(keys, entry.Key.String())
+	}
+	assert.Equal(t, []string{"example.com/Gadget/v1", "example.com/Widget/v1", "example.com/Widget/v1alpha1"}, keys)
+
+	entry, ok := r.Get(Key{Group: "example.com", Kind: "Widget", Version: "v1alpha1"})
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "widget.yaml"), entry.Source)
+	assert.Equal(t, "example.com/v1alpha1", entry.APIVersion())
+	require.Len(t, entry.CRD.Spec.Versions, 1)
+	assert.Equal(t, "string", entry.Schema().Properties["size"].Type)
+
+	_, ok = r.Get(Key{Group: "example.com", Kind: "Widget", Version: "v2"})
+	assert.False(t, ok)
+}
+
+func TestLoadDir_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name:  "duplicate",
+			files: map[string]string{"a.yaml": widgetCRD, "b.yaml": widgetCRD},
+			err:   "duplicate schema for example.com/Widget/v1",
+		},
+		{
+			name:  "invalid YAML",
+			files: map[string]string{"a.yaml": "kind: [unclosed"},
+			err:   "failed to parse",
+		},
+		{
+			name:  "no group",
+			files: map[string]string{"a.yaml": "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: x\n"},
+			err:   "has no group or kind",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDir(writeFiles(t, tt.files))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	r, err := LoadDir(writeFiles(t, map[string]string{"widget.yaml": widgetCRD}))
+	require.NoError(t, err)
+
+	entries := r.Versions("example.com", "Widget")
+	require.Len(t, entries, 2)
+	assert.Equal(t, "v1", entries[0].Version)
+	assert.Equal(t, "v1alpha1", entries[1].Version)
+
+	assert.Empty(t, r.Versions("example.com", "Other"))
+}
+
+func TestEntry_Validate(t *testing.T) {
+	r, err := LoadDir(writeFiles(t, map[string]string{"widget.yaml": widgetCRD}))
+	require.NoError(t, err)
+	entry, ok := r.Get(Key{Group: "example.com", Kind: "Widget", Version: "v1"})
+	require.True(t, ok)
+
+	violations, err := entry.Validate(map[string]interface{}{"size": int64(3), "replicas": int64(2)})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = entry.Validate(map[string]interface{}{"replicas": int64(0), "colour": "red"})
+	require.NoError(t, err)
+	var fields []string
+	for _, violation := range violations {
+		fields = append(fields, violation.Field)
+	}
+	assert.ElementsMatch(t, []string{"replicas", "colour"}, fields)
+
+	_, err = entry.Validate(map[string]interface{}{"apiVersion": "example.com/v1alpha1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't match example.com/v1")
+}
+
+func TestEntry_JSONSchema(t *testing.T) {
+	r, err := LoadDir(writeFiles(t, map[string]string{"widget.yaml": widgetCRD}))
+	require.NoError(t, err)
+	entry, ok := r.Get(Key{Group: "example.com", Kind: "Widget", Version: "v1"})
+	require.True(t, ok)
+
+	schemaJSON, err := entry.JSONSchema()
+	require.NoError(t, err)
+	assert.Contains(t, string(schemaJSON), `"$schema"`)
+	assert.Contains(t, string(schemaJSON), `"minimum": 1`)
+}
@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/crenshaw-dev/miaka/pkg/upgrade"
+	"github.com/crenshaw-dev/miaka/pkg/webhook"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// SchemasPath is the path schemas are served under:
+//
+//	GET  /schemas                                   list all schemas
+//	GET  /schemas/{group}/{kind}                    list the versions of a kind
+//	GET  /schemas/{group}/{kind}/{version}          fetch a schema (?format=openapi, jsonschema, or crd)
+//	POST /schemas/{group}/{kind}/{version}/validate validate YAML or JSON values
+//	GET  /schemas/{group}/{kind}/diff?from=&to=     diff two versions
+const SchemasPath = "/schemas"
+
+// Formats a schema can be fetched in
+const (
+	FormatOpenAPI    = "openapi"
+	FormatJSONSchema = "jsonschema"
+	FormatCRD        = "crd"
+)
+
+// maxRequestBytes limits the size of values to validate
+const maxRequestBytes = 3 * 1024 * 1024
+
+// SchemaInfo describes a schema in listings
+type SchemaInfo struct {
+	Key
+	// URL is the path to fetch the schema from
+	URL string `json:"url"`
+}
+
+// ValidationResult is the response to a validation request
+type ValidationResult struct {
+	Valid      bool                `json:"valid"`
+	Violations []webhook.Violation `json:"violations"`
+}
+
+// DiffResult is the response to a diff request
+type DiffResult struct {
+	From    string                 `json:"from"`
+	To      string                 `json:"to"`
+	Changes []upgrade.SchemaChange `json:"changes"`
+	// Breaking is set if any change can invalidate values that were valid for From
+	Breaking bool `json:"breaking"`
+}
+
+// HandlerOptions configure the registry handler
+type HandlerOptions struct {
+	// Metrics records each validation, if set
+	Metrics *metrics.Metrics
+}
+
+// NewHandler returns an HTTP handler serving the schemas of a registry under
+// SchemasPath, and health checks on webhook.HealthzPath
+func NewHandler(r *Registry, opts HandlerOptions) http.Handler {
+	s := &server{registry: r, metrics: opts.Metrics}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+SchemasPath, s.serveList)
+	mux.HandleFunc("GET "+SchemasPath+"/{group}/{kind}", s.serveVersions)
+	mux.HandleFunc("GET "+SchemasPath+"/{group}/{kind}/diff", s.serveDiff)
+	mux.HandleFunc("GET "+SchemasPath+"/{group}/{kind}/{version}", s.serveSchema)
+	mux.HandleFunc("POST "+SchemasPath+"/{group}/{kind}/{version}/validate", s.serveValidate)
+	mux.HandleFunc("GET "+webhook.HealthzPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = io.WriteString(w, "ok")
+	})
+	return mux
+}
+
+// server serves the schemas of a registry
+type server struct {
+	registry *Registry
+	metrics  *metrics.Metrics
+}
+
+// serveList lists all schemas
+func (s *server) serveList(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, schemaInfos(s.registry.Entries()))
+}
+
+// serveVersions lists the versions of a kind
+func (s *server) serveVersions(w http.ResponseWriter, req *http.Request) {
+	entries := s.registry.Versions(req.PathValue("group"), req.PathValue("kind"))
+	if len(entries) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no schemas for %s/%s", req.PathValue("group"), req.PathValue("kind")))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemaInfos(entries))
+}
+
+// serveSchema writes a schema in the requested format
+func (s *server) serveSchema(w http.ResponseWriter, req *http.Request) {
+	entry, ok := s.entry(w, req, req.PathValue("version"))
+	if !ok {
+		return
+	}
+
+	switch format := req.URL.Query().Get("format"); format {
+	case "", FormatOpenAPI:
+		writeJSON(w, http.StatusOK, entry.Schema())
+	case FormatJSONSchema:
+		schemaJSON, err := entry.JSONSchema()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		_, _ = w.Write(schemaJSON)
+	case FormatCRD:
+		crdYAML, err := yaml.Marshal(entry.CRD)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal CRD: %v", err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(crdYAML)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q (expected %s, %s, or %s)", format, FormatOpenAPI, FormatJSONSchema, FormatCRD))
+	}
+}
+
+// serveValidate validates the YAML or JSON values in the request body
+func (s *server) serveValidate(w http.ResponseWriter, req *http.Request) {
+	entry, ok := s.entry(w, req, req.PathValue("version"))
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read values: %v", err))
+		return
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse values: %v", err))
+		return
+	}
+
+	start := time.Now()
+	violations, err := entry.Validate(values)
+	result := metrics.ResultValid
+	switch {
+	case err != nil:
+		result = metrics.ResultError
+	case len(violations) > 0:
+		result = metrics.ResultInvalid
+	}
+	s.metrics.Observe(schema.GroupKind{Group: entry.Group, Kind: entry.Kind}.String(), result, time.Since(start))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if violations == nil {
+		violations = []webhook.Violation{}
+	}
+	writeJSON(w, http.StatusOK, ValidationResult{Valid: len(violations) == 0, Violations: violations})
+}
+
+// serveDiff compares the JSON Schemas of two versions of a kind
+func (s *server) serveDiff(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if query.Get("from") == "" || query.Get("to") == "" {
+		writeError(w, http.StatusBadRequest, "both from and to versions are required")
+		return
+	}
+
+	var schemas [2][]byte
+	for i, v := range []string{query.Get("from"), query.Get("to")} {
+		entry, ok := s.entry(w, req, v)
+		if !ok {
+			return
+		}
+		schemaJSON, err := entry.JSONSchema()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		schemas[i] = schemaJSON
+	}
+
+	changes, err := upgrade.Diff(schemas[0], schemas[1])
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := DiffResult{From: query.Get("from"), To: query.Get("to"), Changes: changes}
+	if result.Changes == nil {
+		result.Changes = []upgrade.SchemaChange{}
+	}
+	for _, change := range changes {
+		result.Breaking = result.Breaking || change.IsBreaking()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// entry finds the entry for a version of the kind in the request path, writing
+// a not found error if there is none
+func (s *server) entry(w http.ResponseWriter, req *http.Request, version string) (*Entry, bool) {
+	key := Key{Group: req.PathValue("group"), Kind: req.PathValue("kind"), Version: version}
+	entry, ok := s.registry.Get(key)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no schema for %s", key))
+	}
+	return entry, ok
+}
+
+// schemaInfos describes entries for listings
+func schemaInfos(entries []*Entry) []SchemaInfo {
+	infos := make([]SchemaInfo, 0, len(entries))
+	for _, entry := range entries {
+		infos = append(infos, SchemaInfo{Key: entry.Key, URL: SchemasPath + "/" + entry.Key.String()})
+	}
+	return infos
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+// writeError writes an error as a JSON object with an "error" field
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
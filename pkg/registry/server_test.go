@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve sends a request to a handler for a registry of the test CRDs
+func serve(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r, err := LoadDir(writeFiles(t, map[string]string{"widget.yaml": widgetCRD, "gadget.yaml": gadgetCRD}))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	NewHandler(r, HandlerOptions{}).ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+// decode decodes a JSON response
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+}
+
+func TestHandler_List(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/schemas", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var infos []SchemaInfo
+	decode(t, rec, &infos)
+	require.Len(t, infos, 3)
+	assert.Equal(t, SchemaInfo{
+		Key: Key{Group: "example.com", Kind: "Gadget", Version: "v1"},
+		URL: "/schemas/example.com/Gadget/v1",
+	}, infos[0])
+}
+
+func TestHandler_Versions(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/schemas/example.com/Widget", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var infos []SchemaInfo
+	decode(t, rec, &infos)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "v1", infos[0].Version)
+	assert.Equal(t, "v1alpha1", infos[1].Version)
+
+	rec = serve(t, http.MethodGet, "/schemas/example.com/Other", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no schemas for example.com/Other")
+}
+
+func TestHandler_Schema(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{name: "openapi", target: "/schemas/example.com/Widget/v1", code: http.StatusOK, contentType: "application/json", contains: `"replicas"`},
+		{name: "jsonschema", target: "/schemas/example.com/Widget/v1?format=jsonschema", code: http.StatusOK, contentType: "application/schema+json", contains: `"$schema"`},
+		{name: "crd", target: "/schemas/example.com/Widget/v1alpha1?format=crd", code: http.StatusOK, contentType: "application/yaml", contains: "name: v1alpha1"},
+		{name: "unknown format", target: "/schemas/example.com/Widget/v1?format=xml", code: http.StatusBadRequest, contentType: "application/json", contains: `unknown format \"xml\"`},
+		{name: "unknown version", target: "/schemas/example.com/Widget/v2", code: http.StatusNotFound, contentType: "application/json", contains: "no schema for example.com/Widget/v2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, http.MethodGet, tt.target, "")
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+
+	// The CRD only has the requested version
+	rec := serve(t, http.MethodGet, "/schemas/example.com/Widget/v1alpha1?format=crd", "")
+	assert.NotContains(t, rec.Body.String(), "name: v1\n")
+}
+
+func TestHandler_Validate(t *testing.T) {
+	rec := serve(t, http.MethodPost, "/schemas/example.com/Widget/v1/validate", "size: 3\nreplicas: 2\n")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var result ValidationResult
+	decode(t, rec, &result)
+	assert.True(t, result.Valid)
+	assert.NotNil(t, result.Violations)
+	assert.Empty(t, result.Violations)
+
+	// JSON works too, and v1alpha1 has a different schema
+	rec = serve(t, http.MethodPost, "/schemas/example.com/Widget/v1alpha1/validate", `{"size": 3}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	result = ValidationResult{}
+	decode(t, rec, &result)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "size", result.Violations[0].Field)
+	assert.Equal(t, "FieldValueTypeInvalid", result.Violations[0].Rule)
+}
+
+func TestHandler_ValidateMetrics(t *testing.T) {
+	r, err := LoadDir(writeFiles(t, map[string]string{"widget.yaml": widgetCRD}))
+	require.NoError(t, err)
+	m := metrics.New("registry")
+	handler := NewHandler(r, HandlerOptions{Metrics: m})
+
+	for _, body := range []string{"size: 3\nreplicas: 2\n", "size: three\n", "kind: Gadget\n"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/schemas/example.com/Widget/v1/validate", strings.NewReader(body)))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+	output := rec.Body.String()
+	assert.Contains(t, output, `miaka_registry_validations_total{kind="Widget.example.com",result="valid"} 1`)
+	assert.Contains(t, output, `miaka_registry_validations_total{kind="Widget.example.com",result="invalid"} 1`)
+	assert.Contains(t, output, `miaka_registry_validations_total{kind="Widget.example.com",result="error"} 1`)
+	assert.Contains(t, output, `miaka_registry_validation_duration_seconds_count{kind="Widget.example.com"} 3`)
+}
+
+func TestHandler_Validate_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		code   int
+		err    string
+	}{
+		{name: "invalid YAML", method: http.MethodPost, target: "/schemas/example.com/Widget/v1/validate", body: "size: [", code: http.StatusBadRequest, err: "failed to parse values"},
+		{name: "wrong kind", method: http.MethodPost, target: "/schemas/example.com/Widget/v1/validate", body: "kind: Gadget", code: http.StatusBadRequest, err: "kind Gadget doesn't match Widget"},
+		{name: "unknown version", method: http.MethodPost, target: "/schemas/example.com/Widget/v2/validate", body: "{}", code: http.StatusNotFound, err: "no schema"},
+		{name: "GET", method: http.MethodGet, target: "/schemas/example.com/Widget/v1/validate", code: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.err)
+		})
+	}
+}
+
+func TestHandler_Diff(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/schemas/example.com/Widget/diff?from=v1alpha1&to=v1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result DiffResult
+	decode(t, rec, &result)
+	assert.Equal(t, "v1alpha1", result.From)
+	assert.Equal(t, "v1", result.To)
+	assert.True(t, result.Breaking)
+	var changes []string
+	for _, change := range result.Changes {
+		changes = append(changes, change.String())
+	}
+	assert.Contains(t, strings.Join(changes, "\n"), "size")
+	assert.Contains(t, strings.Join(changes, "\n"), "replicas")
+
+	// A version has no changes from itself
+	rec = serve(t, http.MethodGet, "/schemas/example.com/Widget/diff?from=v1&to=v1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	result = DiffResult{}
+	decode(t, rec, &result)
+	assert.False(t, result.Breaking)
+	assert.Empty(t, result.Changes)
+}
+
+func TestHandler_Diff_Errors(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/schemas/example.com/Widget/diff?from=v1", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "both from and to versions are required")
+
+	rec = serve(t, http.MethodGet, "/schemas/example.com/Widget/diff?from=v1&to=v2", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no schema for example.com/Widget/v2")
+}
+
+func TestHandler_Healthz(t *testing.T) {
+	rec := serve(t, http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
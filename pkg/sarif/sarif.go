@@ -0,0 +1,134 @@
+// Package sarif writes findings in the Static Analysis Results Interchange
+// Format (SARIF) 2.1.0, which GitHub code scanning and other tools display
+// inline on pull requests.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+// Version and Schema identify the SARIF format of a Log
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Levels of a Result
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is a SARIF log: the results of runs of analysis tools
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a run of an analysis tool and its results
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool is the analysis tool of a run
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver describes the analysis tool and the rules its results refer to
+type Driver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules,omitempty"`
+}
+
+// Rule is a kind of finding (e.g., a failed schema validation)
+type Rule struct {
+	ID               string   `json:"id"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+}
+
+// Result is a finding of a rule, located in the files it's about
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Message is the text of a result or rule
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location is where a result was found
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a position in a file
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the URI of a file, relative to the repository root for
+// GitHub code scanning
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a position in a file. Lines and columns are 1-based.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// NewLog creates a log with a single run of a tool
+func NewLog(driver Driver) *Log {
+	return &Log{Version: Version, Schema: Schema, Runs: []Run{{Tool: Tool{Driver: driver}, Results: []Result{}}}}
+}
+
+// Add adds a result of a rule to the run, adding the rule to the tool's rules
+// the first time it's used. The result is located in file if it's set, at line
+// and column if line is set.
+func (l *Log) Add(ruleID, level, message, file string, line, column int) {
+	run := &l.Runs[0]
+	if !hasRule(run.Tool.Driver.Rules, ruleID) {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, Rule{ID: ruleID})
+	}
+
+	result := Result{RuleID: ruleID, Level: level, Message: Message{Text: message}}
+	if file != "" {
+		location := PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: filepath.ToSlash(file)}}
+		if line > 0 {
+			location.Region = &Region{StartLine: line, StartColumn: column}
+		}
+		result.Locations = []Location{{PhysicalLocation: location}}
+	}
+	run.Results = append(run.Results, result)
+}
+
+// Write writes the log as indented JSON
+func (l *Log) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(l)
+}
+
+// hasRule reports whether rules has a rule with the ID
+func hasRule(rules []Rule, id string) bool {
+	for _, rule := range rules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
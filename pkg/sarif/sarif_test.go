@@ -0,0 +1,46 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	log := NewLog(Driver{Name: "miaka", Version: "1.0.0"})
+	log.Add("schema-validation", LevelError, "replicas: must be >= 1", "values.yaml", 3, 5)
+	log.Add("schema-validation", LevelError, "image: required", "values.yaml", 0, 0)
+	log.Add("deprecated-field", LevelWarning, "repo is deprecated", "", 0, 0)
+
+	run := log.Runs[0]
+	assert.Equal(t, []Rule{{ID: "schema-validation"}, {ID: "deprecated-field"}}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, Result{
+		RuleID:  "schema-validation",
+		Level:   LevelError,
+		Message: Message{Text: "replicas: must be >= 1"},
+		Locations: []Location{{PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: "values.yaml"},
+			Region:           &Region{StartLine: 3, StartColumn: 5},
+		}}},
+	}, run.Results[0])
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Empty(t, run.Results[2].Locations)
+}
+
+func TestLog_Write(t *testing.T) {
+	log := NewLog(Driver{Name: "miaka"})
+
+	var buf bytes.Buffer
+	require.NoError(t, log.Write(&buf))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "2.1.0", decoded["version"])
+	assert.Equal(t, Schema, decoded["$schema"])
+	// Runs without findings have an empty list of results, not none
+	assert.Equal(t, []interface{}{}, decoded["runs"].([]interface{})[0].(map[string]interface{})["results"])
+}
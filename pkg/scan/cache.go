@@ -0,0 +1,51 @@
+package scan
+
+import (
+	"strconv"
+
+	"github.com/crenshaw-dev/miaka/pkg/cache"
+	"github.com/crenshaw-dev/miaka/pkg/miaka"
+)
+
+// DefaultCacheDir is the cache of 'miaka hook', relative to the repository
+const DefaultCacheDir = ".miaka/cache"
+
+// Cache remembers the example values that built without problems, keyed by a
+// hash of the build's inputs, so checking projects that didn't change doesn't
+// build them again. A nil Cache remembers nothing.
+type Cache struct {
+	store *cache.Cache
+	// version identifies the miaka build, since another one can build differently
+	version string
+}
+
+// NewCache returns a cache in dir for the builds of a miaka version
+func NewCache(dir, version string) *Cache {
+	return &Cache{store: cache.New(dir), version: version}
+}
+
+// key hashes the inputs of a build: the miaka version, the example values, the
+// previous CRD, and the options that change the outputs
+func (c *Cache) key(opts miaka.BuildOptions) string {
+	if c == nil {
+		return ""
+	}
+	return cache.Key([]byte("scan"), []byte(c.version), opts.Input, opts.PreviousCRD, []byte(strconv.FormatBool(opts.Parsing.Plain)))
+}
+
+// built reports whether the build with key passed before
+func (c *Cache) built(key string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.store.Get(key)
+	return ok
+}
+
+// markBuilt records that the build with key passed
+func (c *Cache) markBuilt(key string) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.Put(key, nil)
+}
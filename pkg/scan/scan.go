@@ -0,0 +1,270 @@
+// Package scan finds the charts and example values files of a repository and
+// checks that each builds and validates.
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	"github.com/crenshaw-dev/miaka/pkg/miaka"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"sigs.k8s.io/yaml"
+)
+
+// Files a project is recognized and checked by
+const (
+	ChartFile         = "Chart.yaml"
+	ExampleValuesFile = "example.values.yaml"
+	ValuesFile        = "values.yaml"
+	CRDFile           = "crd.yaml"
+	SchemaFile        = "values.schema.json"
+)
+
+// Status is the outcome of checking a project
+type Status string
+
+// Statuses of projects
+const (
+	// StatusOK means every check of the project passed
+	StatusOK Status = "ok"
+	// StatusFailed means at least one check of the project failed
+	StatusFailed Status = "failed"
+	// StatusSkipped means the project has nothing to check: a chart without
+	// example values or a values.schema.json
+	StatusSkipped Status = "skipped"
+)
+
+// Project is a directory with a Chart.yaml or an example.values.yaml
+type Project struct {
+	// Dir is the directory of the project, relative to the scanned root
+	Dir string `json:"dir"`
+	// Chart is whether the directory has a Chart.yaml
+	Chart bool `json:"chart"`
+	// Example is whether the directory has an example.values.yaml
+	Example bool `json:"example"`
+
+	// root is the scanned directory
+	root string
+}
+
+// Result is the outcome of checking a project
+type Result struct {
+	Project
+	Status Status `json:"status"`
+	// Passed lists the checks that passed (e.g., "built")
+	Passed []string `json:"passed,omitempty"`
+	// Problems lists the checks that failed, and Notes what wasn't checked
+	Problems []string `json:"problems,omitempty"`
+	Notes    []string `json:"notes,omitempty"`
+}
+
+// Find returns the projects under root in lexical order, skipping hidden
+// directories
+func Find(root string) ([]Project, error) {
+	var projects []Project
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		project := Project{root: root, Chart: exists(filepath.Join(path, ChartFile)), Example: exists(filepath.Join(path, ExampleValuesFile))}
+		if !project.Chart && !project.Example {
+			return nil
+		}
+		if project.Dir, err = filepath.Rel(root, path); err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		projects = append(projects, project)
+		return nil
+	})
+	return projects, err
+}
+
+// CheckOptions are the options of CheckWithOptions
+type CheckOptions struct {
+	// Cache skips building example values that built before (nil builds them)
+	Cache *Cache
+	// Values are more values files of the project to validate against its
+	// values.schema.json (e.g., the ones a commit changes), relative to its
+	// directory
+	Values []string
+}
+
+// Check builds the example values of a project in memory, checks that its
+// generated CRD and JSON Schema are up to date, and validates the values.yaml
+// of a chart against its values.schema.json. Example values without apiVersion
+// or kind are built as plain values.
+func Check(ctx context.Context, project Project) Result {
+	return CheckWithOptions(ctx, project, CheckOptions{})
+}
+
+// CheckWithOptions is like Check, with options
+func CheckWithOptions(ctx context.Context, project Project, opts CheckOptions) Result {
+	result := Result{Project: project}
+	dir := filepath.Join(project.root, project.Dir)
+	if project.Example {
+		result.checkBuild(ctx, dir, opts.Cache)
+	}
+	var valuesFiles []string
+	if project.Chart {
+		valuesFiles = append(valuesFiles, ValuesFile)
+	}
+	for _, file := range opts.Values {
+		if !slices.Contains(valuesFiles, file) {
+			valuesFiles = append(valuesFiles, file)
+		}
+	}
+	if len(valuesFiles) > 0 {
+		result.checkValues(dir, valuesFiles)
+	}
+
+	switch {
+	case len(result.Problems) > 0:
+		result.Status = StatusFailed
+	case len(result.Passed) == 0:
+		result.Status = StatusSkipped
+	default:
+		result.Status = StatusOK
+	}
+	return result
+}
+
+// checkBuild builds the example values, unless the cache has their build, and
+// checks the outputs on disk
+func (r *Result) checkBuild(ctx context.Context, dir string, cache *Cache) {
+	input, err := os.ReadFile(filepath.Join(dir, ExampleValuesFile))
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("failed to read %s: %v", ExampleValuesFile, err))
+		return
+	}
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	plain := yaml.Unmarshal(input, &header) == nil && header.APIVersion == "" && header.Kind == ""
+
+	opts := miaka.BuildOptions{Input: input, InputName: ExampleValuesFile, Parsing: parsing.Options{Plain: plain}}
+	if !plain {
+		// Like 'miaka build', changes that break the existing CRD fail the build
+		if previous, err := os.ReadFile(filepath.Join(dir, CRDFile)); err == nil {
+			opts.PreviousCRD = previous
+		}
+	}
+	key := cache.key(opts)
+	if !cache.built(key) {
+		if _, err := miaka.Build(ctx, opts); err != nil {
+			r.Problems = append(r.Problems, "build failed: "+err.Error())
+			return
+		}
+		if err := cache.markBuilt(key); err != nil {
+			r.Notes = append(r.Notes, err.Error())
+		}
+	}
+	r.Passed = append(r.Passed, "built")
+
+	outputs := []struct {
+		file string
+		read func([]byte) (provenance.Provenance, bool, error)
+	}{{CRDFile, provenance.ReadCRD}, {SchemaFile, provenance.ReadSchema}}
+	if plain {
+		outputs = outputs[1:]
+	}
+	hash := provenance.Hash(input)
+	upToDate := true
+	for _, output := range outputs {
+		data, err := os.ReadFile(filepath.Join(dir, output.file))
+		if errors.Is(err, fs.ErrNotExist) {
+			r.Problems = append(r.Problems, output.file+" doesn't exist (run 'miaka build')")
+			upToDate = false
+			continue
+		}
+		if err != nil {
+			r.Problems = append(r.Problems, fmt.Sprintf("failed to read %s: %v", output.file, err))
+			upToDate = false
+			continue
+		}
+		p, ok, err := output.read(data)
+		switch {
+		case err != nil:
+			r.Problems = append(r.Problems, fmt.Sprintf("%s: %v", output.file, err))
+			upToDate = false
+		case !ok || p.InputHash == "":
+			r.Notes = append(r.Notes, output.file+" has no provenance to check")
+		case p.InputHash != hash:
+			r.Problems = append(r.Problems, output.file+" is stale (run 'miaka build')")
+			upToDate = false
+		}
+	}
+	if upToDate {
+		r.Passed = append(r.Passed, "outputs up to date")
+	}
+}
+
+// checkValues validates values files of the project (the values.yaml of a
+// chart) against its values.schema.json
+func (r *Result) checkValues(dir string, files []string) {
+	schemaJSON, err := os.ReadFile(filepath.Join(dir, SchemaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		if !r.Example {
+			r.Notes = append(r.Notes, "no "+ExampleValuesFile+" or "+SchemaFile)
+		}
+		return
+	}
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("failed to read %s: %v", SchemaFile, err))
+		return
+	}
+	for _, file := range files {
+		r.checkValuesFile(dir, file, schemaJSON)
+	}
+}
+
+// checkValuesFile validates a values file against the JSON Schema
+func (r *Result) checkValuesFile(dir, file string, schemaJSON []byte) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Notes = append(r.Notes, "no "+file)
+		return
+	}
+	if err != nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("failed to read %s: %v", file, err))
+		return
+	}
+
+	source, err := validation.ParseSource(file, data)
+	if err != nil {
+		r.Problems = append(r.Problems, err.Error())
+		return
+	}
+	problems, err := validation.SchemaProblems(source.Values, schemaJSON, validation.Sources{source})
+	switch {
+	case err != nil:
+		r.Problems = append(r.Problems, fmt.Sprintf("%s: %v", SchemaFile, err))
+	case len(problems) > 0:
+		for _, problem := range problems {
+			r.Problems = append(r.Problems, problem.String())
+		}
+	default:
+		r.Passed = append(r.Passed, file+" valid")
+	}
+}
+
+// exists reports whether a file exists
+func exists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
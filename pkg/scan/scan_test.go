@@ -0,0 +1,161 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/miaka"
+	"github.com/crenshaw-dev/miaka/pkg/provenance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleValues = `apiVersion: example.com/v1
+kind: App
+# +kubebuilder:validation:Minimum=1
+replicas: 2
+`
+
+// writeFiles writes files under dir, creating their directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// build writes the stamped CRD and JSON Schema of example values to dir
+func build(t *testing.T, dir, input string, plain bool) {
+	t.Helper()
+	stamp := provenance.New("test", []byte(input), time.Now())
+	opts := miaka.BuildOptions{Input: []byte(input), Provenance: &stamp}
+	opts.Parsing.Plain = plain
+	result, err := miaka.Build(context.Background(), opts)
+	require.NoError(t, err)
+	if !plain {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, CRDFile), result.CRDYAML, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SchemaFile), result.JSONSchema, 0644))
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		ChartFile:                             "name: umbrella\n",
+		"charts/api/" + ChartFile:             "name: api\n",
+		"charts/api/" + ExampleValuesFile:     exampleValues,
+		"services/web/" + ExampleValuesFile:   "port: 80\n",
+		"services/docs/README.md":             "not a project\n",
+		".git/hooks/" + ExampleValuesFile:     exampleValues,
+		"charts/" + ExampleValuesFile + "/ok": "a directory, not example values\n",
+	})
+
+	projects, err := Find(root)
+	require.NoError(t, err)
+	var found []Project
+	for _, project := range projects {
+		project.root = ""
+		found = append(found, project)
+	}
+	assert.Equal(t, []Project{
+		{Dir: ".", Chart: true},
+		{Dir: filepath.Join("charts", "api"), Chart: true, Example: true},
+		{Dir: filepath.Join("services", "web"), Example: true},
+	}, found)
+
+	_, err = Find(filepath.Join(root, "missing"))
+	assert.ErrorContains(t, err, "failed to read")
+}
+
+func TestCheck(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"api/" + ChartFile:           "name: api\n",
+		"api/" + ExampleValuesFile:   exampleValues,
+		"api/" + ValuesFile:          "replicas: 3\n",
+		"plain/" + ExampleValuesFile: "port: 80\n",
+		"legacy/" + ChartFile:        "name: legacy\n",
+	})
+	build(t, filepath.Join(root, "api"), exampleValues, false)
+	build(t, filepath.Join(root, "plain"), "port: 80\n", true)
+
+	check := func(dir string) Result {
+		t.Helper()
+		projects, err := Find(filepath.Join(root, dir))
+		require.NoError(t, err)
+		require.Len(t, projects, 1)
+		return Check(context.Background(), projects[0])
+	}
+
+	result := check("api")
+	assert.Equal(t, StatusOK, result.Status)
+	assert.Equal(t, []string{"built", "outputs up to date", "values.yaml valid"}, result.Passed)
+	assert.Empty(t, result.Problems)
+
+	result = check("plain")
+	assert.Equal(t, StatusOK, result.Status)
+	assert.Equal(t, []string{"built", "outputs up to date"}, result.Passed)
+
+	result = check("legacy")
+	assert.Equal(t, StatusSkipped, result.Status)
+	assert.Equal(t, []string{"no example.values.yaml or values.schema.json"}, result.Notes)
+
+	// Invalid chart values, and example values changed since the last build
+	writeFiles(t, root, map[string]string{
+		"api/" + ValuesFile:        "replicas: 0\n",
+		"api/" + ExampleValuesFile: exampleValues + "debug: false\n",
+	})
+	result = check("api")
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Equal(t, []string{
+		"crd.yaml is stale (run 'miaka build')",
+		"values.schema.json is stale (run 'miaka build')",
+		"values.yaml:1:1: replicas: minimum: got 0, want 1",
+	}, result.Problems)
+
+	// Example values that break their own markers don't build
+	writeFiles(t, root, map[string]string{"api/" + ExampleValuesFile: "apiVersion: example.com/v1\nkind: App\n# +kubebuilder:validation:Minimum=5\nreplicas: 2\n"})
+	result = check("api")
+	assert.Equal(t, StatusFailed, result.Status)
+	require.NotEmpty(t, result.Problems)
+	assert.Contains(t, result.Problems[0], "build failed: ")
+
+	require.NoError(t, os.Remove(filepath.Join(root, "plain", SchemaFile)))
+	result = check("plain")
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Equal(t, []string{"values.schema.json doesn't exist (run 'miaka build')"}, result.Problems)
+}
+
+func TestCheckWithOptions(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"api/" + ExampleValuesFile:  exampleValues,
+		"api/envs/values-prod.yaml": "replicas: 0\n",
+	})
+	build(t, filepath.Join(root, "api"), exampleValues, false)
+	projects, err := Find(root)
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+
+	cache := NewCache(filepath.Join(root, DefaultCacheDir), "test")
+	opts := CheckOptions{Cache: cache, Values: []string{filepath.Join("envs", "values-prod.yaml")}}
+	result := CheckWithOptions(context.Background(), projects[0], opts)
+	assert.Equal(t, StatusFailed, result.Status)
+	assert.Equal(t, []string{"built", "outputs up to date"}, result.Passed)
+	assert.Equal(t, []string{filepath.Join("envs", "values-prod.yaml") + ":1:1: replicas: minimum: got 0, want 1"}, result.Problems)
+
+	// The build is cached, so it isn't repeated
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result = CheckWithOptions(ctx, projects[0], opts)
+	assert.Contains(t, result.Passed, "built")
+
+	// Another miaka version builds again
+	result = CheckWithOptions(ctx, projects[0], CheckOptions{Cache: NewCache(filepath.Join(root, DefaultCacheDir), "other")})
+	assert.NotContains(t, result.Passed, "built")
+}
@@ -0,0 +1,173 @@
+// Package score measures the size and complexity of a values schema.
+package score
+
+import (
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Weights of the complexity score: every field adds 1, every level of nesting
+// adds DepthWeight, and every untyped escape hatch adds UntypedWeight
+const (
+	DepthWeight   = 2
+	UntypedWeight = 10
+)
+
+// skippedFields are the root fields every resource has, which don't add complexity
+var skippedFields = map[string]bool{"apiVersion": true, "kind": true, "metadata": true}
+
+// Metrics are the complexity measures of a schema or one of its sections
+type Metrics struct {
+	// Complexity is Fields + DepthWeight*MaxDepth + UntypedWeight*Untyped
+	Complexity int `json:"complexity"`
+	Fields     int `json:"fields"`
+	MaxDepth   int `json:"maxDepth"`
+	// Untyped counts escape hatches: fields without a type, objects without
+	// properties, and objects that preserve unknown fields
+	Untyped int `json:"untyped"`
+	// Validatable counts string and number fields; Validated counts those with
+	// at least one validation (enum, pattern, format, bounds, CEL rule, etc.)
+	Validatable int `json:"validatable"`
+	Validated   int `json:"validated"`
+	// Coverage is the percentage of validatable fields that are validated
+	Coverage float64 `json:"coverage"`
+}
+
+// Section is the metrics of one top-level field
+type Section struct {
+	Name string `json:"name"`
+	Metrics
+}
+
+// Report is the score of a schema and each of its top-level fields
+type Report struct {
+	Metrics
+	Sections     []Section `json:"sections"`
+	UntypedPaths []string  `json:"untypedPaths,omitempty"`
+}
+
+// Analyze scores the OpenAPI v3 schema of a CRD version
+func Analyze(root *apiextensionsv1.JSONSchemaProps) *Report {
+	report := &Report{Sections: []Section{}}
+
+	names := make([]string, 0, len(root.Properties))
+	for name := range root.Properties {
+		if !skippedFields[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := root.Properties[name]
+		var section Metrics
+		walk(&prop, name, 1, &section, &report.UntypedPaths)
+		section.finish()
+		report.Sections = append(report.Sections, Section{Name: name, Metrics: section})
+
+		report.Fields += section.Fields
+		report.Untyped += section.Untyped
+		report.Validatable += section.Validatable
+		report.Validated += section.Validated
+		report.MaxDepth = max(report.MaxDepth, section.MaxDepth)
+	}
+	report.finish()
+	return report
+}
+
+// finish computes the derived metrics
+func (m *Metrics) finish() {
+	m.Complexity = m.Fields + DepthWeight*m.MaxDepth + UntypedWeight*m.Untyped
+	m.Coverage = 100
+	if m.Validatable > 0 {
+		m.Coverage = float64(m.Validated) * 100 / float64(m.Validatable)
+	}
+}
+
+// walk adds a field at the given depth, and its nested fields, to the metrics
+func walk(props *apiextensionsv1.JSONSchemaProps, path string, depth int, m *Metrics, untyped *[]string) {
+	m.Fields++
+	m.MaxDepth = max(m.MaxDepth, depth)
+	measure(props, path, depth, m, untyped)
+}
+
+// measure adds the nested fields of a schema, and whether it's untyped or validated, to the metrics
+func measure(props *apiextensionsv1.JSONSchemaProps, path string, depth int, m *Metrics, untyped *[]string) {
+	if isUntyped(props) {
+		m.Untyped++
+		*untyped = append(*untyped, path)
+		return
+	}
+
+	switch props.Type {
+	case "object":
+		names := make([]string, 0, len(props.Properties))
+		for name := range props.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := props.Properties[name]
+			walk(&prop, path+"."+name, depth+1, m, untyped)
+		}
+		if props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil {
+			measure(props.AdditionalProperties.Schema, path+".*", depth, m, untyped)
+		}
+	case "array":
+		if props.Items == nil || props.Items.Schema == nil {
+			m.Untyped++
+			*untyped = append(*untyped, path)
+			return
+		}
+		items := props.Items.Schema
+		if items.Type == "object" || items.Type == "array" || isUntyped(items) {
+			measure(items, path+"[]", depth, m, untyped)
+			return
+		}
+		// A list of scalars is validated by its own constraints or its items'
+		if isValidatable(items) {
+			m.Validatable++
+			if hasValidation(props) || hasValidation(items) {
+				m.Validated++
+			}
+		}
+	default:
+		if isValidatable(props) {
+			m.Validatable++
+			if hasValidation(props) {
+				m.Validated++
+			}
+		}
+	}
+}
+
+// isUntyped reports whether a schema accepts values of any shape
+func isUntyped(props *apiextensionsv1.JSONSchemaProps) bool {
+	if props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields {
+		return true
+	}
+	if props.Type == "" {
+		return !props.XIntOrString
+	}
+	return props.Type == "object" && len(props.Properties) == 0 &&
+		(props.AdditionalProperties == nil || props.AdditionalProperties.Schema == nil)
+}
+
+// isValidatable reports whether a scalar schema could have meaningful validations
+func isValidatable(props *apiextensionsv1.JSONSchemaProps) bool {
+	switch props.Type {
+	case "string", "integer", "number":
+		return true
+	}
+	return props.XIntOrString
+}
+
+// hasValidation reports whether a schema constrains its value beyond its type
+func hasValidation(props *apiextensionsv1.JSONSchemaProps) bool {
+	return len(props.Enum) > 0 || props.Pattern != "" || props.Format != "" ||
+		props.Minimum != nil || props.Maximum != nil || props.MultipleOf != nil ||
+		props.MinLength != nil || props.MaxLength != nil ||
+		props.MinItems != nil || props.MaxItems != nil || props.UniqueItems ||
+		len(props.XValidations) > 0
+}
@@ -0,0 +1,98 @@
+package score
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testSchema = `type: object
+properties:
+  apiVersion:
+    type: string
+  kind:
+    type: string
+  metadata:
+    type: object
+  replicas:
+    type: integer
+    minimum: 1
+  debug:
+    type: boolean
+  controller:
+    type: object
+    properties:
+      image:
+        type: string
+      pullPolicy:
+        type: string
+        enum: [Always, IfNotPresent]
+      extraArgs:
+        type: array
+        items:
+          type: string
+          minLength: 1
+      env:
+        type: array
+        items:
+          type: object
+          properties:
+            name:
+              type: string
+            value:
+              type: string
+      annotations:
+        type: object
+        additionalProperties:
+          type: string
+      config:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+      port:
+        x-kubernetes-int-or-string: true
+      raw: {}
+`
+
+func TestAnalyze(t *testing.T) {
+	var props apiextensionsv1.JSONSchemaProps
+	require.NoError(t, yaml.Unmarshal([]byte(testSchema), &props))
+
+	report := Analyze(&props)
+
+	require.Len(t, report.Sections, 3)
+	assert.Equal(t, []string{"controller", "debug", "replicas"},
+		[]string{report.Sections[0].Name, report.Sections[1].Name, report.Sections[2].Name})
+
+	controller := report.Sections[0].Metrics
+	// controller + 8 fields, plus env[].name and env[].value
+	assert.Equal(t, 11, controller.Fields)
+	assert.Equal(t, 3, controller.MaxDepth)
+	assert.Equal(t, 2, controller.Untyped)
+	// image, pullPolicy, extraArgs, env[].name, env[].value, annotations.*, port
+	assert.Equal(t, 7, controller.Validatable)
+	assert.Equal(t, 2, controller.Validated)
+	assert.InDelta(t, 28.57, controller.Coverage, 0.01)
+	assert.Equal(t, 11+DepthWeight*3+UntypedWeight*2, controller.Complexity)
+
+	debug := report.Sections[1].Metrics
+	assert.Equal(t, 0, debug.Validatable)
+	assert.Equal(t, float64(100), debug.Coverage, "fields that can't be validated don't lower coverage")
+
+	assert.Equal(t, 13, report.Fields)
+	assert.Equal(t, 3, report.MaxDepth)
+	assert.Equal(t, 2, report.Untyped)
+	assert.Equal(t, 8, report.Validatable)
+	assert.Equal(t, 3, report.Validated)
+	assert.Equal(t, 13+DepthWeight*3+UntypedWeight*2, report.Complexity)
+	assert.Equal(t, []string{"controller.config", "controller.raw"}, report.UntypedPaths)
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	report := Analyze(&apiextensionsv1.JSONSchemaProps{Type: "object"})
+	assert.Empty(t, report.Sections)
+	assert.Equal(t, 0, report.Complexity)
+	assert.Equal(t, float64(100), report.Coverage)
+}
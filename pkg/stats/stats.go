@@ -0,0 +1,172 @@
+// Package stats measures the quality of the schema of an example values file.
+package stats
+
+import (
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/schema"
+)
+
+// validationMarkers are the prefixes of markers that constrain a value beyond its type
+var validationMarkers = []string{
+	"+kubebuilder:validation:",
+	"+miaka:items:kubebuilder:validation:",
+	"+miaka:format:",
+	"+miaka:cel:",
+}
+
+// presenceMarkers are validation markers that don't constrain the value itself
+var presenceMarkers = map[string]bool{
+	"+kubebuilder:validation:Required": true,
+	"+kubebuilder:validation:Optional": true,
+}
+
+// Report is the statistics of a schema. They're measured on the markers and
+// Go types of the example values, so they're named apart from the metrics
+// package score measures on the generated CRD, which count differently.
+type Report struct {
+	// Structs counts the struct types, and Fields the fields of the values
+	Structs int `json:"structs"`
+	Fields  int `json:"fields"`
+	// Nesting is the deepest level of nesting (1 for top-level fields)
+	Nesting int `json:"nesting"`
+	// AnyTyped counts fields that hold values of any shape (interface{} or freeform)
+	AnyTyped int `json:"anyTyped"`
+	// Undescribed counts fields without a description
+	Undescribed int `json:"undescribed"`
+	// Markable counts string and number fields (and lists and maps of them);
+	// Marked counts those with at least one validation marker
+	Markable int `json:"markable"`
+	Marked   int `json:"marked"`
+	// MarkerCoverage is the percentage of markable fields that are marked
+	MarkerCoverage float64 `json:"markerCoverage"`
+
+	AnyTypedPaths    []string `json:"anyTypedPaths,omitempty"`
+	UndescribedPaths []string `json:"undescribedPaths,omitempty"`
+}
+
+// Analyze computes the statistics of a parsed schema
+func Analyze(s *schema.Schema) *Report {
+	a := analyzer{
+		report:  &Report{Structs: len(s.Structs)},
+		structs: make(map[string]schema.StructDef, len(s.Structs)),
+		types:   make(map[string]schema.TypeDef, len(s.Types)),
+	}
+	for _, structDef := range s.Structs {
+		a.structs[structDef.Name] = structDef
+	}
+	for _, typeDef := range s.Types {
+		a.types[typeDef.Name] = typeDef
+	}
+	if root, ok := a.structs[s.Kind]; ok {
+		a.walk(root, "", 1)
+	}
+
+	r := a.report
+	r.MarkerCoverage = 100
+	if r.Markable > 0 {
+		r.MarkerCoverage = float64(r.Marked) * 100 / float64(r.Markable)
+	}
+	return r
+}
+
+// analyzer walks the fields of a schema from its root type
+type analyzer struct {
+	report  *Report
+	structs map[string]schema.StructDef
+	types   map[string]schema.TypeDef
+}
+
+// walk adds the fields of a struct at the given depth, and their nested
+// fields, to the report
+func (a *analyzer) walk(structDef schema.StructDef, prefix string, depth int) {
+	r := a.report
+	for _, field := range structDef.Fields {
+		path := prefix + field.JSONName
+		r.Fields++
+		r.Nesting = max(r.Nesting, depth)
+		if !hasDescription(field.Comments) {
+			r.Undescribed++
+			r.UndescribedPaths = append(r.UndescribedPaths, path)
+		}
+
+		base, suffix := baseType(field.Type)
+		comments := field.Comments
+		if typeDef, ok := a.types[base]; ok {
+			// Named value types of maps and lists carry the markers of their values
+			comments = append(append([]string{}, comments...), typeDef.Comments...)
+			var typeSuffix string
+			base, typeSuffix = baseType(typeDef.Type)
+			suffix += typeSuffix
+		}
+
+		if nested, ok := a.structs[base]; ok {
+			a.walk(nested, path+suffix+".", depth+1)
+			continue
+		}
+		switch {
+		case base == string(schema.TypeInterface) || base == string(schema.TypeFreeform):
+			r.AnyTyped++
+			r.AnyTypedPaths = append(r.AnyTypedPaths, path)
+		case isMarkable(base):
+			r.Markable++
+			if hasValidation(comments) {
+				r.Marked++
+			}
+		}
+	}
+}
+
+// baseType strips pointers, lists, and maps from a Go type, returning the type
+// of the values and the path suffix of the items ("[]" for lists, ".*" for maps)
+func baseType(goType string) (string, string) {
+	suffix := ""
+	for {
+		switch {
+		case strings.HasPrefix(goType, "*"):
+			goType = goType[1:]
+		case strings.HasPrefix(goType, "[]"):
+			goType = goType[2:]
+			suffix += "[]"
+		case strings.HasPrefix(goType, "map[string]"):
+			goType = strings.TrimPrefix(goType, "map[string]")
+			suffix += ".*"
+		default:
+			return goType, suffix
+		}
+	}
+}
+
+// isMarkable reports whether values of a Go type could have meaningful validation markers
+func isMarkable(goType string) bool {
+	switch schema.FieldType(goType) {
+	case schema.TypeString, schema.TypeInt, schema.TypeInt32, schema.TypeInt64, schema.TypeFloat32, schema.TypeFloat64:
+		return true
+	}
+	return false
+}
+
+// hasDescription reports whether comments have a line that isn't a marker
+func hasDescription(comments []string) bool {
+	for _, comment := range comments {
+		if comment = strings.TrimSpace(comment); comment != "" && !strings.HasPrefix(comment, "+") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasValidation reports whether comments have a marker that constrains the value
+func hasValidation(comments []string) bool {
+	for _, comment := range comments {
+		if presenceMarkers[comment] {
+			continue
+		}
+		for _, prefix := range validationMarkers {
+			if strings.HasPrefix(comment, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/parsing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testValues = `apiVersion: example.com/v1
+kind: Example
+# Number of replicas
+# +kubebuilder:validation:Minimum=1
+replicas: 1
+debug: false
+# Controller settings
+controller:
+  # Image to run
+  # +miaka:format:imageref
+  image: nginx:1.25
+  # Pull policy
+  # +kubebuilder:validation:Required
+  pullPolicy: Always
+  # Extra arguments
+  # +miaka:items:kubebuilder:validation:MinLength=1
+  extraArgs: [--verbose]
+  # Environment variables
+  env:
+    - # Variable name
+      name: LOG_LEVEL
+      value: info
+  # Annotations of the pods
+  # +miaka:type: map[string]string
+  annotations: {}
+  # Freeform configuration
+  # +miaka:type: interface{}
+  config: {}
+`
+
+func TestAnalyze(t *testing.T) {
+	s, err := parsing.NewParser().Parse([]byte(testValues))
+	require.NoError(t, err)
+
+	report := Analyze(s)
+	assert.Equal(t, &Report{
+		Structs:          3,
+		Fields:           11,
+		Nesting:          3,
+		AnyTyped:         1,
+		Undescribed:      2,
+		Markable:         7,
+		Marked:           3,
+		MarkerCoverage:   float64(3) * 100 / 7,
+		AnyTypedPaths:    []string{"controller.config"},
+		UndescribedPaths: []string{"debug", "controller.env[].value"},
+	}, report)
+}
+
+func TestAnalyze_Empty(t *testing.T) {
+	s, err := parsing.NewParserWithOptions(parsing.Options{Plain: true}).Parse([]byte("{}\n"))
+	require.NoError(t, err)
+
+	report := Analyze(s)
+	assert.Zero(t, report.Fields)
+	assert.Equal(t, float64(100), report.MarkerCoverage)
+}
+
+func TestBaseType(t *testing.T) {
+	for goType, want := range map[string][2]string{
+		"string":                      {"string", ""},
+		"*int":                        {"int", ""},
+		"[]EnvItem":                   {"EnvItem", "[]"},
+		"map[string][]string":         {"string", ".*[]"},
+		"[]map[string]AnnotationsVal": {"AnnotationsVal", "[].*"},
+	} {
+		base, suffix := baseType(goType)
+		assert.Equal(t, want, [2]string{base, suffix}, goType)
+	}
+}
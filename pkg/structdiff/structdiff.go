@@ -0,0 +1,296 @@
+// Package structdiff compares JSON and YAML documents structurally, reporting
+// each difference at its path (e.g., "spec.properties.replicas: type changed
+// integer -> string") instead of the lines that differ.
+package structdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ChangeKind describes how a value differs between two documents
+type ChangeKind string
+
+// Change kinds
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// maxValueLength is the length above which objects and lists in messages are
+// summarized by their size
+const maxValueLength = 60
+
+// Change is a difference between two documents: a value that only the new one
+// has, only the old one has, or that both have with different scalars or types
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Path holds the keys of objects and the indexes of lists (e.g., "[0]")
+	// leading to the value, and is empty for the root
+	Path []string `json:"path"`
+	// Old and New are the values of the old and new documents, as decoded JSON
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// String renders the change as a line of a diff report. The last key of a
+// changed value is named with the change, so a changed schema keyword reads
+// "controller.replicas: type changed integer -> string".
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s: added %s", FormatPath(c.Path), formatValue(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("%s: removed %s", FormatPath(c.Path), formatValue(c.Old))
+	}
+	if n := len(c.Path); n > 1 && !isIndex(c.Path[n-1]) {
+		return fmt.Sprintf("%s: %s changed %s -> %s", FormatPath(c.Path[:n-1]), c.Path[n-1], formatValue(c.Old), formatValue(c.New))
+	}
+	return fmt.Sprintf("%s: changed %s -> %s", FormatPath(c.Path), formatValue(c.Old), formatValue(c.New))
+}
+
+// Parse decodes a JSON or YAML document into the values Compare takes. Numbers
+// are kept as json.Number, so large integers don't lose precision.
+func Parse(data []byte) (interface{}, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return decode(jsonData)
+}
+
+// Normalize converts a Go value (e.g., a struct with JSON tags) to the values
+// Compare takes
+func Normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+	return decode(data)
+}
+
+// decode decodes JSON, keeping numbers as json.Number
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return value, nil
+}
+
+// CompareDocuments parses two JSON or YAML documents and compares them
+func CompareDocuments(oldData, newData []byte) ([]Change, error) {
+	oldValue, err := Parse(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("old document: %w", err)
+	}
+	newValue, err := Parse(newData)
+	if err != nil {
+		return nil, fmt.Errorf("new document: %w", err)
+	}
+	return Compare(oldValue, newValue), nil
+}
+
+// Compare lists the differences between two decoded JSON values, in document
+// order with object keys sorted. Objects are compared key by key, and lists
+// item by item after matching the items both lists have, so an item inserted
+// into a list is a single change.
+func Compare(oldValue, newValue interface{}) []Change {
+	var changes []Change
+	compare(nil, oldValue, newValue, &changes)
+	return changes
+}
+
+func compare(path []string, oldValue, newValue interface{}, changes *[]Change) {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok {
+			compareObjects(path, oldTyped, newTyped, changes)
+			return
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok {
+			compareLists(path, oldTyped, newTyped, changes)
+			return
+		}
+	}
+	if !equal(oldValue, newValue) {
+		*changes = append(*changes, Change{Kind: ChangeChanged, Path: clone(path), Old: oldValue, New: newValue})
+	}
+}
+
+func compareObjects(path []string, oldObject, newObject map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(oldObject)+len(newObject))
+	for key := range oldObject {
+		keys = append(keys, key)
+	}
+	for key := range newObject {
+		if _, ok := oldObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldValue, inOld := oldObject[key]
+		newValue, inNew := newObject[key]
+		keyPath := append(clone(path), key)
+		switch {
+		case !inNew:
+			*changes = append(*changes, Change{Kind: ChangeRemoved, Path: keyPath, Old: oldValue})
+		case !inOld:
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: keyPath, New: newValue})
+		default:
+			compare(keyPath, oldValue, newValue, changes)
+		}
+	}
+}
+
+// compareLists matches the longest common subsequence of equal items. Between
+// matched items, old and new items are compared pairwise, and the rest are
+// removed or added.
+func compareLists(path []string, oldList, newList []interface{}, changes *[]Change) {
+	// lengths[i][j] is the length of the common subsequence of oldList[i:] and newList[j:]
+	lengths := make([][]int, len(oldList)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(newList)+1)
+	}
+	for i := len(oldList) - 1; i >= 0; i-- {
+		for j := len(newList) - 1; j >= 0; j-- {
+			if equal(oldList[i], newList[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	// Unmatched items are collected until the next match, then reported
+	var removed, added []int
+	flush := func() {
+		paired := min(len(removed), len(added))
+		for k := 0; k < paired; k++ {
+			// Paired items are reported at their new index
+			compare(append(clone(path), index(added[k])), oldList[removed[k]], newList[added[k]], changes)
+		}
+		for _, i := range removed[paired:] {
+			*changes = append(*changes, Change{Kind: ChangeRemoved, Path: append(clone(path), index(i)), Old: oldList[i]})
+		}
+		for _, j := range added[paired:] {
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: append(clone(path), index(j)), New: newList[j]})
+		}
+		removed, added = removed[:0], added[:0]
+	}
+	i, j := 0, 0
+	for i < len(oldList) || j < len(newList) {
+		switch {
+		case i < len(oldList) && j < len(newList) && equal(oldList[i], newList[j]):
+			flush()
+			i++
+			j++
+		case j == len(newList) || (i < len(oldList) && lengths[i+1][j] >= lengths[i][j+1]):
+			removed = append(removed, i)
+			i++
+		default:
+			added = append(added, j)
+			j++
+		}
+	}
+	flush()
+}
+
+// equal reports whether two decoded values are equal, comparing numbers by value
+func equal(a, b interface{}) bool {
+	if an, ok := a.(json.Number); ok {
+		if bn, ok := b.(json.Number); ok {
+			if ai, aErr := an.Int64(); aErr == nil {
+				if bi, bErr := bn.Int64(); bErr == nil {
+					return ai == bi
+				}
+			}
+			af, aErr := an.Float64()
+			bf, bErr := bn.Float64()
+			if aErr == nil && bErr == nil {
+				return af == bf
+			}
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// FormatPath renders a path with dots between keys and indexes in brackets
+// (e.g., "env[0].name"). Keys that would be ambiguous are quoted in brackets,
+// and the root is "(root)".
+func FormatPath(path []string) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+	var out strings.Builder
+	for i, segment := range path {
+		switch {
+		case isIndex(segment):
+			out.WriteString(segment)
+		case segment == "" || strings.ContainsAny(segment, ".[] \t\n\""):
+			fmt.Fprintf(&out, "[%s]", strconv.Quote(segment))
+		default:
+			if i > 0 {
+				out.WriteByte('.')
+			}
+			out.WriteString(segment)
+		}
+	}
+	return out.String()
+}
+
+// index is the path segment of a list index
+func index(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+// isIndex reports whether a path segment is a list index
+func isIndex(segment string) bool {
+	if len(segment) < 3 || segment[0] != '[' || segment[len(segment)-1] != ']' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1 : len(segment)-1])
+	return err == nil
+}
+
+// formatValue renders a value in a message: scalars as they'd be written in
+// YAML, and objects and lists as compact JSON, or by their size if that's long
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if v == "" || strings.TrimSpace(v) != v || strings.ContainsAny(v, "\n\"") || strings.Contains(v, " -> ") {
+			return strconv.Quote(v)
+		}
+		return v
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err == nil && len(data) <= maxValueLength {
+			return string(data)
+		}
+		if object, ok := v.(map[string]interface{}); ok {
+			return fmt.Sprintf("an object with %d key(s)", len(object))
+		}
+		return fmt.Sprintf("a list of %d item(s)", len(v.([]interface{})))
+	}
+	return fmt.Sprint(value)
+}
+
+// clone copies a path, so appending to it doesn't share its array
+func clone(path []string) []string {
+	return append([]string(nil), path...)
+}
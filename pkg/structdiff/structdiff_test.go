@@ -0,0 +1,126 @@
+package structdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messages renders changes as strings
+func messages(changes []Change) []string {
+	var out []string
+	for _, change := range changes {
+		out = append(out, change.String())
+	}
+	return out
+}
+
+func TestCompareDocuments(t *testing.T) {
+	oldDoc := `
+spec:
+  properties:
+    controller:
+      properties:
+        replicas:
+          type: integer
+          minimum: 1
+        image:
+          type: string
+  required: [controller, name]
+  enum: [a, b, c]
+`
+	// JSON and YAML compare alike
+	newDoc := `{
+  "spec": {
+    "properties": {
+      "controller": {
+        "properties": {
+          "replicas": {"type": "string", "minimum": 1.0},
+          "tag": {"type": "string"}
+        }
+      }
+    },
+    "required": ["controller", "name"],
+    "enum": ["a", "x", "c", "d"]
+  }
+}`
+	changes, err := CompareDocuments([]byte(oldDoc), []byte(newDoc))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"spec.enum[1]: changed b -> x",
+		"spec.enum[3]: added d",
+		`spec.properties.controller.properties.image: removed {"type":"string"}`,
+		"spec.properties.controller.properties.replicas: type changed integer -> string",
+		`spec.properties.controller.properties.tag: added {"type":"string"}`,
+	}, messages(changes))
+	assert.Equal(t, []string{"spec", "enum", "[1]"}, changes[0].Path)
+	assert.Equal(t, ChangeChanged, changes[0].Kind)
+
+	_, err = CompareDocuments([]byte("a: [\n"), []byte("{}"))
+	require.ErrorContains(t, err, "old document")
+}
+
+func TestCompare_Lists(t *testing.T) {
+	parse := func(doc string) interface{} {
+		value, err := Parse([]byte(doc))
+		require.NoError(t, err)
+		return value
+	}
+
+	// An inserted item is a single change, not a change of every later item
+	assert.Equal(t, []string{"[1]: added x"}, messages(Compare(parse("[a, b, c]"), parse("[a, x, b, c]"))))
+	assert.Equal(t, []string{"[0]: removed a"}, messages(Compare(parse("[a, b]"), parse("[b]"))))
+
+	// Changed items are compared structurally
+	assert.Equal(t, []string{"env[0]: value changed 1 -> 2"}, messages(Compare(
+		parse("env: [{name: A, value: 1}]"),
+		parse("env: [{name: A, value: 2}]"),
+	)))
+	assert.Empty(t, Compare(parse("[1, 2]"), parse("[1, 2.0]")))
+}
+
+func TestCompare_Values(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"equal", "a: 1", "a: 1", nil},
+		{"root", "1", "2", []string{"(root): changed 1 -> 2"}},
+		{"top-level key", "a: 1", "a: true", []string{"a: changed 1 -> true"}},
+		{"object to scalar", "a: {b: 1}", "a: x", []string{`a: changed {"b":1} -> x`}},
+		{"null", "a: null", "a: 1", []string{"a: changed null -> 1"}},
+		{"quoted strings", "a: {b: ''}", "a: {b: ' x'}", []string{`a: b changed "" -> " x"`}},
+		{"ambiguous keys", "a.b: 1", "a.b: 2", []string{`["a.b"]: changed 1 -> 2`}},
+		{"large numbers", "a: 9007199254740993", "a: 9007199254740992", []string{"a: changed 9007199254740993 -> 9007199254740992"}},
+		{"long values", "a: 1", "a: {k1: aaaaaaaaaa, k2: bbbbbbbbbb, k3: cccccccccc, k4: dddddddddd}", []string{"a: changed 1 -> an object with 4 key(s)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := CompareDocuments([]byte(tt.old), []byte(tt.new))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, messages(changes))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	type props struct {
+		Type    string `json:"type"`
+		Minimum *int   `json:"minimum,omitempty"`
+	}
+	one := 1
+	oldValue, err := Normalize(props{Type: "integer", Minimum: &one})
+	require.NoError(t, err)
+	newValue, err := Normalize(props{Type: "integer"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"minimum: removed 1"}, messages(Compare(oldValue, newValue)))
+}
+
+func TestFormatPath(t *testing.T) {
+	assert.Equal(t, "(root)", FormatPath(nil))
+	assert.Equal(t, "env[0].name", FormatPath([]string{"env", "[0]", "name"}))
+	assert.Equal(t, `labels["app.kubernetes.io/name"]`, FormatPath([]string{"labels", "app.kubernetes.io/name"}))
+	assert.Equal(t, `[""]`, FormatPath([]string{""}))
+}
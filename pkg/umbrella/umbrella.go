@@ -0,0 +1,135 @@
+// Package umbrella composes the JSON Schema of an umbrella chart from the
+// schemas of its subcharts.
+package umbrella
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// definitionsKeyword holds the subchart schemas in the composed schema (draft-07)
+const definitionsKeyword = "definitions"
+
+// Subchart is the schema of the values of a subchart, configured under Key in
+// the values of the umbrella chart
+type Subchart struct {
+	Key    string
+	Schema []byte
+	// Published is true if Schema is the subchart's own values.schema.json,
+	// rather than generated from the example values
+	Published bool
+}
+
+// ExtractValues returns the example values under each key as a standalone YAML
+// document, keeping their comments. Keys missing from the values, or with empty
+// values, are left out.
+func ExtractValues(values []byte, keys []string) (map[string][]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(values, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse values: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values must be a mapping")
+	}
+
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	extracted := make(map[string][]byte)
+	root := doc.Content[0]
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if !wanted[key] || value.ShortTag() == "!!null" {
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("values of subchart %s must be an object (line %d)", key, value.Line)
+		}
+		if len(value.Content) == 0 {
+			continue
+		}
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal values of subchart %s: %w", key, err)
+		}
+		extracted[key] = data
+	}
+	return extracted, nil
+}
+
+// Compose replaces the properties of the subchart keys in the schema of the
+// umbrella chart with $refs to the subchart schemas, which are embedded as
+// definitions named after their keys
+func Compose(parent []byte, subcharts []Subchart) ([]byte, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(parent, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Schema: %w", err)
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = make(map[string]interface{})
+		schema["properties"] = properties
+	}
+	definitions, _ := schema[definitionsKeyword].(map[string]interface{})
+	if definitions == nil {
+		definitions = make(map[string]interface{})
+	}
+
+	for _, subchart := range subcharts {
+		var subschema map[string]interface{}
+		if err := json.Unmarshal(subchart.Schema, &subschema); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON Schema of subchart %s: %w", subchart.Key, err)
+		}
+		if _, exists := definitions[subchart.Key]; exists {
+			return nil, fmt.Errorf("JSON Schema already has a definition named %s", subchart.Key)
+		}
+
+		// The subchart schema is no longer a root schema, so its own refs must
+		// point into its definition
+		delete(subschema, "$schema")
+		delete(subschema, "$id")
+		pointer := "#/" + definitionsKeyword + "/" + escapePointer(subchart.Key)
+		rewriteRefs(subschema, pointer)
+
+		definitions[subchart.Key] = subschema
+		properties[subchart.Key] = map[string]interface{}{"$ref": pointer}
+	}
+	if len(definitions) > 0 {
+		schema[definitionsKeyword] = definitions
+	}
+
+	composed, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON Schema: %w", err)
+	}
+	return composed, nil
+}
+
+// rewriteRefs prefixes the local $refs of a schema (e.g., "#/definitions/port")
+// with the pointer to the schema
+func rewriteRefs(obj interface{}, pointer string) {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" && strings.HasPrefix(ref, "#") {
+				v[key] = pointer + strings.TrimPrefix(ref, "#")
+				continue
+			}
+			rewriteRefs(value, pointer)
+		}
+	case []interface{}:
+		for _, item := range v {
+			rewriteRefs(item, pointer)
+		}
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
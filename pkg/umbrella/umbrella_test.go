@@ -0,0 +1,92 @@
+package umbrella
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractValues(t *testing.T) {
+	values := []byte(`apiVersion: example.com/v1
+kind: Platform
+replicas: 1
+redis:
+  # Port of the Redis server
+  port: 6379
+db:
+  name: app
+cache: {}
+metrics: null
+`)
+	extracted, err := ExtractValues(values, []string{"redis", "db", "cache", "metrics", "missing"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Port of the Redis server\nport: 6379\n", string(extracted["redis"]))
+	assert.Equal(t, "name: app\n", string(extracted["db"]))
+	assert.NotContains(t, extracted, "cache")
+	assert.NotContains(t, extracted, "metrics")
+	assert.NotContains(t, extracted, "missing")
+	assert.NotContains(t, extracted, "replicas")
+
+	_, err = ExtractValues([]byte("redis: 6379\n"), []string{"redis"})
+	require.ErrorContains(t, err, "values of subchart redis must be an object (line 1)")
+
+	_, err = ExtractValues([]byte("- redis\n"), []string{"redis"})
+	require.ErrorContains(t, err, "values must be a mapping")
+}
+
+func TestCompose(t *testing.T) {
+	parent := []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "properties": {
+    "replicas": {"type": "integer"},
+    "redis": {"type": "object"}
+  },
+  "type": "object"
+}`)
+	redis := []byte(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://example.com/redis.schema.json",
+  "definitions": {"port": {"type": "integer"}},
+  "properties": {
+    "port": {"$ref": "#/definitions/port"},
+    "tls": {"$ref": "https://example.com/tls.schema.json"}
+  }
+}`)
+	db := []byte(`{"properties": {"name": {"type": "string"}}}`)
+
+	composed, err := Compose(parent, []Subchart{
+		{Key: "redis", Schema: redis, Published: true},
+		{Key: "db/primary", Schema: db},
+	})
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(composed, &schema))
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["replicas"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/redis"}, properties["redis"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/db~1primary"}, properties["db/primary"])
+
+	definitions := schema["definitions"].(map[string]interface{})
+	redisDef := definitions["redis"].(map[string]interface{})
+	assert.NotContains(t, redisDef, "$schema")
+	assert.NotContains(t, redisDef, "$id")
+	redisProps := redisDef["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/redis/definitions/port"}, redisProps["port"])
+	assert.Equal(t, map[string]interface{}{"$ref": "https://example.com/tls.schema.json"}, redisProps["tls"])
+	assert.Contains(t, definitions, "db/primary")
+}
+
+func TestCompose_Errors(t *testing.T) {
+	_, err := Compose([]byte("not json"), nil)
+	require.ErrorContains(t, err, "failed to parse JSON Schema")
+
+	_, err = Compose([]byte(`{}`), []Subchart{{Key: "redis", Schema: []byte("not json")}})
+	require.ErrorContains(t, err, "failed to parse JSON Schema of subchart redis")
+
+	_, err = Compose([]byte(`{"definitions": {"redis": {}}}`), []Subchart{{Key: "redis", Schema: []byte(`{}`)}})
+	require.ErrorContains(t, err, "already has a definition named redis")
+}
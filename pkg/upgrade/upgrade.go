@@ -0,0 +1,286 @@
+// Package upgrade checks chart values against a new version of a dependency's schema.
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+)
+
+// ChangeKind describes how a schema property changed between versions
+type ChangeKind string
+
+// Schema change kinds
+const (
+	ChangeRemoved     ChangeKind = "removed"
+	ChangeAdded       ChangeKind = "added"
+	ChangeTypeChanged ChangeKind = "type changed"
+	ChangeRenamed     ChangeKind = "renamed"
+)
+
+// SchemaChange is a single property-level difference between two schemas
+type SchemaChange struct {
+	Kind      ChangeKind `json:"kind"`
+	Path      string     `json:"path"` // Dotted property path; array items are written as "[]"
+	OldType   string     `json:"oldType,omitempty"`
+	NewType   string     `json:"newType,omitempty"`
+	RenamedTo string     `json:"renamedTo,omitempty"` // Set for ChangeRenamed
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case ChangeTypeChanged:
+		return fmt.Sprintf("%s: type changed %s -> %s", c.Path, c.OldType, c.NewType)
+	case ChangeRenamed:
+		return fmt.Sprintf("%s: renamed to %s", c.Path, c.RenamedTo)
+	default:
+		return fmt.Sprintf("%s: %s", c.Path, c.Kind)
+	}
+}
+
+// IsBreaking reports whether a change can invalidate values that were valid
+// before it. Only added properties are compatible.
+func (c SchemaChange) IsBreaking() bool {
+	return c.Kind != ChangeAdded
+}
+
+// Finding is a problem with one of our values under the new schema
+type Finding struct {
+	Path    string // Path of the value relative to the dependency key
+	Message string
+}
+
+// Report is the result of an upgrade check
+type Report struct {
+	Dependency string
+	Changes    []SchemaChange
+	Findings   []Finding
+}
+
+// HasFindings reports whether any of our values are affected by the upgrade
+func (r *Report) HasFindings() bool {
+	return len(r.Findings) > 0
+}
+
+// Check compares the old and new JSON Schemas of a dependency and reports which of
+// the values set under the dependency key are invalid or affected by the upgrade
+func Check(dependency string, values map[string]interface{}, oldSchema, newSchema []byte) (*Report, error) {
+	changes, err := Diff(oldSchema, newSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Dependency: dependency,
+		Changes:    changes,
+	}
+
+	subValues, _ := values[dependency].(map[string]interface{})
+	if subValues == nil {
+		return report, nil
+	}
+
+	// Values touching changed properties
+	changesByPath := make(map[string]SchemaChange)
+	for _, change := range report.Changes {
+		changesByPath[change.Path] = change
+	}
+	reported := make(map[string]bool)
+	for _, valuePath := range valuePaths(subValues, "") {
+		change, ok := changesByPath[valuePath]
+		if !ok || change.Kind == ChangeAdded {
+			continue
+		}
+		report.Findings = append(report.Findings, Finding{Path: valuePath, Message: findingMessage(change)})
+		reported[valuePath] = true
+	}
+
+	// Values that no longer validate against the new schema
+	if err := validation.ValidateValues(subValues, newSchema); err != nil {
+		violations := validation.Violations(err)
+		if len(violations) == 0 {
+			return nil, err
+		}
+		for _, v := range violations {
+			path := strings.Join(v.Path, ".")
+			if reported[path] {
+				continue
+			}
+			report.Findings = append(report.Findings, Finding{Path: path, Message: v.Message})
+		}
+	}
+
+	return report, nil
+}
+
+// Diff lists the property-level changes between two JSON Schemas, sorted by path.
+// A removed property with exactly one added sibling of the same type is reported
+// as renamed.
+func Diff(oldSchema, newSchema []byte) ([]SchemaChange, error) {
+	oldProps, err := collectProperties(oldSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read old schema: %w", err)
+	}
+	newProps, err := collectProperties(newSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read new schema: %w", err)
+	}
+	return diffProperties(oldProps, newProps), nil
+}
+
+// findingMessage describes how a schema change affects a value
+func findingMessage(change SchemaChange) string {
+	switch change.Kind {
+	case ChangeRenamed:
+		return fmt.Sprintf("renamed to %s", change.RenamedTo)
+	case ChangeTypeChanged:
+		return fmt.Sprintf("type changed from %s to %s", change.OldType, change.NewType)
+	default:
+		return "no longer exists in the new schema"
+	}
+}
+
+// collectProperties flattens a JSON Schema into property path -> type
+func collectProperties(schemaJSON []byte) (map[string]string, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]string)
+	walkSchema(schema, "", props)
+	return props, nil
+}
+
+// walkSchema recursively records the type of each property
+func walkSchema(schema map[string]interface{}, prefix string, props map[string]string) {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, raw := range properties {
+			child, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := joinPath(prefix, name)
+			props[path] = schemaType(child)
+			walkSchema(child, path, props)
+		}
+	}
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		walkSchema(items, prefix+"[]", props)
+	}
+}
+
+// schemaType returns a printable type for a schema node
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		parts := make([]string, 0, len(t))
+		for _, p := range t {
+			parts = append(parts, fmt.Sprint(p))
+		}
+		return strings.Join(parts, "|")
+	}
+	return "any"
+}
+
+// diffProperties compares flattened property sets and detects likely renames
+func diffProperties(oldProps, newProps map[string]string) []SchemaChange {
+	var removed, added, changes []SchemaChange
+
+	for path, oldType := range oldProps {
+		newType, ok := newProps[path]
+		switch {
+		case !ok:
+			removed = append(removed, SchemaChange{Kind: ChangeRemoved, Path: path, OldType: oldType})
+		case newType != oldType && oldType != "any" && newType != "any":
+			changes = append(changes, SchemaChange{Kind: ChangeTypeChanged, Path: path, OldType: oldType, NewType: newType})
+		}
+	}
+	for path, newType := range newProps {
+		if _, ok := oldProps[path]; !ok {
+			added = append(added, SchemaChange{Kind: ChangeAdded, Path: path, NewType: newType})
+		}
+	}
+
+	sortChanges(removed)
+	sortChanges(added)
+
+	// A removed property with exactly one added sibling of the same type is treated as a rename
+	usedAdded := make(map[string]bool)
+	for _, r := range removed {
+		var candidates []string
+		for _, a := range added {
+			if !usedAdded[a.Path] && parentPath(a.Path) == parentPath(r.Path) && a.NewType == r.OldType {
+				candidates = append(candidates, a.Path)
+			}
+		}
+		if len(candidates) == 1 {
+			usedAdded[candidates[0]] = true
+			changes = append(changes, SchemaChange{Kind: ChangeRenamed, Path: r.Path, OldType: r.OldType, NewType: r.OldType, RenamedTo: candidates[0]})
+			continue
+		}
+		changes = append(changes, r)
+	}
+	for _, a := range added {
+		if !usedAdded[a.Path] {
+			changes = append(changes, a)
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// valuePaths lists the paths of all values set in a values tree
+func valuePaths(value interface{}, prefix string) []string {
+	var paths []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := joinPath(prefix, key)
+			paths = append(paths, path)
+			paths = append(paths, valuePaths(child, path)...)
+		}
+	case []interface{}:
+		seen := make(map[string]bool)
+		for _, item := range v {
+			for _, path := range valuePaths(item, prefix+"[]") {
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// joinPath appends a property name to a dotted path
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// parentPath returns the dotted path of a property's parent
+func parentPath(path string) string {
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		return path[:idx]
+	}
+	return ""
+}
+
+// sortChanges sorts changes by path for stable output
+func sortChanges(changes []SchemaChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+}
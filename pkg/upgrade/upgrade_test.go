@@ -0,0 +1,136 @@
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldSchema = `{
+  "type": "object",
+  "properties": {
+    "password": {"type": "string"},
+    "port": {"type": "integer"},
+    "replicas": {"type": "integer"},
+    "sentinel": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"}
+      }
+    }
+  }
+}`
+
+const newSchema = `{
+  "type": "object",
+  "properties": {
+    "auth": {
+      "type": "object",
+      "properties": {
+        "password": {"type": "string"}
+      }
+    },
+    "port": {"type": "string"},
+    "replicaCount": {"type": "integer"},
+    "sentinel": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"}
+      },
+      "additionalProperties": false
+    }
+  }
+}`
+
+func TestCheck_SchemaChanges(t *testing.T) {
+	report, err := Check("redis", nil, []byte(oldSchema), []byte(newSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAdded, Path: "auth", NewType: "object"},
+		{Kind: ChangeAdded, Path: "auth.password", NewType: "string"},
+		{Kind: ChangeRemoved, Path: "password", OldType: "string"},
+		{Kind: ChangeTypeChanged, Path: "port", OldType: "integer", NewType: "string"},
+		{Kind: ChangeRenamed, Path: "replicas", OldType: "integer", NewType: "integer", RenamedTo: "replicaCount"},
+	}, report.Changes)
+	assert.False(t, report.HasFindings())
+}
+
+func TestCheck_Findings(t *testing.T) {
+	values := map[string]interface{}{
+		"redis": map[string]interface{}{
+			"replicas": 3,
+			"port":     6379,
+			"sentinel": map[string]interface{}{
+				"enabled": true,
+				"quorum":  2,
+			},
+		},
+		"other": map[string]interface{}{"password": "unrelated"},
+	}
+
+	report, err := Check("redis", values, []byte(oldSchema), []byte(newSchema))
+	require.NoError(t, err)
+	require.True(t, report.HasFindings())
+
+	assert.Contains(t, report.Findings, Finding{Path: "replicas", Message: "renamed to replicaCount"})
+	assert.Contains(t, report.Findings, Finding{Path: "port", Message: "type changed from integer to string"})
+	assert.Contains(t, report.Findings, Finding{Path: "sentinel", Message: "additional properties 'quorum' not allowed"})
+
+	// A value already reported for a schema change is not reported again by validation
+	count := 0
+	for _, f := range report.Findings {
+		if f.Path == "port" {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestCheck_NoValuesForDependency(t *testing.T) {
+	values := map[string]interface{}{"other": map[string]interface{}{"replicas": 1}}
+
+	report, err := Check("redis", values, []byte(oldSchema), []byte(newSchema))
+	require.NoError(t, err)
+	assert.False(t, report.HasFindings())
+	assert.NotEmpty(t, report.Changes)
+}
+
+func TestCheck_InvalidSchema(t *testing.T) {
+	_, err := Check("redis", nil, []byte("not json"), []byte(newSchema))
+	require.Error(t, err)
+
+	_, err = Check("redis", nil, []byte(oldSchema), []byte("{"))
+	require.Error(t, err)
+}
+
+func TestSchemaChange_String(t *testing.T) {
+	assert.Equal(t, "port: type changed integer -> string",
+		SchemaChange{Kind: ChangeTypeChanged, Path: "port", OldType: "integer", NewType: "string"}.String())
+	assert.Equal(t, "replicas: renamed to replicaCount",
+		SchemaChange{Kind: ChangeRenamed, Path: "replicas", RenamedTo: "replicaCount"}.String())
+	assert.Equal(t, "password: removed",
+		SchemaChange{Kind: ChangeRemoved, Path: "password"}.String())
+}
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff([]byte(oldSchema), []byte(newSchema))
+	require.NoError(t, err)
+	require.Len(t, changes, 5)
+
+	var breaking []string
+	for _, change := range changes {
+		if change.IsBreaking() {
+			breaking = append(breaking, change.Path)
+		}
+	}
+	assert.Equal(t, []string{"password", "port", "replicas"}, breaking)
+
+	changes, err = Diff([]byte(oldSchema), []byte(oldSchema))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	_, err = Diff([]byte(oldSchema), []byte("{"))
+	assert.ErrorContains(t, err, "failed to read new schema")
+}
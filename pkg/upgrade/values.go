@@ -0,0 +1,106 @@
+package upgrade
+
+import "strings"
+
+// DiffValues lists the structural changes between two values trees (e.g., the
+// values.yaml a chart's example values were made from and its newer upstream
+// version), sorted by path, with the same kinds as Diff. Types are inferred
+// from the values: null values match any type, and the contents of empty
+// objects and lists aren't compared, since they're placeholders. Keys below
+// added, removed, or renamed keys aren't listed.
+func DiffValues(oldValues, newValues map[string]interface{}) []SchemaChange {
+	oldProps, oldOpen := make(map[string]string), make(map[string]bool)
+	newProps, newOpen := make(map[string]string), make(map[string]bool)
+	walkValues(oldValues, "", oldProps, oldOpen)
+	walkValues(newValues, "", newProps, newOpen)
+
+	var open []SchemaChange
+	for _, change := range diffProperties(oldProps, newProps) {
+		if !below(change.Path, oldOpen) && !below(change.Path, newOpen) {
+			open = append(open, change)
+		}
+	}
+
+	// Old keys are gone below removed and renamed keys, and new keys below added
+	// and renamed ones
+	gone, added := make(map[string]bool), make(map[string]bool)
+	for _, change := range open {
+		switch change.Kind {
+		case ChangeRemoved:
+			gone[change.Path] = true
+		case ChangeAdded:
+			added[change.Path] = true
+		case ChangeRenamed:
+			gone[change.Path] = true
+			added[change.RenamedTo] = true
+		}
+	}
+	var changes []SchemaChange
+	for _, change := range open {
+		switch {
+		case change.Kind == ChangeAdded && below(change.Path, added):
+		case change.Kind != ChangeAdded && below(change.Path, gone):
+		default:
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// walkValues recursively records the type of each value, and the paths of empty
+// objects and lists in open
+func walkValues(value interface{}, prefix string, props map[string]string, open map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			open[prefix] = true
+		}
+		for key, child := range v {
+			path := joinPath(prefix, key)
+			props[path] = valueType(child)
+			walkValues(child, path, props, open)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			open[prefix] = true
+		}
+		// Items with different types at a path are of any type
+		for _, item := range v {
+			items := make(map[string]string)
+			walkValues(item, prefix+"[]", items, open)
+			for path, t := range items {
+				if existing, ok := props[path]; ok && existing != t {
+					t = "any"
+				}
+				props[path] = t
+			}
+		}
+	}
+}
+
+// valueType returns the JSON Schema type of a value, or "any" for null
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	}
+	return "any"
+}
+
+// below reports whether a path is below one of paths
+func below(path string, paths map[string]bool) bool {
+	for prefix := range paths {
+		if strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[]") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func parseValues(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &values); err != nil {
+		t.Fatalf("failed to parse values: %v", err)
+	}
+	return values
+}
+
+func TestDiffValues(t *testing.T) {
+	oldValues := parseValues(t, `
+replicas: 3
+legacy: true
+image:
+  repo: nginx
+service:
+  port: 80
+hosts:
+  - name: a
+    paths: [/]
+`)
+	newValues := parseValues(t, `
+replicas: 1
+image:
+  repository: nginx
+  pullSecrets: []
+service:
+  port: "80"
+hosts:
+  - name: b
+    tls: false
+    paths: [/]
+`)
+
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAdded, Path: "hosts[].tls", NewType: "boolean"},
+		{Kind: ChangeAdded, Path: "image.pullSecrets", NewType: "array"},
+		{Kind: ChangeRenamed, Path: "image.repo", OldType: "string", NewType: "string", RenamedTo: "image.repository"},
+		{Kind: ChangeRemoved, Path: "legacy", OldType: "boolean"},
+		{Kind: ChangeTypeChanged, Path: "service.port", OldType: "number", NewType: "string"},
+	}, DiffValues(oldValues, newValues))
+}
+
+func TestDiffValues_Placeholders(t *testing.T) {
+	// Examples fill in null values and empty objects and lists
+	oldValues := parseValues(t, `
+resources:
+  limits:
+    cpu: 100m
+tolerations:
+  - key: a
+nodeSelector: {}
+tag: v1
+`)
+	newValues := parseValues(t, `
+resources: {}
+tolerations: []
+nodeSelector:
+  zone: a
+tag: null
+`)
+
+	assert.Empty(t, DiffValues(oldValues, newValues))
+	assert.Empty(t, DiffValues(oldValues, oldValues))
+}
+
+func TestDiffValues_Subtrees(t *testing.T) {
+	oldValues := parseValues(t, `
+legacy:
+  enabled: true
+  hosts:
+    - name: a
+`)
+	newValues := parseValues(t, `
+ingress:
+  enabled: true
+probes:
+  liveness:
+    path: /healthz
+`)
+
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAdded, Path: "ingress", NewType: "object"},
+		{Kind: ChangeRemoved, Path: "legacy", OldType: "object"},
+		{Kind: ChangeAdded, Path: "probes", NewType: "object"},
+	}, DiffValues(oldValues, newValues))
+}
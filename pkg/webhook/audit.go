@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/anonymize"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Redacted replaces secret values in the audit log
+const Redacted = "<redacted>"
+
+// lastAppliedAnnotation holds a copy of the whole resource, including its secrets
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// AuditEntry is a rejected resource in the audit log
+type AuditEntry struct {
+	Time       time.Time   `json:"time"`
+	UID        string      `json:"uid"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Operation  string      `json:"operation"`
+	User       string      `json:"user,omitempty"`
+	Violations []string    `json:"violations"`
+	Object     interface{} `json:"object"`
+}
+
+// AuditLog writes a JSON line for each rejected resource, with the values of
+// secret fields redacted
+type AuditLog struct {
+	mu          sync.Mutex
+	encoder     *json.Encoder
+	secretPaths map[schema.GroupKind]map[string]bool
+	now         func() time.Time
+}
+
+// NewAuditLog creates an audit log that writes to w
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{
+		encoder:     json.NewEncoder(w),
+		secretPaths: make(map[schema.GroupKind]map[string]bool),
+		now:         time.Now,
+	}
+}
+
+// SetSecretPaths sets the paths of the fields of a kind whose values are redacted.
+// Paths are dotted; list items are written as "[]" (see anonymize.FindSecretPaths).
+func (a *AuditLog) SetSecretPaths(gk schema.GroupKind, paths map[string]bool) {
+	a.secretPaths[gk] = paths
+}
+
+// Record writes an entry for a rejected resource. Nothing is written if a is nil.
+func (a *AuditLog) Record(request *admissionv1.AdmissionRequest, gk schema.GroupKind, obj map[string]interface{}, violations []Violation) error {
+	if a == nil {
+		return nil
+	}
+
+	entry := AuditEntry{
+		Time:       a.now().UTC(),
+		UID:        string(request.UID),
+		Kind:       gk.String(),
+		Namespace:  request.Namespace,
+		Name:       request.Name,
+		Operation:  string(request.Operation),
+		User:       request.UserInfo.Username,
+		Violations: make([]string, 0, len(violations)),
+		Object:     Redact(obj, a.secretPaths[gk]),
+	}
+	for _, violation := range violations {
+		entry.Violations = append(entry.Violations, violation.Message)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Redact returns a copy of a resource with the values at secret paths replaced
+// by Redacted. The last-applied-configuration annotation is redacted too, since it
+// copies the whole resource, and managed fields are dropped.
+func Redact(obj map[string]interface{}, secretPaths map[string]bool) map[string]interface{} {
+	result, _ := redact(obj, "", secretPaths).(map[string]interface{})
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if _, ok := annotations[lastAppliedAnnotation]; ok {
+				annotations[lastAppliedAnnotation] = Redacted
+			}
+		}
+	}
+	return result
+}
+
+// redact copies a value, redacting the values at secret paths
+func redact(value interface{}, path string, secretPaths map[string]bool) interface{} {
+	if anonymize.IsSecret(secretPaths, path) {
+		return Redacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			result[key] = redact(fieldValue, fieldPath, secretPaths)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redact(item, path+"[]", secretPaths)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRedact(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "demo.io/v1",
+		"metadata": map[string]interface{}{
+			"name":          "demo",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"annotations": map[string]interface{}{
+				lastAppliedAnnotation: `{"password": "hunter2"}`,
+				"team":                "a",
+			},
+		},
+		"password": "hunter2",
+		"env": []interface{}{
+			map[string]interface{}{"name": "TOKEN", "value": "abc"},
+		},
+		"database": map[string]interface{}{"credentials": map[string]interface{}{"user": "u"}},
+	}
+
+	redacted := Redact(obj, map[string]bool{"password": true, "env[].value": true, "database.credentials": true})
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "demo.io/v1",
+		"metadata": map[string]interface{}{
+			"name": "demo",
+			"annotations": map[string]interface{}{
+				lastAppliedAnnotation: Redacted,
+				"team":                "a",
+			},
+		},
+		"password": Redacted,
+		"env": []interface{}{
+			map[string]interface{}{"name": "TOKEN", "value": Redacted},
+		},
+		"database": map[string]interface{}{"credentials": Redacted},
+	}, redacted)
+
+	// The original is unchanged
+	assert.Equal(t, "hunter2", obj["password"])
+	assert.Contains(t, obj["metadata"], "managedFields")
+}
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	auditLog := NewAuditLog(&buf)
+	auditLog.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	auditLog.SetSecretPaths(demoKind, map[string]bool{"port": true})
+
+	handler := NewHandler(newTestValidator(t), HandlerOptions{AuditLog: auditLog})
+	demo := metav1.GroupVersionKind{Group: "demo.io", Version: "v1", Kind: "Demo"}
+	for _, obj := range []string{
+		`{"apiVersion": "demo.io/v1", "kind": "Demo", "port": 80}`,
+		`{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(admissionRequest(t, admissionv1.Create, demo, obj)))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Only the rejected resource is logged
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1)
+
+	var entry AuditEntry
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), entry.Time)
+	assert.Equal(t, "1234", entry.UID)
+	assert.Equal(t, "Demo.demo.io", entry.Kind)
+	assert.Equal(t, "CREATE", entry.Operation)
+	require.Len(t, entry.Violations, 1)
+	assert.Contains(t, entry.Violations[0], "port: Invalid value")
+	assert.Equal(t, map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "port": Redacted}, entry.Object)
+}
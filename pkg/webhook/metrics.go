@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/crenshaw-dev/miaka/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MetricsPath is the path metrics are served on
+const MetricsPath = metrics.Path
+
+// Results of a validation
+const (
+	ResultAllowed = "allowed"
+	ResultDenied  = "denied"
+	ResultError   = metrics.ResultError
+)
+
+// indexPattern matches list indexes and map keys in field paths
+// (e.g., "[0]" and "[key]" in CRD errors, ".0" in JSON Schema errors)
+var indexPattern = regexp.MustCompile(`\[[^\]]*\]|\.[0-9]+\b`)
+
+// Metrics records validations in Prometheus metrics: miaka_webhook_validations_total
+// and miaka_webhook_validation_duration_seconds (see metrics.Metrics), and the
+// violations in denied resources
+type Metrics struct {
+	*metrics.Metrics
+	violations *prometheus.CounterVec
+}
+
+// NewMetrics creates the webhook metrics, along with Go runtime and process metrics
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		Metrics: metrics.New("webhook"),
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "miaka_webhook_violations_total",
+			Help: "Violations in denied resources, by kind, field (with list indexes as []), and rule.",
+		}, []string{"kind", "field", "rule"}),
+	}
+	m.MustRegister(m.violations)
+	return m
+}
+
+// observe records a validation. Nothing is recorded if m is nil.
+func (m *Metrics) observe(gk schema.GroupKind, result string, duration time.Duration, violations []Violation) {
+	if m == nil {
+		return
+	}
+	kind := gk.String()
+	m.Observe(kind, result, duration)
+	for _, violation := range violations {
+		m.violations.WithLabelValues(kind, indexPattern.ReplaceAllString(violation.Field, "[]"), violation.Rule).Inc()
+	}
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	handler := NewHandler(newTestValidator(t), HandlerOptions{Metrics: metrics})
+	demo := metav1.GroupVersionKind{Group: "demo.io", Version: "v1", Kind: "Demo"}
+
+	for _, obj := range []string{
+		`{"apiVersion": "demo.io/v1", "kind": "Demo", "port": 80}`,
+		`{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http"}`,
+		`{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http", "debug": true}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(admissionRequest(t, admissionv1.Create, demo, obj)))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// Deletes aren't validated, so they aren't counted
+	req := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(admissionRequest(t, admissionv1.Delete, demo, `{}`)))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	output := rec.Body.String()
+
+	assert.Contains(t, output, `miaka_webhook_validations_total{kind="Demo.demo.io",result="allowed"} 1`)
+	assert.Contains(t, output, `miaka_webhook_validations_total{kind="Demo.demo.io",result="denied"} 2`)
+	assert.Contains(t, output, `miaka_webhook_violations_total{field="port",kind="Demo.demo.io",rule="FieldValueTypeInvalid"} 2`)
+	assert.Contains(t, output, `miaka_webhook_violations_total{field="debug",kind="Demo.demo.io",rule="FieldValueForbidden"} 1`)
+	assert.Contains(t, output, `miaka_webhook_validation_duration_seconds_count{kind="Demo.demo.io"} 3`)
+	assert.Contains(t, output, "go_goroutines")
+}
+
+func TestIndexPattern(t *testing.T) {
+	assert.Equal(t, "ports[].name", indexPattern.ReplaceAllString("ports[0].name", "[]"))
+	assert.Equal(t, "labels[]", indexPattern.ReplaceAllString("labels[app.kubernetes.io/name]", "[]"))
+	assert.Equal(t, "env[].value", indexPattern.ReplaceAllString("env.12.value", "[]"))
+	assert.Equal(t, "v1.2x", indexPattern.ReplaceAllString("v1.2x", "[]"))
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadFunc loads a validator from its source files
+type LoadFunc func() (*Validator, error)
+
+// Reloader keeps a validator up to date with the files it's loaded from. Files
+// are polled rather than watched, which also picks up ConfigMap volumes, whose
+// files are replaced by swapping a symlink. Only local files are supported:
+// remote sources such as OCI references must be pulled to files first (e.g.,
+// by an init container running miaka pull).
+type Reloader struct {
+	paths     []string
+	load      LoadFunc
+	validator atomic.Pointer[Validator]
+
+	// loaded and failed are the checksums of the files that last loaded and
+	// last failed to load, used only by Reload
+	loaded map[string][sha256.Size]byte
+	failed map[string][sha256.Size]byte
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewReloader loads a validator and returns a reloader that reloads it when one
+// of paths changes
+func NewReloader(paths []string, load LoadFunc) (*Reloader, error) {
+	for _, path := range paths {
+		if err := checkLocalPath(path); err != nil {
+			return nil, err
+		}
+	}
+	r := &Reloader{paths: paths, load: load}
+	validator, err := load()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := r.checksums()
+	if err != nil {
+		return nil, err
+	}
+	r.validator.Store(validator)
+	r.loaded = sums
+	return r, nil
+}
+
+// Validator returns the current validator
+func (r *Reloader) Validator() *Validator {
+	return r.validator.Load()
+}
+
+// Err returns the error of the last reload, or nil if the current validator
+// matches the files
+func (r *Reloader) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// Reload reloads the validator if the files changed since it was loaded, and
+// reports whether it did. If loading fails, the previous validator is kept and
+// Err returns the error until the files load.
+func (r *Reloader) Reload() (bool, error) {
+	sums, err := r.checksums()
+	if err != nil {
+		r.setErr(err)
+		return false, err
+	}
+	if sameChecksums(sums, r.loaded) {
+		r.setErr(nil)
+		return false, nil
+	}
+	if sameChecksums(sums, r.failed) {
+		// Don't retry (or report again) files that already failed to load
+		return false, nil
+	}
+
+	validator, err := r.load()
+	if err != nil {
+		r.failed = sums
+		r.setErr(err)
+		return false, err
+	}
+	r.validator.Store(validator)
+	r.loaded, r.failed = sums, nil
+	r.setErr(nil)
+	return true, nil
+}
+
+// Run calls Reload every interval until ctx is done. report is called after
+// each reload, with the error if it failed.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration, report func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if reloaded || err != nil {
+				report(err)
+			}
+		}
+	}
+}
+
+// setErr records the result of the last reload
+func (r *Reloader) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// checksums returns the checksum of each file
+func (r *Reloader) checksums() (map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte, len(r.paths))
+	for _, path := range r.paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		sums[path] = sha256.Sum256(data)
+	}
+	return sums, nil
+}
+
+// checkLocalPath rejects the references of remote sources (e.g., oci://),
+// which would otherwise fail to load as missing files
+func checkLocalPath(path string) error {
+	scheme, _, ok := strings.Cut(path, "://")
+	if !ok || scheme == "" || strings.ContainsAny(scheme, `/\.`) {
+		return nil
+	}
+	return fmt.Errorf("%s: only local files can be served and reloaded, not %s:// references (pull them to files first, e.g., with miaka pull in an init container)", path, scheme)
+}
+
+// sameChecksums reports whether two sets of checksums are equal
+func sameChecksums(a, b map[string][sha256.Size]byte) bool {
+	if a == nil || b == nil || len(a) != len(b) {
+		return false
+	}
+	for path, sum := range a {
+		if b[path] != sum {
+			return false
+		}
+	}
+	return true
+}
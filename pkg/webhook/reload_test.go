@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReloader returns a reloader for the minimal testdata CRD and a JSON Schema file
+func newTestReloader(t *testing.T, schemaJSON string) (*Reloader, string) {
+	t.Helper()
+	schemaPath := filepath.Join(t.TempDir(), "values.schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(schemaJSON), 0644))
+
+	reloader, err := NewReloader([]string{schemaPath}, func() (*Validator, error) {
+		data, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, err
+		}
+		v := newTestValidator(t)
+		if err := v.AddJSONSchema(demoKind, data); err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+	require.NoError(t, err)
+	return reloader, schemaPath
+}
+
+// portMessages validates a Demo with the given port using the reloader's current validator
+func portMessages(t *testing.T, reloader *Reloader, port int64) []string {
+	t.Helper()
+	messages, err := reloader.Validator().Validate(demoKind, map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "port": port})
+	require.NoError(t, err)
+	return messages
+}
+
+func TestReloader(t *testing.T) {
+	reloader, schemaPath := newTestReloader(t, `{"properties": {"port": {"maximum": 1024}}}`)
+	assert.Empty(t, portMessages(t, reloader, 443))
+
+	// Unchanged files aren't reloaded
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"properties": {"port": {"maximum": 100}}}`), 0644))
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Len(t, portMessages(t, reloader, 443), 1)
+
+	// A broken file keeps the previous validator
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": 1}`), 0644))
+	reloaded, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Error(t, reloader.Err())
+	assert.Len(t, portMessages(t, reloader, 443), 1)
+
+	// The same broken file isn't retried, but is still reported by Err
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Error(t, reloader.Err())
+
+	// Restoring the loaded file clears the error without reloading
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"properties": {"port": {"maximum": 100}}}`), 0644))
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.NoError(t, reloader.Err())
+
+	// A missing file is an error
+	require.NoError(t, os.Remove(schemaPath))
+	_, err = reloader.Reload()
+	assert.ErrorContains(t, err, "failed to read")
+	assert.Error(t, reloader.Err())
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	_, err := NewReloader(nil, func() (*Validator, error) { return nil, assert.AnError })
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, err = NewReloader([]string{filepath.Join(t.TempDir(), "missing.yaml")}, func() (*Validator, error) { return NewValidator(), nil })
+	assert.ErrorContains(t, err, "failed to read")
+
+	loaded := false
+	_, err = NewReloader([]string{"oci://registry.example.com/schemas/demo:1.0.0"}, func() (*Validator, error) {
+		loaded = true
+		return NewValidator(), nil
+	})
+	assert.ErrorContains(t, err, "only local files can be served and reloaded, not oci:// references")
+	assert.False(t, loaded, "remote sources should be rejected before loading")
+}
+
+func TestReloader_Run(t *testing.T) {
+	reloader, schemaPath := newTestReloader(t, `{"properties": {"port": {"maximum": 1024}}}`)
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"properties": {"port": {"maximum": 100}}}`), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reports := make(chan error, 1)
+	go reloader.Run(ctx, time.Millisecond, func(err error) {
+		reports <- err
+		cancel()
+	})
+
+	select {
+	case err := <-reports:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't reload")
+	}
+	assert.Len(t, portMessages(t, reloader, 443), 1)
+}
+
+func TestReloadingHandler_Readyz(t *testing.T) {
+	reloader, schemaPath := newTestReloader(t, `{}`)
+	handler := NewReloadingHandler(reloader, HandlerOptions{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": 1}`), 0644))
+	_, _ = reloader.Reload()
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "failed to load schemas")
+
+	// Liveness doesn't depend on the schemas
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthzPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Paths served by the webhook handler
+const (
+	ValidatePath = "/validate"
+	HealthzPath  = "/healthz"
+	ReadyzPath   = "/readyz"
+)
+
+// maxRequestBytes limits the size of an AdmissionReview request body
+const maxRequestBytes = 3 * 1024 * 1024
+
+// HandlerOptions configure the optional features of the webhook handler
+type HandlerOptions struct {
+	// Metrics records each validation, if set
+	Metrics *Metrics
+	// AuditLog records each rejected resource, if set
+	AuditLog *AuditLog
+}
+
+// NewHandler returns an HTTP handler serving AdmissionReview requests on
+// ValidatePath and health checks on HealthzPath and ReadyzPath
+func NewHandler(v *Validator, opts HandlerOptions) http.Handler {
+	return newHandler(func() *Validator { return v }, func() error { return nil }, opts)
+}
+
+// NewReloadingHandler is like NewHandler, but validates with the current
+// validator of a reloader. ReadyzPath fails while the last reload failed.
+func NewReloadingHandler(reloader *Reloader, opts HandlerOptions) http.Handler {
+	return newHandler(reloader.Validator, reloader.Err, opts)
+}
+
+// newHandler returns a handler that validates with the validator returned by
+// current, and is ready when ready returns nil
+func newHandler(current func() *Validator, ready func() error, opts HandlerOptions) http.Handler {
+	r := &reviewer{validator: current, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, r.serveValidate)
+	mux.HandleFunc(HealthzPath, serveOK)
+	mux.HandleFunc(ReadyzPath, func(w http.ResponseWriter, req *http.Request) {
+		if err := ready(); err != nil {
+			http.Error(w, fmt.Sprintf("failed to load schemas: %v", err), http.StatusServiceUnavailable)
+			return
+		}
+		serveOK(w, req)
+	})
+	return mux
+}
+
+// reviewer validates admission requests
+type reviewer struct {
+	validator func() *Validator
+	opts      HandlerOptions
+}
+
+// serveOK responds to health checks
+func serveOK(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = io.WriteString(w, "ok")
+}
+
+// serveValidate decodes an AdmissionReview, validates its object, and writes the response
+func (r *reviewer) serveValidate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	body := http.MaxBytesReader(w, req.Body, maxRequestBytes)
+	if err := json.NewDecoder(body).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	response := r.review(review.Request)
+	review.Request = nil
+	review.Response = response
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode AdmissionReview: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Review validates the object of an admission request. Deletes and kinds the
+// validator doesn't handle are allowed.
+func Review(v *Validator, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return (&reviewer{validator: func() *Validator { return v }}).review(request)
+}
+
+// review validates the object of an admission request, recording the result in
+// the metrics and rejections in the audit log
+func (r *reviewer) review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+
+	// Use one validator for the whole request, even if it's reloaded meanwhile
+	validator := r.validator()
+	gk := schema.GroupKind{Group: request.Kind.Group, Kind: request.Kind.Kind}
+	if request.Operation == admissionv1.Delete || !validator.Handles(gk) {
+		return response
+	}
+	start := time.Now()
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(request.Object.Raw, &obj); err != nil {
+		r.opts.Metrics.observe(gk, ResultError, time.Since(start), nil)
+		return deny(response, http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("failed to decode object: %v", err))
+	}
+
+	violations, err := validator.Violations(gk, obj)
+	if err != nil {
+		r.opts.Metrics.observe(gk, ResultError, time.Since(start), nil)
+		return deny(response, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+	}
+	if len(violations) == 0 {
+		r.opts.Metrics.observe(gk, ResultAllowed, time.Since(start), nil)
+		return response
+	}
+
+	r.opts.Metrics.observe(gk, ResultDenied, time.Since(start), violations)
+	if err := r.opts.AuditLog.Record(request, gk, obj, violations); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+	}
+	return deny(response, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid,
+		fmt.Sprintf("%s is invalid: %s", gk.Kind, strings.Join(messages, "; ")))
+}
+
+// deny marks a response as rejected
+func deny(response *admissionv1.AdmissionResponse, code int32, reason metav1.StatusReason, message string) *admissionv1.AdmissionResponse {
+	response.Allowed = false
+	response.Result = &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Reason:  reason,
+		Message: message,
+	}
+	return response
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// admissionRequest builds an AdmissionReview request for an object
+func admissionRequest(t *testing.T, operation admissionv1.Operation, kind metav1.GroupVersionKind, obj string) []byte {
+	t.Helper()
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "1234",
+			Kind:      kind,
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: []byte(obj)},
+		},
+	}
+	data, err := json.Marshal(review)
+	require.NoError(t, err)
+	return data
+}
+
+func TestHandler_Validate(t *testing.T) {
+	handler := NewHandler(newTestValidator(t), HandlerOptions{})
+	demo := metav1.GroupVersionKind{Group: "demo.io", Version: "v1", Kind: "Demo"}
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		kind      metav1.GroupVersionKind
+		obj       string
+		allowed   bool
+		message   string
+	}{
+		{name: "valid", operation: admissionv1.Create, kind: demo, obj: `{"apiVersion": "demo.io/v1", "kind": "Demo", "port": 80}`, allowed: true},
+		{name: "invalid", operation: admissionv1.Update, kind: demo, obj: `{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http", "debug": true}`,
+			message: "Demo is invalid: port: Invalid value"},
+		{name: "delete", operation: admissionv1.Delete, kind: demo, obj: `{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http"}`, allowed: true},
+		{name: "other kind", operation: admissionv1.Create, kind: metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, obj: `{"data": 1}`, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader(admissionRequest(t, tt.operation, tt.kind, tt.obj)))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var review admissionv1.AdmissionReview
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &review))
+			require.NotNil(t, review.Response)
+			assert.Nil(t, review.Request)
+			assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+			assert.Equal(t, "1234", string(review.Response.UID))
+			assert.Equal(t, tt.allowed, review.Response.Allowed)
+			if tt.message != "" {
+				assert.Equal(t, int32(http.StatusUnprocessableEntity), review.Response.Result.Code)
+				assert.Contains(t, review.Response.Result.Message, tt.message)
+				assert.Contains(t, review.Response.Result.Message, "debug: Forbidden: unknown field")
+			}
+		})
+	}
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	handler := NewHandler(newTestValidator(t), HandlerOptions{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ValidatePath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte("not json"))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ValidatePath, bytes.NewReader([]byte(`{"apiVersion": "admission.k8s.io/v1"}`))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Health(t *testing.T) {
+	handler := NewHandler(NewValidator(), HandlerOptions{})
+	for _, path := range []string{HealthzPath, ReadyzPath} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "ok", rec.Body.String(), path)
+	}
+}
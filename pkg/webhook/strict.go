@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// UnknownFields returns an error for each field of a resource that its schema
+// doesn't declare. The API server silently prunes such fields, which hides typos;
+// the webhook rejects them instead. metadata is left to the API server.
+func UnknownFields(obj map[string]interface{}, props *apiextensionsv1.JSONSchemaProps) field.ErrorList {
+	root := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		if key != "metadata" {
+			root[key] = value
+		}
+	}
+	return unknownFields(root, props, nil)
+}
+
+// unknownFields walks a value alongside its schema. A nil path is the root.
+func unknownFields(value interface{}, props *apiextensionsv1.JSONSchemaProps, path *field.Path) field.ErrorList {
+	if props == nil || (props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields) {
+		return nil
+	}
+
+	var errs field.ErrorList
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := path.Child(key)
+			if prop, ok := props.Properties[key]; ok {
+				errs = append(errs, unknownFields(v[key], &prop, child)...)
+				continue
+			}
+			if props.AdditionalProperties != nil {
+				if props.AdditionalProperties.Schema != nil {
+					errs = append(errs, unknownFields(v[key], props.AdditionalProperties.Schema, child)...)
+					continue
+				}
+				if props.AdditionalProperties.Allows {
+					continue
+				}
+			}
+			errs = append(errs, field.Forbidden(child, "unknown field"))
+		}
+	case []interface{}:
+		if props.Items == nil || props.Items.Schema == nil {
+			return nil
+		}
+		for i, item := range v {
+			errs = append(errs, unknownFields(item, props.Items.Schema, path.Index(i))...)
+		}
+	}
+	return errs
+}
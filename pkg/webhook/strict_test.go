@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestUnknownFields(t *testing.T) {
+	props := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"apiVersion": {Type: "string"},
+			"kind":       {Type: "string"},
+			"metadata":   {Type: "object"},
+			"labels": {
+				Type:                 "object",
+				AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+			},
+			"ports": {
+				Type: "array",
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+					Type:       "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{"port": {Type: "integer"}},
+				}},
+			},
+			"raw": {Type: "object", XPreserveUnknownFields: ptr.To(true)},
+		},
+	}
+
+	obj := map[string]interface{}{
+		"apiVersion": "demo.io/v1",
+		"kind":       "Demo",
+		"metadata":   map[string]interface{}{"name": "demo"},
+		"labels":     map[string]interface{}{"app": "demo"},
+		"ports": []interface{}{
+			map[string]interface{}{"port": 80},
+			map[string]interface{}{"port": 443, "protocol": "TCP"},
+		},
+		"raw":     map[string]interface{}{"anything": true},
+		"replica": 3,
+	}
+
+	var messages []string
+	for _, err := range UnknownFields(obj, props) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"ports[1].protocol: Forbidden: unknown field",
+		"replica: Forbidden: unknown field",
+	}, messages)
+}
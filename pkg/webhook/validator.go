@@ -0,0 +1,156 @@
+// Package webhook validates custom resources against generated CRDs and JSON
+// Schemas and serves the results as a Kubernetes ValidatingAdmissionWebhook.
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/crenshaw-dev/miaka/pkg/build/validation"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Validator validates resources of the kinds defined by a set of CRDs
+type Validator struct {
+	crds        map[schema.GroupKind]*apiextensionsv1.CustomResourceDefinition
+	jsonSchemas map[schema.GroupKind][]byte
+}
+
+// NewValidator creates a validator with no kinds
+func NewValidator() *Validator {
+	return &Validator{
+		crds:        make(map[schema.GroupKind]*apiextensionsv1.CustomResourceDefinition),
+		jsonSchemas: make(map[schema.GroupKind][]byte),
+	}
+}
+
+// AddCRD adds the kind defined by a CRD
+func (v *Validator) AddCRD(crd *apiextensionsv1.CustomResourceDefinition) error {
+	if crd.Spec.Group == "" || crd.Spec.Names.Kind == "" {
+		return fmt.Errorf("CRD %q has no group or kind", crd.Name)
+	}
+	gk := schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+	if _, ok := v.crds[gk]; ok {
+		return fmt.Errorf("duplicate CRD for %s", gk)
+	}
+	v.crds[gk] = crd
+	return nil
+}
+
+// AddJSONSchema adds a JSON Schema that resources of a kind must also satisfy.
+// The kind must already have a CRD.
+func (v *Validator) AddJSONSchema(gk schema.GroupKind, schemaJSON []byte) error {
+	if _, ok := v.crds[gk]; !ok {
+		return fmt.Errorf("no CRD for %s", gk)
+	}
+	// Validate an empty object to make sure the schema compiles
+	if err := validation.ValidateValues(map[string]interface{}{}, schemaJSON); err != nil && validation.Violations(err) == nil {
+		return fmt.Errorf("invalid JSON Schema for %s: %w", gk, err)
+	}
+	v.jsonSchemas[gk] = schemaJSON
+	return nil
+}
+
+// Kinds returns the kinds the validator handles, sorted
+func (v *Validator) Kinds() []schema.GroupKind {
+	kinds := make([]schema.GroupKind, 0, len(v.crds))
+	for gk := range v.crds {
+		kinds = append(kinds, gk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	return kinds
+}
+
+// Handles reports whether the validator has a CRD for a kind
+func (v *Validator) Handles(gk schema.GroupKind) bool {
+	_, ok := v.crds[gk]
+	return ok
+}
+
+// Violation is a reason a resource is invalid
+type Violation struct {
+	// Field is the path of the offending field, e.g. "ports[0].name"
+	Field string `json:"field"`
+	// Rule is the check that failed: the field error type for the CRD (e.g.,
+	// "FieldValueInvalid") or "jsonschema:" and the keyword for the JSON Schema
+	// (e.g., "jsonschema:minimum")
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Validate checks a resource against the CRD of its kind, rejects fields the CRD
+// doesn't declare, and checks the JSON Schema of its kind if there is one.
+// Each violation is returned as a message; err is only set if the resource can't be validated.
+func (v *Validator) Validate(gk schema.GroupKind, obj map[string]interface{}) ([]string, error) {
+	violations, err := v.Violations(gk, obj)
+	if err != nil {
+		return nil, err
+	}
+	var messages []string
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+	}
+	return messages, nil
+}
+
+// Violations is like Validate, but returns the violations with the field and rule of each
+func (v *Validator) Violations(gk schema.GroupKind, obj map[string]interface{}) ([]Violation, error) {
+	crd, ok := v.crds[gk]
+	if !ok {
+		return nil, fmt.Errorf("no CRD for %s", gk)
+	}
+
+	errs, err := validation.ValidateResource(crd, obj)
+	if err != nil {
+		return nil, err
+	}
+	if props := versionSchema(crd, obj); props != nil {
+		errs = append(errs, UnknownFields(obj, props)...)
+	}
+
+	var violations []Violation
+	for _, e := range errs {
+		violations = append(violations, Violation{Field: e.Field, Rule: string(e.Type), Message: e.Error()})
+	}
+
+	if schemaJSON, ok := v.jsonSchemas[gk]; ok {
+		if err := validation.ValidateValues(obj, schemaJSON); err != nil {
+			schemaViolations := validation.Violations(err)
+			if schemaViolations == nil {
+				return nil, err
+			}
+			for _, violation := range schemaViolations {
+				path := formatPath(violation.Path)
+				violations = append(violations, Violation{
+					Field:   path,
+					Rule:    "jsonschema:" + violation.Keyword,
+					Message: fmt.Sprintf("%s: %s", path, violation.Message),
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// versionSchema returns the schema of the CRD version matching a resource's apiVersion
+func versionSchema(crd *apiextensionsv1.CustomResourceDefinition, obj map[string]interface{}) *apiextensionsv1.JSONSchemaProps {
+	apiVersion, _ := obj["apiVersion"].(string)
+	for _, version := range crd.Spec.Versions {
+		if crd.Spec.Group+"/"+version.Name == apiVersion && version.Schema != nil {
+			return version.Schema.OpenAPIV3Schema
+		}
+	}
+	return nil
+}
+
+// formatPath renders a JSON Schema instance location as a dotted path
+func formatPath(path []string) string {
+	if len(path) == 0 {
+		return "<root>"
+	}
+	return strings.Join(path, ".")
+}
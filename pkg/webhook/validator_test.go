@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var demoKind = schema.GroupKind{Group: "demo.io", Kind: "Demo"}
+
+// loadTestCRD reads a CRD from the build testdata
+func loadTestCRD(t *testing.T, name string) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "build", name, "expected_crd.yaml"))
+	require.NoError(t, err)
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	require.NoError(t, yaml.Unmarshal(data, crd))
+	return crd
+}
+
+// newTestValidator creates a validator for the minimal testdata CRD
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+	v := NewValidator()
+	require.NoError(t, v.AddCRD(loadTestCRD(t, "minimal")))
+	return v
+}
+
+func TestValidator(t *testing.T) {
+	v := newTestValidator(t)
+	assert.Equal(t, []schema.GroupKind{demoKind}, v.Kinds())
+	assert.True(t, v.Handles(demoKind))
+	assert.False(t, v.Handles(schema.GroupKind{Group: "demo.io", Kind: "Other"}))
+
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		messages []string
+	}{
+		{
+			name: "valid",
+			obj: map[string]interface{}{
+				"apiVersion": "demo.io/v1", "kind": "Demo",
+				"metadata": map[string]interface{}{"name": "demo", "labels": map[string]interface{}{"a": "b"}},
+				"port":     int64(8080),
+			},
+		},
+		{
+			name: "wrong type",
+			obj:  map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "port": "http"},
+			messages: []string{
+				`port: Invalid value: "string": port in body must be of type integer: "string"`,
+			},
+		},
+		{
+			name:     "unknown field",
+			obj:      map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "prot": int64(8080)},
+			messages: []string{"prot: Forbidden: unknown field"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := v.Validate(demoKind, tt.obj)
+			require.NoError(t, err)
+			assert.Equal(t, tt.messages, messages)
+		})
+	}
+
+	_, err := v.Validate(demoKind, map[string]interface{}{"apiVersion": "demo.io/v2", "kind": "Demo"})
+	assert.Error(t, err)
+}
+
+func TestValidator_JSONSchema(t *testing.T) {
+	v := newTestValidator(t)
+	require.NoError(t, v.AddJSONSchema(demoKind, []byte(`{"properties": {"port": {"type": "integer", "maximum": 1024}}}`)))
+
+	messages, err := v.Validate(demoKind, map[string]interface{}{"apiVersion": "demo.io/v1", "kind": "Demo", "port": int64(8080)})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Contains(t, messages[0], "port: ")
+
+	assert.Error(t, v.AddJSONSchema(schema.GroupKind{Group: "other.io", Kind: "Demo"}, []byte(`{}`)))
+	assert.Error(t, v.AddJSONSchema(demoKind, []byte(`{"type": 1}`)))
+}
+
+func TestValidator_AddCRDErrors(t *testing.T) {
+	v := newTestValidator(t)
+	assert.ErrorContains(t, v.AddCRD(loadTestCRD(t, "minimal")), "duplicate CRD")
+	assert.Error(t, v.AddCRD(&apiextensionsv1.CustomResourceDefinition{}))
+}
@@ -0,0 +1,157 @@
+// Package workspace reads the project config of a repository that generates
+// several schemas, each from its own example values.
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/crenshaw-dev/miaka/pkg/history"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the project config that 'miaka build' reads when it's run
+// without an input file
+const DefaultFile = "miaka.yaml"
+
+// Config is a project config
+type Config struct {
+	// Targets are the schemas to generate, built in order
+	Targets []Target `json:"targets"`
+}
+
+// Target is one schema of a project, generated from its example values. Paths
+// are relative to the directory of the config.
+type Target struct {
+	// Name identifies the target in messages (default: Input)
+	Name string `json:"name,omitempty"`
+	// Input is the example values file
+	Input string `json:"input"`
+	// APIVersion and Kind, if set, must be the apiVersion and kind of the input,
+	// which guards against building the wrong file
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	// Plain builds values without apiVersion or kind (like 'miaka build
+	// --plain'), whose types are named TypeName
+	Plain    bool   `json:"plain,omitempty"`
+	TypeName string `json:"typeName,omitempty"`
+	// CRD and Schema are the outputs (default: crd.yaml and values.schema.json
+	// next to the input), and Types and TypeScript the optional Go types and
+	// TypeScript declarations
+	CRD        string `json:"crd,omitempty"`
+	Schema     string `json:"schema,omitempty"`
+	Types      string `json:"types,omitempty"`
+	TypeScript string `json:"typescript,omitempty"`
+	// ResourceSchema is the optional JSON Schema of the custom resource (like
+	// 'miaka build --resource-schema')
+	ResourceSchema string `json:"resourceSchema,omitempty"`
+	// History is the directory of release snapshots of the CRD (default:
+	// .miaka/history next to the input)
+	History string `json:"history,omitempty"`
+	// Charts are the Helm charts whose values the schema describes, which Argo CD
+	// Applications and Flux HelmReleases refer to: chart names in Helm
+	// repositories, or paths of charts in Git repositories (default: the
+	// directory of a relative input)
+	Charts []string `json:"charts,omitempty"`
+}
+
+// Load reads a project config from fsys, resolving the paths of its targets
+// against its directory and filling in their defaults
+func Load(fsys filesystem.FS, path string) (*Config, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config: %w", err)
+	}
+	config, err := Parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid project config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse parses a project config in dir
+func Parse(data []byte, dir string) (*Config, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("no targets")
+	}
+
+	names := map[string]bool{}
+	outputs := map[string]string{}
+	for i := range config.Targets {
+		target := &config.Targets[i]
+		if target.Input == "" {
+			return nil, fmt.Errorf("target %d has no input", i+1)
+		}
+		if target.Name == "" {
+			target.Name = target.Input
+		}
+		if names[target.Name] {
+			return nil, fmt.Errorf("two targets are named %s", target.Name)
+		}
+		names[target.Name] = true
+		if target.Plain && (target.APIVersion != "" || target.Kind != "") {
+			return nil, fmt.Errorf("target %s: plain values have no apiVersion or kind", target.Name)
+		}
+		if target.Plain && target.ResourceSchema != "" {
+			return nil, fmt.Errorf("target %s: plain values have no resource form for resourceSchema", target.Name)
+		}
+		if !target.Plain && target.TypeName != "" {
+			return nil, fmt.Errorf("target %s: typeName requires plain", target.Name)
+		}
+
+		inputDir := filepath.Dir(target.Input)
+		if len(target.Charts) == 0 && !filepath.IsAbs(target.Input) {
+			target.Charts = []string{filepath.ToSlash(inputDir)}
+		}
+		target.Input = resolve(dir, target.Input, "")
+		target.CRD = resolve(dir, target.CRD, filepath.Join(inputDir, "crd.yaml"))
+		target.Schema = resolve(dir, target.Schema, filepath.Join(inputDir, "values.schema.json"))
+		target.Types = resolve(dir, target.Types, "")
+		target.TypeScript = resolve(dir, target.TypeScript, "")
+		target.ResourceSchema = resolve(dir, target.ResourceSchema, "")
+		target.History = resolve(dir, target.History, filepath.Join(inputDir, history.DefaultDir))
+
+		// Targets that share an output would overwrite each other's
+		for _, output := range []string{target.CRD, target.History, target.Schema, target.Types, target.TypeScript, target.ResourceSchema} {
+			if output == "" || (target.Plain && (output == target.CRD || output == target.History)) {
+				continue
+			}
+			if other, ok := outputs[output]; ok {
+				return nil, fmt.Errorf("targets %s and %s both write %s", other, target.Name, output)
+			}
+			outputs[output] = target.Name
+		}
+	}
+	return config, nil
+}
+
+// ForChart returns the target whose schema describes the values of a Helm chart:
+// chart is its name in a Helm repository, or chartPath its path in a Git repository
+func (c *Config) ForChart(chart, chartPath string) (*Target, bool) {
+	for i := range c.Targets {
+		for _, name := range c.Targets[i].Charts {
+			if (chart != "" && name == chart) || (chartPath != "" && path.Clean(name) == path.Clean(chartPath)) {
+				return &c.Targets[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// resolve returns path, or def if path is empty, relative to dir. An empty
+// def stays empty.
+func resolve(dir, path, def string) string {
+	if path == "" {
+		path = def
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,122 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/crenshaw-dev/miaka/pkg/filesystem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	config, err := Parse([]byte(`targets:
+  - input: api/example.values.yaml
+    apiVersion: example.com/v1
+    kind: API
+    types: api/v1/types.go
+    resourceSchema: api/resource.schema.json
+  - name: chart
+    input: /abs/values.yaml
+    plain: true
+    typeName: ChartValues
+    schema: out/values.schema.json
+`), "repo")
+	require.NoError(t, err)
+	assert.Equal(t, []Target{
+		{
+			Name:           "api/example.values.yaml",
+			Input:          filepath.Join("repo", "api", "example.values.yaml"),
+			APIVersion:     "example.com/v1",
+			Kind:           "API",
+			CRD:            filepath.Join("repo", "api", "crd.yaml"),
+			Schema:         filepath.Join("repo", "api", "values.schema.json"),
+			Types:          filepath.Join("repo", "api", "v1", "types.go"),
+			History:        filepath.Join("repo", "api", ".miaka", "history"),
+			Charts:         []string{"api"},
+			ResourceSchema: filepath.Join("repo", "api", "resource.schema.json"),
+		},
+		{
+			Name:     "chart",
+			Input:    "/abs/values.yaml",
+			Plain:    true,
+			TypeName: "ChartValues",
+			CRD:      filepath.Join("/abs", "crd.yaml"),
+			Schema:   filepath.Join("repo", "out", "values.schema.json"),
+			History:  filepath.Join("/abs", ".miaka", "history"),
+		},
+	}, config.Targets)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"no targets", "targets: []\n", "no targets"},
+		{"unknown field", "targets:\n  - input: a.yaml\n    output: b.yaml\n", `unknown field "output"`},
+		{"no input", "targets:\n  - name: api\n", "target 1 has no input"},
+		{"duplicate name", "targets:\n  - {name: api, input: a/values.yaml}\n  - {name: api, input: b/values.yaml}\n", "two targets are named api"},
+		{"plain with kind", "targets:\n  - {input: values.yaml, plain: true, kind: App}\n", "target values.yaml: plain values have no apiVersion or kind"},
+		{"plain with resource schema", "targets:\n  - {input: values.yaml, plain: true, resourceSchema: r.json}\n", "target values.yaml: plain values have no resource form"},
+		{"type name without plain", "targets:\n  - {input: values.yaml, typeName: Values}\n", "target values.yaml: typeName requires plain"},
+		{"shared output", "targets:\n  - input: a.yaml\n  - input: b.yaml\n", "targets a.yaml and b.yaml both write crd.yaml"},
+		{"shared schema", "targets:\n  - {input: a/values.yaml, schema: values.schema.json}\n  - {input: values.yaml, plain: true}\n", "targets a/values.yaml and values.yaml both write values.schema.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config), ".")
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	// Plain targets don't write a CRD, so they can share a directory with a CRD target
+	_, err := Parse([]byte("targets:\n  - input: example.values.yaml\n  - {input: values.yaml, plain: true, schema: plain.schema.json}\n"), ".")
+	assert.NoError(t, err)
+}
+
+func TestConfig_ForChart(t *testing.T) {
+	config, err := Parse([]byte(`targets:
+  - input: charts/api/example.values.yaml
+  - name: worker
+    input: worker/example.values.yaml
+    charts: [worker, charts/worker]
+`), ".")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		chart, path string
+		want        string
+	}{
+		{"", "charts/api", "charts/api/example.values.yaml"},
+		{"", "./charts/api/", "charts/api/example.values.yaml"},
+		{"worker", "", "worker"},
+		{"", "charts/worker", "worker"},
+	} {
+		target, ok := config.ForChart(tt.chart, tt.path)
+		if assert.True(t, ok, tt) {
+			assert.Equal(t, tt.want, target.Name)
+		}
+	}
+	// The default chart is only the input's directory
+	_, ok := config.ForChart("", "charts")
+	assert.False(t, ok)
+	_, ok = config.ForChart("api", "")
+	assert.False(t, ok)
+}
+
+func TestLoad(t *testing.T) {
+	fsys := filesystem.NewMemory(map[string][]byte{"repo/miaka.yaml": []byte("targets:\n  - input: example.values.yaml\n")})
+	config, err := Load(fsys, "repo/miaka.yaml")
+	require.NoError(t, err)
+	require.Len(t, config.Targets, 1)
+	assert.Equal(t, filepath.Join("repo", "example.values.yaml"), config.Targets[0].Input)
+
+	_, err = Load(fsys, "repo/missing.yaml")
+	assert.ErrorContains(t, err, "failed to read project config")
+
+	fsys = filesystem.NewMemory(map[string][]byte{"miaka.yaml": []byte("targets: []\n")})
+	_, err = Load(fsys, "miaka.yaml")
+	assert.EqualError(t, err, "invalid project config miaka.yaml: no targets")
+}
@@ -11,20 +11,20 @@ type EventsChart struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	// -- Provide a name in place of `argo-events`
+	// Provide a name in place of `argo-events`
 	NameOverride string `json:"nameOverride,omitempty"`
 
-	// -- String to fully override "argo-events.fullname" template
+	// String to fully override "argo-events.fullname" template
 	FullnameOverride string `json:"fullnameOverride,omitempty"`
 
-	// -- Override the namespace
-	// @default -- `.Release.Namespace`
+	// Override the namespace
+	// Default: `.Release.Namespace`
 	NamespaceOverride string `json:"namespaceOverride,omitempty"`
 
-	// -- Deploy on OpenShift
+	// Deploy on OpenShift
 	Openshift bool `json:"openshift,omitempty"`
 
-	// -- Create clusterroles that extend existing clusterroles to interact with argo-events crds
+	// Create clusterroles that extend existing clusterroles to interact with argo-events crds
 	// Only applies for cluster-wide installation (`controller.rbac.namespaced: false`)
 	// # Ref: https://kubernetes.io/docs/reference/access-authn-authz/rbac/#aggregated-clusterroles
 	CreateAggregateRoles bool `json:"createAggregateRoles,omitempty"`
@@ -45,26 +45,26 @@ type EventsChart struct {
 
 // CrdsConfig defines the crds configuration
 type CrdsConfig struct {
-	// -- Install and upgrade CRDs
+	// Install and upgrade CRDs
 	Install bool `json:"install,omitempty"`
 
-	// -- Keep CRDs on chart uninstall
+	// Keep CRDs on chart uninstall
 	Keep bool `json:"keep,omitempty"`
 
-	// -- Annotations to be added to all CRDs
+	// Annotations to be added to all CRDs
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
-// ImageConfig defines the image configuration
-type ImageConfig struct {
-	// -- If defined, a repository applied to all Argo Events deployments
+// GlobalConfigImageConfig defines the global config image configuration
+type GlobalConfigImageConfig struct {
+	// If defined, a repository applied to all Argo Events deployments
 	Repository string `json:"repository,omitempty"`
 
-	// -- Overrides the global Argo Events image tag whose default is the chart appVersion
+	// Overrides the global Argo Events image tag whose default is the chart appVersion
 	Tag string `json:"tag,omitempty"`
 
-	// -- If defined, a imagePullPolicy applied to all Argo Events deployments
+	// If defined, a imagePullPolicy applied to all Argo Events deployments
 	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
 }
 
@@ -89,32 +89,33 @@ type HostAliasesConfig struct {
 
 // GlobalConfig defines the global configuration
 type GlobalConfig struct {
-	Image ImageConfig `json:"image,omitempty"`
+	Image GlobalConfigImageConfig `json:"image,omitempty"`
 
-	// -- If defined, uses a Secret to pull an image from a private Docker registry or repository
+	// If defined, uses a Secret to pull an image from a private Docker registry or repository
 	ImagePullSecrets []ImagePullSecretsConfig `json:"imagePullSecrets,omitempty"`
 
-	// -- Annotations for the all deployed pods
+	// Annotations for the all deployed pods
 	// +miaka:type: map[string]string
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 
-	// -- Labels for the all deployed pods
+	// Labels for the all deployed pods
 	// +miaka:type: map[string]string
 	PodLabels map[string]string `json:"podLabels,omitempty"`
 
-	// -- Additional labels to add to all resources
+	// Additional labels to add to all resources
 	// +miaka:type: map[string]string
+	// app: argo-events
 	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
 
-	// -- Toggle and define securityContext. See [values.yaml]
+	// Toggle and define securityContext. See [values.yaml]
 	SecurityContext SecurityContextConfig `json:"securityContext,omitempty"`
 
-	// -- Mapping between IP and hostnames that will be injected as entries in the pod's hosts files
+	// Mapping between IP and hostnames that will be injected as entries in the pod's hosts files
 	HostAliases []HostAliasesConfig `json:"hostAliases,omitempty"`
 }
 
-// VersionsConfig defines the versions configuration
-type VersionsConfig struct {
+// NatsConfigVersionsConfig defines the nats config versions configuration
+type NatsConfigVersionsConfig struct {
 	Version              string `json:"version,omitempty"`
 	NatsStreamingImage   string `json:"natsStreamingImage,omitempty"`
 	MetricsExporterImage string `json:"metricsExporterImage,omitempty"`
@@ -122,46 +123,46 @@ type VersionsConfig struct {
 
 // NatsConfig defines the nats configuration
 type NatsConfig struct {
-	// -- Supported versions of NATS event bus
-	// @default -- See [values.yaml]
-	Versions []VersionsConfig `json:"versions,omitempty"`
+	// Supported versions of NATS event bus
+	// Default: See [values.yaml]
+	Versions []NatsConfigVersionsConfig `json:"versions,omitempty"`
 }
 
 // SettingsConfig defines the settings configuration
 type SettingsConfig struct {
-	// -- Maximum size of the memory storage (e.g. 1G)
+	// Maximum size of the memory storage (e.g. 1G)
 	MaxMemoryStore int `json:"maxMemoryStore,omitempty"`
 
-	// -- Maximum size of the file storage (e.g. 20G)
+	// Maximum size of the file storage (e.g. 20G)
 	MaxFileStore int `json:"maxFileStore,omitempty"`
 }
 
 // StreamConfig defines the stream configuration
 type StreamConfig struct {
-	// -- Maximum number of messages before expiring oldest message
+	// Maximum number of messages before expiring oldest message
 	MaxMsgs int `json:"maxMsgs,omitempty"`
 
-	// -- Maximum age of existing messages, i.e. “72h”, “4h35m”
+	// Maximum age of existing messages, i.e. “72h”, “4h35m”
 	MaxAge string `json:"maxAge,omitempty"`
 
 	// Total size of messages before expiring oldest message, 0 means unlimited.
 	MaxBytes string `json:"maxBytes,omitempty"`
 
-	// -- Number of replicas, defaults to 3 and requires minimal 3
+	// Number of replicas, defaults to 3 and requires minimal 3
 	Replicas int `json:"replicas,omitempty"`
 
-	// -- Not documented at the moment
+	// Not documented at the moment
 	Duplicates string `json:"duplicates,omitempty"`
 
-	// -- 0: Limits, 1: Interest, 2: WorkQueue
+	// 0: Limits, 1: Interest, 2: WorkQueue
 	Retention int `json:"retention,omitempty"`
 
-	// -- 0: DiscardOld, 1: DiscardNew
+	// 0: DiscardOld, 1: DiscardNew
 	Discard int `json:"discard,omitempty"`
 }
 
-// JetstreamConfigVersionsConfig defines the jetstream config versions configuration
-type JetstreamConfigVersionsConfig struct {
+// VersionsConfig defines the versions configuration
+type VersionsConfig struct {
 	Version              string `json:"version,omitempty"`
 	NatsImage            string `json:"natsImage,omitempty"`
 	MetricsExporterImage string `json:"metricsExporterImage,omitempty"`
@@ -177,7 +178,7 @@ type JetstreamConfig struct {
 	StreamConfig StreamConfig   `json:"streamConfig,omitempty"`
 
 	// Supported versions of JetStream eventbus
-	Versions []JetstreamConfigVersionsConfig `json:"versions,omitempty"`
+	Versions []VersionsConfig `json:"versions,omitempty"`
 }
 
 // ConfigsConfig defines the configs configuration
@@ -198,46 +199,46 @@ type RulesConfig struct {
 
 // RbacConfig defines the rbac configuration
 type RbacConfig struct {
-	// -- Create events controller RBAC
+	// Create events controller RBAC
 	Enabled bool `json:"enabled,omitempty"`
 
-	// -- Restrict events controller to operate only in a single namespace instead of cluster-wide scope.
+	// Restrict events controller to operate only in a single namespace instead of cluster-wide scope.
 	Namespaced bool `json:"namespaced,omitempty"`
 
-	// -- Additional namespace to be monitored by the controller
+	// Additional namespace to be monitored by the controller
 	ManagedNamespace string `json:"managedNamespace,omitempty"`
 
-	// -- Additional user rules for event controller's rbac
+	// Additional user rules for event controller's rbac
 	Rules []RulesConfig `json:"rules,omitempty"`
 }
 
-// ControllerConfigImageConfig defines the controller config image configuration
-type ControllerConfigImageConfig struct {
-	// -- Repository to use for the events controller
-	// @default -- `""` (defaults to global.image.repository)
+// ImageConfig defines the image configuration
+type ImageConfig struct {
+	// Repository to use for the events controller
+	// Default: `""` (defaults to global.image.repository)
 	Repository string `json:"repository,omitempty"`
 
-	// -- Tag to use for the events controller
-	// @default -- `""` (defaults to global.image.tag)
+	// Tag to use for the events controller
+	// Default: `""` (defaults to global.image.tag)
 	Tag string `json:"tag,omitempty"`
 
-	// -- Image pull policy for the events controller
-	// @default -- `""` (defaults to global.image.imagePullPolicy)
+	// Image pull policy for the events controller
+	// Default: `""` (defaults to global.image.imagePullPolicy)
 	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
 }
 
 // PdbConfig defines the pdb configuration
 type PdbConfig struct {
-	// -- Deploy a PodDisruptionBudget for the events controller
+	// Deploy a PodDisruptionBudget for the events controller
 	Enabled bool `json:"enabled,omitempty"`
 
 	// minAvailable: 1
 	// maxUnavailable: 0
-	// -- Labels to be added to events controller pdb
+	// Labels to be added to events controller pdb
 	// +miaka:type: map[string]string
 	Labels map[string]string `json:"labels,omitempty"`
 
-	// -- Annotations to be added to events controller pdb
+	// Annotations to be added to events controller pdb
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 }
@@ -278,37 +279,37 @@ type ContainerSecurityContextConfig struct {
 
 // ReadinessProbeConfig defines the readiness probe configuration
 type ReadinessProbeConfig struct {
-	// -- Minimum consecutive failures for the [probe] to be considered failed after having succeeded
+	// Minimum consecutive failures for the [probe] to be considered failed after having succeeded
 	FailureThreshold int `json:"failureThreshold,omitempty"`
 
-	// -- Number of seconds after the container has started before [probe] is initiated
+	// Number of seconds after the container has started before [probe] is initiated
 	InitialDelaySeconds int `json:"initialDelaySeconds,omitempty"`
 
-	// -- How often (in seconds) to perform the [probe]
+	// How often (in seconds) to perform the [probe]
 	PeriodSeconds int `json:"periodSeconds,omitempty"`
 
-	// -- Minimum consecutive successes for the [probe] to be considered successful after having failed
+	// Minimum consecutive successes for the [probe] to be considered successful after having failed
 	SuccessThreshold int `json:"successThreshold,omitempty"`
 
-	// -- Number of seconds after which the [probe] times out
+	// Number of seconds after which the [probe] times out
 	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // LivenessProbeConfig defines the liveness probe configuration
 type LivenessProbeConfig struct {
-	// -- Minimum consecutive failures for the [probe] to be considered failed after having succeeded
+	// Minimum consecutive failures for the [probe] to be considered failed after having succeeded
 	FailureThreshold int `json:"failureThreshold,omitempty"`
 
-	// -- Number of seconds after the container has started before [probe] is initiated
+	// Number of seconds after the container has started before [probe] is initiated
 	InitialDelaySeconds int `json:"initialDelaySeconds,omitempty"`
 
-	// -- How often (in seconds) to perform the [probe]
+	// How often (in seconds) to perform the [probe]
 	PeriodSeconds int `json:"periodSeconds,omitempty"`
 
-	// -- Minimum consecutive successes for the [probe] to be considered successful after having failed
+	// Minimum consecutive successes for the [probe] to be considered successful after having failed
 	SuccessThreshold int `json:"successThreshold,omitempty"`
 
-	// -- Number of seconds after which the [probe] times out
+	// Number of seconds after which the [probe] times out
 	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
@@ -328,11 +329,6 @@ type VolumeMountsConfig struct {
 	MountPath string `json:"mountPath,omitempty"`
 }
 
-// NodeSelectorConfig defines the node selector configuration
-type NodeSelectorConfig struct {
-	KubernetesIoArch string `json:"kubernetes.io/arch,omitempty"`
-}
-
 // TolerationsConfig defines the tolerations configuration
 type TolerationsConfig struct {
 	Key      string `json:"key,omitempty"`
@@ -408,32 +404,32 @@ type ResourcesConfig struct {
 
 // ServiceAccountConfig defines the service account configuration
 type ServiceAccountConfig struct {
-	// -- Create a service account for the events controller
+	// Create a service account for the events controller
 	Create bool `json:"create,omitempty"`
 
-	// -- Service account name
+	// Service account name
 	Name string `json:"name,omitempty"`
 
-	// -- Annotations applied to created service account
+	// Annotations applied to created service account
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 
-	// -- Automount API credentials for the Service Account
+	// Automount API credentials for the Service Account
 	AutomountServiceAccountToken bool `json:"automountServiceAccountToken,omitempty"`
 }
 
 // ServiceConfig defines the service configuration
 type ServiceConfig struct {
-	// -- Metrics service annotations
+	// Metrics service annotations
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 
 	// +miaka:type: map[string]string
-	// -- Metrics service labels
+	// Metrics service labels
 	// +miaka:type: map[string]string
 	Labels map[string]string `json:"labels,omitempty"`
 
-	// -- Metrics service port
+	// Metrics service port
 	ServicePort int `json:"servicePort,omitempty"`
 }
 
@@ -461,33 +457,34 @@ type SelectorConfig struct {
 
 // ServiceMonitorConfig defines the service monitor configuration
 type ServiceMonitorConfig struct {
-	// -- Enable a prometheus ServiceMonitor
+	// Enable a prometheus ServiceMonitor
 	Enabled bool `json:"enabled,omitempty"`
 
-	// -- Prometheus ServiceMonitor interval
+	// Prometheus ServiceMonitor interval
 	Interval string `json:"interval,omitempty"`
 
-	// -- Prometheus [RelabelConfigs] to apply to samples before scraping
+	// Prometheus [RelabelConfigs] to apply to samples before scraping
 	Relabelings []RelabelingsConfig `json:"relabelings,omitempty"`
 
-	// -- Prometheus [MetricRelabelConfigs] to apply to samples before ingestion
+	// Prometheus [MetricRelabelConfigs] to apply to samples before ingestion
 	MetricRelabelings []MetricRelabelingsConfig `json:"metricRelabelings,omitempty"`
 
-	// -- Prometheus ServiceMonitor selector
+	// Prometheus ServiceMonitor selector
 	Selector SelectorConfig `json:"selector,omitempty"`
 
 	// prometheus: kube-prometheus
-	// -- Prometheus ServiceMonitor namespace
+	// Prometheus ServiceMonitor namespace
+	// "monitoring"
 	Namespace string `json:"namespace,omitempty"`
 
-	// -- Prometheus ServiceMonitor labels
+	// Prometheus ServiceMonitor labels
 	// +miaka:type: map[string]string
 	AdditionalLabels map[string]string `json:"additionalLabels,omitempty"`
 }
 
 // MetricsConfig defines the metrics configuration
 type MetricsConfig struct {
-	// -- Deploy metrics service
+	// Deploy metrics service
 	Enabled        bool                 `json:"enabled,omitempty"`
 	Service        ServiceConfig        `json:"service,omitempty"`
 	ServiceMonitor ServiceMonitorConfig `json:"serviceMonitor,omitempty"`
@@ -495,36 +492,36 @@ type MetricsConfig struct {
 
 // ControllerConfig defines the controller configuration
 type ControllerConfig struct {
-	// -- Argo Events controller name string
-	Name  string                      `json:"name,omitempty"`
-	Rbac  RbacConfig                  `json:"rbac,omitempty"`
-	Image ControllerConfigImageConfig `json:"image,omitempty"`
+	// Argo Events controller name string
+	Name  string      `json:"name,omitempty"`
+	Rbac  RbacConfig  `json:"rbac,omitempty"`
+	Image ImageConfig `json:"image,omitempty"`
 
-	// -- The number of replicasets history to keep
+	// The number of replicasets history to keep
 	RevisionHistoryLimit int `json:"revisionHistoryLimit,omitempty"`
 
-	// -- The number of events controller pods to run.
+	// The number of events controller pods to run.
 	Replicas int `json:"replicas,omitempty"`
 
 	// Pod disruption budget
 	Pdb PdbConfig `json:"pdb,omitempty"`
 
-	// -- Environment variables to pass to events controller
+	// Environment variables to pass to events controller
 	Env []EnvConfig `json:"env,omitempty"`
 
-	// -- envFrom to pass to events controller
-	// @default -- `[]` (See [values.yaml])
+	// envFrom to pass to events controller
+	// Default: `[]` (See [values.yaml])
 	EnvFrom []EnvFromConfig `json:"envFrom,omitempty"`
 
-	// -- Annotations to be added to events controller pods
+	// Annotations to be added to events controller pods
 	// +miaka:type: map[string]string
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 
-	// -- Labels to be added to events controller pods
+	// Labels to be added to events controller pods
 	// +miaka:type: map[string]string
 	PodLabels map[string]string `json:"podLabels,omitempty"`
 
-	// -- Events controller container-level security context
+	// Events controller container-level security context
 	ContainerSecurityContext ContainerSecurityContextConfig `json:"containerSecurityContext,omitempty"`
 
 	// # Readiness and liveness probes for default backend
@@ -532,30 +529,30 @@ type ControllerConfig struct {
 	ReadinessProbe ReadinessProbeConfig `json:"readinessProbe,omitempty"`
 	LivenessProbe  LivenessProbeConfig  `json:"livenessProbe,omitempty"`
 
-	// -- Additional volumes to the events controller pod
+	// Additional volumes to the events controller pod
 	Volumes []VolumesConfig `json:"volumes,omitempty"`
 
-	// -- Additional volumeMounts to the events controller main container
+	// Additional volumeMounts to the events controller main container
 	VolumeMounts []VolumeMountsConfig `json:"volumeMounts,omitempty"`
 
-	// -- [Node selector]
-	NodeSelector NodeSelectorConfig `json:"nodeSelector,omitempty"`
+	// [Node selector]
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
-	// -- [Tolerations] for use with node taints
+	// [Tolerations] for use with node taints
 	Tolerations []TolerationsConfig `json:"tolerations,omitempty"`
 
-	// -- Assign custom [affinity] rules to the deployment
+	// Assign custom [affinity] rules to the deployment
 	Affinity AffinityConfig `json:"affinity,omitempty"`
 
-	// -- Assign custom [TopologySpreadConstraints] rules to the events controller
+	// Assign custom [TopologySpreadConstraints] rules to the events controller
 	// # Ref: https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
 	// # If labelSelector is left out, it will default to the labelSelector configuration of the deployment
 	TopologySpreadConstraints []TopologySpreadConstraintsConfig `json:"topologySpreadConstraints,omitempty"`
 
-	// -- Priority class for the events controller pods
+	// Priority class for the events controller pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
-	// -- Resource limits and requests for the events controller pods
+	// Resource limits and requests for the events controller pods
 	Resources      ResourcesConfig      `json:"resources,omitempty"`
 	ServiceAccount ServiceAccountConfig `json:"serviceAccount,omitempty"`
 
@@ -565,31 +562,31 @@ type ControllerConfig struct {
 
 // WebhookConfigImageConfig defines the webhook config image configuration
 type WebhookConfigImageConfig struct {
-	// -- Repository to use for the event controller
-	// @default -- `""` (defaults to global.image.repository)
+	// Repository to use for the event controller
+	// Default: `""` (defaults to global.image.repository)
 	Repository string `json:"repository,omitempty"`
 
-	// -- Tag to use for the event controller
-	// @default -- `""` (defaults to global.image.tag)
+	// Tag to use for the event controller
+	// Default: `""` (defaults to global.image.tag)
 	Tag string `json:"tag,omitempty"`
 
-	// -- Image pull policy for the event controller
-	// @default -- `""` (defaults to global.image.imagePullPolicy)
+	// Image pull policy for the event controller
+	// Default: `""` (defaults to global.image.imagePullPolicy)
 	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
 }
 
 // WebhookConfigPdbConfig defines the webhook config pdb configuration
 type WebhookConfigPdbConfig struct {
-	// -- Deploy a PodDisruptionBudget for the admission webhook
+	// Deploy a PodDisruptionBudget for the admission webhook
 	Enabled bool `json:"enabled,omitempty"`
 
 	// minAvailable: 1
 	// maxUnavailable: 0
-	// -- Labels to be added to admission webhook pdb
+	// Labels to be added to admission webhook pdb
 	// +miaka:type: map[string]string
 	Labels map[string]string `json:"labels,omitempty"`
 
-	// -- Annotations to be added to admission webhook pdb
+	// Annotations to be added to admission webhook pdb
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 }
@@ -630,37 +627,37 @@ type WebhookConfigContainerSecurityContextConfig struct {
 
 // WebhookConfigReadinessProbeConfig defines the webhook config readiness probe configuration
 type WebhookConfigReadinessProbeConfig struct {
-	// -- Minimum consecutive failures for the [probe] to be considered failed after having succeeded
+	// Minimum consecutive failures for the [probe] to be considered failed after having succeeded
 	FailureThreshold int `json:"failureThreshold,omitempty"`
 
-	// -- Number of seconds after the container has started before [probe] is initiated
+	// Number of seconds after the container has started before [probe] is initiated
 	InitialDelaySeconds int `json:"initialDelaySeconds,omitempty"`
 
-	// -- How often (in seconds) to perform the [probe]
+	// How often (in seconds) to perform the [probe]
 	PeriodSeconds int `json:"periodSeconds,omitempty"`
 
-	// -- Minimum consecutive successes for the [probe] to be considered successful after having failed
+	// Minimum consecutive successes for the [probe] to be considered successful after having failed
 	SuccessThreshold int `json:"successThreshold,omitempty"`
 
-	// -- Number of seconds after which the [probe] times out
+	// Number of seconds after which the [probe] times out
 	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // WebhookConfigLivenessProbeConfig defines the webhook config liveness probe configuration
 type WebhookConfigLivenessProbeConfig struct {
-	// -- Minimum consecutive failures for the [probe] to be considered failed after having succeeded
+	// Minimum consecutive failures for the [probe] to be considered failed after having succeeded
 	FailureThreshold int `json:"failureThreshold,omitempty"`
 
-	// -- Number of seconds after the container has started before [probe] is initiated
+	// Number of seconds after the container has started before [probe] is initiated
 	InitialDelaySeconds int `json:"initialDelaySeconds,omitempty"`
 
-	// -- How often (in seconds) to perform the [probe]
+	// How often (in seconds) to perform the [probe]
 	PeriodSeconds int `json:"periodSeconds,omitempty"`
 
-	// -- Minimum consecutive successes for the [probe] to be considered successful after having failed
+	// Minimum consecutive successes for the [probe] to be considered successful after having failed
 	SuccessThreshold int `json:"successThreshold,omitempty"`
 
-	// -- Number of seconds after which the [probe] times out
+	// Number of seconds after which the [probe] times out
 	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
@@ -680,11 +677,6 @@ type WebhookConfigVolumesConfig struct {
 	EmptyDir WebhookConfigVolumesConfigEmptyDirConfig `json:"emptyDir,omitempty"`
 }
 
-// WebhookConfigNodeSelectorConfig defines the webhook config node selector configuration
-type WebhookConfigNodeSelectorConfig struct {
-	KubernetesIoArch string `json:"kubernetes.io/arch,omitempty"`
-}
-
 // WebhookConfigTolerationsConfig defines the webhook config tolerations configuration
 type WebhookConfigTolerationsConfig struct {
 	Key      string `json:"key,omitempty"`
@@ -760,58 +752,58 @@ type WebhookConfigResourcesConfig struct {
 
 // WebhookConfigServiceAccountConfig defines the webhook config service account configuration
 type WebhookConfigServiceAccountConfig struct {
-	// -- Create a service account for the admission webhook
+	// Create a service account for the admission webhook
 	Create bool `json:"create,omitempty"`
 
-	// -- Service account name
+	// Service account name
 	Name string `json:"name,omitempty"`
 
-	// -- Annotations applied to created service account
+	// Annotations applied to created service account
 	// +miaka:type: map[string]string
 	Annotations map[string]string `json:"annotations,omitempty"`
 
-	// -- Automount API credentials for the Service Account
+	// Automount API credentials for the Service Account
 	AutomountServiceAccountToken bool `json:"automountServiceAccountToken,omitempty"`
 }
 
 // WebhookConfig defines the webhook configuration
 type WebhookConfig struct {
-	// -- Enable admission webhook. Applies only for cluster-wide installation
+	// Enable admission webhook. Applies only for cluster-wide installation
 	Enabled bool `json:"enabled,omitempty"`
 
-	// -- Argo Events admission webhook name string
+	// Argo Events admission webhook name string
 	Name  string                   `json:"name,omitempty"`
 	Image WebhookConfigImageConfig `json:"image,omitempty"`
 
-	// -- The number of replicasets history to keep
+	// The number of replicasets history to keep
 	RevisionHistoryLimit int `json:"revisionHistoryLimit,omitempty"`
 
-	// -- The number of webhook pods to run.
+	// The number of webhook pods to run.
 	Replicas int `json:"replicas,omitempty"`
 
 	// Pod disruption budget
 	Pdb WebhookConfigPdbConfig `json:"pdb,omitempty"`
 
-	// -- Environment variables to pass to event controller
-	// @default -- `[]` (See [values.yaml])
+	// Environment variables to pass to event controller
+	// Default: `[]` (See [values.yaml])
 	Env []WebhookConfigEnvConfig `json:"env,omitempty"`
 
-	// -- envFrom to pass to event controller
-	// @default -- `[]` (See [values.yaml])
+	// envFrom to pass to event controller
+	// Default: `[]` (See [values.yaml])
 	EnvFrom []WebhookConfigEnvFromConfig `json:"envFrom,omitempty"`
 
-	// -- Annotations to be added to event controller pods
+	// Annotations to be added to event controller pods
 	// +miaka:type: map[string]string
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 
-	// -- Labels to be added to event controller pods
+	// Labels to be added to event controller pods
 	// +miaka:type: map[string]string
 	PodLabels map[string]string `json:"podLabels,omitempty"`
 
-	// -- Port to listen on
+	// Port to listen on
 	Port int `json:"port,omitempty"`
 
-	// -- Event controller container-level security context
+	// Event controller container-level security context
 	ContainerSecurityContext WebhookConfigContainerSecurityContextConfig `json:"containerSecurityContext,omitempty"`
 
 	// # Readiness and liveness probes for default backend
@@ -819,30 +811,30 @@ type WebhookConfig struct {
 	ReadinessProbe WebhookConfigReadinessProbeConfig `json:"readinessProbe,omitempty"`
 	LivenessProbe  WebhookConfigLivenessProbeConfig  `json:"livenessProbe,omitempty"`
 
-	// -- Additional volumeMounts to the event controller main container
+	// Additional volumeMounts to the event controller main container
 	VolumeMounts []WebhookConfigVolumeMountsConfig `json:"volumeMounts,omitempty"`
 
-	// -- Additional volumes to the event controller pod
+	// Additional volumes to the event controller pod
 	Volumes []WebhookConfigVolumesConfig `json:"volumes,omitempty"`
 
-	// -- [Node selector]
-	NodeSelector WebhookConfigNodeSelectorConfig `json:"nodeSelector,omitempty"`
+	// [Node selector]
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
-	// -- [Tolerations] for use with node taints
+	// [Tolerations] for use with node taints
 	Tolerations []WebhookConfigTolerationsConfig `json:"tolerations,omitempty"`
 
-	// -- Assign custom [affinity] rules to the deployment
+	// Assign custom [affinity] rules to the deployment
 	Affinity WebhookConfigAffinityConfig `json:"affinity,omitempty"`
 
-	// -- Assign custom [TopologySpreadConstraints] rules to the event controller
+	// Assign custom [TopologySpreadConstraints] rules to the event controller
 	// # Ref: https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
 	// # If labelSelector is left out, it will default to the labelSelector configuration of the deployment
 	TopologySpreadConstraints []WebhookConfigTopologySpreadConstraintsConfig `json:"topologySpreadConstraints,omitempty"`
 
-	// -- Priority class for the event controller pods
+	// Priority class for the event controller pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
-	// -- Resource limits and requests for the event controller pods
+	// Resource limits and requests for the event controller pods
 	Resources      WebhookConfigResourcesConfig      `json:"resources,omitempty"`
 	ServiceAccount WebhookConfigServiceAccountConfig `json:"serviceAccount,omitempty"`
 }
@@ -23,6 +23,8 @@ type MyApp struct {
 	Replicas int `json:"replicas,omitempty"`
 
 	// Enable debug mode for verbose logging
+	//
+	// Deprecated: set the LOG_LEVEL environment variable to debug instead
 	Debug bool `json:"debug,omitempty"`
 
 	// # Image configuration
@@ -38,6 +40,8 @@ type MyApp struct {
 	Resources ResourcesConfig `json:"resources,omitempty"`
 
 	// # Environment variables
+	// +listType=map
+	// +listMapKey=name
 	Env []EnvConfig `json:"env,omitempty"`
 
 	// # Environment variables from ConfigMaps/Secrets
@@ -47,6 +51,7 @@ type MyApp struct {
 	SecurityContext SecurityContextConfig `json:"securityContext,omitempty"`
 
 	// # Liveness probe configuration
+	// +kubebuilder:validation:XValidation:rule="!has(self.timeoutSeconds) || !has(self.periodSeconds) || self.timeoutSeconds < self.periodSeconds",message="timeoutSeconds must be less than periodSeconds"
 	LivenessProbe LivenessProbeConfig `json:"livenessProbe,omitempty"`
 
 	// # Readiness probe configuration
@@ -60,7 +65,7 @@ type MyApp struct {
 
 	// # Node selector for pod assignment
 	// +miaka:type: map[string]string
-	NodeSelector NodeSelectorConfig `json:"nodeSelector,omitempty"`
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
 	// # Tolerations for pod assignment
 	Tolerations []TolerationsConfig `json:"tolerations,omitempty"`
@@ -70,11 +75,11 @@ type MyApp struct {
 
 	// # Pod annotations
 	// +miaka:type: map[string]string
-	PodAnnotations PodAnnotationsConfig `json:"podAnnotations,omitempty"`
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 
 	// # Pod labels
 	// +miaka:type: map[string]string
-	PodLabels PodLabelsConfig `json:"podLabels,omitempty"`
+	PodLabels map[string]string `json:"podLabels,omitempty"`
 
 	// # ServiceAccount configuration
 	ServiceAccount ServiceAccountConfig `json:"serviceAccount,omitempty"`
@@ -95,6 +100,7 @@ type MyApp struct {
 // ImageConfig defines the image configuration
 type ImageConfig struct {
 	// Container image repository
+	// +kubebuilder:validation:Pattern=`^([a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?/)?[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(@[a-zA-Z][a-zA-Z0-9]*([-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?$`
 	Repository string `json:"repository,omitempty"`
 
 	// Image pull policy
@@ -106,16 +112,6 @@ type ImageConfig struct {
 	Tag string `json:"tag,omitempty"`
 }
 
-// AnnotationsConfig defines the annotations configuration
-type AnnotationsConfig struct {
-	ServiceBetaKubernetesIoAwsLoadBalancerType string `json:"service.beta.kubernetes.io/aws-load-balancer-type,omitempty"`
-}
-
-// LabelsConfig defines the labels configuration
-type LabelsConfig struct {
-	Monitoring string `json:"monitoring,omitempty"`
-}
-
 // ServiceConfig defines the service configuration
 type ServiceConfig struct {
 	// Kubernetes service type
@@ -129,16 +125,11 @@ type ServiceConfig struct {
 
 	// Service annotations (e.g., for cloud load balancers)
 	// +miaka:type: map[string]string
-	Annotations AnnotationsConfig `json:"annotations,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 
 	// Service labels
 	// +miaka:type: map[string]string
-	Labels LabelsConfig `json:"labels,omitempty"`
-}
-
-// IngressConfigAnnotationsConfig defines the ingress config annotations configuration
-type IngressConfigAnnotationsConfig struct {
-	CertManagerIoClusterIssuer string `json:"cert-manager.io/cluster-issuer,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // PathsConfig defines the paths configuration
@@ -179,7 +170,7 @@ type IngressConfig struct {
 
 	// Ingress annotations
 	// +miaka:type: map[string]string
-	Annotations IngressConfigAnnotationsConfig `json:"annotations,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 
 	// Ingress hosts configuration
 	Hosts []HostsConfig `json:"hosts,omitempty"`
@@ -219,6 +210,7 @@ type ResourcesConfig struct {
 type EnvConfig struct {
 	// Variable name
 	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Required
 	Name string `json:"name,omitempty"`
 
 	// Variable value
@@ -374,12 +366,6 @@ type VolumesConfig struct {
 	ConfigMap ConfigMapConfig `json:"configMap,omitempty"`
 }
 
-// NodeSelectorConfig defines the node selector configuration
-type NodeSelectorConfig struct {
-	KubernetesIoArch string `json:"kubernetes.io/arch,omitempty"`
-	NodeRole         string `json:"node-role,omitempty"`
-}
-
 // TolerationsConfig defines the tolerations configuration
 type TolerationsConfig struct {
 	// Toleration key
@@ -435,24 +421,6 @@ type AffinityConfig struct {
 	NodeAffinity NodeAffinityConfig `json:"nodeAffinity,omitempty"`
 }
 
-// PodAnnotationsConfig defines the pod annotations configuration
-type PodAnnotationsConfig struct {
-	PrometheusIoScrape string `json:"prometheus.io/scrape,omitempty"`
-	PrometheusIoPort   string `json:"prometheus.io/port,omitempty"`
-	PrometheusIoPath   string `json:"prometheus.io/path,omitempty"`
-}
-
-// PodLabelsConfig defines the pod labels configuration
-type PodLabelsConfig struct {
-	App     string `json:"app,omitempty"`
-	Version string `json:"version,omitempty"`
-}
-
-// ServiceAccountConfigAnnotationsConfig defines the service account config annotations configuration
-type ServiceAccountConfigAnnotationsConfig struct {
-	EksAmazonawsComRoleArn string `json:"eks.amazonaws.com/role-arn,omitempty"`
-}
-
 // ServiceAccountConfig defines the service account configuration
 type ServiceAccountConfig struct {
 	// Create a service account
@@ -463,7 +431,7 @@ type ServiceAccountConfig struct {
 
 	// Service account annotations
 	// +miaka:type: map[string]string
-	Annotations ServiceAccountConfigAnnotationsConfig `json:"annotations,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // AutoscalingConfig defines the autoscaling configuration
@@ -490,11 +458,6 @@ type AutoscalingConfig struct {
 	TargetMemoryUtilizationPercentage int `json:"targetMemoryUtilizationPercentage,omitempty"`
 }
 
-// ServiceMonitorConfigLabelsConfig defines the service monitor config labels configuration
-type ServiceMonitorConfigLabelsConfig struct {
-	Prometheus string `json:"prometheus,omitempty"`
-}
-
 // ServiceMonitorConfig defines the service monitor configuration
 type ServiceMonitorConfig struct {
 	// Enable ServiceMonitor resource
@@ -505,7 +468,7 @@ type ServiceMonitorConfig struct {
 
 	// ServiceMonitor labels
 	// +miaka:type: map[string]string
-	Labels ServiceMonitorConfigLabelsConfig `json:"labels,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // MonitoringConfig defines the monitoring configuration
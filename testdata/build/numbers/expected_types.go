@@ -0,0 +1,40 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+//
+// Metrics is the Schema for the metricss API
+type Metrics struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Port number
+	Port int `json:"port,omitempty"`
+
+	// Maximum size of the cache in bytes
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+
+	// Fraction of requests to sample
+	// +kubebuilder:validation:Format=double
+	SampleRate float64 `json:"sampleRate,omitempty"`
+
+	// Weights of the backends
+	// +kubebuilder:validation:items:Format=double
+	Weights []float64 `json:"weights,omitempty"`
+
+	// Number of retries
+	Retries int32 `json:"retries,omitempty"`
+
+	// Timeout in milliseconds
+	TimeoutMillis int64 `json:"timeoutMillis,omitempty"`
+
+	// Scale factor
+	// +kubebuilder:validation:Format=float
+	Scale float32 `json:"scale,omitempty"`
+
+	// Thresholds of the alerts
+	Thresholds []int64 `json:"thresholds,omitempty"`
+}
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +kubebuilder:object:root=true
+//
+// Semantic is the Schema for the semantics API
+type Semantic struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Compute resources for the server
+	Resources ResourcesConfig `json:"resources,omitempty"`
+
+	// How long to wait for the server to start
+	// +miaka:type: duration
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern=`^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+	StartupTimeout metav1.Duration `json:"startupTimeout,omitempty"`
+
+	// Intervals between retries
+	// +miaka:type: []duration
+	// +kubebuilder:validation:items:Type=string
+	// +kubebuilder:validation:items:Pattern=`^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+	RetryBackoff []metav1.Duration `json:"retryBackoff,omitempty"`
+
+	// Port the service targets, by number or name
+	// +miaka:type: int-or-string
+	TargetPort intstr.IntOrString `json:"targetPort,omitempty"`
+
+	// Maximum pods over the desired count during a rollout
+	// +miaka:type: int-or-string
+	MaxSurge intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// Size of the cache volume, if any
+	// +miaka:type: quantity
+	// +nullable
+	CacheSize *resource.Quantity `json:"cacheSize,omitempty"`
+}
+
+// ResourcesConfig defines the resources configuration
+type ResourcesConfig struct {
+	// CPU limit
+	// +miaka:type: quantity
+	Cpu resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory limit
+	// +miaka:type: quantity
+	Memory resource.Quantity `json:"memory,omitempty"`
+}